@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	auditOutcomeOK    = "ok"
+	auditOutcomeError = "error"
+	auditClientStdio  = "stdio"
+)
+
+// auditRecord is one line of the audit log.
+type auditRecord struct {
+	Time   string `json:"time"`
+	Client string `json:"client"`
+	// ClientName and ClientVersion come from the client's hello request.
+	ClientName    string `json:"clientName,omitempty"`
+	ClientVersion string `json:"clientVersion,omitempty"`
+	Request       string `json:"request"`
+	TerminalID    string `json:"terminalId,omitempty"`
+	Shell         string `json:"shell,omitempty"`
+	Cwd           string `json:"cwd,omitempty"`
+	Outcome       string `json:"outcome"`
+	Code          string `json:"code,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// auditLog appends JSON lines recording security-relevant requests. A nil
+// log records nothing.
+type auditLog struct {
+	mu     sync.Mutex
+	writer io.Writer
+	now    func() time.Time
+}
+
+// openAuditLog opens path for appending, creating it readable only by the
+// current user.
+func openAuditLog(path string) (*auditLog, io.Closer, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newAuditLog(file), file, nil
+}
+
+func newAuditLog(writer io.Writer) *auditLog {
+	return &auditLog{writer: writer, now: time.Now}
+}
+
+// Record stamps rec with the current time and err's outcome and appends it.
+func (l *auditLog) Record(rec auditRecord, err error) {
+	if l == nil {
+		return
+	}
+
+	rec.Outcome = auditOutcomeOK
+	if err != nil {
+		serr := sidecarErrorFrom(err, errorCodeUnknown)
+		rec.Outcome = auditOutcomeError
+		rec.Code = serr.Code
+		rec.Message = serr.Message
+	}
+	if rec.Client == "" {
+		rec.Client = auditClientStdio
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rec.Time = l.now().UTC().Format(time.RFC3339Nano)
+	encoded, marshalErr := json.Marshal(rec)
+	if marshalErr != nil {
+		return
+	}
+	_, _ = l.writer.Write(append(encoded, '\n'))
+}
+
+func (s *sidecar) audit(rec auditRecord, err error) {
+	rec.Client = s.cfg.Peer
+	if client := s.currentClient(); client != nil {
+		rec.ClientName = client.Name
+		rec.ClientVersion = client.Version
+	}
+	s.cfg.Audit.Record(rec, err)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+func decodeAuditRecords(t *testing.T, buf *syncBuffer) []auditRecord {
+	t.Helper()
+
+	records := make([]auditRecord, 0)
+	decoder := json.NewDecoder(buf.Snapshot())
+	for {
+		var rec auditRecord
+		if err := decoder.Decode(&rec); err == io.EOF {
+			return records
+		} else if err != nil {
+			t.Fatalf("decode audit record: %v", err)
+		}
+		records = append(records, rec)
+	}
+}
+
+func TestSidecarAuditsOpenAndClose(t *testing.T) {
+	logged := &syncBuffer{}
+	audit := newAuditLog(logged)
+	audit.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	ts := newTestSidecar(t, runConfig{Audit: audit, Peer: "10.0.0.2:5000"})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Shell: "pwsh", Cwd: t.TempDir()})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Shell: "missing"})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+
+	records := decodeAuditRecords(t, logged)
+	if len(records) != 4 {
+		t.Fatalf("expected 4 audit records, got %#v", records)
+	}
+
+	opened := records[0]
+	if opened.Request != requestTypeOpen || opened.Outcome != auditOutcomeOK || opened.Shell != "pwsh" || opened.Cwd == "" {
+		t.Fatalf("unexpected open record: %#v", opened)
+	}
+	if opened.Client != "10.0.0.2:5000" || opened.Time != "2024-01-02T03:04:05Z" {
+		t.Fatalf("open record should carry client and time: %#v", opened)
+	}
+	if failed := records[1]; failed.Outcome != auditOutcomeError || failed.Code != errorCodeShellNotFound {
+		t.Fatalf("unexpected failed open record: %#v", failed)
+	}
+	if closed := records[2]; closed.Request != requestTypeClose || closed.Outcome != auditOutcomeOK {
+		t.Fatalf("unexpected close record: %#v", closed)
+	}
+	if missing := records[3]; missing.Outcome != auditOutcomeError || missing.Code != errorCodeTerminalNotFound {
+		t.Fatalf("unexpected missing close record: %#v", missing)
+	}
+}
+
+func TestAuditLogDefaultsClientToStdio(t *testing.T) {
+	var logged bytes.Buffer
+	newAuditLog(&logged).Record(auditRecord{Request: requestTypeShutdown}, nil)
+
+	var rec auditRecord
+	if err := json.Unmarshal(logged.Bytes(), &rec); err != nil {
+		t.Fatalf("decode audit record: %v", err)
+	}
+	if rec.Client != auditClientStdio || rec.Outcome != auditOutcomeOK {
+		t.Fatalf("unexpected record: %#v", rec)
+	}
+}
+
+func TestServeListenerAuditsRejectedClients(t *testing.T) {
+	logged := &syncBuffer{}
+	addr, _ := startTestListenerWithConfig(t, "secret", runConfig{Audit: newAuditLog(logged)})
+
+	_, reader := dialTestListener(t, addr, `{"type":"auth","token":"wrong"}`)
+	readTestEvent(t, reader)
+
+	records := decodeAuditRecords(t, logged)
+	if len(records) != 1 || records[0].Request != requestTypeAuth || records[0].Code != errorCodeUnauthorized || records[0].Client == "" {
+		t.Fatalf("unexpected auth audit records: %#v", records)
+	}
+}
+
+func TestSidecarAuditNamesHelloClient(t *testing.T) {
+	logged := &syncBuffer{}
+	ts := newTestSidecar(t, runConfig{Audit: newAuditLog(logged)})
+
+	ts.handleRequest(clientHelloRequest{Type: requestTypeHello, Name: "hub", Version: "2.1.0"})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+
+	records := decodeAuditRecords(t, logged)
+	if len(records) != 2 || records[0].Request != requestTypeHello {
+		t.Fatalf("expected the hello to be audited, got %#v", records)
+	}
+	if closed := records[1]; closed.ClientName != "hub" || closed.ClientVersion != "2.1.0" {
+		t.Fatalf("later records should name the client, got %#v", closed)
+	}
+}
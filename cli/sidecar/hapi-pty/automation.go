@@ -0,0 +1,178 @@
+package main
+
+import (
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWaitTimeout   = 30 * time.Second
+	maxWaitTimeout       = 10 * time.Minute
+	maxWaitBufferBytes   = 64 * 1024
+	waitContextRuneLimit = 200
+)
+
+// outputWaiters tracks pending wait requests for one terminal. Each waiter
+// scans only output produced after it was registered.
+type outputWaiters struct {
+	terminalID string
+	emit       func(payload any)
+
+	mu      sync.Mutex
+	waiters []*outputWaiter
+}
+
+type outputWaiter struct {
+	requestID string
+	pattern   *regexp.Regexp
+	buffer    []byte
+	timer     *time.Timer
+}
+
+func compileWaitPattern(req waitRequest) (*regexp.Regexp, error) {
+	if req.Pattern == "" {
+		return nil, newSidecarError(errorCodeInvalidRequest, "wait request requires pattern")
+	}
+	return compileTextPattern(req.Pattern, req.Regex, req.IgnoreCase)
+}
+
+// compileTextPattern builds the matcher shared by wait and search requests.
+func compileTextPattern(pattern string, isRegex bool, ignoreCase bool) (*regexp.Regexp, error) {
+	expr := pattern
+	if !isRegex {
+		expr = regexp.QuoteMeta(expr)
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, newSidecarError(errorCodeInvalidRequest, "invalid pattern: %v", err)
+	}
+	return compiled, nil
+}
+
+func waitTimeout(req waitRequest) time.Duration {
+	if req.TimeoutMs <= 0 {
+		return defaultWaitTimeout
+	}
+	return min(time.Duration(req.TimeoutMs)*time.Millisecond, maxWaitTimeout)
+}
+
+func (w *outputWaiters) Add(req waitRequest) error {
+	pattern, err := compileWaitPattern(req)
+	if err != nil {
+		return err
+	}
+
+	waiter := &outputWaiter{
+		requestID: req.RequestID,
+		pattern:   pattern,
+	}
+
+	w.mu.Lock()
+	w.waiters = append(w.waiters, waiter)
+	w.mu.Unlock()
+
+	timeout := waitTimeout(req)
+	waiter.timer = time.AfterFunc(timeout, func() {
+		if !w.remove(waiter) {
+			return
+		}
+		w.emit(errorEvent{
+			Type:       eventTypeError,
+			TerminalID: w.terminalID,
+			RequestID:  waiter.requestID,
+			Code:       errorCodeWaitTimeout,
+			Message:    "pattern not matched within " + timeout.String(),
+		})
+	})
+	return nil
+}
+
+func (w *outputWaiters) remove(target *outputWaiter) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for idx, waiter := range w.waiters {
+		if waiter == target {
+			w.waiters = append(w.waiters[:idx], w.waiters[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Feed appends output to every pending waiter and emits matched events.
+func (w *outputWaiters) Feed(chunk []byte) {
+	w.mu.Lock()
+	if len(w.waiters) == 0 {
+		w.mu.Unlock()
+		return
+	}
+
+	matched := make([]matchedEvent, 0)
+	remaining := w.waiters[:0]
+	for _, waiter := range w.waiters {
+		waiter.buffer = append(waiter.buffer, chunk...)
+		if overflow := len(waiter.buffer) - maxWaitBufferBytes; overflow > 0 {
+			waiter.buffer = append([]byte(nil), waiter.buffer[overflow:]...)
+		}
+
+		text := stripANSI(waiter.buffer)
+		loc := waiter.pattern.FindIndex(text)
+		if loc == nil {
+			remaining = append(remaining, waiter)
+			continue
+		}
+
+		waiter.timer.Stop()
+		matched = append(matched, matchedEvent{
+			Type:       eventTypeMatched,
+			TerminalID: w.terminalID,
+			RequestID:  waiter.requestID,
+			Match:      toValidUTF8(text[loc[0]:loc[1]]),
+			Context:    matchContext(text, loc[0], loc[1]),
+		})
+	}
+	w.waiters = remaining
+	w.mu.Unlock()
+
+	for _, evt := range matched {
+		w.emit(evt)
+	}
+}
+
+// Cancel fails all pending waiters, used when the terminal goes away.
+func (w *outputWaiters) Cancel(reason string) {
+	w.mu.Lock()
+	waiters := w.waiters
+	w.waiters = nil
+	w.mu.Unlock()
+
+	for _, waiter := range waiters {
+		waiter.timer.Stop()
+		w.emit(errorEvent{
+			Type:       eventTypeError,
+			TerminalID: w.terminalID,
+			RequestID:  waiter.requestID,
+			Code:       errorCodeWaitCancelled,
+			Message:    reason,
+		})
+	}
+}
+
+// matchContext returns the lines surrounding a match, bounded in length.
+func matchContext(text []byte, start int, end int) string {
+	from := start
+	for from > 0 && text[from-1] != '\n' && start-from < waitContextRuneLimit {
+		from--
+	}
+	to := end
+	for to < len(text) && text[to] != '\n' && text[to] != '\r' && to-end < waitContextRuneLimit {
+		to++
+	}
+	return toValidUTF8(text[from:to])
+}
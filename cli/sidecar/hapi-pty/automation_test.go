@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []any
+	signal chan struct{}
+}
+
+func newRecordedEvents() *recordedEvents {
+	return &recordedEvents{signal: make(chan struct{}, 16)}
+}
+
+func (r *recordedEvents) emit(payload any) {
+	r.mu.Lock()
+	r.events = append(r.events, payload)
+	r.mu.Unlock()
+	r.signal <- struct{}{}
+}
+
+func (r *recordedEvents) all() []any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]any(nil), r.events...)
+}
+
+func TestOutputWaitersMatchLiteralAcrossChunksIgnoringANSI(t *testing.T) {
+	recorder := newRecordedEvents()
+	waiters := &outputWaiters{terminalID: "t1", emit: recorder.emit}
+
+	if err := waiters.Add(waitRequest{RequestID: "r1", Pattern: "login:"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	waiters.Feed([]byte("Welcome\r\nhost log"))
+	waiters.Feed([]byte("\x1b[1min:\x1b[0m "))
+
+	events := recorder.all()
+	if len(events) != 1 {
+		t.Fatalf("expected one matched event, got %#v", events)
+	}
+	evt, ok := events[0].(matchedEvent)
+	if !ok {
+		t.Fatalf("unexpected event type %T", events[0])
+	}
+	if evt.RequestID != "r1" || evt.Match != "login:" || evt.Context != "host login: " {
+		t.Fatalf("unexpected matched event: %+v", evt)
+	}
+}
+
+func TestOutputWaitersRegexIgnoreCase(t *testing.T) {
+	recorder := newRecordedEvents()
+	waiters := &outputWaiters{terminalID: "t1", emit: recorder.emit}
+
+	if err := waiters.Add(waitRequest{Pattern: `ps [a-z]:\\>`, Regex: true, IgnoreCase: true}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	waiters.Feed([]byte("PS C:\\> "))
+
+	events := recorder.all()
+	if len(events) != 1 || events[0].(matchedEvent).Match != `PS C:\>` {
+		t.Fatalf("unexpected events: %#v", events)
+	}
+}
+
+func TestOutputWaitersTimeout(t *testing.T) {
+	recorder := newRecordedEvents()
+	waiters := &outputWaiters{terminalID: "t1", emit: recorder.emit}
+
+	if err := waiters.Add(waitRequest{RequestID: "r1", Pattern: "never", TimeoutMs: 20}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	select {
+	case <-recorder.signal:
+	case <-time.After(time.Second):
+		t.Fatal("wait did not time out")
+	}
+
+	evt, ok := recorder.all()[0].(errorEvent)
+	if !ok || evt.Code != errorCodeWaitTimeout || evt.RequestID != "r1" {
+		t.Fatalf("unexpected timeout event: %#v", recorder.all()[0])
+	}
+
+	waiters.Feed([]byte("never"))
+	if len(recorder.all()) != 1 {
+		t.Fatal("timed out waiter should not match later output")
+	}
+}
+
+func TestOutputWaitersRejectInvalidPattern(t *testing.T) {
+	waiters := &outputWaiters{terminalID: "t1", emit: func(any) {}}
+
+	if err := waiters.Add(waitRequest{Pattern: "(", Regex: true}); err == nil {
+		t.Fatal("expected invalid regex error")
+	}
+	if err := waiters.Add(waitRequest{}); err == nil {
+		t.Fatal("expected missing pattern error")
+	}
+}
+
+func TestSidecarWaitCancelledOnClose(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(waitRequest{Type: requestTypeWait, TerminalID: "t1", RequestID: "r1", Pattern: "$ "})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeWaitCancelled || errors[0]["requestId"] != "r1" {
+		t.Fatalf("expected wait_cancelled error, got %#v", errors)
+	}
+}
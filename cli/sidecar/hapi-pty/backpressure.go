@@ -0,0 +1,219 @@
+package main
+
+import "sync"
+
+const (
+	backpressureBlock  = "block"
+	backpressureBuffer = "buffer"
+	backpressureDrop   = "drop"
+	backpressurePause  = "pause"
+
+	defaultBackpressureBufferBytes = 8 * 1024 * 1024
+	defaultBackpressurePauseBytes  = 256 * 1024
+	maxBackpressureBufferBytes     = 256 * 1024 * 1024
+
+	backpressureStatePaused   = "paused"
+	backpressureStateDropping = "dropping"
+	backpressureStateResumed  = "resumed"
+)
+
+type pumpItem struct {
+	payload any
+	size    int
+}
+
+// outputPump decouples a terminal's output reader from stdout. With the
+// default "block" policy events are written inline, so a host that stops
+// draining stdout stalls the reader exactly as before. The other policies
+// queue events for a dedicated writer goroutine and, once the queue holds
+// limit bytes, either pause the reader ("buffer", "pause") or discard output
+// ("drop") until the queue drains below half the limit. Entering and leaving
+// that state is reported as a backpressure event queued in order with the
+// output it affects, so a "dropping" event marks where output was lost.
+type outputPump struct {
+	terminalID string
+	emit       func(payload any)
+	policy     string
+	limit      int
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	queue        []pumpItem
+	queuedBytes  int
+	droppedBytes int
+	state        string
+	closed       bool
+	done         chan struct{}
+}
+
+func newOutputPump(req openRequest, emit func(payload any)) (*outputPump, error) {
+	policy := req.Backpressure
+	if policy == "" {
+		policy = backpressureBlock
+	}
+	limit, err := backpressureLimit(policy, req.BackpressureBufferBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &outputPump{
+		terminalID: req.TerminalID,
+		emit:       emit,
+		policy:     policy,
+		limit:      limit,
+		done:       make(chan struct{}),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	if policy == backpressureBlock {
+		close(p.done)
+	}
+	return p, nil
+}
+
+// backpressureLimit validates policy and returns its queue limit in bytes.
+func backpressureLimit(policy string, bufferBytes int) (int, error) {
+	if bufferBytes < 0 || bufferBytes > maxBackpressureBufferBytes {
+		return 0, newSidecarError(errorCodeInvalidRequest, "backpressureBufferBytes must be between 0 and %d", maxBackpressureBufferBytes)
+	}
+
+	switch policy {
+	case backpressureBlock:
+		return 0, nil
+	case backpressureBuffer, backpressureDrop:
+		if bufferBytes == 0 {
+			return defaultBackpressureBufferBytes, nil
+		}
+	case backpressurePause:
+		if bufferBytes == 0 {
+			return defaultBackpressurePauseBytes, nil
+		}
+	default:
+		return 0, newSidecarError(errorCodeInvalidRequest, "unsupported backpressure policy %q", policy)
+	}
+	return bufferBytes, nil
+}
+
+// Push queues an output event, applying the policy when the queue is full.
+func (p *outputPump) Push(evt outputEvent) {
+	if p.policy == backpressureBlock {
+		p.emit(evt)
+		return
+	}
+
+	size := len(evt.Data)
+	p.mu.Lock()
+	for !p.closed && (p.state != "" || p.queuedBytes > 0 && p.queuedBytes+size > p.limit) {
+		if p.policy == backpressureDrop {
+			if p.state == "" {
+				p.state = backpressureStateDropping
+				p.enqueueLocked(p.stateEvent(), 0)
+			}
+			p.droppedBytes += size
+			p.mu.Unlock()
+			return
+		}
+
+		if p.state == "" {
+			p.state = backpressureStatePaused
+			p.enqueueLocked(p.stateEvent(), 0)
+		}
+		p.cond.Wait()
+	}
+	if !p.closed {
+		p.enqueueLocked(evt, size)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+
+	// Output that races the exit callback is written after the drained queue.
+	<-p.done
+	p.emit(evt)
+}
+
+// Send queues a non-output event in order with output; it is never dropped.
+func (p *outputPump) Send(payload any) {
+	if p.policy == backpressureBlock {
+		p.emit(payload)
+		return
+	}
+
+	p.mu.Lock()
+	if !p.closed {
+		p.enqueueLocked(payload, 0)
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+
+	<-p.done
+	p.emit(payload)
+}
+
+func (p *outputPump) enqueueLocked(payload any, size int) {
+	p.queue = append(p.queue, pumpItem{payload: payload, size: size})
+	p.queuedBytes += size
+	p.cond.Broadcast()
+}
+
+func (p *outputPump) stateEvent() backpressureEvent {
+	return backpressureEvent{
+		Type:         eventTypeBackpressure,
+		TerminalID:   p.terminalID,
+		State:        p.state,
+		QueuedBytes:  p.queuedBytes,
+		DroppedBytes: p.droppedBytes,
+	}
+}
+
+// Run writes queued events until Close is called and the queue is drained.
+// QueuedBytes reports output waiting for the host to catch up.
+func (p *outputPump) QueuedBytes() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.queuedBytes
+}
+
+func (p *outputPump) Run() {
+	if p.policy == backpressureBlock {
+		return
+	}
+	defer close(p.done)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		for len(p.queue) == 0 {
+			if p.closed {
+				return
+			}
+			p.cond.Wait()
+		}
+
+		item := p.queue[0]
+		p.queue[0] = pumpItem{}
+		p.queue = p.queue[1:]
+
+		p.mu.Unlock()
+		p.emit(item.payload)
+		p.mu.Lock()
+
+		p.queuedBytes -= item.size
+		if p.state != "" && p.queuedBytes <= p.limit/2 {
+			p.state = backpressureStateResumed
+			p.enqueueLocked(p.stateEvent(), 0)
+			p.state = ""
+			p.droppedBytes = 0
+		}
+		p.cond.Broadcast()
+	}
+}
+
+// Close stops accepting events and waits for the queue to drain.
+func (p *outputPump) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+	<-p.done
+}
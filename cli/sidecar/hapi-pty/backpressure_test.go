@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gatedEmitter records payloads and blocks each emit until released, like a
+// host that stopped draining stdout.
+type gatedEmitter struct {
+	mu       sync.Mutex
+	payloads []any
+	gate     chan struct{}
+}
+
+func newGatedEmitter() *gatedEmitter {
+	return &gatedEmitter{gate: make(chan struct{})}
+}
+
+func (g *gatedEmitter) Emit(payload any) {
+	<-g.gate
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.payloads = append(g.payloads, payload)
+}
+
+func (g *gatedEmitter) Payloads() []any {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]any(nil), g.payloads...)
+}
+
+func outputOf(data string) outputEvent {
+	return outputEvent{Type: eventTypeOutput, TerminalID: "t1", Data: data}
+}
+
+func startPump(t *testing.T, req openRequest, emit func(any)) *outputPump {
+	t.Helper()
+
+	req.TerminalID = "t1"
+	pump, err := newOutputPump(req, emit)
+	if err != nil {
+		t.Fatalf("newOutputPump failed: %v", err)
+	}
+	go pump.Run()
+	return pump
+}
+
+func TestOutputPumpDropsWithMarkersWhileHostIsBlocked(t *testing.T) {
+	emitter := newGatedEmitter()
+	pump := startPump(t, openRequest{Backpressure: backpressureDrop, BackpressureBufferBytes: 10}, emitter.Emit)
+
+	pump.Push(outputOf("aaaaaaaa"))
+	pump.Push(outputOf("bbbbbbbb"))
+	pump.Push(outputOf("cccc"))
+	close(emitter.gate)
+	pump.Close()
+
+	payloads := emitter.Payloads()
+	if len(payloads) != 3 {
+		t.Fatalf("unexpected payloads: %+v", payloads)
+	}
+	if evt, ok := payloads[0].(outputEvent); !ok || evt.Data != "aaaaaaaa" {
+		t.Fatalf("expected first output, got %+v", payloads[0])
+	}
+	if evt, ok := payloads[1].(backpressureEvent); !ok || evt.State != backpressureStateDropping {
+		t.Fatalf("expected dropping marker, got %+v", payloads[1])
+	}
+	resumed, ok := payloads[2].(backpressureEvent)
+	if !ok || resumed.State != backpressureStateResumed || resumed.DroppedBytes != 12 {
+		t.Fatalf("expected resumed event with 12 dropped bytes, got %+v", payloads[2])
+	}
+}
+
+func TestOutputPumpPausesReaderUntilHostDrains(t *testing.T) {
+	emitter := newGatedEmitter()
+	pump := startPump(t, openRequest{Backpressure: backpressurePause, BackpressureBufferBytes: 10}, emitter.Emit)
+
+	pump.Push(outputOf("aaaaaaaa"))
+	pushed := make(chan struct{})
+	go func() {
+		pump.Push(outputOf("bbbbbbbb"))
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("expected push to block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(emitter.gate)
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("push did not resume after host drained")
+	}
+	pump.Close()
+
+	var states []string
+	var output []string
+	for _, payload := range emitter.Payloads() {
+		switch evt := payload.(type) {
+		case backpressureEvent:
+			states = append(states, evt.State)
+		case outputEvent:
+			output = append(output, evt.Data)
+		}
+	}
+	if strings.Join(output, ",") != "aaaaaaaa,bbbbbbbb" {
+		t.Fatalf("unexpected output order: %v", output)
+	}
+	if strings.Join(states, ",") != "paused,resumed" {
+		t.Fatalf("unexpected backpressure states: %v", states)
+	}
+}
+
+func TestOutputPumpBlockPolicyEmitsInline(t *testing.T) {
+	var payloads []any
+	pump := startPump(t, openRequest{}, func(payload any) { payloads = append(payloads, payload) })
+
+	pump.Push(outputOf("x"))
+	if len(payloads) != 1 {
+		t.Fatalf("expected inline emit, got %d payloads", len(payloads))
+	}
+	pump.Close()
+}
+
+func TestSidecarRejectsUnknownBackpressurePolicy(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, Backpressure: "spill"})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request error, got %+v", errors)
+	}
+	if _, exists := ts.terminals["t1"]; exists {
+		t.Fatal("terminal should not be opened")
+	}
+}
+
+func TestSidecarBufferedOutputPrecedesExit(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{Backpressure: backpressureBuffer})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	terminal := ts.terminals["t1"]
+	terminal.callbacks.Output([]byte("done"))
+	terminal.callbacks.Exit(0)
+
+	var types []string
+	for _, evt := range ts.events(t) {
+		types = append(types, evt["type"].(string))
+	}
+	if got := strings.Join(types, ","); got != "ready,output,exit" {
+		t.Fatalf("unexpected event order: %s", got)
+	}
+}
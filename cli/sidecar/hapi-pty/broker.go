@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+)
+
+// brokerEvent is the subset of sidecar events a brokerSession consumes.
+type brokerEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	Data       string `json:"data"`
+	Code       any    `json:"code"`
+	Message    string `json:"message"`
+	Reason     string `json:"reason"`
+}
+
+// brokerSession runs one terminal inside another hapi-pty process, such as
+// an elevated broker, by speaking the sidecar protocol over conn. Output and
+// exit events from the broker are replayed through the local callbacks, so
+// the rest of the sidecar cannot tell it apart from a local ConPTY session.
+type brokerSession struct {
+	terminalID string
+	conn       io.ReadWriteCloser
+	reader     *bufio.Reader
+	callbacks  terminalCallbacks
+
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+func newBrokerSession(
+	conn io.ReadWriteCloser,
+	req openRequest,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	s := &brokerSession{
+		terminalID: req.TerminalID,
+		conn:       conn,
+		reader:     bufio.NewReader(conn),
+		callbacks:  callbacks,
+	}
+
+	// The broker greets with hello before reading any request.
+	if err := s.awaitHello(); err != nil {
+		_ = conn.Close()
+		return nil, newSidecarError(errorCodeStartupFailed, "broker did not start: %v", err)
+	}
+	if err := s.send(brokerOpenRequest(req)); err != nil {
+		_ = conn.Close()
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to reach broker: %v", err)
+	}
+
+	for {
+		evt, err := s.next()
+		if err != nil {
+			_ = conn.Close()
+			return nil, newSidecarError(errorCodeStartupFailed, "broker closed before terminal was ready: %v", err)
+		}
+
+		switch evt.Type {
+		case eventTypeReady:
+			runIsolated(req.TerminalID, s.relay)
+			return s, nil
+		case eventTypeError:
+			_ = conn.Close()
+			code, _ := evt.Code.(string)
+			if code == "" {
+				code = errorCodeStartupFailed
+			}
+			return nil, newSidecarError(code, "%s", evt.Message)
+		}
+	}
+}
+
+func (s *brokerSession) awaitHello() error {
+	for {
+		evt, err := s.next()
+		if err != nil {
+			return err
+		}
+		if evt.Type == eventTypeHello {
+			return nil
+		}
+	}
+}
+
+// brokerOpenRequest forwards the resolved launch parameters. The broker does
+// not inherit this process's environment, so the resolved one is sent whole.
+func brokerOpenRequest(req openRequest) openRequest {
+	env := make(map[string]string, len(req.environ))
+	for _, entry := range req.environ {
+		if name, value, ok := strings.Cut(entry, "="); ok && name != "" {
+			env[name] = value
+		}
+	}
+	inherit := false
+
+	return openRequest{
+		Type:            requestTypeOpen,
+		TerminalID:      req.TerminalID,
+		Cwd:             req.Cwd,
+		Shell:           req.Shell,
+		VisualStudio:    req.VisualStudio,
+		ExtraArgs:       req.ExtraArgs,
+		NoProfile:       req.NoProfile,
+		ExecutionPolicy: req.ExecutionPolicy,
+		ForceUTF8:       req.ForceUTF8,
+		OutputCodePage:  req.OutputCodePage,
+		Cols:            req.Cols,
+		Rows:            req.Rows,
+		Env:             env,
+		InheritEnv:      &inherit,
+		Privilege:       req.Privilege,
+		NoWindow:        req.NoWindow,
+		Desktop:         req.Desktop,
+		SessionID:       req.SessionID,
+		MaxMemoryMb:     req.MaxMemoryMb,
+		CPURatePercent:  req.CPURatePercent,
+		Priority:        req.Priority,
+		Affinity:        req.Affinity,
+		InheritCursor:   req.InheritCursor,
+		ConPTYFlags:     req.ConPTYFlags,
+	}
+}
+
+func (s *brokerSession) send(payload any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return writeNDJSONLine(s.conn, payload)
+}
+
+func (s *brokerSession) next() (brokerEvent, error) {
+	line, err := s.reader.ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return brokerEvent{}, err
+	}
+
+	var evt brokerEvent
+	if err := json.Unmarshal(line, &evt); err != nil {
+		return brokerEvent{}, err
+	}
+	return evt, nil
+}
+
+// relay forwards broker events until the terminal exits or the broker goes away.
+func (s *brokerSession) relay() {
+	defer s.shutdown()
+
+	for {
+		evt, err := s.next()
+		if err != nil {
+			if s.callbacks.ExitReason != nil {
+				s.callbacks.ExitReason(exitReasonConPTYFailure)
+			}
+			s.callbacks.Exit(-1)
+			return
+		}
+		if evt.TerminalID != s.terminalID {
+			continue
+		}
+
+		switch evt.Type {
+		case eventTypeOutput:
+			chunk, err := base64.StdEncoding.DecodeString(evt.Data)
+			if err == nil && len(chunk) > 0 && s.callbacks.Output != nil {
+				s.callbacks.Output(chunk)
+			}
+		case eventTypeExit:
+			if evt.Reason != "" && s.callbacks.ExitReason != nil {
+				s.callbacks.ExitReason(evt.Reason)
+			}
+			code, _ := evt.Code.(float64)
+			s.callbacks.Exit(int(code))
+			return
+		}
+	}
+}
+
+func (s *brokerSession) Write(data string) error {
+	err := s.send(writeRequest{
+		Type:       requestTypeWrite,
+		TerminalID: s.terminalID,
+		Data:       base64.StdEncoding.EncodeToString([]byte(data)),
+		Encoding:   writeEncodingBase64,
+	})
+	if err != nil {
+		return newSidecarError(errorCodeStartupFailed, "broker write failed: %v", err)
+	}
+	return nil
+}
+
+func (s *brokerSession) Resize(cols int, rows int) error {
+	err := s.send(resizeRequest{Type: requestTypeResize, TerminalID: s.terminalID, Cols: cols, Rows: rows})
+	if err != nil {
+		return newSidecarError(errorCodeStartupFailed, "broker resize failed: %v", err)
+	}
+	return nil
+}
+
+// Close asks the broker to close the terminal; its exit event still arrives
+// through relay, which then shuts the broker down.
+func (s *brokerSession) Close() error {
+	if err := s.send(closeRequest{Type: requestTypeClose, TerminalID: s.terminalID}); err != nil {
+		s.shutdown()
+	}
+	return nil
+}
+
+func (s *brokerSession) shutdown() {
+	s.closeOnce.Do(func() {
+		_ = s.send(shutdownRequest{Type: requestTypeShutdown})
+		_ = s.conn.Close()
+	})
+}
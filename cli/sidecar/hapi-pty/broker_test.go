@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// brokerConn joins the two halves of an in-memory pipe pair.
+type brokerConn struct {
+	io.Reader
+	io.Writer
+	closers []io.Closer
+}
+
+func (c *brokerConn) Close() error {
+	for _, closer := range c.closers {
+		_ = closer.Close()
+	}
+	return nil
+}
+
+// startTestBroker runs a sidecar on the far end of an in-memory connection
+// and returns the connection together with the fake terminals it opened.
+func startTestBroker(t *testing.T) (io.ReadWriteCloser, chan *fakeTerminal) {
+	t.Helper()
+
+	toBroker, fromParent := io.Pipe()
+	toParent, fromBroker := io.Pipe()
+	opened := make(chan *fakeTerminal, 1)
+
+	done := make(chan int, 1)
+	go func() {
+		done <- runSidecar(toBroker, fromBroker, runConfig{
+			IdleTimeout: time.Minute,
+			LookPath:    fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`}),
+			ProbeConPTY: func() error { return nil },
+			TerminalOpener: func(
+				req openRequest,
+				_ resolvedShell,
+				callbacks terminalCallbacks,
+				_ func(terminalID string, task func()),
+			) (terminalSession, error) {
+				terminal := &fakeTerminal{req: req, callbacks: callbacks}
+				opened <- terminal
+				return terminal, nil
+			},
+		})
+		_ = fromBroker.Close()
+	}()
+	t.Cleanup(func() {
+		_ = fromParent.Close()
+		<-done
+	})
+
+	conn := &brokerConn{
+		Reader:  toParent,
+		Writer:  fromParent,
+		closers: []io.Closer{fromParent, toParent},
+	}
+	return conn, opened
+}
+
+func TestBrokerSessionRelaysOutputWritesAndExit(t *testing.T) {
+	conn, opened := startTestBroker(t)
+
+	output := make(chan string, 4)
+	exited := make(chan int, 1)
+	callbacks := terminalCallbacks{
+		Output: func(chunk []byte) { output <- string(chunk) },
+		Exit:   func(code int) { exited <- code },
+	}
+
+	var wg sync.WaitGroup
+	session, err := newBrokerSession(conn, openRequest{
+		TerminalID: "t1",
+		Shell:      "pwsh",
+		Cols:       80,
+		Rows:       24,
+		environ:    []string{"HAPI_BROKER=1"},
+	}, callbacks, func(_ string, task func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task()
+		}()
+	})
+	if err != nil {
+		t.Fatalf("newBrokerSession failed: %v", err)
+	}
+
+	remote := <-opened
+	if remote.req.Env["HAPI_BROKER"] != "1" || remote.req.InheritEnv == nil || *remote.req.InheritEnv {
+		t.Fatalf("broker should receive the resolved environment only: %+v", remote.req)
+	}
+
+	if err := session.Write("dir\r"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if writes := remote.waitForWrites(t, 1); len(writes) != 1 || writes[0] != "dir\r" {
+		t.Fatalf("unexpected broker writes: %#v", writes)
+	}
+
+	remote.callbacks.Output([]byte("hello"))
+	select {
+	case chunk := <-output:
+		if chunk != "hello" {
+			t.Fatalf("unexpected relayed output: %q", chunk)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("output was not relayed")
+	}
+
+	remote.callbacks.Exit(3)
+	select {
+	case code := <-exited:
+		if code != 3 {
+			t.Fatalf("unexpected relayed exit code: %d", code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("exit was not relayed")
+	}
+	wg.Wait()
+}
+
+func TestBrokerSessionMapsOpenErrors(t *testing.T) {
+	conn, _ := startTestBroker(t)
+
+	_, err := newBrokerSession(conn, openRequest{
+		TerminalID: "t1",
+		Shell:      "missing-shell",
+	}, terminalCallbacks{}, func(string, func()) {})
+
+	var sidecarErr *sidecarError
+	if !errors.As(err, &sidecarErr) || sidecarErr.Code != errorCodeShellNotFound {
+		t.Fatalf("expected shell_not_found from broker, got %v", err)
+	}
+}
@@ -7,21 +7,26 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
 	"unsafe"
 )
 
 const (
-	minTerminalDimension             = 1
-	maxTerminalDimension             = 32767
 	defaultProbeCols                 = 80
 	defaultProbeRows                 = 25
 	procThreadAttributePseudoConsole = 0x00020016
 	extendedStartupInfoPresent       = 0x00080000
 	terminateExitCode                = 1
+	createNoWindow                   = 0x08000000
 	errorInvalidHandle               = 6
 )
 
@@ -34,10 +39,27 @@ var (
 	procInitializeProcThreadAttributeList = kernel32Proc.NewProc("InitializeProcThreadAttributeList")
 	procUpdateProcThreadAttribute         = kernel32Proc.NewProc("UpdateProcThreadAttribute")
 	procDeleteProcThreadAttributeList     = kernel32Proc.NewProc("DeleteProcThreadAttributeList")
+	procGetOEMCP                          = kernel32Proc.NewProc("GetOEMCP")
+	procRtlGetVersion                     = syscall.NewLazyDLL("ntdll.dll").NewProc("RtlGetVersion")
+	procGetCPInfo                         = kernel32Proc.NewProc("GetCPInfo")
+	procMultiByteToWideChar               = kernel32Proc.NewProc("MultiByteToWideChar")
+	procCancelIoEx                        = kernel32Proc.NewProc("CancelIoEx")
+	procGetProcessHeap                    = kernel32Proc.NewProc("GetProcessHeap")
+	procHeapAlloc                         = kernel32Proc.NewProc("HeapAlloc")
 )
 
 type conptyHandle uintptr
 
+// pseudoConsole is what an HPCON points to, in kernel32 and conpty.dll
+// alike: the signal pipe, the reference handle that keeps the console
+// alive, and the conhost process. ClosePseudoConsole frees it from the
+// process heap.
+type pseudoConsole struct {
+	Signal    syscall.Handle
+	Reference syscall.Handle
+	Conhost   syscall.Handle
+}
+
 type windowsCoord struct {
 	X int16
 	Y int16
@@ -53,7 +75,18 @@ type conptySession struct {
 	stdin     io.WriteCloser
 	output    io.ReadCloser
 	process   syscall.Handle
+	pid       uint32
+	job       *terminalJob
 	closeOnce sync.Once
+	// mu serialises Hangup and Close, which both release the console handles.
+	mu sync.Mutex
+
+	// stream starts a drain on output, again after a failed upgrade; drain
+	// is the current one. detached is set once an upgrade stopped the
+	// drain, so the new binary alone reports the exit.
+	stream   func()
+	drain    *outputDrain
+	detached atomic.Bool
 }
 
 func probeConPTY() error {
@@ -75,7 +108,7 @@ func probeConPTY() error {
 	defer closeHandleIfValid(&ptyOutputRead)
 	defer closeHandleIfValid(&ptyOutputWrite)
 
-	pseudoConsole, err := createPseudoConsole(defaultProbeCols, defaultProbeRows, ptyInputRead, ptyOutputWrite)
+	pseudoConsole, err := createPseudoConsole(defaultProbeCols, defaultProbeRows, 0, ptyInputRead, ptyOutputWrite)
 	if err != nil {
 		return newSidecarError(errorCodeConPTYUnavailable, "CreatePseudoConsole probe failed: %v", err)
 	}
@@ -93,6 +126,9 @@ func newPlatformTerminalSession(
 	if err := ensureConPTYAPIs(); err != nil {
 		return nil, err
 	}
+	if req.Elevated && !isProcessElevated() {
+		return openElevatedSession(req, callbacks, runIsolated)
+	}
 
 	ptyInputRead, ptyInputWrite, err := createPipePair()
 	if err != nil {
@@ -108,7 +144,7 @@ func newPlatformTerminalSession(
 	defer closeHandleIfValid(&ptyOutputRead)
 	defer closeHandleIfValid(&ptyOutputWrite)
 
-	pseudoConsole, err := createPseudoConsole(req.Cols, req.Rows, ptyInputRead, ptyOutputWrite)
+	pseudoConsole, err := createPseudoConsole(req.Cols, req.Rows, conptyCreateFlags(req), ptyInputRead, ptyOutputWrite)
 	if err != nil {
 		return nil, newSidecarError(errorCodeStartupFailed, "failed to create pseudo console: %v", err)
 	}
@@ -135,30 +171,216 @@ func newPlatformTerminalSession(
 	}
 	ptyOutputRead = 0
 
-	processHandle, err := startConPTYProcess(req, shell, pseudoConsole)
+	job, err := newTerminalJob(req)
+	if err != nil {
+		_ = stdinFile.Close()
+		_ = outputFile.Close()
+		return nil, err
+	}
+
+	processHandle, err := startConPTYProcess(req, shell, pseudoConsole, job)
 	if err != nil {
+		job.Close()
 		_ = stdinFile.Close()
 		_ = outputFile.Close()
 		return nil, err
 	}
 
+	pid, _, _ := procGetProcessId.Call(uintptr(processHandle))
 	session := &conptySession{
 		conpty:  pseudoConsole,
 		stdin:   stdinFile,
 		output:  outputFile,
 		process: processHandle,
+		pid:     uint32(pid),
+		job:     job,
 	}
 	pseudoConsoleOpened = false
+	session.start(req, callbacks, runIsolated)
 
+	return session, nil
+}
+
+// start streams the session's output and reports the shell's exit.
+func (s *conptySession) start(req openRequest, callbacks terminalCallbacks, runIsolated func(terminalID string, task func())) {
+	// openTerminal already rejected an unsupported output code page.
+	transcoder, _ := newOutputTranscoder(req.OutputCodePage)
+	s.stream = func() {
+		drain := newOutputDrain(callbacks.Output)
+		s.mu.Lock()
+		s.drain = drain
+		output := s.output
+		s.mu.Unlock()
+		runIsolated(req.TerminalID, func() {
+			drain.Stream(output, req.ReadBufferBytes, transcoder)
+		})
+	}
+	s.stream()
 	runIsolated(req.TerminalID, func() {
-		streamOutput(session.output, callbacks.Output)
+		s.job.WatchMemoryLimit(func() {
+			if callbacks.ExitReason != nil {
+				callbacks.ExitReason(exitReasonResourceLimit)
+			}
+		})
 	})
 	runIsolated(req.TerminalID, func() {
-		callbacks.Exit(waitForProcessExit(session.process))
-		closeHandle(session.process)
+		code := waitForProcessExit(s.process)
+		if s.detached.Load() {
+			return
+		}
+		if code < 0 && callbacks.ExitReason != nil {
+			callbacks.ExitReason(exitReasonConPTYFailure)
+		}
+		// Closing the job takes the shell's remaining descendants with it.
+		s.job.Close()
+		// ConPTY keeps the output pipe open until the pseudo console is
+		// closed, which flushes its last frame and lets the stream end.
+		_ = s.Hangup()
+		s.mu.Lock()
+		drain := s.drain
+		s.mu.Unlock()
+		drain.Wait(outputDrainTimeout)
+		callbacks.Exit(code)
+		closeHandle(s.process)
 	})
+}
 
-	return session, nil
+// exportSession duplicates a ConPTY session's handles, including those
+// inside its pseudo console, into the upgrade process.
+func (p *upgradeProcess) exportSession(session terminalSession) (sessionHandles, error) {
+	s, ok := session.(*conptySession)
+	if !ok {
+		return sessionHandles{}, errors.New("only ConPTY terminals can be handed over")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stdin, stdinOK := s.stdin.(*os.File)
+	output, outputOK := s.output.(*os.File)
+	if s.conpty == 0 || !stdinOK || !outputOK {
+		return sessionHandles{}, errors.New("terminal is closing")
+	}
+	console := *(*pseudoConsole)(*(*unsafe.Pointer)(unsafe.Pointer(&s.conpty)))
+
+	handles := sessionHandles{PID: s.pid}
+	sources := []struct {
+		handle uintptr
+		target *uintptr
+	}{
+		{uintptr(console.Signal), &handles.Signal},
+		{uintptr(console.Reference), &handles.Reference},
+		{uintptr(console.Conhost), &handles.Conhost},
+		{stdin.Fd(), &handles.Stdin},
+		{output.Fd(), &handles.Output},
+		{uintptr(s.process), &handles.Process},
+	}
+	if s.job != nil {
+		sources = append(sources, struct {
+			handle uintptr
+			target *uintptr
+		}{uintptr(s.job.handle), &handles.Job})
+	}
+	for _, source := range sources {
+		duplicated, err := p.duplicate(source.handle)
+		if err != nil {
+			return sessionHandles{}, err
+		}
+		*source.target = duplicated
+	}
+	return handles, nil
+}
+
+// detachSession stops reading a session's output for an upgrade. The read
+// in flight is cancelled rather than completed, so no chunk is consumed
+// that the new binary would not forward.
+func detachSession(session terminalSession) error {
+	s, ok := session.(*conptySession)
+	if !ok {
+		return errors.New("only ConPTY terminals can be handed over")
+	}
+
+	s.detached.Store(true)
+	s.mu.Lock()
+	drain, output := s.drain, s.output
+	s.mu.Unlock()
+	cancelPendingReads(output)
+
+	select {
+	case <-drain.done:
+		return nil
+	case <-time.After(outputDrainTimeout):
+		return errors.New("output read could not be cancelled")
+	}
+}
+
+// resumeSession undoes detachSession after a failed upgrade.
+func resumeSession(session terminalSession) {
+	s, ok := session.(*conptySession)
+	if !ok {
+		return
+	}
+
+	s.detached.Store(false)
+	s.mu.Lock()
+	drain := s.drain
+	s.mu.Unlock()
+	select {
+	case <-drain.done:
+		s.stream()
+	default:
+	}
+}
+
+// cancelPendingReads cancels I/O in flight on file, a pipe handle.
+func cancelPendingReads(file any) {
+	if fd, ok := file.(interface{ Fd() uintptr }); ok {
+		procCancelIoEx.Call(fd.Fd(), 0)
+	}
+}
+
+// adoptSession wraps handles an upgrading daemon duplicated into this
+// process as a ConPTY session, rebuilding its pseudo console on this
+// process's heap. The returned function starts reading once the old
+// process stopped. The job's memory limit is no longer watched: its
+// completion port stays with the old process.
+func adoptSession(
+	handles sessionHandles,
+	req openRequest,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, func(), error) {
+	if err := ensureConPTYAPIs(); err != nil {
+		return nil, nil, err
+	}
+
+	heap, _, err := procGetProcessHeap.Call()
+	if heap == 0 {
+		return nil, nil, newSidecarError(errorCodeStartupFailed, "failed to get process heap: %v", err)
+	}
+	console := pseudoConsole{
+		Signal:    syscall.Handle(handles.Signal),
+		Reference: syscall.Handle(handles.Reference),
+		Conhost:   syscall.Handle(handles.Conhost),
+	}
+	memory, _, err := procHeapAlloc.Call(heap, 0, unsafe.Sizeof(console))
+	if memory == 0 {
+		return nil, nil, newSidecarError(errorCodeStartupFailed, "failed to allocate pseudo console: %v", err)
+	}
+	*(*pseudoConsole)(*(*unsafe.Pointer)(unsafe.Pointer(&memory))) = console
+
+	var job *terminalJob
+	if handles.Job != 0 {
+		job = &terminalJob{handle: syscall.Handle(handles.Job)}
+	}
+	session := &conptySession{
+		conpty:  conptyHandle(memory),
+		stdin:   os.NewFile(handles.Stdin, "conpty-stdin"),
+		output:  os.NewFile(handles.Output, "conpty-output"),
+		process: syscall.Handle(handles.Process),
+		pid:     handles.PID,
+		job:     job,
+	}
+	return session, func() { session.start(req, callbacks, runIsolated) }, nil
 }
 
 func (s *conptySession) Write(data string) error {
@@ -186,7 +408,51 @@ func (s *conptySession) Resize(cols int, rows int) error {
 	return nil
 }
 
+// Hangup closes the pseudo console without terminating the shell, which
+// delivers CTRL_CLOSE_EVENT so it can run its exit handlers.
+func (s *conptySession) Hangup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stdin != nil {
+		_ = s.stdin.Close()
+		s.stdin = nil
+	}
+
+	if s.conpty != 0 {
+		closePseudoConsole(s.conpty)
+		s.conpty = 0
+	}
+
+	return nil
+}
+
+// KillProcess terminates pid if it descends from the shell.
+func (s *conptySession) KillProcess(pid int) error {
+	return killShellDescendant(s.pid, pid)
+}
+
+func killShellDescendant(shellPID uint32, pid int) error {
+	if uint32(pid) == shellPID {
+		return newSidecarError(errorCodeInvalidRequest, "pid %d is the shell; close the terminal instead", pid)
+	}
+	inTree, err := processTreeContains(shellPID, uint32(pid))
+	if err != nil {
+		return newSidecarError(errorCodeKillFailed, "failed to list processes: %v", err)
+	}
+	if !inTree {
+		return newSidecarError(errorCodeProcessNotFound, "pid %d is not in the terminal's process tree", pid)
+	}
+	if err := terminateProcessID(uint32(pid)); err != nil {
+		return newSidecarError(errorCodeKillFailed, "failed to terminate pid %d: %v", pid, err)
+	}
+	return nil
+}
+
 func (s *conptySession) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	var closeErr error
 	s.closeOnce.Do(func() {
 		if s.stdin != nil {
@@ -216,6 +482,7 @@ func (s *conptySession) Close() error {
 }
 
 func ensureConPTYAPIs() error {
+	preferConPTYDLL()
 	conptyProcs := []*syscall.LazyProc{
 		procCreatePseudoConsole,
 		procResizePseudoConsole,
@@ -244,7 +511,7 @@ func createPipePair() (syscall.Handle, syscall.Handle, error) {
 	return readHandle, writeHandle, nil
 }
 
-func createPseudoConsole(cols int, rows int, inputRead syscall.Handle, outputWrite syscall.Handle) (conptyHandle, error) {
+func createPseudoConsole(cols int, rows int, flags uint32, inputRead syscall.Handle, outputWrite syscall.Handle) (conptyHandle, error) {
 	coord := makeCoord(cols, rows)
 	coordValue := packCoord(coord)
 
@@ -253,7 +520,7 @@ func createPseudoConsole(cols int, rows int, inputRead syscall.Handle, outputWri
 		uintptr(coordValue),
 		uintptr(inputRead),
 		uintptr(outputWrite),
-		0,
+		uintptr(flags),
 		uintptr(unsafe.Pointer(&pseudoConsole)),
 	)
 	if hr != 0 {
@@ -284,16 +551,7 @@ func closePseudoConsole(handle conptyHandle) {
 }
 
 func makeCoord(cols int, rows int) windowsCoord {
-	if cols < minTerminalDimension {
-		cols = minTerminalDimension
-	} else if cols > maxTerminalDimension {
-		cols = maxTerminalDimension
-	}
-	if rows < minTerminalDimension {
-		rows = minTerminalDimension
-	} else if rows > maxTerminalDimension {
-		rows = maxTerminalDimension
-	}
+	cols, rows = clampTerminalSize(cols, rows)
 	return windowsCoord{
 		X: int16(cols),
 		Y: int16(rows),
@@ -304,8 +562,14 @@ func packCoord(coord windowsCoord) uint32 {
 	return uint32(uint16(coord.X)) | (uint32(uint16(coord.Y)) << 16)
 }
 
-func startConPTYProcess(req openRequest, shell resolvedShell, pseudoConsole conptyHandle) (syscall.Handle, error) {
-	commandLine := buildCommandLine(shell.Path, shell.Args)
+// startConPTYProcess starts the shell attached to pseudoConsole. With a job
+// it starts suspended and only runs once assigned to the job.
+func startConPTYProcess(req openRequest, shell resolvedShell, pseudoConsole conptyHandle, job *terminalJob) (syscall.Handle, error) {
+	// Extended-length paths stay prefixed only when they need to be; see
+	// plainPath. openTerminal already rejected a cwd too long to use.
+	shell.Path = plainPath(shell.Path)
+	req.Cwd = plainPath(req.Cwd)
+	commandLine := buildCommandLine(shell.Path, shell.Args, shell.Command)
 	commandLineUTF16, err := syscall.UTF16FromString(commandLine)
 	if err != nil {
 		return 0, newSidecarError(errorCodeStartupFailed, "failed to encode command line: %v", err)
@@ -324,7 +588,7 @@ func startConPTYProcess(req openRequest, shell resolvedShell, pseudoConsole conp
 		}
 	}
 
-	environmentBlock, err := buildEnvironmentBlock(mergeEnvironment(os.Environ(), req.Env))
+	environmentBlock, err := buildEnvironmentBlock(req.environ)
 	if err != nil {
 		return 0, newSidecarError(errorCodeStartupFailed, "failed to encode environment block: %v", err)
 	}
@@ -336,33 +600,77 @@ func startConPTYProcess(req openRequest, shell resolvedShell, pseudoConsole conp
 	defer deleteProcThreadAttributeList(attributeList)
 
 	startupInfo := newConPTYStartupInfo(attributeList)
+	if req.NoWindow {
+		startupInfo.StartupInfo.Flags |= syscall.STARTF_USESHOWWINDOW
+		startupInfo.StartupInfo.ShowWindow = syscall.SW_HIDE
+	}
+	if req.Desktop != "" {
+		desktop, err := syscall.UTF16PtrFromString(req.Desktop)
+		if err != nil {
+			return 0, newSidecarError(errorCodeStartupFailed, "failed to encode desktop: %v", err)
+		}
+		startupInfo.StartupInfo.Desktop = desktop
+	}
 
 	processInfo := syscall.ProcessInformation{}
 	createFlags := uint32(extendedStartupInfoPresent | syscall.CREATE_UNICODE_ENVIRONMENT)
+	if job != nil {
+		createFlags |= createSuspended
+	}
 
 	var environmentPtr *uint16
 	if len(environmentBlock) > 0 {
 		environmentPtr = &environmentBlock[0]
 	}
 
-	err = syscall.CreateProcess(
-		appNameUTF16,
-		&commandLineUTF16[0],
-		nil,
-		nil,
-		false,
-		createFlags,
-		environmentPtr,
-		cwdUTF16,
-		&startupInfo.StartupInfo,
-		&processInfo,
-	)
+	token, err := childProcessToken(req)
+	if err != nil {
+		return 0, err
+	}
+	if token != 0 {
+		defer token.Close()
+
+		err = syscall.CreateProcessAsUser(
+			token,
+			appNameUTF16,
+			&commandLineUTF16[0],
+			nil,
+			nil,
+			false,
+			createFlags,
+			environmentPtr,
+			cwdUTF16,
+			&startupInfo.StartupInfo,
+			&processInfo,
+		)
+	} else {
+		err = syscall.CreateProcess(
+			appNameUTF16,
+			&commandLineUTF16[0],
+			nil,
+			nil,
+			false,
+			createFlags,
+			environmentPtr,
+			cwdUTF16,
+			&startupInfo.StartupInfo,
+			&processInfo,
+		)
+	}
 	if err != nil {
 		return 0, newSidecarError(errorCodeStartupFailed, "failed to start shell process: %v", err)
 	}
 
-	closeHandleIfValid(&processInfo.Thread)
 	runtime.KeepAlive(attributeListBacking)
+	if job != nil {
+		if err := job.Assign(processInfo.Process, processInfo.Thread); err != nil {
+			_ = syscall.TerminateProcess(processInfo.Process, terminateExitCode)
+			closeHandleIfValid(&processInfo.Thread)
+			closeHandle(processInfo.Process)
+			return 0, err
+		}
+	}
+	closeHandleIfValid(&processInfo.Thread)
 
 	return processInfo.Process, nil
 }
@@ -469,12 +777,15 @@ func isAlreadyClosedProcessError(err error) bool {
 	return errno == syscall.ERROR_ACCESS_DENIED || errno == syscall.Errno(errorInvalidHandle)
 }
 
-func buildCommandLine(path string, args []string) string {
-	parts := make([]string, 0, len(args)+1)
+func buildCommandLine(path string, args []string, command string) string {
+	parts := make([]string, 0, len(args)+2)
 	parts = append(parts, syscall.EscapeArg(path))
 	for _, arg := range args {
 		parts = append(parts, syscall.EscapeArg(arg))
 	}
+	if command != "" {
+		parts = append(parts, command)
+	}
 	return strings.Join(parts, " ")
 }
 
@@ -484,7 +795,7 @@ func buildEnvironmentBlock(env []string) ([]uint16, error) {
 	}
 
 	block := make([]uint16, 0, len(env)*16)
-	for _, item := range env {
+	for _, item := range sortEnvironment(env) {
 		encoded, err := syscall.UTF16FromString(item)
 		if err != nil {
 			return nil, err
@@ -494,3 +805,535 @@ func buildEnvironmentBlock(env []string) ([]uint16, error) {
 	block = append(block, 0)
 	return block, nil
 }
+
+// readSystemComSpec reads the machine-wide ComSpec from the registry, for
+// when the sidecar's own environment lacks one. The value may hold
+// unexpanded %SystemRoot% references.
+func readSystemComSpec() (string, bool) {
+	subkey, _ := syscall.UTF16PtrFromString(`SYSTEM\CurrentControlSet\Control\Session Manager\Environment`)
+	var key syscall.Handle
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, subkey, 0, syscall.KEY_READ, &key); err != nil {
+		return "", false
+	}
+	defer syscall.RegCloseKey(key)
+
+	name, _ := syscall.UTF16PtrFromString("ComSpec")
+	var valueType, size uint32
+	if err := syscall.RegQueryValueEx(key, name, nil, &valueType, nil, &size); err != nil || size < 2 {
+		return "", false
+	}
+	buf := make([]uint16, size/2+1)
+	if err := syscall.RegQueryValueEx(key, name, nil, &valueType, (*byte)(unsafe.Pointer(&buf[0])), &size); err != nil {
+		return "", false
+	}
+	return syscall.UTF16ToString(buf), true
+}
+
+// systemOEMCodePage is the code page consoles start in.
+func systemOEMCodePage() int {
+	codePage, _, _ := procGetOEMCP.Call()
+	return int(codePage)
+}
+
+// cpInfo mirrors CPINFO.
+type cpInfo struct {
+	MaxCharSize uint32
+	DefaultChar [2]byte
+	LeadByte    [12]byte
+}
+
+// systemTranscoder decodes a double-byte code page such as 936 or 932 with
+// MultiByteToWideChar, holding back a lead byte split from its trail byte.
+type systemTranscoder struct {
+	codePage uint32
+	lead     [256]bool
+	pending  []byte
+}
+
+func newSystemTranscoder(codePage int) (outputTranscoder, error) {
+	var info cpInfo
+	if ok, _, _ := procGetCPInfo.Call(uintptr(codePage), uintptr(unsafe.Pointer(&info))); ok == 0 {
+		return nil, newSidecarError(errorCodeInvalidRequest, "output code page %d is not installed", codePage)
+	}
+	if info.MaxCharSize > 2 {
+		return nil, newSidecarError(errorCodeInvalidRequest, "output code page %d is not a single or double byte code page", codePage)
+	}
+
+	t := &systemTranscoder{codePage: uint32(codePage)}
+	// LeadByte lists inclusive ranges, terminated by a zero pair.
+	for i := 0; i+1 < len(info.LeadByte) && info.LeadByte[i] != 0; i += 2 {
+		for b := int(info.LeadByte[i]); b <= int(info.LeadByte[i+1]); b++ {
+			t.lead[b] = true
+		}
+	}
+	return t, nil
+}
+
+func (t *systemTranscoder) Transcode(chunk []byte) []byte {
+	data := append(t.pending, chunk...)
+	complete := len(data)
+	for i := 0; i < len(data); i++ {
+		if t.lead[data[i]] {
+			if i+1 == len(data) {
+				complete = i
+				break
+			}
+			i++
+		}
+	}
+	t.pending = append([]byte(nil), data[complete:]...)
+	return t.decode(data[:complete])
+}
+
+func (t *systemTranscoder) Flush() []byte {
+	rest := t.decode(t.pending)
+	t.pending = nil
+	return rest
+}
+
+func (t *systemTranscoder) decode(data []byte) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	n, _, _ := procMultiByteToWideChar.Call(uintptr(t.codePage), 0, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), 0, 0)
+	if n == 0 {
+		return []byte(strings.Repeat(string(utf8.RuneError), len(data)))
+	}
+	wide := make([]uint16, n)
+	procMultiByteToWideChar.Call(uintptr(t.codePage), 0, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), uintptr(unsafe.Pointer(&wide[0])), n)
+	return []byte(string(utf16.Decode(wide)))
+}
+
+const (
+	winptyDLLName   = "winpty.dll"
+	winptyAgentName = "winpty-agent.exe"
+	// winptyFlagColorEscapes makes the agent emit colors as escape
+	// sequences; winptySpawnFlagAutoShutdown stops it when the shell exits.
+	winptyFlagColorEscapes      = 0x4
+	winptySpawnFlagAutoShutdown = 0x1
+)
+
+// winptyAPI is winpty.dll, which starts winpty-agent.exe from its own
+// directory to host a hidden console and scrape it into escape sequences.
+type winptyAPI struct {
+	configNew            *syscall.LazyProc
+	configFree           *syscall.LazyProc
+	configSetInitialSize *syscall.LazyProc
+	open                 *syscall.LazyProc
+	coninName            *syscall.LazyProc
+	conoutName           *syscall.LazyProc
+	spawnConfigNew       *syscall.LazyProc
+	spawnConfigFree      *syscall.LazyProc
+	spawn                *syscall.LazyProc
+	setSize              *syscall.LazyProc
+	free                 *syscall.LazyProc
+	errorMsg             *syscall.LazyProc
+	errorFree            *syscall.LazyProc
+}
+
+// loadWinpty loads winpty from dir, by default the sidecar's own directory
+// where it is bundled.
+func loadWinpty(dir string) (*winptyAPI, error) {
+	if dir == "" {
+		executable, err := os.Executable()
+		if err != nil {
+			return nil, newSidecarError(errorCodeConPTYUnavailable, "failed to locate winpty: %v", err)
+		}
+		dir = filepath.Dir(executable)
+	}
+	if _, err := os.Stat(filepath.Join(dir, winptyAgentName)); err != nil {
+		return nil, newSidecarError(errorCodeConPTYUnavailable, "winpty is not available: %v", err)
+	}
+
+	dll := syscall.NewLazyDLL(filepath.Join(dir, winptyDLLName))
+	if err := dll.Load(); err != nil {
+		return nil, newSidecarError(errorCodeConPTYUnavailable, "winpty is not available: %v", err)
+	}
+	api := &winptyAPI{
+		configNew:            dll.NewProc("winpty_config_new"),
+		configFree:           dll.NewProc("winpty_config_free"),
+		configSetInitialSize: dll.NewProc("winpty_config_set_initial_size"),
+		open:                 dll.NewProc("winpty_open"),
+		coninName:            dll.NewProc("winpty_conin_name"),
+		conoutName:           dll.NewProc("winpty_conout_name"),
+		spawnConfigNew:       dll.NewProc("winpty_spawn_config_new"),
+		spawnConfigFree:      dll.NewProc("winpty_spawn_config_free"),
+		spawn:                dll.NewProc("winpty_spawn"),
+		setSize:              dll.NewProc("winpty_set_size"),
+		free:                 dll.NewProc("winpty_free"),
+		errorMsg:             dll.NewProc("winpty_error_msg"),
+		errorFree:            dll.NewProc("winpty_error_free"),
+	}
+	for _, proc := range []*syscall.LazyProc{
+		api.configNew, api.configFree, api.configSetInitialSize, api.open, api.coninName, api.conoutName,
+		api.spawnConfigNew, api.spawnConfigFree, api.spawn, api.setSize, api.free, api.errorMsg, api.errorFree,
+	} {
+		if err := proc.Find(); err != nil {
+			return nil, newSidecarError(errorCodeConPTYUnavailable, "winpty is not available: %v", err)
+		}
+	}
+	return api, nil
+}
+
+// errorText returns and frees a winpty_error_ptr_t.
+func (api *winptyAPI) errorText(errPtr uintptr) string {
+	if errPtr == 0 {
+		return "unknown error"
+	}
+	defer api.errorFree.Call(errPtr)
+	msg, _, _ := api.errorMsg.Call(errPtr)
+	return wideStringAt(msg)
+}
+
+// wideStringAt reads the NUL-terminated UTF-16 string a DLL returned.
+func wideStringAt(ptr uintptr) string {
+	if ptr == 0 {
+		return ""
+	}
+	start := *(*unsafe.Pointer)(unsafe.Pointer(&ptr))
+	var chars []uint16
+	for i := uintptr(0); ; i += 2 {
+		c := *(*uint16)(unsafe.Add(start, i))
+		if c == 0 {
+			return string(utf16.Decode(chars))
+		}
+		chars = append(chars, c)
+	}
+}
+
+func probeWinpty(dir string) error {
+	_, err := loadWinpty(dir)
+	return err
+}
+
+// newWinptyTerminalFactory opens terminals through winpty from dir, for
+// Windows builds before 1809 that lack ConPTY.
+func newWinptyTerminalFactory(dir string) terminalFactory {
+	return func(
+		req openRequest,
+		shell resolvedShell,
+		callbacks terminalCallbacks,
+		runIsolated func(terminalID string, task func()),
+	) (terminalSession, error) {
+		if req.Elevated || req.Privilege != privilegeInherit || req.Desktop != "" || req.SessionID != nil {
+			return nil, newSidecarError(errorCodeInvalidRequest, "the winpty backend cannot change a shell's privilege, desktop or session")
+		}
+		api, err := loadWinpty(dir)
+		if err != nil {
+			return nil, err
+		}
+		return openWinptySession(api, req, shell, callbacks, runIsolated)
+	}
+}
+
+type winptySession struct {
+	api       *winptyAPI
+	agent     uintptr
+	stdin     io.WriteCloser
+	output    io.ReadCloser
+	process   syscall.Handle
+	pid       uint32
+	closeOnce sync.Once
+	// mu serialises Hangup and Close, which both free the agent.
+	mu sync.Mutex
+}
+
+func openWinptySession(
+	api *winptyAPI,
+	req openRequest,
+	shell resolvedShell,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	var errPtr uintptr
+	config, _, _ := api.configNew.Call(winptyFlagColorEscapes, uintptr(unsafe.Pointer(&errPtr)))
+	if config == 0 {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to configure winpty: %s", api.errorText(errPtr))
+	}
+	coord := makeCoord(req.Cols, req.Rows)
+	api.configSetInitialSize.Call(config, uintptr(coord.X), uintptr(coord.Y))
+	agent, _, _ := api.open.Call(config, uintptr(unsafe.Pointer(&errPtr)))
+	api.configFree.Call(config)
+	if agent == 0 {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to start winpty agent: %s", api.errorText(errPtr))
+	}
+
+	session := &winptySession{api: api, agent: agent}
+	conin, _, _ := api.coninName.Call(agent)
+	conout, _, _ := api.conoutName.Call(agent)
+	stdin, err := os.OpenFile(wideStringAt(conin), os.O_WRONLY, 0)
+	if err != nil {
+		_ = session.Close()
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to open winpty input pipe: %v", err)
+	}
+	session.stdin = stdin
+	output, err := os.OpenFile(wideStringAt(conout), os.O_RDONLY, 0)
+	if err != nil {
+		_ = session.Close()
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to open winpty output pipe: %v", err)
+	}
+	session.output = output
+
+	job, err := newTerminalJob(req)
+	if err != nil {
+		_ = session.Close()
+		return nil, err
+	}
+	if err := session.spawn(req, shell, job); err != nil {
+		job.Close()
+		_ = session.Close()
+		return nil, err
+	}
+
+	transcoder, _ := newOutputTranscoder(req.OutputCodePage)
+	drain := newOutputDrain(callbacks.Output)
+	runIsolated(req.TerminalID, func() {
+		drain.Stream(session.output, req.ReadBufferBytes, transcoder)
+	})
+	runIsolated(req.TerminalID, func() {
+		job.WatchMemoryLimit(func() {
+			if callbacks.ExitReason != nil {
+				callbacks.ExitReason(exitReasonResourceLimit)
+			}
+		})
+	})
+	runIsolated(req.TerminalID, func() {
+		code := waitForProcessExit(session.process)
+		job.Close()
+		_ = session.Hangup()
+		drain.Wait(outputDrainTimeout)
+		callbacks.Exit(code)
+		closeHandle(session.process)
+	})
+
+	return session, nil
+}
+
+// spawn starts the shell in the agent's console. winpty cannot create it
+// suspended, so the job only catches processes started after it is assigned.
+func (s *winptySession) spawn(req openRequest, shell resolvedShell, job *terminalJob) error {
+	appName, err := syscall.UTF16PtrFromString(shell.Path)
+	if err != nil {
+		return newSidecarError(errorCodeStartupFailed, "failed to encode shell path: %v", err)
+	}
+	commandLine, err := syscall.UTF16PtrFromString(buildCommandLine(shell.Path, shell.Args, shell.Command))
+	if err != nil {
+		return newSidecarError(errorCodeStartupFailed, "failed to encode command line: %v", err)
+	}
+	var cwd *uint16
+	if req.Cwd != "" {
+		if cwd, err = syscall.UTF16PtrFromString(req.Cwd); err != nil {
+			return newSidecarError(errorCodeStartupFailed, "failed to encode cwd: %v", err)
+		}
+	}
+	environmentBlock, err := buildEnvironmentBlock(req.environ)
+	if err != nil {
+		return newSidecarError(errorCodeStartupFailed, "failed to encode environment block: %v", err)
+	}
+
+	var errPtr uintptr
+	config, _, _ := s.api.spawnConfigNew.Call(
+		winptySpawnFlagAutoShutdown,
+		uintptr(unsafe.Pointer(appName)),
+		uintptr(unsafe.Pointer(commandLine)),
+		uintptr(unsafe.Pointer(cwd)),
+		uintptr(unsafe.Pointer(&environmentBlock[0])),
+		uintptr(unsafe.Pointer(&errPtr)),
+	)
+	if config == 0 {
+		return newSidecarError(errorCodeStartupFailed, "failed to configure shell process: %s", s.api.errorText(errPtr))
+	}
+	defer s.api.spawnConfigFree.Call(config)
+
+	var process, thread syscall.Handle
+	var createErr uint32
+	ok, _, _ := s.api.spawn.Call(
+		s.agent,
+		config,
+		uintptr(unsafe.Pointer(&process)),
+		uintptr(unsafe.Pointer(&thread)),
+		uintptr(unsafe.Pointer(&createErr)),
+		uintptr(unsafe.Pointer(&errPtr)),
+	)
+	if ok == 0 {
+		if createErr != 0 {
+			s.api.errorFree.Call(errPtr)
+			return newSidecarError(errorCodeStartupFailed, "failed to start shell process: %v", syscall.Errno(createErr))
+		}
+		return newSidecarError(errorCodeStartupFailed, "failed to start shell process: %s", s.api.errorText(errPtr))
+	}
+	defer closeHandleIfValid(&thread)
+
+	if job != nil {
+		// The thread is already running; Assign's resume leaves it be.
+		if err := job.Assign(process, thread); err != nil {
+			_ = syscall.TerminateProcess(process, terminateExitCode)
+			closeHandle(process)
+			return err
+		}
+	}
+	pid, _, _ := procGetProcessId.Call(uintptr(process))
+	s.process = process
+	s.pid = uint32(pid)
+	return nil
+}
+
+func (s *winptySession) Write(data string) error {
+	s.mu.Lock()
+	stdin := s.stdin
+	s.mu.Unlock()
+	if stdin == nil {
+		return newSidecarError(errorCodeStartupFailed, "stdin pipe is closed")
+	}
+	if _, err := io.WriteString(stdin, data); err != nil {
+		return newSidecarError(errorCodeStartupFailed, "stdin write failed: %v", err)
+	}
+	return nil
+}
+
+func (s *winptySession) Resize(cols int, rows int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.agent == 0 {
+		return newSidecarError(errorCodeStartupFailed, "winpty agent is closed")
+	}
+
+	coord := makeCoord(cols, rows)
+	var errPtr uintptr
+	if ok, _, _ := s.api.setSize.Call(s.agent, uintptr(coord.X), uintptr(coord.Y), uintptr(unsafe.Pointer(&errPtr))); ok == 0 {
+		return newSidecarError(errorCodeStartupFailed, "winpty resize failed: %s", s.api.errorText(errPtr))
+	}
+	return nil
+}
+
+// Hangup frees the agent, which closes its console and delivers
+// CTRL_CLOSE_EVENT to the shell like closing a ConPTY does.
+func (s *winptySession) Hangup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stdin != nil {
+		_ = s.stdin.Close()
+		s.stdin = nil
+	}
+	if s.agent != 0 {
+		s.api.free.Call(s.agent)
+		s.agent = 0
+	}
+	return nil
+}
+
+// KillProcess terminates pid if it descends from the shell.
+func (s *winptySession) KillProcess(pid int) error {
+	return killShellDescendant(s.pid, pid)
+}
+
+func (s *winptySession) Close() error {
+	_ = s.Hangup()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var closeErr error
+	s.closeOnce.Do(func() {
+		if s.output != nil {
+			_ = s.output.Close()
+			s.output = nil
+		}
+		if s.process != 0 {
+			err := syscall.TerminateProcess(s.process, terminateExitCode)
+			if err != nil && !errors.Is(err, os.ErrProcessDone) && !isAlreadyClosedProcessError(err) {
+				closeErr = err
+			}
+		}
+	})
+	return closeErr
+}
+
+// pipesCommand starts shell with a hidden console, or with none at all when
+// noWindow is set. The command line is built as for ConPTY, since Command
+// is already quoted for cmd /S.
+func pipesCommand(shell resolvedShell, noWindow bool) *exec.Cmd {
+	cmd := exec.Command(shell.Path)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine:    buildCommandLine(shell.Path, shell.Args, shell.Command),
+		HideWindow: !noWindow,
+	}
+	if noWindow {
+		cmd.SysProcAttr.CreationFlags = createNoWindow
+	}
+	return cmd
+}
+
+const (
+	conptyDLLName   = "conpty.dll"
+	openConsoleName = "OpenConsole.exe"
+)
+
+var (
+	conptyDLLOnce sync.Once
+	conptyDLLPath string
+)
+
+// preferConPTYDLL switches the pseudo console calls to a conpty.dll bundled
+// beside the sidecar with its OpenConsole.exe. It is the ConPTY Windows
+// Terminal ships, which resizes without flicker and supports passthrough
+// mode on builds whose kernel32 ConPTY does not.
+func preferConPTYDLL() {
+	conptyDLLOnce.Do(func() {
+		executable, err := os.Executable()
+		if err != nil {
+			return
+		}
+		dir := filepath.Dir(executable)
+		path := filepath.Join(dir, conptyDLLName)
+		if _, err := os.Stat(filepath.Join(dir, openConsoleName)); err != nil {
+			return
+		}
+
+		dll := syscall.NewLazyDLL(path)
+		create := dll.NewProc("CreatePseudoConsole")
+		resize := dll.NewProc("ResizePseudoConsole")
+		release := dll.NewProc("ClosePseudoConsole")
+		for _, proc := range []*syscall.LazyProc{create, resize, release} {
+			if proc.Find() != nil {
+				return
+			}
+		}
+		procCreatePseudoConsole, procResizePseudoConsole, procClosePseudoConsole = create, resize, release
+		conptyDLLPath = path
+	})
+}
+
+// osVersionInfo mirrors RTL_OSVERSIONINFOW.
+type osVersionInfo struct {
+	Size         uint32
+	MajorVersion uint32
+	MinorVersion uint32
+	BuildNumber  uint32
+	PlatformID   uint32
+	CSDVersion   [128]uint16
+}
+
+// platformDetails reads the version from RtlGetVersion, which unlike
+// GetVersionEx does not depend on the executable's manifest.
+func platformDetails() *platformInfo {
+	info := osVersionInfo{}
+	info.Size = uint32(unsafe.Sizeof(info))
+	procRtlGetVersion.Call(uintptr(unsafe.Pointer(&info)))
+
+	preferConPTYDLL()
+	details := &platformInfo{
+		OSVersion: fmt.Sprintf("%d.%d.%d", info.MajorVersion, info.MinorVersion, info.BuildNumber),
+		OSBuild:   int(info.BuildNumber),
+		ConPTY:    procCreatePseudoConsole.Find() == nil,
+		ConPTYDLL: conptyDLLPath,
+	}
+	if details.ConPTY {
+		details.ConPTYSource = "kernel32"
+		if conptyDLLPath != "" {
+			details.ConPTYSource = conptyDLLName
+		}
+	}
+	return details
+}
@@ -2,6 +2,16 @@
 
 package main
 
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
 func probeConPTY() error {
 	return newSidecarError(errorCodeConPTYUnavailable, "ConPTY is only available on Windows")
 }
@@ -18,3 +28,173 @@ func newPlatformTerminalSession(
 	_ = runIsolated
 	return nil, newSidecarError(errorCodeConPTYUnavailable, "ConPTY is only available on Windows")
 }
+
+func dialBrokerPipe(name string) (io.ReadWriteCloser, error) {
+	_ = name
+	return nil, newSidecarError(errorCodeConPTYUnavailable, "broker pipes are only available on Windows")
+}
+
+func verifyExecutableSignature(path string) error {
+	_ = path
+	return errors.New("signature verification is only available on Windows")
+}
+
+func readSystemComSpec() (string, bool) {
+	return "", false
+}
+
+func systemOEMCodePage() int {
+	return 0
+}
+
+func newSystemTranscoder(codePage int) (outputTranscoder, error) {
+	return nil, newSidecarError(errorCodeInvalidRequest, "output code page %d is not supported on this platform", codePage)
+}
+
+func probeWinpty(dir string) error {
+	_ = dir
+	return newSidecarError(errorCodeConPTYUnavailable, "winpty is only available on Windows")
+}
+
+func newWinptyTerminalFactory(dir string) terminalFactory {
+	_ = dir
+	return func(openRequest, resolvedShell, terminalCallbacks, func(string, func())) (terminalSession, error) {
+		return nil, newSidecarError(errorCodeConPTYUnavailable, "winpty is only available on Windows")
+	}
+}
+
+func pipesCommand(shell resolvedShell, _ bool) *exec.Cmd {
+	args := shell.Args
+	if shell.Command != "" {
+		args = append(append([]string(nil), args...), shell.Command)
+	}
+	return exec.Command(shell.Path, args...)
+}
+
+func platformDetails() *platformInfo {
+	return nil
+}
+
+func listenDaemonPipe(name string) (daemonListener, error) {
+	_ = name
+	return nil, errors.New("daemon pipes are only available on Windows")
+}
+
+func adoptDaemonPipe(name string, pending uintptr) (daemonListener, error) {
+	_ = name
+	_ = pending
+	return nil, errors.New("daemon pipes are only available on Windows")
+}
+
+func connectDaemonPipe(name string, args []string) (io.ReadWriteCloser, error) {
+	_ = name
+	_ = args
+	return nil, errors.New("daemon pipes are only available on Windows")
+}
+
+var errUpgradeUnsupported = newSidecarError(errorCodeUpgradeFailed, "live upgrade is only available on Windows")
+
+type upgradeProcess struct {
+	*handoffConn
+}
+
+func startUpgradeProcess(path string, args []string) (*upgradeProcess, error) {
+	_ = path
+	_ = args
+	return nil, errUpgradeUnsupported
+}
+
+func (p *upgradeProcess) exportListener(listener daemonListener) (uintptr, error) {
+	_ = listener
+	return 0, errUpgradeUnsupported
+}
+
+func (p *upgradeProcess) exportFile(file any) (uintptr, error) {
+	_ = file
+	return 0, errUpgradeUnsupported
+}
+
+func (p *upgradeProcess) exportSession(session terminalSession) (sessionHandles, error) {
+	_ = session
+	return sessionHandles{}, errUpgradeUnsupported
+}
+
+func (p *upgradeProcess) abort() {}
+
+func (p *upgradeProcess) close() {}
+
+func detachSession(session terminalSession) error {
+	_ = session
+	return errUpgradeUnsupported
+}
+
+func resumeSession(session terminalSession) {
+	_ = session
+}
+
+func cancelPendingReads(file any) {
+	_ = file
+}
+
+func adoptSession(
+	handles sessionHandles,
+	req openRequest,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, func(), error) {
+	_ = handles
+	_ = req
+	_ = callbacks
+	_ = runIsolated
+	return nil, nil, errUpgradeUnsupported
+}
+
+// runUnderServiceManager serves until systemd, or anyone, sends SIGTERM,
+// telling a Type=notify unit once the listener is up and when stopping.
+func runUnderServiceManager(serve func(stop <-chan struct{}) int, stderr io.Writer) int {
+	if err := sdNotify("READY=1"); err != nil {
+		fmt.Fprintf(stderr, "sd_notify failed: %v\n", err)
+	}
+	return serveUntilSignalled(serve, func() {
+		_ = sdNotify("STOPPING=1")
+	})
+}
+
+// installPlatformService writes a systemd unit for the sidecar into
+// unitDir; enabling it is left to systemctl.
+func installPlatformService(name string, executable string, args []string, unitDir string, stdout io.Writer) error {
+	path := filepath.Join(unitDir, name+".service")
+	if err := os.WriteFile(path, []byte(systemdUnit(executable, args)), 0o644); err != nil {
+		return fmt.Errorf("failed to write systemd unit: %w", err)
+	}
+	fmt.Fprintf(stdout, "wrote %s; start it with: systemctl daemon-reload && systemctl enable --now %s\n", path, name)
+	return nil
+}
+
+// systemdUnit renders a Type=notify unit that runs executable with args
+// and restarts it when it fails.
+func systemdUnit(executable string, args []string) string {
+	command := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{executable}, args...) {
+		command = append(command, systemdQuote(arg))
+	}
+	return "[Unit]\n" +
+		"Description=hapi-pty terminal sidecar\n" +
+		"After=network-online.target\n" +
+		"Wants=network-online.target\n" +
+		"\n" +
+		"[Service]\n" +
+		"Type=notify\n" +
+		"ExecStart=" + strings.Join(command, " ") + "\n" +
+		"Restart=on-failure\n" +
+		"\n" +
+		"[Install]\n" +
+		"WantedBy=multi-user.target\n"
+}
+
+// systemdQuote quotes arg for ExecStart, escaping what systemd would
+// otherwise expand: specifiers (%) and variables ($).
+func systemdQuote(arg string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%", "$", "$$", "\n", `\n`)
+	return `"` + replacer.Replace(arg) + `"`
+}
@@ -4,6 +4,9 @@ package main
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -22,6 +25,12 @@ func TestNewPlatformTerminalSessionUnavailableOnNonWindows(t *testing.T) {
 	assertConPTYUnavailableError(t, err)
 }
 
+func TestPlatformDetailsOmittedOnNonWindows(t *testing.T) {
+	if info := platformDetails(); info != nil {
+		t.Fatalf("expected no platform details, got %+v", info)
+	}
+}
+
 func assertConPTYUnavailableError(t *testing.T, err error) {
 	t.Helper()
 
@@ -37,3 +46,32 @@ func assertConPTYUnavailableError(t *testing.T, err error) {
 		t.Fatalf("expected error code %q, got %q", errorCodeConPTYUnavailable, serr.Code)
 	}
 }
+
+func TestInstallServiceWritesSystemdUnit(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout strings.Builder
+	args := []string{"install", "--name", "pty", "--unit-dir", dir, "--", "--listen", "127.0.0.1:7000", "--auth-token-file", tokenFile}
+	if code := runService(args, nil, &stdout, &stdout); code != 0 {
+		t.Fatalf("install failed with %d: %s", code, stdout.String())
+	}
+	unit, err := os.ReadFile(filepath.Join(dir, "pty.service"))
+	if err != nil {
+		t.Fatalf("unit not written: %v", err)
+	}
+	for _, want := range []string{"Type=notify", `"service" "run" "--listen" "127.0.0.1:7000"`, "WantedBy=multi-user.target"} {
+		if !strings.Contains(string(unit), want) {
+			t.Fatalf("expected %q in unit:\n%s", want, unit)
+		}
+	}
+}
+
+func TestSystemdQuoteEscapesExpansions(t *testing.T) {
+	if got := systemdQuote(`50% $HOME "x" \`); got != `"50%% $$HOME \"x\" \\"` {
+		t.Fatalf("unexpected quoting %s", got)
+	}
+}
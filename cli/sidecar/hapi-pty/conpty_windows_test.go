@@ -32,7 +32,7 @@ func TestResizePseudoConsole(t *testing.T) {
 	defer closeHandleIfValid(&ptyOutputRead)
 	defer closeHandleIfValid(&ptyOutputWrite)
 
-	pseudoConsole, err := createPseudoConsole(80, 24, ptyInputRead, ptyOutputWrite)
+	pseudoConsole, err := createPseudoConsole(80, 24, 0, ptyInputRead, ptyOutputWrite)
 	if err != nil {
 		t.Fatalf("failed to create pseudo console: %v", err)
 	}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// daemonPipePrefix is the namespace of Windows named pipes; --daemon names
+// without it are placed there.
+const daemonPipePrefix = `\\.\pipe\`
+
+func daemonPipePath(name string) string {
+	if strings.HasPrefix(strings.ToLower(name), strings.ToLower(daemonPipePrefix)) {
+		return name
+	}
+	return daemonPipePrefix + name
+}
+
+// daemonListener accepts the successive clients of a daemon.
+type daemonListener interface {
+	Accept() (io.ReadWriteCloser, error)
+}
+
+// daemonState is what an upgrade hands over besides the terminals.
+type daemonState struct {
+	listener daemonListener
+	client   io.ReadWriteCloser
+}
+
+// serveDaemon runs one sidecar for a succession of clients, accepted one at
+// a time. Unlike runSidecar, a client that disconnects or idles out leaves
+// its terminals running, and events emitted meanwhile are dropped; the next
+// client finds them with list and re-attaches with attachIfExists, which
+// replays emulated screens. Only a shutdown or an upgrade stops the daemon.
+func serveDaemon(listener daemonListener, cfg runConfig, stderr io.Writer) (exitCode int) {
+	cfg = withRuntimeDefaults(cfg)
+	s := newSidecar(cfg, io.Discard)
+	defer s.recoverMainLoop(&exitCode)
+	defer cfg.Debug.Track(s)()
+
+	s.daemon = &daemonState{listener: listener}
+	return s.serveClients(nil, stderr)
+}
+
+// serveClients serves client, when set, and then each client the daemon
+// listener accepts.
+func (s *sidecar) serveClients(client io.ReadWriteCloser, stderr io.Writer) int {
+	for {
+		if client == nil {
+			conn, err := s.daemon.listener.Accept()
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				s.closeAllTerminals()
+				return 1
+			}
+			client = conn
+		}
+
+		s.daemon.client = client
+		s.writer.Retarget(client)
+		exitCode, shutdown := s.serve(client)
+		s.writer.Retarget(io.Discard)
+		_ = client.Close()
+		client = nil
+		if shutdown {
+			return exitCode
+		}
+	}
+}
+
+// runDaemonProxy relays stdio to a daemon connection. It returns 1 when the
+// host closes stdin, as runSidecar does, and 0 when the daemon hangs up.
+func runDaemonProxy(conn io.ReadWriteCloser, stdin io.Reader, stdout io.Writer) int {
+	done := make(chan int, 2)
+	go func() {
+		_, _ = io.Copy(stdout, conn)
+		done <- 0
+	}()
+	go func() {
+		_, _ = io.Copy(conn, stdin)
+		done <- 1
+	}()
+
+	exitCode := <-done
+	_ = conn.Close()
+	return exitCode
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServeDaemonKeepsTerminalsBetweenClients(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	var terminal *fakeTerminal
+	cfg := runConfig{
+		IdleTimeout: time.Minute,
+		ProbeConPTY: func() error { return nil },
+		LookPath:    fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`}),
+		TerminalOpener: func(req openRequest, _ resolvedShell, callbacks terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+			terminal = &fakeTerminal{req: req, callbacks: callbacks}
+			return terminal, nil
+		},
+	}
+	done := make(chan int, 1)
+	go func() {
+		done <- serveDaemon(testDaemonListener{listener}, cfg, io.Discard)
+	}()
+
+	first, reader := dialTestListener(t, listener.Addr().String(), `{"type":"open","terminalId":"t1","cols":80,"rows":24}`)
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeHello {
+		t.Fatalf("expected hello, got %#v", evt)
+	}
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeReady {
+		t.Fatalf("expected ready, got %#v", evt)
+	}
+	_ = first.Close()
+
+	second, reader := dialTestListener(t, listener.Addr().String(), `{"type":"list"}`)
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeHello {
+		t.Fatalf("expected hello for the next client, got %#v", evt)
+	}
+	evt := readTestEvent(t, reader)
+	if terminals, _ := evt["terminals"].([]any); evt["type"] != eventTypeList || len(terminals) != 1 {
+		t.Fatalf("expected the terminal to survive the first client, got %#v", evt)
+	}
+	if terminal.Closed() {
+		t.Fatal("terminal was closed when its client disconnected")
+	}
+
+	if _, err := io.WriteString(second, `{"type":"shutdown"}`+"\n"); err != nil {
+		t.Fatalf("write shutdown failed: %v", err)
+	}
+	select {
+	case exitCode := <-done:
+		if exitCode != 0 || !terminal.Closed() {
+			t.Fatalf("expected shutdown to close the terminal and exit 0, got %d", exitCode)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("daemon kept serving after shutdown")
+	}
+}
+
+func TestDaemonPipePath(t *testing.T) {
+	if got := daemonPipePath("hapi"); got != `\\.\pipe\hapi` {
+		t.Fatalf("unexpected pipe path %q", got)
+	}
+	if got := daemonPipePath(`\\.\PIPE\hapi`); got != `\\.\PIPE\hapi` {
+		t.Fatalf("expected a full pipe path to be kept, got %q", got)
+	}
+}
+
+type testDaemonListener struct {
+	net.Listener
+}
+
+func (l testDaemonListener) Accept() (io.ReadWriteCloser, error) {
+	return l.Listener.Accept()
+}
@@ -0,0 +1,230 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	errorPipeBusy      = 231
+	detachedProcess    = 0x00000008
+	createBreakawayJob = 0x01000000
+	// daemonStartTimeout bounds how long --daemon waits for the daemon it
+	// started, or for a busy one, to accept its connection.
+	daemonStartTimeout = 10 * time.Second
+	daemonDialInterval = 50 * time.Millisecond
+	// daemonPipeInstances is the connected client plus the instance waiting
+	// for the next one.
+	daemonPipeInstances = 2
+	// upgradeProcessAccess lets an upgrading daemon duplicate handles into
+	// the new binary, verify it on the handoff pipe and stop it on failure.
+	upgradeProcessAccess = 0x0040 | syscall.SYNCHRONIZE | syscall.PROCESS_TERMINATE | syscall.PROCESS_QUERY_INFORMATION
+)
+
+// daemonPipe is the daemon's listener; pending is the instance waiting for
+// the next client.
+type daemonPipe struct {
+	name    string
+	pending syscall.Handle
+}
+
+// listenDaemonPipe creates the daemon's pipe. The first instance is created
+// exclusively, so a second daemon for the same name fails to start rather
+// than share it, and the default pipe DACL keeps other users from writing
+// to it. The next instance is created as soon as a client connects, so the
+// name never disappears while the daemon runs; a client connecting to it
+// waits until the current one leaves.
+func listenDaemonPipe(name string) (daemonListener, error) {
+	pending, err := createNamedPipe(name, fileFlagFirstPipeInstance, daemonPipeInstances)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create daemon pipe %s: %w", name, err)
+	}
+	return &daemonPipe{name: name, pending: pending}, nil
+}
+
+// adoptDaemonPipe listens on the pending instance an upgrading daemon
+// duplicated into this process.
+func adoptDaemonPipe(name string, pending uintptr) (daemonListener, error) {
+	if pending == 0 {
+		return nil, fmt.Errorf("no instance of daemon pipe %s was handed over", name)
+	}
+	return &daemonPipe{name: name, pending: syscall.Handle(pending)}, nil
+}
+
+// Accept waits for the next client.
+func (p *daemonPipe) Accept() (io.ReadWriteCloser, error) {
+	pipe := p.pending
+	ret, _, callErr := procConnectNamedPipe.Call(uintptr(pipe), 0)
+	if ret == 0 && callErr != syscall.Errno(errorPipeConnected) {
+		closeHandle(pipe)
+		return nil, fmt.Errorf("daemon pipe connection failed: %w", callErr)
+	}
+	pending, err := createNamedPipe(p.name, 0, daemonPipeInstances)
+	if err != nil {
+		closeHandle(pipe)
+		return nil, fmt.Errorf("failed to create daemon pipe %s: %w", p.name, err)
+	}
+	p.pending = pending
+	return os.NewFile(uintptr(pipe), p.name), nil
+}
+
+// connectDaemonPipe connects to the daemon on name, starting one with args
+// when none is running, unless args is nil, and waiting while another
+// client holds it.
+func connectDaemonPipe(name string, args []string) (io.ReadWriteCloser, error) {
+	conn, err := dialBrokerPipe(name)
+	if err == nil {
+		return conn, nil
+	}
+	switch err {
+	case syscall.ERROR_FILE_NOT_FOUND:
+		if args == nil {
+			return nil, fmt.Errorf("no daemon is serving %s", name)
+		}
+		if err := startDaemon(name, args); err != nil {
+			return nil, err
+		}
+	case syscall.Errno(errorPipeBusy):
+	default:
+		return nil, fmt.Errorf("failed to connect to daemon pipe %s: %w", name, err)
+	}
+
+	deadline := time.Now().Add(daemonStartTimeout)
+	for {
+		if conn, err = dialBrokerPipe(name); err == nil {
+			return conn, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("daemon on %s did not accept within %s: %w", name, daemonStartTimeout, err)
+		}
+		time.Sleep(daemonDialInterval)
+	}
+}
+
+// startDaemon starts a detached copy of the sidecar serving name. It asks
+// to break away from the host's job so closing the host does not take the
+// daemon along, and starts inside the job when the job does not allow that.
+func startDaemon(name string, args []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate sidecar executable: %w", err)
+	}
+
+	process, err := startDetached(executable, append(append([]string(nil), args...), "--daemon-serve", name))
+	if err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
+	}
+	return process.Release()
+}
+
+func startDetached(executable string, args []string) (*os.Process, error) {
+	var err error
+	flags := uint32(detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP)
+	for _, extra := range []uint32{createBreakawayJob, 0} {
+		cmd := exec.Command(executable, args...)
+		cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: flags | extra, HideWindow: true}
+		if err = cmd.Start(); err == nil {
+			return cmd.Process, nil
+		}
+	}
+	return nil, err
+}
+
+// upgradeProcess is the binary taking a daemon over, connected back on a
+// private handoff pipe.
+type upgradeProcess struct {
+	*handoffConn
+	process     syscall.Handle
+	releaseOnce sync.Once
+}
+
+// startUpgradeProcess starts path with the daemon's args, detached like the
+// daemon, and waits for it to connect to a new handoff pipe.
+func startUpgradeProcess(path string, args []string) (*upgradeProcess, error) {
+	name, err := brokerPipeName()
+	if err != nil {
+		return nil, fmt.Errorf("failed to name handoff pipe: %w", err)
+	}
+	pipe, err := createBrokerPipe(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create handoff pipe: %w", err)
+	}
+
+	started, err := startDetached(path, withHandoffArgs(args, name))
+	if err != nil {
+		closeHandle(pipe)
+		return nil, fmt.Errorf("failed to start %s: %w", path, err)
+	}
+	process, err := syscall.OpenProcess(upgradeProcessAccess, false, uint32(started.Pid))
+	_ = started.Release()
+	if err != nil {
+		closeHandle(pipe)
+		return nil, fmt.Errorf("failed to open new binary's process: %w", err)
+	}
+
+	if err := awaitBrokerConnection(name, pipe, process); err != nil {
+		closeHandle(pipe)
+		_ = syscall.TerminateProcess(process, terminateExitCode)
+		closeHandle(process)
+		return nil, fmt.Errorf("new binary did not connect: %w", err)
+	}
+	return &upgradeProcess{
+		handoffConn: newHandoffConn(os.NewFile(uintptr(pipe), name)),
+		process:     process,
+	}, nil
+}
+
+func (p *upgradeProcess) duplicate(handle uintptr) (uintptr, error) {
+	current, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, err
+	}
+	var duplicated syscall.Handle
+	if err := syscall.DuplicateHandle(current, syscall.Handle(handle), p.process, &duplicated, 0, false, syscall.DUPLICATE_SAME_ACCESS); err != nil {
+		return 0, fmt.Errorf("failed to duplicate handle: %w", err)
+	}
+	return uintptr(duplicated), nil
+}
+
+// exportListener duplicates the daemon pipe's pending instance.
+func (p *upgradeProcess) exportListener(listener daemonListener) (uintptr, error) {
+	pipe, ok := listener.(*daemonPipe)
+	if !ok {
+		return 0, errors.New("listener cannot be handed over")
+	}
+	return p.duplicate(uintptr(pipe.pending))
+}
+
+// exportFile duplicates the handle of file, the daemon's client.
+func (p *upgradeProcess) exportFile(file any) (uintptr, error) {
+	fd, ok := file.(interface{ Fd() uintptr })
+	if !ok {
+		return 0, errors.New("client connection cannot be handed over")
+	}
+	return p.duplicate(fd.Fd())
+}
+
+// abort stops the new binary, which never took anything over; closing its
+// duplicated handles leaves the terminals to this process.
+func (p *upgradeProcess) abort() {
+	p.releaseOnce.Do(func() {
+		_ = syscall.TerminateProcess(p.process, terminateExitCode)
+		_ = p.conn.Close()
+		closeHandle(p.process)
+	})
+}
+
+func (p *upgradeProcess) close() {
+	p.releaseOnce.Do(func() {
+		_ = p.conn.Close()
+		closeHandle(p.process)
+	})
+}
@@ -0,0 +1,1546 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// replDrainTimeout bounds how long the REPL waits for remaining events once
+// its input ends.
+const replDrainTimeout = 5 * time.Second
+
+const replHelp = `commands:
+  open ID [SHELL] [COLS ROWS]   open a terminal
+  write ID TEXT                 write text; quote it for escapes, e.g. "dir\r"
+  keys ID KEY...                send symbolic keys, e.g. enter ctrl+c
+  resize ID COLS ROWS           resize a terminal
+  close ID [force|GRACE_MS]     close a terminal
+  snapshot ID                   render an emulated terminal
+  ping | stats                  query the sidecar
+  shutdown                      close every terminal and stop the sidecar
+  {...}                         send a raw JSON request
+  help | quit`
+
+// runRepl drives a sidecar interactively: it connects to one started with
+// --listen, or spawns its own with the arguments after "--", translates
+// simplified commands into requests and prints events as they arrive.
+func runRepl(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("hapi-pty repl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	connect := flags.String("connect", "", "address of a sidecar started with --listen (spawns a sidecar when empty)")
+	tokenFile := flags.String("auth-token-file", "", "file holding the --connect auth token (default $"+authTokenEnv+")")
+	useTLS := flags.Bool("tls", false, "connect over TLS")
+	tlsCA := flags.String("tls-ca", "", "PEM CA bundle that signed the sidecar's certificate")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitCodeUsage
+	}
+
+	var conn io.ReadWriteCloser
+	var err error
+	if *connect != "" {
+		conn, err = dialRepl(*connect, *tokenFile, *useTLS, *tlsCA)
+	} else {
+		conn, err = spawnReplSidecar(flags.Args(), stderr)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer conn.Close()
+
+	var printMu sync.Mutex
+	printLine := func(line string) {
+		printMu.Lock()
+		defer printMu.Unlock()
+		fmt.Fprintln(stdout, line)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				printLine(formatReplEvent(line))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		payload, err := parseReplCommand(scanner.Text())
+		if errors.Is(err, errReplQuit) {
+			break
+		}
+		switch {
+		case errors.Is(err, errReplHelp):
+			printLine(replHelp)
+		case err != nil:
+			printLine("! " + err.Error())
+		case payload != nil:
+			if err := writeNDJSONLine(conn, payload); err != nil {
+				printLine("! " + err.Error())
+				return 1
+			}
+		}
+
+		select {
+		case <-done:
+			printLine("! sidecar closed the connection")
+			return 0
+		default:
+		}
+	}
+
+	// Ending the request stream makes the sidecar close its terminals and
+	// hang up; wait for that so events still in flight are printed.
+	if writer, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = writer.CloseWrite()
+	}
+	select {
+	case <-done:
+	case <-time.After(replDrainTimeout):
+	}
+	return 0
+}
+
+func dialRepl(addr string, tokenFile string, useTLS bool, caFile string) (io.ReadWriteCloser, error) {
+	token, err := loadAuthToken(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	if useTLS || caFile != "" {
+		config := &tls.Config{MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, err
+			}
+			config.RootCAs = x509.NewCertPool()
+			config.RootCAs.AppendCertsFromPEM(pem)
+		}
+		conn, err = tls.Dial("tcp", addr, config)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeNDJSONLine(conn, authRequest{Type: requestTypeAuth, Token: token}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// replProcess is a spawned sidecar; closing it ends its stdin, which makes
+// it close its terminals and exit.
+type replProcess struct {
+	io.Reader
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func (p *replProcess) CloseWrite() error {
+	return p.WriteCloser.Close()
+}
+
+func (p *replProcess) Close() error {
+	err := p.WriteCloser.Close()
+	_ = p.cmd.Wait()
+	return err
+}
+
+func spawnReplSidecar(args []string, stderr io.Writer) (io.ReadWriteCloser, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(self, args...)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &replProcess{Reader: stdout, WriteCloser: stdin, cmd: cmd}, nil
+}
+
+var replTerminalCommands = map[string]bool{
+	"open": true, "write": true, "keys": true, "resize": true, "close": true, "snapshot": true,
+}
+
+var (
+	errReplQuit = errors.New("quit")
+	errReplHelp = errors.New("help")
+)
+
+// parseReplCommand translates one REPL line into a request payload. Blank
+// lines yield nil.
+func parseReplCommand(line string) (any, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(line, "{") {
+		if !json.Valid([]byte(line)) {
+			return nil, errors.New("invalid JSON")
+		}
+		return json.RawMessage(line), nil
+	}
+
+	fields, err := splitReplArgs(line)
+	if err != nil {
+		return nil, err
+	}
+	command, args := fields[0], fields[1:]
+
+	switch command {
+	case "quit", "exit":
+		return nil, errReplQuit
+	case "help", "?":
+		return nil, errReplHelp
+	case "ping":
+		return pingRequest{Type: requestTypePing}, nil
+	case "stats":
+		return statsRequest{Type: requestTypeStats}, nil
+	case "shutdown":
+		return shutdownRequest{Type: requestTypeShutdown}, nil
+	}
+
+	if !replTerminalCommands[command] {
+		return nil, fmt.Errorf("unknown command %q (try help)", command)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("%s needs a terminal id (try help)", command)
+	}
+	terminalID, args := args[0], args[1:]
+
+	switch command {
+	case "open":
+		req := openRequest{Type: requestTypeOpen, TerminalID: terminalID, Cols: 120, Rows: 30}
+		if len(args) == 1 || len(args) == 3 {
+			req.Shell, args = args[0], args[1:]
+		}
+		if len(args) == 2 {
+			if req.Cols, req.Rows, err = parseReplSize(args); err != nil {
+				return nil, err
+			}
+		} else if len(args) != 0 {
+			return nil, errors.New("usage: open ID [SHELL] [COLS ROWS]")
+		}
+		return req, nil
+	case "write":
+		if len(args) != 1 {
+			return nil, errors.New(`usage: write ID TEXT (quote text with spaces, e.g. "dir\r")`)
+		}
+		return writeRequest{Type: requestTypeWrite, TerminalID: terminalID, Data: args[0]}, nil
+	case "keys":
+		if len(args) == 0 {
+			return nil, errors.New("usage: keys ID KEY...")
+		}
+		return writeRequest{Type: requestTypeWrite, TerminalID: terminalID, Keys: args}, nil
+	case "resize":
+		cols, rows, err := parseReplSize(args)
+		if err != nil {
+			return nil, err
+		}
+		return resizeRequest{Type: requestTypeResize, TerminalID: terminalID, Cols: cols, Rows: rows}, nil
+	case "close":
+		req := closeRequest{Type: requestTypeClose, TerminalID: terminalID}
+		if len(args) == 1 {
+			if args[0] == "force" {
+				req.Force = true
+			} else if req.GraceMs, err = strconv.Atoi(args[0]); err != nil {
+				return nil, errors.New("usage: close ID [force|GRACE_MS]")
+			}
+		}
+		return req, nil
+	default:
+		return snapshotRequest{Type: requestTypeSnapshot, TerminalID: terminalID}, nil
+	}
+}
+
+func parseReplSize(args []string) (int, int, error) {
+	if len(args) != 2 {
+		return 0, 0, errors.New("expected COLS ROWS")
+	}
+	cols, colsErr := strconv.Atoi(args[0])
+	rows, rowsErr := strconv.Atoi(args[1])
+	if colsErr != nil || rowsErr != nil || cols <= 0 || rows <= 0 {
+		return 0, 0, errors.New("COLS and ROWS must be positive integers")
+	}
+	return cols, rows, nil
+}
+
+// splitReplArgs splits on whitespace. Double-quoted arguments use Go escape
+// syntax, so "dir\r" ends in a carriage return.
+func splitReplArgs(line string) ([]string, error) {
+	args := make([]string, 0, 4)
+	for line = strings.TrimLeft(line, " \t"); line != ""; line = strings.TrimLeft(line, " \t") {
+		if line[0] != '"' {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			args = append(args, line[:end])
+			line = line[end:]
+			continue
+		}
+
+		quoted, err := strconv.QuotedPrefix(line)
+		if err != nil {
+			return nil, fmt.Errorf("unterminated or invalid quoted argument: %s", line)
+		}
+		unquoted, _ := strconv.Unquote(quoted)
+		args = append(args, unquoted)
+		line = line[len(quoted):]
+	}
+	return args, nil
+}
+
+// formatReplEvent renders one event line for humans: output is decoded and
+// quoted, everything else is printed as compact JSON.
+func formatReplEvent(line []byte) string {
+	var evt outputEvent
+	if err := json.Unmarshal(line, &evt); err == nil && evt.Type == eventTypeOutput {
+		if chunk, err := decodeOutputEvent(evt); err == nil {
+			return fmt.Sprintf("<- output %s %q", evt.TerminalID, chunk)
+		}
+	}
+	return "<- " + strings.TrimSpace(string(line))
+}
+
+const (
+	schemaFormatJSON = "json"
+	schemaFormatTS   = "ts"
+	// schemaFormatProto is the proto3 file --listen grpc:// clients
+	// generate their stubs from.
+	schemaFormatProto = "proto"
+)
+
+// runSchema prints definitions for every protocol message, as JSON Schema,
+// TypeScript types or protobuf messages.
+func runSchema(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("hapi-pty schema", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", schemaFormatJSON, "output format (json|ts|proto)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitCodeUsage
+	}
+
+	switch *format {
+	case schemaFormatJSON:
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(protocolJSONSchema()); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	case schemaFormatTS:
+		fmt.Fprint(stdout, protocolTypeScript())
+	case schemaFormatProto:
+		fmt.Fprint(stdout, protocolProto())
+	default:
+		fmt.Fprintf(stderr, "unsupported schema format %q\n", *format)
+		return exitCodeUsage
+	}
+	return 0
+}
+
+// schemaField is one JSON property of a protocol struct.
+type schemaField struct {
+	Name     string
+	Type     reflect.Type
+	Optional bool
+}
+
+// schemaDef is a named struct reachable from the protocol messages. Const
+// holds the literal "type" of a request or event.
+type schemaDef struct {
+	Name   string
+	Const  string
+	Fields []schemaField
+}
+
+// protocolSchemaDefs walks the registered messages and every struct they
+// reference, in a stable order: messages first, then nested types.
+func protocolSchemaDefs() (requests []string, events []string, defs []schemaDef) {
+	seen := map[reflect.Type]bool{}
+	var visit func(t reflect.Type, messageType string)
+	visit = func(t reflect.Type, messageType string) {
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+
+		def := schemaDef{Name: schemaTypeName(t), Const: messageType, Fields: schemaFields(t)}
+		defs = append(defs, def)
+		for _, field := range def.Fields {
+			if nested := schemaStructType(field.Type); nested != nil {
+				visit(nested, "")
+			}
+		}
+	}
+
+	for _, msg := range protocolRequests {
+		t := reflect.TypeOf(msg.Payload)
+		requests = append(requests, schemaTypeName(t))
+		visit(t, msg.Type)
+	}
+	for _, msg := range protocolEvents {
+		t := reflect.TypeOf(msg.Payload)
+		events = append(events, schemaTypeName(t))
+		visit(t, msg.Type)
+	}
+
+	// Keep messages in registry order but move nested types after them.
+	sort.SliceStable(defs, func(i, j int) bool { return defs[i].Const != "" && defs[j].Const == "" })
+	return requests, events, defs
+}
+
+// schemaFields flattens embedded structs the way encoding/json does.
+func schemaFields(t reflect.Type) []schemaField {
+	fields := make([]schemaField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, schemaFields(field.Type)...)
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, schemaField{
+			Name:     name,
+			Type:     field.Type,
+			Optional: strings.Contains(options, "omitempty"),
+		})
+	}
+	return fields
+}
+
+// schemaTypeName exports a Go type name, e.g. openRequest -> OpenRequest.
+func schemaTypeName(t reflect.Type) string {
+	name := t.Name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+func schemaStructType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+		if t == rawMessageType {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
+
+// protocolJSONSchema describes every request and event as JSON Schema.
+func protocolJSONSchema() map[string]any {
+	requests, events, defs := protocolSchemaDefs()
+
+	definitions := map[string]any{}
+	for _, def := range defs {
+		properties := map[string]any{}
+		required := make([]string, 0, len(def.Fields))
+		for _, field := range def.Fields {
+			if field.Name == "type" && def.Const != "" {
+				properties[field.Name] = map[string]any{"const": def.Const}
+			} else {
+				properties[field.Name] = jsonSchemaType(field.Type)
+			}
+			if !field.Optional {
+				required = append(required, field.Name)
+			}
+		}
+		definitions[def.Name] = map[string]any{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+
+	refs := func(names []string) []any {
+		oneOf := make([]any, 0, len(names))
+		for _, name := range names {
+			oneOf = append(oneOf, map[string]any{"$ref": "#/$defs/" + name})
+		}
+		return oneOf
+	}
+	definitions["SidecarRequest"] = map[string]any{"oneOf": refs(requests)}
+	definitions["SidecarEvent"] = map[string]any{"oneOf": refs(events)}
+
+	return map[string]any{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"title":    "hapi-pty protocol",
+		"protocol": protocolVersion,
+		"$defs":    definitions,
+	}
+}
+
+func jsonSchemaType(t reflect.Type) map[string]any {
+	if t == rawMessageType {
+		return map[string]any{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonSchemaType(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": jsonSchemaType(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": jsonSchemaType(t.Elem())}
+	case reflect.Struct:
+		return map[string]any{"$ref": "#/$defs/" + schemaTypeName(t)}
+	default:
+		return map[string]any{}
+	}
+}
+
+// protocolTypeScript renders the protocol as TypeScript type aliases in the
+// style of the host's own sources.
+func protocolTypeScript() string {
+	requests, events, defs := protocolSchemaDefs()
+
+	var builder strings.Builder
+	builder.WriteString("// Code generated by `hapi-pty schema --format ts`. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&builder, "export const SIDECAR_PROTOCOL_VERSION = %d\n", protocolVersion)
+	for _, def := range defs {
+		fmt.Fprintf(&builder, "\nexport type %s = {\n", def.Name)
+		for _, field := range def.Fields {
+			optional := ""
+			if field.Optional {
+				optional = "?"
+			}
+			fieldType := typeScriptType(field.Type)
+			if field.Name == "type" && def.Const != "" {
+				fieldType = "'" + def.Const + "'"
+			}
+			fmt.Fprintf(&builder, "    %s%s: %s\n", field.Name, optional, fieldType)
+		}
+		builder.WriteString("}\n")
+	}
+
+	fmt.Fprintf(&builder, "\nexport type SidecarRequest =\n    | %s\n", strings.Join(requests, "\n    | "))
+	fmt.Fprintf(&builder, "\nexport type SidecarEvent =\n    | %s\n", strings.Join(events, "\n    | "))
+	return builder.String()
+}
+
+func typeScriptType(t reflect.Type) string {
+	if t == rawMessageType {
+		return "unknown"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeScriptType(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return typeScriptType(t.Elem()) + "[]"
+	case reflect.Map:
+		return "Record<string, " + typeScriptType(t.Elem()) + ">"
+	case reflect.Struct:
+		return schemaTypeName(t)
+	default:
+		return "unknown"
+	}
+}
+
+// protocolProto renders the protocol as a proto3 file. Requests and events
+// are set in the Request and Event envelopes, whose field says the type, and
+// the Terminal service carries them on a Session stream or, for the
+// management requests in grpcUnaryMethods, as unary calls. Field numbers
+// come from protoFieldNumber. The output is checked in as
+// ptypb/terminal.proto, the source of the code serveGRPC is built on.
+func protocolProto() string {
+	_, _, defs := protocolSchemaDefs()
+
+	var builder strings.Builder
+	builder.WriteString("// Code generated by `hapi-pty schema --format proto`. DO NOT EDIT.\n\n")
+	builder.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&builder, "package %s;\n\n", grpcProtoPackage())
+	fmt.Fprintf(&builder, "option go_package = %q;\n\n", grpcGoPackage)
+
+	// Requests and events may share a type, e.g. list.
+	requestNames, eventNames := map[string]string{}, map[string]string{}
+	for _, msg := range protocolRequests {
+		requestNames[msg.Type] = schemaTypeName(reflect.TypeOf(msg.Payload))
+	}
+	for _, msg := range protocolEvents {
+		eventNames[msg.Type] = schemaTypeName(reflect.TypeOf(msg.Payload))
+	}
+	fmt.Fprintf(&builder, "service %s {\n", grpcServiceName)
+	builder.WriteString("  rpc Session(stream Request) returns (stream Event);\n")
+	for _, method := range grpcUnaryMethods {
+		fmt.Fprintf(&builder, "  rpc %s(%s) returns (%s);\n", method.Name, requestNames[method.Request], eventNames[method.Event])
+	}
+	builder.WriteString("}\n")
+
+	envelope := func(name string, oneof string, messages []protocolMessage, messageNames map[string]string) {
+		fmt.Fprintf(&builder, "\nmessage %s {\n  oneof %s {\n", name, oneof)
+		for _, msg := range messages {
+			fmt.Fprintf(&builder, "    %s %s = %d;\n", messageNames[msg.Type], protoFieldName(msg.Type), protoFieldNumber(msg.Type))
+		}
+		builder.WriteString("  }\n}\n")
+	}
+	envelope("Request", "request", protocolRequests, requestNames)
+	envelope("Event", "event", protocolEvents, eventNames)
+
+	// A map of lists needs a message holding each list.
+	lists := map[string]reflect.Type{}
+	for _, def := range defs {
+		fmt.Fprintf(&builder, "\nmessage %s {\n", def.Name)
+		for _, field := range def.Fields {
+			if field.Name == "type" && def.Const != "" {
+				continue
+			}
+			label := ""
+			if field.Type.Kind() == reflect.Pointer && schemaStructType(field.Type) == nil {
+				label = "optional "
+			}
+			name := protoFieldName(field.Name)
+			option := ""
+			if protoJSONName(name) != field.Name {
+				option = fmt.Sprintf(" [json_name = %q]", field.Name)
+			}
+			fmt.Fprintf(&builder, "  %s%s %s = %d%s;\n", label, protoType(field.Type), name, protoFieldNumber(field.Name), option)
+			t := field.Type
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			if t.Kind() == reflect.Map && t.Elem().Kind() == reflect.Slice && t.Elem() != rawMessageType {
+				lists[protoListName(t.Elem())] = t.Elem()
+			}
+		}
+		builder.WriteString("}\n")
+	}
+	names := make([]string, 0, len(lists))
+	for name := range lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&builder, "\nmessage %s {\n  %s %s = 1;\n}\n", name, protoType(lists[name]), protoListField)
+	}
+	return builder.String()
+}
+
+// protoListName names the message holding a list of t's elements, e.g.
+// MockStepList.
+func protoListName(t reflect.Type) string {
+	name := protoType(t.Elem())
+	return strings.ToUpper(name[:1]) + name[1:] + "List"
+}
+
+func protoType(t reflect.Type) string {
+	if t == rawMessageType {
+		return "string"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return protoType(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int64"
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "uint32"
+	case reflect.Uint, reflect.Uint64:
+		return "uint64"
+	case reflect.Float32, reflect.Float64:
+		return "double"
+	case reflect.Slice, reflect.Array:
+		return "repeated " + protoType(t.Elem())
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Slice && t.Elem() != rawMessageType {
+			return "map<string, " + protoListName(t.Elem()) + ">"
+		}
+		return "map<string, " + protoType(t.Elem()) + ">"
+	case reflect.Struct:
+		return schemaTypeName(t)
+	default:
+		return "bytes"
+	}
+}
+
+const (
+	// Protobuf field numbers are derived from JSON names, see
+	// protoFieldNumber, above the range protobuf reserves for itself.
+	protoMinFieldNumber = 20000
+	protoMaxFieldNumber = 1<<29 - 1
+	// protoListField is the field of the messages protocolProto adds to
+	// hold the lists of a map, see protoListName.
+	protoListField = "items"
+)
+
+// protoFieldNumber derives a field number from a JSON name or message type,
+// so adding or reordering struct fields never renumbers the ones clients
+// were compiled against.
+func protoFieldNumber(name string) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	return protoMinFieldNumber + int(hash.Sum32()%(protoMaxFieldNumber-protoMinFieldNumber+1))
+}
+
+// protoFieldName turns a JSON name or message type into protobuf's snake
+// case, e.g. terminalId -> terminal_id and kill-process -> kill_process.
+func protoFieldName(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if r == '-' {
+			r = '_'
+		}
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				builder.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// protoJSONName is the JSON name protoc gives a field, e.g. terminal_id ->
+// terminalId.
+func protoJSONName(name string) string {
+	var builder strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// sidecarCommit can be set at build time with
+// -ldflags "-X main.sidecarCommit=<sha>"; otherwise the VCS stamp Go
+// embeds when building from a checkout is used.
+var sidecarCommit = ""
+
+type buildInfo struct {
+	Version   string
+	Protocol  int
+	Commit    string
+	Modified  bool
+	GoVersion string
+	Target    string
+}
+
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   sidecarVersion,
+		Protocol:  protocolVersion,
+		Commit:    sidecarCommit,
+		GoVersion: runtime.Version(),
+		Target:    runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok && info.Commit == "" {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+func formatVersion(info buildInfo) string {
+	commit := info.Commit
+	if commit == "" {
+		commit = "unknown"
+	} else if info.Modified {
+		commit += " (modified)"
+	}
+	return fmt.Sprintf("hapi-pty %s\nprotocol: %d\ncommit: %s\ngo: %s\ntarget: %s\n",
+		info.Version, info.Protocol, commit, info.GoVersion, info.Target)
+}
+
+const (
+	traceDirIn  = "in"
+	traceDirOut = "out"
+)
+
+// traceRecord is one line of a --trace-file: a request as it was read or an
+// event as it was written, stamped with milliseconds since tracing started.
+type traceRecord struct {
+	Ms    int64           `json:"ms"`
+	Dir   string          `json:"dir"`
+	Frame json.RawMessage `json:"frame"`
+}
+
+// traceRecorder appends traceRecords to a file. A nil recorder records
+// nothing, so call sites need no checks.
+type traceRecorder struct {
+	mu           sync.Mutex
+	writer       io.Writer
+	start        time.Time
+	redactWrites bool
+}
+
+func openTraceRecorder(path string, redactWrites bool) (*traceRecorder, io.Closer, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newTraceRecorder(file, redactWrites), file, nil
+}
+
+func newTraceRecorder(writer io.Writer, redactWrites bool) *traceRecorder {
+	return &traceRecorder{writer: writer, start: time.Now(), redactWrites: redactWrites}
+}
+
+// Inbound records a request line before it is decoded, so malformed requests
+// are kept too; lines that are not JSON are recorded as a JSON string.
+func (r *traceRecorder) Inbound(line []byte) {
+	if r == nil {
+		return
+	}
+
+	frame := json.RawMessage(line)
+	if !json.Valid(line) {
+		frame, _ = json.Marshal(string(line))
+	} else if r.redactWrites {
+		frame = redactTraceWrite(frame)
+	}
+	r.record(traceDirIn, frame)
+}
+
+// Outbound records an event; msgpack sessions are traced as JSON as well.
+func (r *traceRecorder) Outbound(payload any) {
+	if r == nil {
+		return
+	}
+
+	frame, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	r.record(traceDirOut, frame)
+}
+
+func (r *traceRecorder) record(dir string, frame json.RawMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = writeNDJSONLine(r.writer, traceRecord{
+		Ms:    time.Since(r.start).Milliseconds(),
+		Dir:   dir,
+		Frame: frame,
+	})
+}
+
+// redactTraceWrite blanks the data of a write request and keeps only its
+// length, which is enough to follow a session without recording what was typed.
+func redactTraceWrite(frame json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(frame, &fields); err != nil {
+		return frame
+	}
+	var kind, data string
+	if json.Unmarshal(fields["type"], &kind) != nil || kind != requestTypeWrite {
+		return frame
+	}
+	if json.Unmarshal(fields["data"], &data) != nil || data == "" {
+		return frame
+	}
+
+	fields["data"] = json.RawMessage(`""`)
+	fields["redactedBytes"], _ = json.Marshal(len(data))
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return frame
+	}
+	return redacted
+}
+
+func readTraceFile(path string) ([]traceRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []traceRecord
+	decoder := json.NewDecoder(file)
+	for {
+		var rec traceRecord
+		if err := decoder.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return nil, fmt.Errorf("%s: record %d: %w", path, len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// runReplay feeds the requests of a --trace-file back through the sidecar
+// with their recorded timing. Terminals are served by a backend that plays
+// back the output and exits recorded for them, so a trace replays on any
+// machine; the events emitted now are printed as NDJSON for diffing against
+// the recorded ones.
+func runReplay(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("hapi-pty replay", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	speed := flags.Float64("speed", 1, "timing multiplier: 2 replays twice as fast, 0 without delays")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitCodeUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: hapi-pty replay [--speed N] TRACE_FILE")
+		return exitCodeUsage
+	}
+	if *speed < 0 {
+		fmt.Fprintln(stderr, "--speed must not be negative")
+		return exitCodeUsage
+	}
+
+	records, err := readTraceFile(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return replayTrace(records, *speed, stdout)
+}
+
+func replayTrace(records []traceRecord, speed float64, stdout io.Writer) int {
+	backend := newReplayBackend(records, speed)
+	requests, feed := io.Pipe()
+	stopped := make(chan struct{})
+
+	go func() {
+		defer feed.Close()
+		start := time.Now()
+		// Waiting on every record, not just requests, keeps the stream open
+		// until the last recorded event is due.
+		for _, rec := range records {
+			if !replayWait(start, rec.Ms, speed, stopped) {
+				return
+			}
+			if rec.Dir != traceDirIn {
+				continue
+			}
+			if _, err := feed.Write(append(replayRequestLine(rec.Frame), '\n')); err != nil {
+				return
+			}
+		}
+	}()
+
+	exitCode := runSidecar(requests, stdout, runConfig{
+		IdleTimeout:    time.Duration(math.MaxInt64),
+		LookPath:       func(file string) (string, error) { return file, nil },
+		ProbeConPTY:    func() error { return nil },
+		TerminalOpener: backend.Open,
+	})
+	close(stopped)
+	_ = requests.Close()
+	backend.Stop()
+	return exitCode
+}
+
+// replayWait sleeps until the record at ms is due and reports false if
+// stopped first. A speed of zero never sleeps.
+func replayWait(start time.Time, ms int64, speed float64, stopped <-chan struct{}) bool {
+	var delay time.Duration
+	if speed > 0 {
+		delay = time.Until(start.Add(time.Duration(float64(ms) * float64(time.Millisecond) / speed)))
+	}
+	if delay <= 0 {
+		select {
+		case <-stopped:
+			return false
+		default:
+			return true
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-stopped:
+		return false
+	}
+}
+
+// replayRequestLine turns a recorded request back into a request line.
+// Malformed lines were recorded as JSON strings and are sent unchanged; cwd is
+// dropped from open requests because the recorded directory rarely exists on
+// the replaying machine.
+func replayRequestLine(frame json.RawMessage) []byte {
+	var raw string
+	if json.Unmarshal(frame, &raw) == nil {
+		return []byte(raw)
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(frame, &fields) != nil || string(fields["type"]) != `"`+requestTypeOpen+`"` {
+		return frame
+	}
+	delete(fields, "cwd")
+	line, err := json.Marshal(fields)
+	if err != nil {
+		return frame
+	}
+	return line
+}
+
+// replayStep is one recorded output chunk or exit, due ms after its terminal
+// was opened.
+type replayStep struct {
+	ms     int64
+	output []byte
+	exit   *int
+}
+
+// replayScript is everything recorded for one open of a terminal id.
+type replayScript struct {
+	openedMs int64
+	ready    bool
+	failure  *sidecarError
+	steps    []replayStep
+}
+
+// replayBackend is a terminalFactory serving recorded sessions. Each open of
+// a terminal id consumes the next script recorded for that id.
+type replayBackend struct {
+	speed   float64
+	stopped chan struct{}
+	players sync.WaitGroup
+
+	mu      sync.Mutex
+	scripts map[string][]*replayScript
+}
+
+func newReplayBackend(records []traceRecord, speed float64) *replayBackend {
+	b := &replayBackend{
+		speed:   speed,
+		stopped: make(chan struct{}),
+		scripts: map[string][]*replayScript{},
+	}
+
+	current := map[string]*replayScript{}
+	for _, rec := range records {
+		var frame struct {
+			outputEvent
+			Code    any    `json:"code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(rec.Frame, &frame) != nil || frame.TerminalID == "" {
+			continue
+		}
+
+		if rec.Dir == traceDirIn {
+			if frame.Type == requestTypeOpen {
+				script := &replayScript{openedMs: rec.Ms}
+				current[frame.TerminalID] = script
+				b.scripts[frame.TerminalID] = append(b.scripts[frame.TerminalID], script)
+			}
+			continue
+		}
+
+		script := current[frame.TerminalID]
+		if script == nil {
+			continue
+		}
+		switch frame.Type {
+		case eventTypeReady:
+			script.ready = true
+		case eventTypeError:
+			code, _ := frame.Code.(string)
+			if !script.ready && script.failure == nil && code != "" {
+				script.failure = newSidecarError(code, "%s", frame.Message)
+			}
+		case eventTypeOutput:
+			chunk, err := decodeOutputEvent(frame.outputEvent)
+			if err == nil && len(chunk) > 0 {
+				script.steps = append(script.steps, replayStep{ms: rec.Ms - script.openedMs, output: chunk})
+			}
+		case eventTypeExit:
+			code, _ := frame.Code.(float64)
+			exitCode := int(code)
+			script.steps = append(script.steps, replayStep{ms: rec.Ms - script.openedMs, exit: &exitCode})
+			delete(current, frame.TerminalID)
+		}
+	}
+	return b
+}
+
+func (b *replayBackend) Open(
+	req openRequest,
+	_ resolvedShell,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	b.mu.Lock()
+	queue := b.scripts[req.TerminalID]
+	if len(queue) == 0 {
+		b.mu.Unlock()
+		return nil, newSidecarError(errorCodeSpawnFailed, "trace has no recording for terminal %s", req.TerminalID)
+	}
+	script := queue[0]
+	b.scripts[req.TerminalID] = queue[1:]
+	b.mu.Unlock()
+
+	if script.failure != nil {
+		return nil, script.failure
+	}
+
+	session := &replaySession{closed: make(chan struct{})}
+	b.players.Add(1)
+	runIsolated(req.TerminalID, func() {
+		defer b.players.Done()
+		session.play(script.steps, b.speed, b.stopped, callbacks)
+	})
+	return session, nil
+}
+
+// Stop plays every remaining step without delay and waits for the players,
+// so no callback fires after the sidecar has returned.
+func (b *replayBackend) Stop() {
+	close(b.stopped)
+	b.players.Wait()
+}
+
+// replaySession plays a script in place of a shell. Input is discarded;
+// closing it plays the rest of the script at once, like a shell whose
+// pending output drains as it exits.
+type replaySession struct {
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *replaySession) play(steps []replayStep, speed float64, stopped <-chan struct{}, callbacks terminalCallbacks) {
+	start := time.Now()
+	hurry := make(chan struct{})
+	go func() {
+		select {
+		case <-s.closed:
+		case <-stopped:
+		}
+		close(hurry)
+	}()
+
+	for _, step := range steps {
+		replayWait(start, step.ms, speed, hurry)
+		if step.exit != nil {
+			callbacks.Exit(*step.exit)
+			return
+		}
+		if callbacks.Output != nil {
+			callbacks.Output(step.output)
+		}
+	}
+
+	// The trace ended before this terminal exited.
+	<-hurry
+	callbacks.Exit(-1)
+}
+
+func (s *replaySession) Write(string) error { return nil }
+
+func (s *replaySession) Resize(int, int) error { return nil }
+
+func (s *replaySession) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+// chaosDropExitCode is reported for terminals dropped by --chaos.
+const chaosDropExitCode = -1
+
+// chaosConfig injects faults for --chaos so hosts can exercise their
+// reconnect and error handling against a misbehaving sidecar. A nil config
+// injects nothing.
+type chaosConfig struct {
+	// OutputLatency and WriteDelay bound a random delay added before each
+	// output chunk is emitted and before each write reaches the terminal.
+	OutputLatency time.Duration
+	WriteDelay    time.Duration
+	// DropRate is the chance that a terminal is dropped, checked once per
+	// dropInterval; a dropped terminal exits with chaosDropExitCode.
+	DropRate     float64
+	dropInterval time.Duration
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// parseChaos reads a spec such as "latency=50ms,write-delay=20ms,drop=0.05,seed=1".
+func parseChaos(spec string) (*chaosConfig, error) {
+	c := &chaosConfig{dropInterval: time.Second}
+	seed := time.Now().UnixNano()
+	for _, item := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --chaos setting %q, want key=value", item)
+		}
+
+		var err error
+		switch key {
+		case "latency":
+			c.OutputLatency, err = time.ParseDuration(value)
+		case "write-delay":
+			c.WriteDelay, err = time.ParseDuration(value)
+		case "drop":
+			c.DropRate, err = strconv.ParseFloat(value, 64)
+			if err == nil && (c.DropRate < 0 || c.DropRate > 1) {
+				err = errors.New("must be between 0 and 1")
+			}
+		case "seed":
+			seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			return nil, fmt.Errorf("unknown --chaos setting %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid --chaos %s: %v", key, err)
+		}
+	}
+	if c.OutputLatency < 0 || c.WriteDelay < 0 {
+		return nil, errors.New("--chaos delays must not be negative")
+	}
+
+	c.rng = rand.New(rand.NewSource(seed))
+	return c, nil
+}
+
+func (c *chaosConfig) delay(limit time.Duration) {
+	if limit <= 0 {
+		return
+	}
+	c.mu.Lock()
+	d := time.Duration(c.rng.Int63n(int64(limit) + 1))
+	c.mu.Unlock()
+	time.Sleep(d)
+}
+
+func (c *chaosConfig) chance(p float64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < p
+}
+
+// Wrap returns a terminalFactory whose terminals misbehave as configured.
+func (c *chaosConfig) Wrap(open terminalFactory) terminalFactory {
+	if c == nil {
+		return open
+	}
+
+	return func(
+		req openRequest,
+		shell resolvedShell,
+		callbacks terminalCallbacks,
+		runIsolated func(terminalID string, task func()),
+	) (terminalSession, error) {
+		exited := make(chan struct{})
+		var exitOnce sync.Once
+		exit := func(code int) {
+			exitOnce.Do(func() {
+				close(exited)
+				callbacks.Exit(code)
+			})
+		}
+
+		session, err := open(req, shell, terminalCallbacks{
+			Output: func(chunk []byte) {
+				c.delay(c.OutputLatency)
+				if callbacks.Output != nil {
+					callbacks.Output(chunk)
+				}
+			},
+			Exit:       exit,
+			ExitReason: callbacks.ExitReason,
+		}, runIsolated)
+		if err != nil {
+			return nil, err
+		}
+
+		if c.DropRate > 0 {
+			runIsolated(req.TerminalID, func() { c.dropLater(session, exited, callbacks.ExitReason, exit) })
+		}
+
+		chaotic := &chaosSession{terminalSession: session, chaos: c}
+		if hangup, ok := session.(hangupSession); ok {
+			return &chaosHangupSession{chaosSession: chaotic, hangup: hangup}, nil
+		}
+		return chaotic, nil
+	}
+}
+
+func (c *chaosConfig) dropLater(session terminalSession, exited <-chan struct{}, exitReason func(string), exit func(int)) {
+	ticker := time.NewTicker(c.dropInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-exited:
+			return
+		case <-ticker.C:
+			if c.chance(c.DropRate) {
+				_ = session.Close()
+				if exitReason != nil {
+					exitReason(exitReasonConPTYFailure)
+				}
+				exit(chaosDropExitCode)
+				return
+			}
+		}
+	}
+}
+
+type chaosSession struct {
+	terminalSession
+	chaos *chaosConfig
+}
+
+func (s *chaosSession) Write(data string) error {
+	s.chaos.delay(s.chaos.WriteDelay)
+	return s.terminalSession.Write(data)
+}
+
+func (s *chaosSession) KillProcess(pid int) error {
+	return killTerminalProcess(s.terminalSession, pid)
+}
+
+// chaosHangupSession keeps Hangup visible for sessions that support it, so
+// graceful closes behave as without --chaos.
+type chaosHangupSession struct {
+	*chaosSession
+	hangup hangupSession
+}
+
+func (s *chaosHangupSession) Hangup() error {
+	return s.hangup.Hangup()
+}
+
+// debugRegistry tracks the running sidecars for the --debug-addr endpoints.
+// A nil registry tracks nothing.
+type debugRegistry struct {
+	mu       sync.Mutex
+	sidecars map[*sidecar]struct{}
+}
+
+// Track registers s until the returned function is called.
+func (r *debugRegistry) Track(s *sidecar) func() {
+	if r == nil {
+		return func() {}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sidecars == nil {
+		r.sidecars = map[*sidecar]struct{}{}
+	}
+	r.sidecars[s] = struct{}{}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.sidecars, s)
+	}
+}
+
+type debugSidecar struct {
+	Client   string `json:"client"`
+	UptimeMs int64  `json:"uptimeMs"`
+	// RegistryLocked is set when the terminal registry could not be read
+	// because another goroutine holds it, itself a sign of a hang.
+	RegistryLocked bool            `json:"registryLocked,omitempty"`
+	Terminals      []debugTerminal `json:"terminals"`
+}
+
+type debugTerminal struct {
+	ID                string `json:"id"`
+	Emulated          bool   `json:"emulated"`
+	Exited            bool   `json:"exited"`
+	QueuedWriteBytes  int    `json:"queuedWriteBytes"`
+	QueuedOutputBytes int    `json:"queuedOutputBytes"`
+}
+
+// Snapshot describes every tracked sidecar without waiting on a registry
+// that is held elsewhere.
+func (r *debugRegistry) Snapshot() []debugSidecar {
+	r.mu.Lock()
+	tracked := make([]*sidecar, 0, len(r.sidecars))
+	for s := range r.sidecars {
+		tracked = append(tracked, s)
+	}
+	r.mu.Unlock()
+
+	snapshot := make([]debugSidecar, 0, len(tracked))
+	for _, s := range tracked {
+		client := s.cfg.Peer
+		if client == "" {
+			client = auditClientStdio
+		}
+		described := debugSidecar{
+			Client:    client,
+			UptimeMs:  time.Since(s.startedAt).Milliseconds(),
+			Terminals: []debugTerminal{},
+		}
+
+		var entries []*terminalEntry
+		if s.mu.TryLock() {
+			for _, entry := range s.terminals {
+				entries = append(entries, entry)
+			}
+			s.mu.Unlock()
+		} else {
+			described.RegistryLocked = true
+		}
+		for _, entry := range entries {
+			terminal := debugTerminal{
+				ID:                entry.id,
+				Emulated:          entry.screen != nil,
+				QueuedOutputBytes: entry.output.QueuedBytes(),
+			}
+			if entry.input != nil {
+				terminal.QueuedWriteBytes = entry.input.QueuedBytes()
+			}
+			select {
+			case <-entry.exited:
+				terminal.Exited = true
+			default:
+			}
+			described.Terminals = append(described.Terminals, terminal)
+		}
+		sort.Slice(described.Terminals, func(i, j int) bool {
+			return described.Terminals[i].ID < described.Terminals[j].ID
+		})
+		snapshot = append(snapshot, described)
+	}
+	return snapshot
+}
+
+// startDebugServer serves net/http/pprof under /debug/pprof/ plus
+// /debug/hapi/goroutines and /debug/hapi/terminals. With a token, as when
+// --listen is enabled, requests must carry it as a bearer token.
+func startDebugServer(addr string, token string, registry *debugRegistry) (io.Closer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &http.Server{
+		Handler:           debugHandler(token, registry),
+		ReadHeaderTimeout: authHandshakeTimeout,
+	}
+	go func() { _ = server.Serve(listener) }()
+	return server, nil
+}
+
+func debugHandler(token string, registry *debugRegistry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/hapi/goroutines", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(allGoroutineStacks())
+	})
+	mux.HandleFunc("/debug/hapi/terminals", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(registry.Snapshot())
+	})
+
+	if token == "" {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
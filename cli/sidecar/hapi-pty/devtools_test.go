@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/tiann/hapi/cli/sidecar/hapi-pty/ptypb"
+)
+
+func TestSplitReplArgs(t *testing.T) {
+	args, err := splitReplArgs(`write  t1 "dir \"C:\\\"\r"`)
+	if err != nil {
+		t.Fatalf("splitReplArgs failed: %v", err)
+	}
+	if want := []string{"write", "t1", "dir \"C:\\\"\r"}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("unexpected args %q", args)
+	}
+
+	if _, err := splitReplArgs(`write t1 "open`); err == nil {
+		t.Fatal("expected unterminated quote error")
+	}
+}
+
+func TestParseReplCommand(t *testing.T) {
+	cases := map[string]any{
+		`open t1`:            openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 120, Rows: 30},
+		`open t1 pwsh 80 24`: openRequest{Type: requestTypeOpen, TerminalID: "t1", Shell: "pwsh", Cols: 80, Rows: 24},
+		`write t1 "dir\r"`:   writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "dir\r"},
+		`keys t1 ctrl+c`:     writeRequest{Type: requestTypeWrite, TerminalID: "t1", Keys: []string{"ctrl+c"}},
+		`resize t1 100 40`:   resizeRequest{Type: requestTypeResize, TerminalID: "t1", Cols: 100, Rows: 40},
+		`close t1 force`:     closeRequest{Type: requestTypeClose, TerminalID: "t1", Force: true},
+		`close t1 500`:       closeRequest{Type: requestTypeClose, TerminalID: "t1", GraceMs: 500},
+		`ping`:               pingRequest{Type: requestTypePing},
+		`{"type":"stats"}`:   json.RawMessage(`{"type":"stats"}`),
+		``:                   nil,
+	}
+	for line, want := range cases {
+		got, err := parseReplCommand(line)
+		if err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%q: got %#v, want %#v", line, got, want)
+		}
+	}
+
+	for _, line := range []string{`open`, `resize t1 0 1`, `write t1`, `bogus t1`, `{"type":`} {
+		if _, err := parseReplCommand(line); err == nil {
+			t.Fatalf("%q: expected an error", line)
+		}
+	}
+}
+
+func TestFormatReplEventDecodesOutput(t *testing.T) {
+	line := formatReplEvent([]byte(`{"type":"output","terminalId":"t1","data":"UFM+IA0K"}`))
+	if line != `<- output t1 "PS> \r\n"` {
+		t.Fatalf("unexpected output rendering: %s", line)
+	}
+	if line := formatReplEvent([]byte(`{"type":"pong"}` + "\n")); line != `<- {"type":"pong"}` {
+		t.Fatalf("unexpected event rendering: %s", line)
+	}
+}
+
+func TestRunReplDrivesListeningSidecar(t *testing.T) {
+	t.Setenv(authTokenEnv, "secret")
+	addr, done := startTestListener(t, "secret")
+
+	stdout := &syncBuffer{}
+	exitCode := runRepl([]string{"--connect", addr}, strings.NewReader("ping\nshutdown\n"), stdout, io.Discard)
+	if exitCode != 0 {
+		t.Fatalf("repl exited with %d", exitCode)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("sidecar did not shut down")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(stdout.Snapshot().String(), `"type":"pong"`) && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if output := stdout.Snapshot().String(); !strings.Contains(output, `<- {"type":"hello"`) || !strings.Contains(output, `"type":"pong"`) {
+		t.Fatalf("repl should print events, got:\n%s", output)
+	}
+}
+
+func TestProtocolRequestsMatchDecoder(t *testing.T) {
+	for _, msg := range protocolRequests {
+		if msg.Type == requestTypeAuth {
+			continue
+		}
+		decoded, err := decodeRequestLine([]byte(`{"type":"` + msg.Type + `"}`))
+		if err != nil {
+			t.Fatalf("%s: %v", msg.Type, err)
+		}
+		if reflect.TypeOf(decoded) != reflect.TypeOf(msg.Payload) {
+			t.Fatalf("%s decodes to %T but is registered as %T", msg.Type, decoded, msg.Payload)
+		}
+	}
+}
+
+func TestProtocolJSONSchemaDescribesMessages(t *testing.T) {
+	defs := protocolJSONSchema()["$defs"].(map[string]any)
+
+	open := defs["OpenRequest"].(map[string]any)
+	properties := open["properties"].(map[string]any)
+	if properties["type"].(map[string]any)["const"] != requestTypeOpen {
+		t.Fatalf("open request type should be a const: %#v", properties["type"])
+	}
+	if properties["env"].(map[string]any)["additionalProperties"].(map[string]any)["type"] != "string" {
+		t.Fatalf("env should be a string map: %#v", properties["env"])
+	}
+	required := open["required"].([]string)
+	if !reflect.DeepEqual(required[:2], []string{"type", "cwd"}) {
+		t.Fatalf("unexpected required fields: %v", required)
+	}
+
+	if _, ok := defs["SnapshotLine"]; !ok {
+		t.Fatal("nested snapshot types should be defined")
+	}
+	events := defs["SidecarEvent"].(map[string]any)["oneOf"].([]any)
+	if len(events) != len(protocolEvents) {
+		t.Fatalf("expected %d events, got %d", len(protocolEvents), len(events))
+	}
+	if _, err := json.Marshal(protocolJSONSchema()); err != nil {
+		t.Fatalf("schema should encode: %v", err)
+	}
+}
+
+func TestProtocolTypeScriptFlattensEmbeddedFields(t *testing.T) {
+	ts := protocolTypeScript()
+
+	closed := "export type ClosedEvent = {\n    type: 'closed'\n    terminalId: string\n    method: string\n    exitCode?: number\n    reason?: string\n    seq?: number\n}\n"
+	if !strings.Contains(ts, closed) {
+		t.Fatalf("closed event should flatten its close result:\n%s", ts)
+	}
+	for _, fragment := range []string{
+		"export type SnapshotEvent = {",
+		"    lines: SnapshotLine[]\n",
+		"    | OpenRequest\n",
+		"    | HeartbeatEvent\n",
+	} {
+		if !strings.Contains(ts, fragment) {
+			t.Fatalf("typescript output lacks %q", fragment)
+		}
+	}
+}
+
+func TestProtocolProtoMatchesGeneratedCode(t *testing.T) {
+	proto := protocolProto()
+	generated, err := os.ReadFile(filepath.Join("ptypb", "terminal.proto"))
+	if err != nil {
+		t.Fatalf("read ptypb/terminal.proto: %v", err)
+	}
+	if string(generated) != proto {
+		t.Fatal("ptypb/terminal.proto is out of date, run go generate")
+	}
+
+	requests := (&ptypb.Request{}).ProtoReflect().Descriptor().Fields()
+	for _, msg := range protocolRequests {
+		if field := requests.ByNumber(protoreflect.FieldNumber(protoFieldNumber(msg.Type))); field == nil || string(field.Name()) != protoFieldName(msg.Type) {
+			t.Fatalf("the Request envelope lacks %s", msg.Type)
+		}
+	}
+	events := (&ptypb.Event{}).ProtoReflect().Descriptor().Fields()
+	for _, msg := range protocolEvents {
+		if field := events.ByNumber(protoreflect.FieldNumber(protoFieldNumber(msg.Type))); field == nil || string(field.Name()) != protoFieldName(msg.Type) {
+			t.Fatalf("the Event envelope lacks %s", msg.Type)
+		}
+	}
+
+	for _, fragment := range []string{
+		"package " + grpcProtoPackage() + ";\n",
+		"  rpc Session(stream Request) returns (stream Event);\n",
+		"  rpc List(ListRequest) returns (ListEvent);\n",
+		"    OpenRequest open = ",
+		"    KillProcessRequest kill_process = ",
+		"  string terminal_id = ",
+		"  map<string, string> env = ",
+		"  map<string, MockStepList> replies = ",
+		"  repeated SnapshotLine lines = ",
+	} {
+		if !strings.Contains(proto, fragment) {
+			t.Fatalf("proto output lacks %q:\n%s", fragment, proto)
+		}
+	}
+}
+
+func TestTraceRecordsRequestsAndEvents(t *testing.T) {
+	trace := &syncBuffer{}
+	stdin := strings.NewReader(strings.Join([]string{
+		`{"type":"open","terminalId":"t1","cols":80,"rows":24}`,
+		`{"type":"write","terminalId":"t1","data":"hunter2"}`,
+		`not json`,
+	}, "\n") + "\n")
+
+	runSidecar(stdin, &syncBuffer{}, runConfig{
+		Trace:       newTraceRecorder(trace, true),
+		LookPath:    fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`}),
+		ProbeConPTY: func() error { return nil },
+		TerminalOpener: func(req openRequest, _ resolvedShell, callbacks terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+			return &fakeTerminal{req: req, callbacks: callbacks}, nil
+		},
+	})
+
+	var inbound []string
+	outbound := map[string]bool{}
+	decoder := json.NewDecoder(trace.Snapshot())
+	for decoder.More() {
+		var rec traceRecord
+		if err := decoder.Decode(&rec); err != nil {
+			t.Fatalf("invalid trace record: %v", err)
+		}
+		if rec.Dir == traceDirIn {
+			inbound = append(inbound, string(rec.Frame))
+			continue
+		}
+		var evt struct{ Type string }
+		_ = json.Unmarshal(rec.Frame, &evt)
+		outbound[evt.Type] = true
+	}
+
+	if len(inbound) != 3 {
+		t.Fatalf("expected 3 recorded requests, got %q", inbound)
+	}
+	if strings.Contains(inbound[1], "hunter2") || !strings.Contains(inbound[1], `"redactedBytes":7`) {
+		t.Fatalf("write data was not redacted: %s", inbound[1])
+	}
+	if inbound[2] != `"not json"` {
+		t.Fatalf("malformed request not recorded as a string: %s", inbound[2])
+	}
+	if !outbound[eventTypeHello] || !outbound[eventTypeReady] || !outbound[eventTypeError] {
+		t.Fatalf("missing recorded events: %v", outbound)
+	}
+}
+
+func TestReplayPlaysBackRecordedSession(t *testing.T) {
+	compressed, ok := gzipChunk(bytes.Repeat([]byte("a"), 512))
+	if !ok {
+		t.Fatal("gzipChunk did not compress")
+	}
+	lines := []string{
+		`{"ms":0,"dir":"in","frame":{"type":"open","terminalId":"t1","cwd":"/no/such/dir","cols":80,"rows":24}}`,
+		`{"ms":1,"dir":"out","frame":{"type":"ready","terminalId":"t1"}}`,
+		`{"ms":2,"dir":"out","frame":{"type":"output","terminalId":"t1","data":"UFM+IA=="}}`,
+		`{"ms":3,"dir":"out","frame":{"type":"output","terminalId":"t1","data":"` + base64.StdEncoding.EncodeToString(compressed) + `","compression":"gzip"}}`,
+		`{"ms":4,"dir":"in","frame":{"type":"write","terminalId":"t1","data":"","redactedBytes":4}}`,
+		`{"ms":5,"dir":"out","frame":{"type":"exit","terminalId":"t1","code":3}}`,
+		`{"ms":6,"dir":"in","frame":{"type":"open","terminalId":"t2","cols":80,"rows":24}}`,
+		`{"ms":7,"dir":"out","frame":{"type":"error","terminalId":"t2","code":"spawn_failed","message":"boom"}}`,
+		`{"ms":40,"dir":"out","frame":{"type":"heartbeat"}}`,
+	}
+	path := filepath.Join(t.TempDir(), "trace.ndjson")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runMain([]string{"replay", path}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Fatalf("unexpected exit code %d: %s", code, stderr.String())
+	}
+
+	var output []byte
+	exitCode, failure := -1, ""
+	for _, evt := range decodeRawEvents(t, &stdout) {
+		switch evt["type"] {
+		case eventTypeOutput:
+			chunk, err := base64.StdEncoding.DecodeString(stringValue(evt["data"]))
+			if err != nil {
+				t.Fatalf("invalid output: %v", err)
+			}
+			output = append(output, chunk...)
+		case eventTypeExit:
+			exitCode = int(evt["code"].(float64))
+		case eventTypeError:
+			if evt["terminalId"] == "t2" {
+				failure = stringValue(evt["code"])
+			}
+		}
+	}
+
+	if want := "PS> " + strings.Repeat("a", 512); string(output) != want {
+		t.Fatalf("unexpected replayed output %q", output)
+	}
+	if exitCode != 3 {
+		t.Fatalf("expected recorded exit code 3, got %d", exitCode)
+	}
+	if failure != errorCodeSpawnFailed {
+		t.Fatalf("expected recorded open failure, got %q", failure)
+	}
+}
+
+func TestRedactTraceWriteLeavesOtherRequests(t *testing.T) {
+	frame := json.RawMessage(`{"type":"open","terminalId":"t1","env":{"K":"V"}}`)
+	if got := redactTraceWrite(frame); string(got) != string(frame) {
+		t.Fatalf("non-write request changed: %s", got)
+	}
+	if got := redactTraceWrite(json.RawMessage(`{"type":"write","terminalId":"t1","keys":["enter"]}`)); strings.Contains(string(got), "redactedBytes") {
+		t.Fatalf("write without data marked redacted: %s", got)
+	}
+}
+
+func TestParseChaos(t *testing.T) {
+	chaos, err := parseChaos("latency=50ms, write-delay=20ms,drop=0.25,seed=7")
+	if err != nil {
+		t.Fatalf("parseChaos failed: %v", err)
+	}
+	if chaos.OutputLatency != 50*time.Millisecond || chaos.WriteDelay != 20*time.Millisecond || chaos.DropRate != 0.25 {
+		t.Fatalf("unexpected chaos config: %+v", chaos)
+	}
+
+	for _, spec := range []string{"latency", "bogus=1", "drop=2", "latency=-1s", "seed=x"} {
+		if _, err := parseChaos(spec); err == nil {
+			t.Fatalf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestChaosDropsTerminals(t *testing.T) {
+	chaos, err := parseChaos("drop=1,seed=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chaos.dropInterval = time.Millisecond
+
+	ts := newTestSidecar(t, runConfig{Chaos: chaos})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+
+	if exit := waitForEventOfType(t, ts, eventTypeExit); exit["code"] != float64(chaosDropExitCode) {
+		t.Fatalf("unexpected exit event: %+v", exit)
+	}
+	if !ts.terminals["t1"].Closed() {
+		t.Fatal("dropped terminal was not closed")
+	}
+}
+
+func TestChaosKeepsHangupSupport(t *testing.T) {
+	chaos, err := parseChaos("write-delay=1ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(_ string, task func()) { go task() }
+
+	for _, hangup := range []bool{false, true} {
+		fake := &fakeTerminal{}
+		wrapped, err := chaos.Wrap(func(openRequest, resolvedShell, terminalCallbacks, func(string, func())) (terminalSession, error) {
+			if hangup {
+				return &hangupTerminal{fakeTerminal: fake}, nil
+			}
+			return fake, nil
+		})(openRequest{TerminalID: "t1"}, resolvedShell{}, terminalCallbacks{Exit: func(int) {}}, run)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := wrapped.(hangupSession); ok != hangup {
+			t.Fatalf("hangup support %v after wrapping, want %v", ok, hangup)
+		}
+		if err := wrapped.Write("x"); err != nil || len(fake.Writes()) != 1 {
+			t.Fatalf("write was not forwarded: %v", err)
+		}
+	}
+}
+
+func TestDebugHandlerDumpsRegistryAndStacks(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, Emulate: true})
+
+	registry := &debugRegistry{}
+	untrack := registry.Track(ts.sidecar)
+	server := httptest.NewServer(debugHandler("", registry))
+	defer server.Close()
+
+	get := func(path string) string {
+		t.Helper()
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status %d", path, resp.StatusCode)
+		}
+		return string(body)
+	}
+
+	var sidecars []debugSidecar
+	if err := json.Unmarshal([]byte(get("/debug/hapi/terminals")), &sidecars); err != nil {
+		t.Fatal(err)
+	}
+	if len(sidecars) != 1 || sidecars[0].Client != auditClientStdio || len(sidecars[0].Terminals) != 1 ||
+		sidecars[0].Terminals[0].ID != "t1" || !sidecars[0].Terminals[0].Emulated {
+		t.Fatalf("unexpected registry dump: %+v", sidecars)
+	}
+	if stacks := get("/debug/hapi/goroutines"); !strings.Contains(stacks, "goroutine ") {
+		t.Fatalf("unexpected goroutine dump: %.200s", stacks)
+	}
+	get("/debug/pprof/")
+
+	untrack()
+	if dump := get("/debug/hapi/terminals"); strings.TrimSpace(dump) != "[]" {
+		t.Fatalf("untracked sidecar still listed: %s", dump)
+	}
+}
+
+func TestDebugHandlerRequiresToken(t *testing.T) {
+	server := httptest.NewServer(debugHandler("secret", &debugRegistry{}))
+	defer server.Close()
+
+	for token, want := range map[string]int{"": http.StatusUnauthorized, "wrong": http.StatusUnauthorized, "secret": http.StatusOK} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/debug/hapi/terminals", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("token %q: status %d, want %d", token, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+//go:build windows
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const (
+	pipeAccessDuplex          = 0x3
+	fileFlagFirstPipeInstance = 0x80000
+	brokerPipeBufferBytes     = 64 * 1024
+	seeMaskNoCloseProcess     = 0x40
+	seeMaskNoAsync            = 0x100
+	swHide                    = 0
+	errorPipeConnected        = 535
+	errorCancelled            = 1223
+	tokenElevation            = 20
+	// brokerConnectTimeout covers the time the user takes to answer the
+	// UAC prompt before the elevated broker connects back.
+	brokerConnectTimeout = 2 * time.Minute
+)
+
+var (
+	shell32Proc = syscall.NewLazyDLL("shell32.dll")
+
+	procShellExecuteExW             = shell32Proc.NewProc("ShellExecuteExW")
+	procCreateNamedPipeW            = kernel32Proc.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe            = kernel32Proc.NewProc("ConnectNamedPipe")
+	procGetNamedPipeClientProcessId = kernel32Proc.NewProc("GetNamedPipeClientProcessId")
+	procGetProcessId                = kernel32Proc.NewProc("GetProcessId")
+)
+
+type shellExecuteInfo struct {
+	Size          uint32
+	Mask          uint32
+	Hwnd          uintptr
+	Verb          *uint16
+	File          *uint16
+	Parameters    *uint16
+	Directory     *uint16
+	Show          int32
+	InstApp       uintptr
+	IDList        uintptr
+	Class         *uint16
+	KeyClass      uintptr
+	HotKey        uint32
+	IconOrMonitor uintptr
+	Process       syscall.Handle
+}
+
+func isProcessElevated() bool {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return false
+	}
+
+	var token syscall.Token
+	if err := syscall.OpenProcessToken(process, syscall.TOKEN_QUERY, &token); err != nil {
+		return false
+	}
+	defer token.Close()
+
+	var elevation uint32
+	var returned uint32
+	err = syscall.GetTokenInformation(token, tokenElevation, (*byte)(unsafe.Pointer(&elevation)), uint32(unsafe.Sizeof(elevation)), &returned)
+	return err == nil && elevation != 0
+}
+
+// openElevatedSession starts an elevated copy of the sidecar through the UAC
+// "runas" verb and runs the terminal inside it over a private named pipe.
+func openElevatedSession(
+	req openRequest,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	name, err := brokerPipeName()
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to name broker pipe: %v", err)
+	}
+
+	pipe, err := createBrokerPipe(name)
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to create broker pipe: %v", err)
+	}
+
+	process, err := launchElevatedBroker(name)
+	if err != nil {
+		closeHandle(pipe)
+		return nil, err
+	}
+	defer closeHandle(process)
+
+	if err := awaitBrokerConnection(name, pipe, process); err != nil {
+		closeHandle(pipe)
+		_ = syscall.TerminateProcess(process, terminateExitCode)
+		return nil, err
+	}
+
+	return newBrokerSession(os.NewFile(uintptr(pipe), name), req, callbacks, runIsolated)
+}
+
+func brokerPipeName() (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`\\.\pipe\hapi-pty-broker-%d-%s`, os.Getpid(), hex.EncodeToString(suffix)), nil
+}
+
+func createBrokerPipe(name string) (syscall.Handle, error) {
+	return createNamedPipe(name, fileFlagFirstPipeInstance, 1)
+}
+
+func createNamedPipe(name string, flags uint32, instances uint32) (syscall.Handle, error) {
+	nameUTF16, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	handle, _, callErr := procCreateNamedPipeW.Call(
+		uintptr(unsafe.Pointer(nameUTF16)),
+		uintptr(pipeAccessDuplex|flags),
+		0,
+		uintptr(instances),
+		brokerPipeBufferBytes,
+		brokerPipeBufferBytes,
+		0,
+		0,
+	)
+	if syscall.Handle(handle) == syscall.InvalidHandle {
+		return 0, callErr
+	}
+	return syscall.Handle(handle), nil
+}
+
+func launchElevatedBroker(pipeName string) (syscall.Handle, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, newSidecarError(errorCodeStartupFailed, "failed to locate sidecar executable: %v", err)
+	}
+
+	verb, _ := syscall.UTF16PtrFromString("runas")
+	file, err := syscall.UTF16PtrFromString(executable)
+	if err != nil {
+		return 0, newSidecarError(errorCodeStartupFailed, "failed to encode sidecar path: %v", err)
+	}
+	parameters, err := syscall.UTF16PtrFromString("--broker-pipe " + syscall.EscapeArg(pipeName))
+	if err != nil {
+		return 0, newSidecarError(errorCodeStartupFailed, "failed to encode broker arguments: %v", err)
+	}
+
+	info := shellExecuteInfo{
+		Mask:       seeMaskNoCloseProcess | seeMaskNoAsync,
+		Verb:       verb,
+		File:       file,
+		Parameters: parameters,
+		Show:       swHide,
+	}
+	info.Size = uint32(unsafe.Sizeof(info))
+
+	ret, _, callErr := procShellExecuteExW.Call(uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		if callErr == syscall.Errno(errorCancelled) {
+			return 0, newSidecarError(errorCodeElevationDeclined, "elevation was declined")
+		}
+		return 0, newSidecarError(errorCodeStartupFailed, "failed to start elevated broker: %v", callErr)
+	}
+	if info.Process == 0 {
+		return 0, newSidecarError(errorCodeStartupFailed, "elevated broker did not report a process")
+	}
+	return info.Process, nil
+}
+
+// awaitBrokerConnection waits until the broker process connects to pipe and
+// rejects any other client. ConnectNamedPipe blocks, so a broker that exits
+// or times out is unblocked by connecting to the pipe from this process.
+func awaitBrokerConnection(name string, pipe syscall.Handle, process syscall.Handle) error {
+	connected := make(chan error, 1)
+	go func() {
+		ret, _, err := procConnectNamedPipe.Call(uintptr(pipe), 0)
+		if ret == 0 && err != syscall.Errno(errorPipeConnected) {
+			connected <- err
+			return
+		}
+		connected <- nil
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		_, _ = syscall.WaitForSingleObject(process, syscall.INFINITE)
+		close(exited)
+	}()
+
+	var failure error
+	select {
+	case err := <-connected:
+		if err != nil {
+			return newSidecarError(errorCodeStartupFailed, "broker pipe connection failed: %v", err)
+		}
+		return verifyBrokerClient(pipe, process)
+	case <-exited:
+		failure = newSidecarError(errorCodeStartupFailed, "elevated broker exited before connecting")
+	case <-time.After(brokerConnectTimeout):
+		failure = newSidecarError(errorCodeStartupFailed, "elevated broker did not connect within %s", brokerConnectTimeout)
+	}
+
+	if self, err := dialBrokerPipe(name); err == nil {
+		_ = self.Close()
+	}
+	<-connected
+	return failure
+}
+
+func verifyBrokerClient(pipe syscall.Handle, process syscall.Handle) error {
+	var clientPID uint32
+	ret, _, err := procGetNamedPipeClientProcessId.Call(uintptr(pipe), uintptr(unsafe.Pointer(&clientPID)))
+	if ret == 0 {
+		return newSidecarError(errorCodeStartupFailed, "failed to identify broker client: %v", err)
+	}
+
+	brokerPID, _, _ := procGetProcessId.Call(uintptr(process))
+	if uint32(brokerPID) != clientPID {
+		return newSidecarError(errorCodeStartupFailed, "unexpected client connected to broker pipe")
+	}
+	return nil
+}
+
+func dialBrokerPipe(name string) (io.ReadWriteCloser, error) {
+	nameUTF16, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := syscall.CreateFile(
+		nameUTF16,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		0,
+		nil,
+		syscall.OPEN_EXISTING,
+		0,
+		0,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), name), nil
+}
@@ -0,0 +1,516 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// minimalEnvNames is the base environment for terminals opened with
+// inheritEnv: false, enough for Windows shells and common tools to start.
+var minimalEnvNames = []string{
+	"ALLUSERSPROFILE",
+	"APPDATA",
+	"COMPUTERNAME",
+	"ComSpec",
+	"HOMEDRIVE",
+	"HOMEPATH",
+	"LOCALAPPDATA",
+	"NUMBER_OF_PROCESSORS",
+	"OS",
+	"PATH",
+	"PATHEXT",
+	"PROCESSOR_ARCHITECTURE",
+	"ProgramData",
+	"ProgramFiles",
+	"ProgramFiles(x86)",
+	"ProgramW6432",
+	"PUBLIC",
+	"SystemDrive",
+	"SystemRoot",
+	"TEMP",
+	"TMP",
+	"USERDOMAIN",
+	"USERNAME",
+	"USERPROFILE",
+	"windir",
+}
+
+// envPolicy filters the inherited environment before it reaches a shell.
+// Patterns match variable names case-insensitively and may end in "*" to
+// match a prefix. Deny wins over allow; an empty allow list allows all.
+type envPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+func (p envPolicy) permits(name string) bool {
+	if matchesEnvPattern(name, p.Deny) {
+		return false
+	}
+	return len(p.Allow) == 0 || matchesEnvPattern(name, p.Allow)
+}
+
+func matchesEnvPattern(name string, patterns []string) bool {
+	upper := strings.ToUpper(name)
+	for _, pattern := range patterns {
+		pattern = strings.ToUpper(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(upper, prefix) {
+				return true
+			}
+		} else if upper == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// childEnvironment builds the environment for a new shell: the sidecar's own
+// environment (or its minimal subset when inheritEnv is false) filtered by
+// the policy, followed by the request's explicit overrides.
+func childEnvironment(req openRequest, policy envPolicy, base []string) []string {
+	inherit := req.InheritEnv == nil || *req.InheritEnv
+
+	filtered := make([]string, 0, len(base))
+	for _, entry := range base {
+		name, _, ok := strings.Cut(entry, "=")
+		// Windows keeps per-drive cwd entries such as "=C:=C:\" with an empty name.
+		if !ok || name == "" {
+			if inherit {
+				filtered = append(filtered, entry)
+			}
+			continue
+		}
+		if !inherit && !matchesEnvPattern(name, minimalEnvNames) {
+			continue
+		}
+		if policy.permits(name) {
+			filtered = append(filtered, entry)
+		}
+	}
+
+	// Values may reference the inherited environment as ${VAR}.
+	env := req.Env
+	if len(env) > 0 {
+		env = make(map[string]string, len(req.Env))
+		for name, value := range req.Env {
+			env[name] = expandEnvReferences(value, "${", "}", filtered)
+		}
+	}
+	return editPath(mergeEnvironment(filtered, env), req.PathPrepend, req.PathAppend, filtered)
+}
+
+// editPath puts prepend before and appendix after the PATH in environ,
+// joined with the platform list separator and keeping PATH's spelling.
+// Entries may reference base as ${VAR}.
+func editPath(environ []string, prepend []string, appendix []string, base []string) []string {
+	if len(prepend) == 0 && len(appendix) == 0 {
+		return environ
+	}
+
+	parts := make([]string, 0, len(prepend)+len(appendix)+1)
+	for _, entry := range prepend {
+		parts = append(parts, expandEnvReferences(entry, "${", "}", base))
+	}
+	if path, _ := lookupEnviron(environ, "PATH"); path != "" {
+		parts = append(parts, path)
+	}
+	for _, entry := range appendix {
+		parts = append(parts, expandEnvReferences(entry, "${", "}", base))
+	}
+	return mergeEnvironment(environ, map[string]string{
+		environKey(environ, "PATH"): strings.Join(parts, string(os.PathListSeparator)),
+	})
+}
+
+func splitEnvPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// lookupEnviron finds name in a KEY=VALUE list, case-insensitively like Windows.
+func lookupEnviron(environ []string, name string) (string, bool) {
+	for idx := len(environ) - 1; idx >= 0; idx-- {
+		key, value, ok := strings.Cut(environ[idx], "=")
+		if ok && key != "" && strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// expandPath expands a leading "~" to home and %VAR% or ${VAR} references
+// from environ. Unknown variables are left untouched.
+func expandPath(path string, environ []string, home string) string {
+	if home != "" && (path == "~" || strings.HasPrefix(path, `~\`) || strings.HasPrefix(path, "~/")) {
+		path = home + path[1:]
+	}
+
+	path = expandEnvReferences(path, "%", "%", environ)
+	return expandEnvReferences(path, "${", "}", environ)
+}
+
+func expandEnvReferences(path string, open string, close string, environ []string) string {
+	var expanded strings.Builder
+	for {
+		start := strings.Index(path, open)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(path[start+len(open):], close)
+		if end < 0 {
+			break
+		}
+		end += start + len(open)
+
+		expanded.WriteString(path[:start])
+		name := path[start+len(open) : end]
+		if value, ok := lookupEnviron(environ, name); ok && name != "" {
+			expanded.WriteString(value)
+			path = path[end+len(close):]
+		} else {
+			// Keep the opening delimiter literal; the closing one may open
+			// the next reference, as in "100%%PATH%".
+			expanded.WriteString(path[start:end])
+			path = path[end:]
+			if open != close {
+				expanded.WriteString(close)
+				path = path[len(close):]
+			}
+		}
+	}
+	expanded.WriteString(path)
+	return expanded.String()
+}
+
+var condaEnvName = regexp.MustCompile(`^[A-Za-z0-9._+-]+$`)
+
+// activatePython applies req.Python to the resolved request. A virtualenv is
+// activated through the environment, as its activate scripts do, which works
+// in every shell and needs no script execution policy. Conda environments
+// need conda's shell hook, so the activation is typed ahead of any
+// initialCommand in the shell's own syntax.
+func activatePython(req *openRequest, shell string, home string) error {
+	python := req.Python
+	if python == nil || python.Venv == "" && python.Conda == "" {
+		return nil
+	}
+	if python.Venv != "" && python.Conda != "" {
+		return newSidecarError(errorCodeInvalidRequest, "python accepts either venv or conda, not both")
+	}
+
+	if python.Venv != "" {
+		venv := expandPath(python.Venv, req.environ, home)
+		if !filepath.IsAbs(venv) && req.Cwd != "" {
+			venv = filepath.Join(req.Cwd, venv)
+		}
+		if info, err := os.Stat(filepath.Join(venv, "Scripts")); err != nil || !info.IsDir() {
+			return newSidecarError(errorCodeInvalidRequest, "python venv not found: %s", venv)
+		}
+
+		path, _ := lookupEnviron(req.environ, "PATH")
+		scripts := filepath.Join(venv, "Scripts")
+		if path != "" {
+			scripts += string(os.PathListSeparator) + path
+		}
+		environ := make([]string, 0, len(req.environ)+2)
+		for _, entry := range req.environ {
+			name, _, _ := strings.Cut(entry, "=")
+			if strings.EqualFold(name, "PYTHONHOME") {
+				continue
+			}
+			environ = append(environ, entry)
+		}
+		req.environ = mergeEnvironment(environ, map[string]string{
+			environKey(environ, "PATH"): scripts,
+			"VIRTUAL_ENV":               venv,
+		})
+		return nil
+	}
+
+	if !condaEnvName.MatchString(python.Conda) {
+		return newSidecarError(errorCodeInvalidRequest, "invalid conda environment name %q", python.Conda)
+	}
+	var activate string
+	switch shell {
+	case "pwsh", "powershell", shellVSDevShell:
+		activate = "(& conda 'shell.powershell' 'hook') | Out-String | Invoke-Expression; conda activate " + python.Conda
+	case "cmd", shellVSDevCmd:
+		activate = "conda activate " + python.Conda
+	case "gitbash":
+		activate = `eval "$(conda shell.bash hook)" && conda activate ` + python.Conda
+	default:
+		return newSidecarError(errorCodeInvalidRequest, "conda activation is not supported for shell %q", shell)
+	}
+	if req.InitialCommand != "" {
+		activate += "\r" + req.InitialCommand
+	}
+	req.InitialCommand = activate
+	return nil
+}
+
+// withShellDefaults adds the shell's default variables, such as MSYSTEM,
+// to environ unless the request sets them itself.
+func withShellDefaults(environ []string, defaults map[string]string, requested map[string]string) []string {
+	overrides := make(map[string]string, len(defaults))
+	for key, value := range defaults {
+		if _, ok := requested[key]; !ok {
+			overrides[environKey(environ, key)] = value
+		}
+	}
+	return mergeEnvironment(environ, overrides)
+}
+
+// envProfiles are named env maps, loaded from --env-profiles, that open
+// requests reference through envProfiles instead of repeating them inline.
+type envProfiles map[string]map[string]string
+
+func loadEnvProfiles(file string) (envProfiles, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env profiles: %w", err)
+	}
+
+	var profiles envProfiles
+	if err := json.Unmarshal(content, &profiles); err != nil {
+		return nil, fmt.Errorf("invalid env profiles %s: %w", file, err)
+	}
+	for name, env := range profiles {
+		if name == "" {
+			return nil, fmt.Errorf("invalid env profiles %s: profile names must not be empty", file)
+		}
+		for key := range env {
+			if key == "" || strings.ContainsAny(key, "=\x00") {
+				return nil, fmt.Errorf("env profile %q: invalid variable name %q", name, key)
+			}
+		}
+		if err := validateEnvLimits(env); err != nil {
+			return nil, fmt.Errorf("env profile %q: %w", name, err)
+		}
+	}
+	return profiles, nil
+}
+
+// Resolve merges the named profiles in order, later ones winning, with the
+// request's inline env on top. Profile values may reference %VAR% from base,
+// the environment the terminal inherits, and like inline values ${VAR},
+// which childEnvironment expands.
+func (p envProfiles) Resolve(names []string, inline map[string]string, base []string) (map[string]string, error) {
+	if len(names) == 0 {
+		return inline, nil
+	}
+
+	merged := map[string]string{}
+	for _, name := range names {
+		profile, ok := p[name]
+		if !ok {
+			return nil, newSidecarError(errorCodeInvalidRequest, "unknown env profile %q", name)
+		}
+		for key, value := range profile {
+			merged[key] = expandEnvReferences(value, "%", "%", base)
+		}
+	}
+	for key, value := range inline {
+		merged[key] = value
+	}
+	if err := validateEnvLimits(merged); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// loginEnvTimeout bounds the login shell run to capture its environment.
+const loginEnvTimeout = 10 * time.Second
+
+// loginEnvCommand prints PATH in Windows form on the first line, then the
+// whole environment NUL-separated; bash keeps PATH in POSIX form.
+const loginEnvCommand = `cygpath -w -p "$PATH" && env -0`
+
+// loginEnvSkipped are variables describing the capturing bash session itself,
+// or holding POSIX paths bash converts only for its own processes.
+var loginEnvSkipped = []string{
+	"_", "PWD", "OLDPWD", "SHLVL", "PS1", "PS2", "PS4", "HOME", "TERM", "SHELL",
+	"SHELLOPTS", "BASHOPTS", "HOSTNAME", "HOSTTYPE", "MACHTYPE", "OSTYPE",
+	"TMP", "TEMP", "TMPDIR", "MSYSTEM*", "MINGW_*", "ORIGINAL_*", "EXEPATH",
+	"MANPATH", "INFOPATH", "CHERE_INVOKING", "PATH",
+}
+
+// loginEnvFunc runs bash as a login shell with environ and returns the
+// output of loginEnvCommand.
+type loginEnvFunc func(bash string, environ []string) ([]byte, error)
+
+// loginEnvCache keeps each bash's captured login environment for the life of
+// the sidecar, so only the first loginEnv open pays for running the profile.
+// Failed captures are retried.
+type loginEnvCache struct {
+	mu   sync.Mutex
+	envs map[string]map[string]string
+}
+
+// Capture returns the variables the login profile of bash adds or changes
+// relative to environ.
+func (c *loginEnvCache) Capture(bash string, environ []string, run loginEnvFunc) (map[string]string, error) {
+	c.mu.Lock()
+	captured, ok := c.envs[bash]
+	c.mu.Unlock()
+	if ok {
+		return captured, nil
+	}
+
+	if run == nil {
+		run = runLoginEnv
+	}
+	out, err := run(bash, environ)
+	if err != nil {
+		return nil, err
+	}
+	captured = parseLoginEnv(out, environ)
+
+	c.mu.Lock()
+	if c.envs == nil {
+		c.envs = map[string]map[string]string{}
+	}
+	c.envs[bash] = captured
+	c.mu.Unlock()
+	return captured, nil
+}
+
+func runLoginEnv(bash string, environ []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loginEnvTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, bash, "-lc", loginEnvCommand)
+	cmd.Env = environ
+	return cmd.Output()
+}
+
+func parseLoginEnv(out []byte, environ []string) map[string]string {
+	path, rest, _ := bytes.Cut(out, []byte("\n"))
+	captured := map[string]string{}
+	for _, entry := range bytes.Split(rest, []byte{0}) {
+		name, value, ok := strings.Cut(string(entry), "=")
+		if !ok || name == "" || matchesEnvPattern(name, loginEnvSkipped) {
+			continue
+		}
+		if current, ok := lookupEnviron(environ, name); ok && current == value {
+			continue
+		}
+		captured[environKey(environ, name)] = value
+	}
+	if path := strings.TrimSpace(string(path)); path != "" {
+		captured[environKey(environ, "PATH")] = path
+	}
+	return captured
+}
+
+// environKey returns how environ spells name, which Windows matches
+// case-insensitively, or name itself when it is not set.
+func environKey(environ []string, name string) string {
+	for idx := len(environ) - 1; idx >= 0; idx-- {
+		key, _, ok := strings.Cut(environ[idx], "=")
+		if ok && strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
+
+const (
+	// maxPath is MAX_PATH. A current directory may be two characters
+	// shorter, leaving room for its trailing backslash and NUL, and the
+	// extended-length prefix does not lift that limit.
+	maxPath             = 260
+	maxCurrentDirectory = maxPath - 2
+	extendedPathPrefix  = `\\?\`
+
+	uncCwdPushd = "pushd"
+)
+
+// plainPath drops the extended-length prefix, turning \\?\C:\dir into
+// C:\dir and \\?\UNC\server\share into \\server\share, when the result fits
+// MAX_PATH: shells mishandle a prefixed current directory or argv[0].
+// Longer paths keep the prefix, which is their only valid form.
+func plainPath(path string) string {
+	if !strings.HasPrefix(path, extendedPathPrefix) {
+		return path
+	}
+	plain := path[len(extendedPathPrefix):]
+	if len(plain) >= 4 && strings.EqualFold(plain[:4], `UNC\`) {
+		plain = `\\` + plain[4:]
+	}
+	if len(utf16.Encode([]rune(plain))) >= maxPath {
+		return path
+	}
+	return plain
+}
+
+// isUNCPath reports whether path is on a network share, \\server\share.
+func isUNCPath(path string) bool {
+	path = strings.ReplaceAll(path, "/", `\`)
+	return strings.HasPrefix(path, `\\`) && !strings.HasPrefix(path, extendedPathPrefix) && !strings.HasPrefix(path, `\\.\`)
+}
+
+// shellCwd adapts a resolved cwd to what Windows and shell accept. It
+// fails with cwd_unsupported for a cwd too long to be a current directory
+// and for a UNC cwd in cmd, which would silently start in the Windows
+// directory instead, unless uncCwd is "pushd": then cmd starts in home and
+// its command line pushes the share onto a drive letter.
+func shellCwd(cwd string, shell resolvedShell, uncCwd string, home string) (string, resolvedShell, error) {
+	switch uncCwd {
+	case "", uncCwdPushd:
+	default:
+		return "", shell, newSidecarError(errorCodeInvalidRequest, "unsupported uncCwd %q", uncCwd)
+	}
+	if cwd == "" {
+		return cwd, shell, nil
+	}
+
+	cwd = plainPath(cwd)
+	if length := len(utf16.Encode([]rune(cwd))); length > maxCurrentDirectory {
+		return "", shell, newSidecarError(errorCodeCwdUnsupported, "cwd has %d characters, more than the %d Windows allows for a current directory: %s", length, maxCurrentDirectory, cwd)
+	}
+	if !isUNCPath(cwd) || (shell.Name != "cmd" && shell.Name != shellVSDevCmd) {
+		return cwd, shell, nil
+	}
+	if uncCwd != uncCwdPushd {
+		return "", shell, newSidecarError(errorCodeCwdUnsupported, "cmd cannot start in the UNC path %s; set uncCwd to \"pushd\" to map it to a drive letter", cwd)
+	}
+	return home, pushdShell(shell, cwd), nil
+}
+
+// resolveCwd expands and validates the requested working directory. A
+// missing directory is an error unless cwdFallback selects the home
+// directory instead.
+func resolveCwd(req openRequest, home string) (string, error) {
+	if req.Cwd == "" {
+		return "", nil
+	}
+
+	cwd := expandPath(req.Cwd, req.environ, home)
+	info, err := os.Stat(cwd)
+	if err == nil && info.IsDir() {
+		return cwd, nil
+	}
+
+	if req.CwdFallback && home != "" {
+		return home, nil
+	}
+	if err == nil {
+		return "", newSidecarError(errorCodeCwdNotFound, "cwd is not a directory: %s", cwd)
+	}
+	return "", newSidecarError(errorCodeCwdNotFound, "cwd not found: %s", cwd)
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestChildEnvironmentAppliesDenyAndAllowLists(t *testing.T) {
+	base := []string{"PATH=C:\\Windows", "GITHUB_TOKEN=secret", "AWS_SECRET_ACCESS_KEY=x", "HOME=C:\\Users\\me", "=C:=C:\\"}
+
+	denied := childEnvironment(openRequest{}, envPolicy{Deny: []string{"github_token", "AWS_*"}}, base)
+	if want := []string{"PATH=C:\\Windows", "HOME=C:\\Users\\me", "=C:=C:\\"}; !reflect.DeepEqual(denied, want) {
+		t.Fatalf("deny list: got %q, want %q", denied, want)
+	}
+
+	allowed := childEnvironment(openRequest{}, envPolicy{Allow: []string{"PATH", "AWS_*"}, Deny: []string{"AWS_SECRET_*"}}, base)
+	if want := []string{"PATH=C:\\Windows", "=C:=C:\\"}; !reflect.DeepEqual(allowed, want) {
+		t.Fatalf("allow list: got %q, want %q", allowed, want)
+	}
+}
+
+func TestChildEnvironmentWithoutInheritanceUsesMinimalBase(t *testing.T) {
+	inherit := false
+	base := []string{"Path=C:\\Windows", "SystemRoot=C:\\Windows", "GITHUB_TOKEN=secret", "=C:=C:\\"}
+
+	env := childEnvironment(openRequest{InheritEnv: &inherit, Env: map[string]string{"FOO": "bar"}}, envPolicy{}, base)
+	if want := []string{"Path=C:\\Windows", "SystemRoot=C:\\Windows", "FOO=bar"}; !reflect.DeepEqual(env, want) {
+		t.Fatalf("got %q, want %q", env, want)
+	}
+}
+
+func TestChildEnvironmentKeepsExplicitOverridesOfDeniedNames(t *testing.T) {
+	env := childEnvironment(openRequest{Env: map[string]string{"GITHUB_TOKEN": "scoped"}}, envPolicy{Deny: []string{"GITHUB_TOKEN"}}, []string{"GITHUB_TOKEN=secret"})
+	if want := []string{"GITHUB_TOKEN=scoped"}; !reflect.DeepEqual(env, want) {
+		t.Fatalf("got %q, want %q", env, want)
+	}
+}
+
+func TestChildEnvironmentExpandsValuesAndEditsPath(t *testing.T) {
+	base := []string{"Path=C:\\Windows", "USERPROFILE=C:\\Users\\me", "GITHUB_TOKEN=secret"}
+	req := openRequest{
+		Env:         map[string]string{"CARGO_HOME": "${USERPROFILE}\\.cargo", "TOKEN": "${GITHUB_TOKEN}", "LITERAL": "${NOPE}"},
+		PathPrepend: []string{"${CARGO_HOME}\\bin", "${USERPROFILE}\\bin"},
+		PathAppend:  []string{"D:\\tools"},
+	}
+
+	env := childEnvironment(req, envPolicy{Deny: []string{"GITHUB_TOKEN"}}, base)
+	sep := string(os.PathListSeparator)
+	want := map[string]string{
+		"Path":       "${CARGO_HOME}\\bin" + sep + "C:\\Users\\me\\bin" + sep + "C:\\Windows" + sep + "D:\\tools",
+		"CARGO_HOME": "C:\\Users\\me\\.cargo",
+		"TOKEN":      "${GITHUB_TOKEN}",
+		"LITERAL":    "${NOPE}",
+	}
+	for name, value := range want {
+		if got, _ := lookupEnviron(env, name); got != value {
+			t.Fatalf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if len(env) != 5 {
+		t.Fatalf("expected PATH to be edited in place, got %q", env)
+	}
+}
+
+func TestWithShellDefaultsYieldsToRequest(t *testing.T) {
+	environ := []string{"Path=C:\\Windows", "CHERE_INVOKING=0"}
+	defaults := map[string]string{"MSYSTEM": "MSYS", "CHERE_INVOKING": "1"}
+
+	got := withShellDefaults(environ, defaults, map[string]string{"MSYSTEM": "UCRT64"})
+	want := []string{"Path=C:\\Windows", "CHERE_INVOKING=1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected environment: %q", got)
+	}
+}
+
+func TestSidecarMergesLoginEnvironment(t *testing.T) {
+	t.Setenv("HAPI_LOGIN_TEST", "unchanged")
+	captures := 0
+	ts := newTestSidecar(t, runConfig{
+		LookPath: fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`, "bash.exe": `C:\Git\bin\bash.exe`}),
+		CaptureLoginEnv: func(bash string, environ []string) ([]byte, error) {
+			captures++
+			if bash != `C:\Git\bin\bash.exe` {
+				t.Errorf("captured the wrong bash: %s", bash)
+			}
+			out := `C:\Users\me\.cargo\bin;C:\Windows` + "\n" +
+				"PATH=/c/Users/me/.cargo/bin:/c/Windows\x00NVM_DIR=/c/nvm\x00EDITOR=vim\x00PWD=/c/work\x00HAPI_LOGIN_TEST=unchanged\x00"
+			return []byte(out), nil
+		},
+	})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, LoginEnv: true, Env: map[string]string{"EDITOR": "code"}})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24, LoginEnv: true})
+
+	environ := ts.terminals["t1"].req.environ
+	if path, _ := lookupEnviron(environ, "PATH"); path != `C:\Users\me\.cargo\bin;C:\Windows` {
+		t.Fatalf("expected the login PATH in Windows form, got %q", path)
+	}
+	if nvm, _ := lookupEnviron(environ, "NVM_DIR"); nvm != "/c/nvm" {
+		t.Fatalf("expected NVM_DIR from the login profile, got %q", nvm)
+	}
+	if editor, _ := lookupEnviron(environ, "EDITOR"); editor != "code" {
+		t.Fatalf("the request's EDITOR should win, got %q", editor)
+	}
+	if pwd, _ := lookupEnviron(environ, "PWD"); pwd == "/c/work" {
+		t.Fatal("PWD of the capturing shell leaked into the terminal")
+	}
+	if captures != 1 {
+		t.Fatalf("expected one cached capture, got %d", captures)
+	}
+}
+
+func TestSidecarAppliesEnvProfiles(t *testing.T) {
+	t.Setenv("HAPI_PROFILE_BASE", `C:\base`)
+	t.Setenv("HAPI_PROFILE_SECRET", "hunter2")
+	file := filepath.Join(t.TempDir(), "profiles.json")
+	profiles := `{
+		"node18": {"NODE_HOME": "C:\\node18", "PATH_HINT": "%HAPI_PROFILE_BASE%\\bin", "LEAK": "${HAPI_PROFILE_SECRET}"},
+		"ci": {"CI": "1", "NODE_HOME": "C:\\ci-node"}
+	}`
+	if err := os.WriteFile(file, []byte(profiles), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := parseRunFlags([]string{"--env-profiles", file, "--env-deny", "HAPI_PROFILE_SECRET"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseRunFlags failed: %v", err)
+	}
+
+	ts := newTestSidecar(t, cfg)
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, EnvProfiles: []string{"node18", "ci"}, Env: map[string]string{"CI": "0"}})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", RequestID: "r2", Cols: 80, Rows: 24, EnvProfiles: []string{"node20"}})
+
+	want := map[string]string{"NODE_HOME": `C:\ci-node`, "PATH_HINT": `C:\base\bin`, "LEAK": "${HAPI_PROFILE_SECRET}", "CI": "0"}
+	for name, value := range want {
+		if got, _ := lookupEnviron(ts.terminals["t1"].req.environ, name); got != value {
+			t.Fatalf("%s = %q, want %q", name, got, value)
+		}
+	}
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["requestId"] != "r2" || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request for an unknown profile, got %+v", errors)
+	}
+
+	if err := os.WriteFile(file, []byte(`{"bad": {"A=B": "x"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadEnvProfiles(file); err == nil {
+		t.Fatal("expected an invalid variable name to be rejected")
+	}
+}
+
+func TestParseRunFlagsEnvPolicy(t *testing.T) {
+	cfg, err := parseRunFlags([]string{"--env-deny", "GITHUB_TOKEN, AWS_*,", "--env-allow", "PATH"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseRunFlags failed: %v", err)
+	}
+	if want := (envPolicy{Allow: []string{"PATH"}, Deny: []string{"GITHUB_TOKEN", "AWS_*"}}); !reflect.DeepEqual(cfg.EnvPolicy, want) {
+		t.Fatalf("got %+v, want %+v", cfg.EnvPolicy, want)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	environ := []string{"USERPROFILE=C:\\Users\\me", "Proj=work"}
+	cases := map[string]string{
+		"~":                       "C:\\Users\\me",
+		"~\\src":                  "C:\\Users\\me\\src",
+		"%userprofile%\\%PROJ%":   "C:\\Users\\me\\work",
+		"${USERPROFILE}/code":     "C:\\Users\\me/code",
+		"C:\\$Recycle.Bin":        "C:\\$Recycle.Bin",
+		"100%\\%MISSING%\\%PROJ%": "100%\\%MISSING%\\work",
+		"${MISSING}\\x":           "${MISSING}\\x",
+		"~other":                  "~other",
+	}
+	for input, want := range cases {
+		if got := expandPath(input, environ, "C:\\Users\\me"); got != want {
+			t.Errorf("expandPath(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSidecarOpenRejectsMissingCwd(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cwd: missing, Cols: 80, Rows: 24})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeCwdNotFound {
+		t.Fatalf("expected cwd_not_found, got %+v", errors)
+	}
+	if _, opened := ts.terminals["t1"]; opened {
+		t.Fatal("terminal should not open with a missing cwd")
+	}
+}
+
+func TestSidecarOpenFallsBackToHomeAndExpandsCwd(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	project := filepath.Join(home, "project")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cwd: "~/project", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cwd: filepath.Join(home, "gone"), CwdFallback: true, Cols: 80, Rows: 24})
+
+	if got := ts.terminals["t1"].req.Cwd; got != project {
+		t.Fatalf("expected expanded cwd %q, got %q", project, got)
+	}
+	if got := ts.terminals["t2"].req.Cwd; got != home {
+		t.Fatalf("expected fallback to %q, got %q", home, got)
+	}
+	ready := ts.eventsOfType(t, eventTypeReady)
+	if len(ready) != 2 || ready[1]["cwd"] != home {
+		t.Fatalf("ready should report the effective cwd, got %+v", ready)
+	}
+}
+
+func TestActivatePythonVenvThroughEnvironment(t *testing.T) {
+	project := t.TempDir()
+	venv := filepath.Join(project, ".venv")
+	if err := os.MkdirAll(filepath.Join(venv, "Scripts"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	req := openRequest{
+		Cwd:     project,
+		Python:  &pythonOptions{Venv: ".venv"},
+		environ: []string{"Path=bin", "PYTHONHOME=old", "K=V"},
+	}
+	if err := activatePython(&req, "pwsh", ""); err != nil {
+		t.Fatalf("activatePython failed: %v", err)
+	}
+
+	want := []string{
+		"Path=" + filepath.Join(venv, "Scripts") + string(os.PathListSeparator) + "bin",
+		"K=V",
+		"VIRTUAL_ENV=" + venv,
+	}
+	if !reflect.DeepEqual(req.environ, want) {
+		t.Fatalf("unexpected environment:\n got %q\nwant %q", req.environ, want)
+	}
+}
+
+func TestActivatePythonCondaPerShell(t *testing.T) {
+	cases := map[string]string{
+		"pwsh":    "(& conda 'shell.powershell' 'hook') | Out-String | Invoke-Expression; conda activate ml\rpython",
+		"cmd":     "conda activate ml\rpython",
+		"gitbash": `eval "$(conda shell.bash hook)" && conda activate ml` + "\rpython",
+	}
+	for shell, want := range cases {
+		req := openRequest{Python: &pythonOptions{Conda: "ml"}, InitialCommand: "python"}
+		if err := activatePython(&req, shell, ""); err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		if req.InitialCommand != want {
+			t.Fatalf("%s: got %q, want %q", shell, req.InitialCommand, want)
+		}
+	}
+}
+
+func TestActivatePythonRejectsInvalidOptions(t *testing.T) {
+	cases := map[string]struct {
+		shell  string
+		python pythonOptions
+	}{
+		"both":         {"pwsh", pythonOptions{Venv: ".venv", Conda: "ml"}},
+		"missing venv": {"pwsh", pythonOptions{Venv: filepath.Join(t.TempDir(), "none")}},
+		"conda name":   {"pwsh", pythonOptions{Conda: "ml; rm -rf /"}},
+		"conda shell":  {"mock", pythonOptions{Conda: "ml"}},
+	}
+	for name, tc := range cases {
+		python := tc.python
+		err := activatePython(&openRequest{Python: &python}, tc.shell, "")
+		if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != errorCodeInvalidRequest {
+			t.Fatalf("%s: expected invalid_request, got %v", name, err)
+		}
+	}
+}
+
+func TestPlainPathDropsExtendedLengthPrefix(t *testing.T) {
+	long := `\\?\C:\` + strings.Repeat("d", maxPath)
+	cases := map[string]string{
+		`\\?\C:\work`:              `C:\work`,
+		`\\?\UNC\server\share\dir`: `\\server\share\dir`,
+		`C:\work`:                  `C:\work`,
+		long:                       long,
+	}
+	for path, want := range cases {
+		if got := plainPath(path); got != want {
+			t.Fatalf("plainPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestShellCwdHandlesUNCAndLongPaths(t *testing.T) {
+	cmd := resolvedShell{Name: "cmd", Path: `C:\Windows\System32\cmd.exe`, Args: []string{"/Q"}}
+	pwsh := resolvedShell{Name: "pwsh", Path: `C:\pwsh.exe`}
+	share := `\\server\share\src`
+
+	if cwd, _, err := shellCwd(`\\?\UNC\server\share\src`, pwsh, "", `C:\Users\me`); err != nil || cwd != share {
+		t.Fatalf("pwsh should start in the share itself, got %q, %v", cwd, err)
+	}
+
+	_, _, err := shellCwd(share, cmd, "", `C:\Users\me`)
+	if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != errorCodeCwdUnsupported {
+		t.Fatalf("expected cwd_unsupported for cmd in a share, got %v", err)
+	}
+
+	cwd, shell, err := shellCwd(share, cmd, uncCwdPushd, `C:\Users\me`)
+	if err != nil || cwd != `C:\Users\me` {
+		t.Fatalf("unexpected pushd cwd %q, %v", cwd, err)
+	}
+	if !reflect.DeepEqual(shell.Args, []string{"/Q", "/S", "/K"}) || shell.Command != `"pushd "\\server\share\src""` {
+		t.Fatalf("unexpected pushd command line: %#v %s", shell.Args, shell.Command)
+	}
+
+	utf8 := resolvedShell{Name: "cmd", Args: []string{"/Q", "/S", "/K"}, Command: `"chcp 65001>nul"`}
+	if _, shell, _ := shellCwd(share, utf8, uncCwdPushd, ""); shell.Command != `"chcp 65001>nul & pushd "\\server\share\src""` {
+		t.Fatalf("pushd should follow the existing command: %s", shell.Command)
+	}
+
+	_, _, err = shellCwd(`C:\`+strings.Repeat("d", maxCurrentDirectory), pwsh, "", "")
+	if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != errorCodeCwdUnsupported {
+		t.Fatalf("expected cwd_unsupported for an overlong cwd, got %v", err)
+	}
+}
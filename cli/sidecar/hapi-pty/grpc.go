@@ -0,0 +1,535 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/tiann/hapi/cli/sidecar/hapi-pty/ptypb"
+)
+
+// The Terminal service in ptypb is generated from protocolProto, so that
+// adding a request or event to the protocol adds it to the service too.
+//go:generate sh -c "go run . schema --format proto > ptypb/terminal.proto"
+//go:generate buf generate --template ptypb/buf.gen.yaml ptypb
+
+const (
+	grpcListenScheme = "grpc://"
+	grpcServiceName  = "Terminal"
+	grpcGoPackage    = "github.com/tiann/hapi/cli/sidecar/hapi-pty/ptypb"
+	// wireEncodingGRPC is the encoding of --listen grpc:// clients: the
+	// messages of ptypb, see protocolProto. It is implied by the listen
+	// address rather than chosen with --encoding.
+	wireEncodingGRPC = "grpc"
+	// grpcClientIDHeader is the metadata a Session names its client ID in,
+	// as auth requests do on other transports.
+	grpcClientIDHeader  = "hapi-client-id"
+	grpcErrorCodeHeader = "hapi-error-code"
+	// grpcUnaryTimeout bounds how long a unary call waits for its answer,
+	// and grpcDrainTimeout how long calls may take to finish on shutdown.
+	grpcUnaryTimeout = 30 * time.Second
+	grpcDrainTimeout = 5 * time.Second
+	// grpcEventQueue bounds the events a call has yet to send.
+	grpcEventQueue = 64
+)
+
+// grpcMethod is a unary call of the Terminal service: a management request
+// and the event that answers it.
+type grpcMethod struct {
+	Name    string
+	Request string
+	Event   string
+}
+
+var grpcUnaryMethods = []grpcMethod{
+	{"List", requestTypeList, eventTypeList},
+	{"Stats", requestTypeStats, eventTypeStats},
+	{"ListShells", requestTypeListShells, eventTypeShells},
+	{"Probe", requestTypeProbe, eventTypeProbe},
+	{"CloseAll", requestTypeCloseAll, eventTypeClosedAll},
+	{"Shutdown", requestTypeShutdown, eventTypeShutdownAck},
+}
+
+func grpcProtoPackage() string {
+	return fmt.Sprintf("hapi.pty.v%d", protocolVersion)
+}
+
+// serveGRPC is serveClientsConcurrently for --listen grpc://, serving the
+// Terminal service of ptypb over TLS. Each Session stream is a client of the
+// shared sidecar, authenticated by the bearer token in its authorization
+// metadata. A unary call is a client that leaves once its answer arrives.
+func serveGRPC(listener net.Listener, token string, cfg runConfig, stderr io.Writer, stop <-chan struct{}) (exitCode int) {
+	tlsConfig, err := loadTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSClientCA)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	cfg = withRuntimeDefaults(cfg)
+	s := newSidecar(cfg, io.Discard)
+	defer s.recoverMainLoop(&exitCode)
+	defer cfg.Debug.Track(s)()
+	m := newClientManager(s)
+
+	messages := make(chan clientMessage, 64)
+	service := &grpcService{m: m, token: token, messages: messages}
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.MaxRecvMsgSize(cfg.MaxRequestBytes),
+		grpc.UnaryInterceptor(service.authorizeUnary),
+		grpc.StreamInterceptor(service.authorizeStream),
+	)
+	ptypb.RegisterTerminalServer(server, service)
+	acceptErr := make(chan error, 1)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			acceptErr <- err
+		}
+	}()
+	exitCode = m.run(messages, acceptErr, func() { _ = listener.Close() }, stderr, stop)
+
+	// Let the calls the main loop ended send their status.
+	drained := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(grpcDrainTimeout):
+		server.Stop()
+	}
+	return exitCode
+}
+
+// grpcService implements the Terminal service on a client manager.
+type grpcService struct {
+	ptypb.UnimplementedTerminalServer
+
+	m        *clientManager
+	token    string
+	messages chan<- clientMessage
+}
+
+// authorize checks the bearer token in the authorization metadata of a
+// call, recording the attempt in the audit log.
+func (g *grpcService) authorize(ctx context.Context) error {
+	bearer := ""
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		bearer, _ = strings.CutPrefix(values[0], "Bearer ")
+	}
+	audit := g.m.s.cfg.Audit
+	peer := grpcPeerIdentity(ctx)
+	if subtle.ConstantTimeCompare([]byte(bearer), []byte(g.token)) != 1 {
+		audit.Record(auditRecord{Client: peer, Request: requestTypeAuth}, newSidecarError(errorCodeUnauthorized, "invalid auth token"))
+		return grpcFailure(ctx, codes.Unauthenticated, "invalid auth token", errorCodeUnauthorized)
+	}
+	audit.Record(auditRecord{Client: peer, Request: requestTypeAuth}, nil)
+	return nil
+}
+
+func (g *grpcService) authorizeUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := g.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (g *grpcService) authorizeStream(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.authorize(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// grpcPeerIdentity is peerIdentity for the client of a gRPC call.
+func grpcPeerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	identity := p.Addr.String()
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+		identity = info.State.PeerCertificates[0].Subject.CommonName + "@" + identity
+	}
+	return identity
+}
+
+// grpcFailure is the status of a failed call. errorCode, when set, is the
+// sidecar's code for the failure and goes out in the trailers.
+func grpcFailure(ctx context.Context, code codes.Code, message string, errorCode string) error {
+	if errorCode != "" {
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(grpcErrorCodeHeader, errorCode))
+	}
+	return status.Error(code, message)
+}
+
+// Session serves a client for as long as the stream lasts. A request larger
+// than --max-request-bytes ends the stream with RESOURCE_EXHAUSTED, as gRPC
+// fails the stream rather than the message.
+func (g *grpcService) Session(stream ptypb.Terminal_SessionServer) error {
+	ctx := stream.Context()
+	clientID := ""
+	if values := metadata.ValueFromIncomingContext(ctx, grpcClientIDHeader); len(values) > 0 {
+		clientID = values[0]
+	}
+	conn := newGRPCConn(grpcPeerIdentity(ctx))
+	defer conn.Close()
+	client, err := g.m.join(clientID, conn)
+	if err != nil {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+	go g.m.feed(client, startGRPCRequestReader(stream), g.messages)
+
+	send := func(payload any) error {
+		event, err := protoEvent(payload)
+		if err != nil || event == nil {
+			return err
+		}
+		return stream.Send(event)
+	}
+	for {
+		select {
+		case payload := <-conn.events:
+			if err := send(payload); err != nil {
+				return err
+			}
+		case <-conn.done:
+			// Send what was queued before the client was let go, e.g. the
+			// answer to a shutdown request.
+			for {
+				select {
+				case payload := <-conn.events:
+					if err := send(payload); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// startGRPCRequestReader is startRequestReader for the Request messages of
+// a Session.
+func startGRPCRequestReader(stream ptypb.Terminal_SessionServer) <-chan scannerMessage {
+	out := make(chan scannerMessage, 32)
+	go func() {
+		defer close(out)
+
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+					err = nil
+				}
+				out <- scannerMessage{Done: true, Err: err}
+				return
+			}
+			line, err := protoRequestLine(req)
+			if err != nil {
+				out <- scannerMessage{Invalid: err}
+				continue
+			}
+			out <- scannerMessage{Line: line}
+		}
+	}()
+
+	return out
+}
+
+func (g *grpcService) List(ctx context.Context, req *ptypb.ListRequest) (*ptypb.ListEvent, error) {
+	reply := &ptypb.ListEvent{}
+	return reply, g.call(ctx, requestTypeList, eventTypeList, req, reply)
+}
+
+func (g *grpcService) Stats(ctx context.Context, req *ptypb.StatsRequest) (*ptypb.StatsEvent, error) {
+	reply := &ptypb.StatsEvent{}
+	return reply, g.call(ctx, requestTypeStats, eventTypeStats, req, reply)
+}
+
+func (g *grpcService) ListShells(ctx context.Context, req *ptypb.ListShellsRequest) (*ptypb.ShellsEvent, error) {
+	reply := &ptypb.ShellsEvent{}
+	return reply, g.call(ctx, requestTypeListShells, eventTypeShells, req, reply)
+}
+
+func (g *grpcService) Probe(ctx context.Context, req *ptypb.ProbeRequest) (*ptypb.ProbeEvent, error) {
+	reply := &ptypb.ProbeEvent{}
+	return reply, g.call(ctx, requestTypeProbe, eventTypeProbe, req, reply)
+}
+
+func (g *grpcService) CloseAll(ctx context.Context, req *ptypb.CloseAllRequest) (*ptypb.ClosedAllEvent, error) {
+	reply := &ptypb.ClosedAllEvent{}
+	return reply, g.call(ctx, requestTypeCloseAll, eventTypeClosedAll, req, reply)
+}
+
+func (g *grpcService) Shutdown(ctx context.Context, req *ptypb.ShutdownRequest) (*ptypb.ShutdownAckEvent, error) {
+	reply := &ptypb.ShutdownAckEvent{}
+	return reply, g.call(ctx, requestTypeShutdown, eventTypeShutdownAck, req, reply)
+}
+
+// call runs req, the request of a unary call, as a client of its own, and
+// fills reply from the eventType event that answers it or fails with the
+// error the request ran into.
+func (g *grpcService) call(ctx context.Context, requestType string, eventType string, req proto.Message, reply proto.Message) error {
+	line, err := protoMessageLine(requestType, req.ProtoReflect())
+	if err != nil {
+		return grpcFailure(ctx, codes.InvalidArgument, err.Error(), errorCodeInvalidRequest)
+	}
+	conn := newGRPCConn(grpcPeerIdentity(ctx))
+	client, err := g.m.join("", conn)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer func() { g.messages <- clientMessage{client: client, left: true} }()
+	g.messages <- clientMessage{client: client, line: line}
+
+	timeout := time.NewTimer(grpcUnaryTimeout)
+	defer timeout.Stop()
+	for {
+		var payload any
+		select {
+		case payload = <-conn.events:
+		case <-conn.done:
+			select {
+			case payload = <-conn.events:
+			default:
+				return status.Error(codes.Unavailable, "the sidecar ended the call")
+			}
+		case <-timeout.C:
+			return status.Error(codes.DeadlineExceeded, requestType+" was not answered within "+grpcUnaryTimeout.String())
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		line, err := json.Marshal(payload)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		var evt struct {
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(line, &evt); err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		switch evt.Type {
+		case eventTypeError:
+			code := codes.Unknown
+			if evt.Code == errorCodeInvalidRequest {
+				code = codes.InvalidArgument
+			}
+			return grpcFailure(ctx, code, evt.Message, evt.Code)
+		case eventType:
+			if err := protoEventJSON.Unmarshal(line, reply); err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			return nil
+		}
+	}
+}
+
+// protoEventJSON reads an event's JSON into its message, leaving out the
+// type the envelope field already says.
+var protoEventJSON = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+// protoEvent turns an event into the Event envelope, or nil when the
+// envelope has no field for it.
+func protoEvent(payload any) (*ptypb.Event, error) {
+	line, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	var evt struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(line, &evt); err != nil {
+		return nil, err
+	}
+
+	envelope := &ptypb.Event{}
+	m := envelope.ProtoReflect()
+	field := m.Descriptor().Fields().ByNumber(protoreflect.FieldNumber(protoFieldNumber(evt.Type)))
+	if field == nil || string(field.Name()) != protoFieldName(evt.Type) {
+		return nil, nil
+	}
+	message := m.NewField(field).Message()
+	if err := protoEventJSON.Unmarshal(line, message.Interface()); err != nil {
+		return nil, fmt.Errorf("%s event: %w", evt.Type, err)
+	}
+	m.Set(field, protoreflect.ValueOfMessage(message))
+	return envelope, nil
+}
+
+// grpcRequestTypes names the request type of each Request envelope field.
+var grpcRequestTypes = sync.OnceValue(func() map[protoreflect.FieldNumber]string {
+	types := make(map[protoreflect.FieldNumber]string, len(protocolRequests))
+	for _, msg := range protocolRequests {
+		types[protoreflect.FieldNumber(protoFieldNumber(msg.Type))] = msg.Type
+	}
+	return types
+})
+
+// protoRequestLine turns a Request envelope into a request line, typed
+// after the envelope field that is set.
+func protoRequestLine(req *ptypb.Request) ([]byte, error) {
+	m := req.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("request"))
+	if field == nil {
+		return nil, errors.New("invalid protobuf request: no request message is set")
+	}
+	return protoMessageLine(grpcRequestTypes()[field.Number()], m.Get(field).Message())
+}
+
+func protoMessageLine(requestType string, m protoreflect.Message) ([]byte, error) {
+	fields := protoMessageJSON(m)
+	fields["type"] = requestType
+	return json.Marshal(fields)
+}
+
+// protoMessageJSON turns a message into the JSON form of the protocol
+// struct it was generated from. protojson would write 64-bit integers as
+// strings, which the structs do not take.
+func protoMessageJSON(m protoreflect.Message) map[string]any {
+	fields := make(map[string]any)
+	m.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.IsList():
+			fields[field.JSONName()] = protoListJSON(field, value.List())
+		case field.IsMap():
+			entries := make(map[string]any, value.Map().Len())
+			value.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				entries[key.String()] = protoValueJSON(field.MapValue(), value)
+				return true
+			})
+			fields[field.JSONName()] = entries
+		default:
+			fields[field.JSONName()] = protoValueJSON(field, value)
+		}
+		return true
+	})
+	return fields
+}
+
+func protoListJSON(field protoreflect.FieldDescriptor, list protoreflect.List) []any {
+	items := make([]any, list.Len())
+	for i := range items {
+		items[i] = protoValueJSON(field, list.Get(i))
+	}
+	return items
+}
+
+// protoValueJSON is protoMessageJSON for one value of field, unwrapping the
+// messages protocolProto adds to hold the lists of a map.
+func protoValueJSON(field protoreflect.FieldDescriptor, value protoreflect.Value) any {
+	if field.Kind() != protoreflect.MessageKind {
+		return value.Interface()
+	}
+	message := value.Message()
+	if fields := message.Descriptor().Fields(); fields.Len() == 1 && fields.Get(0).Name() == protoListField && fields.Get(0).IsList() {
+		return protoListJSON(fields.Get(0), message.Get(fields.Get(0)).List())
+	}
+	return protoMessageJSON(message)
+}
+
+// sendGRPCEvent is the event encoder of --listen grpc:// clients, whose
+// conns are grpcConns: it queues payload for the call to send.
+func sendGRPCEvent(w io.Writer, payload any) error {
+	conn, ok := w.(*grpcConn)
+	if !ok {
+		return fmt.Errorf("gRPC events need a gRPC call, not %T", w)
+	}
+	return conn.send(payload)
+}
+
+// grpcConn is the net.Conn of a gRPC call's client. It carries events
+// rather than bytes: sendGRPCEvent queues them for the call's handler to
+// send, within the write deadline. Closing the conn ends the call.
+type grpcConn struct {
+	peer   string
+	events chan any
+	done   chan struct{}
+	closed sync.Once
+
+	mu       sync.Mutex
+	deadline time.Time
+}
+
+func newGRPCConn(peer string) *grpcConn {
+	return &grpcConn{peer: peer, events: make(chan any, grpcEventQueue), done: make(chan struct{})}
+}
+
+func (c *grpcConn) send(payload any) error {
+	c.mu.Lock()
+	deadline := c.deadline
+	c.mu.Unlock()
+
+	var expired <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case <-c.done:
+		return net.ErrClosed
+	default:
+	}
+	select {
+	case c.events <- payload:
+		return nil
+	case <-c.done:
+		return net.ErrClosed
+	case <-expired:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+func (c *grpcConn) Read([]byte) (int, error) { return 0, io.EOF }
+
+func (c *grpcConn) Write([]byte) (int, error) {
+	return 0, errors.New("gRPC calls carry events, not bytes")
+}
+
+func (c *grpcConn) Close() error {
+	c.closed.Do(func() { close(c.done) })
+	return nil
+}
+
+func (c *grpcConn) LocalAddr() net.Addr  { return grpcAddr("") }
+func (c *grpcConn) RemoteAddr() net.Addr { return grpcAddr(c.peer) }
+
+func (c *grpcConn) SetDeadline(t time.Time) error { return c.SetWriteDeadline(t) }
+
+func (c *grpcConn) SetReadDeadline(time.Time) error { return nil }
+
+func (c *grpcConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	return nil
+}
+
+type grpcAddr string
+
+func (a grpcAddr) Network() string { return "grpc" }
+func (a grpcAddr) String() string  { return string(a) }
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tiann/hapi/cli/sidecar/hapi-pty/ptypb"
+)
+
+func TestParseRunFlagsGRPCRequiresTLS(t *testing.T) {
+	if _, err := parseRunFlags([]string{"--listen", "grpc://127.0.0.1:0"}, io.Discard); err == nil {
+		t.Fatal("expected grpc:// to require --tls-cert")
+	}
+	cfg, err := parseRunFlags([]string{"--listen", "grpc://127.0.0.1:0", "--tls-cert", "server.crt", "--tls-key", "server.key"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseRunFlags failed: %v", err)
+	}
+	if cfg.Encoding != wireEncodingGRPC || !cfg.MultiClient {
+		t.Fatalf("expected grpc:// to serve concurrent gRPC clients, got %+v", cfg)
+	}
+}
+
+func TestProtoRequestLineMatchesJSONSchema(t *testing.T) {
+	inheritEnv := false
+	sessionID := uint32(0)
+	want := openRequest{
+		Type:        requestTypeOpen,
+		TerminalID:  "t1",
+		Cols:        120,
+		Rows:        24,
+		Cwd:         `C:\work`,
+		Env:         map[string]string{"K": "V", "EMPTY": ""},
+		PathPrepend: []string{`C:\bin`, `D:\bin`},
+		InheritEnv:  &inheritEnv,
+		SessionID:   &sessionID,
+		Mock: &mockOptions{
+			Prompt:  "$ ",
+			Replies: map[string][]mockStep{"ls": {{Data: "a.txt\r\n", DelayMs: 5}}},
+		},
+	}
+
+	line, err := protoRequestLine(&ptypb.Request{Request: &ptypb.Request_Open{Open: &ptypb.OpenRequest{
+		TerminalId:  "t1",
+		Cols:        120,
+		Rows:        24,
+		Cwd:         `C:\work`,
+		Env:         map[string]string{"K": "V", "EMPTY": ""},
+		PathPrepend: []string{`C:\bin`, `D:\bin`},
+		InheritEnv:  proto.Bool(false),
+		SessionId:   proto.Uint32(0),
+		Mock: &ptypb.MockOptions{
+			Prompt: "$ ",
+			Replies: map[string]*ptypb.MockStepList{
+				"ls": {Items: []*ptypb.MockStep{{Data: "a.txt\r\n", DelayMs: 5}}},
+			},
+		},
+	}}})
+	if err != nil {
+		t.Fatalf("protoRequestLine failed: %v", err)
+	}
+	got, err := decodeRequestLine(line)
+	if err != nil {
+		t.Fatalf("decodeRequestLine failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+
+	if _, err := protoRequestLine(&ptypb.Request{}); err == nil {
+		t.Fatal("expected an empty Request envelope to be refused")
+	}
+}
+
+func TestProtoEventFillsTheEnvelope(t *testing.T) {
+	event, err := protoEvent(exitEvent{Type: eventTypeExit, TerminalID: "t1", Code: 3})
+	if err != nil {
+		t.Fatalf("protoEvent failed: %v", err)
+	}
+	if exit := event.GetExit(); exit == nil || exit.TerminalId != "t1" || exit.Code != 3 {
+		t.Fatalf("expected the exit event in the envelope, got %v", event)
+	}
+	if event, err := protoEvent(map[string]any{"type": "unregistered"}); event != nil || err != nil {
+		t.Fatalf("expected an unregistered event to be left out, got %v (%v)", event, err)
+	}
+}
+
+func TestServeGRPCSessionAndUnaryCalls(t *testing.T) {
+	server := newTestCertificate(t, "server")
+	listener, err := listenTransport(runConfig{Listen: "grpc://127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("listenTransport failed: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	served := make(chan int, 1)
+	go func() {
+		served <- serveGRPC(listener, "secret", runConfig{
+			Encoding:    wireEncodingGRPC,
+			MultiClient: true,
+			IdleTimeout: time.Minute,
+			TLSCert:     server.certFile,
+			TLSKey:      server.keyFile,
+			ProbeConPTY: func() error { return nil },
+		}, io.Discard, nil)
+	}()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: server.pool})))
+	if err != nil {
+		t.Fatalf("grpc.NewClient failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	client := ptypb.NewTerminalClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	authorized := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+
+	var trailer metadata.MD
+	_, err = client.List(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong"), &ptypb.ListRequest{}, grpc.Trailer(&trailer))
+	if code := trailer.Get(grpcErrorCodeHeader); status.Code(err) != codes.Unauthenticated || len(code) == 0 || code[0] != errorCodeUnauthorized {
+		t.Fatalf("expected an invalid token to be unauthenticated, got %v (%v)", err, trailer)
+	}
+
+	session, err := client.Session(metadata.AppendToOutgoingContext(authorized, grpcClientIDHeader, "ui"))
+	if err != nil {
+		t.Fatalf("Session failed: %v", err)
+	}
+	receive := func() *ptypb.Event {
+		t.Helper()
+		event, err := session.Recv()
+		if err != nil {
+			t.Fatalf("receive failed: %v", err)
+		}
+		return event
+	}
+	ping := func() {
+		t.Helper()
+		if err := session.Send(&ptypb.Request{Request: &ptypb.Request_Ping{Ping: &ptypb.PingRequest{}}}); err != nil {
+			t.Fatalf("send ping failed: %v", err)
+		}
+	}
+	if event := receive(); event.GetHello() == nil {
+		t.Fatalf("expected hello first, got %v", event)
+	}
+	ping()
+	if event := receive(); event.GetPong() == nil {
+		t.Fatalf("expected pong, got %v", event)
+	}
+	if err := session.Send(&ptypb.Request{}); err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	if event := receive(); event.GetError() == nil {
+		t.Fatalf("expected an empty envelope to be reported, got %v", event)
+	}
+
+	list, err := client.List(authorized, &ptypb.ListRequest{RequestId: "l1"})
+	if err != nil || list.RequestId != "l1" {
+		t.Fatalf("expected the list event as the answer, got %v (%v)", list, err)
+	}
+	ping()
+	if event := receive(); event.GetPong() == nil {
+		t.Fatalf("a unary call's answer should not reach the session, got %v", event)
+	}
+
+	if _, err := client.Shutdown(authorized, &ptypb.ShutdownRequest{}); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if _, err := session.Recv(); err == nil {
+		t.Fatal("expected shutting down to end the session")
+	}
+	select {
+	case exitCode := <-served:
+		if exitCode != 0 {
+			t.Fatalf("expected a clean shutdown, got exit code %d", exitCode)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveGRPC did not return after shutdown")
+	}
+}
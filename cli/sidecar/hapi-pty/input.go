@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	writeEncodingText   = "text"
+	writeEncodingBase64 = "base64"
+)
+
+const (
+	// maxAssembledWriteBytes caps a multi-part write reassembled from "more" pieces.
+	maxAssembledWriteBytes = 64 * 1024 * 1024
+	// ptyWriteChunkBytes paces large writes so the PTY input pipe drains between slices.
+	ptyWriteChunkBytes = 4096
+	// maxQueuedWriteBytes bounds input waiting for a terminal whose child is not reading.
+	maxQueuedWriteBytes = 64 * 1024 * 1024
+)
+
+const (
+	eofSequenceCtrlZ = "ctrl-z"
+	eofSequenceCtrlD = "ctrl-d"
+)
+
+// eofSequence returns the input that ends a program's stdin. ConPTY has no
+// way to close input for one program only, so EOF is typed like a user would.
+func eofSequence(name string) (string, error) {
+	switch name {
+	case "", eofSequenceCtrlZ:
+		return "\x1a\r", nil
+	case eofSequenceCtrlD:
+		return "\x04", nil
+	default:
+		return "", newSidecarError(errorCodeInvalidRequest, "unsupported eof sequence %q", name)
+	}
+}
+
+// writeAssembler reassembles writes split across requests with more=true.
+type writeAssembler struct {
+	pending []byte
+}
+
+// Add decodes a write piece and returns the full payload once the final piece
+// (more=false) arrives.
+func (a *writeAssembler) Add(req writeRequest) (string, bool, error) {
+	piece, err := decodeWriteData(req)
+	if err != nil {
+		a.pending = nil
+		return "", false, err
+	}
+
+	if len(a.pending)+len(piece) > maxAssembledWriteBytes {
+		a.pending = nil
+		return "", false, newSidecarError(errorCodeRequestTooLarge, "multi-part write exceeds %d bytes", maxAssembledWriteBytes)
+	}
+
+	if req.More {
+		a.pending = append(a.pending, piece...)
+		return "", false, nil
+	}
+
+	if len(a.pending) == 0 {
+		return piece, true, nil
+	}
+	data := string(append(a.pending, piece...))
+	a.pending = nil
+	return data, true, nil
+}
+
+// writeQueue delivers input to one terminal from its own goroutine, so a
+// child that stops reading stdin cannot stall the request loop.
+type writeQueue struct {
+	session terminalSession
+	onError func(error)
+
+	mu          sync.Mutex
+	cond        *sync.Cond
+	items       []string
+	queuedBytes int
+	closed      bool
+}
+
+func newWriteQueue(session terminalSession, onError func(error)) *writeQueue {
+	queue := &writeQueue{
+		session: session,
+		onError: onError,
+	}
+	queue.cond = sync.NewCond(&queue.mu)
+	return queue
+}
+
+func (q *writeQueue) Enqueue(data string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.admitLocked(data); err != nil {
+		return err
+	}
+	q.pushLocked(data)
+	return nil
+}
+
+// enqueueAll queues data on every queue or on none of them. All queues are
+// locked and checked before the first is written, so one backlogged target
+// refuses the whole batch. It returns the index of the queue that refused.
+// Callers pass the queues in a consistent order so batches cannot deadlock.
+func enqueueAll(queues []*writeQueue, data string) (int, error) {
+	for idx, q := range queues {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if err := q.admitLocked(data); err != nil {
+			return idx, err
+		}
+	}
+	for _, q := range queues {
+		q.pushLocked(data)
+	}
+	return -1, nil
+}
+
+// admitLocked reports why data cannot be queued. q.mu must be held.
+func (q *writeQueue) admitLocked(data string) error {
+	if q.closed {
+		return newSidecarError(errorCodeTerminalNotFound, "terminal is closing")
+	}
+	if q.queuedBytes+len(data) > maxQueuedWriteBytes {
+		return newSidecarError(
+			errorCodeWriteBacklogged,
+			"write dropped: %d bytes already queued for a terminal that is not reading input",
+			q.queuedBytes,
+		)
+	}
+	return nil
+}
+
+func (q *writeQueue) pushLocked(data string) {
+	q.items = append(q.items, data)
+	q.queuedBytes += len(data)
+	q.cond.Signal()
+}
+
+// Run drains the queue until Close is called.
+// QueuedBytes reports input waiting to reach the terminal.
+func (q *writeQueue) QueuedBytes() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.queuedBytes
+}
+
+func (q *writeQueue) Run() {
+	for {
+		q.mu.Lock()
+		for len(q.items) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if q.closed {
+			q.mu.Unlock()
+			return
+		}
+		data := q.items[0]
+		q.items = q.items[1:]
+		q.mu.Unlock()
+
+		err := writePaced(q.session, data)
+
+		q.mu.Lock()
+		q.queuedBytes -= len(data)
+		q.mu.Unlock()
+
+		if err != nil && q.onError != nil {
+			q.onError(err)
+		}
+	}
+}
+
+// Close stops the queue, dropping input that has not been written yet.
+func (q *writeQueue) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.items = nil
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// writePaced hands data to the session in bounded slices.
+func writePaced(session terminalSession, data string) error {
+	for len(data) > ptyWriteChunkBytes {
+		if err := session.Write(data[:ptyWriteChunkBytes]); err != nil {
+			return err
+		}
+		data = data[ptyWriteChunkBytes:]
+	}
+	if data == "" {
+		return nil
+	}
+	return session.Write(data)
+}
+
+// decodeWriteData returns the exact bytes a write request asks to send to the PTY.
+func decodeWriteData(req writeRequest) (string, error) {
+	var data string
+	switch req.Encoding {
+	case "", writeEncodingText:
+		data = req.Data
+	case writeEncodingBase64:
+		decoded, err := base64.StdEncoding.DecodeString(req.Data)
+		if err != nil {
+			return "", newSidecarError(errorCodeInvalidRequest, "invalid base64 write data: %v", err)
+		}
+		data = string(decoded)
+	default:
+		return "", newSidecarError(errorCodeInvalidRequest, "unsupported write encoding %q", req.Encoding)
+	}
+
+	if req.Sanitize {
+		data = sanitizePaste(data)
+	}
+
+	if len(req.Keys) == 0 {
+		return data, nil
+	}
+
+	keys, err := encodeKeys(req.Keys)
+	if err != nil {
+		return "", err
+	}
+	return data + keys, nil
+}
+
+// sanitizePaste strips control characters and escape sequences from pasted
+// text so clipboard content cannot inject terminal commands. Tab, newline and
+// carriage return survive; CSI, OSC, DCS, SOS, PM and APC sequences are dropped
+// whole, including their payloads.
+func sanitizePaste(data string) string {
+	var builder strings.Builder
+	builder.Grow(len(data))
+
+	runes := []rune(data)
+	for idx := 0; idx < len(runes); idx++ {
+		r := runes[idx]
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			builder.WriteRune(r)
+		case r == 0x1b:
+			idx = skipEscapeSequence(runes, idx)
+		case r == 0x9b:
+			idx = skipCSI(runes, idx+1)
+		case r == 0x90 || r == 0x9d || r == 0x98 || r == 0x9e || r == 0x9f:
+			idx = skipString(runes, idx+1)
+		case r < 0x20 || r == 0x7f || (r >= 0x80 && r <= 0x9f):
+		case r == utf8.RuneError:
+		default:
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
+
+// skipEscapeSequence returns the index of the last rune belonging to the
+// escape sequence starting at runes[start].
+func skipEscapeSequence(runes []rune, start int) int {
+	next := start + 1
+	if next >= len(runes) {
+		return start
+	}
+
+	switch runes[next] {
+	case '[':
+		return skipCSI(runes, next+1)
+	case ']', 'P', 'X', '^', '_':
+		return skipString(runes, next+1)
+	default:
+		return next
+	}
+}
+
+func skipCSI(runes []rune, idx int) int {
+	for ; idx < len(runes); idx++ {
+		if runes[idx] >= 0x40 && runes[idx] <= 0x7e {
+			return idx
+		}
+	}
+	return len(runes) - 1
+}
+
+// skipString consumes a control string terminated by BEL, ST (ESC \) or C1 ST.
+func skipString(runes []rune, idx int) int {
+	for ; idx < len(runes); idx++ {
+		switch runes[idx] {
+		case 0x07, 0x9c:
+			return idx
+		case 0x1b:
+			if idx+1 < len(runes) && runes[idx+1] == '\\' {
+				return idx + 1
+			}
+		}
+	}
+	return len(runes) - 1
+}
+
+const (
+	keyModShift = 1 << iota
+	keyModAlt
+	keyModCtrl
+)
+
+type namedKey struct {
+	// csi is the final byte of a CSI sequence, or 0 for tilde-style keys.
+	csi byte
+	// tilde is the numeric parameter of a CSI <n> ~ sequence.
+	tilde int
+	// ss3 selects the SS3 form used by unmodified F1-F4.
+	ss3 bool
+}
+
+var namedKeys = map[string]namedKey{
+	"up":       {csi: 'A'},
+	"down":     {csi: 'B'},
+	"right":    {csi: 'C'},
+	"left":     {csi: 'D'},
+	"home":     {csi: 'H'},
+	"end":      {csi: 'F'},
+	"insert":   {tilde: 2},
+	"delete":   {tilde: 3},
+	"pageup":   {tilde: 5},
+	"pagedown": {tilde: 6},
+	"f1":       {csi: 'P', ss3: true},
+	"f2":       {csi: 'Q', ss3: true},
+	"f3":       {csi: 'R', ss3: true},
+	"f4":       {csi: 'S', ss3: true},
+	"f5":       {tilde: 15},
+	"f6":       {tilde: 17},
+	"f7":       {tilde: 18},
+	"f8":       {tilde: 19},
+	"f9":       {tilde: 20},
+	"f10":      {tilde: 21},
+	"f11":      {tilde: 23},
+	"f12":      {tilde: 24},
+}
+
+var keyAliases = map[string]string{
+	"esc":    "escape",
+	"return": "enter",
+	"del":    "delete",
+	"ins":    "insert",
+	"pgup":   "pageup",
+	"pgdn":   "pagedown",
+	"bs":     "backspace",
+}
+
+// encodeKeys translates symbolic key names such as "F5", "Ctrl+L" or
+// "Alt+Enter" into the xterm input sequences ConPTY expects.
+func encodeKeys(keys []string) (string, error) {
+	var builder strings.Builder
+	for _, key := range keys {
+		encoded, err := encodeKey(key)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(encoded)
+	}
+	return builder.String(), nil
+}
+
+func encodeKey(spec string) (string, error) {
+	parts := strings.Split(spec, "+")
+	name := parts[len(parts)-1]
+	if name == "" && len(parts) > 1 {
+		// "Ctrl++" names the plus key itself.
+		name = "+"
+		parts = parts[:len(parts)-1]
+	}
+
+	mods := 0
+	for _, part := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "shift":
+			mods |= keyModShift
+		case "alt", "meta", "option":
+			mods |= keyModAlt
+		case "ctrl", "control":
+			mods |= keyModCtrl
+		default:
+			return "", newSidecarError(errorCodeInvalidRequest, "unknown key modifier %q in %q", part, spec)
+		}
+	}
+
+	base, err := encodeBaseKey(name, mods)
+	if err != nil {
+		return "", newSidecarError(errorCodeInvalidRequest, "unknown key %q", spec)
+	}
+	return base, nil
+}
+
+func encodeBaseKey(name string, mods int) (string, error) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := keyAliases[lower]; ok {
+		lower = alias
+	}
+
+	if key, ok := namedKeys[lower]; ok {
+		return encodeNamedKey(key, mods), nil
+	}
+
+	altPrefix := ""
+	if mods&keyModAlt != 0 {
+		altPrefix = "\x1b"
+	}
+
+	switch lower {
+	case "enter":
+		return altPrefix + "\r", nil
+	case "tab":
+		if mods&keyModShift != 0 {
+			return altPrefix + "\x1b[Z", nil
+		}
+		return altPrefix + "\t", nil
+	case "escape":
+		return altPrefix + "\x1b", nil
+	case "backspace":
+		if mods&keyModCtrl != 0 {
+			return altPrefix + "\b", nil
+		}
+		return altPrefix + "\x7f", nil
+	case "space":
+		if mods&keyModCtrl != 0 {
+			return altPrefix + "\x00", nil
+		}
+		return altPrefix + " ", nil
+	}
+
+	runes := []rune(name)
+	if len(runes) != 1 || runes[0] > 0x7e || runes[0] < 0x20 {
+		return "", fmt.Errorf("unknown key %q", name)
+	}
+
+	char := byte(runes[0])
+	if mods&keyModShift != 0 && char >= 'a' && char <= 'z' {
+		char -= 'a' - 'A'
+	}
+	if mods&keyModCtrl != 0 {
+		switch {
+		case char >= 'a' && char <= 'z':
+			char &= 0x1f
+		case char >= '@' && char <= '_':
+			char &= 0x1f
+		case char == '?':
+			char = 0x7f
+		case char == ' ':
+			char = 0
+		default:
+			return "", fmt.Errorf("key %q has no control encoding", name)
+		}
+	}
+
+	return altPrefix + string([]byte{char}), nil
+}
+
+func encodeNamedKey(key namedKey, mods int) string {
+	if mods == 0 {
+		if key.tilde != 0 {
+			return fmt.Sprintf("\x1b[%d~", key.tilde)
+		}
+		if key.ss3 {
+			return "\x1bO" + string(key.csi)
+		}
+		return "\x1b[" + string(key.csi)
+	}
+
+	param := 1 + mods
+	if key.tilde != 0 {
+		return fmt.Sprintf("\x1b[%d;%d~", key.tilde, param)
+	}
+	return fmt.Sprintf("\x1b[1;%d%c", param, key.csi)
+}
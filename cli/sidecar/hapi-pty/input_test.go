@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeWriteDataText(t *testing.T) {
+	data, err := decodeWriteData(writeRequest{Data: "dir\r"})
+	if err != nil {
+		t.Fatalf("decodeWriteData failed: %v", err)
+	}
+	if data != "dir\r" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestDecodeWriteDataBase64PreservesBytes(t *testing.T) {
+	data, err := decodeWriteData(writeRequest{Data: "G1sxfv8A", Encoding: writeEncodingBase64})
+	if err != nil {
+		t.Fatalf("decodeWriteData failed: %v", err)
+	}
+	if data != "\x1b[1~\xff\x00" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+}
+
+func TestDecodeWriteDataRejectsInvalidPayloads(t *testing.T) {
+	cases := []writeRequest{
+		{Data: "%%%", Encoding: writeEncodingBase64},
+		{Data: "x", Encoding: "hex"},
+	}
+
+	for _, req := range cases {
+		_, err := decodeWriteData(req)
+
+		var serr *sidecarError
+		if !errors.As(err, &serr) {
+			t.Fatalf("expected sidecarError for %+v, got %T", req, err)
+		}
+		if serr.Code != errorCodeInvalidRequest {
+			t.Fatalf("unexpected error code for %+v: %s", req, serr.Code)
+		}
+	}
+}
+
+func TestEncodeKeys(t *testing.T) {
+	cases := map[string]string{
+		"F5":         "\x1b[15~",
+		"F1":         "\x1bOP",
+		"Up":         "\x1b[A",
+		"ctrl+up":    "\x1b[1;5A",
+		"Shift+F5":   "\x1b[15;2~",
+		"Ctrl+L":     "\x0c",
+		"Ctrl+C":     "\x03",
+		"Alt+Enter":  "\x1b\r",
+		"Alt+x":      "\x1bx",
+		"Shift+a":    "A",
+		"Shift+Tab":  "\x1b[Z",
+		"Backspace":  "\x7f",
+		"Esc":        "\x1b",
+		"PgDn":       "\x1b[6~",
+		"Ctrl+Space": "\x00",
+		"Ctrl+[":     "\x1b",
+		"Alt++":      "\x1b+",
+	}
+
+	for spec, expected := range cases {
+		encoded, err := encodeKey(spec)
+		if err != nil {
+			t.Fatalf("encodeKey(%q) failed: %v", spec, err)
+		}
+		if encoded != expected {
+			t.Fatalf("encodeKey(%q) = %q, want %q", spec, encoded, expected)
+		}
+	}
+}
+
+func TestDecodeWriteDataAppendsKeys(t *testing.T) {
+	data, err := decodeWriteData(writeRequest{Data: "ls", Keys: []string{"Enter", "Ctrl+L"}})
+	if err != nil {
+		t.Fatalf("decodeWriteData failed: %v", err)
+	}
+	if data != "ls\r\x0c" {
+		t.Fatalf("unexpected data: %q", data)
+	}
+
+	if _, err := decodeWriteData(writeRequest{Keys: []string{"Hyper+Q"}}); err == nil {
+		t.Fatal("expected unknown modifier error")
+	}
+}
+
+func TestSanitizePasteStripsInjectionSequences(t *testing.T) {
+	cases := map[string]string{
+		"echo hi\r\n":                      "echo hi\r\n",
+		"a\tb":                             "a\tb",
+		"safe\x1b[201~rm -rf /\r":          "saferm -rf /\r",
+		"title\x1b]0;pwned\x07done":        "titledone",
+		"clip\x1b]52;c;ZXZpbA==\x1b\\tail": "cliptail",
+		"dcs\x1bPq#0;2;0;0;0\x1b\\end":     "dcsend",
+		"bell\x07null\x00del\x7f":          "bellnulldel",
+		"c1\u009b31mred":                   "c1red",
+		"unicode ✓ stays":                  "unicode ✓ stays",
+		"unterminated\x1b]0;never ends":    "unterminated",
+		"reset\x1bcdone":                   "resetdone",
+	}
+
+	for input, expected := range cases {
+		if got := sanitizePaste(input); got != expected {
+			t.Fatalf("sanitizePaste(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestDecodeWriteDataSanitizesOnlyWhenRequested(t *testing.T) {
+	raw := "ls\x1b[201~\r"
+
+	data, err := decodeWriteData(writeRequest{Data: raw})
+	if err != nil {
+		t.Fatalf("decodeWriteData failed: %v", err)
+	}
+	if data != raw {
+		t.Fatalf("expected raw data without sanitize, got %q", data)
+	}
+
+	data, err = decodeWriteData(writeRequest{Data: raw, Sanitize: true, Keys: []string{"Ctrl+C"}})
+	if err != nil {
+		t.Fatalf("decodeWriteData failed: %v", err)
+	}
+	if data != "ls\r\x03" {
+		t.Fatalf("expected sanitized data with keys intact, got %q", data)
+	}
+}
+
+func TestWriteAssemblerReassemblesParts(t *testing.T) {
+	var assembler writeAssembler
+
+	if _, ready, err := assembler.Add(writeRequest{Data: "aGVs", Encoding: writeEncodingBase64, More: true}); err != nil || ready {
+		t.Fatalf("first piece should be buffered: ready=%v err=%v", ready, err)
+	}
+	if _, ready, err := assembler.Add(writeRequest{Data: "lo ", More: true}); err != nil || ready {
+		t.Fatalf("second piece should be buffered: ready=%v err=%v", ready, err)
+	}
+
+	data, ready, err := assembler.Add(writeRequest{Data: "world", Keys: []string{"Enter"}})
+	if err != nil || !ready {
+		t.Fatalf("final piece should complete the write: ready=%v err=%v", ready, err)
+	}
+	if data != "hello world\r" {
+		t.Fatalf("unexpected assembled data: %q", data)
+	}
+
+	data, ready, err = assembler.Add(writeRequest{Data: "next"})
+	if err != nil || !ready || data != "next" {
+		t.Fatalf("assembler should reset after completion: %q ready=%v err=%v", data, ready, err)
+	}
+}
+
+func TestWriteAssemblerDropsPendingOnInvalidPiece(t *testing.T) {
+	var assembler writeAssembler
+
+	_, _, _ = assembler.Add(writeRequest{Data: "stale", More: true})
+	if _, _, err := assembler.Add(writeRequest{Data: "!!", Encoding: writeEncodingBase64}); err == nil {
+		t.Fatal("expected invalid base64 error")
+	}
+
+	data, _, _ := assembler.Add(writeRequest{Data: "fresh"})
+	if data != "fresh" {
+		t.Fatalf("expected pending data to be dropped, got %q", data)
+	}
+}
+
+func TestWritePacedSplitsLargePayloads(t *testing.T) {
+	terminal := &fakeTerminal{}
+	payload := strings.Repeat("p", ptyWriteChunkBytes*2+10)
+
+	if err := writePaced(terminal, payload); err != nil {
+		t.Fatalf("writePaced failed: %v", err)
+	}
+
+	writes := terminal.Writes()
+	if len(writes) != 3 || len(writes[0]) != ptyWriteChunkBytes || len(writes[2]) != 10 {
+		t.Fatalf("unexpected write slices: %d", len(writes))
+	}
+	if strings.Join(writes, "") != payload {
+		t.Fatal("paced writes do not reassemble the payload")
+	}
+}
+
+type blockingTerminal struct {
+	fakeTerminal
+	release chan struct{}
+}
+
+func (b *blockingTerminal) Write(data string) error {
+	<-b.release
+	return b.fakeTerminal.Write(data)
+}
+
+func TestWriteQueueDeliversInOrder(t *testing.T) {
+	terminal := &fakeTerminal{}
+	queue := newWriteQueue(terminal, nil)
+	go queue.Run()
+	defer queue.Close()
+
+	for _, data := range []string{"a", "b", "c"} {
+		if err := queue.Enqueue(data); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	if writes := terminal.waitForWrites(t, 3); strings.Join(writes, "") != "abc" {
+		t.Fatalf("unexpected writes: %#v", writes)
+	}
+}
+
+func TestWriteQueueReportsBacklog(t *testing.T) {
+	terminal := &blockingTerminal{release: make(chan struct{})}
+	queue := newWriteQueue(terminal, nil)
+	go queue.Run()
+	defer queue.Close()
+	defer close(terminal.release)
+
+	if err := queue.Enqueue(strings.Repeat("x", maxQueuedWriteBytes)); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+
+	err := queue.Enqueue("more")
+	var serr *sidecarError
+	if !errors.As(err, &serr) || serr.Code != errorCodeWriteBacklogged {
+		t.Fatalf("expected write_backlogged error, got %v", err)
+	}
+}
+
+func TestSidecarWriteDoesNotBlockOtherTerminals(t *testing.T) {
+	stuck := &blockingTerminal{release: make(chan struct{})}
+	defer close(stuck.release)
+
+	healthy := &fakeTerminal{}
+	ts := newTestSidecar(t, runConfig{
+		TerminalOpener: func(req openRequest, _ resolvedShell, _ terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+			if req.TerminalID == "stuck" {
+				return stuck, nil
+			}
+			return healthy, nil
+		},
+	})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "stuck", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "ok", Cols: 80, Rows: 24})
+
+	done := make(chan struct{})
+	go func() {
+		ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "stuck", Data: "blocked"})
+		ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "ok", Data: "fine"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("write to a stuck terminal blocked the request loop")
+	}
+	if writes := healthy.waitForWrites(t, 1); len(writes) != 1 || writes[0] != "fine" {
+		t.Fatalf("unexpected writes to healthy terminal: %#v", writes)
+	}
+}
@@ -0,0 +1,260 @@
+//go:build windows
+
+package main
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	jobObjectAssociateCompletionPortClass = 7
+	jobObjectExtendedLimitClass           = 9
+	jobObjectCPURateControlClass          = 15
+
+	jobObjectLimitAffinity       = 0x00000010
+	jobObjectLimitPriorityClass  = 0x00000020
+	jobObjectLimitJobMemory      = 0x00000200
+	jobObjectLimitKillOnJobClose = 0x00002000
+
+	jobObjectCPURateControlEnable  = 0x1
+	jobObjectCPURateControlHardCap = 0x4
+
+	jobObjectMsgJobMemoryLimit = 10
+
+	normalPriorityClass      = 0x00000020
+	idlePriorityClass        = 0x00000040
+	belowNormalPriorityClass = 0x00004000
+
+	createSuspended = 0x00000004
+	// statusQuotaExceeded (STATUS_QUOTA_EXCEEDED) is the exit code of a
+	// process tree killed for exceeding maxMemoryMb.
+	statusQuotaExceeded = 0xC0000044
+)
+
+var (
+	procCreateJobObjectW         = kernel32Proc.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32Proc.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32Proc.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32Proc.NewProc("TerminateJobObject")
+	procResumeThread             = kernel32Proc.NewProc("ResumeThread")
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32
+}
+
+type jobObjectAssociateCompletionPort struct {
+	CompletionKey  uintptr
+	CompletionPort syscall.Handle
+}
+
+// terminalJob holds a shell's process tree in a job object, which enforces
+// the open request's resource limits and kills the whole tree once closed.
+type terminalJob struct {
+	handle    syscall.Handle
+	port      syscall.Handle
+	closeOnce sync.Once
+}
+
+// newTerminalJob returns nil when req asks for nothing a job enforces.
+func newTerminalJob(req openRequest) (*terminalJob, error) {
+	if req.MaxMemoryMb == 0 && req.CPURatePercent == 0 && req.Priority == priorityInherit && req.Affinity == 0 {
+		return nil, nil
+	}
+
+	handle, _, err := procCreateJobObjectW.Call(0, 0)
+	if handle == 0 {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to create job object: %v", err)
+	}
+	job := &terminalJob{handle: syscall.Handle(handle)}
+
+	limits := jobObjectExtendedLimitInformation{}
+	limits.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	if req.MaxMemoryMb > 0 {
+		limits.BasicLimitInformation.LimitFlags |= jobObjectLimitJobMemory
+		limits.JobMemoryLimit = uintptr(req.MaxMemoryMb) << 20
+	}
+	if class := priorityClass(req.Priority); class != 0 {
+		limits.BasicLimitInformation.LimitFlags |= jobObjectLimitPriorityClass
+		limits.BasicLimitInformation.PriorityClass = class
+	}
+	if req.Affinity != 0 {
+		// Windows rejects masks naming processors the sidecar may not use.
+		limits.BasicLimitInformation.LimitFlags |= jobObjectLimitAffinity
+		limits.BasicLimitInformation.Affinity = uintptr(req.Affinity)
+	}
+	if err := job.set(jobObjectExtendedLimitClass, unsafe.Pointer(&limits), unsafe.Sizeof(limits)); err != nil {
+		job.Close()
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to set job limits: %v", err)
+	}
+
+	if req.CPURatePercent > 0 {
+		rate := jobObjectCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+			// CPURate is in hundredths of a percent of all processors.
+			CPURate: uint32(req.CPURatePercent * 100),
+		}
+		if err := job.set(jobObjectCPURateControlClass, unsafe.Pointer(&rate), unsafe.Sizeof(rate)); err != nil {
+			job.Close()
+			return nil, newSidecarError(errorCodeStartupFailed, "failed to set job CPU rate: %v", err)
+		}
+	}
+
+	// The memory limit only makes allocations fail; the completion port
+	// reports when it is hit so the tree can be killed instead.
+	if req.MaxMemoryMb > 0 {
+		port, err := syscall.CreateIoCompletionPort(syscall.InvalidHandle, 0, 0, 1)
+		if err != nil {
+			job.Close()
+			return nil, newSidecarError(errorCodeStartupFailed, "failed to create job completion port: %v", err)
+		}
+		job.port = port
+		association := jobObjectAssociateCompletionPort{CompletionPort: port}
+		if err := job.set(jobObjectAssociateCompletionPortClass, unsafe.Pointer(&association), unsafe.Sizeof(association)); err != nil {
+			job.Close()
+			return nil, newSidecarError(errorCodeStartupFailed, "failed to watch job limits: %v", err)
+		}
+	}
+
+	return job, nil
+}
+
+func priorityClass(priority string) uint32 {
+	switch priority {
+	case priorityIdle:
+		return idlePriorityClass
+	case priorityBelowNormal:
+		return belowNormalPriorityClass
+	case priorityNormal:
+		return normalPriorityClass
+	default:
+		return 0
+	}
+}
+
+func (j *terminalJob) set(class uintptr, info unsafe.Pointer, size uintptr) error {
+	ret, _, err := procSetInformationJobObject.Call(uintptr(j.handle), class, uintptr(info), size)
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// Assign moves a process created suspended into the job and resumes it, so
+// none of its children can start outside the job.
+func (j *terminalJob) Assign(process syscall.Handle, thread syscall.Handle) error {
+	if ret, _, err := procAssignProcessToJobObject.Call(uintptr(j.handle), uintptr(process)); ret == 0 {
+		return newSidecarError(errorCodeStartupFailed, "failed to assign shell to job object: %v", err)
+	}
+	if ret, _, err := procResumeThread.Call(uintptr(thread)); uint32(ret) == ^uint32(0) {
+		return newSidecarError(errorCodeStartupFailed, "failed to resume shell: %v", err)
+	}
+	return nil
+}
+
+// WatchMemoryLimit kills the tree with statusQuotaExceeded once the job
+// exceeds its memory limit, calling onLimit first. It returns when the job
+// is closed.
+func (j *terminalJob) WatchMemoryLimit(onLimit func()) {
+	if j == nil || j.port == 0 {
+		return
+	}
+
+	port := j.port
+	for {
+		var message, key uint32
+		var overlapped *syscall.Overlapped
+		if err := syscall.GetQueuedCompletionStatus(port, &message, &key, &overlapped, syscall.INFINITE); err != nil {
+			return
+		}
+		if message == jobObjectMsgJobMemoryLimit {
+			onLimit()
+			procTerminateJobObject.Call(uintptr(j.handle), statusQuotaExceeded)
+			return
+		}
+	}
+}
+
+// Close kills whatever is left of the tree and releases the job.
+func (j *terminalJob) Close() {
+	if j == nil {
+		return
+	}
+	j.closeOnce.Do(func() {
+		closeHandle(j.port)
+		closeHandle(j.handle)
+	})
+}
+
+// processTreeContains reports whether pid descends from root, following
+// parent links in a snapshot of the running processes.
+func processTreeContains(root uint32, pid uint32) (bool, error) {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return false, err
+	}
+	defer closeHandle(snapshot)
+
+	parents := make(map[uint32]uint32)
+	entry := syscall.ProcessEntry32{Size: uint32(unsafe.Sizeof(syscall.ProcessEntry32{}))}
+	for err = syscall.Process32First(snapshot, &entry); err == nil; err = syscall.Process32Next(snapshot, &entry) {
+		parents[entry.ProcessID] = entry.ParentProcessID
+	}
+
+	// Parent links can outlive the parent and its PID can be reused, so the
+	// walk is bounded rather than trusted to end at the root.
+	current := pid
+	for hops := 0; hops < len(parents); hops++ {
+		parent, ok := parents[current]
+		if !ok || parent == 0 {
+			return false, nil
+		}
+		if parent == root {
+			return true, nil
+		}
+		current = parent
+	}
+	return false, nil
+}
+
+func terminateProcessID(pid uint32) error {
+	process, err := syscall.OpenProcess(syscall.PROCESS_TERMINATE, false, pid)
+	if err != nil {
+		return err
+	}
+	defer closeHandle(process)
+	return syscall.TerminateProcess(process, terminateExitCode)
+}
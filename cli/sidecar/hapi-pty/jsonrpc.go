@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+const (
+	jsonrpcVersion = "2.0"
+	// jsonrpcInvalidParams and jsonrpcServerError are the JSON-RPC error
+	// codes for invalid_request errors and for every other error; data
+	// carries the error event with the sidecar's code.
+	jsonrpcInvalidParams = -32602
+	jsonrpcServerError   = -32000
+	// eventTypeJSONRPCAck answers a JSON-RPC call whose request type has
+	// no event of its own; it is written as a null result.
+	eventTypeJSONRPCAck = "jsonrpc_ack"
+)
+
+type jsonrpcRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	ID     json.RawMessage `json:"id"`
+}
+
+type jsonrpcAckEvent struct {
+	Type      string `json:"type"`
+	RequestID string `json:"requestId"`
+}
+
+// decodeWireRequest decodes a request line in the session's encoding. The
+// request ID it returns correlates a failure with a JSON-RPC call.
+func (s *sidecar) decodeWireRequest(line []byte) (request, string, error) {
+	line, requestID, err := s.wireRequestLine(line)
+	if err != nil {
+		return nil, requestID, err
+	}
+	req, err := decodeRequestLine(line)
+	return req, requestID, err
+}
+
+// wireRequestLine translates a line in the session's encoding into a
+// request line.
+func (s *sidecar) wireRequestLine(line []byte) ([]byte, string, error) {
+	if s.cfg.Encoding == wireEncodingJSONRPC {
+		return translateJSONRPC(line)
+	}
+	return line, "", nil
+}
+
+// acknowledgeCall answers a JSON-RPC call whose request has no event
+// carrying its requestId.
+func (s *sidecar) acknowledgeCall(req request, requestID string) {
+	if s.cfg.Encoding != wireEncodingJSONRPC || requestID == "" || stringField(req, "RequestID") != "" {
+		return
+	}
+	s.emit(jsonrpcAckEvent{Type: eventTypeJSONRPCAck, RequestID: requestID})
+}
+
+// translateJSONRPC turns a JSON-RPC 2.0 request into a request line: the
+// method is its type, params its fields and the id, kept as JSON text, its
+// requestId, so the event answering it can be written as the response.
+// Notifications, without an id, get no requestId. Batches and positional
+// params are not supported.
+func translateJSONRPC(line []byte) ([]byte, string, error) {
+	var msg jsonrpcRequest
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return nil, "", fmt.Errorf("invalid JSON-RPC request: %w", err)
+	}
+	requestID := ""
+	if len(msg.ID) > 0 && string(msg.ID) != "null" {
+		requestID = string(msg.ID)
+	}
+	if msg.Method == "" {
+		return nil, requestID, newSidecarError(errorCodeInvalidRequest, "JSON-RPC request has no method")
+	}
+
+	fields := map[string]json.RawMessage{}
+	if len(msg.Params) > 0 && string(msg.Params) != "null" {
+		if err := json.Unmarshal(msg.Params, &fields); err != nil {
+			return nil, requestID, newSidecarError(errorCodeInvalidRequest, "JSON-RPC params must be an object")
+		}
+	}
+	delete(fields, "requestId")
+	fields["type"], _ = json.Marshal(msg.Method)
+	if requestID != "" {
+		fields["requestId"], _ = json.Marshal(requestID)
+	}
+	translated, err := json.Marshal(fields)
+	if err != nil {
+		return nil, requestID, err
+	}
+	return translated, requestID, nil
+}
+
+// writeJSONRPCFrame writes an event answering a JSON-RPC call as its
+// response, with the event as the result or, for an error event, as the
+// error's data, and any other event as a notification whose method is the
+// event type.
+func writeJSONRPCFrame(w io.Writer, payload any) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var evt map[string]any
+	if err := decoder.Decode(&evt); err != nil {
+		return err
+	}
+
+	frame := map[string]any{"jsonrpc": jsonrpcVersion}
+	requestID, _ := evt["requestId"].(string)
+	if requestID != "" && json.Valid([]byte(requestID)) {
+		delete(evt, "requestId")
+		frame["id"] = json.RawMessage(requestID)
+		switch evt["type"] {
+		case eventTypeJSONRPCAck:
+			frame["result"] = nil
+		case eventTypeError:
+			code := jsonrpcServerError
+			if evt["code"] == errorCodeInvalidRequest {
+				code = jsonrpcInvalidParams
+			}
+			frame["error"] = map[string]any{"code": code, "message": evt["message"], "data": evt}
+		default:
+			frame["result"] = evt
+		}
+	} else {
+		frame["method"] = evt["type"]
+		delete(evt, "type")
+		frame["params"] = evt
+	}
+	return writeNDJSONLine(w, frame)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSidecarSpeaksJSONRPC(t *testing.T) {
+	stdin := bytes.NewBufferString(strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"list","id":1}`,
+		`{"jsonrpc":"2.0","method":"ping","id":"p"}`,
+		`{"jsonrpc":"2.0","method":"resize","params":["t1"],"id":2}`,
+		`{"jsonrpc":"2.0","method":"ping"}`,
+		`{"jsonrpc":"2.0","method":"shutdown"}`,
+	}, "\n") + "\n")
+	var stdout bytes.Buffer
+
+	exitCode := runSidecar(stdin, &stdout, runConfig{
+		Encoding:    wireEncodingJSONRPC,
+		IdleTimeout: 2 * time.Second,
+		ProbeConPTY: func() error { return nil },
+	})
+	if exitCode != 0 {
+		t.Fatalf("expected graceful shutdown exit code 0, got %d", exitCode)
+	}
+
+	var frames []map[string]any
+	decoder := json.NewDecoder(&stdout)
+	for decoder.More() {
+		var frame map[string]any
+		if err := decoder.Decode(&frame); err != nil {
+			t.Fatalf("decode frame failed: %v", err)
+		}
+		if frame["jsonrpc"] != jsonrpcVersion {
+			t.Fatalf("frame is not JSON-RPC 2.0: %v", frame)
+		}
+		frames = append(frames, frame)
+	}
+	if len(frames) != 7 {
+		t.Fatalf("unexpected frames: %v", frames)
+	}
+	if frames[0]["method"] != eventTypeHello || frames[0]["params"].(map[string]any)["version"] != sidecarVersion {
+		t.Fatalf("hello should be a notification, got %v", frames[0])
+	}
+	if result, _ := frames[1]["result"].(map[string]any); frames[1]["id"] != float64(1) || result["type"] != eventTypeList {
+		t.Fatalf("list should be answered with its event, got %v", frames[1])
+	}
+	if frames[2]["method"] != eventTypePong || frames[3]["id"] != "p" || frames[3]["result"] != nil {
+		t.Fatalf("a call without its own answer should get a null result after its events, got %v %v", frames[2], frames[3])
+	}
+	if rpcErr, _ := frames[4]["error"].(map[string]any); frames[4]["id"] != float64(2) || rpcErr["code"] != float64(jsonrpcInvalidParams) {
+		t.Fatalf("positional params should be refused, got %v", frames[4])
+	}
+	if frames[5]["method"] != eventTypePong || frames[6]["method"] != eventTypeShutdownAck {
+		t.Fatalf("notifications should get only their events, got %v %v", frames[5], frames[6])
+	}
+}
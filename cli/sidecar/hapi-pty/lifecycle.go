@@ -0,0 +1,439 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	closeMethodGraceful  = "graceful"
+	closeMethodEscalated = "escalated"
+	closeMethodForced    = "forced"
+
+	closeReasonIdle = "idle"
+
+	// Exit reasons tell clients why a shell exited. The first reason set for
+	// a terminal wins; without one the exit is classified from its code.
+	exitReasonNormal = "normal"
+	// exitReasonClosed marks a shell stopped by close or shutdown.
+	exitReasonClosed      = "closed"
+	exitReasonIdleTimeout = "idle_timeout"
+	// exitReasonCrashed marks a nonzero exit within crashExitWindow of the
+	// open, or any exit with an NTSTATUS error code.
+	exitReasonCrashed = "crashed"
+	// exitReasonResourceLimit marks a terminal killed for exceeding its
+	// maxMemoryMb.
+	exitReasonResourceLimit = "resource_limit_exceeded"
+	// exitReasonConPTYFailure marks a terminal whose backend failed rather
+	// than its shell exiting, such as a lost broker or a failed wait.
+	exitReasonConPTYFailure = "conpty_failure"
+
+	crashExitWindow = 5 * time.Second
+	// statusControlCExit (STATUS_CONTROL_C_EXIT) is how console programs
+	// interrupted with Ctrl+C exit.
+	statusControlCExit = 0xC000013A
+
+	// terminateExitWait bounds how long a terminated shell may take to report
+	// its exit code.
+	terminateExitWait = 2 * time.Second
+	maxCloseGrace     = 5 * time.Minute
+)
+
+// ntStatusNames names the NTSTATUS codes shells commonly exit with.
+var ntStatusNames = map[uint32]string{
+	0xC0000005:         "STATUS_ACCESS_VIOLATION",
+	0xC0000017:         "STATUS_NO_MEMORY",
+	0xC000001D:         "STATUS_ILLEGAL_INSTRUCTION",
+	0xC0000044:         "STATUS_QUOTA_EXCEEDED",
+	0xC0000094:         "STATUS_INTEGER_DIVIDE_BY_ZERO",
+	0xC00000FD:         "STATUS_STACK_OVERFLOW",
+	statusControlCExit: "STATUS_CONTROL_C_EXIT",
+	0xC0000135:         "STATUS_DLL_NOT_FOUND",
+	0xC0000142:         "STATUS_DLL_INIT_FAILED",
+	0xC0000409:         "STATUS_STACK_BUFFER_OVERRUN",
+}
+
+// ntStatus describes code when it is an NTSTATUS error, as the status name
+// or its hex value, and returns "" for ordinary exit codes.
+func ntStatus(code int) string {
+	if code < 0 {
+		return ""
+	}
+	status := uint32(code)
+	if status>>30 != 3 {
+		return ""
+	}
+	if name, ok := ntStatusNames[status]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%08X", status)
+}
+
+// classifyExit returns the reason for an exit that no one explained.
+func classifyExit(code int, lifetime time.Duration) string {
+	switch {
+	case code == statusControlCExit:
+		// Ctrl+C ends console programs with this status on purpose.
+		return exitReasonNormal
+	case ntStatus(code) != "":
+		return exitReasonCrashed
+	case code != 0 && lifetime < crashExitWindow:
+		return exitReasonCrashed
+	default:
+		return exitReasonNormal
+	}
+}
+
+// waitExit blocks until the terminal's exit callback ran or timeout elapsed.
+func (e *terminalEntry) waitExit(timeout time.Duration) (int, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-e.exited:
+		return e.exitCode, true
+	case <-timer.C:
+		return 0, false
+	}
+}
+
+// closeEntry closes a terminal that already left the registry. With a grace
+// period it first hangs up the session so the shell can exit on its own, and
+// only terminates it once the grace period has elapsed.
+func (s *sidecar) closeEntry(entry *terminalEntry, grace time.Duration) terminalCloseResult {
+	entry.release("terminal closed before pattern matched")
+
+	result := terminalCloseResult{TerminalID: entry.id, Method: closeMethodForced}
+	entry.setReason(exitReasonClosed)
+	if hangup, ok := entry.session.(hangupSession); ok && grace > 0 {
+		if err := hangup.Hangup(); err == nil {
+			if code, exited := entry.waitExit(grace); exited {
+				_ = entry.session.Close()
+				result.Method = closeMethodGraceful
+				result.ExitCode = &code
+				return result
+			}
+		}
+		result.Method = closeMethodEscalated
+	}
+
+	_ = entry.session.Close()
+	return awaitTermination(entry, result)
+}
+
+// terminateEntry closes a terminal that already left the registry at once,
+// as closeEntry does without a grace period, leaving the wait for its exit
+// code to awaitTermination.
+func terminateEntry(entry *terminalEntry) terminalCloseResult {
+	entry.release("terminal closed before pattern matched")
+	entry.setReason(exitReasonClosed)
+	_ = entry.session.Close()
+	return terminalCloseResult{TerminalID: entry.id, Method: closeMethodForced}
+}
+
+// awaitTermination completes the result of a terminated terminal with its
+// exit code, if it exits in time.
+func awaitTermination(entry *terminalEntry, result terminalCloseResult) terminalCloseResult {
+	if code, exited := entry.waitExit(terminateExitWait); exited {
+		result.ExitCode = &code
+	}
+	return result
+}
+
+// shutdownGracefully closes every terminal in parallel, each with the same
+// grace period, and returns their results ordered by terminal id.
+func (s *sidecar) shutdownGracefully(grace time.Duration) []terminalCloseResult {
+	return s.closeEntries(s.takeAllTerminals(), grace)
+}
+
+// closeEntries closes entries in parallel and returns their results in the
+// same order.
+func (s *sidecar) closeEntries(entries []*terminalEntry, grace time.Duration) []terminalCloseResult {
+	results := make([]terminalCloseResult, len(entries))
+
+	var wg sync.WaitGroup
+	for idx, entry := range entries {
+		wg.Add(1)
+		go func(idx int, entry *terminalEntry) {
+			defer wg.Done()
+			results[idx] = s.closeEntry(entry, grace)
+		}(idx, entry)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func closeGracePeriod(graceMs int) (time.Duration, error) {
+	grace := time.Duration(graceMs) * time.Millisecond
+	if graceMs < 0 || grace > maxCloseGrace {
+		return 0, newSidecarError(errorCodeInvalidRequest, "graceMs must be between 0 and %d", maxCloseGrace.Milliseconds())
+	}
+	return grace, nil
+}
+
+const (
+	defaultIdleWarning = 30 * time.Second
+	maxTerminalIdle    = 24 * time.Hour
+	// idleCloseGrace is how long an idle shell may take to exit after hangup.
+	idleCloseGrace = 2 * time.Second
+)
+
+// idleMonitor reaps a terminal that saw neither input nor output for
+// timeout: it calls onWarn, and when the terminal stays idle for a further
+// warning period, onExpire. Touch only records a timestamp so it is cheap
+// enough to call for every output chunk; the timer re-arms itself lazily.
+type idleMonitor struct {
+	timeout  time.Duration
+	warning  time.Duration
+	onWarn   func(closeIn time.Duration)
+	onExpire func()
+
+	lastActivity atomic.Int64
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	warned  bool
+	stopped bool
+}
+
+func newIdleMonitor(req openRequest, onWarn func(time.Duration), onExpire func()) (*idleMonitor, error) {
+	timeout := time.Duration(req.IdleTimeoutMs) * time.Millisecond
+	warning := time.Duration(req.IdleWarningMs) * time.Millisecond
+	if req.IdleTimeoutMs < 0 || timeout > maxTerminalIdle {
+		return nil, newSidecarError(errorCodeInvalidRequest, "idleTimeoutMs must be between 0 and %d", maxTerminalIdle.Milliseconds())
+	}
+	if req.IdleWarningMs < 0 || warning > maxTerminalIdle {
+		return nil, newSidecarError(errorCodeInvalidRequest, "idleWarningMs must be between 0 and %d", maxTerminalIdle.Milliseconds())
+	}
+	if timeout == 0 {
+		return nil, nil
+	}
+	if warning == 0 {
+		warning = defaultIdleWarning
+	}
+
+	return &idleMonitor{
+		timeout:  timeout,
+		warning:  warning,
+		onWarn:   onWarn,
+		onExpire: onExpire,
+	}, nil
+}
+
+func (m *idleMonitor) Start() {
+	if m == nil {
+		return
+	}
+
+	m.Touch()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timer = time.AfterFunc(m.timeout, m.fire)
+}
+
+// Touch records activity; a pending warning is withdrawn on the next check.
+func (m *idleMonitor) Touch() {
+	if m == nil {
+		return
+	}
+	m.lastActivity.Store(time.Now().UnixNano())
+}
+
+func (m *idleMonitor) Stop() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = true
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+}
+
+func (m *idleMonitor) fire() {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return
+	}
+
+	idle := time.Since(time.Unix(0, m.lastActivity.Load()))
+	deadline := m.timeout
+	if m.warned {
+		deadline += m.warning
+	}
+
+	switch {
+	case idle < m.timeout:
+		m.warned = false
+		m.timer.Reset(m.timeout - idle)
+		m.mu.Unlock()
+	case idle < deadline:
+		m.timer.Reset(deadline - idle)
+		m.mu.Unlock()
+	case !m.warned:
+		m.warned = true
+		m.timer.Reset(m.warning)
+		m.mu.Unlock()
+		m.onWarn(m.warning)
+	default:
+		m.stopped = true
+		m.mu.Unlock()
+		m.onExpire()
+	}
+}
+
+const maxStartupTimeout = 10 * time.Minute
+
+// startupWatch fails a terminal whose shell produces no output within the
+// startup timeout. Exactly one of first output, timeout or exit claims it.
+type startupWatch struct {
+	timeout   time.Duration
+	onTimeout func()
+
+	claimed atomic.Bool
+	mu      sync.Mutex
+	timer   *time.Timer
+}
+
+func newStartupWatch(req openRequest, onTimeout func()) (*startupWatch, error) {
+	timeout := time.Duration(req.StartupTimeoutMs) * time.Millisecond
+	if req.StartupTimeoutMs < 0 || timeout > maxStartupTimeout {
+		return nil, newSidecarError(errorCodeInvalidRequest, "startupTimeoutMs must be between 0 and %d", maxStartupTimeout.Milliseconds())
+	}
+	if timeout == 0 {
+		return nil, nil
+	}
+	return &startupWatch{timeout: timeout, onTimeout: onTimeout}, nil
+}
+
+func (w *startupWatch) Start() {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.claimed.Load() {
+		w.timer = time.AfterFunc(w.timeout, func() {
+			if w.claimed.CompareAndSwap(false, true) {
+				w.onTimeout()
+			}
+		})
+	}
+}
+
+// Output records the first output byte.
+func (w *startupWatch) Output() {
+	if w == nil || w.claimed.Load() {
+		return
+	}
+	w.claim()
+}
+
+func (w *startupWatch) Stop() {
+	if w != nil {
+		w.claim()
+	}
+}
+
+// Exited reports whether the shell exited before producing any output.
+func (w *startupWatch) Exited() bool {
+	return w != nil && w.claim()
+}
+
+func (w *startupWatch) claim() bool {
+	if !w.claimed.CompareAndSwap(false, true) {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	return true
+}
+
+const maxInitialCommandDelay = time.Minute
+
+// initialCommand types the open request's initialCommand once the shell is
+// ready, which is its first output, and the write queue exists, which is
+// Start. A nil initialCommand does nothing.
+type initialCommand struct {
+	command string
+	delay   time.Duration
+	send    func(data string)
+
+	mu      sync.Mutex
+	started bool
+	output  bool
+	timer   *time.Timer
+	stopped bool
+}
+
+func newInitialCommand(req openRequest, send func(string)) (*initialCommand, error) {
+	delay := time.Duration(req.InitialCommandDelayMs) * time.Millisecond
+	if req.InitialCommandDelayMs < 0 || delay > maxInitialCommandDelay {
+		return nil, newSidecarError(errorCodeInvalidRequest, "initialCommandDelayMs must be between 0 and %d", maxInitialCommandDelay.Milliseconds())
+	}
+	if req.InitialCommand == "" {
+		return nil, nil
+	}
+	return &initialCommand{command: req.InitialCommand + "\r", delay: delay, send: send}, nil
+}
+
+func (c *initialCommand) Start() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started = true
+	c.scheduleLocked()
+}
+
+// Output records shell output; only the first call matters.
+func (c *initialCommand) Output() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.output = true
+	c.scheduleLocked()
+}
+
+func (c *initialCommand) Stop() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+func (c *initialCommand) scheduleLocked() {
+	if !c.started || !c.output || c.stopped || c.timer != nil {
+		return
+	}
+	c.timer = time.AfterFunc(c.delay, func() {
+		c.mu.Lock()
+		stopped := c.stopped
+		c.mu.Unlock()
+		if !stopped {
+			c.send(c.command)
+		}
+	})
+}
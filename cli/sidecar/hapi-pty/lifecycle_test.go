@@ -0,0 +1,432 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const terminateExitCodeForTests = 1
+
+// hangupTerminal is a fake session whose shell exits on hangup unless it is
+// stubborn, in which case only Close ends it.
+type hangupTerminal struct {
+	*fakeTerminal
+	stubborn bool
+	exitOnce sync.Once
+	hangups  int
+}
+
+func (h *hangupTerminal) Hangups() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.hangups
+}
+
+func (h *hangupTerminal) Hangup() error {
+	h.mu.Lock()
+	h.hangups++
+	h.mu.Unlock()
+	if !h.stubborn {
+		h.exit(0)
+	}
+	return nil
+}
+
+func (h *hangupTerminal) Close() error {
+	_ = h.fakeTerminal.Close()
+	h.exit(terminateExitCodeForTests)
+	return nil
+}
+
+func (h *hangupTerminal) exit(code int) {
+	h.exitOnce.Do(func() {
+		go h.callbacks.Exit(code)
+	})
+}
+
+func newHangupSidecar(t *testing.T, stubborn map[string]bool) (*testSidecar, map[string]*hangupTerminal) {
+	t.Helper()
+
+	sessions := map[string]*hangupTerminal{}
+	var ts *testSidecar
+	ts = newTestSidecar(t, runConfig{
+		TerminalOpener: func(
+			req openRequest,
+			_ resolvedShell,
+			callbacks terminalCallbacks,
+			_ func(terminalID string, task func()),
+		) (terminalSession, error) {
+			fake := &fakeTerminal{req: req, callbacks: callbacks}
+			ts.terminals[req.TerminalID] = fake
+			session := &hangupTerminal{fakeTerminal: fake, stubborn: stubborn[req.TerminalID]}
+			sessions[req.TerminalID] = session
+			return session, nil
+		},
+	})
+	return ts, sessions
+}
+
+func TestSidecarGracefulShutdownReportsPerTerminalResults(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, map[string]bool{"stubborn": true})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "polite", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "stubborn", Cols: 80, Rows: 24})
+
+	exitCode, done := ts.handleRequest(shutdownRequest{Type: requestTypeShutdown, GraceMs: 50})
+	if !done || exitCode != 0 {
+		t.Fatalf("unexpected shutdown result: code=%d done=%v", exitCode, done)
+	}
+
+	acks := ts.eventsOfType(t, eventTypeShutdownAck)
+	if len(acks) != 1 {
+		t.Fatalf("expected one shutdown_ack, got %d", len(acks))
+	}
+	terminals, _ := acks[0]["terminals"].([]any)
+	if len(terminals) != 2 {
+		t.Fatalf("unexpected terminal results: %+v", acks[0])
+	}
+
+	polite := terminals[0].(map[string]any)
+	if polite["terminalId"] != "polite" || polite["method"] != closeMethodGraceful || polite["exitCode"] != float64(0) {
+		t.Fatalf("unexpected polite result: %+v", polite)
+	}
+	stubborn := terminals[1].(map[string]any)
+	if stubborn["terminalId"] != "stubborn" || stubborn["method"] != closeMethodEscalated || stubborn["exitCode"] != float64(terminateExitCodeForTests) {
+		t.Fatalf("unexpected stubborn result: %+v", stubborn)
+	}
+	if sessions["stubborn"].Hangups() != 1 || !sessions["stubborn"].Closed() {
+		t.Fatal("expected stubborn terminal to be hung up and then closed")
+	}
+
+	if exits := ts.eventsOfType(t, eventTypeExit); len(exits) != 2 {
+		t.Fatalf("expected exit events before shutdown_ack, got %d", len(exits))
+	}
+}
+
+func TestSidecarShutdownWithoutGraceClosesImmediately(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, nil)
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(shutdownRequest{Type: requestTypeShutdown})
+
+	if sessions["t1"].Hangups() != 0 || !sessions["t1"].Closed() {
+		t.Fatal("expected immediate close without hangup")
+	}
+	acks := ts.eventsOfType(t, eventTypeShutdownAck)
+	if len(acks) != 1 || acks[0]["terminals"] != nil {
+		t.Fatalf("unexpected shutdown_ack: %+v", acks)
+	}
+}
+
+func waitForEventOfType(t *testing.T, ts *testSidecar, eventType string) map[string]any {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if events := ts.eventsOfType(t, eventType); len(events) > 0 {
+			return events[0]
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s event", eventType)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSidecarCloseWithGraceHangsUpFirst(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, nil)
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1", GraceMs: 1000})
+
+	closed := waitForEventOfType(t, ts, eventTypeClosed)
+	if closed["terminalId"] != "t1" || closed["method"] != closeMethodGraceful || closed["exitCode"] != float64(0) {
+		t.Fatalf("unexpected closed event: %+v", closed)
+	}
+	if sessions["t1"].Hangups() != 1 {
+		t.Fatalf("expected one hangup, got %d", sessions["t1"].Hangups())
+	}
+}
+
+func TestSidecarCloseEscalatesAfterGrace(t *testing.T) {
+	ts, _ := newHangupSidecar(t, map[string]bool{"t1": true})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1", GraceMs: 20})
+
+	closed := waitForEventOfType(t, ts, eventTypeClosed)
+	if closed["method"] != closeMethodEscalated || closed["exitCode"] != float64(terminateExitCodeForTests) {
+		t.Fatalf("unexpected closed event: %+v", closed)
+	}
+}
+
+func TestSidecarForceCloseSkipsHangup(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, nil)
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1", Force: true, GraceMs: 1000})
+
+	closed := waitForEventOfType(t, ts, eventTypeClosed)
+	if closed["method"] != closeMethodForced {
+		t.Fatalf("unexpected closed event: %+v", closed)
+	}
+	if sessions["t1"].Hangups() != 0 {
+		t.Fatal("force close should not hang up first")
+	}
+}
+
+func TestSidecarCloseAllBySelector(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, map[string]bool{"t2": true})
+
+	for _, id := range []string{"t1", "t2", "t3"} {
+		tags := map[string]string{"worktree": "main"}
+		if id == "t3" {
+			tags = nil
+		}
+		ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: id, Cols: 80, Rows: 24, Tags: tags})
+	}
+	ts.handleRequest(closeAllRequest{Type: requestTypeCloseAll, RequestID: "c1", Selector: map[string]string{"worktree": "main"}, GraceMs: 20})
+
+	evt := waitForEventOfType(t, ts, eventTypeClosedAll)
+	var got []string
+	for _, raw := range evt["terminals"].([]any) {
+		result := raw.(map[string]any)
+		got = append(got, result["terminalId"].(string)+":"+result["method"].(string))
+	}
+	if evt["requestId"] != "c1" || strings.Join(got, ",") != "t1:graceful,t2:escalated" {
+		t.Fatalf("unexpected closed_all event: %+v", evt)
+	}
+	if sessions["t3"].Closed() || sessions["t3"].Hangups() != 0 {
+		t.Fatal("t3 does not match the selector and should stay open")
+	}
+}
+
+func TestSidecarCloseAllRequiresAFilter(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, nil)
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(closeAllRequest{Type: requestTypeCloseAll, RequestID: "c1"})
+	ts.handleRequest(closeAllRequest{Type: requestTypeCloseAll, RequestID: "c2", Shell: "cmd", Force: true})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["requestId"] != "c1" || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request for an unfiltered close-all, got %+v", errors)
+	}
+	if evt := waitForEventOfType(t, ts, eventTypeClosedAll); len(evt["terminals"].([]any)) != 0 {
+		t.Fatalf("no terminal runs cmd, got %+v", evt)
+	}
+	ts.handleRequest(closeAllRequest{Type: requestTypeCloseAll, All: true, Force: true})
+	if exit := waitForEventOfType(t, ts, eventTypeExit); exit["terminalId"] != "t1" || !sessions["t1"].Closed() {
+		t.Fatal("all should close every terminal")
+	}
+}
+
+func TestSidecarPlainCloseReportsTermination(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, nil)
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+
+	if !sessions["t1"].Closed() {
+		t.Fatal("expected terminal to be closed before the request returned")
+	}
+	if closed := waitForEventOfType(t, ts, eventTypeClosed); closed["terminalId"] != "t1" || closed["method"] != closeMethodForced {
+		t.Fatalf("expected a forced closed event, got %+v", closed)
+	}
+	if exit := waitForEventOfType(t, ts, eventTypeExit); exit["reason"] != exitReasonClosed {
+		t.Fatalf("unexpected exit event: %+v", exit)
+	}
+}
+
+func TestSidecarCloseRejectsInvalidGraceBeforeClosing(t *testing.T) {
+	ts, sessions := newHangupSidecar(t, nil)
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1", GraceMs: -1})
+
+	if errors := ts.eventsOfType(t, eventTypeError); len(errors) != 1 || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request, got %+v", errors)
+	}
+	if sessions["t1"].Closed() || ts.terminals["t1"] == nil {
+		t.Fatal("an invalid graceMs should leave the terminal open")
+	}
+}
+
+func TestSidecarIdleTerminalIsWarnedThenClosed(t *testing.T) {
+	ts, _ := newHangupSidecar(t, nil)
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, IdleTimeoutMs: 20, IdleWarningMs: 20})
+
+	warning := waitForEventOfType(t, ts, eventTypeWillClose)
+	if warning["terminalId"] != "t1" || warning["reason"] != closeReasonIdle || warning["closeInMs"] != float64(20) {
+		t.Fatalf("unexpected will_close event: %+v", warning)
+	}
+	closed := waitForEventOfType(t, ts, eventTypeClosed)
+	if closed["reason"] != closeReasonIdle || closed["method"] != closeMethodGraceful {
+		t.Fatalf("unexpected closed event: %+v", closed)
+	}
+	if exit := ts.eventsOfType(t, eventTypeExit); len(exit) != 1 || exit[0]["reason"] != exitReasonIdleTimeout {
+		t.Fatalf("unexpected exit events: %+v", exit)
+	}
+
+	ts.mu.Lock()
+	_, registered := ts.sidecar.terminals["t1"]
+	ts.mu.Unlock()
+	if registered {
+		t.Fatal("idle terminal should leave the registry")
+	}
+}
+
+func TestClassifyExit(t *testing.T) {
+	cases := []struct {
+		code     int
+		lifetime time.Duration
+		reason   string
+		status   string
+	}{
+		{0, time.Millisecond, exitReasonNormal, ""},
+		{1, time.Minute, exitReasonNormal, ""},
+		{1, time.Millisecond, exitReasonCrashed, ""},
+		{0xC0000005, time.Hour, exitReasonCrashed, "STATUS_ACCESS_VIOLATION"},
+		{0xC0000123, time.Hour, exitReasonCrashed, "0xC0000123"},
+		{statusControlCExit, time.Millisecond, exitReasonNormal, "STATUS_CONTROL_C_EXIT"},
+		{0x80000003, time.Hour, exitReasonNormal, ""},
+	}
+	for _, tc := range cases {
+		if reason := classifyExit(tc.code, tc.lifetime); reason != tc.reason {
+			t.Fatalf("classifyExit(%#x, %s) = %q, want %q", tc.code, tc.lifetime, reason, tc.reason)
+		}
+		if status := ntStatus(tc.code); status != tc.status {
+			t.Fatalf("ntStatus(%#x) = %q, want %q", tc.code, status, tc.status)
+		}
+	}
+}
+
+func TestSidecarReportsCrashStatus(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.terminals["t1"].callbacks.Exit(0xC00000FD)
+
+	exits := ts.eventsOfType(t, eventTypeExit)
+	if len(exits) != 1 || exits[0]["reason"] != exitReasonCrashed || exits[0]["status"] != "STATUS_STACK_OVERFLOW" {
+		t.Fatalf("unexpected exit events: %+v", exits)
+	}
+}
+
+func TestIdleMonitorActivityPostponesWarning(t *testing.T) {
+	warned := make(chan struct{}, 1)
+	monitor, err := newIdleMonitor(openRequest{IdleTimeoutMs: 60}, func(time.Duration) {
+		warned <- struct{}{}
+	}, func() {})
+	if err != nil {
+		t.Fatalf("newIdleMonitor failed: %v", err)
+	}
+	monitor.Start()
+	defer monitor.Stop()
+
+	for i := 0; i < 15; i++ {
+		monitor.Touch()
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case <-warned:
+		t.Fatal("active terminal should not be warned")
+	default:
+	}
+
+	select {
+	case <-warned:
+	case <-time.After(time.Second):
+		t.Fatal("expected warning once activity stopped")
+	}
+}
+
+func TestIdleMonitorRejectsNegativeTimeout(t *testing.T) {
+	if _, err := newIdleMonitor(openRequest{IdleTimeoutMs: -1}, nil, nil); err == nil {
+		t.Fatal("expected invalid idleTimeoutMs error")
+	}
+	if monitor, err := newIdleMonitor(openRequest{}, nil, nil); err != nil || monitor != nil {
+		t.Fatalf("expected no monitor without timeout, got %v, %v", monitor, err)
+	}
+}
+
+func TestSidecarStartupTimeoutClosesSilentShell(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, StartupTimeoutMs: 20})
+
+	failure := waitForEventOfType(t, ts, eventTypeError)
+	if failure["terminalId"] != "t1" || failure["code"] != errorCodeStartupTimeout {
+		t.Fatalf("expected startup_timeout, got %+v", failure)
+	}
+	if !ts.terminals["t1"].Closed() {
+		t.Fatal("expected silent shell to be closed")
+	}
+}
+
+func TestSidecarStartupTimeoutDisarmedByFirstOutput(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, StartupTimeoutMs: 20})
+	ts.terminals["t1"].callbacks.Output([]byte("PS> "))
+	time.Sleep(60 * time.Millisecond)
+
+	if errors := ts.eventsOfType(t, eventTypeError); len(errors) != 0 {
+		t.Fatalf("unexpected errors: %+v", errors)
+	}
+	if ts.terminals["t1"].Closed() {
+		t.Fatal("terminal with output should stay open")
+	}
+}
+
+func TestSidecarTypesInitialCommandAfterFirstOutput(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, InitialCommand: ".venv\\Scripts\\activate", InitialCommandDelayMs: 5})
+	time.Sleep(20 * time.Millisecond)
+	if writes := ts.terminals["t1"].Writes(); len(writes) != 0 {
+		t.Fatalf("initial command should wait for the shell, got %#v", writes)
+	}
+
+	ts.terminals["t1"].callbacks.Output([]byte("PS> "))
+	ts.terminals["t1"].callbacks.Output([]byte("\r\nPS> "))
+	ts.terminals["t1"].waitForWrites(t, 1)
+	time.Sleep(20 * time.Millisecond)
+	if writes := ts.terminals["t1"].Writes(); len(writes) != 1 || writes[0] != ".venv\\Scripts\\activate\r" {
+		t.Fatalf("expected the initial command once, got %#v", writes)
+	}
+}
+
+func TestSidecarRejectsInitialCommandDelayOutOfRange(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, InitialCommand: "ls", InitialCommandDelayMs: -1})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request, got %+v", errors)
+	}
+}
+
+func TestSidecarReportsShellDyingBeforeOutput(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, StartupTimeoutMs: 1000})
+	ts.terminals["t1"].callbacks.Exit(3)
+
+	var types []string
+	for _, evt := range ts.events(t) {
+		types = append(types, evt["type"].(string)+":"+stringValue(evt["code"]))
+	}
+	if got := strings.Join(types, ","); got != "ready:,error:startup_failed,exit:" {
+		t.Fatalf("unexpected events: %s", got)
+	}
+}
+
+func stringValue(value any) string {
+	text, _ := value.(string)
+	return text
+}
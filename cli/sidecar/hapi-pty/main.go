@@ -2,238 +2,613 @@ package main
 
 import (
 	"bufio"
-	"encoding/base64"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 const (
 	defaultStdinIdleTimeout = 120 * time.Second
-	maxScannerTokenBytes    = 1024 * 1024
+	defaultMaxRequestBytes  = 1024 * 1024
+	exitCodeUsage           = 64
+	missedPingLimit         = 2
+)
+
+const (
+	// exitCodeCrash is returned after a panic in the main loop.
+	exitCodeCrash = 70
+	// crashFrameHistory is how many recent frames a crash report includes,
+	// each truncated to crashFrameBytes.
+	crashFrameHistory = 32
+	crashFrameBytes   = 512
 )
 
 type runConfig struct {
-	IdleTimeout    time.Duration
-	LookPath       shellLookupFunc
-	ProbeConPTY    func() error
-	TerminalOpener terminalFactory
+	Encoding        string
+	IdleTimeout     time.Duration
+	MaxRequestBytes int
+	// ReadBufferBytes is the default fixed output read size; zero is adaptive.
+	ReadBufferBytes int
+	// Backpressure is the default slow-host policy for terminals.
+	Backpressure string
+	// MaxTerminals caps concurrently open terminals; zero means unlimited.
+	MaxTerminals int
+	// PingInterval switches liveness from any stdin traffic to pings only:
+	// the sidecar shuts down after missedPingLimit intervals without a ping.
+	PingInterval time.Duration
+	// HeartbeatInterval emits heartbeat events from the main loop, so they
+	// stop when the loop is stuck even though stdout is still open.
+	HeartbeatInterval time.Duration
+	// EnvPolicy filters the sidecar's environment before shells inherit it.
+	EnvPolicy envPolicy
+	// BrokerPipe serves the protocol over a named pipe for the sidecar that
+	// launched this elevated broker instead of over stdio.
+	BrokerPipe string
+	// Daemon relays stdio to the daemon serving this named pipe, starting
+	// one with the same flags when none is running; DaemonServe is how
+	// that daemon is started. See serveDaemon.
+	Daemon      string
+	DaemonServe string
+	// DaemonArgs are the daemon's command line, which an upgrade starts the
+	// new binary with; Handoff is how that binary is told where to take
+	// the daemon over. See resumeDaemon.
+	DaemonArgs []string
+	Handoff    string
+	// Listen serves authenticated TCP clients on this address instead of
+	// stdio, or gRPC or WebSocket clients when it is a grpc:// or ws://
+	// address; AuthTokenFile holds the shared secret they must present.
+	Listen        string
+	AuthTokenFile string
+	// Service runs --listen under the Windows service manager or systemd,
+	// which stop it gracefully; see runService.
+	Service bool
+	// MultiClient serves --listen clients concurrently from one sidecar;
+	// ClientNamespaces gives each its own terminal IDs and ClientDisconnect
+	// decides what happens to its terminals when it leaves. See
+	// clientManager.
+	MultiClient      bool
+	ClientNamespaces bool
+	ClientDisconnect string
+	// ReplayEvents is how many of each terminal's stream events are kept
+	// for clients that reconnect and attach with resumeFromSeq.
+	ReplayEvents int
+	// TLSCert and TLSKey serve --listen over TLS; TLSClientCA additionally
+	// requires clients to present a certificate signed by that CA.
+	TLSCert     string
+	TLSKey      string
+	TLSClientCA string
+	// AuditPath names the audit log runMain opens into Audit, which records
+	// auth, open, close and shutdown requests; Peer identifies the client
+	// in those records and is empty for stdio.
+	AuditPath string
+	Audit     *auditLog
+	Peer      string
+	// ExecPolicy, loaded from --exec-policy, restricts which executables
+	// terminals may start.
+	ExecPolicy *execPolicy
+	// EnvProfiles, loaded from --env-profiles, are the env maps open
+	// requests may reference by name.
+	EnvProfiles envProfiles
+	// TracePath names the file runMain opens into Trace, which records every
+	// request and event for `hapi-pty replay`; TraceRedact blanks write data.
+	TracePath   string
+	TraceRedact bool
+	Trace       *traceRecorder
+	// MockBackend lets open requests ask for the scripted mock terminal
+	// instead of a shell, for integration tests.
+	MockBackend bool
+	// Chaos, parsed from --chaos, injects latency, slow writes and dropped
+	// terminals for testing hosts.
+	Chaos *chaosConfig
+	// CrashDir receives a report for every panic, in the main loop or a
+	// terminal task, after which the sidecar exits with exitCodeCrash;
+	// without it reports go to stderr. Write data is always redacted.
+	CrashDir string
+	// DebugAddr serves pprof and registry dumps over HTTP; Debug tracks the
+	// running sidecars for them.
+	DebugAddr string
+	Debug     *debugRegistry
+	// Tracer exports terminal lifecycle spans when OTEL_EXPORTER_OTLP_*
+	// variables configure an endpoint.
+	Tracer *otelTracer
+	// ShowVersion and ShowCapabilities print build information or the
+	// hello capabilities and exit instead of serving.
+	ShowVersion      bool
+	ShowCapabilities bool
+	LookPath         shellLookupFunc
+	VSWhere          vswhereFunc
+	ProbeConPTY      func() error
+	TerminalOpener   terminalFactory
+	// WinptyDir holds winpty.dll and winpty-agent.exe, by default the
+	// sidecar's own directory. ProbeWinpty and WinptyOpener stand in for
+	// ProbeConPTY and TerminalOpener when ConPTY is missing.
+	WinptyDir    string
+	ProbeWinpty  func() error
+	WinptyOpener terminalFactory
+	// DefaultShell, from --default-shell, is defaultShellOrder or
+	// defaultShellSystem; ShellOrder, from --shell-order or
+	// HAPI_SHELL_ORDER, replaces the pwsh, powershell, cmd fallback order.
+	DefaultShell string
+	ShellOrder   []string
+	// ProbeShellVersion runs version probes and CaptureLoginEnv login
+	// shells for loginEnv; nil runs the shell.
+	ProbeShellVersion versionProbeFunc
+	CaptureLoginEnv   loginEnvFunc
+	// RunTmux runs the tmux command of a tmux-panes request; nil runs it.
+	RunTmux tmuxCommandFunc
+	// TermProgram, from --term-program, is the TERM_PROGRAM of Unix shells.
+	TermProgram string
+	// CheckpointDir holds the scrollback checkpoints of terminals opened
+	// with checkpoint.
+	CheckpointDir string
+	// ResizeDebounce coalesces resize bursts into one backend resize per
+	// window; zero applies every resize as it arrives.
+	ResizeDebounce time.Duration
 }
 
 type scannerMessage struct {
 	Line []byte
 	Done bool
 	Err  error
+	// TooLarge reports a request line that exceeded the limit and was skipped.
+	TooLarge bool
+	// Invalid reports a frame that could not be decoded and was skipped; a
+	// malformed NDJSON line is reported when it is decoded instead.
+	Invalid error
 }
 
 func main() {
-	os.Exit(runSidecar(os.Stdin, os.Stdout, runConfig{}))
+	os.Exit(runMain(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
 
-func runSidecar(stdin io.Reader, stdout io.Writer, cfg runConfig) int {
-	if cfg.IdleTimeout <= 0 {
-		cfg.IdleTimeout = defaultStdinIdleTimeout
+func runMain(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "repl":
+			return runRepl(args[1:], stdin, stdout, stderr)
+		case "schema":
+			return runSchema(args[1:], stdout, stderr)
+		case "replay":
+			return runReplay(args[1:], stdout, stderr)
+		case "upgrade":
+			return runUpgrade(args[1:], stdout, stderr)
+		case "service":
+			return runService(args[1:], stdin, stdout, stderr)
+		}
 	}
-	if cfg.ProbeConPTY == nil {
-		cfg.ProbeConPTY = probeConPTY
+
+	cfg, err := parseRunFlags(args, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintln(stderr, err)
+		return exitCodeUsage
 	}
-	if cfg.TerminalOpener == nil {
-		cfg.TerminalOpener = newPlatformTerminalSession
+	if cfg.ShowVersion {
+		fmt.Fprint(stdout, formatVersion(currentBuildInfo()))
+		return 0
 	}
-
-	writer := &safeWriter{writer: stdout}
-	emit := func(payload any) {
-		_ = writer.Emit(payload)
+	if cfg.Service && cfg.Listen == "" {
+		fmt.Fprintln(stderr, "--service requires --listen")
+		return exitCodeUsage
 	}
-	emitError := func(terminalID string, code string, message string) {
-		emit(errorEvent{
-			Type:       eventTypeError,
-			TerminalID: terminalID,
-			Code:       code,
-			Message:    message,
-		})
+	if cfg.ShowCapabilities {
+		if err := json.NewEncoder(stdout).Encode(sidecarCapabilities(cfg, backendConPTY)); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
 	}
 
-	emit(helloEvent{
-		Type:     eventTypeHello,
-		Version:  sidecarVersion,
-		Protocol: protocolVersion,
-	})
-
-	conPTYAvailable := true
-	conPTYErrorMessage := ""
-	if err := cfg.ProbeConPTY(); err != nil {
-		conPTYAvailable = false
-		conPTYErrorMessage = err.Error()
+	// The proxy leaves auditing, tracing and everything else to the daemon.
+	if cfg.Daemon != "" && cfg.DaemonServe == "" {
+		conn, err := connectDaemonPipe(daemonPipePath(cfg.Daemon), args)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return runDaemonProxy(conn, stdin, stdout)
 	}
 
-	terminals := map[string]terminalSession{}
-	var terminalsMu sync.Mutex
-
-	closeAllTerminals := func() {
-		terminalsMu.Lock()
-		sessions := make([]terminalSession, 0, len(terminals))
-		for terminalID, session := range terminals {
-			delete(terminals, terminalID)
-			sessions = append(sessions, session)
+	if cfg.AuditPath != "" {
+		audit, closer, err := openAuditLog(cfg.AuditPath)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
 		}
-		terminalsMu.Unlock()
+		defer closer.Close()
+		cfg.Audit = audit
+	}
+	if cfg.TracePath != "" {
+		trace, closer, err := openTraceRecorder(cfg.TracePath, cfg.TraceRedact)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer closer.Close()
+		cfg.Trace = trace
+	}
 
-		for _, session := range sessions {
-			_ = session.Close()
+	tracer, err := newOTelTracerFromEnv(os.Getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "tracing disabled: %v\n", err)
+	}
+	defer tracer.Shutdown()
+	cfg.Tracer = tracer
+
+	var token string
+	if cfg.Listen != "" {
+		if token, err = loadAuthToken(cfg.AuthTokenFile); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitCodeUsage
+		}
+	}
+	if cfg.DebugAddr != "" {
+		registry := &debugRegistry{}
+		server, err := startDebugServer(cfg.DebugAddr, token, registry)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
 		}
+		defer server.Close()
+		cfg.Debug = registry
 	}
 
-	runIsolated := func(terminalID string, task func()) {
-		runIsolatedTerminalTask(terminalID, emitError, task)
+	if cfg.DaemonServe != "" {
+		cfg.DaemonArgs = args
+		if cfg.Handoff != "" {
+			return resumeDaemon(cfg, stderr)
+		}
+		listener, err := listenDaemonPipe(daemonPipePath(cfg.DaemonServe))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return serveDaemon(listener, cfg, stderr)
 	}
 
-	lines := startScanner(stdin)
-	idleTimer := time.NewTimer(cfg.IdleTimeout)
-	defer idleTimer.Stop()
+	if cfg.BrokerPipe != "" {
+		conn, err := dialBrokerPipe(cfg.BrokerPipe)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer conn.Close()
 
-	for {
-		select {
-		case <-idleTimer.C:
-			closeAllTerminals()
-			return 2
-		case msg, ok := <-lines:
-			if !ok {
-				closeAllTerminals()
-				return 1
+		// The broker lives exactly as long as its parent holds the pipe open.
+		cfg.IdleTimeout = time.Duration(math.MaxInt64)
+		return runSidecar(conn, conn, cfg)
+	}
+
+	if cfg.Listen != "" {
+		listener, err := listenTransport(cfg)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer listener.Close()
+		serve := func(stop <-chan struct{}) int {
+			if cfg.Encoding == wireEncodingGRPC {
+				return serveGRPC(listener, token, cfg, stderr, stop)
 			}
-			if msg.Done {
-				closeAllTerminals()
-				return 1
+			if strings.HasPrefix(cfg.Listen, wsListenScheme) {
+				return serveWebSocket(listener, token, cfg, stderr, stop)
 			}
-
-			resetTimer(idleTimer, cfg.IdleTimeout)
-
-			req, err := decodeRequestLine(msg.Line)
-			if err != nil {
-				emitError("", errorCodeUnknown, err.Error())
-				continue
+			if cfg.MultiClient {
+				return serveClientsConcurrently(listener, token, cfg, stderr, stop)
 			}
+			return serveListener(listener, token, cfg, stderr, stop)
+		}
+		if cfg.Service {
+			return runUnderServiceManager(serve, stderr)
+		}
+		return serve(nil)
+	}
 
-			switch typed := req.(type) {
-			case openRequest:
-				if typed.TerminalID == "" {
-					emitError("", errorCodeUnknown, "open request requires terminalId")
-					continue
-				}
+	return runSidecar(stdin, stdout, cfg)
+}
 
-				if !conPTYAvailable {
-					emitError(typed.TerminalID, errorCodeConPTYUnavailable, conPTYErrorMessage)
-					continue
-				}
+func parseRunFlags(args []string, output io.Writer) (runConfig, error) {
+	flags := flag.NewFlagSet("hapi-pty", flag.ContinueOnError)
+	flags.SetOutput(output)
+
+	cfg := runConfig{}
+	var envAllow, envDeny, execPolicyPath, chaosSpec, shellOrder, envProfilesPath string
+	flags.BoolVar(&cfg.ShowVersion, "version", false, "print version and build information and exit")
+	flags.BoolVar(&cfg.ShowCapabilities, "capabilities", false, "print the capabilities JSON advertised in hello and exit")
+	flags.StringVar(&cfg.Encoding, "encoding", wireEncodingJSON, "wire encoding for requests and events (json|msgpack|jsonrpc)")
+	flags.IntVar(&cfg.MaxRequestBytes, "max-request-bytes", defaultMaxRequestBytes, "maximum size of a single request")
+	flags.StringVar(&cfg.Backpressure, "backpressure", backpressureBlock, "default policy when the host stops reading output (block|buffer|drop|pause)")
+	flags.IntVar(&cfg.MaxTerminals, "max-terminals", 0, "maximum number of concurrently open terminals (0 is unlimited)")
+	flags.DurationVar(&cfg.PingInterval, "ping-interval", 0, "require a ping at this interval instead of treating any input as activity")
+	flags.DurationVar(&cfg.HeartbeatInterval, "heartbeat-interval", 0, "emit heartbeat events at this interval (0 disables them)")
+	flags.DurationVar(&cfg.ResizeDebounce, "resize-debounce", defaultResizeDebounce, "coalesce resize requests arriving within this window (0 applies each one)")
+	flags.StringVar(&envAllow, "env-allow", "", "comma-separated environment variables shells may inherit (NAME or PREFIX*)")
+	flags.StringVar(&envDeny, "env-deny", "", "comma-separated environment variables withheld from shells (NAME or PREFIX*)")
+	flags.StringVar(&cfg.BrokerPipe, "broker-pipe", "", "serve a parent sidecar over this named pipe (used for elevated terminals)")
+	flags.StringVar(&cfg.Daemon, "daemon", "", "proxy stdio to the daemon on this named pipe, starting it if needed, so terminals outlive this process")
+	flags.StringVar(&cfg.DaemonServe, "daemon-serve", "", "run as the daemon on this named pipe, keeping terminals open between clients (started by --daemon)")
+	flags.BoolVar(&cfg.Service, "service", false, "run --listen under the Windows service manager or systemd (started by service run)")
+	flags.StringVar(&cfg.Handoff, "handoff", "", "take the --daemon-serve daemon over from the process serving this pipe (started by upgrade)")
+	flags.StringVar(&cfg.Listen, "listen", "", "serve clients on this TCP address, on grpc://ADDR as a gRPC service or on ws://ADDR over WebSockets, instead of stdio (requires an auth token)")
+	flags.BoolVar(&cfg.MultiClient, "multi-client", false, "serve --listen clients concurrently from one sidecar instead of one at a time")
+	flags.BoolVar(&cfg.ClientNamespaces, "client-namespaces", false, "give each --multi-client client its own terminal IDs, hiding other clients' terminals")
+	flags.StringVar(&cfg.ClientDisconnect, "client-disconnect", clientDisconnectClose, "what happens to a --multi-client client's terminals when it disconnects (close|preserve)")
+	flags.IntVar(&cfg.ReplayEvents, "replay-events", 0, "keep this many of each terminal's recent events for --daemon and --multi-client clients resuming with resumeFromSeq (0 disables)")
+	flags.StringVar(&cfg.AuthTokenFile, "auth-token-file", "", "file holding the token --listen clients must present (default $"+authTokenEnv+")")
+	flags.StringVar(&cfg.TLSCert, "tls-cert", "", "PEM certificate for serving --listen over TLS")
+	flags.StringVar(&cfg.TLSKey, "tls-key", "", "PEM private key for --tls-cert")
+	flags.StringVar(&cfg.TLSClientCA, "tls-client-ca", "", "PEM CA bundle that --listen clients must present a certificate from")
+	flags.StringVar(&cfg.AuditPath, "audit-log", "", "append a JSON line per auth, open, close and shutdown request to this file")
+	flags.StringVar(&execPolicyPath, "exec-policy", "", "JSON policy of executables terminals may start (allow/deny globs, requireSigned)")
+	flags.StringVar(&envProfilesPath, "env-profiles", "", "JSON object of named env maps open requests may reference in envProfiles")
+	flags.StringVar(&cfg.TracePath, "trace-file", "", "record every request and event with timestamps to this file (see hapi-pty replay)")
+	flags.BoolVar(&cfg.TraceRedact, "trace-redact", false, "omit write data from --trace-file, keeping only its length")
+	flags.BoolVar(&cfg.MockBackend, "mock-backend", false, "allow open requests with backend \"mock\", a scripted terminal for tests")
+	flags.StringVar(&chaosSpec, "chaos", "", "test only: inject faults, e.g. latency=50ms,write-delay=20ms,drop=0.05,seed=1")
+	flags.StringVar(&cfg.CrashDir, "crash-dir", "", "write a report to this directory when the sidecar panics (default stderr)")
+	flags.StringVar(&cfg.DebugAddr, "debug-addr", "", "serve pprof, goroutine stacks and the terminal registry over HTTP on this address")
+	flags.IntVar(&cfg.ReadBufferBytes, "read-buffer-bytes", 0, "fixed terminal output read size (0 grows and shrinks adaptively)")
+	flags.StringVar(&cfg.DefaultShell, "default-shell", defaultShellOrder, "shell for opens that name none: order (pwsh, powershell, cmd) or system (Windows Terminal's default profile, then %ComSpec%)")
+	flags.StringVar(&cfg.CheckpointDir, "checkpoint-dir", "", "save the scrollback of terminals opened with checkpoint here when they close, and restore it when they are reopened")
+	flags.StringVar(&cfg.WinptyDir, "winpty-dir", "", "directory of winpty.dll and winpty-agent.exe, used where ConPTY is missing (default: next to hapi-pty)")
+	flags.StringVar(&cfg.TermProgram, "term-program", defaultTermProgram, "TERM_PROGRAM for gitbash, cygwin and MSYS2 shells (empty leaves it unset)")
+	flags.StringVar(&shellOrder, "shell-order", os.Getenv(shellOrderEnv), "comma-separated fallback order for opens that name no shell (default $"+shellOrderEnv+" or pwsh,powershell,cmd)")
+
+	if err := flags.Parse(args); err != nil {
+		return runConfig{}, err
+	}
+	cfg.EnvPolicy = envPolicy{Allow: splitEnvPatterns(envAllow), Deny: splitEnvPatterns(envDeny)}
+	if flags.NArg() > 0 {
+		return runConfig{}, fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
 
-				shell, err := resolveShell(typed.Shell, cfg.LookPath)
-				if err != nil {
-					serr := sidecarErrorFrom(err, errorCodeShellNotFound)
-					emitError(typed.TerminalID, serr.Code, serr.Message)
-					continue
-				}
+	switch cfg.Encoding {
+	case wireEncodingJSON, wireEncodingMsgpack, wireEncodingJSONRPC:
+	default:
+		return runConfig{}, fmt.Errorf("unsupported encoding %q", cfg.Encoding)
+	}
+	if err := validateReadBufferBytes(cfg.ReadBufferBytes); err != nil {
+		return runConfig{}, err
+	}
+	switch cfg.DefaultShell {
+	case defaultShellOrder, defaultShellSystem:
+	default:
+		return runConfig{}, fmt.Errorf("unsupported default shell mode %q", cfg.DefaultShell)
+	}
+	order, err := parseShellOrder(shellOrder)
+	if err != nil {
+		return runConfig{}, err
+	}
+	cfg.ShellOrder = order
+	if _, err := backpressureLimit(cfg.Backpressure, 0); err != nil {
+		return runConfig{}, err
+	}
+	if cfg.MaxTerminals < 0 {
+		return runConfig{}, errors.New("--max-terminals must not be negative")
+	}
+	if cfg.PingInterval < 0 {
+		return runConfig{}, errors.New("--ping-interval must not be negative")
+	}
+	if cfg.HeartbeatInterval < 0 {
+		return runConfig{}, errors.New("--heartbeat-interval must not be negative")
+	}
+	if cfg.ResizeDebounce < 0 {
+		return runConfig{}, errors.New("--resize-debounce must not be negative")
+	}
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return runConfig{}, errors.New("--tls-cert and --tls-key must be set together")
+	}
+	if cfg.TLSClientCA != "" && cfg.TLSCert == "" {
+		return runConfig{}, errors.New("--tls-client-ca requires --tls-cert")
+	}
+	if strings.HasPrefix(cfg.Listen, grpcListenScheme) {
+		// gRPC clients share one sidecar, each call being a client.
+		if cfg.TLSCert == "" {
+			return runConfig{}, errors.New("--listen grpc:// requires --tls-cert and --tls-key, as HTTP/2 is only served over TLS")
+		}
+		if cfg.Encoding != wireEncodingJSON {
+			return runConfig{}, errors.New("--listen grpc:// implies its own encoding; --encoding is not supported")
+		}
+		cfg.Encoding = wireEncodingGRPC
+		cfg.MultiClient = true
+	}
+	if strings.HasPrefix(cfg.Listen, wsListenScheme) {
+		// Text messages carry NDJSON lines, one request or event each.
+		if cfg.Encoding != wireEncodingJSON {
+			return runConfig{}, errors.New("--listen ws:// carries JSON; --encoding is not supported")
+		}
+		cfg.MultiClient = true
+	}
+	if cfg.MultiClient && cfg.Listen == "" {
+		return runConfig{}, errors.New("--multi-client requires --listen")
+	}
+	switch cfg.ClientDisconnect {
+	case clientDisconnectClose, clientDisconnectPreserve:
+	default:
+		return runConfig{}, fmt.Errorf("unsupported --client-disconnect policy %q", cfg.ClientDisconnect)
+	}
+	if (cfg.ClientNamespaces || cfg.ClientDisconnect != clientDisconnectClose) && !cfg.MultiClient {
+		return runConfig{}, errors.New("--client-namespaces and --client-disconnect require --multi-client")
+	}
+	if cfg.ReplayEvents < 0 || cfg.ReplayEvents > maxReplayEvents {
+		return runConfig{}, fmt.Errorf("--replay-events must be between 0 and %d", maxReplayEvents)
+	}
+	if cfg.ReplayEvents > 0 && cfg.Daemon == "" && cfg.DaemonServe == "" && !cfg.MultiClient {
+		return runConfig{}, errors.New("--replay-events requires --daemon or --multi-client, whose terminals outlive a connection")
+	}
+	if cfg.TraceRedact && cfg.TracePath == "" {
+		return runConfig{}, errors.New("--trace-redact requires --trace-file")
+	}
+	if chaosSpec != "" {
+		chaos, err := parseChaos(chaosSpec)
+		if err != nil {
+			return runConfig{}, err
+		}
+		cfg.Chaos = chaos
+	}
+	if execPolicyPath != "" {
+		policy, err := loadExecPolicy(execPolicyPath)
+		if err != nil {
+			return runConfig{}, err
+		}
+		cfg.ExecPolicy = policy
+	}
+	if envProfilesPath != "" {
+		profiles, err := loadEnvProfiles(envProfilesPath)
+		if err != nil {
+			return runConfig{}, err
+		}
+		cfg.EnvProfiles = profiles
+	}
 
-				terminalsMu.Lock()
-				_, exists := terminals[typed.TerminalID]
-				terminalsMu.Unlock()
-				if exists {
-					emitError(typed.TerminalID, errorCodeStartupFailed, "terminal already exists")
-					continue
-				}
+	return cfg, nil
+}
 
-				terminalID := typed.TerminalID
-				callbacks := terminalCallbacks{
-					Output: func(chunk []byte) {
-						emit(outputEvent{
-							Type:       eventTypeOutput,
-							TerminalID: terminalID,
-							Data:       base64.StdEncoding.EncodeToString(chunk),
-						})
-					},
-					Exit: func(code int) {
-						terminalsMu.Lock()
-						delete(terminals, terminalID)
-						terminalsMu.Unlock()
-						emit(exitEvent{
-							Type:       eventTypeExit,
-							TerminalID: terminalID,
-							Code:       code,
-						})
-					},
-				}
+func runSidecar(stdin io.Reader, stdout io.Writer, cfg runConfig) (exitCode int) {
+	cfg = withRuntimeDefaults(cfg)
+	s := newSidecar(cfg, stdout)
+	defer s.recoverMainLoop(&exitCode)
+	defer cfg.Debug.Track(s)()
 
-				session, err := cfg.TerminalOpener(typed, shell, callbacks, runIsolated)
-				if err != nil {
-					serr := sidecarErrorFrom(err, errorCodeStartupFailed)
-					emitError(typed.TerminalID, serr.Code, serr.Message)
-					continue
-				}
+	exitCode, shutdown := s.serve(stdin)
+	if !shutdown {
+		s.closeAllTerminals()
+	}
+	return exitCode
+}
 
-				terminalsMu.Lock()
-				terminals[terminalID] = session
-				terminalsMu.Unlock()
-
-				emit(readyEvent{
-					Type:       eventTypeReady,
-					TerminalID: terminalID,
-					Display:    shell.Name,
-				})
-
-			case writeRequest:
-				terminalsMu.Lock()
-				session, exists := terminals[typed.TerminalID]
-				terminalsMu.Unlock()
-				if !exists {
-					emitError(typed.TerminalID, errorCodeTerminalNotFound, "terminal not found")
-					continue
-				}
+// withRuntimeDefaults fills in what runSidecar needs but tests may omit.
+func withRuntimeDefaults(cfg runConfig) runConfig {
+	if cfg.Encoding == "" {
+		cfg.Encoding = wireEncodingJSON
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = defaultStdinIdleTimeout
+	}
+	if cfg.MaxRequestBytes <= 0 {
+		cfg.MaxRequestBytes = defaultMaxRequestBytes
+	}
+	if cfg.ProbeConPTY == nil {
+		cfg.ProbeConPTY = probeConPTY
+	}
+	if cfg.TerminalOpener == nil {
+		cfg.TerminalOpener = newPlatformTerminalSession
+	}
+	if cfg.ProbeWinpty == nil {
+		dir := cfg.WinptyDir
+		cfg.ProbeWinpty = func() error { return probeWinpty(dir) }
+	}
+	if cfg.WinptyOpener == nil {
+		cfg.WinptyOpener = newWinptyTerminalFactory(cfg.WinptyDir)
+	}
+	return cfg
+}
 
-				if err := session.Write(typed.Data); err != nil {
-					serr := sidecarErrorFrom(err, errorCodeStartupFailed)
-					emitError(typed.TerminalID, serr.Code, serr.Message)
-				}
+// serve greets one client and handles its requests until it goes away or
+// idles out, which returns shutdown false and leaves the terminals running,
+// or until a request ends the sidecar.
+func (s *sidecar) serve(stdin io.Reader) (exitCode int, shutdown bool) {
+	cfg := s.cfg
+	// Each connection introduces itself and subscribes anew.
+	s.client.Store(nil)
+	s.subscriptions.Store(newEventSubscriptions())
+	s.emitHello()
+
+	liveness := cfg.IdleTimeout
+	if cfg.PingInterval > 0 {
+		liveness = cfg.PingInterval * missedPingLimit
+	}
 
-			case resizeRequest:
-				terminalsMu.Lock()
-				session, exists := terminals[typed.TerminalID]
-				terminalsMu.Unlock()
-				if !exists {
-					emitError(typed.TerminalID, errorCodeTerminalNotFound, "terminal not found")
-					continue
-				}
+	lines := startRequestReader(stdin, cfg.Encoding, cfg.MaxRequestBytes)
+	idleTimer := time.NewTimer(liveness)
+	defer idleTimer.Stop()
 
-				if err := session.Resize(typed.Cols, typed.Rows); err != nil {
-					serr := sidecarErrorFrom(err, errorCodeStartupFailed)
-					emitError(typed.TerminalID, serr.Code, serr.Message)
-				}
+	var heartbeats <-chan time.Time
+	if cfg.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(cfg.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeats = ticker.C
+	}
 
-			case closeRequest:
-				terminalsMu.Lock()
-				session, exists := terminals[typed.TerminalID]
-				if exists {
-					delete(terminals, typed.TerminalID)
-				}
-				terminalsMu.Unlock()
+	for {
+		select {
+		case <-heartbeats:
+			s.emitHeartbeat()
+		case <-idleTimer.C:
+			if cfg.PingInterval > 0 {
+				s.emitError("", errorCodePingTimeout, fmt.Sprintf("no ping received within %s", liveness))
+			}
+			return 2, false
+		case msg, ok := <-lines:
+			if !ok || msg.Done {
+				return 1, false
+			}
 
-				if exists {
-					_ = session.Close()
-				}
+			if cfg.PingInterval == 0 {
+				resetTimer(idleTimer, liveness)
+			}
+
+			if msg.TooLarge {
+				s.emitError("", errorCodeRequestTooLarge, fmt.Sprintf("request exceeds %d bytes", cfg.MaxRequestBytes))
+				continue
+			}
+			if msg.Invalid != nil {
+				s.emitRequestFailure("", "", msg.Invalid, errorCodeUnknown)
+				continue
+			}
 
-			case pingRequest:
-				emit(pongEvent{Type: eventTypePong})
+			cfg.Trace.Inbound(msg.Line)
+			s.history.Inbound(msg.Line)
+			req, requestID, err := s.decodeWireRequest(msg.Line)
+			if err != nil {
+				s.emitRequestFailure("", requestID, err, errorCodeUnknown)
+				continue
+			}
+			if _, isPing := req.(pingRequest); isPing && cfg.PingInterval > 0 {
+				resetTimer(idleTimer, liveness)
+			}
 
-			case shutdownRequest:
-				closeAllTerminals()
-				emit(shutdownAckEvent{Type: eventTypeShutdownAck})
-				return 0
+			exitCode, done := s.handleRequest(req)
+			s.acknowledgeCall(req, requestID)
+			if done {
+				return exitCode, true
 			}
 		}
 	}
 }
 
+func (s *sidecar) emitHello() {
+	// Hello advertises winpty when it replaces a missing ConPTY, so probe
+	// first.
+	platform := backendConPTY
+	if probe := s.probeBackends(true); probe.Winpty {
+		platform = backendWinpty
+	}
+	s.emit(helloEvent{
+		Type:                eventTypeHello,
+		Version:             sidecarVersion,
+		Protocol:            protocolVersion,
+		Protocols:           supportedProtocols(),
+		Capabilities:        sidecarCapabilities(s.cfg, platform),
+		PingIntervalMs:      s.cfg.PingInterval.Milliseconds(),
+		HeartbeatIntervalMs: s.cfg.HeartbeatInterval.Milliseconds(),
+		ReplayEvents:        s.cfg.ReplayEvents,
+		Platform:            platformDetails(),
+	})
+}
+
 func runIsolatedTerminalTask(
 	terminalID string,
 	emitError func(terminalID string, code string, message string),
@@ -253,22 +628,93 @@ func runIsolatedTerminalTask(
 	}()
 }
 
-func startScanner(reader io.Reader) <-chan scannerMessage {
+func startRequestReader(reader io.Reader, encoding string, maxBytes int) <-chan scannerMessage {
+	switch encoding {
+	case wireEncodingMsgpack:
+		return startMsgpackScanner(reader, maxBytes)
+	}
+	return startScanner(reader, maxBytes)
+}
+
+func startScanner(reader io.Reader, maxBytes int) <-chan scannerMessage {
 	out := make(chan scannerMessage, 32)
 	go func() {
 		defer close(out)
 
-		scanner := bufio.NewScanner(reader)
-		scanner.Buffer(make([]byte, 0, 4096), maxScannerTokenBytes)
+		buffered := bufio.NewReaderSize(reader, 64*1024)
+		for {
+			line, tooLarge, err := readRequestLine(buffered, maxBytes)
+			switch {
+			case tooLarge:
+				out <- scannerMessage{TooLarge: true}
+			case line != nil:
+				out <- scannerMessage{Line: line}
+			}
+
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				out <- scannerMessage{Done: true, Err: err}
+				return
+			}
+		}
+	}()
+
+	return out
+}
 
-		for scanner.Scan() {
-			line := append([]byte(nil), scanner.Bytes()...)
-			out <- scannerMessage{Line: line}
+// readRequestLine reads one newline-terminated request. Lines longer than
+// maxBytes are drained and reported as tooLarge instead of failing the stream.
+func readRequestLine(reader *bufio.Reader, maxBytes int) ([]byte, bool, error) {
+	var line []byte
+	tooLarge := false
+	for {
+		fragment, err := reader.ReadSlice('\n')
+		if !tooLarge {
+			line = append(line, fragment...)
+			if len(bytes.TrimRight(line, "\r\n")) > maxBytes {
+				tooLarge = true
+				line = nil
+			}
 		}
 
-		out <- scannerMessage{
-			Done: true,
-			Err:  scanner.Err(),
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if tooLarge {
+			return nil, true, err
+		}
+		if err != nil && len(line) == 0 {
+			return nil, false, err
+		}
+
+		line = bytes.TrimSuffix(line, []byte{'\n'})
+		return bytes.TrimSuffix(line, []byte{'\r'}), false, err
+	}
+}
+
+func startMsgpackScanner(reader io.Reader, maxBytes int) <-chan scannerMessage {
+	out := make(chan scannerMessage, 32)
+	go func() {
+		defer close(out)
+
+		buffered := bufio.NewReader(reader)
+		for {
+			raw, tooLarge, err := readMsgpackObject(buffered, maxBytes)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				out <- scannerMessage{Done: true, Err: err}
+				return
+			}
+			if tooLarge {
+				out <- scannerMessage{TooLarge: true}
+				continue
+			}
+			line, err := decodeMsgpackObject(raw)
+			out <- scannerMessage{Line: line, Invalid: err}
 		}
 	}()
 
@@ -286,12 +732,209 @@ func resetTimer(timer *time.Timer, timeout time.Duration) {
 }
 
 type safeWriter struct {
-	writer io.Writer
-	mu     sync.Mutex
+	writer  io.Writer
+	encode  func(io.Writer, any) error
+	trace   *traceRecorder
+	history *frameHistory
+	mu      sync.Mutex
+
+	// buf and encoder are reused across NDJSON events to avoid per-event buffers.
+	buf     bytes.Buffer
+	encoder *json.Encoder
+}
+
+// Retarget sends later events to writer, for a daemon changing clients.
+func (w *safeWriter) Retarget(writer io.Writer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writer = writer
 }
 
 func (w *safeWriter) Emit(payload any) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return writeNDJSONLine(w.writer, payload)
+	w.trace.Outbound(payload)
+	w.history.Outbound(payload)
+	if w.encode != nil {
+		return w.encode(w.writer, payload)
+	}
+
+	if w.encoder == nil {
+		w.encoder = json.NewEncoder(&w.buf)
+	}
+	w.buf.Reset()
+	if err := w.encoder.Encode(payload); err != nil {
+		return err
+	}
+	_, err := w.writer.Write(w.buf.Bytes())
+	return err
+}
+
+type historyFrame struct {
+	at      time.Time
+	dir     string
+	line    []byte
+	payload any
+}
+
+// frameHistory keeps the most recent protocol frames for crash reports.
+// Events are kept as values and only encoded, and requests only redacted,
+// when a report is written.
+type frameHistory struct {
+	mu     sync.Mutex
+	frames []historyFrame
+	next   int
+}
+
+func (h *frameHistory) Inbound(line []byte) {
+	h.add(historyFrame{dir: traceDirIn, line: line})
+}
+
+func (h *frameHistory) Outbound(payload any) {
+	h.add(historyFrame{dir: traceDirOut, payload: payload})
+}
+
+func (h *frameHistory) add(frame historyFrame) {
+	if h == nil {
+		return
+	}
+
+	frame.at = time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.frames) < crashFrameHistory {
+		h.frames = append(h.frames, frame)
+		return
+	}
+	h.frames[h.next] = frame
+	h.next = (h.next + 1) % crashFrameHistory
+}
+
+// Frames returns the retained frames, oldest first.
+func (h *frameHistory) Frames() []historyFrame {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append(append([]historyFrame(nil), h.frames[h.next:]...), h.frames[:h.next]...)
+}
+
+// recoverMainLoop turns a panic in runSidecar into a crash report and
+// exitCodeCrash, closing the terminals unless the registry is stuck locked.
+func (s *sidecar) recoverMainLoop(exitCode *int) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	s.writeCrashReport(recovered, "")
+	s.closeTerminalsAfterPanic()
+	*exitCode = exitCodeCrash
+}
+
+// crashExit ends the process after a terminal task panicked; tests replace
+// it.
+var crashExit = os.Exit
+
+// reportTerminalPanic handles a panicking terminal task like a panic in the
+// main loop: it writes a crash report, tells the client, closes the
+// terminals and exits with exitCodeCrash. The task runs on its own
+// goroutine, so it cannot return that code through runSidecar.
+func (s *sidecar) reportTerminalPanic(terminalID string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	message := fmt.Sprintf("terminal panic: %v", recovered)
+	if path := s.writeCrashReport(recovered, terminalID); path != "" {
+		message += fmt.Sprintf(" (crash report: %s)", path)
+	}
+	s.emitError(terminalID, errorCodeSpawnFailed, message)
+	s.closeTerminalsAfterPanic()
+	crashExit(exitCodeCrash)
+}
+
+// closeTerminalsAfterPanic closes the terminals unless the registry is stuck
+// locked, which the panic may have left it.
+func (s *sidecar) closeTerminalsAfterPanic() {
+	if !s.mu.TryLock() {
+		return
+	}
+	s.mu.Unlock()
+	defer func() { _ = recover() }()
+	s.closeAllTerminals()
+}
+
+// writeCrashReport records the panic, open terminals, recent frames and
+// every goroutine's stack, returning the report's path when written to
+// CrashDir.
+func (s *sidecar) writeCrashReport(recovered any, terminalID string) string {
+	var report bytes.Buffer
+	fmt.Fprintf(&report, "hapi-pty %s crash report\n", sidecarVersion)
+	fmt.Fprintf(&report, "time: %s\n", time.Now().Format(time.RFC3339Nano))
+	fmt.Fprintf(&report, "panic: %v\n", recovered)
+	if terminalID != "" {
+		fmt.Fprintf(&report, "terminal: %s\n", terminalID)
+	}
+
+	if s.mu.TryLock() {
+		ids := make([]string, 0, len(s.terminals))
+		for id := range s.terminals {
+			ids = append(ids, id)
+		}
+		s.mu.Unlock()
+		sort.Strings(ids)
+		fmt.Fprintf(&report, "\nopen terminals (%d):\n", len(ids))
+		for _, id := range ids {
+			fmt.Fprintf(&report, "  %s\n", id)
+		}
+	} else {
+		fmt.Fprint(&report, "\nopen terminals: registry locked\n")
+	}
+
+	// Requests are redacted as --trace-redact would, whatever the flag:
+	// reports are shared, and write data is whatever the user typed.
+	fmt.Fprint(&report, "\nrecent frames:\n")
+	for _, frame := range s.history.Frames() {
+		line := frame.line
+		switch {
+		case frame.payload != nil:
+			line, _ = json.Marshal(frame.payload)
+		case json.Valid(line):
+			line = redactTraceWrite(line)
+		default:
+			line = []byte(fmt.Sprintf("(%d bytes that are not JSON)", len(line)))
+		}
+		if len(line) > crashFrameBytes {
+			line = append(line[:crashFrameBytes:crashFrameBytes], "..."...)
+		}
+		fmt.Fprintf(&report, "  %s %-3s %s\n", frame.at.Format("15:04:05.000"), frame.dir, line)
+	}
+
+	fmt.Fprintf(&report, "\ngoroutines:\n%s", allGoroutineStacks())
+
+	if s.cfg.CrashDir == "" {
+		_, _ = os.Stderr.Write(report.Bytes())
+		return ""
+	}
+	file, err := os.CreateTemp(s.cfg.CrashDir, "hapi-pty-crash-"+time.Now().Format("20060102T150405")+"-*.txt")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write crash report: %v\n%s", err, report.Bytes())
+		return ""
+	}
+	defer file.Close()
+	if _, err := file.Write(report.Bytes()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write crash report: %v\n", err)
+	}
+	return file.Name()
+}
+
+func allGoroutineStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
 }
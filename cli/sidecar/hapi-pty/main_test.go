@@ -4,7 +4,12 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
@@ -38,6 +43,14 @@ func TestRunSidecarEmitsHelloPongAndShutdownAck(t *testing.T) {
 		t.Fatalf("unexpected protocol version: %#v", events[0]["protocol"])
 	}
 
+	capabilities, ok := events[0]["capabilities"].(map[string]any)
+	if !ok {
+		t.Fatalf("hello should advertise capabilities, got %#v", events[0])
+	}
+	if compression, ok := capabilities["compression"].([]any); !ok || !reflect.DeepEqual(compression, []any{outputCompressionGzip, outputCompressionZstd}) {
+		t.Fatalf("hello should advertise gzip and zstd compression, got %#v", capabilities)
+	}
+
 	assertEventType(t, events, eventTypePong)
 	assertEventType(t, events, eventTypeShutdownAck)
 }
@@ -65,6 +78,118 @@ func TestRunSidecarIdleTimeoutExitCode(t *testing.T) {
 	}
 }
 
+func TestRunSidecarFallsBackToWinpty(t *testing.T) {
+	stdin := strings.NewReader(
+		`{"type":"open","terminalId":"t1","cols":80,"rows":24}` + "\n" +
+			`{"type":"shutdown"}` + "\n",
+	)
+	var stdout bytes.Buffer
+	opened := false
+	runSidecar(stdin, &stdout, runConfig{
+		IdleTimeout: 2 * time.Second,
+		LookPath:    fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`}),
+		ProbeConPTY: func() error { return errors.New("CreatePseudoConsole not found") },
+		ProbeWinpty: func() error { return nil },
+		WinptyOpener: func(openRequest, resolvedShell, terminalCallbacks, func(string, func())) (terminalSession, error) {
+			opened = true
+			return nil, newSidecarError(errorCodeStartupFailed, "winpty agent failed")
+		},
+	})
+
+	events := decodeRawEvents(t, &stdout)
+	capabilities := events[0]["capabilities"].(map[string]any)
+	if backends := capabilities["backends"].([]any); len(backends) != 1 || backends[0] != backendWinpty {
+		t.Fatalf("hello should advertise winpty, got %#v", capabilities)
+	}
+	if !opened {
+		t.Fatal("open did not go through winpty")
+	}
+}
+
+func TestRunSidecarPingModeIgnoresNonPingTraffic(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	stdout := &syncBuffer{}
+	done := make(chan int, 1)
+	go func() {
+		done <- runSidecar(reader, stdout, runConfig{
+			PingInterval: 20 * time.Millisecond,
+			ProbeConPTY:  func() error { return nil },
+		})
+	}()
+
+	stopTraffic := make(chan struct{})
+	defer close(stopTraffic)
+	go func() {
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopTraffic:
+				return
+			case <-ticker.C:
+				if _, err := io.WriteString(writer, `{"type":"stats"}`+"\n"); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case exitCode := <-done:
+		if exitCode != 2 {
+			t.Fatalf("expected liveness exit code 2, got %d", exitCode)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("sidecar stayed alive without pings")
+	}
+
+	events := decodeRawEvents(t, stdout.Snapshot())
+	if events[0]["pingIntervalMs"] != float64(20) {
+		t.Fatalf("hello should advertise the ping interval, got %#v", events[0])
+	}
+	last := events[len(events)-1]
+	if last["type"] != eventTypeError || last["code"] != errorCodePingTimeout {
+		t.Fatalf("expected ping_timeout error, got %#v", last)
+	}
+}
+
+func TestRunSidecarPingModeStaysAliveWithPings(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	done := make(chan int, 1)
+	go func() {
+		done <- runSidecar(reader, &syncBuffer{}, runConfig{
+			PingInterval: 30 * time.Millisecond,
+			ProbeConPTY:  func() error { return nil },
+		})
+	}()
+
+	for i := 0; i < 10; i++ {
+		if _, err := io.WriteString(writer, `{"type":"ping"}`+"\n"); err != nil {
+			t.Fatalf("write ping: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case exitCode := <-done:
+		t.Fatalf("sidecar exited with %d despite pings", exitCode)
+	default:
+	}
+
+	select {
+	case exitCode := <-done:
+		if exitCode != 2 {
+			t.Fatalf("expected liveness exit code 2, got %d", exitCode)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("sidecar did not exit once pings stopped")
+	}
+}
+
 func TestRunIsolatedTerminalTaskPanicIsolation(t *testing.T) {
 	errorCh := make(chan errorEvent, 2)
 	okCh := make(chan struct{}, 1)
@@ -123,6 +248,20 @@ func TestRunIsolatedTerminalTaskPanicIsolation(t *testing.T) {
 	}
 }
 
+func TestParseRunFlagsEncoding(t *testing.T) {
+	cfg, err := parseRunFlags([]string{"--encoding", "msgpack"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseRunFlags failed: %v", err)
+	}
+	if cfg.Encoding != wireEncodingMsgpack {
+		t.Fatalf("unexpected encoding: %s", cfg.Encoding)
+	}
+
+	if _, err := parseRunFlags([]string{"--encoding", "cbor"}, io.Discard); err == nil {
+		t.Fatal("expected unsupported encoding error")
+	}
+}
+
 func decodeRawEvents(t *testing.T, stdout *bytes.Buffer) []map[string]any {
 	t.Helper()
 
@@ -155,3 +294,172 @@ func assertEventType(t *testing.T, events []map[string]any, eventType string) {
 
 	t.Fatalf("event %q not found in %#v", eventType, events)
 }
+
+func TestRunSidecarSkipsOversizedRequests(t *testing.T) {
+	stdin := strings.NewReader(
+		`{"type":"write","terminalId":"t1","data":"` + strings.Repeat("x", 256) + `"}` + "\n" +
+			`{"type":"ping"}` + "\r\n" +
+			`{"type":"shutdown"}`,
+	)
+	var stdout bytes.Buffer
+
+	exitCode := runSidecar(stdin, &stdout, runConfig{
+		IdleTimeout:     2 * time.Second,
+		MaxRequestBytes: 128,
+		ProbeConPTY:     func() error { return nil },
+	})
+	if exitCode != 0 {
+		t.Fatalf("expected graceful shutdown exit code 0, got %d", exitCode)
+	}
+
+	events := decodeRawEvents(t, &stdout)
+	if len(events) != 4 {
+		t.Fatalf("expected hello, error, pong and shutdown_ack, got %#v", events)
+	}
+	if events[1]["type"] != eventTypeError || events[1]["code"] != errorCodeRequestTooLarge {
+		t.Fatalf("expected request_too_large error, got %#v", events[1])
+	}
+	if events[2]["type"] != eventTypePong || events[3]["type"] != eventTypeShutdownAck {
+		t.Fatalf("sidecar should keep processing after oversized request: %#v", events)
+	}
+}
+
+func TestReadRequestLineHandlesLinesLargerThanBuffer(t *testing.T) {
+	payload := strings.Repeat("y", 100)
+	reader := bufio.NewReaderSize(strings.NewReader(payload+"\n"+"tail"), 16)
+
+	line, tooLarge, err := readRequestLine(reader, 1024)
+	if err != nil || tooLarge || string(line) != payload {
+		t.Fatalf("unexpected first line: %q tooLarge=%v err=%v", line, tooLarge, err)
+	}
+
+	line, tooLarge, err = readRequestLine(reader, 1024)
+	if !errors.Is(err, io.EOF) || tooLarge || string(line) != "tail" {
+		t.Fatalf("unexpected trailing line: %q tooLarge=%v err=%v", line, tooLarge, err)
+	}
+}
+
+func BenchmarkSafeWriterEmit(b *testing.B) {
+	writer := &safeWriter{writer: io.Discard}
+	evt := outputEvent{Type: eventTypeOutput, TerminalID: "t1", Data: strings.Repeat("QUJD", 1024)}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := writer.Emit(evt); err != nil {
+			b.Fatalf("Emit failed: %v", err)
+		}
+	}
+}
+
+func TestRunMainPrintsVersionAndCapabilities(t *testing.T) {
+	var stdout bytes.Buffer
+	if exitCode := runMain([]string{"--version"}, strings.NewReader(""), &stdout, io.Discard); exitCode != 0 {
+		t.Fatalf("--version exited with %d", exitCode)
+	}
+	if !strings.HasPrefix(stdout.String(), "hapi-pty "+sidecarVersion+"\n") || !strings.Contains(stdout.String(), "target: ") {
+		t.Fatalf("unexpected version output: %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if exitCode := runMain([]string{"--capabilities"}, strings.NewReader(""), &stdout, io.Discard); exitCode != 0 {
+		t.Fatalf("--capabilities exited with %d", exitCode)
+	}
+	var capabilities helloCapabilities
+	if err := json.Unmarshal(stdout.Bytes(), &capabilities); err != nil {
+		t.Fatalf("capabilities are not JSON: %v", err)
+	}
+	if len(capabilities.Encodings) == 0 || len(capabilities.OutputEncodings) == 0 || len(capabilities.Backends) != 1 {
+		t.Fatalf("unexpected capabilities: %+v", capabilities)
+	}
+
+	stdout.Reset()
+	runMain([]string{"--capabilities", "--mock-backend"}, strings.NewReader(""), &stdout, io.Discard)
+	if !strings.Contains(stdout.String(), `"backends":["conpty","mock"]`) {
+		t.Fatalf("mock backend not advertised: %s", stdout.String())
+	}
+}
+
+func TestFormatVersionMarksModifiedCommits(t *testing.T) {
+	output := formatVersion(buildInfo{Version: "1.2.3", Protocol: 1, Commit: "abc", Modified: true, GoVersion: "go1.22", Target: "windows/arm64"})
+	expected := "hapi-pty 1.2.3\nprotocol: 1\ncommit: abc (modified)\ngo: go1.22\ntarget: windows/arm64\n"
+	if output != expected {
+		t.Fatalf("unexpected version output:\n%s", output)
+	}
+}
+
+func readCrashReport(t *testing.T, dir string) string {
+	t.Helper()
+
+	matches, err := filepath.Glob(filepath.Join(dir, "hapi-pty-crash-*.txt"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("expected one crash report, got %v (%v)", matches, err)
+	}
+	report, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(report)
+}
+
+func TestRunSidecarWritesCrashReportOnPanic(t *testing.T) {
+	dir := t.TempDir()
+	stdin := strings.NewReader(`{"type":"open","terminalId":"t1","cols":80,"rows":24}` + "\n")
+
+	exitCode := runSidecar(stdin, &syncBuffer{}, runConfig{
+		CrashDir:    dir,
+		LookPath:    fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`}),
+		ProbeConPTY: func() error { return nil },
+		TerminalOpener: func(openRequest, resolvedShell, terminalCallbacks, func(string, func())) (terminalSession, error) {
+			panic("boom")
+		},
+	})
+	if exitCode != exitCodeCrash {
+		t.Fatalf("expected exit code %d, got %d", exitCodeCrash, exitCode)
+	}
+
+	report := readCrashReport(t, dir)
+	for _, want := range []string{"panic: boom", "open terminals (0)", `in  {"type":"open","terminalId":"t1"`, `out {"type":"hello"`, "goroutine "} {
+		if !strings.Contains(report, want) {
+			t.Fatalf("crash report lacks %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestTerminalPanicWritesCrashReportAndExits(t *testing.T) {
+	exited := make(chan int, 1)
+	crashExit = func(code int) { exited <- code }
+	t.Cleanup(func() { crashExit = os.Exit })
+
+	dir := t.TempDir()
+	ts := newTestSidecar(t, runConfig{CrashDir: dir})
+	ts.history.Inbound([]byte(`{"type":"write","terminalId":"t1","data":"hunter2\r"}`))
+	ts.history.Inbound([]byte("hunter2"))
+	ts.runIsolated("t1", func() { panic("kaboom") })
+
+	if code := <-exited; code != exitCodeCrash {
+		t.Fatalf("expected exit code %d, got %d", exitCodeCrash, code)
+	}
+	failure := waitForEventOfType(t, ts, eventTypeError)
+	if message := stringValue(failure["message"]); !strings.Contains(message, "kaboom (crash report: "+dir) {
+		t.Fatalf("error does not point at the crash report: %s", message)
+	}
+	report := readCrashReport(t, dir)
+	if !strings.Contains(report, "terminal: t1") || !strings.Contains(report, `"redactedBytes":8`) {
+		t.Fatalf("crash report lacks the terminal or the redacted write:\n%s", report)
+	}
+	if strings.Contains(report, "hunter2") {
+		t.Fatalf("crash report leaks typed data:\n%s", report)
+	}
+}
+
+func TestFrameHistoryKeepsMostRecentFrames(t *testing.T) {
+	history := &frameHistory{}
+	for i := 0; i < crashFrameHistory+8; i++ {
+		history.Inbound([]byte(fmt.Sprint(i)))
+	}
+
+	frames := history.Frames()
+	if len(frames) != crashFrameHistory || string(frames[0].line) != "8" || string(frames[len(frames)-1].line) != fmt.Sprint(crashFrameHistory+7) {
+		t.Fatalf("unexpected retained frames: %d, first %q", len(frames), frames[0].line)
+	}
+}
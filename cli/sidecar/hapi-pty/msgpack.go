@@ -0,0 +1,682 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	wireEncodingJSON    = "json"
+	wireEncodingMsgpack = "msgpack"
+	// wireEncodingJSONRPC frames NDJSON as JSON-RPC 2.0, see
+	// translateJSONRPC and writeJSONRPCFrame.
+	wireEncodingJSONRPC = "jsonrpc"
+)
+
+const (
+	maxMsgpackContainerLen = 1 << 20
+	// maxMsgpackDepth bounds how deeply a request may nest arrays and maps,
+	// as encoding/json does, so a crafted frame cannot exhaust the stack.
+	maxMsgpackDepth = 10000
+)
+
+// Events are encoded from their structs through their json tags, so both
+// encodings always carry identical fields; types with a JSON form of their
+// own are encoded through it. Requests are re-encoded as JSON lines and
+// flow through decodeRequestLine like NDJSON.
+
+func writeMsgpackFrame(w io.Writer, payload any) error {
+	var buf bytes.Buffer
+	if err := appendMsgpackReflect(&buf, reflect.ValueOf(payload)); err != nil {
+		return err
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+)
+
+func appendMsgpackReflect(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	t := v.Type()
+	if t == jsonNumberType {
+		return appendMsgpackValue(buf, v.Interface())
+	}
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return appendMsgpackViaJSON(buf, v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return appendMsgpackValue(buf, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		appendMsgpackInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n := v.Uint(); n > math.MaxInt64 {
+			buf.WriteByte(0xcf)
+			_ = binary.Write(buf, binary.BigEndian, n)
+		} else {
+			appendMsgpackInt(buf, int64(n))
+		}
+	case reflect.Float32, reflect.Float64:
+		buf.WriteByte(0xcb)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+	case reflect.String:
+		appendMsgpackString(buf, v.String())
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		return appendMsgpackReflect(buf, v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json sends byte slices as base64 strings.
+			appendMsgpackString(buf, base64.StdEncoding.EncodeToString(v.Bytes()))
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		appendMsgpackHeader(buf, v.Len(), 0x90, 0xdc, 0xdd)
+		for i := 0; i < v.Len(); i++ {
+			if err := appendMsgpackReflect(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if t.Key().Kind() != reflect.String {
+			return appendMsgpackViaJSON(buf, v.Interface())
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		appendMsgpackHeader(buf, len(keys), 0x80, 0xde, 0xdf)
+		for _, key := range keys {
+			appendMsgpackString(buf, key.String())
+			if err := appendMsgpackReflect(buf, v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return appendMsgpackStruct(buf, v)
+	default:
+		return fmt.Errorf("unsupported msgpack value %s", t)
+	}
+	return nil
+}
+
+// appendMsgpackViaJSON encodes value through its JSON form.
+func appendMsgpackViaJSON(buf *bytes.Buffer, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic any
+	if err := decoder.Decode(&generic); err != nil {
+		return err
+	}
+	return appendMsgpackValue(buf, generic)
+}
+
+func appendMsgpackStruct(buf *bytes.Buffer, v reflect.Value) error {
+	fields := msgpackStructFields(v.Type())
+	values := make([]reflect.Value, len(fields))
+	n := 0
+	for idx, field := range fields {
+		value, err := v.FieldByIndexErr(field.index)
+		if err != nil {
+			// A nil embedded pointer has no fields to promote.
+			continue
+		}
+		if field.omitEmpty && isEmptyJSONValue(value) {
+			continue
+		}
+		values[idx] = value
+		n++
+	}
+
+	appendMsgpackHeader(buf, n, 0x80, 0xde, 0xdf)
+	for idx, field := range fields {
+		if !values[idx].IsValid() {
+			continue
+		}
+		appendMsgpackString(buf, field.name)
+		if err := appendMsgpackReflect(buf, values[idx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEmptyJSONValue reports whether omitempty leaves v out.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+type msgpackField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var msgpackFieldCache sync.Map
+
+// msgpackStructFields lists the fields encoding/json would encode for t,
+// with those of embedded structs promoted unless a shallower field has
+// their name.
+func msgpackStructFields(t reflect.Type) []msgpackField {
+	if cached, ok := msgpackFieldCache.Load(t); ok {
+		return cached.([]msgpackField)
+	}
+
+	var fields []msgpackField
+	seen := map[string]bool{}
+	level := []msgpackField{{index: nil}}
+	types := []reflect.Type{t}
+	for len(level) > 0 {
+		var next []msgpackField
+		var nextTypes []reflect.Type
+		var named []msgpackField
+		for idx, parent := range level {
+			st := types[idx]
+			for i := 0; i < st.NumField(); i++ {
+				sf := st.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, options, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), parent.index...), i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, msgpackField{index: index})
+					nextTypes = append(nextTypes, ft)
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				if name == "" {
+					name = sf.Name
+				}
+				named = append(named, msgpackField{
+					name:      name,
+					index:     index,
+					omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+				})
+			}
+		}
+		for _, field := range named {
+			if !seen[field.name] {
+				seen[field.name] = true
+				fields = append(fields, field)
+			}
+		}
+		level, types = next, nextTypes
+	}
+
+	cached, _ := msgpackFieldCache.LoadOrStore(t, fields)
+	return cached.([]msgpackField)
+}
+
+func appendMsgpackValue(buf *bytes.Buffer, value any) error {
+	switch typed := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if typed {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if n, err := typed.Int64(); err == nil {
+			appendMsgpackInt(buf, n)
+			return nil
+		}
+		f, err := typed.Float64()
+		if err != nil {
+			return fmt.Errorf("invalid number %q: %w", typed.String(), err)
+		}
+		buf.WriteByte(0xcb)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	case string:
+		appendMsgpackString(buf, typed)
+	case []any:
+		appendMsgpackHeader(buf, len(typed), 0x90, 0xdc, 0xdd)
+		for _, item := range typed {
+			if err := appendMsgpackValue(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		appendMsgpackHeader(buf, len(keys), 0x80, 0xde, 0xdf)
+		for _, key := range keys {
+			appendMsgpackString(buf, key)
+			if err := appendMsgpackValue(buf, typed[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported msgpack value %T", value)
+	}
+
+	return nil
+}
+
+func appendMsgpackInt(buf *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0 && n <= 0x7f:
+		buf.WriteByte(byte(n))
+	case n < 0 && n >= -32:
+		buf.WriteByte(byte(int8(n)))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(n)))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		_ = binary.Write(buf, binary.BigEndian, int16(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(n))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+// appendMsgpackString writes value as a str, replacing invalid UTF-8 as
+// encoding/json does.
+func appendMsgpackString(buf *bytes.Buffer, value string) {
+	if !utf8.ValidString(value) {
+		value = strings.ToValidUTF8(value, "�")
+	}
+	n := len(value)
+	switch {
+	case n <= 31:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+	buf.WriteString(value)
+}
+
+func appendMsgpackHeader(buf *bytes.Buffer, n int, fixBase byte, code16 byte, code32 byte) {
+	switch {
+	case n <= 15:
+		buf.WriteByte(fixBase | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(code32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+// readMsgpackFrame reads one msgpack object and re-encodes it as a JSON
+// line. An object over maxBytes, or one that is not a valid request, is an
+// error here; startMsgpackScanner reports those and reads on.
+func readMsgpackFrame(reader *bufio.Reader, maxBytes int) ([]byte, error) {
+	raw, tooLarge, err := readMsgpackObject(reader, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if tooLarge {
+		return nil, fmt.Errorf("msgpack frame exceeds %d bytes", maxBytes)
+	}
+	return decodeMsgpackObject(raw)
+}
+
+// readMsgpackObject returns the bytes of the next msgpack object, walking
+// its headers without decoding or recursing, so every byte sequence has a
+// known end and a frame that fails to decode does not end the stream. An
+// object over maxBytes is skipped and reported as tooLarge. Only I/O errors,
+// such as a stream ending mid-object, are returned.
+func readMsgpackObject(reader *bufio.Reader, maxBytes int) (raw []byte, tooLarge bool, err error) {
+	// keep adds data to raw until the object outgrows maxBytes.
+	keep := func(data []byte) {
+		if !tooLarge && len(raw)+len(data) > maxBytes {
+			tooLarge, raw = true, nil
+		}
+		if !tooLarge {
+			raw = append(raw, data...)
+		}
+	}
+	// skip passes over a payload of n bytes, reading it only while it fits,
+	// so a huge declared length costs no memory.
+	skip := func(n int64) error {
+		if tooLarge || int64(len(raw))+n > int64(maxBytes) {
+			tooLarge, raw = true, nil
+			_, err := io.CopyN(io.Discard, reader, n)
+			return err
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return err
+		}
+		keep(data)
+		return nil
+	}
+	readLength := func(width int) (int64, error) {
+		var data [4]byte
+		if _, err := io.ReadFull(reader, data[:width]); err != nil {
+			return 0, err
+		}
+		keep(data[:width])
+		var n int64
+		for _, b := range data[:width] {
+			n = n<<8 | int64(b)
+		}
+		return n, nil
+	}
+
+	for remaining := int64(1); remaining > 0; remaining-- {
+		code, err := reader.ReadByte()
+		if err != nil {
+			if len(raw) > 0 || tooLarge {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, false, err
+		}
+		keep([]byte{code})
+
+		var payload, children int64
+		switch {
+		case code <= 0x7f, code >= 0xe0, code >= 0xc0 && code <= 0xc3:
+		case code&0xe0 == 0xa0:
+			payload = int64(code & 0x1f)
+		case code&0xf0 == 0x90:
+			children = int64(code & 0x0f)
+		case code&0xf0 == 0x80:
+			children = 2 * int64(code&0x0f)
+		case code >= 0xc4 && code <= 0xc6:
+			if payload, err = readLength(1 << (code - 0xc4)); err != nil {
+				return nil, false, err
+			}
+		case code >= 0xd9 && code <= 0xdb:
+			if payload, err = readLength(1 << (code - 0xd9)); err != nil {
+				return nil, false, err
+			}
+		case code >= 0xc7 && code <= 0xc9:
+			if payload, err = readLength(1 << (code - 0xc7)); err != nil {
+				return nil, false, err
+			}
+			payload++ // the extension type
+		case code >= 0xca && code <= 0xd3:
+			payload = int64([]int{4, 8, 1, 2, 4, 8, 1, 2, 4, 8}[code-0xca])
+		case code >= 0xd4 && code <= 0xd8:
+			payload = 1 + int64(1)<<(code-0xd4)
+		case code == 0xdc, code == 0xde:
+			if children, err = readLength(2); err != nil {
+				return nil, false, err
+			}
+		case code == 0xdd, code == 0xdf:
+			if children, err = readLength(4); err != nil {
+				return nil, false, err
+			}
+		}
+		if code == 0xde || code == 0xdf {
+			children *= 2
+		}
+		if payload > 0 {
+			if err := skip(payload); err != nil {
+				return nil, false, err
+			}
+		}
+		remaining += children
+	}
+	return raw, tooLarge, nil
+}
+
+// decodeMsgpackObject converts a msgpack object read by readMsgpackObject
+// to a JSON line.
+func decodeMsgpackObject(raw []byte) ([]byte, error) {
+	reader := bytes.NewReader(raw)
+	value, err := readMsgpackValue(reader, 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid msgpack request: %w", err)
+	}
+	return json.Marshal(value)
+}
+
+// msgpackReader is what the decoder reads from.
+type msgpackReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+func readMsgpackValue(reader msgpackReader, depth int) (any, error) {
+	code, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xe0 == 0xa0:
+		return readMsgpackString(reader, int(code&0x1f))
+	case code&0xf0 == 0x90:
+		return readMsgpackArray(reader, depth, int(code&0x0f))
+	case code&0xf0 == 0x80:
+		return readMsgpackMap(reader, depth, int(code&0x0f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackLength(reader, code-0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackString(reader, n)
+	case 0xca:
+		var bits uint32
+		if err := binary.Read(reader, binary.BigEndian, &bits); err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(bits)), nil
+	case 0xcb:
+		var bits uint64
+		if err := binary.Read(reader, binary.BigEndian, &bits); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(bits), nil
+	case 0xcc:
+		var n uint8
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int64(n), err
+	case 0xcd:
+		var n uint16
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int64(n), err
+	case 0xce:
+		var n uint32
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int64(n), err
+	case 0xcf:
+		var n uint64
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return n, err
+	case 0xd0:
+		var n int8
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int64(n), err
+	case 0xd1:
+		var n int16
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int64(n), err
+	case 0xd2:
+		var n int32
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int64(n), err
+	case 0xd3:
+		var n int64
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return n, err
+	case 0xd9, 0xda, 0xdb:
+		n, err := readMsgpackLength(reader, code-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackString(reader, n)
+	case 0xdc, 0xdd:
+		n, err := readMsgpackLength(reader, code-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(reader, depth, n)
+	case 0xde, 0xdf:
+		n, err := readMsgpackLength(reader, code-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(reader, depth, n)
+	default:
+		return nil, fmt.Errorf("unsupported msgpack type 0x%02x", code)
+	}
+}
+
+// readMsgpackLength reads a 1, 2 or 4 byte big-endian length for width 0, 1 or 2.
+func readMsgpackLength(reader msgpackReader, width byte) (int, error) {
+	switch width {
+	case 0:
+		var n uint8
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int(n), err
+	case 1:
+		var n uint16
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int(n), err
+	default:
+		var n uint32
+		err := binary.Read(reader, binary.BigEndian, &n)
+		return int(n), err
+	}
+}
+
+// readMsgpackString reads n bytes; readMsgpackObject already bounded them.
+func readMsgpackString(reader msgpackReader, n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func readMsgpackArray(reader msgpackReader, depth int, n int) ([]any, error) {
+	if n > maxMsgpackContainerLen {
+		return nil, fmt.Errorf("msgpack array of %d items exceeds limit", n)
+	}
+	if depth >= maxMsgpackDepth {
+		return nil, fmt.Errorf("msgpack nesting exceeds %d levels", maxMsgpackDepth)
+	}
+
+	items := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		item, err := readMsgpackValue(reader, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func readMsgpackMap(reader msgpackReader, depth int, n int) (map[string]any, error) {
+	if n > maxMsgpackContainerLen {
+		return nil, fmt.Errorf("msgpack map of %d entries exceeds limit", n)
+	}
+	if depth >= maxMsgpackDepth {
+		return nil, fmt.Errorf("msgpack nesting exceeds %d levels", maxMsgpackDepth)
+	}
+
+	entries := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		key, err := readMsgpackValue(reader, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, errors.New("msgpack map keys must be strings")
+		}
+
+		value, err := readMsgpackValue(reader, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		entries[keyString] = value
+	}
+	return entries, nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgpackFrameRoundTripMatchesJSONSchema(t *testing.T) {
+	var buf bytes.Buffer
+
+	payload := exitEvent{
+		Type:       eventTypeExit,
+		TerminalID: "t1",
+		Code:       -1073741510,
+	}
+	if err := writeMsgpackFrame(&buf, payload); err != nil {
+		t.Fatalf("writeMsgpackFrame failed: %v", err)
+	}
+
+	line, err := readMsgpackFrame(bufio.NewReader(&buf), defaultMaxRequestBytes)
+	if err != nil {
+		t.Fatalf("readMsgpackFrame failed: %v", err)
+	}
+
+	var decoded exitEvent
+	if err := json.Unmarshal(line, &decoded); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if decoded != payload {
+		t.Fatalf("round trip mismatch: %+v", decoded)
+	}
+}
+
+func TestMsgpackFrameEncodesLongStringsAndMaps(t *testing.T) {
+	var buf bytes.Buffer
+
+	longValue := string(bytes.Repeat([]byte("x"), 70000))
+	req := openRequest{
+		Type:       requestTypeOpen,
+		TerminalID: "t1",
+		Cols:       300,
+		Rows:       24,
+		Cwd:        longValue,
+		Env:        map[string]string{"K": "V"},
+	}
+	if err := writeMsgpackFrame(&buf, req); err != nil {
+		t.Fatalf("writeMsgpackFrame failed: %v", err)
+	}
+
+	line, err := readMsgpackFrame(bufio.NewReader(&buf), defaultMaxRequestBytes)
+	if err != nil {
+		t.Fatalf("readMsgpackFrame failed: %v", err)
+	}
+
+	decoded, err := decodeRequestLine(line)
+	if err != nil {
+		t.Fatalf("decodeRequestLine failed: %v", err)
+	}
+	openReq, ok := decoded.(openRequest)
+	if !ok {
+		t.Fatalf("decoded type mismatch: %T", decoded)
+	}
+	if openReq.Cols != 300 || openReq.Cwd != longValue || openReq.Env["K"] != "V" {
+		t.Fatalf("unexpected open request: cols=%d cwdLen=%d env=%v", openReq.Cols, len(openReq.Cwd), openReq.Env)
+	}
+}
+
+func TestMsgpackEncodesEventsLikeJSON(t *testing.T) {
+	payloads := []any{
+		helloEvent{
+			Type:         eventTypeHello,
+			Version:      sidecarVersion,
+			Protocols:    []int{1, 2},
+			Capabilities: sidecarCapabilities(runConfig{}, backendConPTY),
+			Platform:     &platformInfo{},
+		},
+		outputEvent{Type: eventTypeOutput, TerminalID: "t1", Data: "aGk=", Timestamp: &outputTimestamp{}},
+		closedEvent{Type: eventTypeClosed, terminalCloseResult: terminalCloseResult{TerminalID: "t1", Method: "forced"}, Seq: 3},
+		exitEvent{Type: eventTypeExit, TerminalID: "t1", Code: -1073741510},
+		addressedEvent{payload: errorEvent{Type: eventTypeError, Message: "bad \xff byte"}},
+	}
+	for _, message := range protocolEvents {
+		payloads = append(payloads, message.Payload)
+	}
+
+	for _, payload := range payloads {
+		var buf bytes.Buffer
+		if err := writeMsgpackFrame(&buf, payload); err != nil {
+			t.Fatalf("writeMsgpackFrame(%T) failed: %v", payload, err)
+		}
+		line, err := readMsgpackFrame(bufio.NewReader(&buf), defaultMaxRequestBytes)
+		if err != nil {
+			t.Fatalf("readMsgpackFrame(%T) failed: %v", payload, err)
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatalf("json.Marshal(%T) failed: %v", payload, err)
+		}
+
+		var got, want any
+		if err := json.Unmarshal(line, &got); err != nil {
+			t.Fatalf("json unmarshal failed: %v", err)
+		}
+		if err := json.Unmarshal(encoded, &want); err != nil {
+			t.Fatalf("json unmarshal failed: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%T differs between encodings:\nmsgpack %s\n   json %s", payload, line, encoded)
+		}
+	}
+}
+
+func TestMsgpackScannerSkipsMalformedFrames(t *testing.T) {
+	var stdin bytes.Buffer
+	// Nesting past the depth limit, a map with an integer key and a string
+	// over the size limit, each followed by a valid frame.
+	stdin.Write(bytes.Repeat([]byte{0x91}, maxMsgpackDepth+1))
+	stdin.WriteByte(0xc0)
+	stdin.Write([]byte{0x81, 0x01, 0x02})
+	stdin.Write([]byte{0xdb, 0x00, 0x01, 0x00, 0x00})
+	stdin.Write(make([]byte, 1<<16))
+	if err := writeMsgpackFrame(&stdin, pingRequest{Type: requestTypePing}); err != nil {
+		t.Fatalf("writeMsgpackFrame failed: %v", err)
+	}
+
+	var messages []scannerMessage
+	for msg := range startMsgpackScanner(&stdin, 1<<15) {
+		messages = append(messages, msg)
+	}
+	if len(messages) != 5 || messages[4].Done != true {
+		t.Fatalf("unexpected messages: %+v", messages)
+	}
+	if messages[0].Invalid == nil || !strings.Contains(messages[0].Invalid.Error(), "nesting") {
+		t.Fatalf("deep nesting should be invalid, got %+v", messages[0])
+	}
+	if messages[1].Invalid == nil || !messages[2].TooLarge {
+		t.Fatalf("expected an invalid and a too large frame, got %+v %+v", messages[1], messages[2])
+	}
+	if req, err := decodeRequestLine(messages[3].Line); err != nil || req.requestType() != requestTypePing {
+		t.Fatalf("the stream should go on after malformed frames, got %q: %v", messages[3].Line, err)
+	}
+}
+
+func TestRunSidecarSpeaksMsgpack(t *testing.T) {
+	var stdin bytes.Buffer
+	// A malformed frame is reported like a malformed NDJSON line.
+	stdin.Write([]byte{0x81, 0x01, 0x02})
+	for _, req := range []any{pingRequest{Type: requestTypePing}, shutdownRequest{Type: requestTypeShutdown}} {
+		if err := writeMsgpackFrame(&stdin, req); err != nil {
+			t.Fatalf("writeMsgpackFrame failed: %v", err)
+		}
+	}
+	var stdout bytes.Buffer
+
+	exitCode := runSidecar(&stdin, &stdout, runConfig{
+		Encoding:    wireEncodingMsgpack,
+		IdleTimeout: 2 * time.Second,
+		ProbeConPTY: func() error { return nil },
+	})
+	if exitCode != 0 {
+		t.Fatalf("expected graceful shutdown exit code 0, got %d", exitCode)
+	}
+
+	reader := bufio.NewReader(&stdout)
+	types := make([]string, 0, 3)
+	for {
+		line, err := readMsgpackFrame(reader, defaultMaxRequestBytes)
+		if err != nil {
+			break
+		}
+		var evt map[string]any
+		if err := json.Unmarshal(line, &evt); err != nil {
+			t.Fatalf("json unmarshal failed: %v", err)
+		}
+		types = append(types, evt["type"].(string))
+	}
+
+	expected := []string{eventTypeHello, eventTypeError, eventTypePong, eventTypeShutdownAck}
+	if len(types) != len(expected) {
+		t.Fatalf("unexpected events: %v", types)
+	}
+	for idx := range expected {
+		if types[idx] != expected[idx] {
+			t.Fatalf("unexpected events: %v", types)
+		}
+	}
+}
+
+func BenchmarkWriteMsgpackFrame(b *testing.B) {
+	evt := outputEvent{Type: eventTypeOutput, TerminalID: "t1", Data: strings.Repeat("QUJD", 1024)}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := writeMsgpackFrame(io.Discard, evt); err != nil {
+			b.Fatalf("writeMsgpackFrame failed: %v", err)
+		}
+	}
+}
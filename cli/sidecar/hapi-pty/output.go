@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	outputCompressionNone = ""
+	outputCompressionGzip = "gzip"
+	outputCompressionZstd = "zstd"
+)
+
+const (
+	outputEncodingBase64 = "base64"
+	outputEncodingUTF8   = "utf8"
+)
+
+// minCompressibleChunkBytes skips compression for chunks too small to shrink,
+// such as single keystroke echoes.
+const minCompressibleChunkBytes = 256
+
+var supportedOutputCompressions = []string{outputCompressionGzip, outputCompressionZstd}
+
+// zstdEncoder and zstdDecoder are shared by every terminal, as EncodeAll and
+// DecodeAll may run concurrently.
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		encoder, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return encoder
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		decoder, _ := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+		return decoder
+	})
+)
+
+// processStart anchors the monotonic part of output timestamps, so they are
+// comparable across every terminal of the process.
+var processStart = time.Now()
+
+// outputEncoder turns raw PTY chunks into output events using the options
+// negotiated by the open request.
+type outputEncoder struct {
+	terminalID  string
+	compression string
+	utf8        bool
+	// normalize replaces invalid UTF-8 in base64 payloads too, for
+	// terminals opened with forceUtf8.
+	normalize  bool
+	timestamps bool
+
+	mu sync.Mutex
+	// pending holds a trailing partial UTF-8 sequence until the next chunk.
+	pending []byte
+}
+
+func newOutputEncoder(req openRequest) (*outputEncoder, error) {
+	switch req.Compression {
+	case outputCompressionNone, outputCompressionGzip, outputCompressionZstd:
+	default:
+		return nil, newSidecarError(errorCodeInvalidRequest, "unsupported compression %q", req.Compression)
+	}
+
+	switch req.OutputEncoding {
+	case "", outputEncodingBase64, outputEncodingUTF8:
+	default:
+		return nil, newSidecarError(errorCodeInvalidRequest, "unsupported output encoding %q", req.OutputEncoding)
+	}
+
+	useUTF8 := req.OutputEncoding == outputEncodingUTF8
+	if useUTF8 && req.Compression != outputCompressionNone {
+		return nil, newSidecarError(errorCodeInvalidRequest, "utf8 output encoding cannot be combined with compression")
+	}
+
+	return &outputEncoder{
+		terminalID:  req.TerminalID,
+		compression: req.Compression,
+		utf8:        useUTF8,
+		normalize:   req.ForceUTF8,
+		timestamps:  req.Timestamps,
+	}, nil
+}
+
+// timestamp stamps an event with the time its chunk was read, before any
+// backpressure queueing, when the terminal was opened with timestamps.
+func (e *outputEncoder) timestamp() *outputTimestamp {
+	if !e.timestamps {
+		return nil
+	}
+
+	now := time.Now()
+	return &outputTimestamp{
+		MonotonicUs: now.Sub(processStart).Microseconds(),
+		Wall:        now.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func (e *outputEncoder) Event(chunk []byte) outputEvent {
+	evt := outputEvent{
+		Type:       eventTypeOutput,
+		TerminalID: e.terminalID,
+		Timestamp:  e.timestamp(),
+	}
+
+	if e.utf8 || e.normalize {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		data := append(e.pending, chunk...)
+		complete := completeUTF8Prefix(data)
+		e.pending = append([]byte(nil), data[complete:]...)
+
+		if e.utf8 {
+			evt.Encoding = outputEncodingUTF8
+			evt.Data = toValidUTF8(data[:complete])
+			return evt
+		}
+		chunk = bytes.ToValidUTF8(data[:complete], []byte(string(utf8.RuneError)))
+	}
+
+	if e.compression != outputCompressionNone && len(chunk) >= minCompressibleChunkBytes {
+		compress := gzipChunk
+		if e.compression == outputCompressionZstd {
+			compress = zstdChunk
+		}
+		if compressed, ok := compress(chunk); ok {
+			evt.Compression = e.compression
+			evt.Data = encodeBase64(compressed)
+			return evt
+		}
+	}
+
+	evt.Data = encodeBase64(chunk)
+	return evt
+}
+
+// Flush emits any partial UTF-8 sequence still buffered when the stream ends.
+func (e *outputEncoder) Flush() (outputEvent, bool) {
+	if !e.utf8 && !e.normalize {
+		return outputEvent{}, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.pending) == 0 {
+		return outputEvent{}, false
+	}
+
+	data := toValidUTF8(e.pending)
+	e.pending = nil
+	if !e.utf8 {
+		return outputEvent{Type: eventTypeOutput, TerminalID: e.terminalID, Data: encodeBase64([]byte(data)), Timestamp: e.timestamp()}, true
+	}
+	return outputEvent{
+		Type:       eventTypeOutput,
+		TerminalID: e.terminalID,
+		Data:       data,
+		Encoding:   outputEncodingUTF8,
+		Timestamp:  e.timestamp(),
+	}, true
+}
+
+// completeUTF8Prefix returns the length of data excluding a trailing sequence
+// that is a valid but truncated UTF-8 encoding.
+func completeUTF8Prefix(data []byte) int {
+	for back := 1; back <= utf8.UTFMax-1 && back <= len(data); back++ {
+		start := len(data) - back
+		lead := data[start]
+		if utf8.RuneStart(lead) {
+			if lead >= utf8.RuneSelf && !utf8.FullRune(data[start:]) {
+				return start
+			}
+			break
+		}
+	}
+	return len(data)
+}
+
+func toValidUTF8(data []byte) string {
+	return string(bytes.ToValidUTF8(data, []byte(string(utf8.RuneError))))
+}
+
+var base64BufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, base64.StdEncoding.EncodedLen(4096))
+		return &buf
+	},
+}
+
+// encodeBase64 encodes through a pooled scratch buffer so only the resulting
+// string is allocated.
+func encodeBase64(data []byte) string {
+	bufPtr := base64BufferPool.Get().(*[]byte)
+	size := base64.StdEncoding.EncodedLen(len(data))
+	if cap(*bufPtr) < size {
+		*bufPtr = make([]byte, size)
+	}
+	buf := (*bufPtr)[:size]
+	base64.StdEncoding.Encode(buf, data)
+	encoded := string(buf)
+	base64BufferPool.Put(bufPtr)
+	return encoded
+}
+
+// gzipChunk compresses a chunk as a standalone gzip member and reports whether
+// the result is actually smaller than the input.
+func gzipChunk(chunk []byte) ([]byte, bool) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(chunk); err != nil {
+		return nil, false
+	}
+	if err := writer.Close(); err != nil {
+		return nil, false
+	}
+	if buf.Len() >= len(chunk) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// zstdChunk compresses chunk as one zstd frame, like gzipChunk.
+func zstdChunk(chunk []byte) ([]byte, bool) {
+	compressed := zstdEncoder().EncodeAll(chunk, nil)
+	if len(compressed) >= len(chunk) {
+		return nil, false
+	}
+	return compressed, true
+}
+
+// decodeOutputEvent reverses outputEncoder.Event, returning the raw chunk.
+func decodeOutputEvent(evt outputEvent) ([]byte, error) {
+	if evt.Encoding == outputEncodingUTF8 {
+		return []byte(evt.Data), nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(evt.Data)
+	if err != nil {
+		return nil, err
+	}
+	switch evt.Compression {
+	case outputCompressionZstd:
+		return zstdDecoder().DecodeAll(data, nil)
+	case outputCompressionGzip:
+	default:
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+const (
+	outputFilterRaw           = "raw"
+	outputFilterStripANSI     = "strip-ansi"
+	outputFilterNormalizeCRLF = "normalize-crlf"
+)
+
+var supportedOutputFilters = []string{outputFilterRaw, outputFilterStripANSI, outputFilterNormalizeCRLF}
+
+// outputFilter rewrites output before it is encoded, for hosts that want
+// text rather than a terminal stream. strip-ansi removes escape sequences
+// and control characters other than tab, newline and carriage return, as
+// stripANSI does; normalize-crlf turns CRLF into LF. Sequences split across
+// chunks are held back until complete. A nil filter passes output through.
+type outputFilter struct {
+	mode string
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func newOutputFilter(mode string) (*outputFilter, error) {
+	switch mode {
+	case "", outputFilterRaw:
+		return nil, nil
+	case outputFilterStripANSI, outputFilterNormalizeCRLF:
+		return &outputFilter{mode: mode}, nil
+	default:
+		return nil, newSidecarError(errorCodeInvalidRequest, "unsupported output filter %q", mode)
+	}
+}
+
+func (f *outputFilter) Apply(chunk []byte) []byte {
+	if f == nil {
+		return chunk
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data := chunk
+	if len(f.pending) > 0 {
+		data = append(f.pending, chunk...)
+		f.pending = nil
+	}
+
+	var out []byte
+	var rest int
+	if f.mode == outputFilterStripANSI {
+		out, rest = stripANSIStream(data)
+	} else {
+		out, rest = normalizeCRLF(data)
+	}
+	if rest < len(data) {
+		f.pending = append([]byte(nil), data[rest:]...)
+	}
+	return out
+}
+
+// Flush returns what is left of a sequence held back when the stream ends:
+// a lone CR is kept, an unterminated escape sequence is dropped.
+func (f *outputFilter) Flush() []byte {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pending := f.pending
+	f.pending = nil
+	if f.mode == outputFilterNormalizeCRLF {
+		return pending
+	}
+	return nil
+}
+
+// stripANSIStream strips data like stripANSI and reports how much of it was
+// consumed; the rest is an escape sequence that may continue in the next
+// chunk.
+func stripANSIStream(data []byte) ([]byte, int) {
+	out := make([]byte, 0, len(data))
+	for idx := 0; idx < len(data); idx++ {
+		b := data[idx]
+		switch {
+		case b == 0x1b:
+			end := skipOutputEscape(data, idx)
+			if !outputEscapeComplete(data, idx, end) && len(data)-idx <= maxPendingSequenceBytes {
+				return out, idx
+			}
+			idx = end
+		case b == '\t' || b == '\n' || b == '\r':
+			out = append(out, b)
+		case b < 0x20 || b == 0x7f:
+		default:
+			out = append(out, b)
+		}
+	}
+	return out, len(data)
+}
+
+// outputEscapeComplete reports whether the sequence skipOutputEscape found
+// between start and end is terminated rather than cut off by the buffer.
+func outputEscapeComplete(data []byte, start int, end int) bool {
+	if end == start {
+		return false
+	}
+	switch data[start+1] {
+	case '[':
+		return end > start+1 && data[end] >= 0x40 && data[end] <= 0x7e
+	case ']', 'P', 'X', '^', '_':
+		_, terminated := findStringTerminator(data, start+2)
+		return terminated > 0
+	case '(', ')', '*', '+':
+		return end == start+2
+	}
+	return true
+}
+
+// normalizeCRLF replaces CRLF with LF, holding back a trailing CR until the
+// next chunk shows whether an LF follows it.
+func normalizeCRLF(data []byte) ([]byte, int) {
+	consumed := len(data)
+	if consumed > 0 && data[consumed-1] == '\r' {
+		consumed--
+	}
+	if bytes.Index(data[:consumed], []byte("\r\n")) < 0 {
+		return data[:consumed], consumed
+	}
+	return bytes.ReplaceAll(data[:consumed], []byte("\r\n"), []byte("\n")), consumed
+}
+
+// maxReplayEvents bounds --replay-events, which every terminal keeps.
+const maxReplayEvents = 1 << 16
+
+// eventLog numbers a terminal's stream events, the ones with a Seq field,
+// and keeps the latest limit of them so a client that reconnects can
+// resume where it left off instead of taking a snapshot. Events are
+// emitted under its lock, so the wire order matches the seq order.
+type eventLog struct {
+	mu     sync.Mutex
+	limit  int
+	seq    uint64
+	events []any
+}
+
+func newEventLog(limit int) *eventLog {
+	if limit <= 0 {
+		return nil
+	}
+	return &eventLog{limit: limit}
+}
+
+// Emit numbers payload, keeps it and passes it to emit.
+func (l *eventLog) Emit(payload any, emit func(any)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	payload = withSeq(payload, l.seq)
+	if len(l.events) == l.limit {
+		copy(l.events, l.events[1:])
+		l.events = l.events[:l.limit-1]
+	}
+	l.events = append(l.events, payload)
+	emit(payload)
+}
+
+// Attach calls attached with the latest seq and, when every event from
+// seq from on is still kept, those events. No event is emitted meanwhile.
+func (l *eventLog) Attach(from uint64, attached func(last uint64, missed []any, resumed bool)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	oldest := l.seq - uint64(len(l.events)) + 1
+	if from == 0 || from < oldest || from > l.seq+1 {
+		attached(l.seq, nil, false)
+		return
+	}
+	attached(l.seq, l.events[from-oldest:], true)
+}
+
+// withSeq returns payload with its Seq field set, or payload itself when it
+// has none.
+func withSeq(payload any, seq uint64) any {
+	value := reflect.New(reflect.TypeOf(payload)).Elem()
+	value.Set(reflect.ValueOf(payload))
+	field := value.FieldByName("Seq")
+	if !field.IsValid() || field.Kind() != reflect.Uint64 {
+		return payload
+	}
+	field.SetUint(seq)
+	return value.Interface()
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestOutputEncoderDefaultsToBase64(t *testing.T) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1"})
+	if err != nil {
+		t.Fatalf("newOutputEncoder failed: %v", err)
+	}
+
+	evt := encoder.Event([]byte("hello"))
+	if evt.Type != eventTypeOutput || evt.TerminalID != "t1" {
+		t.Fatalf("unexpected event envelope: %+v", evt)
+	}
+	if evt.Compression != "" {
+		t.Fatalf("expected uncompressed event, got %q", evt.Compression)
+	}
+	if evt.Data != base64.StdEncoding.EncodeToString([]byte("hello")) {
+		t.Fatalf("unexpected data: %s", evt.Data)
+	}
+}
+
+func TestOutputEncoderGzipCompressesLargeChunks(t *testing.T) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1", Compression: outputCompressionGzip})
+	if err != nil {
+		t.Fatalf("newOutputEncoder failed: %v", err)
+	}
+
+	chunk := []byte(strings.Repeat("building module 42\r\n", 200))
+	evt := encoder.Event(chunk)
+	if evt.Compression != outputCompressionGzip {
+		t.Fatalf("expected gzip compression, got %q", evt.Compression)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(evt.Data)
+	if err != nil {
+		t.Fatalf("base64 decode failed: %v", err)
+	}
+	if len(compressed) >= len(chunk) {
+		t.Fatalf("expected compressed payload to shrink: %d >= %d", len(compressed), len(chunk))
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("gzip reader failed: %v", err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("gzip read failed: %v", err)
+	}
+	if !bytes.Equal(decoded, chunk) {
+		t.Fatal("decompressed payload mismatch")
+	}
+}
+
+func TestOutputEncoderGzipSkipsSmallChunks(t *testing.T) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1", Compression: outputCompressionGzip})
+	if err != nil {
+		t.Fatalf("newOutputEncoder failed: %v", err)
+	}
+
+	evt := encoder.Event([]byte("a"))
+	if evt.Compression != "" {
+		t.Fatalf("expected small chunk to stay uncompressed, got %q", evt.Compression)
+	}
+}
+
+func TestOutputEncoderZstdCompressesLargeChunks(t *testing.T) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1", Compression: outputCompressionZstd})
+	if err != nil {
+		t.Fatalf("newOutputEncoder failed: %v", err)
+	}
+
+	chunk := []byte(strings.Repeat("building module 42\r\n", 200))
+	evt := encoder.Event(chunk)
+	if evt.Compression != outputCompressionZstd {
+		t.Fatalf("expected zstd compression, got %q", evt.Compression)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(evt.Data)
+	if err != nil {
+		t.Fatalf("base64 decode failed: %v", err)
+	}
+	if len(compressed) >= len(chunk) {
+		t.Fatalf("expected compressed payload to shrink: %d >= %d", len(compressed), len(chunk))
+	}
+
+	reader, err := zstd.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("zstd reader failed: %v", err)
+	}
+	defer reader.Close()
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("zstd read failed: %v", err)
+	}
+	if !bytes.Equal(decoded, chunk) {
+		t.Fatal("decompressed payload mismatch")
+	}
+	if roundTrip, err := decodeOutputEvent(evt); err != nil || !bytes.Equal(roundTrip, chunk) {
+		t.Fatalf("decodeOutputEvent should reverse zstd compression (%v)", err)
+	}
+}
+
+func TestOutputEncoderRejectsUnsupportedCompression(t *testing.T) {
+	_, err := newOutputEncoder(openRequest{TerminalID: "t1", Compression: "brotli"})
+
+	var serr *sidecarError
+	if !errors.As(err, &serr) {
+		t.Fatalf("expected sidecarError, got %T", err)
+	}
+	if serr.Code != errorCodeInvalidRequest {
+		t.Fatalf("unexpected error code: %s", serr.Code)
+	}
+}
+
+func TestOutputEncoderUTF8CarriesSplitSequences(t *testing.T) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1", OutputEncoding: outputEncodingUTF8})
+	if err != nil {
+		t.Fatalf("newOutputEncoder failed: %v", err)
+	}
+
+	euro := []byte("€")
+	first := encoder.Event(append([]byte("price "), euro[:2]...))
+	second := encoder.Event(append(euro[2:], []byte("5")...))
+
+	if first.Encoding != outputEncodingUTF8 || second.Encoding != outputEncodingUTF8 {
+		t.Fatalf("expected utf8 encoding, got %q and %q", first.Encoding, second.Encoding)
+	}
+	if first.Data != "price " {
+		t.Fatalf("unexpected first chunk: %q", first.Data)
+	}
+	if second.Data != "€5" {
+		t.Fatalf("unexpected second chunk: %q", second.Data)
+	}
+	if _, ok := encoder.Flush(); ok {
+		t.Fatal("expected nothing pending after complete sequence")
+	}
+}
+
+func TestOutputEncoderUTF8ReplacesInvalidBytes(t *testing.T) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1", OutputEncoding: outputEncodingUTF8})
+	if err != nil {
+		t.Fatalf("newOutputEncoder failed: %v", err)
+	}
+
+	evt := encoder.Event([]byte{'a', 0xff, 'b'})
+	if evt.Data != "a�b" {
+		t.Fatalf("unexpected replacement output: %q", evt.Data)
+	}
+
+	encoder.Event([]byte{0xe2, 0x82})
+	pending, ok := encoder.Flush()
+	if !ok {
+		t.Fatal("expected truncated sequence to be flushed")
+	}
+	if pending.Data != "�" {
+		t.Fatalf("unexpected flushed output: %q", pending.Data)
+	}
+}
+
+func TestOutputEncoderForceUTF8NormalizesBase64(t *testing.T) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1", ForceUTF8: true})
+	if err != nil {
+		t.Fatalf("newOutputEncoder failed: %v", err)
+	}
+
+	euro := []byte("€")
+	var got []byte
+	for _, chunk := range [][]byte{append([]byte{'a', 0xff}, euro[:1]...), euro[1:], {0xe2}} {
+		evt := encoder.Event(chunk)
+		if evt.Encoding != "" {
+			t.Fatalf("expected base64 payloads, got %q", evt.Encoding)
+		}
+		data, _ := decodeOutputEvent(evt)
+		got = append(got, data...)
+	}
+	pending, ok := encoder.Flush()
+	if !ok {
+		t.Fatal("expected the truncated sequence to be flushed")
+	}
+	data, _ := decodeOutputEvent(pending)
+	got = append(got, data...)
+
+	if string(got) != "a�€�" {
+		t.Fatalf("unexpected normalized output: %q", got)
+	}
+}
+
+func TestOutputEncoderRejectsUTF8WithCompression(t *testing.T) {
+	_, err := newOutputEncoder(openRequest{
+		TerminalID:     "t1",
+		OutputEncoding: outputEncodingUTF8,
+		Compression:    outputCompressionGzip,
+	})
+	if err == nil {
+		t.Fatal("expected invalid combination error")
+	}
+}
+
+func BenchmarkOutputEncoderEvent(b *testing.B) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1"})
+	if err != nil {
+		b.Fatalf("newOutputEncoder failed: %v", err)
+	}
+	chunk := bytes.Repeat([]byte("PS C:\\> dir\r\n"), 4096/13)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(chunk)))
+	for i := 0; i < b.N; i++ {
+		encoder.Event(chunk)
+	}
+}
+
+func BenchmarkOutputPath(b *testing.B) {
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1"})
+	if err != nil {
+		b.Fatalf("newOutputEncoder failed: %v", err)
+	}
+	inspector := newVTInspector(openRequest{TerminalID: "t1"}, func(any) {})
+	writer := &safeWriter{writer: io.Discard}
+	chunk := bytes.Repeat([]byte("PS C:\\> dir\r\n"), 4096/13)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(chunk)))
+	for i := 0; i < b.N; i++ {
+		forwarded := inspector.Feed(chunk)
+		if err := writer.Emit(encoder.Event(forwarded)); err != nil {
+			b.Fatalf("Emit failed: %v", err)
+		}
+	}
+}
+
+func TestOutputFilterStripsANSIAcrossChunks(t *testing.T) {
+	filter, err := newOutputFilter(outputFilterStripANSI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	for _, chunk := range []string{"\x1b[32mok\x1b", "[0m\r\n\x1b]0;ti", "tle\x07done\x07\x1b[1"} {
+		got = append(got, filter.Apply([]byte(chunk))...)
+	}
+	got = append(got, filter.Flush()...)
+	if string(got) != "ok\r\ndone" {
+		t.Fatalf("unexpected filtered output %q", got)
+	}
+}
+
+func TestOutputFilterNormalizesSplitCRLF(t *testing.T) {
+	filter, err := newOutputFilter(outputFilterNormalizeCRLF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+	for _, chunk := range []string{"a\r\nb\r", "\nprogress\r", "50%\r"} {
+		got = append(got, filter.Apply([]byte(chunk))...)
+	}
+	got = append(got, filter.Flush()...)
+	if string(got) != "a\nb\nprogress\r50%\r" {
+		t.Fatalf("unexpected filtered output %q", got)
+	}
+
+	if raw, err := newOutputFilter(outputFilterRaw); raw != nil || err != nil {
+		t.Fatalf("expected raw to disable filtering, got %v, %v", raw, err)
+	}
+	if _, err := newOutputFilter("html"); err == nil {
+		t.Fatal("expected an unsupported filter to be rejected")
+	}
+}
+
+func TestOutputEncoderTimestampsEvents(t *testing.T) {
+	plain, err := newOutputEncoder(openRequest{TerminalID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evt := plain.Event([]byte("a")); evt.Timestamp != nil {
+		t.Fatalf("expected no timestamp by default, got %+v", evt.Timestamp)
+	}
+
+	encoder, err := newOutputEncoder(openRequest{TerminalID: "t1", Timestamps: true, OutputEncoding: outputEncodingUTF8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := encoder.Event([]byte("a"))
+	second := encoder.Event([]byte("b\xe2\x82"))
+	flushed, ok := encoder.Flush()
+	if !ok || first.Timestamp == nil || second.Timestamp == nil || flushed.Timestamp == nil {
+		t.Fatalf("expected every event to be stamped: %+v %+v %+v", first, second, flushed)
+	}
+	if second.Timestamp.MonotonicUs < first.Timestamp.MonotonicUs {
+		t.Fatalf("monotonic time went backwards: %d then %d", first.Timestamp.MonotonicUs, second.Timestamp.MonotonicUs)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, first.Timestamp.Wall); err != nil {
+		t.Fatalf("wall time is not RFC 3339: %v", err)
+	}
+}
+
+func TestEventLogKeepsLatestEventsInSeqOrder(t *testing.T) {
+	log := newEventLog(2)
+	var emitted []any
+	for _, data := range []string{"a", "b", "c"} {
+		log.Emit(outputEvent{Type: eventTypeOutput, TerminalID: "t1", Data: data}, func(payload any) {
+			emitted = append(emitted, payload)
+		})
+	}
+	if got := emitted[2].(outputEvent); got.Seq != 3 || got.Data != "c" {
+		t.Fatalf("expected the third event to be seq 3, got %+v", got)
+	}
+
+	for _, tc := range []struct {
+		from    uint64
+		missed  int
+		resumed bool
+	}{
+		{0, 0, false},
+		{1, 0, false},
+		{2, 2, true},
+		{4, 0, true},
+		{5, 0, false},
+	} {
+		log.Attach(tc.from, func(last uint64, missed []any, resumed bool) {
+			if last != 3 || len(missed) != tc.missed || resumed != tc.resumed {
+				t.Fatalf("Attach(%d) = %d, %d missed, resumed %v", tc.from, last, len(missed), resumed)
+			}
+		})
+	}
+	if newEventLog(0) != nil {
+		t.Fatal("a zero limit should disable the log")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	privilegeInherit = ""
+	// privilegeRestricted drops administrator group membership and
+	// privileges and runs at medium integrity.
+	privilegeRestricted = "restricted"
+	// privilegeLow additionally runs at low integrity, which blocks writes
+	// to most of the user profile.
+	privilegeLow = "low"
+)
+
+// Priority classes a terminal's process tree may be held to. Only classes
+// at or below normal are offered so terminals never outrank the host's UI.
+const (
+	priorityInherit     = ""
+	priorityIdle        = "idle"
+	priorityBelowNormal = "below-normal"
+	priorityNormal      = "normal"
+)
+
+func validatePrivilege(req openRequest) error {
+	switch req.Privilege {
+	case privilegeInherit:
+		return nil
+	case privilegeRestricted, privilegeLow:
+		if req.Elevated {
+			return newSidecarError(errorCodeInvalidRequest, "elevated terminals cannot use privilege %q", req.Privilege)
+		}
+		return nil
+	default:
+		return newSidecarError(errorCodeInvalidRequest, "unsupported privilege %q", req.Privilege)
+	}
+}
+
+// maxDesktopBytes bounds a desktop name, which Windows limits to 256
+// characters for the station and desktop together.
+const maxDesktopBytes = 512
+
+// validateWindowOptions checks an open's desktop and session, which only
+// ConPTY terminals can honor.
+func validateWindowOptions(req openRequest) error {
+	if len(req.Desktop) > maxDesktopBytes || strings.ContainsRune(req.Desktop, 0) || strings.Count(req.Desktop, `\`) > 1 {
+		return newSidecarError(errorCodeInvalidRequest, "desktop must be \"station\\desktop\" or a desktop name")
+	}
+	if (req.Desktop != "" || req.SessionID != nil) && req.Backend != "" && req.Backend != backendConPTY {
+		return newSidecarError(errorCodeInvalidRequest, "desktop and sessionId need the conpty backend")
+	}
+	return nil
+}
+
+// validateResourceLimits checks the job object limits of an open request.
+func validateResourceLimits(req openRequest) error {
+	if req.MaxMemoryMb < 0 {
+		return newSidecarError(errorCodeInvalidRequest, "maxMemoryMb must not be negative")
+	}
+	if req.CPURatePercent < 0 || req.CPURatePercent > 100 {
+		return newSidecarError(errorCodeInvalidRequest, "cpuRatePercent must be between 1 and 100")
+	}
+	switch req.Priority {
+	case priorityInherit, priorityIdle, priorityBelowNormal, priorityNormal:
+	default:
+		return newSidecarError(errorCodeInvalidRequest, "unsupported priority %q", req.Priority)
+	}
+	return nil
+}
+
+// execPolicy constrains which executables terminals may start. Patterns are
+// path.Match globs compared case-insensitively with forward slashes, so
+// "c:/program files/powershell/*/pwsh.exe" matches any installed version.
+type execPolicy struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+	// RequireSigned rejects executables without a valid Authenticode
+	// signature. Binaries under the Windows directory are exempt: they are
+	// catalog signed and protected by the OS.
+	RequireSigned bool `json:"requireSigned,omitempty"`
+
+	verifySignature func(path string) error
+}
+
+func loadExecPolicy(file string) (*execPolicy, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exec policy: %w", err)
+	}
+
+	var policy execPolicy
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("invalid exec policy %s: %w", file, err)
+	}
+	for _, pattern := range append(append([]string(nil), policy.Allow...), policy.Deny...) {
+		if _, err := path.Match(normalizeExecPath(pattern), ""); err != nil {
+			return nil, fmt.Errorf("invalid exec policy pattern %q: %w", pattern, err)
+		}
+	}
+	return &policy, nil
+}
+
+// Check returns a command_denied error unless executable may be started.
+// A nil policy allows everything.
+func (p *execPolicy) Check(executable string) error {
+	if p == nil {
+		return nil
+	}
+
+	normalized := normalizeExecPath(executable)
+	if matchesExecPattern(p.Deny, normalized) {
+		return newSidecarError(errorCodeCommandDenied, "%s is denied by the exec policy", executable)
+	}
+	if len(p.Allow) > 0 && !matchesExecPattern(p.Allow, normalized) {
+		return newSidecarError(errorCodeCommandDenied, "%s is not allowed by the exec policy", executable)
+	}
+
+	if p.RequireSigned && !isWindowsDirPath(normalized) {
+		verify := p.verifySignature
+		if verify == nil {
+			verify = verifyExecutableSignature
+		}
+		if err := verify(executable); err != nil {
+			return newSidecarError(errorCodeCommandDenied, "%s has no trusted signature: %v", executable, err)
+		}
+	}
+	return nil
+}
+
+func normalizeExecPath(value string) string {
+	return strings.ToLower(strings.ReplaceAll(value, `\`, "/"))
+}
+
+func matchesExecPattern(patterns []string, normalized string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(normalizeExecPath(pattern), normalized); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func isWindowsDirPath(normalized string) bool {
+	windir := os.Getenv("SystemRoot")
+	if windir == "" {
+		return false
+	}
+	prefix := strings.TrimSuffix(normalizeExecPath(windir), "/") + "/"
+	return strings.HasPrefix(normalized, prefix)
+}
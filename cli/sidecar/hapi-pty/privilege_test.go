@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatePrivilege(t *testing.T) {
+	for _, privilege := range []string{privilegeInherit, privilegeRestricted, privilegeLow} {
+		if err := validatePrivilege(openRequest{Privilege: privilege}); err != nil {
+			t.Fatalf("privilege %q rejected: %v", privilege, err)
+		}
+	}
+	if err := validatePrivilege(openRequest{Privilege: "system"}); err == nil {
+		t.Fatal("expected unsupported privilege error")
+	}
+	if err := validatePrivilege(openRequest{Privilege: privilegeLow, Elevated: true}); err == nil {
+		t.Fatal("expected elevated low-privilege request to be rejected")
+	}
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	valid := []openRequest{
+		{},
+		{MaxMemoryMb: 512},
+		{CPURatePercent: 100},
+		{MaxMemoryMb: 1, CPURatePercent: 1},
+		{Priority: priorityIdle, Affinity: 0x3},
+		{Priority: priorityBelowNormal},
+		{Priority: priorityNormal},
+	}
+	for _, req := range valid {
+		if err := validateResourceLimits(req); err != nil {
+			t.Fatalf("limits %+v rejected: %v", req, err)
+		}
+	}
+
+	invalid := []openRequest{{MaxMemoryMb: -1}, {CPURatePercent: -1}, {CPURatePercent: 101}, {Priority: "high"}, {Priority: "realtime"}}
+	for _, req := range invalid {
+		err := validateResourceLimits(req)
+		if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != errorCodeInvalidRequest {
+			t.Fatalf("limits %+v: expected invalid_request, got %v", req, err)
+		}
+	}
+}
+
+func TestValidateWindowOptions(t *testing.T) {
+	session := uint32(1)
+	valid := []openRequest{
+		{},
+		{NoWindow: true, Backend: backendPipes},
+		{Desktop: `winsta0\default`},
+		{Desktop: "default", SessionID: &session, Backend: backendConPTY},
+	}
+	for _, req := range valid {
+		if err := validateWindowOptions(req); err != nil {
+			t.Fatalf("window options %+v rejected: %v", req, err)
+		}
+	}
+
+	invalid := []openRequest{
+		{Desktop: `a\b\c`},
+		{Desktop: "default\x00"},
+		{Desktop: "default", Backend: backendPipes},
+		{SessionID: &session, Backend: backendMock},
+	}
+	for _, req := range invalid {
+		err := validateWindowOptions(req)
+		if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != errorCodeInvalidRequest {
+			t.Fatalf("window options %+v: expected invalid_request, got %v", req, err)
+		}
+	}
+}
+
+func TestSidecarReportsResourceLimitExitReason(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, MaxMemoryMb: 64})
+	terminal := ts.terminals["t1"]
+	terminal.callbacks.ExitReason(exitReasonResourceLimit)
+	terminal.callbacks.Exit(int(0xC0000044))
+
+	exits := ts.eventsOfType(t, eventTypeExit)
+	if len(exits) != 1 || exits[0]["reason"] != exitReasonResourceLimit {
+		t.Fatalf("expected resource limit exit reason, got %+v", exits)
+	}
+}
+
+func TestSidecarPassesPrivilegeToTerminal(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, Privilege: privilegeLow})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24, Privilege: "root"})
+
+	if got := ts.terminals["t1"].req.Privilege; got != privilegeLow {
+		t.Fatalf("expected low privilege request, got %q", got)
+	}
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["terminalId"] != "t2" || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request for t2, got %+v", errors)
+	}
+}
+
+func TestExecPolicyCheck(t *testing.T) {
+	policy := &execPolicy{
+		Allow: []string{`C:\Program Files\PowerShell\*\pwsh.exe`, "c:/windows/system32/*.exe"},
+		Deny:  []string{`C:\Windows\System32\wsl.exe`},
+	}
+
+	if err := policy.Check(`c:\program files\powershell\7\PWSH.EXE`); err != nil {
+		t.Fatalf("allowed executable rejected: %v", err)
+	}
+	for _, executable := range []string{`C:\Windows\System32\wsl.exe`, `C:\Tools\evil.exe`} {
+		if serr := sidecarErrorFrom(policy.Check(executable), ""); serr.Code != errorCodeCommandDenied {
+			t.Fatalf("expected command_denied for %s, got %+v", executable, serr)
+		}
+	}
+
+	var unrestricted *execPolicy
+	if err := unrestricted.Check(`C:\Tools\anything.exe`); err != nil {
+		t.Fatalf("nil policy should allow everything: %v", err)
+	}
+}
+
+func TestExecPolicyRequireSigned(t *testing.T) {
+	t.Setenv("SystemRoot", `C:\Windows`)
+
+	var verified []string
+	policy := &execPolicy{
+		RequireSigned: true,
+		verifySignature: func(path string) error {
+			verified = append(verified, path)
+			if path == `C:\Tools\unsigned.exe` {
+				return errors.New("no signature")
+			}
+			return nil
+		},
+	}
+
+	if err := policy.Check(`C:\Windows\System32\cmd.exe`); err != nil {
+		t.Fatalf("windows directory binaries should be exempt: %v", err)
+	}
+	if err := policy.Check(`C:\Tools\signed.exe`); err != nil {
+		t.Fatalf("signed executable rejected: %v", err)
+	}
+	if serr := sidecarErrorFrom(policy.Check(`C:\Tools\unsigned.exe`), ""); serr.Code != errorCodeCommandDenied {
+		t.Fatalf("expected command_denied for unsigned executable, got %+v", serr)
+	}
+	if len(verified) != 2 {
+		t.Fatalf("unexpected signature checks: %v", verified)
+	}
+}
+
+func TestLoadExecPolicy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write policy: %v", err)
+		}
+		return path
+	}
+
+	policy, err := loadExecPolicy(write("ok.json", `{"allow":["c:/tools/*.exe"],"requireSigned":true}`))
+	if err != nil || len(policy.Allow) != 1 || !policy.RequireSigned {
+		t.Fatalf("unexpected policy %+v: %v", policy, err)
+	}
+	if _, err := loadExecPolicy(write("typo.json", `{"alow":["*"]}`)); err == nil {
+		t.Fatal("expected unknown field to be rejected")
+	}
+	if _, err := loadExecPolicy(write("pattern.json", `{"deny":["["]}`)); err == nil {
+		t.Fatal("expected malformed pattern to be rejected")
+	}
+}
+
+func TestSidecarOpenRejectsDeniedExecutable(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{ExecPolicy: &execPolicy{Deny: []string{"c:/pwsh.exe"}}})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Shell: "pwsh"})
+
+	errs := ts.eventsOfType(t, eventTypeError)
+	if len(errs) != 1 || errs[0]["code"] != errorCodeCommandDenied || ts.terminals["t1"] != nil {
+		t.Fatalf("expected command_denied without spawning, got %#v", ts.events(t))
+	}
+}
@@ -0,0 +1,232 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	disableMaxPrivilege   = 0x1
+	luaToken              = 0x4
+	tokenIntegrityLevel   = 25
+	seGroupIntegrity      = 0x20
+	mediumIntegritySID    = "S-1-16-8192"
+	lowIntegritySID       = "S-1-16-4096"
+	restrictedTokenRight  = syscall.TOKEN_DUPLICATE | syscall.TOKEN_QUERY | syscall.TOKEN_ASSIGN_PRIMARY | syscall.TOKEN_ADJUST_DEFAULT
+	tokenSessionID        = 12
+	tokenAdjustSessionID  = 0x0100
+	securityImpersonation = 2
+	tokenPrimary          = 1
+	maximumAllowed        = 0x02000000
+
+	wtdUINone            = 2
+	wtdRevokeWholeChain  = 1
+	wtdChoiceFile        = 1
+	wtdStateActionVerify = 1
+	wtdStateActionClose  = 2
+)
+
+var (
+	advapi32Proc = syscall.NewLazyDLL("advapi32.dll")
+
+	procCreateRestrictedToken = advapi32Proc.NewProc("CreateRestrictedToken")
+	procSetTokenInformation   = advapi32Proc.NewProc("SetTokenInformation")
+	procDuplicateTokenEx      = advapi32Proc.NewProc("DuplicateTokenEx")
+
+	procWinVerifyTrust = syscall.NewLazyDLL("wintrust.dll").NewProc("WinVerifyTrust")
+
+	// wintrustActionGenericVerifyV2 is WINTRUST_ACTION_GENERIC_VERIFY_V2.
+	wintrustActionGenericVerifyV2 = syscall.GUID{
+		Data1: 0x00aac56b,
+		Data2: 0xcd44,
+		Data3: 0x11d0,
+		Data4: [8]byte{0x8c, 0xc2, 0x00, 0xc0, 0x4f, 0xc2, 0x95, 0xee},
+	}
+)
+
+type wintrustFileInfo struct {
+	Size         uint32
+	FilePath     *uint16
+	File         syscall.Handle
+	KnownSubject *syscall.GUID
+}
+
+type wintrustData struct {
+	Size               uint32
+	PolicyCallbackData uintptr
+	SIPClientData      uintptr
+	UIChoice           uint32
+	RevocationChecks   uint32
+	UnionChoice        uint32
+	File               *wintrustFileInfo
+	StateAction        uint32
+	StateData          syscall.Handle
+	URLReference       *uint16
+	ProvFlags          uint32
+	UIContext          uint32
+	SignatureSettings  uintptr
+}
+
+type sidAndAttributes struct {
+	Sid        *syscall.SID
+	Attributes uint32
+}
+
+type tokenMandatoryLabel struct {
+	Label sidAndAttributes
+}
+
+// childProcessToken returns the token an open's shell starts with, or zero
+// for the sidecar's own: restricted for Privilege and moved to SessionID.
+func childProcessToken(req openRequest) (syscall.Token, error) {
+	var token syscall.Token
+	var err error
+	if req.Privilege != privilegeInherit {
+		if token, err = restrictedProcessToken(req.Privilege); err != nil {
+			return 0, err
+		}
+	}
+	if req.SessionID == nil {
+		return token, nil
+	}
+
+	if token == 0 {
+		if token, err = duplicateProcessToken(); err != nil {
+			return 0, err
+		}
+	}
+	session := *req.SessionID
+	ret, _, err := procSetTokenInformation.Call(uintptr(token), tokenSessionID, uintptr(unsafe.Pointer(&session)), unsafe.Sizeof(session))
+	if ret == 0 {
+		token.Close()
+		return 0, newSidecarError(errorCodeRestrictionFailed, "failed to move shell to session %d (the sidecar must run as LocalSystem): %v", session, err)
+	}
+	return token, nil
+}
+
+// duplicateProcessToken copies the sidecar's token into a primary token
+// whose session can be changed.
+func duplicateProcessToken() (syscall.Token, error) {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, newSidecarError(errorCodeRestrictionFailed, "failed to open current process: %v", err)
+	}
+	var current syscall.Token
+	if err := syscall.OpenProcessToken(process, restrictedTokenRight|tokenAdjustSessionID, &current); err != nil {
+		return 0, newSidecarError(errorCodeRestrictionFailed, "failed to open process token: %v", err)
+	}
+	defer current.Close()
+
+	var duplicate syscall.Token
+	ret, _, err := procDuplicateTokenEx.Call(
+		uintptr(current),
+		maximumAllowed,
+		0,
+		securityImpersonation,
+		tokenPrimary,
+		uintptr(unsafe.Pointer(&duplicate)),
+	)
+	if ret == 0 {
+		return 0, newSidecarError(errorCodeRestrictionFailed, "DuplicateTokenEx failed: %v", err)
+	}
+	return duplicate, nil
+}
+
+// restrictedProcessToken derives a primary token from the sidecar's own with
+// administrator rights filtered out and the integrity level lowered.
+func restrictedProcessToken(privilege string) (syscall.Token, error) {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0, newSidecarError(errorCodeRestrictionFailed, "failed to open current process: %v", err)
+	}
+
+	var current syscall.Token
+	if err := syscall.OpenProcessToken(process, restrictedTokenRight, &current); err != nil {
+		return 0, newSidecarError(errorCodeRestrictionFailed, "failed to open process token: %v", err)
+	}
+	defer current.Close()
+
+	var restricted syscall.Token
+	ret, _, err := procCreateRestrictedToken.Call(
+		uintptr(current),
+		disableMaxPrivilege|luaToken,
+		0, 0,
+		0, 0,
+		0, 0,
+		uintptr(unsafe.Pointer(&restricted)),
+	)
+	if ret == 0 {
+		return 0, newSidecarError(errorCodeRestrictionFailed, "CreateRestrictedToken failed: %v", err)
+	}
+
+	integrity := mediumIntegritySID
+	if privilege == privilegeLow {
+		integrity = lowIntegritySID
+	}
+	if err := setTokenIntegrity(restricted, integrity); err != nil {
+		restricted.Close()
+		return 0, err
+	}
+
+	return restricted, nil
+}
+
+func setTokenIntegrity(token syscall.Token, sidString string) error {
+	sid, err := syscall.StringToSid(sidString)
+	if err != nil {
+		return newSidecarError(errorCodeRestrictionFailed, "failed to parse integrity SID: %v", err)
+	}
+
+	label := tokenMandatoryLabel{Label: sidAndAttributes{Sid: sid, Attributes: seGroupIntegrity}}
+	ret, _, err := procSetTokenInformation.Call(
+		uintptr(token),
+		tokenIntegrityLevel,
+		uintptr(unsafe.Pointer(&label)),
+		unsafe.Sizeof(label)+uintptr(syscall.GetLengthSid(sid)),
+	)
+	if ret == 0 {
+		return newSidecarError(errorCodeRestrictionFailed, "failed to lower integrity level: %v", err)
+	}
+	return nil
+}
+
+// verifyExecutableSignature checks the file's embedded Authenticode
+// signature, including revocation of the whole chain.
+func verifyExecutableSignature(path string) error {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	file := wintrustFileInfo{FilePath: pathPtr}
+	file.Size = uint32(unsafe.Sizeof(file))
+	data := wintrustData{
+		UIChoice:         wtdUINone,
+		RevocationChecks: wtdRevokeWholeChain,
+		UnionChoice:      wtdChoiceFile,
+		File:             &file,
+		StateAction:      wtdStateActionVerify,
+	}
+	data.Size = uint32(unsafe.Sizeof(data))
+
+	ret, _, _ := procWinVerifyTrust.Call(
+		uintptr(syscall.InvalidHandle),
+		uintptr(unsafe.Pointer(&wintrustActionGenericVerifyV2)),
+		uintptr(unsafe.Pointer(&data)),
+	)
+
+	data.StateAction = wtdStateActionClose
+	_, _, _ = procWinVerifyTrust.Call(
+		uintptr(syscall.InvalidHandle),
+		uintptr(unsafe.Pointer(&wintrustActionGenericVerifyV2)),
+		uintptr(unsafe.Pointer(&data)),
+	)
+
+	if status := int32(ret); status != 0 {
+		return fmt.Errorf("WinVerifyTrust returned 0x%08x", uint32(status))
+	}
+	return nil
+}
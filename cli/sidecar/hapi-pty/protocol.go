@@ -4,11 +4,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
+	"strings"
+	"sync"
 )
 
 const (
-	sidecarVersion  = "1.0.0"
-	protocolVersion = 1
+	sidecarVersion = "1.0.0"
+	// protocolVersion is what hello announces and what a host speaks until
+	// its hello request picks another version, see negotiateProtocol.
+	// Versions 1 and 2 share one wire format: version 2 only adds the
+	// guarantee that all of a terminal's output events precede its exit
+	// event, which a version 1 host does not rely on. Version 3 sends
+	// output as UTF-8 unless an open asks for base64 or compression, and an
+	// output event omits its encoding only when it is utf8, see
+	// protocolShim.
+	protocolVersion    = 2
+	minProtocolVersion = 1
+	maxProtocolVersion = 3
 )
 
 const (
@@ -18,60 +31,393 @@ const (
 	requestTypeClose    = "close"
 	requestTypePing     = "ping"
 	requestTypeShutdown = "shutdown"
+	requestTypeSnapshot = "snapshot"
+	requestTypeWait     = "wait"
+	requestTypeSearch   = "search"
+	requestTypeExport   = "export"
+	requestTypeStats    = "stats"
+	requestTypeAuth     = "auth"
+	// requestTypeKillProcess terminates one process in a terminal's tree.
+	requestTypeKillProcess = "kill-process"
+	// requestTypeEOF ends the input of the program reading the terminal
+	// without closing the terminal.
+	requestTypeEOF = "eof"
+	// requestTypeBroadcast writes the same input to several terminals.
+	requestTypeBroadcast = "broadcast"
+	requestTypeList      = "list"
+	requestTypeCloseAll  = "close-all"
+	// requestTypeListShells reports the shells this machine can open.
+	requestTypeListShells = "list-shells"
+	// requestTypeProbe probes ConPTY again after a failure at startup.
+	requestTypeProbe = "probe"
+	// requestTypeTmuxPanes lists the panes of a tmux session, which opens
+	// with backend "tmux" adopt by ID.
+	requestTypeTmuxPanes = "tmux-panes"
+	// requestTypeGetSize reports a terminal's applied size.
+	requestTypeGetSize = "get-size"
+	// requestTypeFocus tells a terminal the host gained or lost focus.
+	requestTypeFocus = "focus"
+	// requestTypeUpgrade hands a daemon's terminals to a new binary.
+	requestTypeUpgrade = "upgrade"
+	// requestTypeHello identifies the client and negotiates the optional
+	// protocol features it wants.
+	requestTypeHello = "hello"
+	// requestTypeSubscribe and requestTypeUnsubscribe turn event categories
+	// back on and off, see eventSubscriptions.
+	requestTypeSubscribe   = "subscribe"
+	requestTypeUnsubscribe = "unsubscribe"
 )
 
 const (
-	eventTypeHello       = "hello"
-	eventTypeReady       = "ready"
-	eventTypeOutput      = "output"
-	eventTypeExit        = "exit"
-	eventTypeError       = "error"
-	eventTypePong        = "pong"
-	eventTypeShutdownAck = "shutdown_ack"
+	eventTypeHello         = "hello"
+	eventTypeReady         = "ready"
+	eventTypeOutput        = "output"
+	eventTypeExit          = "exit"
+	eventTypeError         = "error"
+	eventTypePong          = "pong"
+	eventTypeShutdownAck   = "shutdown_ack"
+	eventTypeClipboard     = "clipboard"
+	eventTypeProgress      = "progress"
+	eventTypeSnapshot      = "snapshot"
+	eventTypeMatched       = "matched"
+	eventTypeSearchResults = "search_results"
+	eventTypeExport        = "export"
+	eventTypeBackpressure  = "backpressure"
+	eventTypeClosed        = "closed"
+	eventTypeWillClose     = "will_close"
+	eventTypeStats         = "stats"
+	eventTypeHeartbeat     = "heartbeat"
+	eventTypeList          = "list"
+	eventTypeClosedAll     = "closed_all"
+	eventTypeShells        = "shells"
+	eventTypeProbe         = "probe"
+	eventTypeTmuxPanes     = "tmux_panes"
+	eventTypeSize          = "size"
+	eventTypeScreenMode    = "screen_mode"
+	eventTypeUpgrade       = "upgrade"
+	eventTypeHelloAck      = "hello_ack"
+	eventTypeSubscriptions = "subscriptions"
 )
 
 const (
-	errorCodeConPTYUnavailable = "conpty_unavailable"
-	errorCodeShellNotFound     = "shell_not_found"
-	errorCodeSpawnFailed       = "spawn_failed"
-	errorCodeStartupFailed     = "startup_failed"
-	errorCodeTerminalNotFound  = "terminal_not_found"
-	errorCodeUnknown           = "unknown"
+	errorCodeCheckpointFailed    = "checkpoint_failed"
+	errorCodeCommandDenied       = "command_denied"
+	errorCodeConPTYUnavailable   = "conpty_unavailable"
+	errorCodeCwdNotFound         = "cwd_not_found"
+	errorCodeCwdUnsupported      = "cwd_unsupported"
+	errorCodeElevationDeclined   = "elevation_declined"
+	errorCodeEnvTooLarge         = "env_too_large"
+	errorCodeExportFailed        = "export_failed"
+	errorCodeInvalidRequest      = "invalid_request"
+	errorCodeKillFailed          = "kill_failed"
+	errorCodePingTimeout         = "ping_timeout"
+	errorCodeProcessNotFound     = "process_not_found"
+	errorCodeRequestTooLarge     = "request_too_large"
+	errorCodeRestrictionFailed   = "restriction_failed"
+	errorCodeShellNotFound       = "shell_not_found"
+	errorCodeSpawnFailed         = "spawn_failed"
+	errorCodeStartupFailed       = "startup_failed"
+	errorCodeStartupTimeout      = "startup_timeout"
+	errorCodeTerminalLimit       = "terminal_limit_reached"
+	errorCodeTerminalIDTooLong   = "terminal_id_too_long"
+	errorCodeTerminalNotFound    = "terminal_not_found"
+	errorCodeUnauthorized        = "unauthorized"
+	errorCodeUnknown             = "unknown"
+	errorCodeUpgradeFailed       = "upgrade_failed"
+	errorCodeUnsupportedProtocol = "unsupported_protocol"
+	errorCodeWaitCancelled       = "wait_cancelled"
+	errorCodeWaitTimeout         = "wait_timeout"
+	errorCodeWriteBacklogged     = "write_backlogged"
+	errorCodeWriteTooLarge       = "write_too_large"
 )
 
 type request interface {
 	requestType() string
 }
 
+// Limits enforced by decodeRequestLine on top of the request size limit, so
+// raising --max-request-bytes cannot turn one field into an unbounded blob.
+const (
+	maxTerminalIDBytes = 256
+	maxEnvEntries      = 4096
+	// maxEnvEntryBytes is the Windows limit for one NAME=value entry.
+	maxEnvEntryBytes  = 32767
+	maxWriteDataBytes = defaultMaxRequestBytes
+	maxTags           = 64
+	maxTagBytes       = 256
+	// maxExtraArgBytes keeps extraArgs well inside the 32767 character
+	// Windows command line.
+	maxExtraArgs     = 64
+	maxExtraArgBytes = 16384
+)
+
 type requestEnvelope struct {
-	Type string `json:"type"`
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
 }
 
 type openRequest struct {
-	Type       string            `json:"type"`
-	TerminalID string            `json:"terminalId"`
+	Type string `json:"type"`
+	// TerminalID may be omitted to have the sidecar assign a ULID, which the
+	// ready event returns along with RequestID.
+	TerminalID string            `json:"terminalId,omitempty"`
+	RequestID  string            `json:"requestId,omitempty"`
 	Cwd        string            `json:"cwd"`
 	Shell      string            `json:"shell,omitempty"`
 	Cols       int               `json:"cols"`
 	Rows       int               `json:"rows"`
 	Env        map[string]string `json:"env,omitempty"`
+	// EnvProfiles name --env-profiles entries merged, in order, under Env.
+	// Env values may reference the inherited environment as ${VAR}.
+	EnvProfiles []string `json:"envProfiles,omitempty"`
+	// PathPrepend and PathAppend add directories around the resulting PATH
+	// without the host having to read it; see editPath.
+	PathPrepend []string `json:"pathPrepend,omitempty"`
+	PathAppend  []string `json:"pathAppend,omitempty"`
+	// Compression selects how output payloads are compressed, see outputEncoder.
+	Compression string `json:"compression,omitempty"`
+	// OutputEncoding is "base64" (default) or "utf8" for plain string payloads.
+	OutputEncoding string `json:"outputEncoding,omitempty"`
+	// OutputFilter is "raw" (default), "strip-ansi" or "normalize-crlf", see
+	// outputFilter. Only output events are filtered; snapshots, searches and
+	// waits still see the terminal stream.
+	OutputFilter string `json:"outputFilter,omitempty"`
+	// Timestamps stamps every output event, see outputTimestamp.
+	Timestamps bool `json:"timestamps,omitempty"`
+	// ForceUTF8 switches the console to UTF-8 as the shell starts, see
+	// forceUTF8, and replaces invalid UTF-8 in output.
+	ForceUTF8 bool `json:"forceUtf8,omitempty"`
+	// OutputCodePage transcodes output from that code page to UTF-8, for
+	// shells that keep writing in the OEM code page regardless.
+	OutputCodePage int `json:"outputCodePage,omitempty"`
+	// SuppressClipboard removes OSC 52 sequences from output once surfaced.
+	SuppressClipboard bool `json:"suppressClipboard,omitempty"`
+	// Emulate keeps a headless screen model for snapshot requests.
+	Emulate         bool `json:"emulate,omitempty"`
+	ScrollbackLines int  `json:"scrollbackLines,omitempty"`
+	// Checkpoint saves an emulated terminal's retained output to
+	// --checkpoint-dir when it closes and restores it into the scrollback
+	// when the terminalId is opened again, see scrollbackCheckpoint.
+	Checkpoint bool `json:"checkpoint,omitempty"`
+	// ReadBufferBytes pins the output read size; zero sizes reads adaptively.
+	ReadBufferBytes int `json:"readBufferBytes,omitempty"`
+	// Backpressure selects the slow-host policy, see outputPump.
+	Backpressure            string `json:"backpressure,omitempty"`
+	BackpressureBufferBytes int    `json:"backpressureBufferBytes,omitempty"`
+	// IdleTimeoutMs closes the terminal after that long without input or
+	// output, IdleWarningMs after announcing it with a will_close event.
+	IdleTimeoutMs int `json:"idleTimeoutMs,omitempty"`
+	IdleWarningMs int `json:"idleWarningMs,omitempty"`
+	// InheritEnv false starts from a minimal base environment instead of
+	// the sidecar's own before Env is applied.
+	InheritEnv *bool `json:"inheritEnv,omitempty"`
+	// CwdFallback opens in the home directory when Cwd does not exist.
+	CwdFallback bool `json:"cwdFallback,omitempty"`
+	// UNCCwd "pushd" lets cmd shells open in a UNC Cwd, which cmd refuses
+	// as its current directory, by mapping it to a drive letter with pushd;
+	// otherwise such an open fails with cwd_unsupported. See shellCwd.
+	UNCCwd string `json:"uncCwd,omitempty"`
+	// StartupTimeoutMs fails the terminal with startup_timeout when the
+	// shell produces no output within that window.
+	StartupTimeoutMs int `json:"startupTimeoutMs,omitempty"`
+	// InitialCommand is typed, followed by Enter, once the shell produced
+	// its first output and InitialCommandDelayMs more elapsed.
+	InitialCommand        string `json:"initialCommand,omitempty"`
+	InitialCommandDelayMs int    `json:"initialCommandDelayMs,omitempty"`
+	// Privilege "restricted" or "low" starts the shell with a restricted
+	// token even when the sidecar runs elevated.
+	Privilege string `json:"privilege,omitempty"`
+	// Elevated runs the shell as administrator, prompting through UAC when
+	// the sidecar itself is not elevated.
+	Elevated bool `json:"elevated,omitempty"`
+	// NoWindow keeps the shell from flashing a window on server hosts: the
+	// pipes backend starts it with CREATE_NO_WINDOW instead of a hidden
+	// console, and ConPTY shells start with their first window hidden.
+	NoWindow bool `json:"noWindow,omitempty"`
+	// Desktop is the window station and desktop, such as
+	// "winsta0\default", that windows the shell opens appear on.
+	// SessionID starts the shell in that Windows session, which needs a
+	// sidecar running as LocalSystem. Both are ConPTY only.
+	Desktop   string  `json:"desktop,omitempty"`
+	SessionID *uint32 `json:"sessionId,omitempty"`
+	// Backend is "conpty" (default), "winpty" where ConPTY is missing and
+	// winpty took its place, "pipes" for plain stdin and stdout pipes
+	// without a console, "mock" for the scripted terminal enabled by
+	// --mock-backend, which Mock scripts, "tmux" for a pane of a running
+	// tmux session, which Tmux names, "containerd" for a process exec'd
+	// into a containerd container, which Containerd names, "serial" for a
+	// VM's serial console on a named pipe, which Serial names, or "telnet"
+	// for a device's telnet console, which Telnet names.
+	Backend    string             `json:"backend,omitempty"`
+	Mock       *mockOptions       `json:"mock,omitempty"`
+	Tmux       *tmuxOptions       `json:"tmux,omitempty"`
+	Containerd *containerdOptions `json:"containerd,omitempty"`
+	Serial     *serialOptions     `json:"serial,omitempty"`
+	Telnet     *telnetOptions     `json:"telnet,omitempty"`
+	// Python activates a virtualenv or conda environment in the shell, see
+	// activatePython.
+	Python *pythonOptions `json:"python,omitempty"`
+	// VisualStudio selects the installation, toolset and architecture for
+	// the vsdevcmd and vsdevshell shells.
+	VisualStudio *visualStudioOptions `json:"visualStudio,omitempty"`
+	// MaxMemoryMb and CPURatePercent limit the shell's whole process tree
+	// through a job object; exceeding the memory limit kills the tree with
+	// exit reason resource_limit_exceeded.
+	MaxMemoryMb    int `json:"maxMemoryMb,omitempty"`
+	CPURatePercent int `json:"cpuRatePercent,omitempty"`
+	// Priority (idle, below-normal or normal) and Affinity, a processor
+	// bit mask, are likewise applied to the whole tree.
+	Priority string `json:"priority,omitempty"`
+	Affinity uint64 `json:"affinity,omitempty"`
+	// InheritCursor starts the shell at the host's cursor position instead
+	// of a cleared screen, avoiding a repaint flash on attach. ConPTY asks
+	// for the position with a DSR query the host must answer with
+	// "\x1b[row;colR" before the shell draws anything.
+	InheritCursor bool `json:"inheritCursor,omitempty"`
+	// ConPTYFlags are passed to CreatePseudoConsole as they are, for flags
+	// this sidecar does not name.
+	ConPTYFlags uint32 `json:"conptyFlags,omitempty"`
+	// Traceparent is a W3C trace context that parents the request's span
+	// when OTLP tracing is configured; write, resize and close accept it too.
+	Traceparent string `json:"traceparent,omitempty"`
+	// Tags label the terminal for list filters and selectors, see
+	// matchTags.
+	Tags map[string]string `json:"tags,omitempty"`
+	// ExtraArgs are appended to the shell's arguments; NoProfile skips its
+	// profile or AutoRun scripts and ExecutionPolicy, for PowerShell only,
+	// sets -ExecutionPolicy. See shellLaunch.
+	ExtraArgs       []string `json:"extraArgs,omitempty"`
+	NoProfile       bool     `json:"noProfile,omitempty"`
+	ExecutionPolicy string   `json:"executionPolicy,omitempty"`
+	// LoginEnv merges the login environment of the terminal's bash, or of
+	// Git Bash for other shells, so tools .profile and .bashrc put on PATH
+	// are found; see loginEnvCache.
+	LoginEnv bool `json:"loginEnv,omitempty"`
+	// AttachIfExists answers an open for a running terminalId with a ready
+	// event marked attached, followed by a snapshot with scrollback when the
+	// terminal is emulated, instead of failing.
+	AttachIfExists bool `json:"attachIfExists,omitempty"`
+	// ResumeFromSeq, on an attach, replays the terminal's stream events
+	// from this seq on instead of the snapshot, when they are all still
+	// kept; see eventLog.
+	ResumeFromSeq uint64 `json:"resumeFromSeq,omitempty"`
+
+	// environ is the child environment resolved by the sidecar.
+	environ []string
+	// span is the open span, which parents the terminal's session span.
+	span *otelSpan
+	// adopted, set when a new binary takes the terminal over from an
+	// upgrading daemon, replaces starting a shell.
+	adopted *adoptedTerminal
 }
 
 func (r openRequest) requestType() string { return r.Type }
 
+// pythonOptions names the Python environment to activate: Venv is a
+// virtualenv directory, relative to the cwd unless absolute, and Conda an
+// environment name. At most one may be set.
+type pythonOptions struct {
+	Venv  string `json:"venv,omitempty"`
+	Conda string `json:"conda,omitempty"`
+}
+
+// mockOptions scripts a mock terminal. Input is echoed back; each entered
+// line is answered with its Replies entry and the prompt, and "exit [CODE]"
+// ends the terminal.
+type mockOptions struct {
+	// Output is written step by step when the terminal starts.
+	Output  []mockStep            `json:"output,omitempty"`
+	Prompt  string                `json:"prompt,omitempty"`
+	Replies map[string][]mockStep `json:"replies,omitempty"`
+	NoEcho  bool                  `json:"noEcho,omitempty"`
+}
+
+// telnetOptions names the console a telnet terminal connects to.
+type telnetOptions struct {
+	Host string `json:"host"`
+	// Port is 23 when zero.
+	Port int `json:"port,omitempty"`
+	// NAWS, true by default, offers the server the terminal's size and
+	// sends it again on every resize. Some devices misbehave when offered
+	// options, so false leaves it out.
+	NAWS *bool `json:"naws,omitempty"`
+	// TerminalType answers the server's TERMINAL-TYPE question when set,
+	// e.g. "xterm-256color"; the option is refused otherwise.
+	TerminalType string `json:"terminalType,omitempty"`
+}
+
+// serialOptions names the console a serial terminal connects to.
+type serialOptions struct {
+	// Pipe is the named pipe a Hyper-V COM port (Set-VMComPort -Path) or
+	// another console serves, e.g. `\\.\pipe\vm-com1`; a bare name gets
+	// the `\\.\pipe\` prefix.
+	Pipe string `json:"pipe"`
+	// Reconnect, true by default, keeps the terminal open while the pipe
+	// goes away, as it does while the VM reboots, and reconnects once it
+	// is back. Otherwise the terminal exits when the pipe closes.
+	Reconnect *bool `json:"reconnect,omitempty"`
+}
+
+// containerdOptions names the container a containerd terminal execs into.
+// The sidecar does not link the containerd client: it runs "ctr tasks exec"
+// under a console, so ctr must be on PATH, or the open fails with
+// shell_not_found.
+type containerdOptions struct {
+	// Namespace is containerd's namespace, "default" when empty.
+	Namespace string `json:"namespace,omitempty"`
+	// Container names the container whose running task the process joins.
+	Container string `json:"container"`
+	// ExecID identifies the process started in the container's task;
+	// "hapi-" and the terminal ID by default.
+	ExecID string `json:"execId,omitempty"`
+	// Command runs in the container, "sh" when empty.
+	Command []string `json:"command,omitempty"`
+	Cwd     string   `json:"cwd,omitempty"`
+	User    string   `json:"user,omitempty"`
+}
+
+// tmuxOptions names the pane a tmux terminal adopts, see tmuxSession.
+type tmuxOptions struct {
+	Session string `json:"session"`
+	// Pane is a pane ID such as "%3"; the session's active pane by default.
+	Pane string `json:"pane,omitempty"`
+	// Socket is the tmux server's socket name, as with tmux -L.
+	Socket string `json:"socket,omitempty"`
+	// SSH runs tmux on this destination, e.g. "user@host", over ssh.
+	SSH string `json:"ssh,omitempty"`
+}
+
+type mockStep struct {
+	Data    string `json:"data"`
+	DelayMs int    `json:"delayMs,omitempty"`
+}
+
 type writeRequest struct {
 	Type       string `json:"type"`
 	TerminalID string `json:"terminalId"`
 	Data       string `json:"data"`
+	// Encoding is "text" (default) or "base64" for exact byte sequences.
+	Encoding string `json:"encoding,omitempty"`
+	// Keys are symbolic key names sent after Data, see encodeKeys.
+	Keys []string `json:"keys,omitempty"`
+	// Sanitize strips control characters from Data, see sanitizePaste.
+	Sanitize bool `json:"sanitize,omitempty"`
+	// More marks a piece of a multi-part write; the payload is written once a
+	// piece without More arrives.
+	More        bool   `json:"more,omitempty"`
+	Traceparent string `json:"traceparent,omitempty"`
 }
 
 func (r writeRequest) requestType() string { return r.Type }
 
+// resizeRequest is answered with a size event only when RequestID is set.
 type resizeRequest struct {
-	Type       string `json:"type"`
-	TerminalID string `json:"terminalId"`
-	Cols       int    `json:"cols"`
-	Rows       int    `json:"rows"`
+	Type        string `json:"type"`
+	TerminalID  string `json:"terminalId"`
+	RequestID   string `json:"requestId,omitempty"`
+	Cols        int    `json:"cols"`
+	Rows        int    `json:"rows"`
+	Traceparent string `json:"traceparent,omitempty"`
 }
 
 func (r resizeRequest) requestType() string { return r.Type }
@@ -79,18 +425,257 @@ func (r resizeRequest) requestType() string { return r.Type }
 type closeRequest struct {
 	Type       string `json:"type"`
 	TerminalID string `json:"terminalId"`
+	// Force terminates immediately, as a close without GraceMs does; GraceMs
+	// hangs up first and escalates to termination once it elapses. Every
+	// close reports the path it took in a closed event.
+	Force       bool   `json:"force,omitempty"`
+	GraceMs     int    `json:"graceMs,omitempty"`
+	Traceparent string `json:"traceparent,omitempty"`
 }
 
 func (r closeRequest) requestType() string { return r.Type }
 
+// closeAllRequest closes every terminal matching Selector and Shell, or all
+// of them with All. Force and GraceMs behave as in closeRequest.
+type closeAllRequest struct {
+	Type      string            `json:"type"`
+	RequestID string            `json:"requestId,omitempty"`
+	All       bool              `json:"all,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	// Shell matches the resolved shell name, such as "pwsh".
+	Shell   string `json:"shell,omitempty"`
+	Force   bool   `json:"force,omitempty"`
+	GraceMs int    `json:"graceMs,omitempty"`
+}
+
+func (r closeAllRequest) requestType() string { return r.Type }
+
+type snapshotRequest struct {
+	Type              string `json:"type"`
+	TerminalID        string `json:"terminalId"`
+	IncludeScrollback bool   `json:"includeScrollback,omitempty"`
+}
+
+func (r snapshotRequest) requestType() string { return r.Type }
+
+type waitRequest struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
+	Pattern    string `json:"pattern"`
+	Regex      bool   `json:"regex,omitempty"`
+	IgnoreCase bool   `json:"ignoreCase,omitempty"`
+	TimeoutMs  int    `json:"timeoutMs,omitempty"`
+}
+
+func (r waitRequest) requestType() string { return r.Type }
+
+type searchRequest struct {
+	Type         string `json:"type"`
+	TerminalID   string `json:"terminalId"`
+	RequestID    string `json:"requestId,omitempty"`
+	Pattern      string `json:"pattern"`
+	Regex        bool   `json:"regex,omitempty"`
+	IgnoreCase   bool   `json:"ignoreCase,omitempty"`
+	MaxResults   int    `json:"maxResults,omitempty"`
+	ContextLines int    `json:"contextLines,omitempty"`
+}
+
+func (r searchRequest) requestType() string { return r.Type }
+
+type exportRequest struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
+	// Format is "text" (default) or "html".
+	Format string `json:"format,omitempty"`
+	// Path writes the export to a file instead of returning it inline.
+	Path string `json:"path,omitempty"`
+	// Redact masks credentials matched by the built-in patterns and by
+	// RedactPatterns, see secretRedactor.
+	Redact         bool     `json:"redact,omitempty"`
+	RedactPatterns []string `json:"redactPatterns,omitempty"`
+}
+
+func (r exportRequest) requestType() string { return r.Type }
+
 type pingRequest struct {
 	Type string `json:"type"`
+	// Nonce and Timestamp are echoed in the pong so the client can time the
+	// round trip; RTTMs reports the client's previous measurement.
+	Nonce     string  `json:"nonce,omitempty"`
+	Timestamp int64   `json:"timestamp,omitempty"`
+	RTTMs     float64 `json:"rttMs,omitempty"`
 }
 
 func (r pingRequest) requestType() string { return r.Type }
 
+// authRequest is the handshake a network client must send as its first
+// line; it is never dispatched to handleRequest.
+type authRequest struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
+	// ClientID names a --multi-client client, which gets a generated one
+	// otherwise. A client reconnecting under the same ID finds the
+	// terminals of its namespace again.
+	ClientID string `json:"clientId,omitempty"`
+}
+
+// broadcastRequest writes one input to every listed terminal. When any of
+// them is unknown nothing is written. Data, Encoding, Keys and Sanitize
+// behave as in writeRequest.
+type broadcastRequest struct {
+	Type        string   `json:"type"`
+	RequestID   string   `json:"requestId,omitempty"`
+	TerminalIDs []string `json:"terminalIds,omitempty"`
+	// Selector adds every terminal whose tags match it to TerminalIDs.
+	Selector map[string]string `json:"selector,omitempty"`
+	Data     string            `json:"data"`
+	Encoding string            `json:"encoding,omitempty"`
+	Keys     []string          `json:"keys,omitempty"`
+	Sanitize bool              `json:"sanitize,omitempty"`
+}
+
+func (r broadcastRequest) requestType() string { return r.Type }
+
+// listRequest asks for the open terminals whose tags match Selector.
+type listRequest struct {
+	Type      string            `json:"type"`
+	RequestID string            `json:"requestId,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+}
+
+func (r listRequest) requestType() string { return r.Type }
+
+// listShellsRequest asks which shells can be opened and which one an open
+// without a shell gets.
+type listShellsRequest struct {
+	Type      string `json:"type"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+func (r listShellsRequest) requestType() string { return r.Type }
+
+// probeRequest probes ConPTY, and winpty while ConPTY is missing, again.
+type probeRequest struct {
+	Type      string `json:"type"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+func (r probeRequest) requestType() string { return r.Type }
+
+// tmuxPanesRequest asks for the panes of the tmux session Tmux names, on
+// its socket or ssh destination; Tmux.Pane is ignored.
+type tmuxPanesRequest struct {
+	Type      string       `json:"type"`
+	RequestID string       `json:"requestId,omitempty"`
+	Tmux      *tmuxOptions `json:"tmux"`
+}
+
+func (r tmuxPanesRequest) requestType() string { return r.Type }
+
+// focusRequest reports the host's focus. It is forwarded as CSI I or CSI O
+// only while the program has enabled focus reporting (DECSET 1004) and is
+// dropped otherwise, since programs that did not ask would read it as keys.
+type focusRequest struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	Focused    bool   `json:"focused"`
+}
+
+func (r focusRequest) requestType() string { return r.Type }
+
+type getSizeRequest struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
+}
+
+func (r getSizeRequest) requestType() string { return r.Type }
+
+// upgradeRequest replaces a daemon with the binary now installed at its
+// own path; clients cannot name another, as that would run a program the
+// exec policy never checked. The new process takes over the daemon's pipe,
+// its terminals and this connection, then reports upgrade; the shells keep
+// running throughout. Requests sent before that event may be lost.
+type upgradeRequest struct {
+	Type      string `json:"type"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+func (r upgradeRequest) requestType() string { return r.Type }
+
+// clientHelloRequest introduces the client. Protocols lists the protocol
+// versions it speaks and Features the optional protocol features it wants;
+// the hello_ack reports the version and the features it got.
+type clientHelloRequest struct {
+	Type      string   `json:"type"`
+	RequestID string   `json:"requestId,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Version   string   `json:"version,omitempty"`
+	Protocols []int    `json:"protocols,omitempty"`
+	Features  []string `json:"features,omitempty"`
+}
+
+func (r clientHelloRequest) requestType() string { return r.Type }
+
+// subscriptionChange names the event categories a subscribe or unsubscribe
+// request turns on or off, all of them when Categories is empty, for the
+// terminals in TerminalIDs or, when it is empty, for every terminal,
+// including later ones.
+type subscriptionChange struct {
+	RequestID   string   `json:"requestId,omitempty"`
+	Categories  []string `json:"categories,omitempty"`
+	TerminalIDs []string `json:"terminalIds,omitempty"`
+}
+
+type subscribeRequest struct {
+	Type string `json:"type"`
+	subscriptionChange
+}
+
+func (r subscribeRequest) requestType() string { return r.Type }
+
+type unsubscribeRequest struct {
+	Type string `json:"type"`
+	subscriptionChange
+}
+
+func (r unsubscribeRequest) requestType() string { return r.Type }
+
+// eofRequest sends an end-of-input sequence after any queued writes, see
+// eofSequence.
+type eofRequest struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	// Sequence is "ctrl-z" (default), which Windows console programs read as
+	// EOF once Enter follows, or "ctrl-d" for Unix-style programs.
+	Sequence string `json:"sequence,omitempty"`
+}
+
+func (r eofRequest) requestType() string { return r.Type }
+
+// killProcessRequest terminates PID, which must descend from the terminal's
+// shell. The shell itself is only stopped through close.
+type killProcessRequest struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
+	PID        int    `json:"pid"`
+}
+
+func (r killProcessRequest) requestType() string { return r.Type }
+
+type statsRequest struct {
+	Type string `json:"type"`
+}
+
+func (r statsRequest) requestType() string { return r.Type }
+
 type shutdownRequest struct {
 	Type string `json:"type"`
+	// GraceMs lets shells exit on their own before they are terminated.
+	GraceMs int `json:"graceMs,omitempty"`
 }
 
 func (r shutdownRequest) requestType() string { return r.Type }
@@ -99,39 +684,773 @@ type helloEvent struct {
 	Type     string `json:"type"`
 	Version  string `json:"version"`
 	Protocol int    `json:"protocol"`
+	// Protocols are the versions a hello request may pick from.
+	Protocols    []int             `json:"protocols"`
+	Capabilities helloCapabilities `json:"capabilities"`
+	// PingIntervalMs is set when the client must ping at that interval to
+	// keep the sidecar alive, see --ping-interval.
+	PingIntervalMs int64 `json:"pingIntervalMs,omitempty"`
+	// HeartbeatIntervalMs is set when heartbeat events are enabled.
+	HeartbeatIntervalMs int64 `json:"heartbeatIntervalMs,omitempty"`
+	// ReplayEvents is how many of each terminal's stream events are kept
+	// for attaches with resumeFromSeq, see --replay-events.
+	ReplayEvents int `json:"replayEvents,omitempty"`
+	// Platform describes Windows and its ConPTY; omitted elsewhere.
+	Platform *platformInfo `json:"platform,omitempty"`
+}
+
+// platformInfo lets hosts account for ConPTY differences between Windows
+// builds and between kernel32's ConPTY and the newer conpty.dll that
+// Windows Terminal ships.
+type platformInfo struct {
+	// OSVersion is the real Windows version, such as "10.0.22631", and
+	// OSBuild its build number; ConPTY needs 17763 (1809).
+	OSVersion string `json:"osVersion"`
+	OSBuild   int    `json:"osBuild"`
+	// ConPTY reports whether CreatePseudoConsole is available and
+	// ConPTYSource whether it comes from "kernel32" or "conpty.dll".
+	ConPTY       bool   `json:"conpty"`
+	ConPTYSource string `json:"conptySource,omitempty"`
+	// ConPTYDLL is the conpty.dll found beside the sidecar, which is
+	// preferred over kernel32's.
+	ConPTYDLL string `json:"conptyDll,omitempty"`
+}
+
+type helloCapabilities struct {
+	Encodings       []string `json:"encodings"`
+	Compression     []string `json:"compression"`
+	OutputEncodings []string `json:"outputEncodings"`
+	OutputFilters   []string `json:"outputFilters"`
+	Backends        []string `json:"backends"`
+	// Features are what a hello request may ask for.
+	Features []string `json:"features"`
+	// EventCategories are what subscribe and unsubscribe accept.
+	EventCategories []string `json:"eventCategories"`
+}
+
+// subscriptionsEvent answers subscribe and unsubscribe with the client's
+// subscriptions.
+type subscriptionsEvent struct {
+	Type       string                 `json:"type"`
+	RequestID  string                 `json:"requestId,omitempty"`
+	Categories []categorySubscription `json:"categories"`
+}
+
+// categorySubscription reports whether a category is on, apart from the
+// terminals in Except, for which it is the other way round.
+type categorySubscription struct {
+	Category   string   `json:"category"`
+	Subscribed bool     `json:"subscribed"`
+	Except     []string `json:"except,omitempty"`
+}
+
+// helloAckEvent answers a hello request with the features the client got.
+type helloAckEvent struct {
+	Type      string   `json:"type"`
+	RequestID string   `json:"requestId,omitempty"`
+	Protocol  int      `json:"protocol"`
+	Features  []string `json:"features"`
+}
+
+// Optional protocol features. A client that never sends a hello request
+// gets all of them, as before the request existed; one that does gets only
+// those it names.
+const (
+	clientFeatureHeartbeat  = "heartbeat"
+	clientFeatureProgress   = "progress"
+	clientFeatureClipboard  = "clipboard"
+	clientFeatureScreenMode = "screenMode"
+	// clientFeatureTimestamps stamps the output of every terminal the client
+	// opens, as if each open set timestamps.
+	clientFeatureTimestamps = "timestamps"
+
+	maxClientNameBytes = 128
+)
+
+var supportedClientFeatures = []string{
+	clientFeatureHeartbeat,
+	clientFeatureProgress,
+	clientFeatureClipboard,
+	clientFeatureScreenMode,
+	clientFeatureTimestamps,
+}
+
+// featureEvents maps each event a client must ask for to its feature.
+var featureEvents = map[string]string{
+	eventTypeHeartbeat:  clientFeatureHeartbeat,
+	eventTypeProgress:   clientFeatureProgress,
+	eventTypeClipboard:  clientFeatureClipboard,
+	eventTypeScreenMode: clientFeatureScreenMode,
+}
+
+// clientInfo is what a client said in its hello request. ID is set only
+// under --multi-client.
+type clientInfo struct {
+	ID       string   `json:"id,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	Protocol int      `json:"protocol"`
+	Features []string `json:"features"`
+}
+
+func supportedProtocols() []int {
+	versions := make([]int, 0, maxProtocolVersion-minProtocolVersion+1)
+	for version := minProtocolVersion; version <= maxProtocolVersion; version++ {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// negotiateProtocol picks the newest version both sides speak, or
+// protocolVersion when the client named none.
+func negotiateProtocol(requested []int) (int, error) {
+	if len(requested) == 0 {
+		return protocolVersion, nil
+	}
+	picked := 0
+	for _, version := range requested {
+		if version >= minProtocolVersion && version <= maxProtocolVersion && version > picked {
+			picked = version
+		}
+	}
+	if picked == 0 {
+		return 0, newSidecarError(errorCodeUnsupportedProtocol, "none of protocols %v is supported; this sidecar speaks %d to %d", requested, minProtocolVersion, maxProtocolVersion)
+	}
+	return picked, nil
+}
+
+// protocol is the version the client speaks.
+func (c *clientInfo) protocol() int {
+	if c == nil {
+		return protocolVersion
+	}
+	return c.Protocol
+}
+
+// openDefaults fills in what the client's protocol version and features
+// imply for an open that leaves it unset.
+func (c *clientInfo) openDefaults(req openRequest) openRequest {
+	if c.protocol() >= 3 && req.OutputEncoding == "" && req.Compression == "" {
+		req.OutputEncoding = outputEncodingUTF8
+	}
+	if c != nil && c.has(clientFeatureTimestamps) {
+		req.Timestamps = true
+	}
+	return req
+}
+
+// protocolShim rewrites payload, encoded for the newest version, for the
+// client's version. Versions 1 and 2 need no rewriting, as they share one
+// wire format.
+func (c *clientInfo) protocolShim(payload any) any {
+	if evt, ok := payload.(outputEvent); ok && c.protocol() >= 3 {
+		switch evt.Encoding {
+		case outputEncodingUTF8:
+			evt.Encoding = ""
+		case "":
+			evt.Encoding = outputEncodingBase64
+		}
+		return evt
+	}
+	return payload
+}
+
+// negotiateClientFeatures keeps the requested features this sidecar
+// supports, once each.
+func negotiateClientFeatures(requested []string) []string {
+	features := []string{}
+	for _, feature := range requested {
+		if containsString(supportedClientFeatures, feature) && !containsString(features, feature) {
+			features = append(features, feature)
+		}
+	}
+	return features
+}
+
+// has reports whether the client negotiated feature; a client that sent no
+// hello request has every feature.
+func (c *clientInfo) has(feature string) bool {
+	return c == nil || containsString(c.Features, feature)
+}
+
+// wants reports whether payload should reach the client.
+func (c *clientInfo) wants(payload any) bool {
+	feature, optional := featureEvents[stringField(payload, "Type")]
+	return !optional || c.has(feature)
+}
+
+// Event categories a client may unsubscribe from. Lifecycle events, such as
+// ready, exit and error, and answers to requests always arrive.
+const (
+	eventCategoryOutput    = "output"
+	eventCategoryHeartbeat = "heartbeat"
+	// eventCategoryMetrics covers backpressure reports.
+	eventCategoryMetrics = "metrics"
+	// eventCategoryActivity covers what programs signal through escape
+	// sequences: progress, screen mode changes and clipboard writes.
+	eventCategoryActivity = "activity"
+)
+
+var eventCategories = []string{
+	eventCategoryOutput,
+	eventCategoryHeartbeat,
+	eventCategoryMetrics,
+	eventCategoryActivity,
+}
+
+// categoryEvents maps each event a client may unsubscribe from to its
+// category.
+var categoryEvents = map[string]string{
+	eventTypeOutput:       eventCategoryOutput,
+	eventTypeHeartbeat:    eventCategoryHeartbeat,
+	eventTypeBackpressure: eventCategoryMetrics,
+	eventTypeProgress:     eventCategoryActivity,
+	eventTypeScreenMode:   eventCategoryActivity,
+	eventTypeClipboard:    eventCategoryActivity,
+}
+
+func validateEventCategories(categories []string) error {
+	for _, category := range categories {
+		if !containsString(eventCategories, category) {
+			return newSidecarError(errorCodeInvalidRequest, "unsupported event category %q", category)
+		}
+	}
+	return nil
+}
+
+// eventSubscriptions are the categories a client turned off. A nil
+// eventSubscriptions delivers everything.
+type eventSubscriptions struct {
+	mu         sync.Mutex
+	categories map[string]*categoryState
+}
+
+// categoryState is off for every terminal when muted, except for those in
+// flipped, and the other way round.
+type categoryState struct {
+	muted   bool
+	flipped map[string]bool
+}
+
+func newEventSubscriptions() *eventSubscriptions {
+	return &eventSubscriptions{categories: make(map[string]*categoryState)}
+}
+
+// Change turns change's categories on or off.
+func (e *eventSubscriptions) Change(change subscriptionChange, subscribe bool) {
+	categories := change.Categories
+	if len(categories) == 0 {
+		categories = eventCategories
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, category := range categories {
+		state := e.categories[category]
+		if state == nil {
+			state = &categoryState{flipped: make(map[string]bool)}
+			e.categories[category] = state
+		}
+		if len(change.TerminalIDs) == 0 {
+			state.muted = !subscribe
+			state.flipped = make(map[string]bool)
+			continue
+		}
+		for _, terminalID := range change.TerminalIDs {
+			if state.muted == !subscribe {
+				delete(state.flipped, terminalID)
+			} else {
+				state.flipped[terminalID] = true
+			}
+		}
+	}
+}
+
+// Admits reports whether payload should reach the client, forgetting a
+// terminal once it exits so a new terminal reusing its ID starts afresh.
+func (e *eventSubscriptions) Admits(payload any) bool {
+	if e == nil {
+		return true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	terminalID := stringField(payload, "TerminalID")
+	if _, exited := payload.(exitEvent); exited {
+		for _, state := range e.categories {
+			delete(state.flipped, terminalID)
+		}
+		return true
+	}
+	state := e.categories[categoryEvents[stringField(payload, "Type")]]
+	if state == nil {
+		return true
+	}
+	return state.muted == state.flipped[terminalID]
+}
+
+// Snapshot describes every category for a subscriptions event.
+func (e *eventSubscriptions) Snapshot() []categorySubscription {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	snapshot := make([]categorySubscription, 0, len(eventCategories))
+	for _, category := range eventCategories {
+		entry := categorySubscription{Category: category, Subscribed: true}
+		if state := e.categories[category]; state != nil {
+			entry.Subscribed = !state.muted
+			for terminalID := range state.flipped {
+				entry.Except = append(entry.Except, terminalID)
+			}
+			sort.Strings(entry.Except)
+		}
+		snapshot = append(snapshot, entry)
+	}
+	return snapshot
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
 }
 
 type readyEvent struct {
 	Type       string `json:"type"`
 	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
 	Display    string `json:"displayName"`
+	// Cwd is the expanded working directory, which differs from the request
+	// after "~"/variable expansion or a cwdFallback.
+	Cwd string `json:"cwd,omitempty"`
+	// Attached marks a ready event for a terminal that was already running.
+	Attached bool `json:"attached,omitempty"`
+	// Backend is the backend serving the terminal. Under "pipes" programs
+	// see redirected handles, so there is no cursor movement, line editing
+	// or resizing and clients should fall back to plain text.
+	Backend string `json:"backend,omitempty"`
+	// ShellVersion is the shell's probed version, such as "7.4.1" for pwsh,
+	// omitted when unknown.
+	ShellVersion string `json:"shellVersion,omitempty"`
+	// CodePage is the console code page programs in the terminal write in:
+	// 65001 with forceUtf8, otherwise the system OEM code page.
+	CodePage int `json:"codePage,omitempty"`
+	// RestoredFrom is when the checkpoint restored into a new terminal's
+	// scrollback was saved; a snapshot with that scrollback follows.
+	RestoredFrom string `json:"restoredFrom,omitempty"`
+	// Seq is the seq of the terminal's latest stream event. Resumed marks
+	// an attach followed by the events from resumeFromSeq to Seq rather
+	// than by a snapshot.
+	Seq     uint64 `json:"seq,omitempty"`
+	Resumed bool   `json:"resumed,omitempty"`
 }
 
 type outputEvent struct {
-	Type       string `json:"type"`
-	TerminalID string `json:"terminalId"`
-	Data       string `json:"data"`
+	Type        string `json:"type"`
+	TerminalID  string `json:"terminalId"`
+	Data        string `json:"data"`
+	Encoding    string `json:"encoding,omitempty"`
+	Compression string `json:"compression,omitempty"`
+	// Timestamp is set for terminals opened with timestamps.
+	Timestamp *outputTimestamp `json:"timestamp,omitempty"`
+
+	// Seq numbers the terminal's stream events when --replay-events is
+	// set, see eventLog.
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// outputTimestamp records when the sidecar read an output chunk. Wall is
+// the RFC 3339 UTC time; MonotonicUs counts microseconds on the monotonic
+// clock since the sidecar process started and never jumps, so differences
+// between events are exact even across wall-clock adjustments.
+type outputTimestamp struct {
+	MonotonicUs int64  `json:"monotonicUs"`
+	Wall        string `json:"wall"`
 }
 
 type exitEvent struct {
 	Type       string `json:"type"`
 	TerminalID string `json:"terminalId"`
 	Code       int    `json:"code"`
+	// Reason is one of the exitReason values; Status names the NTSTATUS
+	// code, such as STATUS_ACCESS_VIOLATION, when the exit code is one.
+	Reason string `json:"reason,omitempty"`
+	Status string `json:"status,omitempty"`
+
+	Seq uint64 `json:"seq,omitempty"`
 }
 
 type errorEvent struct {
 	Type       string `json:"type"`
 	TerminalID string `json:"terminalId,omitempty"`
+	RequestID  string `json:"requestId,omitempty"`
 	Code       string `json:"code"`
 	Message    string `json:"message"`
 }
 
+type clipboardEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	Selection  string `json:"selection"`
+	Text       string `json:"text"`
+
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+type progressEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	State      string `json:"state"`
+	Percent    int    `json:"percent"`
+
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+type snapshotEvent struct {
+	Type            string         `json:"type"`
+	TerminalID      string         `json:"terminalId"`
+	Cols            int            `json:"cols"`
+	Rows            int            `json:"rows"`
+	Cursor          snapshotCursor `json:"cursor"`
+	AlternateScreen bool           `json:"alternateScreen"`
+	Lines           []snapshotLine `json:"lines"`
+	Scrollback      []snapshotLine `json:"scrollback,omitempty"`
+}
+
+type snapshotCursor struct {
+	X       int  `json:"x"`
+	Y       int  `json:"y"`
+	Visible bool `json:"visible"`
+}
+
+type snapshotLine struct {
+	Text string        `json:"text"`
+	Runs []snapshotRun `json:"runs,omitempty"`
+}
+
+// snapshotRun describes styled cells; unstyled cells are omitted.
+type snapshotRun struct {
+	attrs cellAttrs
+
+	Start         int    `json:"start"`
+	Length        int    `json:"length"`
+	FG            string `json:"fg,omitempty"`
+	BG            string `json:"bg,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Dim           bool   `json:"dim,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underline     bool   `json:"underline,omitempty"`
+	Inverse       bool   `json:"inverse,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+}
+
+type matchedEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
+	Match      string `json:"match"`
+	Context    string `json:"context"`
+}
+
+type searchResultsEvent struct {
+	Type       string        `json:"type"`
+	TerminalID string        `json:"terminalId"`
+	RequestID  string        `json:"requestId,omitempty"`
+	TotalLines int           `json:"totalLines"`
+	Matches    []searchMatch `json:"matches"`
+	Truncated  bool          `json:"truncated,omitempty"`
+}
+
+// searchMatch positions are zero-based; Line counts from the oldest retained
+// scrollback line and Column/Length are in runes.
+type searchMatch struct {
+	Line   int      `json:"line"`
+	Column int      `json:"column"`
+	Length int      `json:"length"`
+	Text   string   `json:"text"`
+	Before []string `json:"before,omitempty"`
+	After  []string `json:"after,omitempty"`
+}
+
+type backpressureEvent struct {
+	Type         string `json:"type"`
+	TerminalID   string `json:"terminalId"`
+	State        string `json:"state"`
+	QueuedBytes  int    `json:"queuedBytes"`
+	DroppedBytes int    `json:"droppedBytes,omitempty"`
+
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+type exportEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
+	Format     string `json:"format"`
+	Bytes      int    `json:"bytes"`
+	Content    string `json:"content,omitempty"`
+	Path       string `json:"path,omitempty"`
+	// Redacted counts the secrets masked in a redacted export.
+	Redacted int `json:"redacted,omitempty"`
+}
+
 type pongEvent struct {
-	Type string `json:"type"`
+	Type      string `json:"type"`
+	Nonce     string `json:"nonce,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	// ReceivedAt is the sidecar's Unix time in milliseconds when the ping
+	// was handled, set only for pings carrying a nonce or timestamp.
+	ReceivedAt int64 `json:"receivedAt,omitempty"`
+}
+
+type statsEvent struct {
+	Type      string `json:"type"`
+	Terminals int    `json:"terminals"`
+	// MaxTerminals is omitted when the number of terminals is unlimited.
+	MaxTerminals int           `json:"maxTerminals,omitempty"`
+	Latency      *latencyStats `json:"latency,omitempty"`
+	// Clients lists the connected clients that sent a hello request.
+	Clients []clientInfo `json:"clients,omitempty"`
+}
+
+type listEvent struct {
+	Type      string         `json:"type"`
+	RequestID string         `json:"requestId,omitempty"`
+	Terminals []terminalInfo `json:"terminals"`
+}
+
+type shellsEvent struct {
+	Type      string `json:"type"`
+	RequestID string `json:"requestId,omitempty"`
+	// Default is the shell an open without one gets, omitted when none is
+	// available; Order is the fallback order behind it.
+	Default string      `json:"default,omitempty"`
+	Order   []string    `json:"order"`
+	Shells  []shellInfo `json:"shells"`
+}
+
+// probeEvent answers a probe request. Backend is what opens naming none now
+// get, omitted when neither ConPTY nor winpty works; Error says why ConPTY
+// does not.
+type probeEvent struct {
+	Type      string `json:"type"`
+	RequestID string `json:"requestId,omitempty"`
+	Backend   string `json:"backend,omitempty"`
+	ConPTY    bool   `json:"conpty"`
+	Winpty    bool   `json:"winpty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// tmuxPanesEvent answers a tmux-panes request with the session's panes in
+// window and pane order.
+type tmuxPanesEvent struct {
+	Type      string         `json:"type"`
+	RequestID string         `json:"requestId,omitempty"`
+	Session   string         `json:"session"`
+	Panes     []tmuxPaneInfo `json:"panes"`
+}
+
+// tmuxPaneInfo describes one tmux pane. Pane is what an open's tmux.pane
+// takes to adopt it.
+type tmuxPaneInfo struct {
+	Pane       string `json:"pane"`
+	Window     string `json:"window"`
+	WindowName string `json:"windowName,omitempty"`
+	Index      int    `json:"index"`
+	Cols       int    `json:"cols"`
+	Rows       int    `json:"rows"`
+	// Active marks the session's current pane, which an open without
+	// tmux.pane adopts.
+	Active  bool   `json:"active,omitempty"`
+	Command string `json:"command,omitempty"`
+	Title   string `json:"title,omitempty"`
+}
+
+// shellInfo describes one supported shell; Available is false when it is
+// not installed or the exec policy denies it.
+type shellInfo struct {
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Available bool   `json:"available"`
+}
+
+type terminalInfo struct {
+	TerminalID string            `json:"terminalId"`
+	Display    string            `json:"displayName"`
+	Cwd        string            `json:"cwd,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	UptimeMs   int64             `json:"uptimeMs"`
+	// ScreenMode is "main" or "alternate", see screenModeEvent.
+	ScreenMode string `json:"screenMode"`
+}
+
+type heartbeatEvent struct {
+	Type      string `json:"type"`
+	UptimeMs  int64  `json:"uptimeMs"`
+	Terminals int    `json:"terminals"`
+}
+
+// latencyStats summarises the round trips reported by recent pings.
+type latencyStats struct {
+	Samples int     `json:"samples"`
+	LastMs  float64 `json:"lastMs"`
+	MinMs   float64 `json:"minMs"`
+	AvgMs   float64 `json:"avgMs"`
+	MaxMs   float64 `json:"maxMs"`
 }
 
 type shutdownAckEvent struct {
+	Type      string                `json:"type"`
+	Terminals []terminalCloseResult `json:"terminals,omitempty"`
+}
+
+type closedAllEvent struct {
+	Type      string                `json:"type"`
+	RequestID string                `json:"requestId,omitempty"`
+	Terminals []terminalCloseResult `json:"terminals"`
+}
+
+type closedEvent struct {
 	Type string `json:"type"`
+	terminalCloseResult
+
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// terminalCloseResult reports how a terminal was closed: "graceful" when it
+// exited after hangup, "escalated" when the grace period expired and
+// "forced" when it was terminated directly.
+type terminalCloseResult struct {
+	TerminalID string `json:"terminalId"`
+	Method     string `json:"method"`
+	ExitCode   *int   `json:"exitCode,omitempty"`
+	// Reason is set when the sidecar closed the terminal on its own.
+	Reason string `json:"reason,omitempty"`
+}
+
+// sizeEvent answers get-size and a resize with a requestId. Sizes are
+// clamped to 1-32767 cells, so they can differ from the requested ones; a
+// resize reports the size it resolves to even while debouncing defers it.
+type sizeEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	RequestID  string `json:"requestId,omitempty"`
+	Cols       int    `json:"cols"`
+	Rows       int    `json:"rows"`
+}
+
+const (
+	screenModeMain      = "main"
+	screenModeAlternate = "alternate"
+)
+
+// screenModeEvent reports a switch between the main screen and the
+// alternate screen of a full-screen program. Output written to the
+// alternate screen is redrawn rather than scrolled, so hosts may pause
+// scrollback capture until the terminal is back on the main screen.
+type screenModeEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	Mode       string `json:"mode"`
+
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// upgradeEvent is the first event from the binary that took over a daemon.
+type upgradeEvent struct {
+	Type      string   `json:"type"`
+	RequestID string   `json:"requestId,omitempty"`
+	Version   string   `json:"version"`
+	PID       int      `json:"pid"`
+	Terminals []string `json:"terminals"`
+}
+
+func screenModeName(alternate bool) string {
+	if alternate {
+		return screenModeAlternate
+	}
+	return screenModeMain
+}
+
+type willCloseEvent struct {
+	Type       string `json:"type"`
+	TerminalID string `json:"terminalId"`
+	Reason     string `json:"reason"`
+	CloseInMs  int64  `json:"closeInMs"`
+
+	Seq uint64 `json:"seq,omitempty"`
+}
+
+// protocolMessage pairs a wire type with the struct that carries it.
+type protocolMessage struct {
+	Type    string
+	Payload any
+}
+
+// protocolRequests and protocolEvents list every message on the wire; the
+// schema command generates client type definitions from them.
+var protocolRequests = []protocolMessage{
+	{requestTypeAuth, authRequest{}},
+	{requestTypeOpen, openRequest{}},
+	{requestTypeWrite, writeRequest{}},
+	{requestTypeResize, resizeRequest{}},
+	{requestTypeClose, closeRequest{}},
+	{requestTypeSnapshot, snapshotRequest{}},
+	{requestTypeWait, waitRequest{}},
+	{requestTypeSearch, searchRequest{}},
+	{requestTypeExport, exportRequest{}},
+	{requestTypePing, pingRequest{}},
+	{requestTypeStats, statsRequest{}},
+	{requestTypeKillProcess, killProcessRequest{}},
+	{requestTypeEOF, eofRequest{}},
+	{requestTypeBroadcast, broadcastRequest{}},
+	{requestTypeList, listRequest{}},
+	{requestTypeCloseAll, closeAllRequest{}},
+	{requestTypeListShells, listShellsRequest{}},
+	{requestTypeProbe, probeRequest{}},
+	{requestTypeTmuxPanes, tmuxPanesRequest{}},
+	{requestTypeGetSize, getSizeRequest{}},
+	{requestTypeFocus, focusRequest{}},
+	{requestTypeUpgrade, upgradeRequest{}},
+	{requestTypeHello, clientHelloRequest{}},
+	{requestTypeSubscribe, subscribeRequest{}},
+	{requestTypeUnsubscribe, unsubscribeRequest{}},
+	{requestTypeShutdown, shutdownRequest{}},
+}
+
+var protocolEvents = []protocolMessage{
+	{eventTypeHello, helloEvent{}},
+	{eventTypeReady, readyEvent{}},
+	{eventTypeOutput, outputEvent{}},
+	{eventTypeExit, exitEvent{}},
+	{eventTypeError, errorEvent{}},
+	{eventTypePong, pongEvent{}},
+	{eventTypeShutdownAck, shutdownAckEvent{}},
+	{eventTypeClipboard, clipboardEvent{}},
+	{eventTypeProgress, progressEvent{}},
+	{eventTypeSnapshot, snapshotEvent{}},
+	{eventTypeMatched, matchedEvent{}},
+	{eventTypeSearchResults, searchResultsEvent{}},
+	{eventTypeExport, exportEvent{}},
+	{eventTypeBackpressure, backpressureEvent{}},
+	{eventTypeClosed, closedEvent{}},
+	{eventTypeWillClose, willCloseEvent{}},
+	{eventTypeStats, statsEvent{}},
+	{eventTypeHeartbeat, heartbeatEvent{}},
+	{eventTypeList, listEvent{}},
+	{eventTypeClosedAll, closedAllEvent{}},
+	{eventTypeShells, shellsEvent{}},
+	{eventTypeProbe, probeEvent{}},
+	{eventTypeTmuxPanes, tmuxPanesEvent{}},
+	{eventTypeSize, sizeEvent{}},
+	{eventTypeScreenMode, screenModeEvent{}},
+	{eventTypeUpgrade, upgradeEvent{}},
+	{eventTypeHelloAck, helloAckEvent{}},
+	{eventTypeSubscriptions, subscriptionsEvent{}},
 }
 
 type sidecarError struct {
@@ -150,24 +1469,62 @@ func newSidecarError(code string, format string, args ...any) *sidecarError {
 	}
 }
 
+// sidecarCapabilities advertises platform, the backend that serves opens
+// naming none, and the optional ones cfg enables.
+func sidecarCapabilities(cfg runConfig, platform string) helloCapabilities {
+	backends := []string{platform}
+	if cfg.MockBackend {
+		backends = append(backends, backendMock)
+	}
+
+	return helloCapabilities{
+		Encodings:       []string{wireEncodingJSON, wireEncodingMsgpack, wireEncodingJSONRPC},
+		Compression:     append([]string(nil), supportedOutputCompressions...),
+		OutputEncodings: []string{outputEncodingBase64, outputEncodingUTF8},
+		OutputFilters:   append([]string(nil), supportedOutputFilters...),
+		Backends:        backends,
+		Features:        append([]string(nil), supportedClientFeatures...),
+		EventCategories: append([]string(nil), eventCategories...),
+	}
+}
+
 func decodeRequestLine(line []byte) (request, error) {
 	var env requestEnvelope
 	if err := json.Unmarshal(line, &env); err != nil {
 		return nil, fmt.Errorf("invalid request JSON: %w", err)
 	}
 
+	if len(env.TerminalID) > maxTerminalIDBytes {
+		return nil, newSidecarError(errorCodeTerminalIDTooLong, "terminalId exceeds %d bytes", maxTerminalIDBytes)
+	}
+
 	switch env.Type {
 	case requestTypeOpen:
 		var req openRequest
 		if err := json.Unmarshal(line, &req); err != nil {
 			return nil, fmt.Errorf("invalid open request: %w", err)
 		}
+		if err := validateEnvLimits(req.Env); err != nil {
+			return nil, err
+		}
+		if err := validateTags("tags", req.Tags); err != nil {
+			return nil, err
+		}
+		if err := validateExtraArgs(req.ExtraArgs); err != nil {
+			return nil, err
+		}
+		if err := validatePathEdits(req.PathPrepend, req.PathAppend); err != nil {
+			return nil, err
+		}
 		return req, nil
 	case requestTypeWrite:
 		var req writeRequest
 		if err := json.Unmarshal(line, &req); err != nil {
 			return nil, fmt.Errorf("invalid write request: %w", err)
 		}
+		if len(req.Data) > maxWriteDataBytes {
+			return nil, newSidecarError(errorCodeWriteTooLarge, "write data exceeds %d bytes; split it with more=true", maxWriteDataBytes)
+		}
 		return req, nil
 	case requestTypeResize:
 		var req resizeRequest
@@ -181,12 +1538,152 @@ func decodeRequestLine(line []byte) (request, error) {
 			return nil, fmt.Errorf("invalid close request: %w", err)
 		}
 		return req, nil
+	case requestTypeSnapshot:
+		var req snapshotRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid snapshot request: %w", err)
+		}
+		return req, nil
+	case requestTypeWait:
+		var req waitRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid wait request: %w", err)
+		}
+		return req, nil
+	case requestTypeSearch:
+		var req searchRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid search request: %w", err)
+		}
+		return req, nil
+	case requestTypeExport:
+		var req exportRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid export request: %w", err)
+		}
+		return req, nil
 	case requestTypePing:
 		var req pingRequest
 		if err := json.Unmarshal(line, &req); err != nil {
 			return nil, fmt.Errorf("invalid ping request: %w", err)
 		}
 		return req, nil
+	case requestTypeStats:
+		var req statsRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid stats request: %w", err)
+		}
+		return req, nil
+	case requestTypeBroadcast:
+		var req broadcastRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid broadcast request: %w", err)
+		}
+		if len(req.Data) > maxWriteDataBytes {
+			return nil, newSidecarError(errorCodeWriteTooLarge, "broadcast data exceeds %d bytes", maxWriteDataBytes)
+		}
+		for _, id := range req.TerminalIDs {
+			if len(id) > maxTerminalIDBytes {
+				return nil, newSidecarError(errorCodeTerminalIDTooLong, "terminalId exceeds %d bytes", maxTerminalIDBytes)
+			}
+		}
+		if err := validateTags("selector", req.Selector); err != nil {
+			return nil, err
+		}
+		return req, nil
+	case requestTypeCloseAll:
+		var req closeAllRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid close-all request: %w", err)
+		}
+		if err := validateTags("selector", req.Selector); err != nil {
+			return nil, err
+		}
+		return req, nil
+	case requestTypeList:
+		var req listRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid list request: %w", err)
+		}
+		if err := validateTags("selector", req.Selector); err != nil {
+			return nil, err
+		}
+		return req, nil
+	case requestTypeListShells:
+		var req listShellsRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid list-shells request: %w", err)
+		}
+		return req, nil
+	case requestTypeProbe:
+		var req probeRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid probe request: %w", err)
+		}
+		return req, nil
+	case requestTypeTmuxPanes:
+		var req tmuxPanesRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid tmux-panes request: %w", err)
+		}
+		return req, nil
+	case requestTypeGetSize:
+		var req getSizeRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid get-size request: %w", err)
+		}
+		return req, nil
+	case requestTypeFocus:
+		var req focusRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid focus request: %w", err)
+		}
+		return req, nil
+	case requestTypeEOF:
+		var req eofRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid eof request: %w", err)
+		}
+		return req, nil
+	case requestTypeKillProcess:
+		var req killProcessRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid kill-process request: %w", err)
+		}
+		return req, nil
+	case requestTypeUpgrade:
+		var req upgradeRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid upgrade request: %w", err)
+		}
+		return req, nil
+	case requestTypeHello:
+		var req clientHelloRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid hello request: %w", err)
+		}
+		if len(req.Name) > maxClientNameBytes || len(req.Version) > maxClientNameBytes {
+			return nil, newSidecarError(errorCodeInvalidRequest, "hello name and version may not exceed %d bytes", maxClientNameBytes)
+		}
+		return req, nil
+	case requestTypeSubscribe:
+		var req subscribeRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid subscribe request: %w", err)
+		}
+		if err := validateEventCategories(req.Categories); err != nil {
+			return nil, err
+		}
+		return req, nil
+	case requestTypeUnsubscribe:
+		var req unsubscribeRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			return nil, fmt.Errorf("invalid unsubscribe request: %w", err)
+		}
+		if err := validateEventCategories(req.Categories); err != nil {
+			return nil, err
+		}
+		return req, nil
 	case requestTypeShutdown:
 		var req shutdownRequest
 		if err := json.Unmarshal(line, &req); err != nil {
@@ -198,6 +1695,82 @@ func decodeRequestLine(line []byte) (request, error) {
 	}
 }
 
+func validateEnvLimits(env map[string]string) error {
+	if len(env) > maxEnvEntries {
+		return newSidecarError(errorCodeEnvTooLarge, "env has more than %d entries", maxEnvEntries)
+	}
+	for name, value := range env {
+		if len(name)+1+len(value) > maxEnvEntryBytes {
+			return newSidecarError(errorCodeEnvTooLarge, "env entry %.64q exceeds %d bytes", name, maxEnvEntryBytes)
+		}
+	}
+	return nil
+}
+
+// validateTags bounds a tag map or selector, named field in errors.
+func validateTags(field string, tags map[string]string) error {
+	if len(tags) > maxTags {
+		return newSidecarError(errorCodeInvalidRequest, "%s has more than %d entries", field, maxTags)
+	}
+	for name, value := range tags {
+		if name == "" {
+			return newSidecarError(errorCodeInvalidRequest, "%s names must not be empty", field)
+		}
+		if len(name)+len(value) > maxTagBytes {
+			return newSidecarError(errorCodeInvalidRequest, "%s entry %.64q exceeds %d bytes", field, name, maxTagBytes)
+		}
+	}
+	return nil
+}
+
+// validatePathEdits keeps pathPrepend and pathAppend within one environment
+// entry and rejects empty directories, which would put the cwd on PATH.
+func validatePathEdits(prepend []string, appendix []string) error {
+	total := 0
+	for _, entry := range append(append([]string(nil), prepend...), appendix...) {
+		if strings.TrimSpace(entry) == "" {
+			return newSidecarError(errorCodeInvalidRequest, "pathPrepend and pathAppend entries must not be empty")
+		}
+		total += len(entry) + 1
+	}
+	if total > maxEnvEntryBytes {
+		return newSidecarError(errorCodeEnvTooLarge, "pathPrepend and pathAppend exceed %d bytes", maxEnvEntryBytes)
+	}
+	return nil
+}
+
+// validateExtraArgs bounds extraArgs and rejects NUL, which cannot be passed
+// on a command line.
+func validateExtraArgs(args []string) error {
+	if len(args) > maxExtraArgs {
+		return newSidecarError(errorCodeInvalidRequest, "extraArgs has more than %d entries", maxExtraArgs)
+	}
+	total := 0
+	for _, arg := range args {
+		if strings.ContainsRune(arg, 0) {
+			return newSidecarError(errorCodeInvalidRequest, "extraArgs entry %.64q contains NUL", arg)
+		}
+		total += len(arg) + 1
+	}
+	if total > maxExtraArgBytes {
+		return newSidecarError(errorCodeInvalidRequest, "extraArgs exceeds %d bytes", maxExtraArgBytes)
+	}
+	return nil
+}
+
+// matchTags reports whether tags has every name/value pair in selector; an
+// empty selector matches everything, and an empty selector value matches any
+// value of that tag.
+func matchTags(tags map[string]string, selector map[string]string) bool {
+	for name, want := range selector {
+		value, ok := tags[name]
+		if !ok || want != "" && value != want {
+			return false
+		}
+	}
+	return true
+}
+
 func writeNDJSONLine(w io.Writer, payload any) error {
 	encoded, err := json.Marshal(payload)
 	if err != nil {
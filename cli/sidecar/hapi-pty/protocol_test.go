@@ -1,8 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -40,6 +43,111 @@ func TestDecodeRequestLineUnknownType(t *testing.T) {
 	}
 }
 
+func TestDecodeRequestLineEnforcesLimits(t *testing.T) {
+	manyEnv := make(map[string]string, maxEnvEntries+1)
+	for i := 0; i <= maxEnvEntries; i++ {
+		manyEnv[fmt.Sprintf("K%d", i)] = "v"
+	}
+	manyEnvJSON, _ := json.Marshal(manyEnv)
+
+	cases := map[string]struct {
+		raw  string
+		code string
+	}{
+		"terminal id": {
+			raw:  `{"type":"resize","terminalId":"` + strings.Repeat("t", maxTerminalIDBytes+1) + `"}`,
+			code: errorCodeTerminalIDTooLong,
+		},
+		"env entries": {
+			raw:  `{"type":"open","terminalId":"t1","env":` + string(manyEnvJSON) + `}`,
+			code: errorCodeEnvTooLarge,
+		},
+		"env value": {
+			raw:  `{"type":"open","terminalId":"t1","env":{"K":"` + strings.Repeat("v", maxEnvEntryBytes) + `"}}`,
+			code: errorCodeEnvTooLarge,
+		},
+		"tags": {
+			raw:  `{"type":"open","terminalId":"t1","tags":{"k":"` + strings.Repeat("v", maxTagBytes) + `"}}`,
+			code: errorCodeInvalidRequest,
+		},
+		"selector": {
+			raw:  `{"type":"list","selector":{"":"x"}}`,
+			code: errorCodeInvalidRequest,
+		},
+		"extra args": {
+			raw:  `{"type":"open","terminalId":"t1","extraArgs":["-x\u0000"]}`,
+			code: errorCodeInvalidRequest,
+		},
+		"path edits": {
+			raw:  `{"type":"open","terminalId":"t1","pathAppend":[" "]}`,
+			code: errorCodeInvalidRequest,
+		},
+		"write data": {
+			raw:  `{"type":"write","terminalId":"t1","data":"` + strings.Repeat("x", maxWriteDataBytes+1) + `"}`,
+			code: errorCodeWriteTooLarge,
+		},
+	}
+
+	for name, tc := range cases {
+		_, err := decodeRequestLine([]byte(tc.raw))
+		if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != tc.code {
+			t.Fatalf("%s: expected %s, got %v", name, tc.code, err)
+		}
+	}
+
+	atLimit := `{"type":"open","terminalId":"` + strings.Repeat("t", maxTerminalIDBytes) + `","env":{"K":"` + strings.Repeat("v", maxEnvEntryBytes-2) + `"}}`
+	if _, err := decodeRequestLine([]byte(atLimit)); err != nil {
+		t.Fatalf("request at the limits rejected: %v", err)
+	}
+}
+
+func FuzzDecodeRequestLine(f *testing.F) {
+	f.Add([]byte(`{"type":"open","terminalId":"t1","cwd":"C:/","shell":"pwsh","cols":80,"rows":24,"env":{"K":"V"}}`))
+	f.Add([]byte(`{"type":"write","terminalId":"t1","data":"aGk=","encoding":"base64","keys":["enter"],"more":true}`))
+	f.Add([]byte(`{"type":"wait","terminalId":"t1","pattern":"\\$ $","timeoutMs":100}`))
+	f.Add([]byte(`{"type":"ping","nonce":"n","timestamp":1}`))
+	f.Add([]byte(`{"type":"close","terminalId":"t1","force":true,"graceMs":-1}`))
+	f.Add([]byte(`{"type":"open","env":null,"inheritEnv":false}`))
+	f.Add([]byte(`{"type":`))
+
+	f.Fuzz(func(t *testing.T, line []byte) {
+		req, err := decodeRequestLine(line)
+		if err != nil {
+			return
+		}
+
+		switch typed := req.(type) {
+		case openRequest:
+			if len(typed.TerminalID) > maxTerminalIDBytes || validateEnvLimits(typed.Env) != nil {
+				t.Fatalf("open request passed despite exceeding limits: %q", line)
+			}
+		case writeRequest:
+			if len(typed.TerminalID) > maxTerminalIDBytes || len(typed.Data) > maxWriteDataBytes {
+				t.Fatalf("write request passed despite exceeding limits: %q", line)
+			}
+		case nil:
+			t.Fatalf("nil request without error for %q", line)
+		}
+	})
+}
+
+func FuzzReadMsgpackFrame(f *testing.F) {
+	f.Add([]byte{0x82, 0xa4, 't', 'y', 'p', 'e', 0xa4, 'p', 'i', 'n', 'g', 0xa1, 'n', 0x01})
+	f.Add([]byte{0xdd, 0xff, 0xff, 0xff, 0xff})
+	f.Add([]byte{0xc4, 0x03, 'a', 'b', 'c'})
+
+	f.Fuzz(func(t *testing.T, frame []byte) {
+		line, err := readMsgpackFrame(bufio.NewReader(bytes.NewReader(frame)), 4096)
+		if err != nil {
+			return
+		}
+		if !json.Valid(line) {
+			t.Fatalf("msgpack frame %x decoded to invalid JSON %q", frame, line)
+		}
+		_, _ = decodeRequestLine(line)
+	})
+}
+
 func TestWriteNDJSONLineAddsTrailingNewline(t *testing.T) {
 	var out bytes.Buffer
 
@@ -66,3 +174,62 @@ func TestWriteNDJSONLineAddsTrailingNewline(t *testing.T) {
 		t.Fatalf("unexpected event payload: %+v", decoded)
 	}
 }
+
+func TestNegotiateProtocolPicksNewestCommonVersion(t *testing.T) {
+	for _, tc := range []struct {
+		requested []int
+		want      int
+	}{
+		{nil, protocolVersion},
+		{[]int{1}, 1},
+		{[]int{2, 3, 9}, 3},
+		{[]int{0, 2}, 2},
+	} {
+		got, err := negotiateProtocol(tc.requested)
+		if err != nil || got != tc.want {
+			t.Fatalf("negotiateProtocol(%v) = %d, %v; want %d", tc.requested, got, err, tc.want)
+		}
+	}
+	if _, err := negotiateProtocol([]int{maxProtocolVersion + 1}); sidecarErrorFrom(err, "").Code != errorCodeUnsupportedProtocol {
+		t.Fatalf("expected unsupported_protocol, got %v", err)
+	}
+}
+
+func TestProtocolShimStatesOutputEncodingForVersion3(t *testing.T) {
+	v3 := &clientInfo{Protocol: 3}
+	base64Chunk := outputEvent{Type: eventTypeOutput, TerminalID: "t1", Data: "aGk="}
+	if got := v3.protocolShim(base64Chunk).(outputEvent); got.Encoding != outputEncodingBase64 {
+		t.Fatalf("version 3 should state base64, got %+v", got)
+	}
+	utf8Chunk := outputEvent{Type: eventTypeOutput, TerminalID: "t1", Data: "hi", Encoding: outputEncodingUTF8}
+	if got := v3.protocolShim(utf8Chunk).(outputEvent); got.Encoding != "" {
+		t.Fatalf("version 3 should omit its default encoding, got %+v", got)
+	}
+
+	var legacy *clientInfo
+	if got := legacy.protocolShim(base64Chunk).(outputEvent); got != base64Chunk {
+		t.Fatalf("a host that never negotiated should get output unchanged, got %+v", got)
+	}
+	if req := v3.openDefaults(openRequest{}); req.OutputEncoding != outputEncodingUTF8 {
+		t.Fatalf("version 3 opens should default to utf8, got %q", req.OutputEncoding)
+	}
+	for _, compression := range supportedOutputCompressions {
+		req := v3.openDefaults(openRequest{Compression: compression})
+		if req.OutputEncoding != "" {
+			t.Fatalf("version 3 opens with %s compression should keep base64, got %q", compression, req.OutputEncoding)
+		}
+		if _, err := newOutputEncoder(req); err != nil {
+			t.Fatalf("version 3 opens with %s compression should be accepted: %v", compression, err)
+		}
+	}
+	v1 := &clientInfo{Protocol: 1}
+	if got := v1.protocolShim(base64Chunk).(outputEvent); got != base64Chunk {
+		t.Fatalf("version 1 shares version 2's wire format, got %+v", got)
+	}
+	if req := v1.openDefaults(openRequest{}); req.OutputEncoding != "" {
+		t.Fatalf("version 1 opens should keep base64, got %q", req.OutputEncoding)
+	}
+	if req := legacy.openDefaults(openRequest{}); req.OutputEncoding != "" || req.Timestamps {
+		t.Fatalf("a host that never negotiated should keep open defaults, got %+v", req)
+	}
+}
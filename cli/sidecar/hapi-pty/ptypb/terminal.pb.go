@@ -0,0 +1,8098 @@
+// Code generated by `hapi-pty schema --format proto`. DO NOT EDIT.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.5
+// 	protoc        (unknown)
+// source: terminal.proto
+
+package ptypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Request struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Request:
+	//
+	//	*Request_Auth
+	//	*Request_Open
+	//	*Request_Write
+	//	*Request_Resize
+	//	*Request_Close
+	//	*Request_Snapshot
+	//	*Request_Wait
+	//	*Request_Search
+	//	*Request_Export
+	//	*Request_Ping
+	//	*Request_Stats
+	//	*Request_KillProcess
+	//	*Request_Eof
+	//	*Request_Broadcast
+	//	*Request_List
+	//	*Request_CloseAll
+	//	*Request_ListShells
+	//	*Request_Probe
+	//	*Request_TmuxPanes
+	//	*Request_GetSize
+	//	*Request_Focus
+	//	*Request_Upgrade
+	//	*Request_Hello
+	//	*Request_Subscribe
+	//	*Request_Unsubscribe
+	//	*Request_Shutdown
+	Request       isRequest_Request `protobuf_oneof:"request"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	mi := &file_terminal_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Request) GetRequest() isRequest_Request {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *Request) GetAuth() *AuthRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Auth); ok {
+			return x.Auth
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetOpen() *OpenRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Open); ok {
+			return x.Open
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetWrite() *WriteRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Write); ok {
+			return x.Write
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetResize() *ResizeRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Resize); ok {
+			return x.Resize
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetClose() *CloseRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Close); ok {
+			return x.Close
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetSnapshot() *SnapshotRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetWait() *WaitRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Wait); ok {
+			return x.Wait
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetSearch() *SearchRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Search); ok {
+			return x.Search
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetExport() *ExportRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Export); ok {
+			return x.Export
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetPing() *PingRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Ping); ok {
+			return x.Ping
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetStats() *StatsRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Stats); ok {
+			return x.Stats
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetKillProcess() *KillProcessRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_KillProcess); ok {
+			return x.KillProcess
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetEof() *EofRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Eof); ok {
+			return x.Eof
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetBroadcast() *BroadcastRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Broadcast); ok {
+			return x.Broadcast
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetList() *ListRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_List); ok {
+			return x.List
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetCloseAll() *CloseAllRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_CloseAll); ok {
+			return x.CloseAll
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetListShells() *ListShellsRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_ListShells); ok {
+			return x.ListShells
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetProbe() *ProbeRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Probe); ok {
+			return x.Probe
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetTmuxPanes() *TmuxPanesRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_TmuxPanes); ok {
+			return x.TmuxPanes
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetGetSize() *GetSizeRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_GetSize); ok {
+			return x.GetSize
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetFocus() *FocusRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Focus); ok {
+			return x.Focus
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetUpgrade() *UpgradeRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Upgrade); ok {
+			return x.Upgrade
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetHello() *ClientHelloRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetSubscribe() *SubscribeRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Subscribe); ok {
+			return x.Subscribe
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetUnsubscribe() *UnsubscribeRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Unsubscribe); ok {
+			return x.Unsubscribe
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetShutdown() *ShutdownRequest {
+	if x != nil {
+		if x, ok := x.Request.(*Request_Shutdown); ok {
+			return x.Shutdown
+		}
+	}
+	return nil
+}
+
+type isRequest_Request interface {
+	isRequest_Request()
+}
+
+type Request_Auth struct {
+	Auth *AuthRequest `protobuf:"bytes,356593391,opt,name=auth,proto3,oneof"`
+}
+
+type Request_Open struct {
+	Open *OpenRequest `protobuf:"bytes,325117865,opt,name=open,proto3,oneof"`
+}
+
+type Request_Write struct {
+	Write *WriteRequest `protobuf:"bytes,505967644,opt,name=write,proto3,oneof"`
+}
+
+type Request_Resize struct {
+	Resize *ResizeRequest `protobuf:"bytes,293526657,opt,name=resize,proto3,oneof"`
+}
+
+type Request_Close struct {
+	Close *CloseRequest `protobuf:"bytes,130799459,opt,name=close,proto3,oneof"`
+}
+
+type Request_Snapshot struct {
+	Snapshot *SnapshotRequest `protobuf:"bytes,178530253,opt,name=snapshot,proto3,oneof"`
+}
+
+type Request_Wait struct {
+	Wait *WaitRequest `protobuf:"bytes,154129216,opt,name=wait,proto3,oneof"`
+}
+
+type Request_Search struct {
+	Search *SearchRequest `protobuf:"bytes,3452745,opt,name=search,proto3,oneof"`
+}
+
+type Request_Export struct {
+	Export *ExportRequest `protobuf:"bytes,453672371,opt,name=export,proto3,oneof"`
+}
+
+type Request_Ping struct {
+	Ping *PingRequest `protobuf:"bytes,375275177,opt,name=ping,proto3,oneof"`
+}
+
+type Request_Stats struct {
+	Stats *StatsRequest `protobuf:"bytes,171070828,opt,name=stats,proto3,oneof"`
+}
+
+type Request_KillProcess struct {
+	KillProcess *KillProcessRequest `protobuf:"bytes,67479313,opt,name=kill_process,json=killProcess,proto3,oneof"`
+}
+
+type Request_Eof struct {
+	Eof *EofRequest `protobuf:"bytes,177368385,opt,name=eof,proto3,oneof"`
+}
+
+type Request_Broadcast struct {
+	Broadcast *BroadcastRequest `protobuf:"bytes,47839956,opt,name=broadcast,proto3,oneof"`
+}
+
+type Request_List struct {
+	List *ListRequest `protobuf:"bytes,217818785,opt,name=list,proto3,oneof"`
+}
+
+type Request_CloseAll struct {
+	CloseAll *CloseAllRequest `protobuf:"bytes,252391505,opt,name=close_all,json=closeAll,proto3,oneof"`
+}
+
+type Request_ListShells struct {
+	ListShells *ListShellsRequest `protobuf:"bytes,357526655,opt,name=list_shells,json=listShells,proto3,oneof"`
+}
+
+type Request_Probe struct {
+	Probe *ProbeRequest `protobuf:"bytes,501474865,opt,name=probe,proto3,oneof"`
+}
+
+type Request_TmuxPanes struct {
+	TmuxPanes *TmuxPanesRequest `protobuf:"bytes,50396025,opt,name=tmux_panes,json=tmuxPanes,proto3,oneof"`
+}
+
+type Request_GetSize struct {
+	GetSize *GetSizeRequest `protobuf:"bytes,192345583,opt,name=get_size,json=getSize,proto3,oneof"`
+}
+
+type Request_Focus struct {
+	Focus *FocusRequest `protobuf:"bytes,337678899,opt,name=focus,proto3,oneof"`
+}
+
+type Request_Upgrade struct {
+	Upgrade *UpgradeRequest `protobuf:"bytes,479850327,opt,name=upgrade,proto3,oneof"`
+}
+
+type Request_Hello struct {
+	Hello *ClientHelloRequest `protobuf:"bytes,262149899,opt,name=hello,proto3,oneof"`
+}
+
+type Request_Subscribe struct {
+	Subscribe *SubscribeRequest `protobuf:"bytes,262151875,opt,name=subscribe,proto3,oneof"`
+}
+
+type Request_Unsubscribe struct {
+	Unsubscribe *UnsubscribeRequest `protobuf:"bytes,432107414,opt,name=unsubscribe,proto3,oneof"`
+}
+
+type Request_Shutdown struct {
+	Shutdown *ShutdownRequest `protobuf:"bytes,404937547,opt,name=shutdown,proto3,oneof"`
+}
+
+func (*Request_Auth) isRequest_Request() {}
+
+func (*Request_Open) isRequest_Request() {}
+
+func (*Request_Write) isRequest_Request() {}
+
+func (*Request_Resize) isRequest_Request() {}
+
+func (*Request_Close) isRequest_Request() {}
+
+func (*Request_Snapshot) isRequest_Request() {}
+
+func (*Request_Wait) isRequest_Request() {}
+
+func (*Request_Search) isRequest_Request() {}
+
+func (*Request_Export) isRequest_Request() {}
+
+func (*Request_Ping) isRequest_Request() {}
+
+func (*Request_Stats) isRequest_Request() {}
+
+func (*Request_KillProcess) isRequest_Request() {}
+
+func (*Request_Eof) isRequest_Request() {}
+
+func (*Request_Broadcast) isRequest_Request() {}
+
+func (*Request_List) isRequest_Request() {}
+
+func (*Request_CloseAll) isRequest_Request() {}
+
+func (*Request_ListShells) isRequest_Request() {}
+
+func (*Request_Probe) isRequest_Request() {}
+
+func (*Request_TmuxPanes) isRequest_Request() {}
+
+func (*Request_GetSize) isRequest_Request() {}
+
+func (*Request_Focus) isRequest_Request() {}
+
+func (*Request_Upgrade) isRequest_Request() {}
+
+func (*Request_Hello) isRequest_Request() {}
+
+func (*Request_Subscribe) isRequest_Request() {}
+
+func (*Request_Unsubscribe) isRequest_Request() {}
+
+func (*Request_Shutdown) isRequest_Request() {}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*Event_Hello
+	//	*Event_Ready
+	//	*Event_Output
+	//	*Event_Exit
+	//	*Event_Error
+	//	*Event_Pong
+	//	*Event_ShutdownAck
+	//	*Event_Clipboard
+	//	*Event_Progress
+	//	*Event_Snapshot
+	//	*Event_Matched
+	//	*Event_SearchResults
+	//	*Event_Export
+	//	*Event_Backpressure
+	//	*Event_Closed
+	//	*Event_WillClose
+	//	*Event_Stats
+	//	*Event_Heartbeat
+	//	*Event_List
+	//	*Event_ClosedAll
+	//	*Event_Shells
+	//	*Event_Probe
+	//	*Event_TmuxPanes
+	//	*Event_Size
+	//	*Event_ScreenMode
+	//	*Event_Upgrade
+	//	*Event_HelloAck
+	//	*Event_Subscriptions
+	Event         isEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_terminal_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Event) GetEvent() isEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Event) GetHello() *HelloEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetReady() *ReadyEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Ready); ok {
+			return x.Ready
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetOutput() *OutputEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetExit() *ExitEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Exit); ok {
+			return x.Exit
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetError() *ErrorEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetPong() *PongEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Pong); ok {
+			return x.Pong
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetShutdownAck() *ShutdownAckEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_ShutdownAck); ok {
+			return x.ShutdownAck
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetClipboard() *ClipboardEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Clipboard); ok {
+			return x.Clipboard
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetProgress() *ProgressEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetSnapshot() *SnapshotEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetMatched() *MatchedEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Matched); ok {
+			return x.Matched
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetSearchResults() *SearchResultsEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_SearchResults); ok {
+			return x.SearchResults
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetExport() *ExportEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Export); ok {
+			return x.Export
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetBackpressure() *BackpressureEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Backpressure); ok {
+			return x.Backpressure
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetClosed() *ClosedEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Closed); ok {
+			return x.Closed
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetWillClose() *WillCloseEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_WillClose); ok {
+			return x.WillClose
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetStats() *StatsEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Stats); ok {
+			return x.Stats
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetHeartbeat() *HeartbeatEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetList() *ListEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_List); ok {
+			return x.List
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetClosedAll() *ClosedAllEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_ClosedAll); ok {
+			return x.ClosedAll
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetShells() *ShellsEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Shells); ok {
+			return x.Shells
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetProbe() *ProbeEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Probe); ok {
+			return x.Probe
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetTmuxPanes() *TmuxPanesEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_TmuxPanes); ok {
+			return x.TmuxPanes
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetSize() *SizeEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Size); ok {
+			return x.Size
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetScreenMode() *ScreenModeEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_ScreenMode); ok {
+			return x.ScreenMode
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetUpgrade() *UpgradeEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Upgrade); ok {
+			return x.Upgrade
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetHelloAck() *HelloAckEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_HelloAck); ok {
+			return x.HelloAck
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetSubscriptions() *SubscriptionsEvent {
+	if x != nil {
+		if x, ok := x.Event.(*Event_Subscriptions); ok {
+			return x.Subscriptions
+		}
+	}
+	return nil
+}
+
+type isEvent_Event interface {
+	isEvent_Event()
+}
+
+type Event_Hello struct {
+	Hello *HelloEvent `protobuf:"bytes,262149899,opt,name=hello,proto3,oneof"`
+}
+
+type Event_Ready struct {
+	Ready *ReadyEvent `protobuf:"bytes,101710196,opt,name=ready,proto3,oneof"`
+}
+
+type Event_Output struct {
+	Output *OutputEvent `protobuf:"bytes,430606212,opt,name=output,proto3,oneof"`
+}
+
+type Event_Exit struct {
+	Exit *ExitEvent `protobuf:"bytes,233782629,opt,name=exit,proto3,oneof"`
+}
+
+type Event_Error struct {
+	Error *ErrorEvent `protobuf:"bytes,26354577,opt,name=error,proto3,oneof"`
+}
+
+type Event_Pong struct {
+	Pong *PongEvent `protobuf:"bytes,450645815,opt,name=pong,proto3,oneof"`
+}
+
+type Event_ShutdownAck struct {
+	ShutdownAck *ShutdownAckEvent `protobuf:"bytes,22988293,opt,name=shutdown_ack,json=shutdownAck,proto3,oneof"`
+}
+
+type Event_Clipboard struct {
+	Clipboard *ClipboardEvent `protobuf:"bytes,488638385,opt,name=clipboard,proto3,oneof"`
+}
+
+type Event_Progress struct {
+	Progress *ProgressEvent `protobuf:"bytes,185233702,opt,name=progress,proto3,oneof"`
+}
+
+type Event_Snapshot struct {
+	Snapshot *SnapshotEvent `protobuf:"bytes,178530253,opt,name=snapshot,proto3,oneof"`
+}
+
+type Event_Matched struct {
+	Matched *MatchedEvent `protobuf:"bytes,133596951,opt,name=matched,proto3,oneof"`
+}
+
+type Event_SearchResults struct {
+	SearchResults *SearchResultsEvent `protobuf:"bytes,161429016,opt,name=search_results,json=searchResults,proto3,oneof"`
+}
+
+type Event_Export struct {
+	Export *ExportEvent `protobuf:"bytes,453672371,opt,name=export,proto3,oneof"`
+}
+
+type Event_Backpressure struct {
+	Backpressure *BackpressureEvent `protobuf:"bytes,176597181,opt,name=backpressure,proto3,oneof"`
+}
+
+type Event_Closed struct {
+	Closed *ClosedEvent `protobuf:"bytes,200327621,opt,name=closed,proto3,oneof"`
+}
+
+type Event_WillClose struct {
+	WillClose *WillCloseEvent `protobuf:"bytes,242309458,opt,name=will_close,json=willClose,proto3,oneof"`
+}
+
+type Event_Stats struct {
+	Stats *StatsEvent `protobuf:"bytes,171070828,opt,name=stats,proto3,oneof"`
+}
+
+type Event_Heartbeat struct {
+	Heartbeat *HeartbeatEvent `protobuf:"bytes,127932631,opt,name=heartbeat,proto3,oneof"`
+}
+
+type Event_List struct {
+	List *ListEvent `protobuf:"bytes,217818785,opt,name=list,proto3,oneof"`
+}
+
+type Event_ClosedAll struct {
+	ClosedAll *ClosedAllEvent `protobuf:"bytes,408202173,opt,name=closed_all,json=closedAll,proto3,oneof"`
+}
+
+type Event_Shells struct {
+	Shells *ShellsEvent `protobuf:"bytes,415362582,opt,name=shells,proto3,oneof"`
+}
+
+type Event_Probe struct {
+	Probe *ProbeEvent `protobuf:"bytes,501474865,opt,name=probe,proto3,oneof"`
+}
+
+type Event_TmuxPanes struct {
+	TmuxPanes *TmuxPanesEvent `protobuf:"bytes,46434031,opt,name=tmux_panes,json=tmuxPanes,proto3,oneof"`
+}
+
+type Event_Size struct {
+	Size *SizeEvent `protobuf:"bytes,60913052,opt,name=size,proto3,oneof"`
+}
+
+type Event_ScreenMode struct {
+	ScreenMode *ScreenModeEvent `protobuf:"bytes,462177713,opt,name=screen_mode,json=screenMode,proto3,oneof"`
+}
+
+type Event_Upgrade struct {
+	Upgrade *UpgradeEvent `protobuf:"bytes,479850327,opt,name=upgrade,proto3,oneof"`
+}
+
+type Event_HelloAck struct {
+	HelloAck *HelloAckEvent `protobuf:"bytes,228983301,opt,name=hello_ack,json=helloAck,proto3,oneof"`
+}
+
+type Event_Subscriptions struct {
+	Subscriptions *SubscriptionsEvent `protobuf:"bytes,196762245,opt,name=subscriptions,proto3,oneof"`
+}
+
+func (*Event_Hello) isEvent_Event() {}
+
+func (*Event_Ready) isEvent_Event() {}
+
+func (*Event_Output) isEvent_Event() {}
+
+func (*Event_Exit) isEvent_Event() {}
+
+func (*Event_Error) isEvent_Event() {}
+
+func (*Event_Pong) isEvent_Event() {}
+
+func (*Event_ShutdownAck) isEvent_Event() {}
+
+func (*Event_Clipboard) isEvent_Event() {}
+
+func (*Event_Progress) isEvent_Event() {}
+
+func (*Event_Snapshot) isEvent_Event() {}
+
+func (*Event_Matched) isEvent_Event() {}
+
+func (*Event_SearchResults) isEvent_Event() {}
+
+func (*Event_Export) isEvent_Event() {}
+
+func (*Event_Backpressure) isEvent_Event() {}
+
+func (*Event_Closed) isEvent_Event() {}
+
+func (*Event_WillClose) isEvent_Event() {}
+
+func (*Event_Stats) isEvent_Event() {}
+
+func (*Event_Heartbeat) isEvent_Event() {}
+
+func (*Event_List) isEvent_Event() {}
+
+func (*Event_ClosedAll) isEvent_Event() {}
+
+func (*Event_Shells) isEvent_Event() {}
+
+func (*Event_Probe) isEvent_Event() {}
+
+func (*Event_TmuxPanes) isEvent_Event() {}
+
+func (*Event_Size) isEvent_Event() {}
+
+func (*Event_ScreenMode) isEvent_Event() {}
+
+func (*Event_Upgrade) isEvent_Event() {}
+
+func (*Event_HelloAck) isEvent_Event() {}
+
+func (*Event_Subscriptions) isEvent_Event() {}
+
+type AuthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,343634130,opt,name=token,proto3" json:"token,omitempty"`
+	ClientId      string                 `protobuf:"bytes,5406435,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthRequest) Reset() {
+	*x = AuthRequest{}
+	mi := &file_terminal_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthRequest) ProtoMessage() {}
+
+func (x *AuthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthRequest.ProtoReflect.Descriptor instead.
+func (*AuthRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AuthRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AuthRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+type OpenRequest struct {
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId              string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId               string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Cwd                     string                 `protobuf:"bytes,441595745,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Shell                   string                 `protobuf:"bytes,300042785,opt,name=shell,proto3" json:"shell,omitempty"`
+	Cols                    int64                  `protobuf:"varint,390521350,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows                    int64                  `protobuf:"varint,171462616,opt,name=rows,proto3" json:"rows,omitempty"`
+	Env                     map[string]string      `protobuf:"bytes,412075060,rep,name=env,proto3" json:"env,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	EnvProfiles             []string               `protobuf:"bytes,295602934,rep,name=env_profiles,json=envProfiles,proto3" json:"env_profiles,omitempty"`
+	PathPrepend             []string               `protobuf:"bytes,482931810,rep,name=path_prepend,json=pathPrepend,proto3" json:"path_prepend,omitempty"`
+	PathAppend              []string               `protobuf:"bytes,339072002,rep,name=path_append,json=pathAppend,proto3" json:"path_append,omitempty"`
+	Compression             string                 `protobuf:"bytes,450663477,opt,name=compression,proto3" json:"compression,omitempty"`
+	OutputEncoding          string                 `protobuf:"bytes,487247249,opt,name=output_encoding,json=outputEncoding,proto3" json:"output_encoding,omitempty"`
+	OutputFilter            string                 `protobuf:"bytes,226603366,opt,name=output_filter,json=outputFilter,proto3" json:"output_filter,omitempty"`
+	Timestamps              bool                   `protobuf:"varint,361346896,opt,name=timestamps,proto3" json:"timestamps,omitempty"`
+	ForceUtf8               bool                   `protobuf:"varint,487545891,opt,name=force_utf8,json=forceUtf8,proto3" json:"force_utf8,omitempty"`
+	OutputCodePage          int64                  `protobuf:"varint,238568498,opt,name=output_code_page,json=outputCodePage,proto3" json:"output_code_page,omitempty"`
+	SuppressClipboard       bool                   `protobuf:"varint,484207328,opt,name=suppress_clipboard,json=suppressClipboard,proto3" json:"suppress_clipboard,omitempty"`
+	Emulate                 bool                   `protobuf:"varint,351120186,opt,name=emulate,proto3" json:"emulate,omitempty"`
+	ScrollbackLines         int64                  `protobuf:"varint,421091922,opt,name=scrollback_lines,json=scrollbackLines,proto3" json:"scrollback_lines,omitempty"`
+	Checkpoint              bool                   `protobuf:"varint,401020543,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"`
+	ReadBufferBytes         int64                  `protobuf:"varint,373328910,opt,name=read_buffer_bytes,json=readBufferBytes,proto3" json:"read_buffer_bytes,omitempty"`
+	Backpressure            string                 `protobuf:"bytes,176597181,opt,name=backpressure,proto3" json:"backpressure,omitempty"`
+	BackpressureBufferBytes int64                  `protobuf:"varint,508292358,opt,name=backpressure_buffer_bytes,json=backpressureBufferBytes,proto3" json:"backpressure_buffer_bytes,omitempty"`
+	IdleTimeoutMs           int64                  `protobuf:"varint,411394158,opt,name=idle_timeout_ms,json=idleTimeoutMs,proto3" json:"idle_timeout_ms,omitempty"`
+	IdleWarningMs           int64                  `protobuf:"varint,429376961,opt,name=idle_warning_ms,json=idleWarningMs,proto3" json:"idle_warning_ms,omitempty"`
+	InheritEnv              *bool                  `protobuf:"varint,91107663,opt,name=inherit_env,json=inheritEnv,proto3,oneof" json:"inherit_env,omitempty"`
+	CwdFallback             bool                   `protobuf:"varint,183869103,opt,name=cwd_fallback,json=cwdFallback,proto3" json:"cwd_fallback,omitempty"`
+	UncCwd                  string                 `protobuf:"bytes,350003179,opt,name=unc_cwd,json=uncCwd,proto3" json:"unc_cwd,omitempty"`
+	StartupTimeoutMs        int64                  `protobuf:"varint,328102425,opt,name=startup_timeout_ms,json=startupTimeoutMs,proto3" json:"startup_timeout_ms,omitempty"`
+	InitialCommand          string                 `protobuf:"bytes,143522512,opt,name=initial_command,json=initialCommand,proto3" json:"initial_command,omitempty"`
+	InitialCommandDelayMs   int64                  `protobuf:"varint,36850719,opt,name=initial_command_delay_ms,json=initialCommandDelayMs,proto3" json:"initial_command_delay_ms,omitempty"`
+	Privilege               string                 `protobuf:"bytes,201010412,opt,name=privilege,proto3" json:"privilege,omitempty"`
+	Elevated                bool                   `protobuf:"varint,2193563,opt,name=elevated,proto3" json:"elevated,omitempty"`
+	NoWindow                bool                   `protobuf:"varint,391468886,opt,name=no_window,json=noWindow,proto3" json:"no_window,omitempty"`
+	Desktop                 string                 `protobuf:"bytes,495102611,opt,name=desktop,proto3" json:"desktop,omitempty"`
+	SessionId               *uint32                `protobuf:"varint,505903130,opt,name=session_id,json=sessionId,proto3,oneof" json:"session_id,omitempty"`
+	Backend                 string                 `protobuf:"bytes,66038031,opt,name=backend,proto3" json:"backend,omitempty"`
+	Mock                    *MockOptions           `protobuf:"bytes,243043627,opt,name=mock,proto3" json:"mock,omitempty"`
+	Tmux                    *TmuxOptions           `protobuf:"bytes,516321263,opt,name=tmux,proto3" json:"tmux,omitempty"`
+	Containerd              *ContainerdOptions     `protobuf:"bytes,133770068,opt,name=containerd,proto3" json:"containerd,omitempty"`
+	Serial                  *SerialOptions         `protobuf:"bytes,466612313,opt,name=serial,proto3" json:"serial,omitempty"`
+	Telnet                  *TelnetOptions         `protobuf:"bytes,173649245,opt,name=telnet,proto3" json:"telnet,omitempty"`
+	Python                  *PythonOptions         `protobuf:"bytes,265244087,opt,name=python,proto3" json:"python,omitempty"`
+	VisualStudio            *VisualStudioOptions   `protobuf:"bytes,339626183,opt,name=visual_studio,json=visualStudio,proto3" json:"visual_studio,omitempty"`
+	MaxMemoryMb             int64                  `protobuf:"varint,438057725,opt,name=max_memory_mb,json=maxMemoryMb,proto3" json:"max_memory_mb,omitempty"`
+	CpuRatePercent          int64                  `protobuf:"varint,420218344,opt,name=cpu_rate_percent,json=cpuRatePercent,proto3" json:"cpu_rate_percent,omitempty"`
+	Priority                string                 `protobuf:"bytes,350644649,opt,name=priority,proto3" json:"priority,omitempty"`
+	Affinity                uint64                 `protobuf:"varint,230995193,opt,name=affinity,proto3" json:"affinity,omitempty"`
+	InheritCursor           bool                   `protobuf:"varint,506013410,opt,name=inherit_cursor,json=inheritCursor,proto3" json:"inherit_cursor,omitempty"`
+	ConptyFlags             uint32                 `protobuf:"varint,348081821,opt,name=conpty_flags,json=conptyFlags,proto3" json:"conpty_flags,omitempty"`
+	Traceparent             string                 `protobuf:"bytes,202132894,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
+	Tags                    map[string]string      `protobuf:"bytes,337206432,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	ExtraArgs               []string               `protobuf:"bytes,165818072,rep,name=extra_args,json=extraArgs,proto3" json:"extra_args,omitempty"`
+	NoProfile               bool                   `protobuf:"varint,147101815,opt,name=no_profile,json=noProfile,proto3" json:"no_profile,omitempty"`
+	ExecutionPolicy         string                 `protobuf:"bytes,285975023,opt,name=execution_policy,json=executionPolicy,proto3" json:"execution_policy,omitempty"`
+	LoginEnv                bool                   `protobuf:"varint,119350293,opt,name=login_env,json=loginEnv,proto3" json:"login_env,omitempty"`
+	AttachIfExists          bool                   `protobuf:"varint,450170003,opt,name=attach_if_exists,json=attachIfExists,proto3" json:"attach_if_exists,omitempty"`
+	ResumeFromSeq           uint64                 `protobuf:"varint,156183797,opt,name=resume_from_seq,json=resumeFromSeq,proto3" json:"resume_from_seq,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
+}
+
+func (x *OpenRequest) Reset() {
+	*x = OpenRequest{}
+	mi := &file_terminal_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenRequest) ProtoMessage() {}
+
+func (x *OpenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenRequest.ProtoReflect.Descriptor instead.
+func (*OpenRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OpenRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetShell() string {
+	if x != nil {
+		return x.Shell
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetCols() int64 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetRows() int64 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetEnv() map[string]string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetEnvProfiles() []string {
+	if x != nil {
+		return x.EnvProfiles
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetPathPrepend() []string {
+	if x != nil {
+		return x.PathPrepend
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetPathAppend() []string {
+	if x != nil {
+		return x.PathAppend
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetOutputEncoding() string {
+	if x != nil {
+		return x.OutputEncoding
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetOutputFilter() string {
+	if x != nil {
+		return x.OutputFilter
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetTimestamps() bool {
+	if x != nil {
+		return x.Timestamps
+	}
+	return false
+}
+
+func (x *OpenRequest) GetForceUtf8() bool {
+	if x != nil {
+		return x.ForceUtf8
+	}
+	return false
+}
+
+func (x *OpenRequest) GetOutputCodePage() int64 {
+	if x != nil {
+		return x.OutputCodePage
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetSuppressClipboard() bool {
+	if x != nil {
+		return x.SuppressClipboard
+	}
+	return false
+}
+
+func (x *OpenRequest) GetEmulate() bool {
+	if x != nil {
+		return x.Emulate
+	}
+	return false
+}
+
+func (x *OpenRequest) GetScrollbackLines() int64 {
+	if x != nil {
+		return x.ScrollbackLines
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetCheckpoint() bool {
+	if x != nil {
+		return x.Checkpoint
+	}
+	return false
+}
+
+func (x *OpenRequest) GetReadBufferBytes() int64 {
+	if x != nil {
+		return x.ReadBufferBytes
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetBackpressure() string {
+	if x != nil {
+		return x.Backpressure
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetBackpressureBufferBytes() int64 {
+	if x != nil {
+		return x.BackpressureBufferBytes
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetIdleTimeoutMs() int64 {
+	if x != nil {
+		return x.IdleTimeoutMs
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetIdleWarningMs() int64 {
+	if x != nil {
+		return x.IdleWarningMs
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetInheritEnv() bool {
+	if x != nil && x.InheritEnv != nil {
+		return *x.InheritEnv
+	}
+	return false
+}
+
+func (x *OpenRequest) GetCwdFallback() bool {
+	if x != nil {
+		return x.CwdFallback
+	}
+	return false
+}
+
+func (x *OpenRequest) GetUncCwd() string {
+	if x != nil {
+		return x.UncCwd
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetStartupTimeoutMs() int64 {
+	if x != nil {
+		return x.StartupTimeoutMs
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetInitialCommand() string {
+	if x != nil {
+		return x.InitialCommand
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetInitialCommandDelayMs() int64 {
+	if x != nil {
+		return x.InitialCommandDelayMs
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetPrivilege() string {
+	if x != nil {
+		return x.Privilege
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetElevated() bool {
+	if x != nil {
+		return x.Elevated
+	}
+	return false
+}
+
+func (x *OpenRequest) GetNoWindow() bool {
+	if x != nil {
+		return x.NoWindow
+	}
+	return false
+}
+
+func (x *OpenRequest) GetDesktop() string {
+	if x != nil {
+		return x.Desktop
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetSessionId() uint32 {
+	if x != nil && x.SessionId != nil {
+		return *x.SessionId
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetMock() *MockOptions {
+	if x != nil {
+		return x.Mock
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetTmux() *TmuxOptions {
+	if x != nil {
+		return x.Tmux
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetContainerd() *ContainerdOptions {
+	if x != nil {
+		return x.Containerd
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetSerial() *SerialOptions {
+	if x != nil {
+		return x.Serial
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetTelnet() *TelnetOptions {
+	if x != nil {
+		return x.Telnet
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetPython() *PythonOptions {
+	if x != nil {
+		return x.Python
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetVisualStudio() *VisualStudioOptions {
+	if x != nil {
+		return x.VisualStudio
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetMaxMemoryMb() int64 {
+	if x != nil {
+		return x.MaxMemoryMb
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetCpuRatePercent() int64 {
+	if x != nil {
+		return x.CpuRatePercent
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetAffinity() uint64 {
+	if x != nil {
+		return x.Affinity
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetInheritCursor() bool {
+	if x != nil {
+		return x.InheritCursor
+	}
+	return false
+}
+
+func (x *OpenRequest) GetConptyFlags() uint32 {
+	if x != nil {
+		return x.ConptyFlags
+	}
+	return 0
+}
+
+func (x *OpenRequest) GetTraceparent() string {
+	if x != nil {
+		return x.Traceparent
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetExtraArgs() []string {
+	if x != nil {
+		return x.ExtraArgs
+	}
+	return nil
+}
+
+func (x *OpenRequest) GetNoProfile() bool {
+	if x != nil {
+		return x.NoProfile
+	}
+	return false
+}
+
+func (x *OpenRequest) GetExecutionPolicy() string {
+	if x != nil {
+		return x.ExecutionPolicy
+	}
+	return ""
+}
+
+func (x *OpenRequest) GetLoginEnv() bool {
+	if x != nil {
+		return x.LoginEnv
+	}
+	return false
+}
+
+func (x *OpenRequest) GetAttachIfExists() bool {
+	if x != nil {
+		return x.AttachIfExists
+	}
+	return false
+}
+
+func (x *OpenRequest) GetResumeFromSeq() uint64 {
+	if x != nil {
+		return x.ResumeFromSeq
+	}
+	return 0
+}
+
+type WriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Data          string                 `protobuf:"bytes,410322309,opt,name=data,proto3" json:"data,omitempty"`
+	Encoding      string                 `protobuf:"bytes,519939760,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Keys          []string               `protobuf:"bytes,424442317,rep,name=keys,proto3" json:"keys,omitempty"`
+	Sanitize      bool                   `protobuf:"varint,79640914,opt,name=sanitize,proto3" json:"sanitize,omitempty"`
+	More          bool                   `protobuf:"varint,139965152,opt,name=more,proto3" json:"more,omitempty"`
+	Traceparent   string                 `protobuf:"bytes,202132894,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteRequest) Reset() {
+	*x = WriteRequest{}
+	mi := &file_terminal_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteRequest) ProtoMessage() {}
+
+func (x *WriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WriteRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *WriteRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *WriteRequest) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *WriteRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *WriteRequest) GetSanitize() bool {
+	if x != nil {
+		return x.Sanitize
+	}
+	return false
+}
+
+func (x *WriteRequest) GetMore() bool {
+	if x != nil {
+		return x.More
+	}
+	return false
+}
+
+func (x *WriteRequest) GetTraceparent() string {
+	if x != nil {
+		return x.Traceparent
+	}
+	return ""
+}
+
+type ResizeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Cols          int64                  `protobuf:"varint,390521350,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows          int64                  `protobuf:"varint,171462616,opt,name=rows,proto3" json:"rows,omitempty"`
+	Traceparent   string                 `protobuf:"bytes,202132894,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResizeRequest) Reset() {
+	*x = ResizeRequest{}
+	mi := &file_terminal_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResizeRequest) ProtoMessage() {}
+
+func (x *ResizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResizeRequest.ProtoReflect.Descriptor instead.
+func (*ResizeRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ResizeRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ResizeRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ResizeRequest) GetCols() int64 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *ResizeRequest) GetRows() int64 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *ResizeRequest) GetTraceparent() string {
+	if x != nil {
+		return x.Traceparent
+	}
+	return ""
+}
+
+type CloseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Force         bool                   `protobuf:"varint,430620932,opt,name=force,proto3" json:"force,omitempty"`
+	GraceMs       int64                  `protobuf:"varint,188495507,opt,name=grace_ms,json=graceMs,proto3" json:"grace_ms,omitempty"`
+	Traceparent   string                 `protobuf:"bytes,202132894,opt,name=traceparent,proto3" json:"traceparent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseRequest) Reset() {
+	*x = CloseRequest{}
+	mi := &file_terminal_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseRequest) ProtoMessage() {}
+
+func (x *CloseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseRequest.ProtoReflect.Descriptor instead.
+func (*CloseRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CloseRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *CloseRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *CloseRequest) GetGraceMs() int64 {
+	if x != nil {
+		return x.GraceMs
+	}
+	return 0
+}
+
+func (x *CloseRequest) GetTraceparent() string {
+	if x != nil {
+		return x.Traceparent
+	}
+	return ""
+}
+
+type SnapshotRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId        string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	IncludeScrollback bool                   `protobuf:"varint,358172997,opt,name=include_scrollback,json=includeScrollback,proto3" json:"include_scrollback,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	mi := &file_terminal_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SnapshotRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *SnapshotRequest) GetIncludeScrollback() bool {
+	if x != nil {
+		return x.IncludeScrollback
+	}
+	return false
+}
+
+type WaitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Pattern       string                 `protobuf:"bytes,121538505,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Regex         bool                   `protobuf:"varint,184648056,opt,name=regex,proto3" json:"regex,omitempty"`
+	IgnoreCase    bool                   `protobuf:"varint,295987455,opt,name=ignore_case,json=ignoreCase,proto3" json:"ignore_case,omitempty"`
+	TimeoutMs     int64                  `protobuf:"varint,24354692,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WaitRequest) Reset() {
+	*x = WaitRequest{}
+	mi := &file_terminal_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WaitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitRequest) ProtoMessage() {}
+
+func (x *WaitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitRequest.ProtoReflect.Descriptor instead.
+func (*WaitRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WaitRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *WaitRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *WaitRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *WaitRequest) GetRegex() bool {
+	if x != nil {
+		return x.Regex
+	}
+	return false
+}
+
+func (x *WaitRequest) GetIgnoreCase() bool {
+	if x != nil {
+		return x.IgnoreCase
+	}
+	return false
+}
+
+func (x *WaitRequest) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Pattern       string                 `protobuf:"bytes,121538505,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Regex         bool                   `protobuf:"varint,184648056,opt,name=regex,proto3" json:"regex,omitempty"`
+	IgnoreCase    bool                   `protobuf:"varint,295987455,opt,name=ignore_case,json=ignoreCase,proto3" json:"ignore_case,omitempty"`
+	MaxResults    int64                  `protobuf:"varint,465270001,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`
+	ContextLines  int64                  `protobuf:"varint,332084023,opt,name=context_lines,json=contextLines,proto3" json:"context_lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_terminal_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetRegex() bool {
+	if x != nil {
+		return x.Regex
+	}
+	return false
+}
+
+func (x *SearchRequest) GetIgnoreCase() bool {
+	if x != nil {
+		return x.IgnoreCase
+	}
+	return false
+}
+
+func (x *SearchRequest) GetMaxResults() int64 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetContextLines() int64 {
+	if x != nil {
+		return x.ContextLines
+	}
+	return 0
+}
+
+type ExportRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId     string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId      string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Format         string                 `protobuf:"bytes,429873682,opt,name=format,proto3" json:"format,omitempty"`
+	Path           string                 `protobuf:"bytes,76075990,opt,name=path,proto3" json:"path,omitempty"`
+	Redact         bool                   `protobuf:"varint,140264264,opt,name=redact,proto3" json:"redact,omitempty"`
+	RedactPatterns []string               `protobuf:"bytes,99661059,rep,name=redact_patterns,json=redactPatterns,proto3" json:"redact_patterns,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_terminal_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ExportRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ExportRequest) GetRedact() bool {
+	if x != nil {
+		return x.Redact
+	}
+	return false
+}
+
+func (x *ExportRequest) GetRedactPatterns() []string {
+	if x != nil {
+		return x.RedactPatterns
+	}
+	return nil
+}
+
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         string                 `protobuf:"bytes,420145912,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,310749667,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	RttMs         float64                `protobuf:"fixed64,276664881,opt,name=rtt_ms,json=rttMs,proto3" json:"rtt_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_terminal_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PingRequest) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *PingRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *PingRequest) GetRttMs() float64 {
+	if x != nil {
+		return x.RttMs
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_terminal_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{12}
+}
+
+type KillProcessRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Pid           int64                  `protobuf:"varint,155334050,opt,name=pid,proto3" json:"pid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KillProcessRequest) Reset() {
+	*x = KillProcessRequest{}
+	mi := &file_terminal_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KillProcessRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KillProcessRequest) ProtoMessage() {}
+
+func (x *KillProcessRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KillProcessRequest.ProtoReflect.Descriptor instead.
+func (*KillProcessRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *KillProcessRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *KillProcessRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *KillProcessRequest) GetPid() int64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+type EofRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Sequence      string                 `protobuf:"bytes,474254248,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EofRequest) Reset() {
+	*x = EofRequest{}
+	mi := &file_terminal_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EofRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EofRequest) ProtoMessage() {}
+
+func (x *EofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EofRequest.ProtoReflect.Descriptor instead.
+func (*EofRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *EofRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *EofRequest) GetSequence() string {
+	if x != nil {
+		return x.Sequence
+	}
+	return ""
+}
+
+type BroadcastRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	TerminalIds   []string               `protobuf:"bytes,320339061,rep,name=terminal_ids,json=terminalIds,proto3" json:"terminal_ids,omitempty"`
+	Selector      map[string]string      `protobuf:"bytes,335249468,rep,name=selector,proto3" json:"selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Data          string                 `protobuf:"bytes,410322309,opt,name=data,proto3" json:"data,omitempty"`
+	Encoding      string                 `protobuf:"bytes,519939760,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Keys          []string               `protobuf:"bytes,424442317,rep,name=keys,proto3" json:"keys,omitempty"`
+	Sanitize      bool                   `protobuf:"varint,79640914,opt,name=sanitize,proto3" json:"sanitize,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BroadcastRequest) Reset() {
+	*x = BroadcastRequest{}
+	mi := &file_terminal_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BroadcastRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BroadcastRequest) ProtoMessage() {}
+
+func (x *BroadcastRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BroadcastRequest.ProtoReflect.Descriptor instead.
+func (*BroadcastRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BroadcastRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *BroadcastRequest) GetTerminalIds() []string {
+	if x != nil {
+		return x.TerminalIds
+	}
+	return nil
+}
+
+func (x *BroadcastRequest) GetSelector() map[string]string {
+	if x != nil {
+		return x.Selector
+	}
+	return nil
+}
+
+func (x *BroadcastRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *BroadcastRequest) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *BroadcastRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+func (x *BroadcastRequest) GetSanitize() bool {
+	if x != nil {
+		return x.Sanitize
+	}
+	return false
+}
+
+type ListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Selector      map[string]string      `protobuf:"bytes,335249468,rep,name=selector,proto3" json:"selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_terminal_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ListRequest) GetSelector() map[string]string {
+	if x != nil {
+		return x.Selector
+	}
+	return nil
+}
+
+type CloseAllRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	All           bool                   `protobuf:"varint,321231332,opt,name=all,proto3" json:"all,omitempty"`
+	Selector      map[string]string      `protobuf:"bytes,335249468,rep,name=selector,proto3" json:"selector,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Shell         string                 `protobuf:"bytes,300042785,opt,name=shell,proto3" json:"shell,omitempty"`
+	Force         bool                   `protobuf:"varint,430620932,opt,name=force,proto3" json:"force,omitempty"`
+	GraceMs       int64                  `protobuf:"varint,188495507,opt,name=grace_ms,json=graceMs,proto3" json:"grace_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseAllRequest) Reset() {
+	*x = CloseAllRequest{}
+	mi := &file_terminal_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseAllRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseAllRequest) ProtoMessage() {}
+
+func (x *CloseAllRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseAllRequest.ProtoReflect.Descriptor instead.
+func (*CloseAllRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CloseAllRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CloseAllRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+func (x *CloseAllRequest) GetSelector() map[string]string {
+	if x != nil {
+		return x.Selector
+	}
+	return nil
+}
+
+func (x *CloseAllRequest) GetShell() string {
+	if x != nil {
+		return x.Shell
+	}
+	return ""
+}
+
+func (x *CloseAllRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *CloseAllRequest) GetGraceMs() int64 {
+	if x != nil {
+		return x.GraceMs
+	}
+	return 0
+}
+
+type ListShellsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListShellsRequest) Reset() {
+	*x = ListShellsRequest{}
+	mi := &file_terminal_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListShellsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListShellsRequest) ProtoMessage() {}
+
+func (x *ListShellsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListShellsRequest.ProtoReflect.Descriptor instead.
+func (*ListShellsRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListShellsRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type ProbeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeRequest) Reset() {
+	*x = ProbeRequest{}
+	mi := &file_terminal_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeRequest) ProtoMessage() {}
+
+func (x *ProbeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeRequest.ProtoReflect.Descriptor instead.
+func (*ProbeRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ProbeRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type TmuxPanesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Tmux          *TmuxOptions           `protobuf:"bytes,516321263,opt,name=tmux,proto3" json:"tmux,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TmuxPanesRequest) Reset() {
+	*x = TmuxPanesRequest{}
+	mi := &file_terminal_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TmuxPanesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TmuxPanesRequest) ProtoMessage() {}
+
+func (x *TmuxPanesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TmuxPanesRequest.ProtoReflect.Descriptor instead.
+func (*TmuxPanesRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *TmuxPanesRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *TmuxPanesRequest) GetTmux() *TmuxOptions {
+	if x != nil {
+		return x.Tmux
+	}
+	return nil
+}
+
+type GetSizeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSizeRequest) Reset() {
+	*x = GetSizeRequest{}
+	mi := &file_terminal_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSizeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSizeRequest) ProtoMessage() {}
+
+func (x *GetSizeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSizeRequest.ProtoReflect.Descriptor instead.
+func (*GetSizeRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetSizeRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *GetSizeRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type FocusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Focused       bool                   `protobuf:"varint,315206386,opt,name=focused,proto3" json:"focused,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FocusRequest) Reset() {
+	*x = FocusRequest{}
+	mi := &file_terminal_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FocusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FocusRequest) ProtoMessage() {}
+
+func (x *FocusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FocusRequest.ProtoReflect.Descriptor instead.
+func (*FocusRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *FocusRequest) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *FocusRequest) GetFocused() bool {
+	if x != nil {
+		return x.Focused
+	}
+	return false
+}
+
+type UpgradeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpgradeRequest) Reset() {
+	*x = UpgradeRequest{}
+	mi := &file_terminal_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpgradeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpgradeRequest) ProtoMessage() {}
+
+func (x *UpgradeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpgradeRequest.ProtoReflect.Descriptor instead.
+func (*UpgradeRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *UpgradeRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type ClientHelloRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Name          string                 `protobuf:"bytes,221987974,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,108173559,opt,name=version,proto3" json:"version,omitempty"`
+	Protocols     []int64                `protobuf:"varint,100774642,rep,packed,name=protocols,proto3" json:"protocols,omitempty"`
+	Features      []string               `protobuf:"bytes,528772650,rep,name=features,proto3" json:"features,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientHelloRequest) Reset() {
+	*x = ClientHelloRequest{}
+	mi := &file_terminal_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientHelloRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientHelloRequest) ProtoMessage() {}
+
+func (x *ClientHelloRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientHelloRequest.ProtoReflect.Descriptor instead.
+func (*ClientHelloRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ClientHelloRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ClientHelloRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClientHelloRequest) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ClientHelloRequest) GetProtocols() []int64 {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *ClientHelloRequest) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Categories    []string               `protobuf:"bytes,514960077,rep,name=categories,proto3" json:"categories,omitempty"`
+	TerminalIds   []string               `protobuf:"bytes,320339061,rep,name=terminal_ids,json=terminalIds,proto3" json:"terminal_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_terminal_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SubscribeRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetTerminalIds() []string {
+	if x != nil {
+		return x.TerminalIds
+	}
+	return nil
+}
+
+type UnsubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Categories    []string               `protobuf:"bytes,514960077,rep,name=categories,proto3" json:"categories,omitempty"`
+	TerminalIds   []string               `protobuf:"bytes,320339061,rep,name=terminal_ids,json=terminalIds,proto3" json:"terminal_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnsubscribeRequest) Reset() {
+	*x = UnsubscribeRequest{}
+	mi := &file_terminal_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnsubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnsubscribeRequest) ProtoMessage() {}
+
+func (x *UnsubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnsubscribeRequest.ProtoReflect.Descriptor instead.
+func (*UnsubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *UnsubscribeRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *UnsubscribeRequest) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *UnsubscribeRequest) GetTerminalIds() []string {
+	if x != nil {
+		return x.TerminalIds
+	}
+	return nil
+}
+
+type ShutdownRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GraceMs       int64                  `protobuf:"varint,188495507,opt,name=grace_ms,json=graceMs,proto3" json:"grace_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownRequest) Reset() {
+	*x = ShutdownRequest{}
+	mi := &file_terminal_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownRequest) ProtoMessage() {}
+
+func (x *ShutdownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownRequest.ProtoReflect.Descriptor instead.
+func (*ShutdownRequest) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ShutdownRequest) GetGraceMs() int64 {
+	if x != nil {
+		return x.GraceMs
+	}
+	return 0
+}
+
+type HelloEvent struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Version             string                 `protobuf:"bytes,108173559,opt,name=version,proto3" json:"version,omitempty"`
+	Protocol            int64                  `protobuf:"varint,455767733,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Protocols           []int64                `protobuf:"varint,100774642,rep,packed,name=protocols,proto3" json:"protocols,omitempty"`
+	Capabilities        *HelloCapabilities     `protobuf:"bytes,434875679,opt,name=capabilities,proto3" json:"capabilities,omitempty"`
+	PingIntervalMs      int64                  `protobuf:"varint,519505864,opt,name=ping_interval_ms,json=pingIntervalMs,proto3" json:"ping_interval_ms,omitempty"`
+	HeartbeatIntervalMs int64                  `protobuf:"varint,178820326,opt,name=heartbeat_interval_ms,json=heartbeatIntervalMs,proto3" json:"heartbeat_interval_ms,omitempty"`
+	ReplayEvents        int64                  `protobuf:"varint,456737767,opt,name=replay_events,json=replayEvents,proto3" json:"replay_events,omitempty"`
+	Platform            *PlatformInfo          `protobuf:"bytes,172674802,opt,name=platform,proto3" json:"platform,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *HelloEvent) Reset() {
+	*x = HelloEvent{}
+	mi := &file_terminal_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloEvent) ProtoMessage() {}
+
+func (x *HelloEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloEvent.ProtoReflect.Descriptor instead.
+func (*HelloEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *HelloEvent) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *HelloEvent) GetProtocol() int64 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *HelloEvent) GetProtocols() []int64 {
+	if x != nil {
+		return x.Protocols
+	}
+	return nil
+}
+
+func (x *HelloEvent) GetCapabilities() *HelloCapabilities {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *HelloEvent) GetPingIntervalMs() int64 {
+	if x != nil {
+		return x.PingIntervalMs
+	}
+	return 0
+}
+
+func (x *HelloEvent) GetHeartbeatIntervalMs() int64 {
+	if x != nil {
+		return x.HeartbeatIntervalMs
+	}
+	return 0
+}
+
+func (x *HelloEvent) GetReplayEvents() int64 {
+	if x != nil {
+		return x.ReplayEvents
+	}
+	return 0
+}
+
+func (x *HelloEvent) GetPlatform() *PlatformInfo {
+	if x != nil {
+		return x.Platform
+	}
+	return nil
+}
+
+type ReadyEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,268667190,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Cwd           string                 `protobuf:"bytes,441595745,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Attached      bool                   `protobuf:"varint,427921749,opt,name=attached,proto3" json:"attached,omitempty"`
+	Backend       string                 `protobuf:"bytes,66038031,opt,name=backend,proto3" json:"backend,omitempty"`
+	ShellVersion  string                 `protobuf:"bytes,91099403,opt,name=shell_version,json=shellVersion,proto3" json:"shell_version,omitempty"`
+	CodePage      int64                  `protobuf:"varint,422535855,opt,name=code_page,json=codePage,proto3" json:"code_page,omitempty"`
+	RestoredFrom  string                 `protobuf:"bytes,316109059,opt,name=restored_from,json=restoredFrom,proto3" json:"restored_from,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	Resumed       bool                   `protobuf:"varint,364761578,opt,name=resumed,proto3" json:"resumed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadyEvent) Reset() {
+	*x = ReadyEvent{}
+	mi := &file_terminal_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadyEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadyEvent) ProtoMessage() {}
+
+func (x *ReadyEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadyEvent.ProtoReflect.Descriptor instead.
+func (*ReadyEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ReadyEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ReadyEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ReadyEvent) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *ReadyEvent) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *ReadyEvent) GetAttached() bool {
+	if x != nil {
+		return x.Attached
+	}
+	return false
+}
+
+func (x *ReadyEvent) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *ReadyEvent) GetShellVersion() string {
+	if x != nil {
+		return x.ShellVersion
+	}
+	return ""
+}
+
+func (x *ReadyEvent) GetCodePage() int64 {
+	if x != nil {
+		return x.CodePage
+	}
+	return 0
+}
+
+func (x *ReadyEvent) GetRestoredFrom() string {
+	if x != nil {
+		return x.RestoredFrom
+	}
+	return ""
+}
+
+func (x *ReadyEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ReadyEvent) GetResumed() bool {
+	if x != nil {
+		return x.Resumed
+	}
+	return false
+}
+
+type OutputEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Data          string                 `protobuf:"bytes,410322309,opt,name=data,proto3" json:"data,omitempty"`
+	Encoding      string                 `protobuf:"bytes,519939760,opt,name=encoding,proto3" json:"encoding,omitempty"`
+	Compression   string                 `protobuf:"bytes,450663477,opt,name=compression,proto3" json:"compression,omitempty"`
+	Timestamp     *OutputTimestamp       `protobuf:"bytes,310749667,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputEvent) Reset() {
+	*x = OutputEvent{}
+	mi := &file_terminal_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputEvent) ProtoMessage() {}
+
+func (x *OutputEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputEvent.ProtoReflect.Descriptor instead.
+func (*OutputEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *OutputEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *OutputEvent) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *OutputEvent) GetEncoding() string {
+	if x != nil {
+		return x.Encoding
+	}
+	return ""
+}
+
+func (x *OutputEvent) GetCompression() string {
+	if x != nil {
+		return x.Compression
+	}
+	return ""
+}
+
+func (x *OutputEvent) GetTimestamp() *OutputTimestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *OutputEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type ExitEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Code          int64                  `protobuf:"varint,422829556,opt,name=code,proto3" json:"code,omitempty"`
+	Reason        string                 `protobuf:"bytes,413519641,opt,name=reason,proto3" json:"reason,omitempty"`
+	Status        string                 `protobuf:"bytes,441273519,opt,name=status,proto3" json:"status,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExitEvent) Reset() {
+	*x = ExitEvent{}
+	mi := &file_terminal_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExitEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExitEvent) ProtoMessage() {}
+
+func (x *ExitEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExitEvent.ProtoReflect.Descriptor instead.
+func (*ExitEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ExitEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ExitEvent) GetCode() int64 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ExitEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ExitEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ExitEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type ErrorEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Code          string                 `protobuf:"bytes,422829556,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,83010852,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorEvent) Reset() {
+	*x = ErrorEvent{}
+	mi := &file_terminal_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorEvent) ProtoMessage() {}
+
+func (x *ErrorEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorEvent.ProtoReflect.Descriptor instead.
+func (*ErrorEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ErrorEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ErrorEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ErrorEvent) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ErrorEvent) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type PongEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         string                 `protobuf:"bytes,420145912,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,310749667,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ReceivedAt    int64                  `protobuf:"varint,470327111,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PongEvent) Reset() {
+	*x = PongEvent{}
+	mi := &file_terminal_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PongEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PongEvent) ProtoMessage() {}
+
+func (x *PongEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PongEvent.ProtoReflect.Descriptor instead.
+func (*PongEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *PongEvent) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *PongEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *PongEvent) GetReceivedAt() int64 {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return 0
+}
+
+type ShutdownAckEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Terminals     []*TerminalCloseResult `protobuf:"bytes,489504134,rep,name=terminals,proto3" json:"terminals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownAckEvent) Reset() {
+	*x = ShutdownAckEvent{}
+	mi := &file_terminal_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownAckEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownAckEvent) ProtoMessage() {}
+
+func (x *ShutdownAckEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownAckEvent.ProtoReflect.Descriptor instead.
+func (*ShutdownAckEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ShutdownAckEvent) GetTerminals() []*TerminalCloseResult {
+	if x != nil {
+		return x.Terminals
+	}
+	return nil
+}
+
+type ClipboardEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Selection     string                 `protobuf:"bytes,57004149,opt,name=selection,proto3" json:"selection,omitempty"`
+	Text          string                 `protobuf:"bytes,501752574,opt,name=text,proto3" json:"text,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClipboardEvent) Reset() {
+	*x = ClipboardEvent{}
+	mi := &file_terminal_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClipboardEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClipboardEvent) ProtoMessage() {}
+
+func (x *ClipboardEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClipboardEvent.ProtoReflect.Descriptor instead.
+func (*ClipboardEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ClipboardEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ClipboardEvent) GetSelection() string {
+	if x != nil {
+		return x.Selection
+	}
+	return ""
+}
+
+func (x *ClipboardEvent) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ClipboardEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type ProgressEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	State         string                 `protobuf:"bytes,405957494,opt,name=state,proto3" json:"state,omitempty"`
+	Percent       int64                  `protobuf:"varint,368784090,opt,name=percent,proto3" json:"percent,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProgressEvent) Reset() {
+	*x = ProgressEvent{}
+	mi := &file_terminal_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProgressEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProgressEvent) ProtoMessage() {}
+
+func (x *ProgressEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProgressEvent.ProtoReflect.Descriptor instead.
+func (*ProgressEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ProgressEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ProgressEvent) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *ProgressEvent) GetPercent() int64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *ProgressEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type SnapshotEvent struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId      string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Cols            int64                  `protobuf:"varint,390521350,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows            int64                  `protobuf:"varint,171462616,opt,name=rows,proto3" json:"rows,omitempty"`
+	Cursor          *SnapshotCursor        `protobuf:"bytes,54043407,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	AlternateScreen bool                   `protobuf:"varint,126166617,opt,name=alternate_screen,json=alternateScreen,proto3" json:"alternate_screen,omitempty"`
+	Lines           []*SnapshotLine        `protobuf:"bytes,31889212,rep,name=lines,proto3" json:"lines,omitempty"`
+	Scrollback      []*SnapshotLine        `protobuf:"bytes,148394855,rep,name=scrollback,proto3" json:"scrollback,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SnapshotEvent) Reset() {
+	*x = SnapshotEvent{}
+	mi := &file_terminal_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotEvent) ProtoMessage() {}
+
+func (x *SnapshotEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotEvent.ProtoReflect.Descriptor instead.
+func (*SnapshotEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *SnapshotEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *SnapshotEvent) GetCols() int64 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *SnapshotEvent) GetRows() int64 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *SnapshotEvent) GetCursor() *SnapshotCursor {
+	if x != nil {
+		return x.Cursor
+	}
+	return nil
+}
+
+func (x *SnapshotEvent) GetAlternateScreen() bool {
+	if x != nil {
+		return x.AlternateScreen
+	}
+	return false
+}
+
+func (x *SnapshotEvent) GetLines() []*SnapshotLine {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+func (x *SnapshotEvent) GetScrollback() []*SnapshotLine {
+	if x != nil {
+		return x.Scrollback
+	}
+	return nil
+}
+
+type MatchedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Match         string                 `protobuf:"bytes,505505814,opt,name=match,proto3" json:"match,omitempty"`
+	Context       string                 `protobuf:"bytes,210278172,opt,name=context,proto3" json:"context,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchedEvent) Reset() {
+	*x = MatchedEvent{}
+	mi := &file_terminal_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchedEvent) ProtoMessage() {}
+
+func (x *MatchedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchedEvent.ProtoReflect.Descriptor instead.
+func (*MatchedEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *MatchedEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *MatchedEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *MatchedEvent) GetMatch() string {
+	if x != nil {
+		return x.Match
+	}
+	return ""
+}
+
+func (x *MatchedEvent) GetContext() string {
+	if x != nil {
+		return x.Context
+	}
+	return ""
+}
+
+type SearchResultsEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	TotalLines    int64                  `protobuf:"varint,369005748,opt,name=total_lines,json=totalLines,proto3" json:"total_lines,omitempty"`
+	Matches       []*SearchMatch         `protobuf:"bytes,351705998,rep,name=matches,proto3" json:"matches,omitempty"`
+	Truncated     bool                   `protobuf:"varint,407510857,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResultsEvent) Reset() {
+	*x = SearchResultsEvent{}
+	mi := &file_terminal_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResultsEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResultsEvent) ProtoMessage() {}
+
+func (x *SearchResultsEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResultsEvent.ProtoReflect.Descriptor instead.
+func (*SearchResultsEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *SearchResultsEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *SearchResultsEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SearchResultsEvent) GetTotalLines() int64 {
+	if x != nil {
+		return x.TotalLines
+	}
+	return 0
+}
+
+func (x *SearchResultsEvent) GetMatches() []*SearchMatch {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+func (x *SearchResultsEvent) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type ExportEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Format        string                 `protobuf:"bytes,429873682,opt,name=format,proto3" json:"format,omitempty"`
+	Bytes         int64                  `protobuf:"varint,95619204,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Content       string                 `protobuf:"bytes,281037410,opt,name=content,proto3" json:"content,omitempty"`
+	Path          string                 `protobuf:"bytes,76075990,opt,name=path,proto3" json:"path,omitempty"`
+	Redacted      int64                  `protobuf:"varint,156981289,opt,name=redacted,proto3" json:"redacted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportEvent) Reset() {
+	*x = ExportEvent{}
+	mi := &file_terminal_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportEvent) ProtoMessage() {}
+
+func (x *ExportEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportEvent.ProtoReflect.Descriptor instead.
+func (*ExportEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ExportEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ExportEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ExportEvent) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ExportEvent) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *ExportEvent) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *ExportEvent) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ExportEvent) GetRedacted() int64 {
+	if x != nil {
+		return x.Redacted
+	}
+	return 0
+}
+
+type BackpressureEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	State         string                 `protobuf:"bytes,405957494,opt,name=state,proto3" json:"state,omitempty"`
+	QueuedBytes   int64                  `protobuf:"varint,345064215,opt,name=queued_bytes,json=queuedBytes,proto3" json:"queued_bytes,omitempty"`
+	DroppedBytes  int64                  `protobuf:"varint,430461796,opt,name=dropped_bytes,json=droppedBytes,proto3" json:"dropped_bytes,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackpressureEvent) Reset() {
+	*x = BackpressureEvent{}
+	mi := &file_terminal_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackpressureEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackpressureEvent) ProtoMessage() {}
+
+func (x *BackpressureEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackpressureEvent.ProtoReflect.Descriptor instead.
+func (*BackpressureEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *BackpressureEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *BackpressureEvent) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *BackpressureEvent) GetQueuedBytes() int64 {
+	if x != nil {
+		return x.QueuedBytes
+	}
+	return 0
+}
+
+func (x *BackpressureEvent) GetDroppedBytes() int64 {
+	if x != nil {
+		return x.DroppedBytes
+	}
+	return 0
+}
+
+func (x *BackpressureEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type ClosedEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Method        string                 `protobuf:"bytes,189254640,opt,name=method,proto3" json:"method,omitempty"`
+	ExitCode      *int64                 `protobuf:"varint,455536436,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
+	Reason        string                 `protobuf:"bytes,413519641,opt,name=reason,proto3" json:"reason,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClosedEvent) Reset() {
+	*x = ClosedEvent{}
+	mi := &file_terminal_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClosedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClosedEvent) ProtoMessage() {}
+
+func (x *ClosedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClosedEvent.ProtoReflect.Descriptor instead.
+func (*ClosedEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *ClosedEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ClosedEvent) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *ClosedEvent) GetExitCode() int64 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+func (x *ClosedEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ClosedEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type WillCloseEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,413519641,opt,name=reason,proto3" json:"reason,omitempty"`
+	CloseInMs     int64                  `protobuf:"varint,211960412,opt,name=close_in_ms,json=closeInMs,proto3" json:"close_in_ms,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WillCloseEvent) Reset() {
+	*x = WillCloseEvent{}
+	mi := &file_terminal_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WillCloseEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WillCloseEvent) ProtoMessage() {}
+
+func (x *WillCloseEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WillCloseEvent.ProtoReflect.Descriptor instead.
+func (*WillCloseEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *WillCloseEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *WillCloseEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *WillCloseEvent) GetCloseInMs() int64 {
+	if x != nil {
+		return x.CloseInMs
+	}
+	return 0
+}
+
+func (x *WillCloseEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type StatsEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Terminals     int64                  `protobuf:"varint,489504134,opt,name=terminals,proto3" json:"terminals,omitempty"`
+	MaxTerminals  int64                  `protobuf:"varint,404709114,opt,name=max_terminals,json=maxTerminals,proto3" json:"max_terminals,omitempty"`
+	Latency       *LatencyStats          `protobuf:"bytes,318633049,opt,name=latency,proto3" json:"latency,omitempty"`
+	Clients       []*ClientInfo          `protobuf:"bytes,281901079,rep,name=clients,proto3" json:"clients,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsEvent) Reset() {
+	*x = StatsEvent{}
+	mi := &file_terminal_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsEvent) ProtoMessage() {}
+
+func (x *StatsEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsEvent.ProtoReflect.Descriptor instead.
+func (*StatsEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *StatsEvent) GetTerminals() int64 {
+	if x != nil {
+		return x.Terminals
+	}
+	return 0
+}
+
+func (x *StatsEvent) GetMaxTerminals() int64 {
+	if x != nil {
+		return x.MaxTerminals
+	}
+	return 0
+}
+
+func (x *StatsEvent) GetLatency() *LatencyStats {
+	if x != nil {
+		return x.Latency
+	}
+	return nil
+}
+
+func (x *StatsEvent) GetClients() []*ClientInfo {
+	if x != nil {
+		return x.Clients
+	}
+	return nil
+}
+
+type HeartbeatEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UptimeMs      int64                  `protobuf:"varint,196869053,opt,name=uptime_ms,json=uptimeMs,proto3" json:"uptime_ms,omitempty"`
+	Terminals     int64                  `protobuf:"varint,489504134,opt,name=terminals,proto3" json:"terminals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeartbeatEvent) Reset() {
+	*x = HeartbeatEvent{}
+	mi := &file_terminal_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeartbeatEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatEvent) ProtoMessage() {}
+
+func (x *HeartbeatEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatEvent.ProtoReflect.Descriptor instead.
+func (*HeartbeatEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *HeartbeatEvent) GetUptimeMs() int64 {
+	if x != nil {
+		return x.UptimeMs
+	}
+	return 0
+}
+
+func (x *HeartbeatEvent) GetTerminals() int64 {
+	if x != nil {
+		return x.Terminals
+	}
+	return 0
+}
+
+type ListEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Terminals     []*TerminalInfo        `protobuf:"bytes,489504134,rep,name=terminals,proto3" json:"terminals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEvent) Reset() {
+	*x = ListEvent{}
+	mi := &file_terminal_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEvent) ProtoMessage() {}
+
+func (x *ListEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEvent.ProtoReflect.Descriptor instead.
+func (*ListEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *ListEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ListEvent) GetTerminals() []*TerminalInfo {
+	if x != nil {
+		return x.Terminals
+	}
+	return nil
+}
+
+type ClosedAllEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Terminals     []*TerminalCloseResult `protobuf:"bytes,489504134,rep,name=terminals,proto3" json:"terminals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClosedAllEvent) Reset() {
+	*x = ClosedAllEvent{}
+	mi := &file_terminal_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClosedAllEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClosedAllEvent) ProtoMessage() {}
+
+func (x *ClosedAllEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClosedAllEvent.ProtoReflect.Descriptor instead.
+func (*ClosedAllEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *ClosedAllEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ClosedAllEvent) GetTerminals() []*TerminalCloseResult {
+	if x != nil {
+		return x.Terminals
+	}
+	return nil
+}
+
+type ShellsEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Default       string                 `protobuf:"bytes,322757246,opt,name=default,proto3" json:"default,omitempty"`
+	Order         []string               `protobuf:"bytes,321734935,rep,name=order,proto3" json:"order,omitempty"`
+	Shells        []*ShellInfo           `protobuf:"bytes,415362582,rep,name=shells,proto3" json:"shells,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShellsEvent) Reset() {
+	*x = ShellsEvent{}
+	mi := &file_terminal_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShellsEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShellsEvent) ProtoMessage() {}
+
+func (x *ShellsEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShellsEvent.ProtoReflect.Descriptor instead.
+func (*ShellsEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *ShellsEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ShellsEvent) GetDefault() string {
+	if x != nil {
+		return x.Default
+	}
+	return ""
+}
+
+func (x *ShellsEvent) GetOrder() []string {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+func (x *ShellsEvent) GetShells() []*ShellInfo {
+	if x != nil {
+		return x.Shells
+	}
+	return nil
+}
+
+type ProbeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Backend       string                 `protobuf:"bytes,66038031,opt,name=backend,proto3" json:"backend,omitempty"`
+	Conpty        bool                   `protobuf:"varint,102704738,opt,name=conpty,proto3" json:"conpty,omitempty"`
+	Winpty        bool                   `protobuf:"varint,429615516,opt,name=winpty,proto3" json:"winpty,omitempty"`
+	Error         string                 `protobuf:"bytes,26354577,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeEvent) Reset() {
+	*x = ProbeEvent{}
+	mi := &file_terminal_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeEvent) ProtoMessage() {}
+
+func (x *ProbeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeEvent.ProtoReflect.Descriptor instead.
+func (*ProbeEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *ProbeEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *ProbeEvent) GetBackend() string {
+	if x != nil {
+		return x.Backend
+	}
+	return ""
+}
+
+func (x *ProbeEvent) GetConpty() bool {
+	if x != nil {
+		return x.Conpty
+	}
+	return false
+}
+
+func (x *ProbeEvent) GetWinpty() bool {
+	if x != nil {
+		return x.Winpty
+	}
+	return false
+}
+
+func (x *ProbeEvent) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type TmuxPanesEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Session       string                 `protobuf:"bytes,56717271,opt,name=session,proto3" json:"session,omitempty"`
+	Panes         []*TmuxPaneInfo        `protobuf:"bytes,503351784,rep,name=panes,proto3" json:"panes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TmuxPanesEvent) Reset() {
+	*x = TmuxPanesEvent{}
+	mi := &file_terminal_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TmuxPanesEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TmuxPanesEvent) ProtoMessage() {}
+
+func (x *TmuxPanesEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TmuxPanesEvent.ProtoReflect.Descriptor instead.
+func (*TmuxPanesEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *TmuxPanesEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *TmuxPanesEvent) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *TmuxPanesEvent) GetPanes() []*TmuxPaneInfo {
+	if x != nil {
+		return x.Panes
+	}
+	return nil
+}
+
+type SizeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Cols          int64                  `protobuf:"varint,390521350,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows          int64                  `protobuf:"varint,171462616,opt,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SizeEvent) Reset() {
+	*x = SizeEvent{}
+	mi := &file_terminal_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SizeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SizeEvent) ProtoMessage() {}
+
+func (x *SizeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SizeEvent.ProtoReflect.Descriptor instead.
+func (*SizeEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *SizeEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *SizeEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SizeEvent) GetCols() int64 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *SizeEvent) GetRows() int64 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+type ScreenModeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Mode          string                 `protobuf:"bytes,208752914,opt,name=mode,proto3" json:"mode,omitempty"`
+	Seq           uint64                 `protobuf:"varint,153693852,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScreenModeEvent) Reset() {
+	*x = ScreenModeEvent{}
+	mi := &file_terminal_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScreenModeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScreenModeEvent) ProtoMessage() {}
+
+func (x *ScreenModeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScreenModeEvent.ProtoReflect.Descriptor instead.
+func (*ScreenModeEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *ScreenModeEvent) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *ScreenModeEvent) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *ScreenModeEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+type UpgradeEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Version       string                 `protobuf:"bytes,108173559,opt,name=version,proto3" json:"version,omitempty"`
+	Pid           int64                  `protobuf:"varint,155334050,opt,name=pid,proto3" json:"pid,omitempty"`
+	Terminals     []string               `protobuf:"bytes,489504134,rep,name=terminals,proto3" json:"terminals,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpgradeEvent) Reset() {
+	*x = UpgradeEvent{}
+	mi := &file_terminal_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpgradeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpgradeEvent) ProtoMessage() {}
+
+func (x *UpgradeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpgradeEvent.ProtoReflect.Descriptor instead.
+func (*UpgradeEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *UpgradeEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *UpgradeEvent) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *UpgradeEvent) GetPid() int64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *UpgradeEvent) GetTerminals() []string {
+	if x != nil {
+		return x.Terminals
+	}
+	return nil
+}
+
+type HelloAckEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Protocol      int64                  `protobuf:"varint,455767733,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Features      []string               `protobuf:"bytes,528772650,rep,name=features,proto3" json:"features,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HelloAckEvent) Reset() {
+	*x = HelloAckEvent{}
+	mi := &file_terminal_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloAckEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloAckEvent) ProtoMessage() {}
+
+func (x *HelloAckEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloAckEvent.ProtoReflect.Descriptor instead.
+func (*HelloAckEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *HelloAckEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *HelloAckEvent) GetProtocol() int64 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *HelloAckEvent) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+type SubscriptionsEvent struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	RequestId     string                  `protobuf:"bytes,376867551,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Categories    []*CategorySubscription `protobuf:"bytes,514960077,rep,name=categories,proto3" json:"categories,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscriptionsEvent) Reset() {
+	*x = SubscriptionsEvent{}
+	mi := &file_terminal_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscriptionsEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionsEvent) ProtoMessage() {}
+
+func (x *SubscriptionsEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionsEvent.ProtoReflect.Descriptor instead.
+func (*SubscriptionsEvent) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *SubscriptionsEvent) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *SubscriptionsEvent) GetCategories() []*CategorySubscription {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type MockOptions struct {
+	state         protoimpl.MessageState   `protogen:"open.v1"`
+	Output        []*MockStep              `protobuf:"bytes,430606212,rep,name=output,proto3" json:"output,omitempty"`
+	Prompt        string                   `protobuf:"bytes,535325723,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	Replies       map[string]*MockStepList `protobuf:"bytes,499994559,rep,name=replies,proto3" json:"replies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	NoEcho        bool                     `protobuf:"varint,508358443,opt,name=no_echo,json=noEcho,proto3" json:"no_echo,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MockOptions) Reset() {
+	*x = MockOptions{}
+	mi := &file_terminal_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MockOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MockOptions) ProtoMessage() {}
+
+func (x *MockOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MockOptions.ProtoReflect.Descriptor instead.
+func (*MockOptions) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *MockOptions) GetOutput() []*MockStep {
+	if x != nil {
+		return x.Output
+	}
+	return nil
+}
+
+func (x *MockOptions) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *MockOptions) GetReplies() map[string]*MockStepList {
+	if x != nil {
+		return x.Replies
+	}
+	return nil
+}
+
+func (x *MockOptions) GetNoEcho() bool {
+	if x != nil {
+		return x.NoEcho
+	}
+	return false
+}
+
+type MockStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          string                 `protobuf:"bytes,410322309,opt,name=data,proto3" json:"data,omitempty"`
+	DelayMs       int64                  `protobuf:"varint,258598548,opt,name=delay_ms,json=delayMs,proto3" json:"delay_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MockStep) Reset() {
+	*x = MockStep{}
+	mi := &file_terminal_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MockStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MockStep) ProtoMessage() {}
+
+func (x *MockStep) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MockStep.ProtoReflect.Descriptor instead.
+func (*MockStep) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *MockStep) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *MockStep) GetDelayMs() int64 {
+	if x != nil {
+		return x.DelayMs
+	}
+	return 0
+}
+
+type TmuxOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Session       string                 `protobuf:"bytes,56717271,opt,name=session,proto3" json:"session,omitempty"`
+	Pane          string                 `protobuf:"bytes,222804523,opt,name=pane,proto3" json:"pane,omitempty"`
+	Socket        string                 `protobuf:"bytes,269745420,opt,name=socket,proto3" json:"socket,omitempty"`
+	Ssh           string                 `protobuf:"bytes,507565073,opt,name=ssh,proto3" json:"ssh,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TmuxOptions) Reset() {
+	*x = TmuxOptions{}
+	mi := &file_terminal_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TmuxOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TmuxOptions) ProtoMessage() {}
+
+func (x *TmuxOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TmuxOptions.ProtoReflect.Descriptor instead.
+func (*TmuxOptions) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *TmuxOptions) GetSession() string {
+	if x != nil {
+		return x.Session
+	}
+	return ""
+}
+
+func (x *TmuxOptions) GetPane() string {
+	if x != nil {
+		return x.Pane
+	}
+	return ""
+}
+
+func (x *TmuxOptions) GetSocket() string {
+	if x != nil {
+		return x.Socket
+	}
+	return ""
+}
+
+func (x *TmuxOptions) GetSsh() string {
+	if x != nil {
+		return x.Ssh
+	}
+	return ""
+}
+
+type ContainerdOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,181443968,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Container     string                 `protobuf:"bytes,229734424,opt,name=container,proto3" json:"container,omitempty"`
+	ExecId        string                 `protobuf:"bytes,186410609,opt,name=exec_id,json=execId,proto3" json:"exec_id,omitempty"`
+	Command       []string               `protobuf:"bytes,324718930,rep,name=command,proto3" json:"command,omitempty"`
+	Cwd           string                 `protobuf:"bytes,441595745,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	User          string                 `protobuf:"bytes,7968626,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContainerdOptions) Reset() {
+	*x = ContainerdOptions{}
+	mi := &file_terminal_proto_msgTypes[59]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContainerdOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContainerdOptions) ProtoMessage() {}
+
+func (x *ContainerdOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[59]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContainerdOptions.ProtoReflect.Descriptor instead.
+func (*ContainerdOptions) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{59}
+}
+
+func (x *ContainerdOptions) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ContainerdOptions) GetContainer() string {
+	if x != nil {
+		return x.Container
+	}
+	return ""
+}
+
+func (x *ContainerdOptions) GetExecId() string {
+	if x != nil {
+		return x.ExecId
+	}
+	return ""
+}
+
+func (x *ContainerdOptions) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *ContainerdOptions) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *ContainerdOptions) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type SerialOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pipe          string                 `protobuf:"bytes,404720377,opt,name=pipe,proto3" json:"pipe,omitempty"`
+	Reconnect     *bool                  `protobuf:"varint,397045766,opt,name=reconnect,proto3,oneof" json:"reconnect,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SerialOptions) Reset() {
+	*x = SerialOptions{}
+	mi := &file_terminal_proto_msgTypes[60]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SerialOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialOptions) ProtoMessage() {}
+
+func (x *SerialOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[60]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialOptions.ProtoReflect.Descriptor instead.
+func (*SerialOptions) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{60}
+}
+
+func (x *SerialOptions) GetPipe() string {
+	if x != nil {
+		return x.Pipe
+	}
+	return ""
+}
+
+func (x *SerialOptions) GetReconnect() bool {
+	if x != nil && x.Reconnect != nil {
+		return *x.Reconnect
+	}
+	return false
+}
+
+type TelnetOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Host          string                 `protobuf:"bytes,268466735,opt,name=host,proto3" json:"host,omitempty"`
+	Port          int64                  `protobuf:"varint,468011814,opt,name=port,proto3" json:"port,omitempty"`
+	Naws          *bool                  `protobuf:"varint,520439522,opt,name=naws,proto3,oneof" json:"naws,omitempty"`
+	TerminalType  string                 `protobuf:"bytes,404762105,opt,name=terminal_type,json=terminalType,proto3" json:"terminal_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TelnetOptions) Reset() {
+	*x = TelnetOptions{}
+	mi := &file_terminal_proto_msgTypes[61]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TelnetOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TelnetOptions) ProtoMessage() {}
+
+func (x *TelnetOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[61]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TelnetOptions.ProtoReflect.Descriptor instead.
+func (*TelnetOptions) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{61}
+}
+
+func (x *TelnetOptions) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *TelnetOptions) GetPort() int64 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *TelnetOptions) GetNaws() bool {
+	if x != nil && x.Naws != nil {
+		return *x.Naws
+	}
+	return false
+}
+
+func (x *TelnetOptions) GetTerminalType() string {
+	if x != nil {
+		return x.TerminalType
+	}
+	return ""
+}
+
+type PythonOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Venv          string                 `protobuf:"bytes,370781496,opt,name=venv,proto3" json:"venv,omitempty"`
+	Conda         string                 `protobuf:"bytes,456774164,opt,name=conda,proto3" json:"conda,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PythonOptions) Reset() {
+	*x = PythonOptions{}
+	mi := &file_terminal_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PythonOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PythonOptions) ProtoMessage() {}
+
+func (x *PythonOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PythonOptions.ProtoReflect.Descriptor instead.
+func (*PythonOptions) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *PythonOptions) GetVenv() string {
+	if x != nil {
+		return x.Venv
+	}
+	return ""
+}
+
+func (x *PythonOptions) GetConda() string {
+	if x != nil {
+		return x.Conda
+	}
+	return ""
+}
+
+type VisualStudioOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,108173559,opt,name=version,proto3" json:"version,omitempty"`
+	Toolset       string                 `protobuf:"bytes,99993181,opt,name=toolset,proto3" json:"toolset,omitempty"`
+	Arch          string                 `protobuf:"bytes,268569737,opt,name=arch,proto3" json:"arch,omitempty"`
+	HostArch      string                 `protobuf:"bytes,306995195,opt,name=host_arch,json=hostArch,proto3" json:"host_arch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VisualStudioOptions) Reset() {
+	*x = VisualStudioOptions{}
+	mi := &file_terminal_proto_msgTypes[63]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VisualStudioOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VisualStudioOptions) ProtoMessage() {}
+
+func (x *VisualStudioOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[63]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VisualStudioOptions.ProtoReflect.Descriptor instead.
+func (*VisualStudioOptions) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{63}
+}
+
+func (x *VisualStudioOptions) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *VisualStudioOptions) GetToolset() string {
+	if x != nil {
+		return x.Toolset
+	}
+	return ""
+}
+
+func (x *VisualStudioOptions) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *VisualStudioOptions) GetHostArch() string {
+	if x != nil {
+		return x.HostArch
+	}
+	return ""
+}
+
+type HelloCapabilities struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Encodings       []string               `protobuf:"bytes,158035321,rep,name=encodings,proto3" json:"encodings,omitempty"`
+	Compression     []string               `protobuf:"bytes,450663477,rep,name=compression,proto3" json:"compression,omitempty"`
+	OutputEncodings []string               `protobuf:"bytes,387973958,rep,name=output_encodings,json=outputEncodings,proto3" json:"output_encodings,omitempty"`
+	OutputFilters   []string               `protobuf:"bytes,389481935,rep,name=output_filters,json=outputFilters,proto3" json:"output_filters,omitempty"`
+	Backends        []string               `protobuf:"bytes,191235284,rep,name=backends,proto3" json:"backends,omitempty"`
+	Features        []string               `protobuf:"bytes,528772650,rep,name=features,proto3" json:"features,omitempty"`
+	EventCategories []string               `protobuf:"bytes,291740819,rep,name=event_categories,json=eventCategories,proto3" json:"event_categories,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *HelloCapabilities) Reset() {
+	*x = HelloCapabilities{}
+	mi := &file_terminal_proto_msgTypes[64]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HelloCapabilities) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HelloCapabilities) ProtoMessage() {}
+
+func (x *HelloCapabilities) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[64]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HelloCapabilities.ProtoReflect.Descriptor instead.
+func (*HelloCapabilities) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{64}
+}
+
+func (x *HelloCapabilities) GetEncodings() []string {
+	if x != nil {
+		return x.Encodings
+	}
+	return nil
+}
+
+func (x *HelloCapabilities) GetCompression() []string {
+	if x != nil {
+		return x.Compression
+	}
+	return nil
+}
+
+func (x *HelloCapabilities) GetOutputEncodings() []string {
+	if x != nil {
+		return x.OutputEncodings
+	}
+	return nil
+}
+
+func (x *HelloCapabilities) GetOutputFilters() []string {
+	if x != nil {
+		return x.OutputFilters
+	}
+	return nil
+}
+
+func (x *HelloCapabilities) GetBackends() []string {
+	if x != nil {
+		return x.Backends
+	}
+	return nil
+}
+
+func (x *HelloCapabilities) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *HelloCapabilities) GetEventCategories() []string {
+	if x != nil {
+		return x.EventCategories
+	}
+	return nil
+}
+
+type PlatformInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OsVersion     string                 `protobuf:"bytes,199313369,opt,name=os_version,json=osVersion,proto3" json:"os_version,omitempty"`
+	OsBuild       int64                  `protobuf:"varint,243966205,opt,name=os_build,json=osBuild,proto3" json:"os_build,omitempty"`
+	Conpty        bool                   `protobuf:"varint,102704738,opt,name=conpty,proto3" json:"conpty,omitempty"`
+	ConptySource  string                 `protobuf:"bytes,86570207,opt,name=conpty_source,json=conptySource,proto3" json:"conpty_source,omitempty"`
+	ConptyDll     string                 `protobuf:"bytes,145425410,opt,name=conpty_dll,json=conptyDll,proto3" json:"conpty_dll,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlatformInfo) Reset() {
+	*x = PlatformInfo{}
+	mi := &file_terminal_proto_msgTypes[65]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlatformInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlatformInfo) ProtoMessage() {}
+
+func (x *PlatformInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[65]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlatformInfo.ProtoReflect.Descriptor instead.
+func (*PlatformInfo) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{65}
+}
+
+func (x *PlatformInfo) GetOsVersion() string {
+	if x != nil {
+		return x.OsVersion
+	}
+	return ""
+}
+
+func (x *PlatformInfo) GetOsBuild() int64 {
+	if x != nil {
+		return x.OsBuild
+	}
+	return 0
+}
+
+func (x *PlatformInfo) GetConpty() bool {
+	if x != nil {
+		return x.Conpty
+	}
+	return false
+}
+
+func (x *PlatformInfo) GetConptySource() string {
+	if x != nil {
+		return x.ConptySource
+	}
+	return ""
+}
+
+func (x *PlatformInfo) GetConptyDll() string {
+	if x != nil {
+		return x.ConptyDll
+	}
+	return ""
+}
+
+type OutputTimestamp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MonotonicUs   int64                  `protobuf:"varint,372893615,opt,name=monotonic_us,json=monotonicUs,proto3" json:"monotonic_us,omitempty"`
+	Wall          string                 `protobuf:"bytes,120062421,opt,name=wall,proto3" json:"wall,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputTimestamp) Reset() {
+	*x = OutputTimestamp{}
+	mi := &file_terminal_proto_msgTypes[66]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputTimestamp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputTimestamp) ProtoMessage() {}
+
+func (x *OutputTimestamp) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[66]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputTimestamp.ProtoReflect.Descriptor instead.
+func (*OutputTimestamp) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{66}
+}
+
+func (x *OutputTimestamp) GetMonotonicUs() int64 {
+	if x != nil {
+		return x.MonotonicUs
+	}
+	return 0
+}
+
+func (x *OutputTimestamp) GetWall() string {
+	if x != nil {
+		return x.Wall
+	}
+	return ""
+}
+
+type TerminalCloseResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	Method        string                 `protobuf:"bytes,189254640,opt,name=method,proto3" json:"method,omitempty"`
+	ExitCode      *int64                 `protobuf:"varint,455536436,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
+	Reason        string                 `protobuf:"bytes,413519641,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalCloseResult) Reset() {
+	*x = TerminalCloseResult{}
+	mi := &file_terminal_proto_msgTypes[67]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalCloseResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalCloseResult) ProtoMessage() {}
+
+func (x *TerminalCloseResult) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[67]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalCloseResult.ProtoReflect.Descriptor instead.
+func (*TerminalCloseResult) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{67}
+}
+
+func (x *TerminalCloseResult) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *TerminalCloseResult) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *TerminalCloseResult) GetExitCode() int64 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+func (x *TerminalCloseResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type SnapshotCursor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             int64                  `protobuf:"varint,487506311,opt,name=x,proto3" json:"x,omitempty"`
+	Y             int64                  `protobuf:"varint,470728692,opt,name=y,proto3" json:"y,omitempty"`
+	Visible       bool                   `protobuf:"varint,352027649,opt,name=visible,proto3" json:"visible,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotCursor) Reset() {
+	*x = SnapshotCursor{}
+	mi := &file_terminal_proto_msgTypes[68]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotCursor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotCursor) ProtoMessage() {}
+
+func (x *SnapshotCursor) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[68]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotCursor.ProtoReflect.Descriptor instead.
+func (*SnapshotCursor) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{68}
+}
+
+func (x *SnapshotCursor) GetX() int64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *SnapshotCursor) GetY() int64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+func (x *SnapshotCursor) GetVisible() bool {
+	if x != nil {
+		return x.Visible
+	}
+	return false
+}
+
+type SnapshotLine struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,501752574,opt,name=text,proto3" json:"text,omitempty"`
+	Runs          []*SnapshotRun         `protobuf:"bytes,439565147,rep,name=runs,proto3" json:"runs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotLine) Reset() {
+	*x = SnapshotLine{}
+	mi := &file_terminal_proto_msgTypes[69]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotLine) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotLine) ProtoMessage() {}
+
+func (x *SnapshotLine) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[69]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotLine.ProtoReflect.Descriptor instead.
+func (*SnapshotLine) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{69}
+}
+
+func (x *SnapshotLine) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SnapshotLine) GetRuns() []*SnapshotRun {
+	if x != nil {
+		return x.Runs
+	}
+	return nil
+}
+
+type SnapshotRun struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int64                  `protobuf:"varint,86785375,opt,name=start,proto3" json:"start,omitempty"`
+	Length        int64                  `protobuf:"varint,64076981,opt,name=length,proto3" json:"length,omitempty"`
+	Fg            string                 `protobuf:"bytes,170143066,opt,name=fg,proto3" json:"fg,omitempty"`
+	Bg            string                 `protobuf:"bytes,439107558,opt,name=bg,proto3" json:"bg,omitempty"`
+	Bold          bool                   `protobuf:"varint,513349974,opt,name=bold,proto3" json:"bold,omitempty"`
+	Dim           bool                   `protobuf:"varint,275033369,opt,name=dim,proto3" json:"dim,omitempty"`
+	Italic        bool                   `protobuf:"varint,15414253,opt,name=italic,proto3" json:"italic,omitempty"`
+	Underline     bool                   `protobuf:"varint,92579199,opt,name=underline,proto3" json:"underline,omitempty"`
+	Inverse       bool                   `protobuf:"varint,302237507,opt,name=inverse,proto3" json:"inverse,omitempty"`
+	Strikethrough bool                   `protobuf:"varint,515037796,opt,name=strikethrough,proto3" json:"strikethrough,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotRun) Reset() {
+	*x = SnapshotRun{}
+	mi := &file_terminal_proto_msgTypes[70]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRun) ProtoMessage() {}
+
+func (x *SnapshotRun) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[70]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRun.ProtoReflect.Descriptor instead.
+func (*SnapshotRun) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{70}
+}
+
+func (x *SnapshotRun) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *SnapshotRun) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *SnapshotRun) GetFg() string {
+	if x != nil {
+		return x.Fg
+	}
+	return ""
+}
+
+func (x *SnapshotRun) GetBg() string {
+	if x != nil {
+		return x.Bg
+	}
+	return ""
+}
+
+func (x *SnapshotRun) GetBold() bool {
+	if x != nil {
+		return x.Bold
+	}
+	return false
+}
+
+func (x *SnapshotRun) GetDim() bool {
+	if x != nil {
+		return x.Dim
+	}
+	return false
+}
+
+func (x *SnapshotRun) GetItalic() bool {
+	if x != nil {
+		return x.Italic
+	}
+	return false
+}
+
+func (x *SnapshotRun) GetUnderline() bool {
+	if x != nil {
+		return x.Underline
+	}
+	return false
+}
+
+func (x *SnapshotRun) GetInverse() bool {
+	if x != nil {
+		return x.Inverse
+	}
+	return false
+}
+
+func (x *SnapshotRun) GetStrikethrough() bool {
+	if x != nil {
+		return x.Strikethrough
+	}
+	return false
+}
+
+type SearchMatch struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          int64                  `protobuf:"varint,400254023,opt,name=line,proto3" json:"line,omitempty"`
+	Column        int64                  `protobuf:"varint,368788263,opt,name=column,proto3" json:"column,omitempty"`
+	Length        int64                  `protobuf:"varint,64076981,opt,name=length,proto3" json:"length,omitempty"`
+	Text          string                 `protobuf:"bytes,501752574,opt,name=text,proto3" json:"text,omitempty"`
+	Before        []string               `protobuf:"bytes,407280510,rep,name=before,proto3" json:"before,omitempty"`
+	After         []string               `protobuf:"bytes,460245993,rep,name=after,proto3" json:"after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchMatch) Reset() {
+	*x = SearchMatch{}
+	mi := &file_terminal_proto_msgTypes[71]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchMatch) ProtoMessage() {}
+
+func (x *SearchMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[71]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchMatch.ProtoReflect.Descriptor instead.
+func (*SearchMatch) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{71}
+}
+
+func (x *SearchMatch) GetLine() int64 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *SearchMatch) GetColumn() int64 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
+func (x *SearchMatch) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *SearchMatch) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SearchMatch) GetBefore() []string {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *SearchMatch) GetAfter() []string {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+type LatencyStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Samples       int64                  `protobuf:"varint,472374812,opt,name=samples,proto3" json:"samples,omitempty"`
+	LastMs        float64                `protobuf:"fixed64,342098749,opt,name=last_ms,json=lastMs,proto3" json:"last_ms,omitempty"`
+	MinMs         float64                `protobuf:"fixed64,132483655,opt,name=min_ms,json=minMs,proto3" json:"min_ms,omitempty"`
+	AvgMs         float64                `protobuf:"fixed64,287246611,opt,name=avg_ms,json=avgMs,proto3" json:"avg_ms,omitempty"`
+	MaxMs         float64                `protobuf:"fixed64,63871965,opt,name=max_ms,json=maxMs,proto3" json:"max_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LatencyStats) Reset() {
+	*x = LatencyStats{}
+	mi := &file_terminal_proto_msgTypes[72]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LatencyStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LatencyStats) ProtoMessage() {}
+
+func (x *LatencyStats) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[72]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LatencyStats.ProtoReflect.Descriptor instead.
+func (*LatencyStats) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{72}
+}
+
+func (x *LatencyStats) GetSamples() int64 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *LatencyStats) GetLastMs() float64 {
+	if x != nil {
+		return x.LastMs
+	}
+	return 0
+}
+
+func (x *LatencyStats) GetMinMs() float64 {
+	if x != nil {
+		return x.MinMs
+	}
+	return 0
+}
+
+func (x *LatencyStats) GetAvgMs() float64 {
+	if x != nil {
+		return x.AvgMs
+	}
+	return 0
+}
+
+func (x *LatencyStats) GetMaxMs() float64 {
+	if x != nil {
+		return x.MaxMs
+	}
+	return 0
+}
+
+type ClientInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,389613344,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,221987974,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,108173559,opt,name=version,proto3" json:"version,omitempty"`
+	Protocol      int64                  `protobuf:"varint,455767733,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	Features      []string               `protobuf:"bytes,528772650,rep,name=features,proto3" json:"features,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientInfo) Reset() {
+	*x = ClientInfo{}
+	mi := &file_terminal_proto_msgTypes[73]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientInfo) ProtoMessage() {}
+
+func (x *ClientInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[73]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientInfo.ProtoReflect.Descriptor instead.
+func (*ClientInfo) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{73}
+}
+
+func (x *ClientInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ClientInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClientInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ClientInfo) GetProtocol() int64 {
+	if x != nil {
+		return x.Protocol
+	}
+	return 0
+}
+
+func (x *ClientInfo) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+type TerminalInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TerminalId    string                 `protobuf:"bytes,474255332,opt,name=terminal_id,json=terminalId,proto3" json:"terminal_id,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,268667190,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Cwd           string                 `protobuf:"bytes,441595745,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	Tags          map[string]string      `protobuf:"bytes,337206432,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UptimeMs      int64                  `protobuf:"varint,196869053,opt,name=uptime_ms,json=uptimeMs,proto3" json:"uptime_ms,omitempty"`
+	ScreenMode    string                 `protobuf:"bytes,222926862,opt,name=screen_mode,json=screenMode,proto3" json:"screen_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminalInfo) Reset() {
+	*x = TerminalInfo{}
+	mi := &file_terminal_proto_msgTypes[74]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminalInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminalInfo) ProtoMessage() {}
+
+func (x *TerminalInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[74]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminalInfo.ProtoReflect.Descriptor instead.
+func (*TerminalInfo) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{74}
+}
+
+func (x *TerminalInfo) GetTerminalId() string {
+	if x != nil {
+		return x.TerminalId
+	}
+	return ""
+}
+
+func (x *TerminalInfo) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *TerminalInfo) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *TerminalInfo) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *TerminalInfo) GetUptimeMs() int64 {
+	if x != nil {
+		return x.UptimeMs
+	}
+	return 0
+}
+
+func (x *TerminalInfo) GetScreenMode() string {
+	if x != nil {
+		return x.ScreenMode
+	}
+	return ""
+}
+
+type ShellInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,221987974,opt,name=name,proto3" json:"name,omitempty"`
+	Path          string                 `protobuf:"bytes,76075990,opt,name=path,proto3" json:"path,omitempty"`
+	Version       string                 `protobuf:"bytes,108173559,opt,name=version,proto3" json:"version,omitempty"`
+	Available     bool                   `protobuf:"varint,117386008,opt,name=available,proto3" json:"available,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShellInfo) Reset() {
+	*x = ShellInfo{}
+	mi := &file_terminal_proto_msgTypes[75]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShellInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShellInfo) ProtoMessage() {}
+
+func (x *ShellInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[75]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShellInfo.ProtoReflect.Descriptor instead.
+func (*ShellInfo) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{75}
+}
+
+func (x *ShellInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ShellInfo) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ShellInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *ShellInfo) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+type TmuxPaneInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pane          string                 `protobuf:"bytes,222804523,opt,name=pane,proto3" json:"pane,omitempty"`
+	Window        string                 `protobuf:"bytes,24415389,opt,name=window,proto3" json:"window,omitempty"`
+	WindowName    string                 `protobuf:"bytes,93165470,opt,name=window_name,json=windowName,proto3" json:"window_name,omitempty"`
+	Index         int64                  `protobuf:"varint,151713739,opt,name=index,proto3" json:"index,omitempty"`
+	Cols          int64                  `protobuf:"varint,390521350,opt,name=cols,proto3" json:"cols,omitempty"`
+	Rows          int64                  `protobuf:"varint,171462616,opt,name=rows,proto3" json:"rows,omitempty"`
+	Active        bool                   `protobuf:"varint,427277327,opt,name=active,proto3" json:"active,omitempty"`
+	Command       string                 `protobuf:"bytes,324718930,opt,name=command,proto3" json:"command,omitempty"`
+	Title         string                 `protobuf:"bytes,409418665,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TmuxPaneInfo) Reset() {
+	*x = TmuxPaneInfo{}
+	mi := &file_terminal_proto_msgTypes[76]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TmuxPaneInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TmuxPaneInfo) ProtoMessage() {}
+
+func (x *TmuxPaneInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[76]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TmuxPaneInfo.ProtoReflect.Descriptor instead.
+func (*TmuxPaneInfo) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{76}
+}
+
+func (x *TmuxPaneInfo) GetPane() string {
+	if x != nil {
+		return x.Pane
+	}
+	return ""
+}
+
+func (x *TmuxPaneInfo) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *TmuxPaneInfo) GetWindowName() string {
+	if x != nil {
+		return x.WindowName
+	}
+	return ""
+}
+
+func (x *TmuxPaneInfo) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *TmuxPaneInfo) GetCols() int64 {
+	if x != nil {
+		return x.Cols
+	}
+	return 0
+}
+
+func (x *TmuxPaneInfo) GetRows() int64 {
+	if x != nil {
+		return x.Rows
+	}
+	return 0
+}
+
+func (x *TmuxPaneInfo) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *TmuxPaneInfo) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *TmuxPaneInfo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+type CategorySubscription struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Category      string                 `protobuf:"bytes,254895441,opt,name=category,proto3" json:"category,omitempty"`
+	Subscribed    bool                   `protobuf:"varint,489767685,opt,name=subscribed,proto3" json:"subscribed,omitempty"`
+	Except        []string               `protobuf:"bytes,414083120,rep,name=except,proto3" json:"except,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CategorySubscription) Reset() {
+	*x = CategorySubscription{}
+	mi := &file_terminal_proto_msgTypes[77]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CategorySubscription) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CategorySubscription) ProtoMessage() {}
+
+func (x *CategorySubscription) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[77]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CategorySubscription.ProtoReflect.Descriptor instead.
+func (*CategorySubscription) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{77}
+}
+
+func (x *CategorySubscription) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CategorySubscription) GetSubscribed() bool {
+	if x != nil {
+		return x.Subscribed
+	}
+	return false
+}
+
+func (x *CategorySubscription) GetExcept() []string {
+	if x != nil {
+		return x.Except
+	}
+	return nil
+}
+
+type MockStepList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Items         []*MockStep            `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MockStepList) Reset() {
+	*x = MockStepList{}
+	mi := &file_terminal_proto_msgTypes[78]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MockStepList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MockStepList) ProtoMessage() {}
+
+func (x *MockStepList) ProtoReflect() protoreflect.Message {
+	mi := &file_terminal_proto_msgTypes[78]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MockStepList.ProtoReflect.Descriptor instead.
+func (*MockStepList) Descriptor() ([]byte, []int) {
+	return file_terminal_proto_rawDescGZIP(), []int{78}
+}
+
+func (x *MockStepList) GetItems() []*MockStep {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+var File_terminal_proto protoreflect.FileDescriptor
+
+var file_terminal_proto_rawDesc = string([]byte{
+	0x0a, 0x0e, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0b, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x22, 0x99, 0x0c,
+	0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x32, 0x0a, 0x04, 0x61, 0x75, 0x74,
+	0x68, 0x18, 0xef, 0xdd, 0x84, 0xaa, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x12, 0x32, 0x0a,
+	0x04, 0x6f, 0x70, 0x65, 0x6e, 0x18, 0xa9, 0xcf, 0x83, 0x9b, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4f, 0x70,
+	0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x6f, 0x70, 0x65,
+	0x6e, 0x12, 0x35, 0x0a, 0x05, 0x77, 0x72, 0x69, 0x74, 0x65, 0x18, 0x9c, 0xe8, 0xa1, 0xf1, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48,
+	0x00, 0x52, 0x05, 0x77, 0x72, 0x69, 0x74, 0x65, 0x12, 0x38, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x69,
+	0x7a, 0x65, 0x18, 0x81, 0xb9, 0xfb, 0x8b, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68,
+	0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x52, 0x65, 0x73, 0x69, 0x7a,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x06, 0x72, 0x65, 0x73, 0x69,
+	0x7a, 0x65, 0x12, 0x34, 0x0a, 0x05, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x18, 0xe3, 0xae, 0xaf, 0x3e,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48,
+	0x00, 0x52, 0x05, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x73, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x18, 0xcd, 0xcf, 0x90, 0x55, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x08, 0x73,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x31, 0x0a, 0x04, 0x77, 0x61, 0x69, 0x74, 0x18,
+	0xc0, 0xa6, 0xbf, 0x49, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e,
+	0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x57, 0x61, 0x69, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x77, 0x61, 0x69, 0x74, 0x12, 0x37, 0x0a, 0x06, 0x73, 0x65,
+	0x61, 0x72, 0x63, 0x68, 0x18, 0xc9, 0xde, 0xd2, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x65, 0x61, 0x72,
+	0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x06, 0x73, 0x65, 0x61,
+	0x72, 0x63, 0x68, 0x12, 0x38, 0x0a, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x18, 0xb3, 0xfb,
+	0xa9, 0xd8, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x32, 0x0a,
+	0x04, 0x70, 0x69, 0x6e, 0x67, 0x18, 0xa9, 0xfd, 0xf8, 0xb2, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x69,
+	0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x70, 0x69, 0x6e,
+	0x67, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0xec, 0xaa, 0xc9, 0x51, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76,
+	0x32, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00,
+	0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73, 0x12, 0x47, 0x0a, 0x0c, 0x6b, 0x69, 0x6c, 0x6c, 0x5f,
+	0x70, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x18, 0x91, 0xce, 0x96, 0x20, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1f, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4b,
+	0x69, 0x6c, 0x6c, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x48, 0x00, 0x52, 0x0b, 0x6b, 0x69, 0x6c, 0x6c, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73,
+	0x12, 0x2e, 0x0a, 0x03, 0x65, 0x6f, 0x66, 0x18, 0xc1, 0xda, 0xc9, 0x54, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x45,
+	0x6f, 0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x03, 0x65, 0x6f, 0x66,
+	0x12, 0x40, 0x0a, 0x09, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x18, 0xd4, 0xf5,
+	0xe7, 0x16, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74,
+	0x79, 0x2e, 0x76, 0x32, 0x2e, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x09, 0x62, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61,
+	0x73, 0x74, 0x12, 0x31, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0xa1, 0xcd, 0xee, 0x67, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76,
+	0x32, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52,
+	0x04, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x3e, 0x0a, 0x09, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x61,
+	0x6c, 0x6c, 0x18, 0xd1, 0xe0, 0xac, 0x78, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x41,
+	0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x08, 0x63, 0x6c, 0x6f,
+	0x73, 0x65, 0x41, 0x6c, 0x6c, 0x12, 0x45, 0x0a, 0x0b, 0x6c, 0x69, 0x73, 0x74, 0x5f, 0x73, 0x68,
+	0x65, 0x6c, 0x6c, 0x73, 0x18, 0xff, 0xd8, 0xbd, 0xaa, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e,
+	0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00,
+	0x52, 0x0a, 0x6c, 0x69, 0x73, 0x74, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x12, 0x35, 0x0a, 0x05,
+	0x70, 0x72, 0x6f, 0x62, 0x65, 0x18, 0xb1, 0xcc, 0x8f, 0xef, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x72,
+	0x6f, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x05, 0x70, 0x72,
+	0x6f, 0x62, 0x65, 0x12, 0x41, 0x0a, 0x0a, 0x74, 0x6d, 0x75, 0x78, 0x5f, 0x70, 0x61, 0x6e, 0x65,
+	0x73, 0x18, 0xf9, 0xf6, 0x83, 0x18, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x68, 0x61, 0x70,
+	0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54, 0x6d, 0x75, 0x78, 0x50, 0x61, 0x6e,
+	0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x09, 0x74, 0x6d, 0x75,
+	0x78, 0x50, 0x61, 0x6e, 0x65, 0x73, 0x12, 0x3b, 0x0a, 0x08, 0x67, 0x65, 0x74, 0x5f, 0x73, 0x69,
+	0x7a, 0x65, 0x18, 0xef, 0xeb, 0xdb, 0x5b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x47, 0x65, 0x74, 0x53, 0x69, 0x7a,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x07, 0x67, 0x65, 0x74, 0x53,
+	0x69, 0x7a, 0x65, 0x12, 0x35, 0x0a, 0x05, 0x66, 0x6f, 0x63, 0x75, 0x73, 0x18, 0xb3, 0xa4, 0x82,
+	0xa1, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74,
+	0x79, 0x2e, 0x76, 0x32, 0x2e, 0x46, 0x6f, 0x63, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x48, 0x00, 0x52, 0x05, 0x66, 0x6f, 0x63, 0x75, 0x73, 0x12, 0x3b, 0x0a, 0x07, 0x75, 0x70,
+	0x67, 0x72, 0x61, 0x64, 0x65, 0x18, 0xd7, 0xde, 0xe7, 0xe4, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x55, 0x70,
+	0x67, 0x72, 0x61, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x07,
+	0x75, 0x70, 0x67, 0x72, 0x61, 0x64, 0x65, 0x12, 0x3a, 0x0a, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f,
+	0x18, 0x8b, 0xae, 0x80, 0x7d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x48, 0x65,
+	0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x05, 0x68, 0x65,
+	0x6c, 0x6c, 0x6f, 0x12, 0x40, 0x0a, 0x09, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65,
+	0x18, 0xc3, 0xbd, 0x80, 0x7d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x09, 0x73, 0x75, 0x62, 0x73,
+	0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x47, 0x0a, 0x0b, 0x75, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x62, 0x65, 0x18, 0x96, 0xdf, 0x85, 0xce, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f,
+	0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x55, 0x6e, 0x73,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48,
+	0x00, 0x52, 0x0b, 0x75, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x12, 0x3e,
+	0x0a, 0x08, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x18, 0xcb, 0xb6, 0x8b, 0xc1, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x48, 0x00, 0x52, 0x08, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x42, 0x09,
+	0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x99, 0x0d, 0x0a, 0x05, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x12, 0x32, 0x0a, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x18, 0x8b, 0xae, 0x80,
+	0x7d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79,
+	0x2e, 0x76, 0x32, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00,
+	0x52, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x32, 0x0a, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79,
+	0x18, 0xf4, 0xf2, 0xbf, 0x30, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x79, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x48, 0x00, 0x52, 0x05, 0x72, 0x65, 0x61, 0x64, 0x79, 0x12, 0x36, 0x0a, 0x06, 0x6f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x84, 0x8f, 0xaa, 0xcd, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x06, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x12, 0x2f, 0x0a, 0x04, 0x65, 0x78, 0x69, 0x74, 0x18, 0xe5, 0xfa, 0xbc, 0x6f,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x45, 0x78, 0x69, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x04,
+	0x65, 0x78, 0x69, 0x74, 0x12, 0x32, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x91, 0xc7,
+	0xc8, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74,
+	0x79, 0x2e, 0x76, 0x32, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48,
+	0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x30, 0x0a, 0x04, 0x70, 0x6f, 0x6e, 0x67,
+	0x18, 0xb7, 0x9e, 0xf1, 0xd6, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x61, 0x70,
+	0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x48, 0x00, 0x52, 0x04, 0x70, 0x6f, 0x6e, 0x67, 0x12, 0x45, 0x0a, 0x0c, 0x73, 0x68,
+	0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x5f, 0x61, 0x63, 0x6b, 0x18, 0x85, 0x8c, 0xfb, 0x0a, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76,
+	0x32, 0x2e, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x41, 0x63, 0x6b, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x48, 0x00, 0x52, 0x0b, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x41, 0x63,
+	0x6b, 0x12, 0x3f, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x70, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x18, 0xb1,
+	0x8f, 0x80, 0xe9, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e,
+	0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6c, 0x69, 0x70, 0x62, 0x6f, 0x61, 0x72, 0x64,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x09, 0x63, 0x6c, 0x69, 0x70, 0x62, 0x6f, 0x61,
+	0x72, 0x64, 0x12, 0x3b, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0xa6,
+	0xe2, 0xa9, 0x58, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12,
+	0x3b, 0x0a, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x18, 0xcd, 0xcf, 0x90, 0x55,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x48, 0x00, 0x52, 0x08, 0x73, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x38, 0x0a, 0x07,
+	0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x18, 0x97, 0x8e, 0xda, 0x3f, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4d,
+	0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x07, 0x6d,
+	0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x12, 0x4b, 0x0a, 0x0e, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x98, 0xec, 0xfc, 0x4c, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1f, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e,
+	0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x48, 0x00, 0x52, 0x0d, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x73, 0x12, 0x36, 0x0a, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x18, 0xb3, 0xfb,
+	0xa9, 0xd8, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x48, 0x00, 0x52, 0x06, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x47, 0x0a, 0x0c, 0x62,
+	0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x18, 0xbd, 0xd1, 0x9a, 0x54,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x42, 0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x0c, 0x62, 0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x75, 0x72, 0x65, 0x12, 0x35, 0x0a, 0x06, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x18, 0xc5,
+	0x83, 0xc3, 0x5f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x48, 0x00, 0x52, 0x06, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x12, 0x3f, 0x0a, 0x0a, 0x77,
+	0x69, 0x6c, 0x6c, 0x5f, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x18, 0xd2, 0xb2, 0xc5, 0x73, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32,
+	0x2e, 0x57, 0x69, 0x6c, 0x6c, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48,
+	0x00, 0x52, 0x09, 0x77, 0x69, 0x6c, 0x6c, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x05,
+	0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0xec, 0xaa, 0xc9, 0x51, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x73,
+	0x12, 0x3e, 0x0a, 0x09, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x18, 0xd7, 0xb1,
+	0x80, 0x3d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74,
+	0x79, 0x2e, 0x76, 0x32, 0x2e, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x09, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74,
+	0x12, 0x2f, 0x0a, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x18, 0xa1, 0xcd, 0xee, 0x67, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x16, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x04, 0x6c, 0x69, 0x73,
+	0x74, 0x12, 0x40, 0x0a, 0x0a, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x5f, 0x61, 0x6c, 0x6c, 0x18,
+	0xbd, 0xd7, 0xd2, 0xc2, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x41, 0x6c,
+	0x6c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x09, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x64,
+	0x41, 0x6c, 0x6c, 0x12, 0x36, 0x0a, 0x06, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x18, 0x96, 0xdc,
+	0x87, 0xc6, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x48, 0x00, 0x52, 0x06, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x12, 0x33, 0x0a, 0x05, 0x70,
+	0x72, 0x6f, 0x62, 0x65, 0x18, 0xb1, 0xcc, 0x8f, 0xef, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x72, 0x6f,
+	0x62, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05, 0x70, 0x72, 0x6f, 0x62, 0x65,
+	0x12, 0x3f, 0x0a, 0x0a, 0x74, 0x6d, 0x75, 0x78, 0x5f, 0x70, 0x61, 0x6e, 0x65, 0x73, 0x18, 0xef,
+	0x8d, 0x92, 0x16, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54, 0x6d, 0x75, 0x78, 0x50, 0x61, 0x6e, 0x65, 0x73, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x09, 0x74, 0x6d, 0x75, 0x78, 0x50, 0x61, 0x6e, 0x65,
+	0x73, 0x12, 0x2f, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x9c, 0xeb, 0x85, 0x1d, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32,
+	0x2e, 0x53, 0x69, 0x7a, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x04, 0x73, 0x69,
+	0x7a, 0x65, 0x12, 0x43, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x5f, 0x6d, 0x6f, 0x64,
+	0x65, 0x18, 0xb1, 0x8b, 0xb1, 0xdc, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e,
+	0x4d, 0x6f, 0x64, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x0a, 0x73, 0x63, 0x72,
+	0x65, 0x65, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x39, 0x0a, 0x07, 0x75, 0x70, 0x67, 0x72, 0x61,
+	0x64, 0x65, 0x18, 0xd7, 0xde, 0xe7, 0xe4, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68,
+	0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x55, 0x70, 0x67, 0x72, 0x61,
+	0x64, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x07, 0x75, 0x70, 0x67, 0x72, 0x61,
+	0x64, 0x65, 0x12, 0x3c, 0x0a, 0x09, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x5f, 0x61, 0x63, 0x6b, 0x18,
+	0x85, 0x84, 0x98, 0x6d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e,
+	0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x41, 0x63, 0x6b, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x08, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x41, 0x63, 0x6b,
+	0x12, 0x4a, 0x0a, 0x0d, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x85, 0xb5, 0xe9, 0x5d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x68, 0x61, 0x70,
+	0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x0d, 0x73,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x42, 0x07, 0x0a, 0x05,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x47, 0x0a, 0x0b, 0x41, 0x75, 0x74, 0x68, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0xd2, 0xe1,
+	0xed, 0xa3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1e,
+	0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xe3, 0xfd, 0xc9, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x22, 0x87,
+	0x14, 0x0a, 0x0b, 0x4f, 0x70, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23,
+	0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f,
+	0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61,
+	0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x03, 0x63, 0x77, 0x64, 0x18, 0xe1, 0xee,
+	0xc8, 0xd2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x77, 0x64, 0x12, 0x18, 0x0a, 0x05,
+	0x73, 0x68, 0x65, 0x6c, 0x6c, 0x18, 0xa1, 0x94, 0x89, 0x8f, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x12, 0x16, 0x0a, 0x04, 0x63, 0x6f, 0x6c, 0x73, 0x18, 0x86,
+	0xc4, 0x9b, 0xba, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f, 0x6c, 0x73, 0x12, 0x15,
+	0x0a, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x18, 0xd8, 0x9f, 0xe1, 0x51, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x04, 0x72, 0x6f, 0x77, 0x73, 0x12, 0x37, 0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0xb4, 0x88, 0xbf,
+	0xc4, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74,
+	0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x2e, 0x45, 0x6e, 0x76, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x25,
+	0x0a, 0x0c, 0x65, 0x6e, 0x76, 0x5f, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0xf6,
+	0x95, 0xfa, 0x8c, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x65, 0x6e, 0x76, 0x50, 0x72, 0x6f,
+	0x66, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0c, 0x70, 0x61, 0x74, 0x68, 0x5f, 0x70, 0x72,
+	0x65, 0x70, 0x65, 0x6e, 0x64, 0x18, 0xe2, 0xe8, 0xa3, 0xe6, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0b, 0x70, 0x61, 0x74, 0x68, 0x50, 0x72, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x12, 0x23, 0x0a, 0x0b,
+	0x70, 0x61, 0x74, 0x68, 0x5f, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x18, 0x82, 0xa8, 0xd7, 0xa1,
+	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x61, 0x74, 0x68, 0x41, 0x70, 0x70, 0x65, 0x6e,
+	0x64, 0x12, 0x24, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0xb5, 0xa8, 0xf2, 0xd6, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x2b, 0x0a, 0x0f, 0x6f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x5f, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x91, 0x9b, 0xab, 0xe8, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x45, 0x6e, 0x63, 0x6f,
+	0x64, 0x69, 0x6e, 0x67, 0x12, 0x26, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x66,
+	0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0xe6, 0xe2, 0x86, 0x6c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
+	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x22, 0x0a, 0x0a,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x73, 0x18, 0xd0, 0xee, 0xa6, 0xac, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x73,
+	0x12, 0x21, 0x0a, 0x0a, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x5f, 0x75, 0x74, 0x66, 0x38, 0x18, 0xa3,
+	0xb8, 0xbd, 0xe8, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x55,
+	0x74, 0x66, 0x38, 0x12, 0x2b, 0x0a, 0x10, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x63, 0x6f,
+	0x64, 0x65, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x18, 0xb2, 0x88, 0xe1, 0x71, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0e, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x50, 0x61, 0x67, 0x65,
+	0x12, 0x31, 0x0a, 0x12, 0x73, 0x75, 0x70, 0x70, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x63, 0x6c, 0x69,
+	0x70, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x18, 0xe0, 0xd5, 0xf1, 0xe6, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x11, 0x73, 0x75, 0x70, 0x70, 0x72, 0x65, 0x73, 0x73, 0x43, 0x6c, 0x69, 0x70, 0x62, 0x6f,
+	0x61, 0x72, 0x64, 0x12, 0x1c, 0x0a, 0x07, 0x65, 0x6d, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x18, 0xba,
+	0xd6, 0xb6, 0xa7, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6d, 0x75, 0x6c, 0x61, 0x74,
+	0x65, 0x12, 0x2d, 0x0a, 0x10, 0x73, 0x63, 0x72, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f,
+	0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0xd2, 0xb4, 0xe5, 0xc8, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0f, 0x73, 0x63, 0x72, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x4c, 0x69, 0x6e, 0x65, 0x73,
+	0x12, 0x22, 0x0a, 0x0a, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0xff,
+	0xac, 0x9c, 0xbf, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x11, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x62, 0x75, 0x66,
+	0x66, 0x65, 0x72, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x8e, 0x98, 0x82, 0xb2, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0f, 0x72, 0x65, 0x61, 0x64, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0c, 0x62, 0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x75, 0x72, 0x65, 0x18, 0xbd, 0xd1, 0x9a, 0x54, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x62,
+	0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x12, 0x3e, 0x0a, 0x19, 0x62,
+	0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65, 0x5f, 0x62, 0x75, 0x66, 0x66,
+	0x65, 0x72, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x86, 0xda, 0xaf, 0xf2, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x17, 0x62, 0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65,
+	0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x0f, 0x69,
+	0x64, 0x6c, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0xee,
+	0xc0, 0x95, 0xc4, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x69, 0x64, 0x6c, 0x65, 0x54, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x12, 0x2a, 0x0a, 0x0f, 0x69, 0x64, 0x6c, 0x65, 0x5f,
+	0x77, 0x61, 0x72, 0x6e, 0x69, 0x6e, 0x67, 0x5f, 0x6d, 0x73, 0x18, 0xc1, 0x8b, 0xdf, 0xcc, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x69, 0x64, 0x6c, 0x65, 0x57, 0x61, 0x72, 0x6e, 0x69, 0x6e,
+	0x67, 0x4d, 0x73, 0x12, 0x27, 0x0a, 0x0b, 0x69, 0x6e, 0x68, 0x65, 0x72, 0x69, 0x74, 0x5f, 0x65,
+	0x6e, 0x76, 0x18, 0xcf, 0xe2, 0xb8, 0x2b, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x0a, 0x69,
+	0x6e, 0x68, 0x65, 0x72, 0x69, 0x74, 0x45, 0x6e, 0x76, 0x88, 0x01, 0x01, 0x12, 0x24, 0x0a, 0x0c,
+	0x63, 0x77, 0x64, 0x5f, 0x66, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x18, 0xaf, 0xbd, 0xd6,
+	0x57, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x63, 0x77, 0x64, 0x46, 0x61, 0x6c, 0x6c, 0x62, 0x61,
+	0x63, 0x6b, 0x12, 0x1b, 0x0a, 0x07, 0x75, 0x6e, 0x63, 0x5f, 0x63, 0x77, 0x64, 0x18, 0xeb, 0xbf,
+	0xf2, 0xa6, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x6e, 0x63, 0x43, 0x77, 0x64, 0x12,
+	0x30, 0x0a, 0x12, 0x73, 0x74, 0x61, 0x72, 0x74, 0x75, 0x70, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f,
+	0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x99, 0xe4, 0xb9, 0x9c, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x10, 0x73, 0x74, 0x61, 0x72, 0x74, 0x75, 0x70, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d,
+	0x73, 0x12, 0x2a, 0x0a, 0x0f, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x5f, 0x63, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x18, 0xd0, 0xf5, 0xb7, 0x44, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x69,
+	0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x3a, 0x0a,
+	0x18, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64,
+	0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6d, 0x73, 0x18, 0x9f, 0x98, 0xc9, 0x11, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x15, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x43, 0x6f, 0x6d, 0x6d, 0x61,
+	0x6e, 0x64, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x4d, 0x73, 0x12, 0x1f, 0x0a, 0x09, 0x70, 0x72, 0x69,
+	0x76, 0x69, 0x6c, 0x65, 0x67, 0x65, 0x18, 0xec, 0xd9, 0xec, 0x5f, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x70, 0x72, 0x69, 0x76, 0x69, 0x6c, 0x65, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x08, 0x65, 0x6c,
+	0x65, 0x76, 0x61, 0x74, 0x65, 0x64, 0x18, 0x9b, 0xf1, 0x85, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x08, 0x65, 0x6c, 0x65, 0x76, 0x61, 0x74, 0x65, 0x64, 0x12, 0x1f, 0x0a, 0x09, 0x6e, 0x6f, 0x5f,
+	0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0xd6, 0xae, 0xd5, 0xba, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x6e, 0x6f, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x1c, 0x0a, 0x07, 0x64, 0x65,
+	0x73, 0x6b, 0x74, 0x6f, 0x70, 0x18, 0x93, 0xd5, 0x8a, 0xec, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x64, 0x65, 0x73, 0x6b, 0x74, 0x6f, 0x70, 0x12, 0x26, 0x0a, 0x0a, 0x73, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x9a, 0xf0, 0x9d, 0xf1, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x48, 0x01, 0x52, 0x09, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x88, 0x01, 0x01,
+	0x12, 0x1b, 0x0a, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x18, 0x8f, 0xd2, 0xbe, 0x1f,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x12, 0x2f, 0x0a,
+	0x04, 0x6d, 0x6f, 0x63, 0x6b, 0x18, 0xab, 0x9a, 0xf2, 0x73, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18,
+	0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4d, 0x6f, 0x63,
+	0x6b, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x04, 0x6d, 0x6f, 0x63, 0x6b, 0x12, 0x30,
+	0x0a, 0x04, 0x74, 0x6d, 0x75, 0x78, 0x18, 0xef, 0xdf, 0x99, 0xf6, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54,
+	0x6d, 0x75, 0x78, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x04, 0x74, 0x6d, 0x75, 0x78,
+	0x12, 0x41, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x64, 0x18, 0xd4,
+	0xd6, 0xe4, 0x3f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x64,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e,
+	0x65, 0x72, 0x64, 0x12, 0x36, 0x0a, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x18, 0xd9, 0xe0,
+	0xbf, 0xde, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x06, 0x73, 0x65, 0x72, 0x69, 0x61, 0x6c, 0x12, 0x35, 0x0a, 0x06, 0x74,
+	0x65, 0x6c, 0x6e, 0x65, 0x74, 0x18, 0xdd, 0xda, 0xe6, 0x52, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a,
+	0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54, 0x65, 0x6c,
+	0x6e, 0x65, 0x74, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x06, 0x74, 0x65, 0x6c, 0x6e,
+	0x65, 0x74, 0x12, 0x35, 0x0a, 0x06, 0x70, 0x79, 0x74, 0x68, 0x6f, 0x6e, 0x18, 0xb7, 0x9b, 0xbd,
+	0x7e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79,
+	0x2e, 0x76, 0x32, 0x2e, 0x50, 0x79, 0x74, 0x68, 0x6f, 0x6e, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x52, 0x06, 0x70, 0x79, 0x74, 0x68, 0x6f, 0x6e, 0x12, 0x49, 0x0a, 0x0d, 0x76, 0x69, 0x73,
+	0x75, 0x61, 0x6c, 0x5f, 0x73, 0x74, 0x75, 0x64, 0x69, 0x6f, 0x18, 0xc7, 0x91, 0xf9, 0xa1, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x56, 0x69, 0x73, 0x75, 0x61, 0x6c, 0x53, 0x74, 0x75, 0x64, 0x69, 0x6f, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x0c, 0x76, 0x69, 0x73, 0x75, 0x61, 0x6c, 0x53, 0x74,
+	0x75, 0x64, 0x69, 0x6f, 0x12, 0x26, 0x0a, 0x0d, 0x6d, 0x61, 0x78, 0x5f, 0x6d, 0x65, 0x6d, 0x6f,
+	0x72, 0x79, 0x5f, 0x6d, 0x62, 0x18, 0xfd, 0xf5, 0xf0, 0xd0, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0b, 0x6d, 0x61, 0x78, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x4d, 0x62, 0x12, 0x2c, 0x0a, 0x10,
+	0x63, 0x70, 0x75, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74,
+	0x18, 0xe8, 0x8b, 0xb0, 0xc8, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x63, 0x70, 0x75, 0x52,
+	0x61, 0x74, 0x65, 0x50, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x08, 0x70, 0x72,
+	0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0xa9, 0xd3, 0x99, 0xa7, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x1d, 0x0a, 0x08, 0x61, 0x66,
+	0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x18, 0xf9, 0xe9, 0x92, 0x6e, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x08, 0x61, 0x66, 0x66, 0x69, 0x6e, 0x69, 0x74, 0x79, 0x12, 0x29, 0x0a, 0x0e, 0x69, 0x6e, 0x68,
+	0x65, 0x72, 0x69, 0x74, 0x5f, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0xe2, 0xcd, 0xa4, 0xf1,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x69, 0x6e, 0x68, 0x65, 0x72, 0x69, 0x74, 0x43, 0x75,
+	0x72, 0x73, 0x6f, 0x72, 0x12, 0x25, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x5f, 0x66,
+	0x6c, 0x61, 0x67, 0x73, 0x18, 0x9d, 0x9d, 0xfd, 0xa5, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b,
+	0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x46, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x23, 0x0a, 0x0b, 0x74,
+	0x72, 0x61, 0x63, 0x65, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x9e, 0x9b, 0xb1, 0x60, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74,
+	0x12, 0x3a, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0xa0, 0xb9, 0xe5, 0xa0, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x22, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32,
+	0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x54, 0x61, 0x67,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x20, 0x0a, 0x0a,
+	0x65, 0x78, 0x74, 0x72, 0x61, 0x5f, 0x61, 0x72, 0x67, 0x73, 0x18, 0xd8, 0xdd, 0x88, 0x4f, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x09, 0x65, 0x78, 0x74, 0x72, 0x61, 0x41, 0x72, 0x67, 0x73, 0x12, 0x20,
+	0x0a, 0x0a, 0x6e, 0x6f, 0x5f, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0xf7, 0xb0, 0x92,
+	0x46, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x6e, 0x6f, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65,
+	0x12, 0x2d, 0x0a, 0x10, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x70, 0x6f,
+	0x6c, 0x69, 0x63, 0x79, 0x18, 0xef, 0xc3, 0xae, 0x88, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f,
+	0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12,
+	0x1e, 0x0a, 0x09, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x5f, 0x65, 0x6e, 0x76, 0x18, 0x95, 0xc8, 0xf4,
+	0x38, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x45, 0x6e, 0x76, 0x12,
+	0x2c, 0x0a, 0x10, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x5f, 0x69, 0x66, 0x5f, 0x65, 0x78, 0x69,
+	0x73, 0x74, 0x73, 0x18, 0x93, 0x99, 0xd4, 0xd6, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x61,
+	0x74, 0x74, 0x61, 0x63, 0x68, 0x49, 0x66, 0x45, 0x78, 0x69, 0x73, 0x74, 0x73, 0x12, 0x29, 0x0a,
+	0x0f, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x73, 0x65, 0x71,
+	0x18, 0xf5, 0xd9, 0xbc, 0x4a, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d, 0x72, 0x65, 0x73, 0x75, 0x6d,
+	0x65, 0x46, 0x72, 0x6f, 0x6d, 0x53, 0x65, 0x71, 0x1a, 0x36, 0x0a, 0x08, 0x45, 0x6e, 0x76, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
+	0x1a, 0x37, 0x0a, 0x09, 0x54, 0x61, 0x67, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x69, 0x6e,
+	0x68, 0x65, 0x72, 0x69, 0x74, 0x5f, 0x65, 0x6e, 0x76, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x73, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x22, 0xde, 0x01, 0x0a, 0x0c, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72,
+	0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x16,
+	0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x85, 0x8b, 0xd4, 0xc3, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1e, 0x0a, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69,
+	0x6e, 0x67, 0x18, 0xb0, 0xcd, 0xf6, 0xf7, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e,
+	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0xcd,
+	0xf3, 0xb1, 0xca, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x12, 0x1d,
+	0x0a, 0x08, 0x73, 0x61, 0x6e, 0x69, 0x74, 0x69, 0x7a, 0x65, 0x18, 0xd2, 0xf2, 0xfc, 0x25, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x08, 0x73, 0x61, 0x6e, 0x69, 0x74, 0x69, 0x7a, 0x65, 0x12, 0x15, 0x0a,
+	0x04, 0x6d, 0x6f, 0x72, 0x65, 0x18, 0xe0, 0xe5, 0xde, 0x42, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04,
+	0x6d, 0x6f, 0x72, 0x65, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x70, 0x61, 0x72,
+	0x65, 0x6e, 0x74, 0x18, 0x9e, 0x9b, 0xb1, 0x60, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x72,
+	0x61, 0x63, 0x65, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x22, 0xab, 0x01, 0x0a, 0x0d, 0x52, 0x65,
+	0x73, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64,
+	0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf,
+	0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x04, 0x63, 0x6f, 0x6c, 0x73, 0x18, 0x86, 0xc4, 0x9b, 0xba,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f, 0x6c, 0x73, 0x12, 0x15, 0x0a, 0x04, 0x72,
+	0x6f, 0x77, 0x73, 0x18, 0xd8, 0x9f, 0xe1, 0x51, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x72, 0x6f,
+	0x77, 0x73, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x70, 0x61, 0x72, 0x65, 0x6e,
+	0x74, 0x18, 0x9e, 0x9b, 0xb1, 0x60, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x22, 0x90, 0x01, 0x0a, 0x0c, 0x43, 0x6c, 0x6f, 0x73,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x18, 0x0a,
+	0x05, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x18, 0x84, 0x82, 0xab, 0xcd, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x05, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x08, 0x67, 0x72, 0x61, 0x63, 0x65,
+	0x5f, 0x6d, 0x73, 0x18, 0x93, 0xed, 0xf0, 0x59, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x67, 0x72,
+	0x61, 0x63, 0x65, 0x4d, 0x73, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x70, 0x61,
+	0x72, 0x65, 0x6e, 0x74, 0x18, 0x9e, 0x9b, 0xb1, 0x60, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74,
+	0x72, 0x61, 0x63, 0x65, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x22, 0x69, 0x0a, 0x0f, 0x53, 0x6e,
+	0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a,
+	0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92,
+	0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c,
+	0x49, 0x64, 0x12, 0x31, 0x0a, 0x12, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x73, 0x63,
+	0x72, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x18, 0xc5, 0x92, 0xe5, 0xaa, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x11, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x53, 0x63, 0x72, 0x6f, 0x6c,
+	0x6c, 0x62, 0x61, 0x63, 0x6b, 0x22, 0xd2, 0x01, 0x0a, 0x0b, 0x57, 0x61, 0x69, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x1b, 0x0a,
+	0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x18, 0xc9, 0x8f, 0xfa, 0x39, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x12, 0x17, 0x0a, 0x05, 0x72, 0x65,
+	0x67, 0x65, 0x78, 0x18, 0xf8, 0x82, 0x86, 0x58, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x72, 0x65,
+	0x67, 0x65, 0x78, 0x12, 0x23, 0x0a, 0x0b, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65, 0x5f, 0x63, 0x61,
+	0x73, 0x65, 0x18, 0xff, 0xd1, 0x91, 0x8d, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x69, 0x67,
+	0x6e, 0x6f, 0x72, 0x65, 0x43, 0x61, 0x73, 0x65, 0x12, 0x20, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0x84, 0xbf, 0xce, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x22, 0x80, 0x02, 0x0a, 0x0d, 0x53,
+	0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49,
+	0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x18,
+	0xc9, 0x8f, 0xfa, 0x39, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72,
+	0x6e, 0x12, 0x17, 0x0a, 0x05, 0x72, 0x65, 0x67, 0x65, 0x78, 0x18, 0xf8, 0x82, 0x86, 0x58, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x05, 0x72, 0x65, 0x67, 0x65, 0x78, 0x12, 0x23, 0x0a, 0x0b, 0x69, 0x67,
+	0x6e, 0x6f, 0x72, 0x65, 0x5f, 0x63, 0x61, 0x73, 0x65, 0x18, 0xff, 0xd1, 0x91, 0x8d, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0a, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65, 0x43, 0x61, 0x73, 0x65, 0x12,
+	0x23, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0xf1,
+	0xe9, 0xed, 0xdd, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x5f,
+	0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0xb7, 0xe6, 0xac, 0x9e, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x4c, 0x69, 0x6e, 0x65, 0x73, 0x22, 0xd1, 0x01,
+	0x0a, 0x0d, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4,
+	0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61,
+	0x74, 0x18, 0x92, 0xb4, 0xfd, 0xcc, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x12, 0x15, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0xd6, 0xa7, 0xa3, 0x24,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x19, 0x0a, 0x06, 0x72, 0x65,
+	0x64, 0x61, 0x63, 0x74, 0x18, 0xc8, 0x86, 0xf1, 0x42, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72,
+	0x65, 0x64, 0x61, 0x63, 0x74, 0x12, 0x2a, 0x0a, 0x0f, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x5f,
+	0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x73, 0x18, 0x83, 0xea, 0xc2, 0x2f, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0e, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x50, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e,
+	0x73, 0x22, 0x64, 0x0a, 0x0b, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x18, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0xf8, 0xd5, 0xab, 0xc8, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0xe3, 0xd3, 0x96, 0x94, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x19, 0x0a, 0x06,
+	0x72, 0x74, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0xb1, 0xa4, 0xf6, 0x83, 0x01, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x05, 0x72, 0x74, 0x74, 0x4d, 0x73, 0x22, 0x0e, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x71, 0x0a, 0x12, 0x4b, 0x69, 0x6c, 0x6c, 0x50,
+	0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a,
+	0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92,
+	0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c,
+	0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x13, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0xa2, 0xeb, 0x88,
+	0x4a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x70, 0x69, 0x64, 0x22, 0x51, 0x0a, 0x0a, 0x45, 0x6f,
+	0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x1e, 0x0a,
+	0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0xa8, 0x97, 0x92, 0xe2, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x22, 0xd5, 0x02,
+	0x0a, 0x10, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0c, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x64, 0x73, 0x18, 0xf5, 0xf8, 0xdf, 0x98, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x73, 0x12, 0x4b, 0x0a, 0x08,
+	0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0xbc, 0x80, 0xee, 0x9f, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x2b, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32,
+	0x2e, 0x42, 0x72, 0x6f, 0x61, 0x64, 0x63, 0x61, 0x73, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52,
+	0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x16, 0x0a, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x85, 0x8b, 0xd4, 0xc3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x12, 0x1e, 0x0a, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x18, 0xb0, 0xcd,
+	0xf6, 0xf7, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e,
+	0x67, 0x12, 0x16, 0x0a, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x18, 0xcd, 0xf3, 0xb1, 0xca, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x04, 0x6b, 0x65, 0x79, 0x73, 0x12, 0x1d, 0x0a, 0x08, 0x73, 0x61, 0x6e,
+	0x69, 0x74, 0x69, 0x7a, 0x65, 0x18, 0xd2, 0xf2, 0xfc, 0x25, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08,
+	0x73, 0x61, 0x6e, 0x69, 0x74, 0x69, 0x7a, 0x65, 0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x6c, 0x65,
+	0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xb5, 0x01, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x46, 0x0a, 0x08, 0x73, 0x65, 0x6c, 0x65,
+	0x63, 0x74, 0x6f, 0x72, 0x18, 0xbc, 0x80, 0xee, 0x9f, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26,
+	0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f,
+	0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72,
+	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xa5, 0x02,
+	0x0a, 0x0f, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x03, 0x61, 0x6c, 0x6c, 0x18, 0xe4, 0xb3, 0x96, 0x99,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x61, 0x6c, 0x6c, 0x12, 0x4a, 0x0a, 0x08, 0x73, 0x65,
+	0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x18, 0xbc, 0x80, 0xee, 0x9f, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x2a, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43,
+	0x6c, 0x6f, 0x73, 0x65, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53,
+	0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65,
+	0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x18,
+	0xa1, 0x94, 0x89, 0x8f, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x68, 0x65, 0x6c, 0x6c,
+	0x12, 0x18, 0x0a, 0x05, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x18, 0x84, 0x82, 0xab, 0xcd, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x05, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x08, 0x67, 0x72,
+	0x61, 0x63, 0x65, 0x5f, 0x6d, 0x73, 0x18, 0x93, 0xed, 0xf0, 0x59, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x07, 0x67, 0x72, 0x61, 0x63, 0x65, 0x4d, 0x73, 0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x6c, 0x65,
+	0x63, 0x74, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x36, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x68, 0x65,
+	0x6c, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x22, 0x31, 0x0a,
+	0x0c, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a,
+	0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64,
+	0x22, 0x67, 0x0a, 0x10, 0x54, 0x6d, 0x75, 0x78, 0x50, 0x61, 0x6e, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x30, 0x0a, 0x04, 0x74, 0x6d, 0x75, 0x78, 0x18,
+	0xef, 0xdf, 0x99, 0xf6, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54, 0x6d, 0x75, 0x78, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x04, 0x74, 0x6d, 0x75, 0x78, 0x22, 0x58, 0x0a, 0x0e, 0x47, 0x65, 0x74,
+	0x53, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64,
+	0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf,
+	0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x49, 0x64, 0x22, 0x51, 0x0a, 0x0c, 0x46, 0x6f, 0x63, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f,
+	0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65,
+	0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x07, 0x66, 0x6f, 0x63, 0x75,
+	0x73, 0x65, 0x64, 0x18, 0xf2, 0xd5, 0xa6, 0x96, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x66,
+	0x6f, 0x63, 0x75, 0x73, 0x65, 0x64, 0x22, 0x33, 0x0a, 0x0e, 0x55, 0x70, 0x67, 0x72, 0x61, 0x64,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x22, 0xac, 0x01, 0x0a, 0x12,
+	0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x15, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x86, 0x89,
+	0xed, 0x69, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xf7, 0xb1, 0xca, 0x33, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x09, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x18, 0xf2, 0xe5, 0x86, 0x30, 0x20, 0x03, 0x28, 0x03, 0x52,
+	0x09, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x12, 0x1e, 0x0a, 0x08, 0x66, 0x65,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0xaa, 0xdc, 0x91, 0xfc, 0x01, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x22, 0x80, 0x01, 0x0a, 0x10, 0x53,
+	0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95,
+	0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x49, 0x64, 0x12, 0x22, 0x0a, 0x0a, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73,
+	0x18, 0xcd, 0xd5, 0xc6, 0xf5, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x61, 0x74, 0x65,
+	0x67, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0c, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x6c, 0x5f, 0x69, 0x64, 0x73, 0x18, 0xf5, 0xf8, 0xdf, 0x98, 0x01, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x73, 0x22, 0x82, 0x01,
+	0x0a, 0x12, 0x55, 0x6e, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x22, 0x0a, 0x0a, 0x63, 0x61, 0x74, 0x65, 0x67,
+	0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0xcd, 0xd5, 0xc6, 0xf5, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0a, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0c, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x73, 0x18, 0xf5, 0xf8, 0xdf, 0x98,
+	0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49,
+	0x64, 0x73, 0x22, 0x2f, 0x0a, 0x0f, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x08, 0x67, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x6d,
+	0x73, 0x18, 0x93, 0xed, 0xf0, 0x59, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x67, 0x72, 0x61, 0x63,
+	0x65, 0x4d, 0x73, 0x22, 0xfa, 0x02, 0x0a, 0x0a, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x12, 0x1b, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xf7, 0xb1,
+	0xca, 0x33, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x1e, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0xb5, 0xed, 0xa9, 0xd9,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12,
+	0x1f, 0x0a, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x18, 0xf2, 0xe5, 0x86,
+	0x30, 0x20, 0x03, 0x28, 0x03, 0x52, 0x09, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73,
+	0x12, 0x46, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73,
+	0x18, 0x9f, 0xda, 0xae, 0xcf, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x68, 0x61, 0x70,
+	0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x43, 0x61,
+	0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x2c, 0x0a, 0x10, 0x70, 0x69, 0x6e, 0x67,
+	0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x6d, 0x73, 0x18, 0xc8, 0x8f, 0xdc,
+	0xf7, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x70, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74, 0x65,
+	0x72, 0x76, 0x61, 0x6c, 0x4d, 0x73, 0x12, 0x35, 0x0a, 0x15, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62,
+	0x65, 0x61, 0x74, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x6d, 0x73, 0x18,
+	0xe6, 0xa9, 0xa2, 0x55, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x68, 0x65, 0x61, 0x72, 0x74, 0x62,
+	0x65, 0x61, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4d, 0x73, 0x12, 0x27, 0x0a,
+	0x0d, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0xe7,
+	0x87, 0xe5, 0xd9, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x79,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x38, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f,
+	0x72, 0x6d, 0x18, 0xf2, 0x9d, 0xab, 0x52, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x6c, 0x61, 0x74, 0x66, 0x6f,
+	0x72, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d,
+	0x22, 0xf3, 0x02, 0x0a, 0x0a, 0x52, 0x65, 0x61, 0x64, 0x79, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4,
+	0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x70, 0x6c,
+	0x61, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0xb6, 0x92, 0x8e, 0x80, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x14,
+	0x0a, 0x03, 0x63, 0x77, 0x64, 0x18, 0xe1, 0xee, 0xc8, 0xd2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x63, 0x77, 0x64, 0x12, 0x1e, 0x0a, 0x08, 0x61, 0x74, 0x74, 0x61, 0x63, 0x68, 0x65, 0x64,
+	0x18, 0xd5, 0xa2, 0x86, 0xcc, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x61, 0x74, 0x74, 0x61,
+	0x63, 0x68, 0x65, 0x64, 0x12, 0x1b, 0x0a, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x18,
+	0x8f, 0xd2, 0xbe, 0x1f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e,
+	0x64, 0x12, 0x26, 0x0a, 0x0d, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x8b, 0xa2, 0xb8, 0x2b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x73, 0x68, 0x65,
+	0x6c, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x09, 0x63, 0x6f, 0x64,
+	0x65, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x18, 0xaf, 0xc5, 0xbd, 0xc9, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x08, 0x63, 0x6f, 0x64, 0x65, 0x50, 0x61, 0x67, 0x65, 0x12, 0x27, 0x0a, 0x0d, 0x72, 0x65,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x83, 0xe2, 0xdd, 0x96,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x64, 0x46,
+	0x72, 0x6f, 0x6d, 0x12, 0x13, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x9c, 0xdd, 0xa4, 0x49, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x1c, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75,
+	0x6d, 0x65, 0x64, 0x18, 0xea, 0xa3, 0xf7, 0xad, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x72,
+	0x65, 0x73, 0x75, 0x6d, 0x65, 0x64, 0x22, 0xe5, 0x01, 0x0a, 0x0b, 0x4f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x18, 0x85, 0x8b, 0xd4, 0xc3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x12, 0x1e, 0x0a, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x18,
+	0xb0, 0xcd, 0xf6, 0xf7, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e, 0x63, 0x6f, 0x64,
+	0x69, 0x6e, 0x67, 0x12, 0x24, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0xb5, 0xa8, 0xf2, 0xd6, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f,
+	0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x3e, 0x0a, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0xe3, 0xd3, 0x96, 0x94, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1c, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x13, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x9c, 0xdd, 0xa4, 0x49, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x95,
+	0x01, 0x0a, 0x09, 0x45, 0x78, 0x69, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49,
+	0x64, 0x12, 0x16, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0xf4, 0xbb, 0xcf, 0xc9, 0x01, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x1a, 0x0a, 0x06, 0x72, 0x65, 0x61,
+	0x73, 0x6f, 0x6e, 0x18, 0x99, 0x9e, 0x97, 0xc5, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
+	0xaf, 0x99, 0xb5, 0xd2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x13, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x9c, 0xdd, 0xa4, 0x49, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x89, 0x01, 0x0a, 0x0a, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0xf4, 0xbb, 0xcf, 0xc9, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x1b, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x18, 0xa4, 0xca, 0xca, 0x27, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x22, 0x6c, 0x0a, 0x09, 0x50, 0x6f, 0x6e, 0x67, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x18, 0x0a, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x18, 0xf8, 0xd5, 0xab, 0xc8, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x6e, 0x6f, 0x6e, 0x63, 0x65, 0x12, 0x20, 0x0a, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0xe3, 0xd3, 0x96, 0x94, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x23, 0x0a, 0x0b, 0x72,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0xc7, 0xbe, 0xa2, 0xe0, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x41, 0x74,
+	0x22, 0x56, 0x0a, 0x10, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x41, 0x63, 0x6b, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x12, 0x42, 0x0a, 0x09, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c,
+	0x73, 0x18, 0x86, 0xfb, 0xb4, 0xe9, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x6c, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x09, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73, 0x22, 0x83, 0x01, 0x0a, 0x0e, 0x43, 0x6c, 0x69,
+	0x70, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64,
+	0x12, 0x1f, 0x0a, 0x09, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0xf5, 0xa0,
+	0x97, 0x1b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x16, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0xfe, 0xc5, 0xa0, 0xef, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x13, 0x0a, 0x03, 0x73, 0x65, 0x71,
+	0x18, 0x9c, 0xdd, 0xa4, 0x49, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x81,
+	0x01, 0x0a, 0x0d, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18,
+	0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69,
+	0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0xf6,
+	0xd6, 0xc9, 0xc1, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x12,
+	0x1c, 0x0a, 0x07, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x18, 0xda, 0xe5, 0xec, 0xaf, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x13, 0x0a,
+	0x03, 0x73, 0x65, 0x71, 0x18, 0x9c, 0xdd, 0xa4, 0x49, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73,
+	0x65, 0x71, 0x22, 0xbb, 0x02, 0x0a, 0x0d, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c,
+	0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x04, 0x63, 0x6f, 0x6c,
+	0x73, 0x18, 0x86, 0xc4, 0x9b, 0xba, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f, 0x6c,
+	0x73, 0x12, 0x15, 0x0a, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x18, 0xd8, 0x9f, 0xe1, 0x51, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x12, 0x36, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73,
+	0x6f, 0x72, 0x18, 0x8f, 0xc6, 0xe2, 0x19, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68,
+	0x6f, 0x74, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x52, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72,
+	0x12, 0x2c, 0x0a, 0x10, 0x61, 0x6c, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x63,
+	0x72, 0x65, 0x65, 0x6e, 0x18, 0xd9, 0xcc, 0x94, 0x3c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x61,
+	0x6c, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x74, 0x65, 0x53, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x12, 0x32,
+	0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0xbc, 0xae, 0x9a, 0x0f, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53,
+	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x4c, 0x69, 0x6e, 0x65, 0x52, 0x05, 0x6c, 0x69, 0x6e,
+	0x65, 0x73, 0x12, 0x3c, 0x0a, 0x0a, 0x73, 0x63, 0x72, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b,
+	0x18, 0xe7, 0xa6, 0xe1, 0x46, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74,
+	0x4c, 0x69, 0x6e, 0x65, 0x52, 0x0a, 0x73, 0x63, 0x72, 0x6f, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b,
+	0x22, 0x8d, 0x01, 0x0a, 0x0c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64,
+	0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x05, 0x6d, 0x61, 0x74,
+	0x63, 0x68, 0x18, 0x96, 0xd0, 0x85, 0xf1, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x61,
+	0x74, 0x63, 0x68, 0x12, 0x1b, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74, 0x18, 0x9c,
+	0xae, 0xa2, 0x64, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x78, 0x74,
+	0x22, 0xdb, 0x01, 0x0a, 0x12, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69,
+	0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12,
+	0x23, 0x0a, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x73, 0x18, 0xb4,
+	0xa9, 0xfa, 0xaf, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x4c,
+	0x69, 0x6e, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x18,
+	0x8e, 0xb7, 0xda, 0xa7, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x61,
+	0x74, 0x63, 0x68, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x12, 0x20, 0x0a, 0x09,
+	0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x18, 0xc9, 0xbe, 0xa8, 0xc2, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x09, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x64, 0x22, 0xde,
+	0x01, 0x0a, 0x0b, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23,
+	0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f,
+	0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61,
+	0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x18, 0x92, 0xb4, 0xfd, 0xcc, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d,
+	0x61, 0x74, 0x12, 0x17, 0x0a, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x84, 0x91, 0xcc, 0x2d,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x62, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x07, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0xe2, 0x94, 0x81, 0x86, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x15, 0x0a, 0x04, 0x70, 0x61, 0x74,
+	0x68, 0x18, 0xd6, 0xa7, 0xa3, 0x24, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x12, 0x1d, 0x0a, 0x08, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x65, 0x64, 0x18, 0xa9, 0xb0, 0xed,
+	0x4a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x72, 0x65, 0x64, 0x61, 0x63, 0x74, 0x65, 0x64, 0x22,
+	0xb7, 0x01, 0x0a, 0x11, 0x42, 0x61, 0x63, 0x6b, 0x70, 0x72, 0x65, 0x73, 0x73, 0x75, 0x72, 0x65,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x18, 0xf6, 0xd6, 0xc9, 0xc1, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x25, 0x0a, 0x0c, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x5f, 0x62,
+	0x79, 0x74, 0x65, 0x73, 0x18, 0x97, 0x86, 0xc5, 0xa4, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b,
+	0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0d, 0x64,
+	0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0xe4, 0xa6, 0xa1,
+	0xcd, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x12, 0x13, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x9c, 0xdd, 0xa4, 0x49,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0xb2, 0x01, 0x0a, 0x0b, 0x43, 0x6c,
+	0x6f, 0x73, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72,
+	0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x19,
+	0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0xf0, 0x97, 0x9f, 0x5a, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x24, 0x0a, 0x09, 0x65, 0x78, 0x69,
+	0x74, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0xb4, 0xde, 0x9b, 0xd9, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x48, 0x00, 0x52, 0x08, 0x65, 0x78, 0x69, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x88, 0x01, 0x01, 0x12,
+	0x1a, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x99, 0x9e, 0x97, 0xc5, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x13, 0x0a, 0x03, 0x73,
+	0x65, 0x71, 0x18, 0x9c, 0xdd, 0xa4, 0x49, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71,
+	0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x65, 0x78, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x89,
+	0x01, 0x0a, 0x0e, 0x57, 0x69, 0x6c, 0x6c, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64,
+	0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x18, 0x99, 0x9e, 0x97, 0xc5, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0b, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x5f, 0x69, 0x6e, 0x5f, 0x6d,
+	0x73, 0x18, 0xdc, 0x84, 0x89, 0x65, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x63, 0x6c, 0x6f, 0x73,
+	0x65, 0x49, 0x6e, 0x4d, 0x73, 0x12, 0x13, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x9c, 0xdd, 0xa4,
+	0x49, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0xc7, 0x01, 0x0a, 0x0a, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x20, 0x0a, 0x09, 0x74, 0x65, 0x72,
+	0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73, 0x18, 0x86, 0xfb, 0xb4, 0xe9, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73, 0x12, 0x27, 0x0a, 0x0d, 0x6d,
+	0x61, 0x78, 0x5f, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73, 0x18, 0xfa, 0xbd, 0xfd,
+	0xc0, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x54, 0x65, 0x72, 0x6d, 0x69,
+	0x6e, 0x61, 0x6c, 0x73, 0x12, 0x37, 0x0a, 0x07, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x18,
+	0xd9, 0xe8, 0xf7, 0x97, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69,
+	0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x52, 0x07, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x35, 0x0a,
+	0x07, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x97, 0xf0, 0xb5, 0x86, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x17, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32,
+	0x2e, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x07, 0x63, 0x6c, 0x69,
+	0x65, 0x6e, 0x74, 0x73, 0x22, 0x52, 0x0a, 0x0e, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61,
+	0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x09, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65,
+	0x5f, 0x6d, 0x73, 0x18, 0xbd, 0xf7, 0xef, 0x5d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x75, 0x70,
+	0x74, 0x69, 0x6d, 0x65, 0x4d, 0x73, 0x12, 0x20, 0x0a, 0x09, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e,
+	0x61, 0x6c, 0x73, 0x18, 0x86, 0xfb, 0xb4, 0xe9, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74,
+	0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73, 0x22, 0x6b, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x3b, 0x0a, 0x09, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x73, 0x18, 0x86, 0xfb, 0xb4, 0xe9, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54, 0x65,
+	0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x09, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x73, 0x22, 0x77, 0x0a, 0x0e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x64, 0x41,
+	0x6c, 0x6c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x42, 0x0a, 0x09, 0x74, 0x65,
+	0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73, 0x18, 0x86, 0xfb, 0xb4, 0xe9, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x20, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e,
+	0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x52, 0x09, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73, 0x22, 0x9c,
+	0x01, 0x0a, 0x0b, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x21,
+	0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda,
+	0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49,
+	0x64, 0x12, 0x1c, 0x0a, 0x07, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x18, 0xfe, 0xc4, 0xf3,
+	0x99, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x12,
+	0x18, 0x0a, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x18, 0x97, 0x92, 0xb5, 0x99, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x05, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x32, 0x0a, 0x06, 0x73, 0x68, 0x65,
+	0x6c, 0x6c, 0x73, 0x18, 0x96, 0xdc, 0x87, 0xc6, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x68, 0x65, 0x6c,
+	0x6c, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x22, 0x9c, 0x01,
+	0x0a, 0x0a, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0a,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12,
+	0x1b, 0x0a, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x18, 0x8f, 0xd2, 0xbe, 0x1f, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x12, 0x19, 0x0a, 0x06,
+	0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x18, 0xe2, 0xcc, 0xfc, 0x30, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x06, 0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x12, 0x1a, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x70, 0x74,
+	0x79, 0x18, 0x9c, 0xd3, 0xed, 0xcc, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x77, 0x69, 0x6e,
+	0x70, 0x74, 0x79, 0x12, 0x17, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x91, 0xc7, 0xc8,
+	0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x85, 0x01, 0x0a,
+	0x0e, 0x54, 0x6d, 0x75, 0x78, 0x50, 0x61, 0x6e, 0x65, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95,
+	0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x49, 0x64, 0x12, 0x1b, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xd7, 0xdf,
+	0x85, 0x1b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x33, 0x0a, 0x05, 0x70, 0x61, 0x6e, 0x65, 0x73, 0x18, 0xe8, 0x93, 0x82, 0xf0, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32,
+	0x2e, 0x54, 0x6d, 0x75, 0x78, 0x50, 0x61, 0x6e, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x70,
+	0x61, 0x6e, 0x65, 0x73, 0x22, 0x82, 0x01, 0x0a, 0x09, 0x53, 0x69, 0x7a, 0x65, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69,
+	0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72,
+	0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x04, 0x63, 0x6f,
+	0x6c, 0x73, 0x18, 0x86, 0xc4, 0x9b, 0xba, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f,
+	0x6c, 0x73, 0x12, 0x15, 0x0a, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x18, 0xd8, 0x9f, 0xe1, 0x51, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x22, 0x62, 0x0a, 0x0f, 0x53, 0x63, 0x72,
+	0x65, 0x65, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0b,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49,
+	0x64, 0x12, 0x15, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x92, 0xa2, 0xc5, 0x63, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x13, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18,
+	0x9c, 0xdd, 0xa4, 0x49, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x22, 0x85, 0x01,
+	0x0a, 0x0c, 0x55, 0x70, 0x67, 0x72, 0x61, 0x64, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x21,
+	0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda,
+	0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49,
+	0x64, 0x12, 0x1b, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xf7, 0xb1, 0xca,
+	0x33, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x13,
+	0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0xa2, 0xeb, 0x88, 0x4a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03,
+	0x70, 0x69, 0x64, 0x12, 0x20, 0x0a, 0x09, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x73,
+	0x18, 0x86, 0xfb, 0xb4, 0xe9, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x73, 0x22, 0x72, 0x0a, 0x0d, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x41, 0x63,
+	0x6b, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95, 0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x49, 0x64, 0x12, 0x1e, 0x0a, 0x08, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0xb5, 0xed, 0xa9, 0xd9, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x12, 0x1e, 0x0a, 0x08, 0x66, 0x65, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0xaa, 0xdc, 0x91, 0xfc, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x22, 0x7e, 0x0a, 0x12, 0x53, 0x75, 0x62,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x21, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f, 0x69, 0x64, 0x18, 0xdf, 0x95,
+	0xda, 0xb3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x49, 0x64, 0x12, 0x45, 0x0a, 0x0a, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73,
+	0x18, 0xcd, 0xd5, 0xc6, 0xf5, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x68, 0x61, 0x70,
+	0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72,
+	0x79, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x63,
+	0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x22, 0x95, 0x02, 0x0a, 0x0b, 0x4d, 0x6f,
+	0x63, 0x6b, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x31, 0x0a, 0x06, 0x6f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x18, 0x84, 0x8f, 0xaa, 0xcd, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4d, 0x6f, 0x63, 0x6b,
+	0x53, 0x74, 0x65, 0x70, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x1a, 0x0a, 0x06,
+	0x70, 0x72, 0x6f, 0x6d, 0x70, 0x74, 0x18, 0x9b, 0xd8, 0xa1, 0xff, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x70, 0x72, 0x6f, 0x6d, 0x70, 0x74, 0x12, 0x43, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c,
+	0x69, 0x65, 0x73, 0x18, 0xbf, 0x9f, 0xb5, 0xee, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x25, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4d, 0x6f, 0x63, 0x6b,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x65, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x65, 0x73, 0x12, 0x1b, 0x0a,
+	0x07, 0x6e, 0x6f, 0x5f, 0x65, 0x63, 0x68, 0x6f, 0x18, 0xab, 0xde, 0xb3, 0xf2, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x6e, 0x6f, 0x45, 0x63, 0x68, 0x6f, 0x1a, 0x55, 0x0a, 0x0c, 0x52, 0x65,
+	0x70, 0x6c, 0x69, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2f, 0x0a, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4d, 0x6f, 0x63, 0x6b, 0x53, 0x74,
+	0x65, 0x70, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x40, 0x0a, 0x08, 0x4d, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x65, 0x70, 0x12, 0x16, 0x0a,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x85, 0x8b, 0xd4, 0xc3, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x1c, 0x0a, 0x08, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6d,
+	0x73, 0x18, 0x94, 0xcd, 0xa7, 0x7b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x64, 0x65, 0x6c, 0x61,
+	0x79, 0x4d, 0x73, 0x22, 0x73, 0x0a, 0x0b, 0x54, 0x6d, 0x75, 0x78, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x1b, 0x0a, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xd7, 0xdf,
+	0x85, 0x1b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x15, 0x0a, 0x04, 0x70, 0x61, 0x6e, 0x65, 0x18, 0xab, 0xf4, 0x9e, 0x6a, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x70, 0x61, 0x6e, 0x65, 0x12, 0x1a, 0x0a, 0x06, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74,
+	0x18, 0x8c, 0xfa, 0xcf, 0x80, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x63, 0x6b,
+	0x65, 0x74, 0x12, 0x14, 0x0a, 0x03, 0x73, 0x73, 0x68, 0x18, 0x91, 0xa8, 0x83, 0xf2, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x73, 0x68, 0x22, 0xbc, 0x01, 0x0a, 0x11, 0x43, 0x6f, 0x6e,
+	0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1f,
+	0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x80, 0xbb, 0xc2, 0x56,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12,
+	0x1f, 0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x18, 0x98, 0xf0, 0xc5,
+	0x6d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72,
+	0x12, 0x1a, 0x0a, 0x07, 0x65, 0x78, 0x65, 0x63, 0x5f, 0x69, 0x64, 0x18, 0xf1, 0xcc, 0xf1, 0x58,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x65, 0x78, 0x65, 0x63, 0x49, 0x64, 0x12, 0x1c, 0x0a, 0x07,
+	0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0xd2, 0xa2, 0xeb, 0x9a, 0x01, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x14, 0x0a, 0x03, 0x63, 0x77,
+	0x64, 0x18, 0xe1, 0xee, 0xc8, 0xd2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x77, 0x64,
+	0x12, 0x15, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0xf2, 0xae, 0xe6, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x5c, 0x0a, 0x0d, 0x53, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x04, 0x70, 0x69, 0x70, 0x65,
+	0x18, 0xf9, 0x95, 0xfe, 0xc0, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x69, 0x70, 0x65,
+	0x12, 0x25, 0x0a, 0x09, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x18, 0x86, 0xe0,
+	0xa9, 0xbd, 0x01, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x09, 0x72, 0x65, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x72, 0x65, 0x63, 0x6f,
+	0x6e, 0x6e, 0x65, 0x63, 0x74, 0x22, 0x8e, 0x01, 0x0a, 0x0d, 0x54, 0x65, 0x6c, 0x6e, 0x65, 0x74,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x18,
+	0xaf, 0xf4, 0x81, 0x80, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x73, 0x74, 0x12,
+	0x16, 0x0a, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x18, 0xa6, 0x96, 0x95, 0xdf, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x04, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x1b, 0x0a, 0x04, 0x6e, 0x61, 0x77, 0x73, 0x18,
+	0xe2, 0x8d, 0x95, 0xf8, 0x01, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x61, 0x77,
+	0x73, 0x88, 0x01, 0x01, 0x12, 0x27, 0x0a, 0x0d, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0xf9, 0xdb, 0x80, 0xc1, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x42, 0x07, 0x0a,
+	0x05, 0x5f, 0x6e, 0x61, 0x77, 0x73, 0x22, 0x41, 0x0a, 0x0d, 0x50, 0x79, 0x74, 0x68, 0x6f, 0x6e,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x16, 0x0a, 0x04, 0x76, 0x65, 0x6e, 0x76, 0x18,
+	0xb8, 0xda, 0xe6, 0xb0, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x76, 0x65, 0x6e, 0x76, 0x12,
+	0x18, 0x0a, 0x05, 0x63, 0x6f, 0x6e, 0x64, 0x61, 0x18, 0x94, 0xa4, 0xe7, 0xd9, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x63, 0x6f, 0x6e, 0x64, 0x61, 0x22, 0x88, 0x01, 0x0a, 0x13, 0x56, 0x69,
+	0x73, 0x75, 0x61, 0x6c, 0x53, 0x74, 0x75, 0x64, 0x69, 0x6f, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x12, 0x1b, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xf7, 0xb1, 0xca,
+	0x33, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1b,
+	0x0a, 0x07, 0x74, 0x6f, 0x6f, 0x6c, 0x73, 0x65, 0x74, 0x18, 0xdd, 0x8c, 0xd7, 0x2f, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x74, 0x6f, 0x6f, 0x6c, 0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x04, 0x61,
+	0x72, 0x63, 0x68, 0x18, 0x89, 0x99, 0x88, 0x80, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61,
+	0x72, 0x63, 0x68, 0x12, 0x1f, 0x0a, 0x09, 0x68, 0x6f, 0x73, 0x74, 0x5f, 0x61, 0x72, 0x63, 0x68,
+	0x18, 0xfb, 0xbf, 0xb1, 0x92, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x68, 0x6f, 0x73, 0x74,
+	0x41, 0x72, 0x63, 0x68, 0x22, 0xa2, 0x02, 0x0a, 0x11, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x43, 0x61,
+	0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x1f, 0x0a, 0x09, 0x65, 0x6e,
+	0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x73, 0x18, 0xf9, 0xda, 0xad, 0x4b, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x09, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x73, 0x12, 0x24, 0x0a, 0x0b, 0x63,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xb5, 0xa8, 0xf2, 0xd6, 0x01,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x2d, 0x0a, 0x10, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x65, 0x6e, 0x63, 0x6f,
+	0x64, 0x69, 0x6e, 0x67, 0x73, 0x18, 0xc6, 0x86, 0x80, 0xb9, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0f, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x45, 0x6e, 0x63, 0x6f, 0x64, 0x69, 0x6e, 0x67, 0x73,
+	0x12, 0x29, 0x0a, 0x0e, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x66, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x73, 0x18, 0xcf, 0x8b, 0xdc, 0xb9, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x6f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x73, 0x12, 0x1d, 0x0a, 0x08, 0x62,
+	0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x18, 0xd4, 0x89, 0x98, 0x5b, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x73, 0x12, 0x1e, 0x0a, 0x08, 0x66, 0x65,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0xaa, 0xdc, 0x91, 0xfc, 0x01, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12, 0x2d, 0x0a, 0x10, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x5f, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x93,
+	0xb9, 0x8e, 0x8b, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x43,
+	0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x22, 0xb3, 0x01, 0x0a, 0x0c, 0x50, 0x6c,
+	0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x20, 0x0a, 0x0a, 0x6f, 0x73,
+	0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xd9, 0x8f, 0x85, 0x5f, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x6f, 0x73, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x08,
+	0x6f, 0x73, 0x5f, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x18, 0xfd, 0xc1, 0xaa, 0x74, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x07, 0x6f, 0x73, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x12, 0x19, 0x0a, 0x06, 0x63, 0x6f,
+	0x6e, 0x70, 0x74, 0x79, 0x18, 0xe2, 0xcc, 0xfc, 0x30, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x63,
+	0x6f, 0x6e, 0x70, 0x74, 0x79, 0x12, 0x26, 0x0a, 0x0d, 0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x5f,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0xdf, 0xe9, 0xa3, 0x29, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x20, 0x0a,
+	0x0a, 0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x5f, 0x64, 0x6c, 0x6c, 0x18, 0x82, 0x88, 0xac, 0x45,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x70, 0x74, 0x79, 0x44, 0x6c, 0x6c, 0x22,
+	0x4f, 0x0a, 0x0f, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x12, 0x25, 0x0a, 0x0c, 0x6d, 0x6f, 0x6e, 0x6f, 0x74, 0x6f, 0x6e, 0x69, 0x63, 0x5f,
+	0x75, 0x73, 0x18, 0xaf, 0xcf, 0xe7, 0xb1, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x6d, 0x6f,
+	0x6e, 0x6f, 0x74, 0x6f, 0x6e, 0x69, 0x63, 0x55, 0x73, 0x12, 0x15, 0x0a, 0x04, 0x77, 0x61, 0x6c,
+	0x6c, 0x18, 0xd5, 0x83, 0xa0, 0x39, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x77, 0x61, 0x6c, 0x6c,
+	0x22, 0xa5, 0x01, 0x0a, 0x13, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x43, 0x6c, 0x6f,
+	0x73, 0x65, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d,
+	0x69, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x19, 0x0a,
+	0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0xf0, 0x97, 0x9f, 0x5a, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x24, 0x0a, 0x09, 0x65, 0x78, 0x69, 0x74,
+	0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0xb4, 0xde, 0x9b, 0xd9, 0x01, 0x20, 0x01, 0x28, 0x03, 0x48,
+	0x00, 0x52, 0x08, 0x65, 0x78, 0x69, 0x74, 0x43, 0x6f, 0x64, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1a,
+	0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x99, 0x9e, 0x97, 0xc5, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x65,
+	0x78, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x22, 0x52, 0x0a, 0x0e, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x10, 0x0a, 0x01, 0x78, 0x18,
+	0x87, 0x83, 0xbb, 0xe8, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x01, 0x78, 0x12, 0x10, 0x0a, 0x01,
+	0x79, 0x18, 0xf4, 0xff, 0xba, 0xe0, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x01, 0x79, 0x12, 0x1c,
+	0x0a, 0x07, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x18, 0x81, 0x88, 0xee, 0xa7, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x22, 0x58, 0x0a, 0x0c,
+	0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x4c, 0x69, 0x6e, 0x65, 0x12, 0x16, 0x0a, 0x04,
+	0x74, 0x65, 0x78, 0x74, 0x18, 0xfe, 0xc5, 0xa0, 0xef, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x74, 0x65, 0x78, 0x74, 0x12, 0x30, 0x0a, 0x04, 0x72, 0x75, 0x6e, 0x73, 0x18, 0xdb, 0xf6, 0xcc,
+	0xd1, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74,
+	0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x75, 0x6e,
+	0x52, 0x04, 0x72, 0x75, 0x6e, 0x73, 0x22, 0x9a, 0x02, 0x0a, 0x0b, 0x53, 0x6e, 0x61, 0x70, 0x73,
+	0x68, 0x6f, 0x74, 0x52, 0x75, 0x6e, 0x12, 0x17, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
+	0xdf, 0xfa, 0xb0, 0x29, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12,
+	0x19, 0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0xb5, 0xf9, 0xc6, 0x1e, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x12, 0x11, 0x0a, 0x02, 0x66, 0x67,
+	0x18, 0xda, 0xda, 0x90, 0x51, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x66, 0x67, 0x12, 0x12, 0x0a,
+	0x02, 0x62, 0x67, 0x18, 0xe6, 0xff, 0xb0, 0xd1, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x62,
+	0x67, 0x12, 0x16, 0x0a, 0x04, 0x62, 0x6f, 0x6c, 0x64, 0x18, 0xd6, 0xb2, 0xe4, 0xf4, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x04, 0x62, 0x6f, 0x6c, 0x64, 0x12, 0x14, 0x0a, 0x03, 0x64, 0x69, 0x6d,
+	0x18, 0x99, 0xda, 0x92, 0x83, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x64, 0x69, 0x6d, 0x12,
+	0x19, 0x0a, 0x06, 0x69, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x18, 0xed, 0xe7, 0xac, 0x07, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x69, 0x74, 0x61, 0x6c, 0x69, 0x63, 0x12, 0x1f, 0x0a, 0x09, 0x75, 0x6e,
+	0x64, 0x65, 0x72, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0xff, 0xca, 0x92, 0x2c, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x09, 0x75, 0x6e, 0x64, 0x65, 0x72, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x1c, 0x0a, 0x07, 0x69,
+	0x6e, 0x76, 0x65, 0x72, 0x73, 0x65, 0x18, 0xc3, 0x8e, 0x8f, 0x90, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x07, 0x69, 0x6e, 0x76, 0x65, 0x72, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x0d, 0x73, 0x74, 0x72,
+	0x69, 0x6b, 0x65, 0x74, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68, 0x18, 0xe4, 0xb4, 0xcb, 0xf5, 0x01,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x73, 0x74, 0x72, 0x69, 0x6b, 0x65, 0x74, 0x68, 0x72, 0x6f,
+	0x75, 0x67, 0x68, 0x22, 0xaa, 0x01, 0x0a, 0x0b, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x4d, 0x61,
+	0x74, 0x63, 0x68, 0x12, 0x16, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0xc7, 0xc8, 0xed, 0xbe,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x1a, 0x0a, 0x06, 0x63,
+	0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x18, 0xa7, 0x86, 0xed, 0xaf, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x12, 0x19, 0x0a, 0x06, 0x6c, 0x65, 0x6e, 0x67, 0x74,
+	0x68, 0x18, 0xb5, 0xf9, 0xc6, 0x1e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6c, 0x65, 0x6e, 0x67,
+	0x74, 0x68, 0x12, 0x16, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0xfe, 0xc5, 0xa0, 0xef, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x1a, 0x0a, 0x06, 0x62, 0x65,
+	0x66, 0x6f, 0x72, 0x65, 0x18, 0xfe, 0xb6, 0x9a, 0xc2, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06,
+	0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x18, 0x0a, 0x05, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18,
+	0xe9, 0x97, 0xbb, 0xdb, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x66, 0x74, 0x65, 0x72,
+	0x22, 0x98, 0x01, 0x0a, 0x0c, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x12, 0x1c, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x18, 0x9c, 0xbc, 0x9f,
+	0xe1, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x12,
+	0x1b, 0x0a, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x6d, 0x73, 0x18, 0xbd, 0x86, 0x90, 0xa3, 0x01,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x6c, 0x61, 0x73, 0x74, 0x4d, 0x73, 0x12, 0x18, 0x0a, 0x06,
+	0x6d, 0x69, 0x6e, 0x5f, 0x6d, 0x73, 0x18, 0xc7, 0x94, 0x96, 0x3f, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x05, 0x6d, 0x69, 0x6e, 0x4d, 0x73, 0x12, 0x19, 0x0a, 0x06, 0x61, 0x76, 0x67, 0x5f, 0x6d, 0x73,
+	0x18, 0x93, 0x92, 0xfc, 0x88, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x61, 0x76, 0x67, 0x4d,
+	0x73, 0x12, 0x18, 0x0a, 0x06, 0x6d, 0x61, 0x78, 0x5f, 0x6d, 0x73, 0x18, 0xdd, 0xb7, 0xba, 0x1e,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x6d, 0x61, 0x78, 0x4d, 0x73, 0x22, 0x94, 0x01, 0x0a, 0x0a,
+	0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0xa0, 0x8e, 0xe4, 0xb9, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x15,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x86, 0x89, 0xed, 0x69, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0xf7, 0xb1, 0xca, 0x33, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0xb5,
+	0xed, 0xa9, 0xd9, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x12, 0x1e, 0x0a, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0xaa,
+	0xdc, 0x91, 0xfc, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x73, 0x22, 0xaa, 0x02, 0x0a, 0x0c, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x23, 0x0a, 0x0b, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x5f,
+	0x69, 0x64, 0x18, 0xe4, 0x9f, 0x92, 0xe2, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x74, 0x65,
+	0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x64, 0x12, 0x25, 0x0a, 0x0c, 0x64, 0x69, 0x73, 0x70,
+	0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0xb6, 0x92, 0x8e, 0x80, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x14, 0x0a, 0x03, 0x63, 0x77, 0x64, 0x18, 0xe1, 0xee, 0xc8, 0xd2, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x63, 0x77, 0x64, 0x12, 0x3b, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0xa0, 0xb9,
+	0xe5, 0xa0, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x49, 0x6e,
+	0x66, 0x6f, 0x2e, 0x54, 0x61, 0x67, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x74, 0x61,
+	0x67, 0x73, 0x12, 0x1e, 0x0a, 0x09, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6d, 0x73, 0x18,
+	0xbd, 0xf7, 0xef, 0x5d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65,
+	0x4d, 0x73, 0x12, 0x22, 0x0a, 0x0b, 0x73, 0x63, 0x72, 0x65, 0x65, 0x6e, 0x5f, 0x6d, 0x6f, 0x64,
+	0x65, 0x18, 0x8e, 0xb0, 0xa6, 0x6a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x63, 0x72, 0x65,
+	0x65, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x1a, 0x37, 0x0a, 0x09, 0x54, 0x61, 0x67, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
+	0x77, 0x0a, 0x09, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x15, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x86, 0x89, 0xed, 0x69, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x15, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0xd6, 0xa7, 0xa3, 0x24,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1b, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0xf7, 0xb1, 0xca, 0x33, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x09, 0x61, 0x76, 0x61, 0x69, 0x6c,
+	0x61, 0x62, 0x6c, 0x65, 0x18, 0x98, 0xd6, 0xfc, 0x37, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x61,
+	0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x80, 0x02, 0x0a, 0x0c, 0x54, 0x6d, 0x75,
+	0x78, 0x50, 0x61, 0x6e, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x15, 0x0a, 0x04, 0x70, 0x61, 0x6e,
+	0x65, 0x18, 0xab, 0xf4, 0x9e, 0x6a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x6e, 0x65,
+	0x12, 0x19, 0x0a, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x9d, 0x99, 0xd2, 0x0b, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x22, 0x0a, 0x0b, 0x77,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x9e, 0xaf, 0xb6, 0x2c, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0a, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4e, 0x61, 0x6d, 0x65, 0x12,
+	0x17, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0xcb, 0xef, 0xab, 0x48, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x16, 0x0a, 0x04, 0x63, 0x6f, 0x6c, 0x73,
+	0x18, 0x86, 0xc4, 0x9b, 0xba, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x63, 0x6f, 0x6c, 0x73,
+	0x12, 0x15, 0x0a, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x18, 0xd8, 0x9f, 0xe1, 0x51, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x04, 0x72, 0x6f, 0x77, 0x73, 0x12, 0x1a, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x76,
+	0x65, 0x18, 0x8f, 0xf8, 0xde, 0xcb, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x63, 0x74,
+	0x69, 0x76, 0x65, 0x12, 0x1c, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0xd2,
+	0xa2, 0xeb, 0x9a, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e,
+	0x64, 0x12, 0x18, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18, 0xa9, 0xf7, 0x9c, 0xc3, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x22, 0x75, 0x0a, 0x14, 0x43,
+	0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x18,
+	0xd1, 0xca, 0xc5, 0x79, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x61, 0x74, 0x65, 0x67, 0x6f,
+	0x72, 0x79, 0x12, 0x22, 0x0a, 0x0a, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x64,
+	0x18, 0x85, 0x86, 0xc5, 0xe9, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x73, 0x75, 0x62, 0x73,
+	0x63, 0x72, 0x69, 0x62, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x06, 0x65, 0x78, 0x63, 0x65, 0x70, 0x74,
+	0x18, 0xb0, 0xd0, 0xb9, 0xc5, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x65, 0x78, 0x63, 0x65,
+	0x70, 0x74, 0x22, 0x3b, 0x0a, 0x0c, 0x4d, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x65, 0x70, 0x4c, 0x69,
+	0x73, 0x74, 0x12, 0x2b, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e,
+	0x4d, 0x6f, 0x63, 0x6b, 0x53, 0x74, 0x65, 0x70, 0x52, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x32,
+	0xcf, 0x03, 0x0a, 0x08, 0x54, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x12, 0x37, 0x0a, 0x07,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x28, 0x01, 0x30, 0x01, 0x12, 0x38, 0x0a, 0x04, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x18, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70,
+	0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12,
+	0x3b, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x19, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e,
+	0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76,
+	0x32, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x46, 0x0a, 0x0a,
+	0x4c, 0x69, 0x73, 0x74, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x12, 0x1e, 0x2e, 0x68, 0x61, 0x70,
+	0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x68, 0x65,
+	0x6c, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x68, 0x61, 0x70,
+	0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x53, 0x68, 0x65, 0x6c, 0x6c, 0x73, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x12, 0x3b, 0x0a, 0x05, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x12, 0x19, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x72, 0x6f, 0x62,
+	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e,
+	0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x50, 0x72, 0x6f, 0x62, 0x65, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x45, 0x0a, 0x08, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x41, 0x6c, 0x6c, 0x12, 0x1c, 0x2e,
+	0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6c, 0x6f, 0x73,
+	0x65, 0x41, 0x6c, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x68, 0x61,
+	0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x64,
+	0x41, 0x6c, 0x6c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x47, 0x0a, 0x08, 0x53, 0x68, 0x75, 0x74,
+	0x64, 0x6f, 0x77, 0x6e, 0x12, 0x1c, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e,
+	0x76, 0x32, 0x2e, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x68, 0x61, 0x70, 0x69, 0x2e, 0x70, 0x74, 0x79, 0x2e, 0x76, 0x32,
+	0x2e, 0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x41, 0x63, 0x6b, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x42, 0x32, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x74, 0x69, 0x61, 0x6e, 0x6e, 0x2f, 0x68, 0x61, 0x70, 0x69, 0x2f, 0x63, 0x6c, 0x69, 0x2f, 0x73,
+	0x69, 0x64, 0x65, 0x63, 0x61, 0x72, 0x2f, 0x68, 0x61, 0x70, 0x69, 0x2d, 0x70, 0x74, 0x79, 0x2f,
+	0x70, 0x74, 0x79, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+})
+
+var (
+	file_terminal_proto_rawDescOnce sync.Once
+	file_terminal_proto_rawDescData []byte
+)
+
+func file_terminal_proto_rawDescGZIP() []byte {
+	file_terminal_proto_rawDescOnce.Do(func() {
+		file_terminal_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_terminal_proto_rawDesc), len(file_terminal_proto_rawDesc)))
+	})
+	return file_terminal_proto_rawDescData
+}
+
+var file_terminal_proto_msgTypes = make([]protoimpl.MessageInfo, 86)
+var file_terminal_proto_goTypes = []any{
+	(*Request)(nil),              // 0: hapi.pty.v2.Request
+	(*Event)(nil),                // 1: hapi.pty.v2.Event
+	(*AuthRequest)(nil),          // 2: hapi.pty.v2.AuthRequest
+	(*OpenRequest)(nil),          // 3: hapi.pty.v2.OpenRequest
+	(*WriteRequest)(nil),         // 4: hapi.pty.v2.WriteRequest
+	(*ResizeRequest)(nil),        // 5: hapi.pty.v2.ResizeRequest
+	(*CloseRequest)(nil),         // 6: hapi.pty.v2.CloseRequest
+	(*SnapshotRequest)(nil),      // 7: hapi.pty.v2.SnapshotRequest
+	(*WaitRequest)(nil),          // 8: hapi.pty.v2.WaitRequest
+	(*SearchRequest)(nil),        // 9: hapi.pty.v2.SearchRequest
+	(*ExportRequest)(nil),        // 10: hapi.pty.v2.ExportRequest
+	(*PingRequest)(nil),          // 11: hapi.pty.v2.PingRequest
+	(*StatsRequest)(nil),         // 12: hapi.pty.v2.StatsRequest
+	(*KillProcessRequest)(nil),   // 13: hapi.pty.v2.KillProcessRequest
+	(*EofRequest)(nil),           // 14: hapi.pty.v2.EofRequest
+	(*BroadcastRequest)(nil),     // 15: hapi.pty.v2.BroadcastRequest
+	(*ListRequest)(nil),          // 16: hapi.pty.v2.ListRequest
+	(*CloseAllRequest)(nil),      // 17: hapi.pty.v2.CloseAllRequest
+	(*ListShellsRequest)(nil),    // 18: hapi.pty.v2.ListShellsRequest
+	(*ProbeRequest)(nil),         // 19: hapi.pty.v2.ProbeRequest
+	(*TmuxPanesRequest)(nil),     // 20: hapi.pty.v2.TmuxPanesRequest
+	(*GetSizeRequest)(nil),       // 21: hapi.pty.v2.GetSizeRequest
+	(*FocusRequest)(nil),         // 22: hapi.pty.v2.FocusRequest
+	(*UpgradeRequest)(nil),       // 23: hapi.pty.v2.UpgradeRequest
+	(*ClientHelloRequest)(nil),   // 24: hapi.pty.v2.ClientHelloRequest
+	(*SubscribeRequest)(nil),     // 25: hapi.pty.v2.SubscribeRequest
+	(*UnsubscribeRequest)(nil),   // 26: hapi.pty.v2.UnsubscribeRequest
+	(*ShutdownRequest)(nil),      // 27: hapi.pty.v2.ShutdownRequest
+	(*HelloEvent)(nil),           // 28: hapi.pty.v2.HelloEvent
+	(*ReadyEvent)(nil),           // 29: hapi.pty.v2.ReadyEvent
+	(*OutputEvent)(nil),          // 30: hapi.pty.v2.OutputEvent
+	(*ExitEvent)(nil),            // 31: hapi.pty.v2.ExitEvent
+	(*ErrorEvent)(nil),           // 32: hapi.pty.v2.ErrorEvent
+	(*PongEvent)(nil),            // 33: hapi.pty.v2.PongEvent
+	(*ShutdownAckEvent)(nil),     // 34: hapi.pty.v2.ShutdownAckEvent
+	(*ClipboardEvent)(nil),       // 35: hapi.pty.v2.ClipboardEvent
+	(*ProgressEvent)(nil),        // 36: hapi.pty.v2.ProgressEvent
+	(*SnapshotEvent)(nil),        // 37: hapi.pty.v2.SnapshotEvent
+	(*MatchedEvent)(nil),         // 38: hapi.pty.v2.MatchedEvent
+	(*SearchResultsEvent)(nil),   // 39: hapi.pty.v2.SearchResultsEvent
+	(*ExportEvent)(nil),          // 40: hapi.pty.v2.ExportEvent
+	(*BackpressureEvent)(nil),    // 41: hapi.pty.v2.BackpressureEvent
+	(*ClosedEvent)(nil),          // 42: hapi.pty.v2.ClosedEvent
+	(*WillCloseEvent)(nil),       // 43: hapi.pty.v2.WillCloseEvent
+	(*StatsEvent)(nil),           // 44: hapi.pty.v2.StatsEvent
+	(*HeartbeatEvent)(nil),       // 45: hapi.pty.v2.HeartbeatEvent
+	(*ListEvent)(nil),            // 46: hapi.pty.v2.ListEvent
+	(*ClosedAllEvent)(nil),       // 47: hapi.pty.v2.ClosedAllEvent
+	(*ShellsEvent)(nil),          // 48: hapi.pty.v2.ShellsEvent
+	(*ProbeEvent)(nil),           // 49: hapi.pty.v2.ProbeEvent
+	(*TmuxPanesEvent)(nil),       // 50: hapi.pty.v2.TmuxPanesEvent
+	(*SizeEvent)(nil),            // 51: hapi.pty.v2.SizeEvent
+	(*ScreenModeEvent)(nil),      // 52: hapi.pty.v2.ScreenModeEvent
+	(*UpgradeEvent)(nil),         // 53: hapi.pty.v2.UpgradeEvent
+	(*HelloAckEvent)(nil),        // 54: hapi.pty.v2.HelloAckEvent
+	(*SubscriptionsEvent)(nil),   // 55: hapi.pty.v2.SubscriptionsEvent
+	(*MockOptions)(nil),          // 56: hapi.pty.v2.MockOptions
+	(*MockStep)(nil),             // 57: hapi.pty.v2.MockStep
+	(*TmuxOptions)(nil),          // 58: hapi.pty.v2.TmuxOptions
+	(*ContainerdOptions)(nil),    // 59: hapi.pty.v2.ContainerdOptions
+	(*SerialOptions)(nil),        // 60: hapi.pty.v2.SerialOptions
+	(*TelnetOptions)(nil),        // 61: hapi.pty.v2.TelnetOptions
+	(*PythonOptions)(nil),        // 62: hapi.pty.v2.PythonOptions
+	(*VisualStudioOptions)(nil),  // 63: hapi.pty.v2.VisualStudioOptions
+	(*HelloCapabilities)(nil),    // 64: hapi.pty.v2.HelloCapabilities
+	(*PlatformInfo)(nil),         // 65: hapi.pty.v2.PlatformInfo
+	(*OutputTimestamp)(nil),      // 66: hapi.pty.v2.OutputTimestamp
+	(*TerminalCloseResult)(nil),  // 67: hapi.pty.v2.TerminalCloseResult
+	(*SnapshotCursor)(nil),       // 68: hapi.pty.v2.SnapshotCursor
+	(*SnapshotLine)(nil),         // 69: hapi.pty.v2.SnapshotLine
+	(*SnapshotRun)(nil),          // 70: hapi.pty.v2.SnapshotRun
+	(*SearchMatch)(nil),          // 71: hapi.pty.v2.SearchMatch
+	(*LatencyStats)(nil),         // 72: hapi.pty.v2.LatencyStats
+	(*ClientInfo)(nil),           // 73: hapi.pty.v2.ClientInfo
+	(*TerminalInfo)(nil),         // 74: hapi.pty.v2.TerminalInfo
+	(*ShellInfo)(nil),            // 75: hapi.pty.v2.ShellInfo
+	(*TmuxPaneInfo)(nil),         // 76: hapi.pty.v2.TmuxPaneInfo
+	(*CategorySubscription)(nil), // 77: hapi.pty.v2.CategorySubscription
+	(*MockStepList)(nil),         // 78: hapi.pty.v2.MockStepList
+	nil,                          // 79: hapi.pty.v2.OpenRequest.EnvEntry
+	nil,                          // 80: hapi.pty.v2.OpenRequest.TagsEntry
+	nil,                          // 81: hapi.pty.v2.BroadcastRequest.SelectorEntry
+	nil,                          // 82: hapi.pty.v2.ListRequest.SelectorEntry
+	nil,                          // 83: hapi.pty.v2.CloseAllRequest.SelectorEntry
+	nil,                          // 84: hapi.pty.v2.MockOptions.RepliesEntry
+	nil,                          // 85: hapi.pty.v2.TerminalInfo.TagsEntry
+}
+var file_terminal_proto_depIdxs = []int32{
+	2,  // 0: hapi.pty.v2.Request.auth:type_name -> hapi.pty.v2.AuthRequest
+	3,  // 1: hapi.pty.v2.Request.open:type_name -> hapi.pty.v2.OpenRequest
+	4,  // 2: hapi.pty.v2.Request.write:type_name -> hapi.pty.v2.WriteRequest
+	5,  // 3: hapi.pty.v2.Request.resize:type_name -> hapi.pty.v2.ResizeRequest
+	6,  // 4: hapi.pty.v2.Request.close:type_name -> hapi.pty.v2.CloseRequest
+	7,  // 5: hapi.pty.v2.Request.snapshot:type_name -> hapi.pty.v2.SnapshotRequest
+	8,  // 6: hapi.pty.v2.Request.wait:type_name -> hapi.pty.v2.WaitRequest
+	9,  // 7: hapi.pty.v2.Request.search:type_name -> hapi.pty.v2.SearchRequest
+	10, // 8: hapi.pty.v2.Request.export:type_name -> hapi.pty.v2.ExportRequest
+	11, // 9: hapi.pty.v2.Request.ping:type_name -> hapi.pty.v2.PingRequest
+	12, // 10: hapi.pty.v2.Request.stats:type_name -> hapi.pty.v2.StatsRequest
+	13, // 11: hapi.pty.v2.Request.kill_process:type_name -> hapi.pty.v2.KillProcessRequest
+	14, // 12: hapi.pty.v2.Request.eof:type_name -> hapi.pty.v2.EofRequest
+	15, // 13: hapi.pty.v2.Request.broadcast:type_name -> hapi.pty.v2.BroadcastRequest
+	16, // 14: hapi.pty.v2.Request.list:type_name -> hapi.pty.v2.ListRequest
+	17, // 15: hapi.pty.v2.Request.close_all:type_name -> hapi.pty.v2.CloseAllRequest
+	18, // 16: hapi.pty.v2.Request.list_shells:type_name -> hapi.pty.v2.ListShellsRequest
+	19, // 17: hapi.pty.v2.Request.probe:type_name -> hapi.pty.v2.ProbeRequest
+	20, // 18: hapi.pty.v2.Request.tmux_panes:type_name -> hapi.pty.v2.TmuxPanesRequest
+	21, // 19: hapi.pty.v2.Request.get_size:type_name -> hapi.pty.v2.GetSizeRequest
+	22, // 20: hapi.pty.v2.Request.focus:type_name -> hapi.pty.v2.FocusRequest
+	23, // 21: hapi.pty.v2.Request.upgrade:type_name -> hapi.pty.v2.UpgradeRequest
+	24, // 22: hapi.pty.v2.Request.hello:type_name -> hapi.pty.v2.ClientHelloRequest
+	25, // 23: hapi.pty.v2.Request.subscribe:type_name -> hapi.pty.v2.SubscribeRequest
+	26, // 24: hapi.pty.v2.Request.unsubscribe:type_name -> hapi.pty.v2.UnsubscribeRequest
+	27, // 25: hapi.pty.v2.Request.shutdown:type_name -> hapi.pty.v2.ShutdownRequest
+	28, // 26: hapi.pty.v2.Event.hello:type_name -> hapi.pty.v2.HelloEvent
+	29, // 27: hapi.pty.v2.Event.ready:type_name -> hapi.pty.v2.ReadyEvent
+	30, // 28: hapi.pty.v2.Event.output:type_name -> hapi.pty.v2.OutputEvent
+	31, // 29: hapi.pty.v2.Event.exit:type_name -> hapi.pty.v2.ExitEvent
+	32, // 30: hapi.pty.v2.Event.error:type_name -> hapi.pty.v2.ErrorEvent
+	33, // 31: hapi.pty.v2.Event.pong:type_name -> hapi.pty.v2.PongEvent
+	34, // 32: hapi.pty.v2.Event.shutdown_ack:type_name -> hapi.pty.v2.ShutdownAckEvent
+	35, // 33: hapi.pty.v2.Event.clipboard:type_name -> hapi.pty.v2.ClipboardEvent
+	36, // 34: hapi.pty.v2.Event.progress:type_name -> hapi.pty.v2.ProgressEvent
+	37, // 35: hapi.pty.v2.Event.snapshot:type_name -> hapi.pty.v2.SnapshotEvent
+	38, // 36: hapi.pty.v2.Event.matched:type_name -> hapi.pty.v2.MatchedEvent
+	39, // 37: hapi.pty.v2.Event.search_results:type_name -> hapi.pty.v2.SearchResultsEvent
+	40, // 38: hapi.pty.v2.Event.export:type_name -> hapi.pty.v2.ExportEvent
+	41, // 39: hapi.pty.v2.Event.backpressure:type_name -> hapi.pty.v2.BackpressureEvent
+	42, // 40: hapi.pty.v2.Event.closed:type_name -> hapi.pty.v2.ClosedEvent
+	43, // 41: hapi.pty.v2.Event.will_close:type_name -> hapi.pty.v2.WillCloseEvent
+	44, // 42: hapi.pty.v2.Event.stats:type_name -> hapi.pty.v2.StatsEvent
+	45, // 43: hapi.pty.v2.Event.heartbeat:type_name -> hapi.pty.v2.HeartbeatEvent
+	46, // 44: hapi.pty.v2.Event.list:type_name -> hapi.pty.v2.ListEvent
+	47, // 45: hapi.pty.v2.Event.closed_all:type_name -> hapi.pty.v2.ClosedAllEvent
+	48, // 46: hapi.pty.v2.Event.shells:type_name -> hapi.pty.v2.ShellsEvent
+	49, // 47: hapi.pty.v2.Event.probe:type_name -> hapi.pty.v2.ProbeEvent
+	50, // 48: hapi.pty.v2.Event.tmux_panes:type_name -> hapi.pty.v2.TmuxPanesEvent
+	51, // 49: hapi.pty.v2.Event.size:type_name -> hapi.pty.v2.SizeEvent
+	52, // 50: hapi.pty.v2.Event.screen_mode:type_name -> hapi.pty.v2.ScreenModeEvent
+	53, // 51: hapi.pty.v2.Event.upgrade:type_name -> hapi.pty.v2.UpgradeEvent
+	54, // 52: hapi.pty.v2.Event.hello_ack:type_name -> hapi.pty.v2.HelloAckEvent
+	55, // 53: hapi.pty.v2.Event.subscriptions:type_name -> hapi.pty.v2.SubscriptionsEvent
+	79, // 54: hapi.pty.v2.OpenRequest.env:type_name -> hapi.pty.v2.OpenRequest.EnvEntry
+	56, // 55: hapi.pty.v2.OpenRequest.mock:type_name -> hapi.pty.v2.MockOptions
+	58, // 56: hapi.pty.v2.OpenRequest.tmux:type_name -> hapi.pty.v2.TmuxOptions
+	59, // 57: hapi.pty.v2.OpenRequest.containerd:type_name -> hapi.pty.v2.ContainerdOptions
+	60, // 58: hapi.pty.v2.OpenRequest.serial:type_name -> hapi.pty.v2.SerialOptions
+	61, // 59: hapi.pty.v2.OpenRequest.telnet:type_name -> hapi.pty.v2.TelnetOptions
+	62, // 60: hapi.pty.v2.OpenRequest.python:type_name -> hapi.pty.v2.PythonOptions
+	63, // 61: hapi.pty.v2.OpenRequest.visual_studio:type_name -> hapi.pty.v2.VisualStudioOptions
+	80, // 62: hapi.pty.v2.OpenRequest.tags:type_name -> hapi.pty.v2.OpenRequest.TagsEntry
+	81, // 63: hapi.pty.v2.BroadcastRequest.selector:type_name -> hapi.pty.v2.BroadcastRequest.SelectorEntry
+	82, // 64: hapi.pty.v2.ListRequest.selector:type_name -> hapi.pty.v2.ListRequest.SelectorEntry
+	83, // 65: hapi.pty.v2.CloseAllRequest.selector:type_name -> hapi.pty.v2.CloseAllRequest.SelectorEntry
+	58, // 66: hapi.pty.v2.TmuxPanesRequest.tmux:type_name -> hapi.pty.v2.TmuxOptions
+	64, // 67: hapi.pty.v2.HelloEvent.capabilities:type_name -> hapi.pty.v2.HelloCapabilities
+	65, // 68: hapi.pty.v2.HelloEvent.platform:type_name -> hapi.pty.v2.PlatformInfo
+	66, // 69: hapi.pty.v2.OutputEvent.timestamp:type_name -> hapi.pty.v2.OutputTimestamp
+	67, // 70: hapi.pty.v2.ShutdownAckEvent.terminals:type_name -> hapi.pty.v2.TerminalCloseResult
+	68, // 71: hapi.pty.v2.SnapshotEvent.cursor:type_name -> hapi.pty.v2.SnapshotCursor
+	69, // 72: hapi.pty.v2.SnapshotEvent.lines:type_name -> hapi.pty.v2.SnapshotLine
+	69, // 73: hapi.pty.v2.SnapshotEvent.scrollback:type_name -> hapi.pty.v2.SnapshotLine
+	71, // 74: hapi.pty.v2.SearchResultsEvent.matches:type_name -> hapi.pty.v2.SearchMatch
+	72, // 75: hapi.pty.v2.StatsEvent.latency:type_name -> hapi.pty.v2.LatencyStats
+	73, // 76: hapi.pty.v2.StatsEvent.clients:type_name -> hapi.pty.v2.ClientInfo
+	74, // 77: hapi.pty.v2.ListEvent.terminals:type_name -> hapi.pty.v2.TerminalInfo
+	67, // 78: hapi.pty.v2.ClosedAllEvent.terminals:type_name -> hapi.pty.v2.TerminalCloseResult
+	75, // 79: hapi.pty.v2.ShellsEvent.shells:type_name -> hapi.pty.v2.ShellInfo
+	76, // 80: hapi.pty.v2.TmuxPanesEvent.panes:type_name -> hapi.pty.v2.TmuxPaneInfo
+	77, // 81: hapi.pty.v2.SubscriptionsEvent.categories:type_name -> hapi.pty.v2.CategorySubscription
+	57, // 82: hapi.pty.v2.MockOptions.output:type_name -> hapi.pty.v2.MockStep
+	84, // 83: hapi.pty.v2.MockOptions.replies:type_name -> hapi.pty.v2.MockOptions.RepliesEntry
+	70, // 84: hapi.pty.v2.SnapshotLine.runs:type_name -> hapi.pty.v2.SnapshotRun
+	85, // 85: hapi.pty.v2.TerminalInfo.tags:type_name -> hapi.pty.v2.TerminalInfo.TagsEntry
+	57, // 86: hapi.pty.v2.MockStepList.items:type_name -> hapi.pty.v2.MockStep
+	78, // 87: hapi.pty.v2.MockOptions.RepliesEntry.value:type_name -> hapi.pty.v2.MockStepList
+	0,  // 88: hapi.pty.v2.Terminal.Session:input_type -> hapi.pty.v2.Request
+	16, // 89: hapi.pty.v2.Terminal.List:input_type -> hapi.pty.v2.ListRequest
+	12, // 90: hapi.pty.v2.Terminal.Stats:input_type -> hapi.pty.v2.StatsRequest
+	18, // 91: hapi.pty.v2.Terminal.ListShells:input_type -> hapi.pty.v2.ListShellsRequest
+	19, // 92: hapi.pty.v2.Terminal.Probe:input_type -> hapi.pty.v2.ProbeRequest
+	17, // 93: hapi.pty.v2.Terminal.CloseAll:input_type -> hapi.pty.v2.CloseAllRequest
+	27, // 94: hapi.pty.v2.Terminal.Shutdown:input_type -> hapi.pty.v2.ShutdownRequest
+	1,  // 95: hapi.pty.v2.Terminal.Session:output_type -> hapi.pty.v2.Event
+	46, // 96: hapi.pty.v2.Terminal.List:output_type -> hapi.pty.v2.ListEvent
+	44, // 97: hapi.pty.v2.Terminal.Stats:output_type -> hapi.pty.v2.StatsEvent
+	48, // 98: hapi.pty.v2.Terminal.ListShells:output_type -> hapi.pty.v2.ShellsEvent
+	49, // 99: hapi.pty.v2.Terminal.Probe:output_type -> hapi.pty.v2.ProbeEvent
+	47, // 100: hapi.pty.v2.Terminal.CloseAll:output_type -> hapi.pty.v2.ClosedAllEvent
+	34, // 101: hapi.pty.v2.Terminal.Shutdown:output_type -> hapi.pty.v2.ShutdownAckEvent
+	95, // [95:102] is the sub-list for method output_type
+	88, // [88:95] is the sub-list for method input_type
+	88, // [88:88] is the sub-list for extension type_name
+	88, // [88:88] is the sub-list for extension extendee
+	0,  // [0:88] is the sub-list for field type_name
+}
+
+func init() { file_terminal_proto_init() }
+func file_terminal_proto_init() {
+	if File_terminal_proto != nil {
+		return
+	}
+	file_terminal_proto_msgTypes[0].OneofWrappers = []any{
+		(*Request_Auth)(nil),
+		(*Request_Open)(nil),
+		(*Request_Write)(nil),
+		(*Request_Resize)(nil),
+		(*Request_Close)(nil),
+		(*Request_Snapshot)(nil),
+		(*Request_Wait)(nil),
+		(*Request_Search)(nil),
+		(*Request_Export)(nil),
+		(*Request_Ping)(nil),
+		(*Request_Stats)(nil),
+		(*Request_KillProcess)(nil),
+		(*Request_Eof)(nil),
+		(*Request_Broadcast)(nil),
+		(*Request_List)(nil),
+		(*Request_CloseAll)(nil),
+		(*Request_ListShells)(nil),
+		(*Request_Probe)(nil),
+		(*Request_TmuxPanes)(nil),
+		(*Request_GetSize)(nil),
+		(*Request_Focus)(nil),
+		(*Request_Upgrade)(nil),
+		(*Request_Hello)(nil),
+		(*Request_Subscribe)(nil),
+		(*Request_Unsubscribe)(nil),
+		(*Request_Shutdown)(nil),
+	}
+	file_terminal_proto_msgTypes[1].OneofWrappers = []any{
+		(*Event_Hello)(nil),
+		(*Event_Ready)(nil),
+		(*Event_Output)(nil),
+		(*Event_Exit)(nil),
+		(*Event_Error)(nil),
+		(*Event_Pong)(nil),
+		(*Event_ShutdownAck)(nil),
+		(*Event_Clipboard)(nil),
+		(*Event_Progress)(nil),
+		(*Event_Snapshot)(nil),
+		(*Event_Matched)(nil),
+		(*Event_SearchResults)(nil),
+		(*Event_Export)(nil),
+		(*Event_Backpressure)(nil),
+		(*Event_Closed)(nil),
+		(*Event_WillClose)(nil),
+		(*Event_Stats)(nil),
+		(*Event_Heartbeat)(nil),
+		(*Event_List)(nil),
+		(*Event_ClosedAll)(nil),
+		(*Event_Shells)(nil),
+		(*Event_Probe)(nil),
+		(*Event_TmuxPanes)(nil),
+		(*Event_Size)(nil),
+		(*Event_ScreenMode)(nil),
+		(*Event_Upgrade)(nil),
+		(*Event_HelloAck)(nil),
+		(*Event_Subscriptions)(nil),
+	}
+	file_terminal_proto_msgTypes[3].OneofWrappers = []any{}
+	file_terminal_proto_msgTypes[42].OneofWrappers = []any{}
+	file_terminal_proto_msgTypes[60].OneofWrappers = []any{}
+	file_terminal_proto_msgTypes[61].OneofWrappers = []any{}
+	file_terminal_proto_msgTypes[67].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_terminal_proto_rawDesc), len(file_terminal_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   86,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_terminal_proto_goTypes,
+		DependencyIndexes: file_terminal_proto_depIdxs,
+		MessageInfos:      file_terminal_proto_msgTypes,
+	}.Build()
+	File_terminal_proto = out.File
+	file_terminal_proto_goTypes = nil
+	file_terminal_proto_depIdxs = nil
+}
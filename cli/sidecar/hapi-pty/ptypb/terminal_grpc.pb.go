@@ -0,0 +1,346 @@
+// Code generated by `hapi-pty schema --format proto`. DO NOT EDIT.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: terminal.proto
+
+package ptypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Terminal_Session_FullMethodName    = "/hapi.pty.v2.Terminal/Session"
+	Terminal_List_FullMethodName       = "/hapi.pty.v2.Terminal/List"
+	Terminal_Stats_FullMethodName      = "/hapi.pty.v2.Terminal/Stats"
+	Terminal_ListShells_FullMethodName = "/hapi.pty.v2.Terminal/ListShells"
+	Terminal_Probe_FullMethodName      = "/hapi.pty.v2.Terminal/Probe"
+	Terminal_CloseAll_FullMethodName   = "/hapi.pty.v2.Terminal/CloseAll"
+	Terminal_Shutdown_FullMethodName   = "/hapi.pty.v2.Terminal/Shutdown"
+)
+
+// TerminalClient is the client API for Terminal service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TerminalClient interface {
+	Session(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Request, Event], error)
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListEvent, error)
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsEvent, error)
+	ListShells(ctx context.Context, in *ListShellsRequest, opts ...grpc.CallOption) (*ShellsEvent, error)
+	Probe(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeEvent, error)
+	CloseAll(ctx context.Context, in *CloseAllRequest, opts ...grpc.CallOption) (*ClosedAllEvent, error)
+	Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*ShutdownAckEvent, error)
+}
+
+type terminalClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTerminalClient(cc grpc.ClientConnInterface) TerminalClient {
+	return &terminalClient{cc}
+}
+
+func (c *terminalClient) Session(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Request, Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Terminal_ServiceDesc.Streams[0], Terminal_Session_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Request, Event]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Terminal_SessionClient = grpc.BidiStreamingClient[Request, Event]
+
+func (c *terminalClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEvent)
+	err := c.cc.Invoke(ctx, Terminal_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terminalClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsEvent)
+	err := c.cc.Invoke(ctx, Terminal_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terminalClient) ListShells(ctx context.Context, in *ListShellsRequest, opts ...grpc.CallOption) (*ShellsEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShellsEvent)
+	err := c.cc.Invoke(ctx, Terminal_ListShells_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terminalClient) Probe(ctx context.Context, in *ProbeRequest, opts ...grpc.CallOption) (*ProbeEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProbeEvent)
+	err := c.cc.Invoke(ctx, Terminal_Probe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terminalClient) CloseAll(ctx context.Context, in *CloseAllRequest, opts ...grpc.CallOption) (*ClosedAllEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClosedAllEvent)
+	err := c.cc.Invoke(ctx, Terminal_CloseAll_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *terminalClient) Shutdown(ctx context.Context, in *ShutdownRequest, opts ...grpc.CallOption) (*ShutdownAckEvent, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShutdownAckEvent)
+	err := c.cc.Invoke(ctx, Terminal_Shutdown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TerminalServer is the server API for Terminal service.
+// All implementations must embed UnimplementedTerminalServer
+// for forward compatibility.
+type TerminalServer interface {
+	Session(grpc.BidiStreamingServer[Request, Event]) error
+	List(context.Context, *ListRequest) (*ListEvent, error)
+	Stats(context.Context, *StatsRequest) (*StatsEvent, error)
+	ListShells(context.Context, *ListShellsRequest) (*ShellsEvent, error)
+	Probe(context.Context, *ProbeRequest) (*ProbeEvent, error)
+	CloseAll(context.Context, *CloseAllRequest) (*ClosedAllEvent, error)
+	Shutdown(context.Context, *ShutdownRequest) (*ShutdownAckEvent, error)
+	mustEmbedUnimplementedTerminalServer()
+}
+
+// UnimplementedTerminalServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTerminalServer struct{}
+
+func (UnimplementedTerminalServer) Session(grpc.BidiStreamingServer[Request, Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Session not implemented")
+}
+func (UnimplementedTerminalServer) List(context.Context, *ListRequest) (*ListEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedTerminalServer) Stats(context.Context, *StatsRequest) (*StatsEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedTerminalServer) ListShells(context.Context, *ListShellsRequest) (*ShellsEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListShells not implemented")
+}
+func (UnimplementedTerminalServer) Probe(context.Context, *ProbeRequest) (*ProbeEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Probe not implemented")
+}
+func (UnimplementedTerminalServer) CloseAll(context.Context, *CloseAllRequest) (*ClosedAllEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseAll not implemented")
+}
+func (UnimplementedTerminalServer) Shutdown(context.Context, *ShutdownRequest) (*ShutdownAckEvent, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Shutdown not implemented")
+}
+func (UnimplementedTerminalServer) mustEmbedUnimplementedTerminalServer() {}
+func (UnimplementedTerminalServer) testEmbeddedByValue()                  {}
+
+// UnsafeTerminalServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TerminalServer will
+// result in compilation errors.
+type UnsafeTerminalServer interface {
+	mustEmbedUnimplementedTerminalServer()
+}
+
+func RegisterTerminalServer(s grpc.ServiceRegistrar, srv TerminalServer) {
+	// If the following call pancis, it indicates UnimplementedTerminalServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Terminal_ServiceDesc, srv)
+}
+
+func _Terminal_Session_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TerminalServer).Session(&grpc.GenericServerStream[Request, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Terminal_SessionServer = grpc.BidiStreamingServer[Request, Event]
+
+func _Terminal_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerminalServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Terminal_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerminalServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Terminal_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerminalServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Terminal_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerminalServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Terminal_ListShells_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListShellsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerminalServer).ListShells(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Terminal_ListShells_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerminalServer).ListShells(ctx, req.(*ListShellsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Terminal_Probe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerminalServer).Probe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Terminal_Probe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerminalServer).Probe(ctx, req.(*ProbeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Terminal_CloseAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerminalServer).CloseAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Terminal_CloseAll_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerminalServer).CloseAll(ctx, req.(*CloseAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Terminal_Shutdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShutdownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TerminalServer).Shutdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Terminal_Shutdown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TerminalServer).Shutdown(ctx, req.(*ShutdownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Terminal_ServiceDesc is the grpc.ServiceDesc for Terminal service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Terminal_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hapi.pty.v2.Terminal",
+	HandlerType: (*TerminalServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "List",
+			Handler:    _Terminal_List_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _Terminal_Stats_Handler,
+		},
+		{
+			MethodName: "ListShells",
+			Handler:    _Terminal_ListShells_Handler,
+		},
+		{
+			MethodName: "Probe",
+			Handler:    _Terminal_Probe_Handler,
+		},
+		{
+			MethodName: "CloseAll",
+			Handler:    _Terminal_CloseAll_Handler,
+		},
+		{
+			MethodName: "Shutdown",
+			Handler:    _Terminal_Shutdown_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Session",
+			Handler:       _Terminal_Session_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "terminal.proto",
+}
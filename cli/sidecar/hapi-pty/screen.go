@@ -0,0 +1,762 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	defaultScrollbackLines = 1000
+	maxScrollbackLines     = 100000
+	screenTabWidth         = 8
+)
+
+const (
+	cellBold uint8 = 1 << iota
+	cellDim
+	cellItalic
+	cellUnderline
+	cellInverse
+	cellStrikethrough
+)
+
+// screenColor is 0 for the default color, a palette index tagged with
+// colorPalette, or a 24-bit RGB value tagged with colorRGB.
+type screenColor uint32
+
+const (
+	colorDefault screenColor = 0
+	colorPalette screenColor = 1 << 25
+	colorRGB     screenColor = 1 << 24
+)
+
+type cellAttrs struct {
+	fg    screenColor
+	bg    screenColor
+	flags uint8
+}
+
+type screenCell struct {
+	ch    rune
+	attrs cellAttrs
+}
+
+type screenCursor struct {
+	x     int
+	y     int
+	attrs cellAttrs
+}
+
+type vtParserState int
+
+const (
+	vtGround vtParserState = iota
+	vtEscape
+	vtCSI
+	vtString
+	vtStringEscape
+	vtCharset
+)
+
+// screenEmulator is a headless VT emulator that keeps a terminal's visible
+// grid and scrollback so clients can request rendered snapshots.
+type screenEmulator struct {
+	mu sync.Mutex
+
+	cols          int
+	rows          int
+	lines         [][]screenCell
+	savedMain     [][]screenCell
+	scrollback    [][]screenCell
+	maxScrollback int
+
+	cursor        screenCursor
+	saved         screenCursor
+	wrapPending   bool
+	cursorVisible bool
+	altScreen     bool
+	top           int
+	bottom        int
+
+	state     vtParserState
+	csiParams []byte
+	partial   []byte
+}
+
+func newScreenEmulator(cols int, rows int, scrollbackLines int) *screenEmulator {
+	if scrollbackLines <= 0 {
+		scrollbackLines = defaultScrollbackLines
+	}
+	scrollbackLines = min(scrollbackLines, maxScrollbackLines)
+
+	cols, rows = clampScreenSize(cols, rows)
+	screen := &screenEmulator{
+		cols:          cols,
+		rows:          rows,
+		maxScrollback: scrollbackLines,
+		cursorVisible: true,
+		bottom:        rows - 1,
+	}
+	screen.lines = screen.blankLines(rows)
+	return screen
+}
+
+func clampScreenSize(cols int, rows int) (int, int) {
+	return min(max(cols, 1), maxScreenDimension), min(max(rows, 1), maxScreenDimension)
+}
+
+// maxScreenDimension keeps emulator allocations bounded independently of the
+// ConPTY coordinate limits.
+const maxScreenDimension = 1000
+
+func (s *screenEmulator) blankLine() []screenCell {
+	line := make([]screenCell, s.cols)
+	for idx := range line {
+		line[idx] = screenCell{ch: ' ', attrs: cellAttrs{bg: s.cursor.attrs.bg}}
+	}
+	return line
+}
+
+func (s *screenEmulator) blankLines(n int) [][]screenCell {
+	lines := make([][]screenCell, n)
+	for idx := range lines {
+		lines[idx] = s.blankLine()
+	}
+	return lines
+}
+
+func (s *screenEmulator) Write(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.partial) > 0 {
+		data = append(s.partial, data...)
+		s.partial = nil
+	}
+
+	for idx := 0; idx < len(data); {
+		b := data[idx]
+		if s.state != vtGround || b < utf8.RuneSelf {
+			s.feedByte(b)
+			idx++
+			continue
+		}
+
+		if !utf8.FullRune(data[idx:]) {
+			s.partial = append([]byte(nil), data[idx:]...)
+			return
+		}
+		r, size := utf8.DecodeRune(data[idx:])
+		s.print(r)
+		idx += size
+	}
+}
+
+func (s *screenEmulator) feedByte(b byte) {
+	switch s.state {
+	case vtGround:
+		s.feedGround(b)
+	case vtEscape:
+		s.feedEscape(b)
+	case vtCSI:
+		switch {
+		case b >= 0x40 && b <= 0x7e:
+			s.state = vtGround
+			s.executeCSI(b)
+		case b >= 0x20:
+			if len(s.csiParams) < 256 {
+				s.csiParams = append(s.csiParams, b)
+			}
+		case b == 0x1b:
+			s.state = vtEscape
+		default:
+			s.control(b)
+		}
+	case vtString:
+		switch b {
+		case 0x07:
+			s.state = vtGround
+		case 0x1b:
+			s.state = vtStringEscape
+		}
+	case vtStringEscape:
+		if b == '\\' {
+			s.state = vtGround
+		} else {
+			s.state = vtString
+		}
+	case vtCharset:
+		s.state = vtGround
+	}
+}
+
+func (s *screenEmulator) feedGround(b byte) {
+	if b == 0x1b {
+		s.state = vtEscape
+		return
+	}
+	if b < 0x20 || b == 0x7f {
+		s.control(b)
+		return
+	}
+	s.print(rune(b))
+}
+
+func (s *screenEmulator) feedEscape(b byte) {
+	s.state = vtGround
+	switch b {
+	case '[':
+		s.state = vtCSI
+		s.csiParams = s.csiParams[:0]
+	case ']', 'P', 'X', '^', '_':
+		s.state = vtString
+	case '(', ')', '*', '+':
+		s.state = vtCharset
+	case '7':
+		s.saved = s.cursor
+	case '8':
+		s.restoreCursor()
+	case 'D':
+		s.lineFeed()
+	case 'E':
+		s.cursor.x = 0
+		s.lineFeed()
+	case 'M':
+		s.reverseIndex()
+	case 'c':
+		s.reset()
+	}
+}
+
+func (s *screenEmulator) control(b byte) {
+	switch b {
+	case '\r':
+		s.cursor.x = 0
+		s.wrapPending = false
+	case '\n', 0x0b, 0x0c:
+		s.lineFeed()
+	case '\b':
+		if s.cursor.x > 0 {
+			s.cursor.x--
+		}
+		s.wrapPending = false
+	case '\t':
+		next := (s.cursor.x/screenTabWidth + 1) * screenTabWidth
+		s.cursor.x = min(next, s.cols-1)
+		s.wrapPending = false
+	}
+}
+
+func (s *screenEmulator) print(r rune) {
+	if s.wrapPending {
+		s.cursor.x = 0
+		s.lineFeed()
+	}
+
+	s.lines[s.cursor.y][s.cursor.x] = screenCell{ch: r, attrs: s.cursor.attrs}
+	if s.cursor.x == s.cols-1 {
+		s.wrapPending = true
+	} else {
+		s.cursor.x++
+	}
+}
+
+func (s *screenEmulator) lineFeed() {
+	s.wrapPending = false
+	if s.cursor.y == s.bottom {
+		s.scrollUp(1)
+		return
+	}
+	if s.cursor.y < s.rows-1 {
+		s.cursor.y++
+	}
+}
+
+func (s *screenEmulator) reverseIndex() {
+	s.wrapPending = false
+	if s.cursor.y == s.top {
+		s.scrollDown(1)
+		return
+	}
+	if s.cursor.y > 0 {
+		s.cursor.y--
+	}
+}
+
+func (s *screenEmulator) scrollUp(n int) {
+	n = min(n, s.bottom-s.top+1)
+	for i := 0; i < n; i++ {
+		removed := s.lines[s.top]
+		if s.top == 0 && !s.altScreen {
+			s.pushScrollback(removed)
+		}
+		copy(s.lines[s.top:s.bottom], s.lines[s.top+1:s.bottom+1])
+		s.lines[s.bottom] = s.blankLine()
+	}
+}
+
+func (s *screenEmulator) scrollDown(n int) {
+	n = min(n, s.bottom-s.top+1)
+	for i := 0; i < n; i++ {
+		copy(s.lines[s.top+1:s.bottom+1], s.lines[s.top:s.bottom])
+		s.lines[s.top] = s.blankLine()
+	}
+}
+
+func (s *screenEmulator) pushScrollback(line []screenCell) {
+	s.scrollback = append(s.scrollback, line)
+	if overflow := len(s.scrollback) - s.maxScrollback; overflow > 0 {
+		s.scrollback = append([][]screenCell(nil), s.scrollback[overflow:]...)
+	}
+}
+
+func (s *screenEmulator) restoreCursor() {
+	s.cursor = s.saved
+	s.cursor.x = min(s.cursor.x, s.cols-1)
+	s.cursor.y = min(s.cursor.y, s.rows-1)
+	s.wrapPending = false
+}
+
+func (s *screenEmulator) reset() {
+	s.cursor = screenCursor{}
+	s.saved = screenCursor{}
+	s.wrapPending = false
+	s.cursorVisible = true
+	s.altScreen = false
+	s.savedMain = nil
+	s.top = 0
+	s.bottom = s.rows - 1
+	s.lines = s.blankLines(s.rows)
+}
+
+func (s *screenEmulator) executeCSI(final byte) {
+	private := len(s.csiParams) > 0 && s.csiParams[0] == '?'
+	raw := string(s.csiParams)
+	if private {
+		raw = raw[1:]
+	}
+	if strings.ContainsAny(raw, "<=>!\"$' ") {
+		return
+	}
+	params := parseCSIParams(raw)
+	arg := func(idx int, fallback int) int {
+		if idx < len(params) && params[idx] > 0 {
+			return params[idx]
+		}
+		return fallback
+	}
+
+	if private {
+		if final == 'h' || final == 'l' {
+			for _, mode := range params {
+				s.setPrivateMode(mode, final == 'h')
+			}
+		}
+		return
+	}
+
+	s.wrapPending = false
+	switch final {
+	case 'A':
+		s.cursor.y = max(s.cursor.y-arg(0, 1), 0)
+	case 'B', 'e':
+		s.cursor.y = min(s.cursor.y+arg(0, 1), s.rows-1)
+	case 'C', 'a':
+		s.cursor.x = min(s.cursor.x+arg(0, 1), s.cols-1)
+	case 'D':
+		s.cursor.x = max(s.cursor.x-arg(0, 1), 0)
+	case 'E':
+		s.cursor.y = min(s.cursor.y+arg(0, 1), s.rows-1)
+		s.cursor.x = 0
+	case 'F':
+		s.cursor.y = max(s.cursor.y-arg(0, 1), 0)
+		s.cursor.x = 0
+	case 'G', '`':
+		s.cursor.x = min(arg(0, 1), s.cols) - 1
+	case 'd':
+		s.cursor.y = min(arg(0, 1), s.rows) - 1
+	case 'H', 'f':
+		s.cursor.y = min(arg(0, 1), s.rows) - 1
+		s.cursor.x = min(arg(1, 1), s.cols) - 1
+	case 'J':
+		s.eraseDisplay(arg(0, 0))
+	case 'K':
+		s.eraseLine(arg(0, 0))
+	case 'L':
+		s.insertLines(arg(0, 1))
+	case 'M':
+		s.deleteLines(arg(0, 1))
+	case '@':
+		s.insertChars(arg(0, 1))
+	case 'P':
+		s.deleteChars(arg(0, 1))
+	case 'X':
+		s.clearCells(s.cursor.y, s.cursor.x, min(s.cursor.x+arg(0, 1), s.cols))
+	case 'S':
+		s.scrollUp(arg(0, 1))
+	case 'T':
+		s.scrollDown(arg(0, 1))
+	case 'r':
+		top := arg(0, 1) - 1
+		bottom := min(arg(1, s.rows), s.rows) - 1
+		if top < bottom {
+			s.top, s.bottom = top, bottom
+			s.cursor.x, s.cursor.y = 0, 0
+		}
+	case 's':
+		s.saved = s.cursor
+	case 'u':
+		s.restoreCursor()
+	case 'm':
+		s.applySGR(params)
+	}
+}
+
+func parseCSIParams(raw string) []int {
+	if raw == "" {
+		return nil
+	}
+
+	fields := strings.FieldsFunc(raw, func(r rune) bool { return r == ';' || r == ':' })
+	params := make([]int, 0, len(fields))
+	for _, field := range fields {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			value = 0
+		}
+		params = append(params, value)
+	}
+	return params
+}
+
+func (s *screenEmulator) setPrivateMode(mode int, enabled bool) {
+	switch mode {
+	case 25:
+		s.cursorVisible = enabled
+	case 47, 1047, 1049:
+		s.setAltScreen(enabled, mode == 1049)
+	}
+}
+
+func (s *screenEmulator) setAltScreen(enabled bool, saveCursor bool) {
+	if enabled == s.altScreen {
+		return
+	}
+
+	if enabled {
+		if saveCursor {
+			s.saved = s.cursor
+		}
+		s.savedMain = s.lines
+		s.lines = s.blankLines(s.rows)
+		s.altScreen = true
+		return
+	}
+
+	if s.savedMain != nil {
+		s.lines = s.savedMain
+		s.savedMain = nil
+	}
+	s.altScreen = false
+	if saveCursor {
+		s.restoreCursor()
+	}
+}
+
+func (s *screenEmulator) clearCells(row int, from int, to int) {
+	blank := screenCell{ch: ' ', attrs: cellAttrs{bg: s.cursor.attrs.bg}}
+	for col := max(from, 0); col < to && col < s.cols; col++ {
+		s.lines[row][col] = blank
+	}
+}
+
+func (s *screenEmulator) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.clearCells(s.cursor.y, s.cursor.x, s.cols)
+		for row := s.cursor.y + 1; row < s.rows; row++ {
+			s.clearCells(row, 0, s.cols)
+		}
+	case 1:
+		for row := 0; row < s.cursor.y; row++ {
+			s.clearCells(row, 0, s.cols)
+		}
+		s.clearCells(s.cursor.y, 0, s.cursor.x+1)
+	case 2:
+		for row := 0; row < s.rows; row++ {
+			s.clearCells(row, 0, s.cols)
+		}
+	case 3:
+		s.scrollback = nil
+	}
+}
+
+func (s *screenEmulator) eraseLine(mode int) {
+	switch mode {
+	case 0:
+		s.clearCells(s.cursor.y, s.cursor.x, s.cols)
+	case 1:
+		s.clearCells(s.cursor.y, 0, s.cursor.x+1)
+	case 2:
+		s.clearCells(s.cursor.y, 0, s.cols)
+	}
+}
+
+func (s *screenEmulator) insertLines(n int) {
+	if s.cursor.y < s.top || s.cursor.y > s.bottom {
+		return
+	}
+	top := s.top
+	s.top = s.cursor.y
+	s.scrollDown(n)
+	s.top = top
+	s.cursor.x = 0
+}
+
+func (s *screenEmulator) deleteLines(n int) {
+	if s.cursor.y < s.top || s.cursor.y > s.bottom {
+		return
+	}
+	top := s.top
+	s.top = s.cursor.y
+	for i := 0; i < min(n, s.bottom-s.top+1); i++ {
+		copy(s.lines[s.top:s.bottom], s.lines[s.top+1:s.bottom+1])
+		s.lines[s.bottom] = s.blankLine()
+	}
+	s.top = top
+	s.cursor.x = 0
+}
+
+func (s *screenEmulator) insertChars(n int) {
+	line := s.lines[s.cursor.y]
+	n = min(n, s.cols-s.cursor.x)
+	copy(line[s.cursor.x+n:], line[s.cursor.x:s.cols-n])
+	s.clearCells(s.cursor.y, s.cursor.x, s.cursor.x+n)
+}
+
+func (s *screenEmulator) deleteChars(n int) {
+	line := s.lines[s.cursor.y]
+	n = min(n, s.cols-s.cursor.x)
+	copy(line[s.cursor.x:], line[s.cursor.x+n:])
+	s.clearCells(s.cursor.y, s.cols-n, s.cols)
+}
+
+func (s *screenEmulator) applySGR(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+
+	attrs := &s.cursor.attrs
+	for idx := 0; idx < len(params); idx++ {
+		code := params[idx]
+		switch {
+		case code == 0:
+			*attrs = cellAttrs{}
+		case code == 1:
+			attrs.flags |= cellBold
+		case code == 2:
+			attrs.flags |= cellDim
+		case code == 3:
+			attrs.flags |= cellItalic
+		case code == 4:
+			attrs.flags |= cellUnderline
+		case code == 7:
+			attrs.flags |= cellInverse
+		case code == 9:
+			attrs.flags |= cellStrikethrough
+		case code == 22:
+			attrs.flags &^= cellBold | cellDim
+		case code == 23:
+			attrs.flags &^= cellItalic
+		case code == 24:
+			attrs.flags &^= cellUnderline
+		case code == 27:
+			attrs.flags &^= cellInverse
+		case code == 29:
+			attrs.flags &^= cellStrikethrough
+		case code >= 30 && code <= 37:
+			attrs.fg = colorPalette | screenColor(code-30)
+		case code == 39:
+			attrs.fg = colorDefault
+		case code >= 40 && code <= 47:
+			attrs.bg = colorPalette | screenColor(code-40)
+		case code == 49:
+			attrs.bg = colorDefault
+		case code >= 90 && code <= 97:
+			attrs.fg = colorPalette | screenColor(code-90+8)
+		case code >= 100 && code <= 107:
+			attrs.bg = colorPalette | screenColor(code-100+8)
+		case code == 38 || code == 48:
+			color, consumed := parseExtendedColor(params[idx+1:])
+			idx += consumed
+			if code == 38 {
+				attrs.fg = color
+			} else {
+				attrs.bg = color
+			}
+		}
+	}
+}
+
+// parseExtendedColor decodes the 5;n and 2;r;g;b forms following SGR 38/48.
+func parseExtendedColor(params []int) (screenColor, int) {
+	if len(params) == 0 {
+		return colorDefault, 0
+	}
+	switch params[0] {
+	case 5:
+		if len(params) < 2 {
+			return colorDefault, len(params)
+		}
+		return colorPalette | screenColor(params[1]&0xff), 2
+	case 2:
+		if len(params) < 4 {
+			return colorDefault, len(params)
+		}
+		rgb := (params[1]&0xff)<<16 | (params[2]&0xff)<<8 | params[3]&0xff
+		return colorRGB | screenColor(rgb), 4
+	default:
+		return colorDefault, 1
+	}
+}
+
+func (s *screenEmulator) Resize(cols int, rows int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cols, rows = clampScreenSize(cols, rows)
+	if cols == s.cols && rows == s.rows {
+		return
+	}
+
+	s.lines = s.resizeGrid(s.lines, cols, rows, !s.altScreen)
+	if s.savedMain != nil {
+		s.savedMain = s.resizeGrid(s.savedMain, cols, rows, false)
+	}
+
+	s.cols, s.rows = cols, rows
+	s.top, s.bottom = 0, rows-1
+	s.cursor.x = min(s.cursor.x, cols-1)
+	s.cursor.y = min(s.cursor.y, rows-1)
+	s.wrapPending = false
+}
+
+// resizeGrid truncates or pads a grid. When the grid shrinks vertically the
+// rows above the cursor move into scrollback, mirroring common terminals.
+func (s *screenEmulator) resizeGrid(lines [][]screenCell, cols int, rows int, keepScrollback bool) [][]screenCell {
+	if overflow := len(lines) - rows; overflow > 0 {
+		shift := min(overflow, s.cursor.y)
+		if keepScrollback {
+			for _, line := range lines[:shift] {
+				s.pushScrollback(line)
+			}
+		}
+		lines = lines[shift:]
+		lines = lines[:min(len(lines), rows)]
+		s.cursor.y -= shift
+	}
+
+	resized := make([][]screenCell, rows)
+	for row := range resized {
+		line := make([]screenCell, cols)
+		for col := range line {
+			line[col] = screenCell{ch: ' '}
+		}
+		if row < len(lines) {
+			copy(line, lines[row])
+		}
+		resized[row] = line
+	}
+	return resized
+}
+
+func (s *screenEmulator) Snapshot(terminalID string, includeScrollback bool) snapshotEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evt := snapshotEvent{
+		Type:       eventTypeSnapshot,
+		TerminalID: terminalID,
+		Cols:       s.cols,
+		Rows:       s.rows,
+		Cursor: snapshotCursor{
+			X:       s.cursor.x,
+			Y:       s.cursor.y,
+			Visible: s.cursorVisible,
+		},
+		AlternateScreen: s.altScreen,
+		Lines:           renderSnapshotLines(s.lines),
+	}
+	if includeScrollback {
+		evt.Scrollback = renderSnapshotLines(s.scrollback)
+	}
+	return evt
+}
+
+func renderSnapshotLines(lines [][]screenCell) []snapshotLine {
+	rendered := make([]snapshotLine, 0, len(lines))
+	for _, line := range lines {
+		rendered = append(rendered, renderSnapshotLine(line))
+	}
+	return rendered
+}
+
+func renderSnapshotLine(line []screenCell) snapshotLine {
+	end := len(line)
+	for end > 0 && line[end-1].ch == ' ' && line[end-1].attrs == (cellAttrs{}) {
+		end--
+	}
+
+	var text strings.Builder
+	runs := make([]snapshotRun, 0)
+	for col := 0; col < end; col++ {
+		cell := line[col]
+		text.WriteRune(cell.ch)
+		if cell.attrs == (cellAttrs{}) {
+			continue
+		}
+
+		if last := len(runs) - 1; last >= 0 && runs[last].attrs == cell.attrs && runs[last].Start+runs[last].Length == col {
+			runs[last].Length++
+			continue
+		}
+		runs = append(runs, newSnapshotRun(col, cell.attrs))
+	}
+
+	rendered := snapshotLine{Text: text.String()}
+	if len(runs) > 0 {
+		rendered.Runs = runs
+	}
+	return rendered
+}
+
+func newSnapshotRun(start int, attrs cellAttrs) snapshotRun {
+	return snapshotRun{
+		attrs:         attrs,
+		Start:         start,
+		Length:        1,
+		FG:            attrs.fg.String(),
+		BG:            attrs.bg.String(),
+		Bold:          attrs.flags&cellBold != 0,
+		Dim:           attrs.flags&cellDim != 0,
+		Italic:        attrs.flags&cellItalic != 0,
+		Underline:     attrs.flags&cellUnderline != 0,
+		Inverse:       attrs.flags&cellInverse != 0,
+		Strikethrough: attrs.flags&cellStrikethrough != 0,
+	}
+}
+
+// String renders palette colors as their index and RGB colors as #rrggbb.
+func (c screenColor) String() string {
+	switch {
+	case c&colorPalette != 0:
+		return strconv.Itoa(int(c &^ colorPalette))
+	case c&colorRGB != 0:
+		return fmt.Sprintf("#%06x", uint32(c&^colorRGB))
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func screenText(screen *screenEmulator) []string {
+	snapshot := screen.Snapshot("t", false)
+	lines := make([]string, 0, len(snapshot.Lines))
+	for _, line := range snapshot.Lines {
+		lines = append(lines, line.Text)
+	}
+	return lines
+}
+
+func TestScreenEmulatorWrapsAndScrollsIntoScrollback(t *testing.T) {
+	screen := newScreenEmulator(5, 2, 10)
+
+	screen.Write([]byte("abcdefg\r\nxy\r\nz"))
+
+	if got := strings.Join(screenText(screen), "|"); got != "xy|z" {
+		t.Fatalf("unexpected screen: %q", got)
+	}
+
+	snapshot := screen.Snapshot("t", true)
+	if len(snapshot.Scrollback) != 2 || snapshot.Scrollback[0].Text != "abcde" || snapshot.Scrollback[1].Text != "fg" {
+		t.Fatalf("unexpected scrollback: %+v", snapshot.Scrollback)
+	}
+	if snapshot.Cursor.X != 1 || snapshot.Cursor.Y != 1 {
+		t.Fatalf("unexpected cursor: %+v", snapshot.Cursor)
+	}
+}
+
+func TestScreenEmulatorCursorMovementAndErase(t *testing.T) {
+	screen := newScreenEmulator(10, 3, 10)
+
+	screen.Write([]byte("hello\x1b[2;3Hmid\x1b[1;1H\x1b[2K\x1b[3;1Hbottom\x1b[1D\x1b[K"))
+
+	if got := strings.Join(screenText(screen), "|"); got != "|  mid|botto" {
+		t.Fatalf("unexpected screen: %q", got)
+	}
+}
+
+func TestScreenEmulatorAlternateScreenRestoresMain(t *testing.T) {
+	screen := newScreenEmulator(10, 2, 10)
+
+	screen.Write([]byte("shell$ "))
+	screen.Write([]byte("\x1b[?1049h\x1b[Hvim"))
+
+	snapshot := screen.Snapshot("t", false)
+	if !snapshot.AlternateScreen || snapshot.Lines[0].Text != "vim" {
+		t.Fatalf("unexpected alternate screen: %+v", snapshot)
+	}
+
+	screen.Write([]byte("\x1b[?1049l"))
+	snapshot = screen.Snapshot("t", false)
+	if snapshot.AlternateScreen || snapshot.Lines[0].Text != "shell$" || snapshot.Cursor.X != 7 {
+		t.Fatalf("unexpected restored screen: %+v", snapshot)
+	}
+}
+
+func TestScreenEmulatorSGRRuns(t *testing.T) {
+	screen := newScreenEmulator(20, 1, 10)
+
+	screen.Write([]byte("\x1b[1;38;2;255;0;0mred\x1b[0m \x1b[48;5;236;4mbg\x1b[m"))
+
+	line := screen.Snapshot("t", false).Lines[0]
+	if line.Text != "red bg" || len(line.Runs) != 2 {
+		t.Fatalf("unexpected line: %+v", line)
+	}
+	if run := line.Runs[0]; run.FG != "#ff0000" || !run.Bold || run.Start != 0 || run.Length != 3 {
+		t.Fatalf("unexpected first run: %+v", run)
+	}
+	if run := line.Runs[1]; run.BG != "236" || !run.Underline || run.Start != 4 || run.Length != 2 {
+		t.Fatalf("unexpected second run: %+v", run)
+	}
+}
+
+func TestScreenEmulatorHandlesSplitUTF8AndOSC(t *testing.T) {
+	screen := newScreenEmulator(10, 1, 10)
+	euro := []byte("€")
+
+	screen.Write([]byte("\x1b]0;ti"))
+	screen.Write([]byte("tle\x07a"))
+	screen.Write(euro[:1])
+	screen.Write(euro[1:])
+
+	if got := screenText(screen)[0]; got != "a€" {
+		t.Fatalf("unexpected screen: %q", got)
+	}
+}
+
+func TestScreenEmulatorResizeKeepsCursorRowVisible(t *testing.T) {
+	screen := newScreenEmulator(10, 4, 10)
+	screen.Write([]byte("1\r\n2\r\n3\r\n4"))
+
+	screen.Resize(6, 2)
+
+	snapshot := screen.Snapshot("t", true)
+	if got := strings.Join(screenText(screen), "|"); got != "3|4" {
+		t.Fatalf("unexpected screen after resize: %q", got)
+	}
+	if snapshot.Cols != 6 || snapshot.Cursor.Y != 1 || len(snapshot.Scrollback) != 2 {
+		t.Fatalf("unexpected snapshot after resize: %+v", snapshot)
+	}
+}
@@ -0,0 +1,534 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultSearchResults = 100
+	maxSearchResults     = 1000
+	maxSearchContext     = 10
+)
+
+const (
+	exportFormatText = "text"
+	exportFormatHTML = "html"
+)
+
+const (
+	exportDefaultFG = "#d4d4d4"
+	exportDefaultBG = "#1e1e1e"
+)
+
+// ansiPalette holds the xterm defaults for the 16 base colors.
+var ansiPalette = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// RetainedLines returns a copy of the scrollback followed by the visible
+// screen, oldest first.
+func (s *screenEmulator) RetainedLines() [][]screenCell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lines := make([][]screenCell, 0, len(s.scrollback)+len(s.lines))
+	for _, line := range s.scrollback {
+		lines = append(lines, append([]screenCell(nil), line...))
+	}
+	for _, line := range s.lines {
+		lines = append(lines, append([]screenCell(nil), line...))
+	}
+	return lines
+}
+
+// RetainedText returns the retained lines as plain text.
+func (s *screenEmulator) RetainedText() []string {
+	cells := s.RetainedLines()
+	lines := make([]string, 0, len(cells))
+	for _, line := range cells {
+		lines = append(lines, renderSnapshotLine(line).Text)
+	}
+	return lines
+}
+
+func searchRetainedText(lines []string, req searchRequest) (searchResultsEvent, error) {
+	if req.Pattern == "" {
+		return searchResultsEvent{}, newSidecarError(errorCodeInvalidRequest, "search request requires pattern")
+	}
+	pattern, err := compileTextPattern(req.Pattern, req.Regex, req.IgnoreCase)
+	if err != nil {
+		return searchResultsEvent{}, err
+	}
+
+	limit := req.MaxResults
+	if limit <= 0 {
+		limit = defaultSearchResults
+	}
+	limit = min(limit, maxSearchResults)
+	contextLines := min(max(req.ContextLines, 0), maxSearchContext)
+
+	evt := searchResultsEvent{
+		Type:       eventTypeSearchResults,
+		TerminalID: req.TerminalID,
+		RequestID:  req.RequestID,
+		TotalLines: len(lines),
+		Matches:    make([]searchMatch, 0),
+	}
+
+	for lineIdx, line := range lines {
+		for _, loc := range pattern.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			if len(evt.Matches) == limit {
+				evt.Truncated = true
+				return evt, nil
+			}
+
+			match := searchMatch{
+				Line:   lineIdx,
+				Column: utf8.RuneCountInString(line[:loc[0]]),
+				Length: utf8.RuneCountInString(line[loc[0]:loc[1]]),
+				Text:   line,
+			}
+			if contextLines > 0 {
+				match.Before = append([]string(nil), lines[max(lineIdx-contextLines, 0):lineIdx]...)
+				match.After = append([]string(nil), lines[lineIdx+1:min(lineIdx+1+contextLines, len(lines))]...)
+			}
+			evt.Matches = append(evt.Matches, match)
+		}
+	}
+
+	return evt, nil
+}
+
+// exportRetainedOutput renders retained lines in the requested format and
+// either returns the content inline or writes it to req.Path.
+func exportRetainedOutput(lines [][]screenCell, req exportRequest) (exportEvent, error) {
+	format := req.Format
+	if format == "" {
+		format = exportFormatText
+	}
+
+	redacted := 0
+	if req.Redact {
+		redactor, err := newSecretRedactor(req.RedactPatterns)
+		if err != nil {
+			return exportEvent{}, err
+		}
+		lines, redacted = redactor.Redact(lines)
+	}
+
+	var content string
+	switch format {
+	case exportFormatText:
+		content = renderPlainText(lines)
+	case exportFormatHTML:
+		content = renderHTML(lines)
+	default:
+		return exportEvent{}, newSidecarError(errorCodeInvalidRequest, "unsupported export format %q", req.Format)
+	}
+
+	evt := exportEvent{
+		Type:       eventTypeExport,
+		TerminalID: req.TerminalID,
+		RequestID:  req.RequestID,
+		Format:     format,
+		Bytes:      len(content),
+		Redacted:   redacted,
+	}
+
+	if req.Path == "" {
+		evt.Content = content
+		return evt, nil
+	}
+
+	if err := os.WriteFile(req.Path, []byte(content), 0o600); err != nil {
+		return exportEvent{}, newSidecarError(errorCodeExportFailed, "failed to write export: %v", err)
+	}
+	evt.Path = req.Path
+	return evt, nil
+}
+
+const redactionMask = '*'
+
+// secretPatterns match credentials commonly printed to terminals. When a
+// pattern has a "secret" group only that group is masked, so the label that
+// identifies the value stays readable.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key)\b["']?\s*[:=]\s*["']?(?P<secret>[^\s"']+)`),
+	regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/=-]{8,})`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\b(?:gh[pousr]|github_pat)_[A-Za-z0-9_]{20,}\b`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}\b`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	// Anything shown after a password prompt was typed with echo off, or
+	// echoed by a program that should not have.
+	regexp.MustCompile(`(?i)\b(?:password|passphrase|pin)(?: for [^:]*)?:\s*(?P<secret>\S.*)$`),
+}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// secretRedactor masks secrets cell by cell, so redacted exports keep their
+// layout and colors in every format.
+type secretRedactor struct {
+	patterns []*regexp.Regexp
+}
+
+func newSecretRedactor(extra []string) (*secretRedactor, error) {
+	patterns := append([]*regexp.Regexp(nil), secretPatterns...)
+	for _, expr := range extra {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, newSidecarError(errorCodeInvalidRequest, "invalid redact pattern %q: %v", expr, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return &secretRedactor{patterns: patterns}, nil
+}
+
+// Redact returns a masked copy of lines and the number of secrets masked.
+// Private key blocks are masked whole, including their delimiters.
+func (r *secretRedactor) Redact(lines [][]screenCell) ([][]screenCell, int) {
+	redacted := make([][]screenCell, len(lines))
+	count := 0
+	inKey := false
+	for idx, line := range lines {
+		line = append([]screenCell(nil), line...)
+		redacted[idx] = line
+
+		text := cellText(line)
+		if inKey || privateKeyBegin.MatchString(text) {
+			if !inKey {
+				count++
+			}
+			inKey = !privateKeyEnd.MatchString(text)
+			maskCells(line, text, 0, len(text))
+			continue
+		}
+
+		for _, pattern := range r.patterns {
+			secret := pattern.SubexpIndex("secret")
+			for _, match := range pattern.FindAllStringSubmatchIndex(text, -1) {
+				start, end := match[0], match[1]
+				if secret > 0 && match[2*secret] >= 0 {
+					start, end = match[2*secret], match[2*secret+1]
+				}
+				if start < end && maskCells(line, text, start, end) {
+					count++
+				}
+			}
+		}
+	}
+	return redacted, count
+}
+
+// cellText holds one rune per cell, so rune offsets are cell columns.
+func cellText(line []screenCell) string {
+	var builder strings.Builder
+	for _, cell := range line {
+		builder.WriteRune(cell.ch)
+	}
+	return builder.String()
+}
+
+// maskCells masks the cells covering text[start:end], given in bytes, and
+// reports whether any was not masked already by an overlapping pattern.
+func maskCells(line []screenCell, text string, start int, end int) bool {
+	changed := false
+	col := utf8.RuneCountInString(text[:start])
+	for _, ch := range text[start:end] {
+		if ch != ' ' && line[col].ch != redactionMask {
+			line[col].ch = redactionMask
+			changed = true
+		}
+		col++
+	}
+	return changed
+}
+
+func renderPlainText(lines [][]screenCell) string {
+	rendered := make([]string, 0, len(lines))
+	for _, line := range lines {
+		rendered = append(rendered, renderSnapshotLine(line).Text)
+	}
+	return strings.TrimRight(strings.Join(rendered, "\n"), "\n") + "\n"
+}
+
+func renderHTML(lines [][]screenCell) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder,
+		"<pre style=\"background:%s;color:%s;font-family:monospace\">",
+		exportDefaultBG,
+		exportDefaultFG,
+	)
+
+	for idx, line := range lines {
+		if idx > 0 {
+			builder.WriteByte('\n')
+		}
+		rendered := renderSnapshotLine(line)
+		runes := []rune(rendered.Text)
+		col := 0
+		for _, run := range rendered.Runs {
+			builder.WriteString(html.EscapeString(string(runes[col:run.Start])))
+			fmt.Fprintf(&builder, "<span style=\"%s\">", cssForAttrs(run.attrs))
+			builder.WriteString(html.EscapeString(string(runes[run.Start : run.Start+run.Length])))
+			builder.WriteString("</span>")
+			col = run.Start + run.Length
+		}
+		builder.WriteString(html.EscapeString(string(runes[col:])))
+	}
+
+	builder.WriteString("</pre>\n")
+	return builder.String()
+}
+
+func cssForAttrs(attrs cellAttrs) string {
+	fg := cssColor(attrs.fg, exportDefaultFG)
+	bg := cssColor(attrs.bg, "")
+	if attrs.flags&cellInverse != 0 {
+		if bg == "" {
+			bg = exportDefaultBG
+		}
+		fg, bg = bg, fg
+	}
+
+	styles := make([]string, 0, 6)
+	if attrs.fg != colorDefault || attrs.flags&cellInverse != 0 {
+		styles = append(styles, "color:"+fg)
+	}
+	if bg != "" {
+		styles = append(styles, "background:"+bg)
+	}
+	if attrs.flags&cellBold != 0 {
+		styles = append(styles, "font-weight:bold")
+	}
+	if attrs.flags&cellDim != 0 {
+		styles = append(styles, "opacity:0.7")
+	}
+	if attrs.flags&cellItalic != 0 {
+		styles = append(styles, "font-style:italic")
+	}
+
+	decorations := make([]string, 0, 2)
+	if attrs.flags&cellUnderline != 0 {
+		decorations = append(decorations, "underline")
+	}
+	if attrs.flags&cellStrikethrough != 0 {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		styles = append(styles, "text-decoration:"+strings.Join(decorations, " "))
+	}
+
+	return strings.Join(styles, ";")
+}
+
+func cssColor(color screenColor, fallback string) string {
+	switch {
+	case color&colorRGB != 0:
+		return color.String()
+	case color&colorPalette != 0:
+		return paletteColor(int(color &^ colorPalette))
+	default:
+		return fallback
+	}
+}
+
+// paletteColor resolves an xterm 256-color index to CSS hex.
+func paletteColor(index int) string {
+	switch {
+	case index < 16:
+		return ansiPalette[index]
+	case index < 232:
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		index -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[index/36], levels[(index/6)%6], levels[index%6])
+	default:
+		level := 8 + (index-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", level, level, level)
+	}
+}
+
+const checkpointVersion = 1
+
+// scrollbackCheckpoint is the file a checkpointed terminal leaves in
+// --checkpoint-dir when it closes, so a terminal reopened under the same id
+// after the host restarted starts with the old history in its scrollback.
+type scrollbackCheckpoint struct {
+	Version    int               `json:"version"`
+	TerminalID string            `json:"terminalId"`
+	Display    string            `json:"displayName"`
+	Cwd        string            `json:"cwd,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Cols       int               `json:"cols"`
+	Rows       int               `json:"rows"`
+	SavedAt    time.Time         `json:"savedAt"`
+	// Lines are the retained lines, scrollback then screen, oldest first.
+	Lines []checkpointLine `json:"lines"`
+}
+
+// checkpointLine keeps cell attributes in their internal form so a restored
+// line renders exactly as it did before.
+type checkpointLine struct {
+	Text string          `json:"text"`
+	Runs []checkpointRun `json:"runs,omitempty"`
+}
+
+type checkpointRun struct {
+	Start  int         `json:"start"`
+	Length int         `json:"length"`
+	FG     screenColor `json:"fg,omitempty"`
+	BG     screenColor `json:"bg,omitempty"`
+	Flags  uint8       `json:"flags,omitempty"`
+}
+
+// checkpointPath names a terminal's checkpoint by a hash of its id, which
+// may hold characters a file name cannot.
+func checkpointPath(dir string, terminalID string) string {
+	sum := sha256.Sum256([]byte(terminalID))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// newScrollbackCheckpoint captures the retained lines of screen, dropping
+// the blank rows below the last output.
+func newScrollbackCheckpoint(entry *terminalEntry) scrollbackCheckpoint {
+	cells := entry.screen.RetainedLines()
+	lines := make([]checkpointLine, 0, len(cells))
+	for _, line := range cells {
+		lines = append(lines, encodeCheckpointLine(line))
+	}
+	for len(lines) > 0 && lines[len(lines)-1].Text == "" && len(lines[len(lines)-1].Runs) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	cols, rows := entry.resizer.Size()
+	return scrollbackCheckpoint{
+		Version:    checkpointVersion,
+		TerminalID: entry.id,
+		Display:    entry.display,
+		Cwd:        entry.cwd,
+		Tags:       entry.tags,
+		Cols:       cols,
+		Rows:       rows,
+		SavedAt:    time.Now().UTC(),
+		Lines:      lines,
+	}
+}
+
+func encodeCheckpointLine(line []screenCell) checkpointLine {
+	rendered := renderSnapshotLine(line)
+	encoded := checkpointLine{Text: rendered.Text}
+	for _, run := range rendered.Runs {
+		encoded.Runs = append(encoded.Runs, checkpointRun{
+			Start:  run.Start,
+			Length: run.Length,
+			FG:     run.attrs.fg,
+			BG:     run.attrs.bg,
+			Flags:  run.attrs.flags,
+		})
+	}
+	return encoded
+}
+
+func decodeCheckpointLine(line checkpointLine) []screenCell {
+	runes := []rune(line.Text)
+	cells := make([]screenCell, len(runes))
+	for idx, ch := range runes {
+		cells[idx] = screenCell{ch: ch}
+	}
+	for _, run := range line.Runs {
+		attrs := cellAttrs{fg: run.FG, bg: run.BG, flags: run.Flags}
+		for idx := max(run.Start, 0); idx < min(run.Start+run.Length, len(cells)); idx++ {
+			cells[idx].attrs = attrs
+		}
+	}
+	return cells
+}
+
+// saveScrollbackCheckpoint replaces a terminal's checkpoint through a
+// temporary file, so a crash mid-write leaves the previous one intact.
+func saveScrollbackCheckpoint(dir string, checkpoint scrollbackCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	path := checkpointPath(dir, checkpoint.TerminalID)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// loadScrollbackCheckpoint reads a terminal's checkpoint, returning nil
+// without error when there is none.
+func loadScrollbackCheckpoint(dir string, terminalID string) (*scrollbackCheckpoint, error) {
+	path := checkpointPath(dir, terminalID)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint scrollbackCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	if checkpoint.Version != checkpointVersion || checkpoint.TerminalID != terminalID {
+		return nil, fmt.Errorf("checkpoint %s does not belong to terminal %q", path, terminalID)
+	}
+	return &checkpoint, nil
+}
+
+// RestoreScrollback puts lines ahead of the current scrollback, keeping the
+// newest lines when they do not all fit.
+func (s *screenEmulator) RestoreScrollback(lines []checkpointLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	restored := make([][]screenCell, 0, len(lines)+len(s.scrollback))
+	for _, line := range lines {
+		restored = append(restored, decodeCheckpointLine(line))
+	}
+	restored = append(restored, s.scrollback...)
+	if overflow := len(restored) - s.maxScrollback; overflow > 0 {
+		restored = restored[overflow:]
+	}
+	s.scrollback = restored
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+const (
+	// defaultServiceName names the service service install registers.
+	defaultServiceName    = "hapi-pty"
+	defaultSystemdUnitDir = "/etc/systemd/system"
+)
+
+// runService implements "hapi-pty service": install registers the --listen
+// sidecar with the Windows service manager or as a systemd unit, and run is
+// what the service manager starts.
+func runService(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "install":
+			return installService(args[1:], stdout, stderr)
+		case "run":
+			return runMain(append([]string{"--service"}, args[1:]...), stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintln(stderr, "usage: hapi-pty service install|run [flags]")
+	return exitCodeUsage
+}
+
+func installService(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("hapi-pty service install", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	name := flags.String("name", defaultServiceName, "service or systemd unit name")
+	unitDir := flags.String("unit-dir", defaultSystemdUnitDir, "directory the systemd unit is written to, outside Windows")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitCodeUsage
+	}
+
+	// What follows the install flags, after --, is the service's run flags.
+	runArgs := flags.Args()
+	cfg, err := parseRunFlags(runArgs, stderr)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(stderr, err)
+		}
+		return exitCodeUsage
+	}
+	if cfg.Listen == "" {
+		fmt.Fprintln(stderr, "usage: hapi-pty service install [--name NAME] -- --listen ADDR --auth-token-file FILE [flags]")
+		return exitCodeUsage
+	}
+	// The service manager starts the sidecar without the installer's
+	// environment.
+	if cfg.AuthTokenFile == "" {
+		fmt.Fprintf(stderr, "service install requires --auth-token-file; the service does not inherit $%s\n", authTokenEnv)
+		return exitCodeUsage
+	}
+	if _, err := loadAuthToken(cfg.AuthTokenFile); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitCodeUsage
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to locate sidecar executable: %v\n", err)
+		return 1
+	}
+	if err := installPlatformService(*name, executable, append([]string{"service", "run"}, runArgs...), *unitDir, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// serveUntilSignalled runs serve until an interrupt or SIGTERM arrives,
+// calling onStop first.
+func serveUntilSignalled(serve func(stop <-chan struct{}) int, onStop func()) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-signals:
+			if onStop != nil {
+				onStop()
+			}
+			close(stop)
+		case <-done:
+		}
+	}()
+	return serve(stop)
+}
+
+// sdNotify sends state to systemd's notification socket. It does nothing
+// when the sidecar was not started by a Type=notify unit.
+func sdNotify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	// A leading @ names a socket in the abstract namespace.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
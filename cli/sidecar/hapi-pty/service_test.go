@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSDNotifySendsStateToNotifySocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("systemd notifications are Unix datagrams")
+	}
+	path := filepath.Join(t.TempDir(), "notify")
+	socket, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = socket.Close() })
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("sdNotify failed: %v", err)
+	}
+	buffer := make([]byte, 64)
+	_ = socket.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := socket.Read(buffer)
+	if err != nil || string(buffer[:n]) != "READY=1" {
+		t.Fatalf("expected READY=1, got %q (%v)", buffer[:n], err)
+	}
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("STOPPING=1"); err != nil {
+		t.Fatalf("expected no-op without NOTIFY_SOCKET, got %v", err)
+	}
+}
+
+func TestInstallServiceRequiresListenAndTokenFile(t *testing.T) {
+	var stderr strings.Builder
+	if code := runService([]string{"install", "--", "--idle-timeout", "1m"}, nil, io.Discard, &stderr); code != exitCodeUsage {
+		t.Fatalf("expected usage error without --listen, got %d", code)
+	}
+	stderr.Reset()
+	if code := runService([]string{"install", "--", "--listen", "127.0.0.1:0"}, nil, io.Discard, &stderr); code != exitCodeUsage {
+		t.Fatalf("expected usage error without --auth-token-file, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "--auth-token-file") {
+		t.Fatalf("expected the missing token file named, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,177 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	serviceWin32OwnProcess    = 0x10
+	serviceAutoStart          = 2
+	serviceErrorNormal        = 1
+	serviceAllAccess          = 0xF01FF
+	scManagerCreateService    = 0x2
+	serviceStopped            = 1
+	serviceStopPending        = 3
+	serviceRunning            = 4
+	serviceAcceptStop         = 0x1
+	serviceAcceptShutdown     = 0x4
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+	errorCallNotImplemented   = 120
+	errorServiceNotConnected  = 1063
+	serviceConfigDescription  = 1
+	serviceStopWaitHintMs     = 10000
+	serviceDisplayName        = "hapi-pty terminal sidecar"
+	serviceDescriptionText    = "Serves hapi terminals over the network; terminals outlive interactive logins."
+)
+
+var (
+	procStartServiceCtrlDispatcherW = advapi32Proc.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerW = advapi32Proc.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus            = advapi32Proc.NewProc("SetServiceStatus")
+	procOpenSCManagerW              = advapi32Proc.NewProc("OpenSCManagerW")
+	procCreateServiceW              = advapi32Proc.NewProc("CreateServiceW")
+	procChangeServiceConfig2W       = advapi32Proc.NewProc("ChangeServiceConfig2W")
+	procCloseServiceHandle          = advapi32Proc.NewProc("CloseServiceHandle")
+)
+
+type serviceTableEntry struct {
+	Name *uint16
+	Proc uintptr
+}
+
+type serviceStatus struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+}
+
+// windowsService is the one service this process runs. The service manager
+// calls serviceMain and the control handler on threads of its own, which
+// reach it through this variable rather than through callback arguments.
+var windowsService struct {
+	serve    func(stop <-chan struct{}) int
+	status   uintptr
+	stop     chan struct{}
+	stopOnce sync.Once
+	exitCode int
+}
+
+// runUnderServiceManager hands the main thread to the service manager,
+// which runs serve on its own thread and stops it through the control
+// handler. Started from a console instead, it serves until Ctrl+C.
+func runUnderServiceManager(serve func(stop <-chan struct{}) int, stderr io.Writer) int {
+	windowsService.serve = serve
+	windowsService.stop = make(chan struct{})
+
+	name, _ := syscall.UTF16PtrFromString(defaultServiceName)
+	table := []serviceTableEntry{
+		{Name: name, Proc: syscall.NewCallback(serviceMain)},
+		{},
+	}
+	ret, _, err := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0])))
+	if ret == 0 {
+		if err == syscall.Errno(errorServiceNotConnected) {
+			return serveUntilSignalled(serve, nil)
+		}
+		fmt.Fprintf(stderr, "failed to connect to the service manager: %v\n", err)
+		return 1
+	}
+	return windowsService.exitCode
+}
+
+func serviceMain(argc uintptr, argv uintptr) uintptr {
+	_ = argc
+	_ = argv
+	empty, _ := syscall.UTF16PtrFromString("")
+	status, _, _ := procRegisterServiceCtrlHandlerW.Call(uintptr(unsafe.Pointer(empty)), syscall.NewCallback(serviceControlHandler), 0)
+	if status == 0 {
+		return 0
+	}
+	windowsService.status = status
+
+	setServiceStatus(serviceRunning, serviceAcceptStop|serviceAcceptShutdown, 0, 0)
+	windowsService.exitCode = windowsService.serve(windowsService.stop)
+	setServiceStatus(serviceStopped, 0, uint32(windowsService.exitCode), 0)
+	return 0
+}
+
+func serviceControlHandler(control uintptr, eventType uintptr, eventData uintptr, context uintptr) uintptr {
+	_ = eventType
+	_ = eventData
+	_ = context
+	switch control {
+	case serviceControlStop, serviceControlShutdown:
+		setServiceStatus(serviceStopPending, 0, 0, serviceStopWaitHintMs)
+		windowsService.stopOnce.Do(func() { close(windowsService.stop) })
+		return 0
+	case serviceControlInterrogate:
+		return 0
+	}
+	return errorCallNotImplemented
+}
+
+func setServiceStatus(state uint32, accepted uint32, exitCode uint32, waitHintMs uint32) {
+	status := serviceStatus{
+		ServiceType:      serviceWin32OwnProcess,
+		CurrentState:     state,
+		ControlsAccepted: accepted,
+		Win32ExitCode:    exitCode,
+		WaitHint:         waitHintMs,
+	}
+	procSetServiceStatus.Call(windowsService.status, uintptr(unsafe.Pointer(&status)))
+}
+
+// installPlatformService registers an automatically started service that
+// runs executable with args as LocalSystem.
+func installPlatformService(name string, executable string, args []string, unitDir string, stdout io.Writer) error {
+	_ = unitDir
+	manager, _, err := procOpenSCManagerW.Call(0, 0, scManagerCreateService)
+	if manager == 0 {
+		return fmt.Errorf("failed to open the service manager: %w", err)
+	}
+	defer procCloseServiceHandle.Call(manager)
+
+	namePtr, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	displayPtr, _ := syscall.UTF16PtrFromString(serviceDisplayName)
+	commandPtr, err := syscall.UTF16PtrFromString(buildCommandLine(executable, args, ""))
+	if err != nil {
+		return err
+	}
+	service, _, err := procCreateServiceW.Call(
+		manager,
+		uintptr(unsafe.Pointer(namePtr)),
+		uintptr(unsafe.Pointer(displayPtr)),
+		serviceAllAccess,
+		serviceWin32OwnProcess,
+		serviceAutoStart,
+		serviceErrorNormal,
+		uintptr(unsafe.Pointer(commandPtr)),
+		0, 0, 0, 0, 0,
+	)
+	if service == 0 {
+		return fmt.Errorf("failed to create service %s: %w", name, err)
+	}
+	defer procCloseServiceHandle.Call(service)
+
+	descriptionPtr, _ := syscall.UTF16PtrFromString(serviceDescriptionText)
+	description := struct{ Description *uint16 }{descriptionPtr}
+	procChangeServiceConfig2W.Call(service, serviceConfigDescription, uintptr(unsafe.Pointer(&description)))
+
+	fmt.Fprintf(stdout, "installed service %s; start it with: sc.exe start %s\n", name, name)
+	return nil
+}
@@ -1,55 +1,186 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type shellLookupFunc func(file string) (string, error)
 type pathExistsFunc func(path string) bool
 
+// vswhereFunc runs vswhere.exe with args and returns its standard output.
+type vswhereFunc func(path string, args ...string) (string, error)
+
+// versionProbeFunc runs a shell with its VersionArgs and returns the output.
+type versionProbeFunc func(path string, args ...string) (string, error)
+
 type resolvedShell struct {
 	Name string
 	Path string
 	Args []string
+	// Command follows Args on the command line unescaped. It carries the
+	// command of cmd /S /K, whose quoting is not the C runtime's that Args
+	// are escaped for.
+	Command string
+	// Env holds defaults the shell needs, which the request's env overrides.
+	Env map[string]string
 }
 
 type shellSpec struct {
 	Executable string
 	Args       []string
+	Env        map[string]string
+	// Candidates lists standard install locations tried after PATH, or
+	// instead of it when SkipPath is set.
+	Candidates func(env map[string]string) []string
+	SkipPath   bool
+	// VersionArgs make the shell print its version and exit.
+	VersionArgs []string
+	// Unix marks shells whose programs read TERM, see terminalEnv.
+	Unix bool
 }
 
 type shellResolveOptions struct {
 	LookPath   shellLookupFunc
 	PathExists pathExistsFunc
 	Env        map[string]string
+	// VisualStudio selects the installation and toolset for the vsdevcmd
+	// and vsdevshell shells, which locate it through VSWhere.
+	VisualStudio *visualStudioOptions
+	VSWhere      vswhereFunc
+	// DefaultShell picks the shell for opens that name none, see
+	// defaultShellOrder and defaultShellSystem; ReadFile reads Windows
+	// Terminal's settings for the latter.
+	DefaultShell string
+	ReadFile     func(path string) ([]byte, error)
+	// Order replaces shellOrder when set, see parseShellOrder.
+	Order []string
+	// Launch adjusts the arguments of whichever shell is resolved.
+	Launch shellLaunch
+	// TermProgram is the TERM_PROGRAM Unix shells see; empty leaves it unset.
+	TermProgram string
+}
+
+// shellLaunch holds an open's extraArgs, noProfile, executionPolicy and
+// forceUtf8.
+type shellLaunch struct {
+	ExtraArgs       []string
+	NoProfile       bool
+	ExecutionPolicy string
+	UTF8            bool
+}
+
+// utf8CodePage is the console code page forceUtf8 selects.
+const utf8CodePage = 65001
+
+const (
+	cmdUTF8        = "chcp 65001>nul"
+	powerShellUTF8 = "$OutputEncoding = [Console]::InputEncoding = [Console]::OutputEncoding = [Text.UTF8Encoding]::new($false)"
+)
+
+// powerShellExecutionPolicies maps accepted -ExecutionPolicy values, in any
+// case, to their canonical spelling.
+var powerShellExecutionPolicies = map[string]string{
+	"allsigned":    "AllSigned",
+	"bypass":       "Bypass",
+	"default":      "Default",
+	"remotesigned": "RemoteSigned",
+	"restricted":   "Restricted",
+	"undefined":    "Undefined",
+	"unrestricted": "Unrestricted",
 }
 
 const (
 	gitBashEnvPath = "HAPI_GIT_BASH_PATH"
+	shellOrderEnv  = "HAPI_SHELL_ORDER"
+	// defaultTermProgram is the TERM_PROGRAM identity, see terminalEnv.
+	defaultTermProgram = "hapi"
+
+	shellVSDevCmd   = "vsdevcmd"
+	shellVSDevShell = "vsdevshell"
+	shellNu         = "nu"
+	shellCygwinBash = "cygwin-bash"
+	shellMSYS2Bash  = "msys2-bash"
+
+	// defaultShellOrder tries shellOrder; defaultShellSystem follows the
+	// user's Windows Terminal default profile, then %ComSpec%, and only
+	// then shellOrder.
+	defaultShellOrder  = "order"
+	defaultShellSystem = "system"
 )
 
 var shellOrder = []string{"pwsh", "powershell", "cmd"}
 
 var shellSpecs = map[string]shellSpec{
 	"pwsh": {
-		Executable: "pwsh.exe",
-		Args:       []string{"-NoLogo"},
+		Executable:  "pwsh.exe",
+		Args:        []string{"-NoLogo"},
+		VersionArgs: []string{"-v"},
 	},
 	"powershell": {
-		Executable: "powershell.exe",
-		Args:       []string{"-NoLogo"},
+		Executable:  "powershell.exe",
+		Args:        []string{"-NoLogo"},
+		VersionArgs: []string{"-NoLogo", "-NoProfile", "-NonInteractive", "-Command", "$PSVersionTable.PSVersion.ToString()"},
 	},
 	"cmd": {
+		Executable:  "cmd.exe",
+		Args:        []string{"/Q"},
+		VersionArgs: []string{"/c", "ver"},
+	},
+	"gitbash": {
+		Executable:  "bash.exe",
+		Args:        []string{"--login", "-i"},
+		VersionArgs: []string{"--version"},
+		Unix:        true,
+	},
+	// The Developer Command Prompt and Developer PowerShell; their
+	// remaining arguments depend on the Visual Studio installation.
+	shellVSDevCmd: {
 		Executable: "cmd.exe",
 		Args:       []string{"/Q"},
 	},
-	"gitbash": {
+	shellVSDevShell: {
+		Executable: "pwsh.exe",
+		Args:       []string{"-NoLogo", "-NoExit"},
+	},
+	shellNu: {
+		Executable:  "nu.exe",
+		Args:        []string{"--login"},
+		Candidates:  nuCandidates,
+		VersionArgs: []string{"--version"},
+	},
+	// bash.exe on PATH is usually Git's or WSL's, so cygwin and MSYS2 are
+	// only looked for where they install. CHERE_INVOKING keeps their login
+	// profiles from changing to the home directory.
+	shellCygwinBash: {
 		Executable: "bash.exe",
 		Args:       []string{"--login", "-i"},
+		Env:        map[string]string{"CHERE_INVOKING": "1"},
+		Candidates: cygwinBashCandidates,
+		SkipPath:   true,
+		// The version is bash's, not cygwin's.
+		VersionArgs: []string{"--version"},
+		Unix:        true,
+	},
+	shellMSYS2Bash: {
+		Executable:  "bash.exe",
+		Args:        []string{"--login", "-i"},
+		Env:         map[string]string{"MSYSTEM": "MSYS", "CHERE_INVOKING": "1"},
+		Candidates:  msys2BashCandidates,
+		SkipPath:    true,
+		VersionArgs: []string{"--version"},
+		Unix:        true,
 	},
 }
 
@@ -66,7 +197,12 @@ func resolveShellWithOptions(requested string, options shellResolveOptions) (res
 	}
 
 	if requested == "" {
-		return resolveDefaultShell(lookPath)
+		if options.DefaultShell == defaultShellSystem {
+			if shell, ok := resolveSystemDefaultShell(options); ok {
+				return shell, nil
+			}
+		}
+		return resolveDefaultShell(options)
 	}
 
 	spec, ok := shellSpecs[requested]
@@ -79,24 +215,116 @@ func resolveShellWithOptions(requested string, options shellResolveOptions) (res
 		return resolvedShell{}, err
 	}
 
+	args, err := launchArgs(requested, spec.Args, options.Launch)
+	if err != nil {
+		return resolvedShell{}, err
+	}
+	var command string
+	if requested == shellVSDevCmd || requested == shellVSDevShell {
+		devArgs, devCommand, err := visualStudioShellArgs(requested, options)
+		if err != nil {
+			return resolvedShell{}, err
+		}
+		args = append(args, devArgs...)
+		command = devCommand
+	}
+	if options.Launch.UTF8 {
+		args, command = forceUTF8(requested, args, command)
+	}
+	if command != "" {
+		// cmd /S strips exactly these quotes and runs the rest verbatim.
+		command = `"` + command + `"`
+	}
+
+	var env map[string]string
+	if len(spec.Env) > 0 || spec.Unix {
+		env = make(map[string]string, len(spec.Env))
+		for key, value := range spec.Env {
+			env[key] = value
+		}
+	}
+	if spec.Unix {
+		for key, value := range terminalEnv(options.TermProgram) {
+			env[key] = value
+		}
+	}
+
 	return resolvedShell{
-		Name: requested,
-		Path: path,
-		Args: append([]string(nil), spec.Args...),
+		Name:    requested,
+		Path:    path,
+		Args:    args,
+		Command: command,
+		Env:     env,
 	}, nil
 }
 
-func resolveDefaultShell(lookPath shellLookupFunc) (resolvedShell, error) {
+// terminalEnv describes the ConPTY terminal to programs of Unix shells,
+// which otherwise fall back to a dumb terminal without color or cursor
+// movement. Like the shell's own defaults they yield to the open's env.
+func terminalEnv(termProgram string) map[string]string {
+	env := map[string]string{
+		"TERM":      "xterm-256color",
+		"COLORTERM": "truecolor",
+	}
+	if termProgram != "" {
+		env["TERM_PROGRAM"] = termProgram
+	}
+	return env
+}
+
+// pushdShell has a cmd shell pushd into cwd as it starts, after whatever
+// its command line already runs, such as VsDevCmd.bat.
+func pushdShell(shell resolvedShell, cwd string) resolvedShell {
+	pushd := `pushd "` + cwd + `"`
+	if shell.Command == "" {
+		shell.Args = append(append([]string(nil), shell.Args...), "/S", "/K")
+		shell.Command = `"` + pushd + `"`
+		return shell
+	}
+	// Command is already wrapped in the quotes /S strips.
+	shell.Command = strings.TrimSuffix(shell.Command, `"`) + " & " + pushd + `"`
+	return shell
+}
+
+// consoleCodePage is the code page programs in shell write in, see forceUTF8.
+func consoleCodePage(shell string, forceUTF8 bool) int {
+	switch shell {
+	case "cmd", "pwsh", "powershell", shellVSDevCmd, shellVSDevShell:
+		if forceUTF8 {
+			return utf8CodePage
+		}
+	}
+	return systemOEMCodePage()
+}
+
+// forceUTF8 switches the console to code page 65001 as the shell starts: cmd
+// runs chcp and PowerShell sets its console and pipeline encodings. bash and
+// nu write UTF-8 themselves and are left alone.
+func forceUTF8(shell string, args []string, command string) ([]string, string) {
+	switch shell {
+	case "cmd":
+		return append(args, "/S", "/K"), cmdUTF8
+	case shellVSDevCmd:
+		return args, command + " & " + cmdUTF8
+	case "pwsh", "powershell":
+		return append(args, "-NoExit", "-Command", powerShellUTF8), command
+	case shellVSDevShell:
+		args[len(args)-1] += "; " + powerShellUTF8
+	}
+	return args, command
+}
+
+func resolveDefaultShell(options shellResolveOptions) (resolvedShell, error) {
+	order := options.Order
+	if len(order) == 0 {
+		order = shellOrder
+	}
+
 	var lastErr error
-	for _, name := range shellOrder {
-		spec := shellSpecs[name]
-		path, err := lookPath(spec.Executable)
+	for _, name := range order {
+		shell, err := resolveShellWithOptions(name, options)
 		if err == nil {
-			return resolvedShell{
-				Name: name,
-				Path: path,
-				Args: append([]string(nil), spec.Args...),
-			}, nil
+			return shell, nil
 		}
 		lastErr = err
 	}
@@ -108,11 +336,300 @@ func resolveDefaultShell(lookPath shellLookupFunc) (resolvedShell, error) {
 	return resolvedShell{}, newSidecarError(
 		errorCodeShellNotFound,
 		"no supported shell found (tried %s): %v",
-		fmtShellCandidates(),
+		fmtShellCandidates(order),
 		lastErr,
 	)
 }
 
+// parseShellOrder validates a comma-separated shell fallback order such as
+// "gitbash,pwsh,cmd" from --shell-order or HAPI_SHELL_ORDER.
+func parseShellOrder(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var order []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := shellSpecs[name]; !ok {
+			return nil, fmt.Errorf("unknown shell %q in shell order", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("shell %q appears twice in shell order", name)
+		}
+		seen[name] = true
+		order = append(order, name)
+	}
+	return order, nil
+}
+
+// shellNames returns every supported shell name, sorted.
+func shellNames() []string {
+	names := make([]string, 0, len(shellSpecs))
+	for name := range shellSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// launchArgs merges launch into the shell's base arguments. The toggles use
+// each shell's own flag: -NoProfile for PowerShell, /D to skip cmd's AutoRun
+// and --noprofile --norc for bash, whose long options must come first.
+// ExtraArgs follow the base arguments but precede the vsdevcmd and vsdevshell
+// scripts, since cmd and PowerShell treat everything after /K or -Command as
+// part of the command.
+func launchArgs(shell string, base []string, launch shellLaunch) ([]string, error) {
+	var prefix, flags []string
+	powerShell := shell == "pwsh" || shell == "powershell" || shell == shellVSDevShell
+
+	if launch.NoProfile {
+		switch {
+		case powerShell:
+			flags = append(flags, "-NoProfile")
+		case shell == "cmd" || shell == shellVSDevCmd:
+			flags = append(flags, "/D")
+		case shell == "gitbash" || shell == shellCygwinBash || shell == shellMSYS2Bash:
+			prefix = append(prefix, "--noprofile", "--norc")
+		case shell == shellNu:
+			flags = append(flags, "--no-config-file")
+		default:
+			return nil, newSidecarError(errorCodeInvalidRequest, "noProfile is not supported for shell %q", shell)
+		}
+	}
+
+	if launch.ExecutionPolicy != "" {
+		if !powerShell {
+			return nil, newSidecarError(errorCodeInvalidRequest, "executionPolicy only applies to PowerShell, not %q", shell)
+		}
+		policy, ok := powerShellExecutionPolicies[strings.ToLower(launch.ExecutionPolicy)]
+		if !ok {
+			return nil, newSidecarError(errorCodeInvalidRequest, "unknown execution policy %q", launch.ExecutionPolicy)
+		}
+		flags = append(flags, "-ExecutionPolicy", policy)
+	}
+
+	args := make([]string, 0, len(prefix)+len(base)+len(flags)+len(launch.ExtraArgs))
+	args = append(args, prefix...)
+	args = append(args, base...)
+	args = append(args, flags...)
+	return append(args, launch.ExtraArgs...), nil
+}
+
+// resolveSystemDefaultShell returns the shell of the default profile in
+// Windows Terminal's settings, or else the one %ComSpec% names.
+func resolveSystemDefaultShell(options shellResolveOptions) (resolvedShell, bool) {
+	pathExists := options.PathExists
+	if pathExists == nil {
+		pathExists = defaultPathExists
+	}
+	readFile := options.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+
+	if localAppData, ok := lookupEnv(options.Env, "LocalAppData"); ok {
+		for _, settingsPath := range windowsTerminalSettingsPaths(localAppData) {
+			data, err := readFile(settingsPath)
+			if err != nil {
+				continue
+			}
+			name, executable := windowsTerminalDefaultShell(data)
+			if name == "" {
+				// The default profile is one hapi-pty cannot run, such as WSL.
+				break
+			}
+			shell, err := resolveShellWithOptions(name, options)
+			if err != nil {
+				break
+			}
+			if filepath.IsAbs(executable) && pathExists(executable) {
+				shell.Path = executable
+			}
+			return shell, true
+		}
+	}
+
+	comSpec, ok := lookupEnv(options.Env, "ComSpec")
+	if !ok || strings.TrimSpace(comSpec) == "" {
+		comSpec, ok = readSystemComSpec()
+	}
+	if !ok {
+		return resolvedShell{}, false
+	}
+	comSpec = filepath.Clean(expandPath(strings.TrimSpace(comSpec), os.Environ(), ""))
+	if !pathExists(comSpec) {
+		return resolvedShell{}, false
+	}
+
+	name := shellNameForExecutable(comSpec)
+	spec, known := shellSpecs[name]
+	if !known {
+		name = strings.TrimSuffix(strings.ToLower(filepath.Base(comSpec)), ".exe")
+	}
+	return resolvedShell{Name: name, Path: comSpec, Args: append([]string(nil), spec.Args...)}, true
+}
+
+// windowsTerminalSettingsPaths lists where the store, preview and
+// unpackaged Windows Terminal builds keep settings.json.
+func windowsTerminalSettingsPaths(localAppData string) []string {
+	return []string{
+		filepath.Join(localAppData, "Packages", "Microsoft.WindowsTerminal_8wekyb3d8bbwe", "LocalState", "settings.json"),
+		filepath.Join(localAppData, "Packages", "Microsoft.WindowsTerminalPreview_8wekyb3d8bbwe", "LocalState", "settings.json"),
+		filepath.Join(localAppData, "Microsoft", "Windows Terminal", "settings.json"),
+	}
+}
+
+type windowsTerminalProfile struct {
+	GUID        string `json:"guid"`
+	Name        string `json:"name"`
+	Commandline string `json:"commandline"`
+	Source      string `json:"source"`
+}
+
+// windowsTerminalBuiltinProfiles are the profiles Windows Terminal generates
+// without a commandline in settings.json.
+var windowsTerminalBuiltinProfiles = map[string]string{
+	"{61c54bbd-c2c6-5271-96e7-009a87ff44bf}": "powershell",
+	"{0caa0dad-35be-5f56-a8ff-afceeeaa6101}": "cmd",
+}
+
+var windowsTerminalSources = map[string]string{
+	"Windows.Terminal.PowershellCore": "pwsh",
+	"Git":                             "gitbash",
+	"Windows.Terminal.VisualStudio":   shellVSDevShell,
+}
+
+// windowsTerminalDefaultShell returns the shell name of the default profile
+// in a Windows Terminal settings.json, and the executable its commandline
+// starts when that is a plain path.
+func windowsTerminalDefaultShell(data []byte) (string, string) {
+	var settings struct {
+		DefaultProfile string          `json:"defaultProfile"`
+		Profiles       json.RawMessage `json:"profiles"`
+	}
+	if err := json.Unmarshal(stripJSONComments(data), &settings); err != nil {
+		return "", ""
+	}
+
+	// profiles is either the list itself or an object holding it.
+	var profiles []windowsTerminalProfile
+	if err := json.Unmarshal(settings.Profiles, &profiles); err != nil {
+		var wrapped struct {
+			List []windowsTerminalProfile `json:"list"`
+		}
+		if err := json.Unmarshal(settings.Profiles, &wrapped); err != nil {
+			return "", ""
+		}
+		profiles = wrapped.List
+	}
+
+	for _, profile := range profiles {
+		if !strings.EqualFold(profile.GUID, settings.DefaultProfile) && profile.Name != settings.DefaultProfile {
+			continue
+		}
+		if profile.Commandline != "" {
+			executable := commandLineExecutable(profile.Commandline)
+			if strings.Contains(executable, "%") {
+				executable = expandPath(executable, os.Environ(), "")
+			}
+			return shellNameForExecutable(executable), executable
+		}
+		if name, ok := windowsTerminalSources[profile.Source]; ok {
+			return name, ""
+		}
+		return windowsTerminalBuiltinProfiles[strings.ToLower(profile.GUID)], ""
+	}
+	return "", ""
+}
+
+// shellNameForExecutable maps an executable path to the shell it runs, or ""
+// when it is not one hapi-pty knows, such as WSL's bash.exe.
+func shellNameForExecutable(path string) string {
+	lower := strings.ToLower(strings.ReplaceAll(path, "/", `\`))
+	switch strings.TrimSuffix(lower[strings.LastIndex(lower, `\`)+1:], ".exe") {
+	case "pwsh":
+		return "pwsh"
+	case "powershell":
+		return "powershell"
+	case "cmd":
+		return "cmd"
+	case "nu":
+		return shellNu
+	case "bash":
+		switch {
+		case strings.Contains(lower, `\git\`):
+			return "gitbash"
+		case strings.Contains(lower, "msys"):
+			return shellMSYS2Bash
+		case strings.Contains(lower, "cygwin"):
+			return shellCygwinBash
+		}
+	}
+	return ""
+}
+
+// commandLineExecutable returns the first, possibly quoted, token.
+func commandLineExecutable(commandLine string) string {
+	commandLine = strings.TrimSpace(commandLine)
+	if rest, ok := strings.CutPrefix(commandLine, `"`); ok {
+		executable, _, _ := strings.Cut(rest, `"`)
+		return executable
+	}
+	executable, _, _ := strings.Cut(commandLine, " ")
+	return executable
+}
+
+// stripJSONComments removes the // and /* */ comments and trailing commas
+// that Windows Terminal allows in settings.json.
+func stripJSONComments(data []byte) []byte {
+	var out bytes.Buffer
+	inString := false
+	for idx := 0; idx < len(data); idx++ {
+		c := data[idx]
+		if inString {
+			out.WriteByte(c)
+			if c == '\\' && idx+1 < len(data) {
+				idx++
+				out.WriteByte(data[idx])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && idx+1 < len(data) && data[idx+1] == '/':
+			for idx < len(data) && data[idx] != '\n' {
+				idx++
+			}
+			out.WriteByte('\n')
+		case c == '/' && idx+1 < len(data) && data[idx+1] == '*':
+			end := bytes.Index(data[idx+2:], []byte("*/"))
+			if end < 0 {
+				return out.Bytes()
+			}
+			idx += end + 3
+		case c == ',':
+			next := idx + 1
+			for next < len(data) && (data[next] == ' ' || data[next] == '\t' || data[next] == '\r' || data[next] == '\n') {
+				next++
+			}
+			if next < len(data) && (data[next] == '}' || data[next] == ']') {
+				continue
+			}
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
+
 func resolveShellPath(
 	requested string,
 	spec shellSpec,
@@ -122,12 +639,47 @@ func resolveShellPath(
 	if requested == "gitbash" {
 		return resolveGitBashPath(options, lookPath)
 	}
+	if requested == shellVSDevShell {
+		// Launch-VsDevShell.ps1 runs in Windows PowerShell as well.
+		if path, err := lookPath(spec.Executable); err == nil {
+			return path, nil
+		}
+		spec = shellSpecs["powershell"]
+	}
 
-	path, err := lookPath(spec.Executable)
-	if err != nil {
-		return "", newSidecarError(errorCodeShellNotFound, "%s not found in PATH", spec.Executable)
+	if spec.Candidates == nil {
+		path, err := lookPath(spec.Executable)
+		if err != nil {
+			return "", newSidecarError(errorCodeShellNotFound, "%s not found in PATH", spec.Executable)
+		}
+		return path, nil
 	}
-	return path, nil
+
+	var attemptedCandidates []string
+	if !spec.SkipPath {
+		if path, err := lookPath(spec.Executable); err == nil {
+			return path, nil
+		}
+		attemptedCandidates = append(attemptedCandidates, spec.Executable+" (PATH)")
+	}
+
+	pathExists := options.PathExists
+	if pathExists == nil {
+		pathExists = defaultPathExists
+	}
+	for _, candidate := range spec.Candidates(options.Env) {
+		attemptedCandidates = append(attemptedCandidates, candidate)
+		if pathExists(candidate) {
+			return candidate, nil
+		}
+	}
+
+	return "", newSidecarError(
+		errorCodeShellNotFound,
+		"%s not found (tried %s)",
+		requested,
+		strings.Join(uniqueNonEmpty(attemptedCandidates), ", "),
+	)
 }
 
 func resolveGitBashPath(options shellResolveOptions, lookPath shellLookupFunc) (string, error) {
@@ -216,6 +768,113 @@ func gitBashCommonCandidates(env map[string]string) []string {
 	return uniqueNonEmpty(candidates)
 }
 
+// shellVersionProbeTimeout bounds a version probe; a shell that does not
+// answer in time reports no version.
+const shellVersionProbeTimeout = 5 * time.Second
+
+// shellVersionPattern finds the version in probe output such as
+// "PowerShell 7.4.1", "Microsoft Windows [Version 10.0.22631.3007]" or
+// "GNU bash, version 5.2.21(1)-release".
+var shellVersionPattern = regexp.MustCompile(`[0-9]+(\.[0-9]+)+(\([0-9]+\))?(-[A-Za-z0-9.]+)?`)
+
+// shellVersionCache remembers the version probed for each executable,
+// including failed probes, so every shell is run at most once to ask.
+type shellVersionCache struct {
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+// Version returns the version of shell, probing it on first use, or "" when
+// the shell has no version probe or the probe failed.
+func (c *shellVersionCache) Version(shell resolvedShell, probe versionProbeFunc) string {
+	spec, ok := shellSpecs[shell.Name]
+	if !ok || len(spec.VersionArgs) == 0 || shell.Path == "" {
+		return ""
+	}
+
+	c.mu.Lock()
+	version, cached := c.versions[shell.Path]
+	c.mu.Unlock()
+	if cached {
+		return version
+	}
+
+	if probe == nil {
+		probe = runShellVersionProbe
+	}
+	if out, err := probe(shell.Path, spec.VersionArgs...); err == nil {
+		version = shellVersionPattern.FindString(out)
+	}
+
+	c.mu.Lock()
+	if c.versions == nil {
+		c.versions = map[string]string{}
+	}
+	c.versions[shell.Path] = version
+	c.mu.Unlock()
+	return version
+}
+
+func runShellVersionProbe(path string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shellVersionProbeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, args...).Output()
+	return string(out), err
+}
+
+func nuCandidates(env map[string]string) []string {
+	var candidates []string
+	for _, envName := range []string{"ProgramW6432", "ProgramFiles"} {
+		if programFiles, ok := lookupEnv(env, envName); ok {
+			candidates = append(candidates, filepath.Join(programFiles, "nu", "bin", "nu.exe"))
+		}
+	}
+	if localAppData, ok := lookupEnv(env, "LocalAppData"); ok {
+		candidates = append(candidates, filepath.Join(localAppData, "Programs", "nu", "bin", "nu.exe"))
+	}
+	if scoopRoot, ok := lookupEnv(env, "SCOOP"); ok {
+		candidates = append(candidates, filepath.Join(scoopRoot, "apps", "nu", "current", "nu.exe"))
+	}
+	if userProfile, ok := lookupEnv(env, "USERPROFILE"); ok {
+		candidates = append(candidates,
+			filepath.Join(userProfile, "scoop", "apps", "nu", "current", "nu.exe"),
+			filepath.Join(userProfile, ".cargo", "bin", "nu.exe"),
+		)
+	}
+	return uniqueNonEmpty(candidates)
+}
+
+func cygwinBashCandidates(env map[string]string) []string {
+	candidates := []string{`C:\cygwin64\bin\bash.exe`, `C:\cygwin\bin\bash.exe`}
+	if systemDrive, ok := lookupEnv(env, "SystemDrive"); ok {
+		candidates = append(candidates,
+			filepath.Join(systemDrive+`\`, "cygwin64", "bin", "bash.exe"),
+			filepath.Join(systemDrive+`\`, "cygwin", "bin", "bash.exe"),
+		)
+	}
+	if userProfile, ok := lookupEnv(env, "USERPROFILE"); ok {
+		candidates = append(candidates, filepath.Join(userProfile, "scoop", "apps", "cygwin", "current", "root", "bin", "bash.exe"))
+	}
+	return uniqueNonEmpty(candidates)
+}
+
+func msys2BashCandidates(env map[string]string) []string {
+	candidates := []string{`C:\msys64\usr\bin\bash.exe`, `C:\tools\msys64\usr\bin\bash.exe`}
+	if systemDrive, ok := lookupEnv(env, "SystemDrive"); ok {
+		candidates = append(candidates, filepath.Join(systemDrive+`\`, "msys64", "usr", "bin", "bash.exe"))
+	}
+	if tools, ok := lookupEnv(env, "ChocolateyToolsLocation"); ok {
+		candidates = append(candidates, filepath.Join(tools, "msys64", "usr", "bin", "bash.exe"))
+	}
+	if scoopRoot, ok := lookupEnv(env, "SCOOP"); ok {
+		candidates = append(candidates, filepath.Join(scoopRoot, "apps", "msys2", "current", "usr", "bin", "bash.exe"))
+	}
+	if userProfile, ok := lookupEnv(env, "USERPROFILE"); ok {
+		candidates = append(candidates, filepath.Join(userProfile, "scoop", "apps", "msys2", "current", "usr", "bin", "bash.exe"))
+	}
+	return uniqueNonEmpty(candidates)
+}
+
 func defaultPathExists(path string) bool {
 	if path == "" {
 		return false
@@ -253,8 +912,12 @@ func uniqueNonEmpty(items []string) []string {
 	return unique
 }
 
-func fmtShellCandidates() string {
-	return "pwsh.exe, powershell.exe, cmd.exe"
+func fmtShellCandidates(order []string) string {
+	executables := make([]string, 0, len(order))
+	for _, name := range order {
+		executables = append(executables, shellSpecs[name].Executable)
+	}
+	return strings.Join(uniqueNonEmpty(executables), ", ")
 }
 
 func sidecarErrorFrom(err error, fallbackCode string) *sidecarError {
@@ -268,3 +931,137 @@ func sidecarErrorFrom(err error, fallbackCode string) *sidecarError {
 		Message: err.Error(),
 	}
 }
+
+// visualStudioOptions picks the installation for the vsdevcmd and vsdevshell
+// shells. Version is a vswhere version range such as "[17.0,18.0)", Toolset
+// an MSVC toolset such as "14.29" and Arch and HostArch are x86, amd64, arm
+// or arm64. The newest installation and its default toolset are used when
+// unset.
+type visualStudioOptions struct {
+	Version  string `json:"version,omitempty"`
+	Toolset  string `json:"toolset,omitempty"`
+	Arch     string `json:"arch,omitempty"`
+	HostArch string `json:"hostArch,omitempty"`
+}
+
+var (
+	vsVersionRange = regexp.MustCompile(`^[\[(]?[0-9.]*(,[0-9.]*)?[\])]?$`)
+	vsToolset      = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+	vsArchs        = map[string]bool{"x86": true, "amd64": true, "arm": true, "arm64": true}
+)
+
+// visualStudioShellArgs locates the installation through vswhere and returns
+// the arguments that run VsDevCmd.bat or Launch-VsDevShell.ps1 from it, and
+// for vsdevcmd the command for /K.
+func visualStudioShellArgs(shell string, options shellResolveOptions) ([]string, string, error) {
+	var vs visualStudioOptions
+	if options.VisualStudio != nil {
+		vs = *options.VisualStudio
+	}
+	if !vsVersionRange.MatchString(vs.Version) {
+		return nil, "", newSidecarError(errorCodeInvalidRequest, "invalid visual studio version %q", vs.Version)
+	}
+	if vs.Toolset != "" && !vsToolset.MatchString(vs.Toolset) {
+		return nil, "", newSidecarError(errorCodeInvalidRequest, "invalid visual studio toolset %q", vs.Toolset)
+	}
+	for _, arch := range []string{vs.Arch, vs.HostArch} {
+		if arch != "" && !vsArchs[arch] {
+			return nil, "", newSidecarError(errorCodeInvalidRequest, "invalid visual studio arch %q", arch)
+		}
+	}
+
+	install, err := findVisualStudio(vs.Version, options)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var devArgs []string
+	if vs.Arch != "" {
+		devArgs = append(devArgs, "-arch="+vs.Arch)
+	}
+	if vs.HostArch != "" {
+		devArgs = append(devArgs, "-host_arch="+vs.HostArch)
+	}
+	if vs.Toolset != "" {
+		devArgs = append(devArgs, "-vcvars_ver="+vs.Toolset)
+	}
+
+	pathExists := options.PathExists
+	if pathExists == nil {
+		pathExists = defaultPathExists
+	}
+	tools := filepath.Join(install, "Common7", "Tools")
+
+	if shell == shellVSDevCmd {
+		script := filepath.Join(tools, "VsDevCmd.bat")
+		if !pathExists(script) {
+			return nil, "", newSidecarError(errorCodeShellNotFound, "VsDevCmd.bat not found in %s", install)
+		}
+		// Passed through Command, since Build Tools installs to
+		// "Program Files (x86)", whose parentheses defeat cmd's quoting.
+		return []string{"/S", "/K"}, strings.Join(append([]string{`"` + script + `"`, "-no_logo"}, devArgs...), " "), nil
+	}
+
+	script := filepath.Join(tools, "Launch-VsDevShell.ps1")
+	if !pathExists(script) {
+		return nil, "", newSidecarError(errorCodeShellNotFound, "Launch-VsDevShell.ps1 not found in %s", install)
+	}
+	command := fmt.Sprintf("& '%s' -VsInstallPath '%s' -SkipAutomaticLocation",
+		strings.ReplaceAll(script, "'", "''"), strings.ReplaceAll(install, "'", "''"))
+	if len(devArgs) > 0 {
+		command += " -DevCmdArguments '" + strings.Join(devArgs, " ") + "'"
+	}
+	return []string{"-Command", command}, "", nil
+}
+
+// findVisualStudio returns the installation path of the newest Visual Studio
+// or Build Tools matching version.
+func findVisualStudio(version string, options shellResolveOptions) (string, error) {
+	vswhere := ""
+	if programFiles, ok := lookupEnv(options.Env, "ProgramFiles(x86)"); ok {
+		vswhere = filepath.Join(programFiles, "Microsoft Visual Studio", "Installer", "vswhere.exe")
+	}
+	pathExists := options.PathExists
+	if pathExists == nil {
+		pathExists = defaultPathExists
+	}
+	if vswhere == "" || !pathExists(vswhere) {
+		lookPath := options.LookPath
+		if lookPath == nil {
+			lookPath = exec.LookPath
+		}
+		path, err := lookPath("vswhere.exe")
+		if err != nil {
+			return "", newSidecarError(errorCodeShellNotFound, "vswhere.exe not found; is Visual Studio installed?")
+		}
+		vswhere = path
+	}
+
+	args := []string{"-latest", "-products", "*", "-prerelease", "-property", "installationPath", "-format", "value"}
+	if version != "" {
+		args = append(args, "-version", version)
+	}
+	run := options.VSWhere
+	if run == nil {
+		run = runVSWhere
+	}
+	out, err := run(vswhere, args...)
+	if err != nil {
+		return "", newSidecarError(errorCodeShellNotFound, "vswhere failed: %v", err)
+	}
+
+	install, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	install = strings.TrimSpace(install)
+	if install == "" {
+		if version != "" {
+			return "", newSidecarError(errorCodeShellNotFound, "no Visual Studio installation matches version %s", version)
+		}
+		return "", newSidecarError(errorCodeShellNotFound, "no Visual Studio installation found")
+	}
+	return install, nil
+}
+
+func runVSWhere(path string, args ...string) (string, error) {
+	out, err := exec.Command(path, args...).Output()
+	return string(out), err
+}
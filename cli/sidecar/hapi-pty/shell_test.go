@@ -2,6 +2,9 @@ package main
 
 import (
 	"errors"
+	"io"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -148,6 +151,360 @@ func TestResolveShellReturnsShellNotFoundForMissingGitBashOverride(t *testing.T)
 	}
 }
 
+func TestResolveShellLocatesVisualStudioDevShells(t *testing.T) {
+	programFiles := `C:\Program Files (x86)`
+	vswhere := filepath.Join(programFiles, "Microsoft Visual Studio", "Installer", "vswhere.exe")
+	install := `C:\Program Files\Microsoft Visual Studio\2019\BuildTools`
+	tools := filepath.Join(install, "Common7", "Tools")
+
+	var gotArgs []string
+	options := shellResolveOptions{
+		LookPath: fakeLookup(map[string]string{
+			"cmd.exe":        `C:\Windows\System32\cmd.exe`,
+			"powershell.exe": `C:\Windows\System32\WindowsPowerShell\v1.0\powershell.exe`,
+		}),
+		Env: map[string]string{"ProgramFiles(x86)": programFiles},
+		PathExists: fakePathExists(map[string]bool{
+			vswhere:                              true,
+			filepath.Join(tools, "VsDevCmd.bat"): true,
+			filepath.Join(tools, "Launch-VsDevShell.ps1"): true,
+		}),
+		VisualStudio: &visualStudioOptions{Version: "[16.0,17.0)", Toolset: "14.29", Arch: "amd64"},
+		VSWhere: func(path string, args ...string) (string, error) {
+			if path != vswhere {
+				t.Fatalf("unexpected vswhere path: %s", path)
+			}
+			gotArgs = args
+			return install + "\r\n", nil
+		},
+	}
+
+	resolved, err := resolveShellWithOptions(shellVSDevCmd, options)
+	if err != nil {
+		t.Fatalf("resolveShellWithOptions failed: %v", err)
+	}
+	if !strings.Contains(strings.Join(gotArgs, " "), "-version [16.0,17.0)") {
+		t.Fatalf("vswhere did not get the version range: %q", gotArgs)
+	}
+	wantCommand := `""` + filepath.Join(tools, "VsDevCmd.bat") + `" -no_logo -arch=amd64 -vcvars_ver=14.29"`
+	if resolved.Path != `C:\Windows\System32\cmd.exe` || strings.Join(resolved.Args, " ") != "/Q /S /K" || resolved.Command != wantCommand {
+		t.Fatalf("unexpected developer command prompt: %s %q %s", resolved.Path, resolved.Args, resolved.Command)
+	}
+
+	resolved, err = resolveShellWithOptions(shellVSDevShell, options)
+	if err != nil {
+		t.Fatalf("resolveShellWithOptions failed: %v", err)
+	}
+	if !strings.HasSuffix(resolved.Path, "powershell.exe") {
+		t.Fatalf("expected Windows PowerShell fallback, got %s", resolved.Path)
+	}
+	command := resolved.Args[len(resolved.Args)-1]
+	if !strings.Contains(command, "Launch-VsDevShell.ps1") || !strings.Contains(command, "-DevCmdArguments '-arch=amd64 -vcvars_ver=14.29'") {
+		t.Fatalf("unexpected developer powershell command: %s", command)
+	}
+}
+
+func TestResolveShellRejectsVisualStudioOptions(t *testing.T) {
+	cases := map[string]struct {
+		vs     visualStudioOptions
+		output string
+		code   string
+	}{
+		"toolset injection": {vs: visualStudioOptions{Toolset: "14.29 & calc"}, code: errorCodeInvalidRequest},
+		"arch":              {vs: visualStudioOptions{Arch: "mips"}, code: errorCodeInvalidRequest},
+		"version":           {vs: visualStudioOptions{Version: "latest"}, code: errorCodeInvalidRequest},
+		"no installation":   {vs: visualStudioOptions{Version: "[99.0"}, code: errorCodeShellNotFound},
+	}
+
+	for name, tc := range cases {
+		vs := tc.vs
+		_, err := resolveShellWithOptions(shellVSDevCmd, shellResolveOptions{
+			LookPath:     fakeLookup(map[string]string{"cmd.exe": "cmd.exe", "vswhere.exe": "vswhere.exe"}),
+			Env:          map[string]string{},
+			PathExists:   fakePathExists(map[string]bool{}),
+			VisualStudio: &vs,
+			VSWhere:      func(string, ...string) (string, error) { return tc.output, nil },
+		})
+		if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != tc.code {
+			t.Fatalf("%s: expected %s, got %v", name, tc.code, err)
+		}
+	}
+}
+
+func TestResolveShellFindsNuCygwinAndMSYS2(t *testing.T) {
+	userProfile := `C:\Users\me`
+	nuPath := filepath.Join(userProfile, ".cargo", "bin", "nu.exe")
+	msysPath := filepath.Join(`C:\`, "msys64", "usr", "bin", "bash.exe")
+	options := shellResolveOptions{
+		// bash.exe on PATH must not be mistaken for cygwin's or MSYS2's.
+		LookPath: fakeLookup(map[string]string{"bash.exe": `C:\Windows\System32\bash.exe`}),
+		Env:      map[string]string{"USERPROFILE": userProfile, "SystemDrive": "C:"},
+		PathExists: fakePathExists(map[string]bool{
+			nuPath:   true,
+			msysPath: true,
+		}),
+	}
+
+	nu, err := resolveShellWithOptions(shellNu, options)
+	if err != nil || nu.Path != nuPath {
+		t.Fatalf("unexpected nu resolution: %+v, %v", nu, err)
+	}
+
+	msys, err := resolveShellWithOptions(shellMSYS2Bash, options)
+	if err != nil || msys.Path != msysPath {
+		t.Fatalf("unexpected msys2 resolution: %+v, %v", msys, err)
+	}
+	if msys.Env["MSYSTEM"] != "MSYS" || msys.Env["CHERE_INVOKING"] != "1" {
+		t.Fatalf("unexpected msys2 env: %#v", msys.Env)
+	}
+
+	_, err = resolveShellWithOptions(shellCygwinBash, options)
+	if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != errorCodeShellNotFound || strings.Contains(serr.Message, "System32") {
+		t.Fatalf("expected cygwin to be missing, got %v", err)
+	}
+}
+
+func TestResolveShellSetsTerminalEnvForUnixShells(t *testing.T) {
+	options := shellResolveOptions{
+		LookPath: fakeLookup(map[string]string{
+			"bash.exe": `C:\Program Files\Git\bin\bash.exe`,
+			"pwsh.exe": `C:\pwsh.exe`,
+		}),
+		TermProgram: "hapi",
+	}
+
+	bash, err := resolveShellWithOptions("gitbash", options)
+	if err != nil {
+		t.Fatalf("resolveShellWithOptions failed: %v", err)
+	}
+	want := map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor", "TERM_PROGRAM": "hapi"}
+	if !reflect.DeepEqual(bash.Env, want) {
+		t.Fatalf("unexpected gitbash env: %#v", bash.Env)
+	}
+
+	pwsh, err := resolveShellWithOptions("pwsh", options)
+	if err != nil || pwsh.Env != nil {
+		t.Fatalf("pwsh should get no terminal env: %#v, %v", pwsh.Env, err)
+	}
+}
+
+func TestResolveShellFollowsWindowsTerminalDefaultProfile(t *testing.T) {
+	localAppData := `C:\Users\me\AppData\Local`
+	settingsPath := windowsTerminalSettingsPaths(localAppData)[0]
+	settings := `{
+		// Comments and trailing commas are allowed.
+		"defaultProfile": "{574E775E-4F2A-5B96-AC1E-A2962A402336}",
+		"profiles": {
+			"defaults": {},
+			"list": [
+				{"guid": "{61c54bbd-c2c6-5271-96e7-009a87ff44bf}", "name": "Windows PowerShell"},
+				/* PowerShell 7 */
+				{"guid": "{574e775e-4f2a-5b96-ac1e-a2962a402336}", "commandline": "\"C:\\Tools\\pwsh.exe\" -NoProfile",},
+			],
+		},
+	}`
+	files := map[string]string{settingsPath: settings}
+	options := shellResolveOptions{
+		LookPath:     fakeLookup(map[string]string{"pwsh.exe": `C:\Program Files\PowerShell\7\pwsh.exe`}),
+		Env:          map[string]string{"LocalAppData": localAppData},
+		PathExists:   fakePathExists(map[string]bool{}),
+		DefaultShell: defaultShellSystem,
+		ReadFile: func(path string) ([]byte, error) {
+			data, ok := files[path]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			return []byte(data), nil
+		},
+	}
+
+	resolved, err := resolveShellWithOptions("", options)
+	if err != nil || resolved.Name != "pwsh" || resolved.Path != `C:\Program Files\PowerShell\7\pwsh.exe` {
+		t.Fatalf("unexpected default shell: %+v, %v", resolved, err)
+	}
+
+	// A WSL default profile falls back to %ComSpec%.
+	files[settingsPath] = `{"defaultProfile": "Ubuntu", "profiles": [{"name": "Ubuntu", "source": "Windows.Terminal.Wsl"}]}`
+	comSpec := filepath.Join(`C:\Windows`, "System32", "cmd.exe")
+	options.Env["ComSpec"] = comSpec
+	options.PathExists = fakePathExists(map[string]bool{comSpec: true})
+	resolved, err = resolveShellWithOptions("", options)
+	if err != nil || resolved.Name != "cmd" || resolved.Path != comSpec || len(resolved.Args) != 1 || resolved.Args[0] != "/Q" {
+		t.Fatalf("unexpected ComSpec shell: %+v, %v", resolved, err)
+	}
+}
+
+func TestShellNameForExecutable(t *testing.T) {
+	cases := map[string]string{
+		`%SystemRoot%\System32\WindowsPowerShell\v1.0\powershell.exe`: "powershell",
+		`C:\Program Files\Git\bin\bash.exe`:                           "gitbash",
+		`C:\msys64\usr\bin\bash.exe`:                                  shellMSYS2Bash,
+		`C:\Windows\System32\bash.exe`:                                "",
+		`nu`:                                                          shellNu,
+	}
+	for path, want := range cases {
+		if got := shellNameForExecutable(path); got != want {
+			t.Fatalf("shellNameForExecutable(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestParseShellOrder(t *testing.T) {
+	order, err := parseShellOrder(" gitbash, pwsh ,cmd")
+	if err != nil || strings.Join(order, ",") != "gitbash,pwsh,cmd" {
+		t.Fatalf("unexpected order: %q, %v", order, err)
+	}
+	for _, value := range []string{"pwsh,fish", "cmd,cmd", "pwsh,"} {
+		if _, err := parseShellOrder(value); err == nil {
+			t.Fatalf("expected %q to be rejected", value)
+		}
+	}
+
+	t.Setenv(shellOrderEnv, "cmd,pwsh")
+	cfg, err := parseRunFlags(nil, io.Discard)
+	if err != nil || strings.Join(cfg.ShellOrder, ",") != "cmd,pwsh" {
+		t.Fatalf("expected the order from %s, got %q, %v", shellOrderEnv, cfg.ShellOrder, err)
+	}
+}
+
+func TestSidecarListShellsFollowsShellOrder(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{
+		LookPath: fakeLookup(map[string]string{
+			"pwsh.exe": `C:\pwsh.exe`,
+			"cmd.exe":  `C:\Windows\System32\cmd.exe`,
+		}),
+		ShellOrder: []string{"cmd", "pwsh"},
+	})
+
+	ts.handleRequest(listShellsRequest{Type: requestTypeListShells, RequestID: "s1"})
+	evt := waitForEventOfType(t, ts, eventTypeShells)
+	if evt["requestId"] != "s1" || evt["default"] != "cmd" {
+		t.Fatalf("expected cmd as the default, got %+v", evt)
+	}
+
+	available := map[string]bool{}
+	for _, raw := range evt["shells"].([]any) {
+		shell := raw.(map[string]any)
+		available[shell["name"].(string)] = shell["available"].(bool)
+	}
+	if len(available) != len(shellSpecs) || !available["pwsh"] || !available["cmd"] || available["powershell"] {
+		t.Fatalf("unexpected shells: %+v", available)
+	}
+}
+
+func TestShellVersionCacheProbesOnce(t *testing.T) {
+	outputs := map[string]string{
+		`C:\pwsh.exe`: "PowerShell 7.5.0-preview.2\r\n",
+		`C:\cmd.exe`:  "\r\nMicrosoft Windows [Version 10.0.22631.3007]\r\n",
+		`C:\bash.exe`: "GNU bash, version 5.2.21(1)-release (x86_64-pc-msys)\nCopyright (C) 2022\n",
+	}
+	probes := 0
+	probe := func(path string, args ...string) (string, error) {
+		probes++
+		out, ok := outputs[path]
+		if !ok {
+			return "", errors.New("not found")
+		}
+		return out, nil
+	}
+
+	var cache shellVersionCache
+	cases := []struct {
+		shell resolvedShell
+		want  string
+	}{
+		{resolvedShell{Name: "pwsh", Path: `C:\pwsh.exe`}, "7.5.0-preview.2"},
+		{resolvedShell{Name: "cmd", Path: `C:\cmd.exe`}, "10.0.22631.3007"},
+		{resolvedShell{Name: "gitbash", Path: `C:\bash.exe`}, "5.2.21(1)-release"},
+		{resolvedShell{Name: "powershell", Path: `C:\missing.exe`}, ""},
+		{resolvedShell{Name: shellVSDevCmd, Path: `C:\cmd.exe`}, ""},
+	}
+	for round := 0; round < 2; round++ {
+		for _, tc := range cases {
+			if got := cache.Version(tc.shell, probe); got != tc.want {
+				t.Fatalf("%s: got version %q, want %q", tc.shell.Name, got, tc.want)
+			}
+		}
+	}
+	if probes != 4 {
+		t.Fatalf("expected each executable to be probed once, got %d probes", probes)
+	}
+}
+
+func TestSidecarReportsShellVersion(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{
+		ProbeShellVersion: func(path string, args ...string) (string, error) {
+			return "PowerShell 7.4.1", nil
+		},
+	})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, AttachIfExists: true})
+
+	ready := ts.eventsOfType(t, eventTypeReady)
+	if len(ready) != 2 || ready[0]["shellVersion"] != "7.4.1" || ready[1]["shellVersion"] != "7.4.1" {
+		t.Fatalf("expected the pwsh version in both ready events, got %+v", ready)
+	}
+}
+
+func TestResolveShellMergesLaunchOptions(t *testing.T) {
+	lookup := fakeLookup(map[string]string{
+		"pwsh.exe": `C:\pwsh.exe`,
+		"cmd.exe":  `C:\cmd.exe`,
+		"bash.exe": `C:\Git\bin\bash.exe`,
+	})
+	cases := []struct {
+		shell  string
+		launch shellLaunch
+		want   string
+	}{
+		{"pwsh", shellLaunch{NoProfile: true, ExecutionPolicy: "bypass", ExtraArgs: []string{"-MTA"}}, "-NoLogo -NoProfile -ExecutionPolicy Bypass -MTA"},
+		{"cmd", shellLaunch{NoProfile: true, ExtraArgs: []string{"/U"}}, "/Q /D /U"},
+		{"gitbash", shellLaunch{NoProfile: true}, "--noprofile --norc --login -i"},
+		{"", shellLaunch{NoProfile: true}, "-NoLogo -NoProfile"},
+	}
+	for _, tc := range cases {
+		resolved, err := resolveShellWithOptions(tc.shell, shellResolveOptions{LookPath: lookup, Launch: tc.launch})
+		if err != nil {
+			t.Fatalf("%s: resolveShellWithOptions failed: %v", tc.shell, err)
+		}
+		if got := strings.Join(resolved.Args, " "); got != tc.want {
+			t.Fatalf("%s: got args %q, want %q", tc.shell, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		shell  string
+		launch shellLaunch
+	}{
+		{"cmd", shellLaunch{ExecutionPolicy: "Bypass"}},
+		{"pwsh", shellLaunch{ExecutionPolicy: "Lenient"}},
+	} {
+		_, err := resolveShellWithOptions(tc.shell, shellResolveOptions{LookPath: lookup, Launch: tc.launch})
+		if serr := sidecarErrorFrom(err, ""); err == nil || serr.Code != errorCodeInvalidRequest {
+			t.Fatalf("%s %+v: expected invalid_request, got %v", tc.shell, tc.launch, err)
+		}
+	}
+}
+
+func TestResolveShellForcesUTF8(t *testing.T) {
+	lookup := fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`, "cmd.exe": `C:\cmd.exe`, "bash.exe": `C:\Git\bin\bash.exe`})
+	options := shellResolveOptions{LookPath: lookup, Launch: shellLaunch{UTF8: true, NoProfile: true}}
+
+	cmd, err := resolveShellWithOptions("cmd", options)
+	if err != nil || strings.Join(cmd.Args, " ") != "/Q /D /S /K" || cmd.Command != `"chcp 65001>nul"` {
+		t.Fatalf("unexpected cmd launch: %+v, %v", cmd, err)
+	}
+	pwsh, err := resolveShellWithOptions("pwsh", options)
+	if err != nil || strings.Join(pwsh.Args, " ") != "-NoLogo -NoProfile -NoExit -Command "+powerShellUTF8 {
+		t.Fatalf("unexpected pwsh launch: %+v, %v", pwsh, err)
+	}
+	bash, err := resolveShellWithOptions("gitbash", options)
+	if err != nil || bash.Command != "" || consoleCodePage("gitbash", true) == utf8CodePage {
+		t.Fatalf("bash should be left alone: %+v, %v", bash, err)
+	}
+}
+
 func fakeLookup(paths map[string]string) shellLookupFunc {
 	return func(file string) (string, error) {
 		path, ok := paths[file]
@@ -0,0 +1,1349 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errEmulationDisabled = newSidecarError(errorCodeInvalidRequest, "terminal emulation is not enabled")
+
+// sidecar owns the terminal registry and dispatches decoded requests.
+type sidecar struct {
+	cfg     runConfig
+	writer  *safeWriter
+	history *frameHistory
+
+	// probeMu guards the outcome of the last probe, see probeBackends.
+	probeMu            sync.Mutex
+	conPTYAvailable    bool
+	conPTYErrorMessage string
+	// winptyAvailable is set when winpty replaces a missing ConPTY.
+	winptyAvailable bool
+	// reprobe has the next open probe again after a failed probe, since
+	// antivirus scans or pending updates can fail one only for a while.
+	reprobe bool
+
+	startedAt time.Time
+	latency   latencyTracker
+	versions  shellVersionCache
+	loginEnvs loginEnvCache
+
+	mu        sync.Mutex
+	terminals map[string]*terminalEntry
+	// reserved holds the IDs of terminals being opened, see
+	// reserveTerminal.
+	reserved map[string]bool
+
+	// daemon is set while serving as a daemon, see serveDaemon.
+	daemon *daemonState
+	// client is what the connected client said in its hello request, nil
+	// until it sends one. Under --multi-client, clients tracks each client's
+	// instead.
+	client  atomic.Pointer[clientInfo]
+	clients *clientManager
+	// subscriptions are the connected client's; see eventSubscriptions.
+	subscriptions atomic.Pointer[eventSubscriptions]
+}
+
+type terminalEntry struct {
+	id      string
+	session terminalSession
+	screen  *screenEmulator
+	modes   *modeTracker
+	waiters *outputWaiters
+	writes  writeAssembler
+	input   *writeQueue
+	output  *outputPump
+	idle    *idleMonitor
+	startup *startupWatch
+	initial *initialCommand
+	resizer *resizeDebouncer
+	// span covers the terminal from open to exit.
+	span *otelSpan
+	// events keeps the stream events for resuming attaches; nil without
+	// --replay-events.
+	events *eventLog
+	// checkpoint, when set, saves the retained output as the terminal
+	// leaves the registry.
+	checkpoint func()
+
+	// exited is closed by the exit callback once exitCode is set.
+	exited   chan struct{}
+	exitCode int
+	// exitReason is set through setReason; opened dates an unexplained
+	// exit for classifyExit.
+	exitReason atomic.Pointer[string]
+	opened     time.Time
+
+	// display, cwd and tags describe the terminal in list events.
+	display string
+	cwd     string
+	tags    map[string]string
+	// backend, shellVersion and codePage are repeated in the ready event
+	// of an attach.
+	backend      string
+	shellVersion string
+	codePage     int
+	// request is the resolved open, which an upgrade hands to the new
+	// binary along with the session.
+	request openRequest
+}
+
+// setReason records why the terminal is exiting unless a reason was already
+// recorded.
+func (e *terminalEntry) setReason(reason string) {
+	e.exitReason.CompareAndSwap(nil, &reason)
+}
+
+func (e *terminalEntry) reason(code int) string {
+	if reason := e.exitReason.Load(); reason != nil {
+		return *reason
+	}
+	return classifyExit(code, time.Since(e.opened))
+}
+
+// release stops per-terminal workers once the entry leaves the registry and
+// checkpoints its output. A close followed by the shell's exit releases
+// twice; the second checkpoint adds whatever the shell printed last.
+func (e *terminalEntry) release(reason string) {
+	if e.checkpoint != nil {
+		e.checkpoint()
+	}
+	e.idle.Stop()
+	e.startup.Stop()
+	e.initial.Stop()
+	e.resizer.Stop()
+	e.input.Close()
+	e.waiters.Cancel(reason)
+}
+
+func newSidecar(cfg runConfig, stdout io.Writer) *sidecar {
+	history := &frameHistory{}
+	writer := &safeWriter{writer: stdout, trace: cfg.Trace, history: history}
+	switch cfg.Encoding {
+	case wireEncodingMsgpack:
+		writer.encode = writeMsgpackFrame
+	case wireEncodingJSONRPC:
+		writer.encode = writeJSONRPCFrame
+	case wireEncodingGRPC:
+		writer.encode = sendGRPCEvent
+	}
+
+	s := &sidecar{
+		cfg:             cfg,
+		writer:          writer,
+		history:         history,
+		conPTYAvailable: true,
+		startedAt:       time.Now(),
+		terminals:       map[string]*terminalEntry{},
+	}
+	s.subscriptions.Store(newEventSubscriptions())
+	return s
+}
+
+func (s *sidecar) emit(payload any) {
+	client := s.client.Load()
+	if !client.wants(payload) || !s.subscriptions.Load().Admits(payload) {
+		return
+	}
+	_ = s.writer.Emit(client.protocolShim(payload))
+}
+
+// currentClient describes the client whose request is being handled, nil
+// when it sent no hello request.
+func (s *sidecar) currentClient() *clientInfo {
+	if s.clients != nil {
+		return s.clients.currentInfo()
+	}
+	return s.client.Load()
+}
+
+// handleSubscription turns the categories in change on or off for the
+// client whose request it is.
+func (s *sidecar) handleSubscription(change subscriptionChange, subscribe bool) {
+	var subscriptions *eventSubscriptions
+	if s.clients != nil {
+		subscriptions = s.clients.currentSubscriptions()
+	} else {
+		subscriptions = s.subscriptions.Load()
+	}
+	if subscriptions == nil {
+		return
+	}
+	subscriptions.Change(change, subscribe)
+	s.emit(subscriptionsEvent{
+		Type:       eventTypeSubscriptions,
+		RequestID:  change.RequestID,
+		Categories: subscriptions.Snapshot(),
+	})
+}
+
+// handleClientHello records who the client is and which optional features
+// it negotiated, see clientInfo.wants.
+func (s *sidecar) handleClientHello(req clientHelloRequest) {
+	protocol, err := negotiateProtocol(req.Protocols)
+	if err != nil {
+		s.audit(auditRecord{Request: requestTypeHello}, err)
+		s.emitRequestFailure("", req.RequestID, err, errorCodeUnsupportedProtocol)
+		return
+	}
+	client := &clientInfo{
+		Name:     req.Name,
+		Version:  req.Version,
+		Protocol: protocol,
+		Features: negotiateClientFeatures(req.Features),
+	}
+	if s.clients != nil {
+		s.clients.identify(client)
+	} else {
+		s.client.Store(client)
+	}
+	s.audit(auditRecord{Request: requestTypeHello}, nil)
+	s.emit(helloAckEvent{
+		Type:      eventTypeHelloAck,
+		RequestID: req.RequestID,
+		Protocol:  client.Protocol,
+		Features:  client.Features,
+	})
+}
+
+func (s *sidecar) emitError(terminalID string, code string, message string) {
+	s.emit(errorEvent{
+		Type:       eventTypeError,
+		TerminalID: terminalID,
+		Code:       code,
+		Message:    message,
+	})
+}
+
+func (s *sidecar) emitFailure(terminalID string, err error, fallbackCode string) {
+	serr := sidecarErrorFrom(err, fallbackCode)
+	s.emitError(terminalID, serr.Code, serr.Message)
+}
+
+// emitRequestFailure reports an error correlated with a client requestId.
+func (s *sidecar) emitRequestFailure(terminalID string, requestID string, err error, fallbackCode string) {
+	serr := sidecarErrorFrom(err, fallbackCode)
+	s.emit(errorEvent{
+		Type:       eventTypeError,
+		TerminalID: terminalID,
+		RequestID:  requestID,
+		Code:       serr.Code,
+		Message:    serr.Message,
+	})
+}
+
+// replyTo returns how to answer the request being handled from a task that
+// outlives its handler. With concurrent clients the answer is addressed to
+// the requesting client, as the main loop has moved on to others by then.
+func (s *sidecar) replyTo() func(payload any) {
+	if s.clients == nil {
+		return s.emit
+	}
+	client := s.clients.requester()
+	return func(payload any) { s.clients.sendTo(client, payload) }
+}
+
+func (s *sidecar) runIsolated(terminalID string, task func()) {
+	runIsolatedTerminalTask(terminalID, s.emitError, func() {
+		defer s.reportTerminalPanic(terminalID)
+		task()
+	})
+}
+
+func (s *sidecar) lookupTerminal(terminalID string) (*terminalEntry, bool) {
+	s.mu.Lock()
+	entry, exists := s.terminals[terminalID]
+	s.mu.Unlock()
+	if !exists {
+		s.emitError(terminalID, errorCodeTerminalNotFound, "terminal not found")
+	}
+	return entry, exists
+}
+
+// removeTerminal drops entry from the registry unless the id was reused, and
+// reports whether it was still registered.
+func (s *sidecar) removeTerminal(entry *terminalEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.terminals[entry.id] != entry {
+		return false
+	}
+	delete(s.terminals, entry.id)
+	return true
+}
+
+// takeAllTerminals empties the registry and returns its entries by id.
+func (s *sidecar) takeAllTerminals() []*terminalEntry {
+	return s.takeTerminals(func(*terminalEntry) bool { return true })
+}
+
+// takeTerminals removes the entries matching match from the registry and
+// returns them ordered by terminal id.
+func (s *sidecar) takeTerminals(match func(*terminalEntry) bool) []*terminalEntry {
+	s.mu.Lock()
+	entries := make([]*terminalEntry, 0, len(s.terminals))
+	for terminalID, entry := range s.terminals {
+		if !match(entry) {
+			continue
+		}
+		delete(s.terminals, terminalID)
+		entries = append(entries, entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+	return entries
+}
+
+func (s *sidecar) closeAllTerminals() {
+	for _, entry := range s.takeAllTerminals() {
+		entry.release("terminal closed before pattern matched")
+		_ = entry.session.Close()
+	}
+}
+
+// handleRequest processes one request and reports whether the sidecar should
+// exit, and with which code.
+func (s *sidecar) handleRequest(req request) (int, bool) {
+	switch typed := req.(type) {
+	case openRequest:
+		s.handleOpen(typed)
+	case writeRequest:
+		span := s.cfg.Tracer.StartRequest("terminal.write", typed.Traceparent, typed.TerminalID)
+		span.SetAttribute("write.bytes", len(typed.Data))
+		s.handleWrite(typed)
+		span.End(nil)
+	case resizeRequest:
+		span := s.cfg.Tracer.StartRequest("terminal.resize", typed.Traceparent, typed.TerminalID)
+		span.SetAttribute("terminal.cols", typed.Cols)
+		span.SetAttribute("terminal.rows", typed.Rows)
+		s.handleResize(typed)
+		span.End(nil)
+	case closeRequest:
+		span := s.cfg.Tracer.StartRequest("terminal.close", typed.Traceparent, typed.TerminalID)
+		span.SetAttribute("close.force", typed.Force)
+		s.handleClose(typed)
+		span.End(nil)
+	case snapshotRequest:
+		s.handleSnapshot(typed)
+	case waitRequest:
+		s.handleWait(typed)
+	case searchRequest:
+		s.handleSearch(typed)
+	case exportRequest:
+		s.handleExport(typed)
+	case statsRequest:
+		s.handleStats()
+	case broadcastRequest:
+		s.handleBroadcast(typed)
+	case closeAllRequest:
+		s.handleCloseAll(typed)
+	case listRequest:
+		s.handleList(typed)
+	case listShellsRequest:
+		s.handleListShells(typed)
+	case probeRequest:
+		s.handleProbe(typed)
+	case tmuxPanesRequest:
+		s.handleTmuxPanes(typed)
+	case getSizeRequest:
+		s.handleGetSize(typed)
+	case focusRequest:
+		s.handleFocus(typed)
+	case eofRequest:
+		s.handleEOF(typed)
+	case killProcessRequest:
+		s.handleKillProcess(typed)
+	case pingRequest:
+		s.handlePing(typed)
+	case upgradeRequest:
+		return s.handleUpgrade(typed)
+	case clientHelloRequest:
+		s.handleClientHello(typed)
+	case subscribeRequest:
+		s.handleSubscription(typed.subscriptionChange, true)
+	case unsubscribeRequest:
+		s.handleSubscription(typed.subscriptionChange, false)
+	case shutdownRequest:
+		s.handleShutdown(typed)
+		return 0, true
+	}
+	return 0, false
+}
+
+func (s *sidecar) handleOpen(req openRequest) {
+	if req.TerminalID == "" {
+		id, err := s.newTerminalID()
+		if err != nil {
+			s.emitRequestFailure("", req.RequestID, err, errorCodeStartupFailed)
+			return
+		}
+		req.TerminalID = id
+	}
+	req = s.currentClient().openDefaults(req)
+	req.span = s.cfg.Tracer.StartRequest("terminal.open", req.Traceparent, req.TerminalID)
+	req.span.SetAttribute("terminal.shell", req.Shell)
+	err := s.openTerminal(req)
+	req.span.End(err)
+	if err != nil {
+		s.emitRequestFailure(req.TerminalID, req.RequestID, err, errorCodeStartupFailed)
+	}
+	s.audit(auditRecord{
+		Request:    requestTypeOpen,
+		TerminalID: req.TerminalID,
+		Shell:      req.Shell,
+		Cwd:        req.Cwd,
+	}, err)
+}
+
+// resolveBackend picks the terminal factory for req, the shell it runs and
+// the name of the backend the ready event reports.
+func (s *sidecar) resolveBackend(req openRequest) (resolvedShell, terminalFactory, string, error) {
+	switch req.Backend {
+	case "", backendConPTY, backendWinpty, backendPipes, backendContainerd:
+	case backendMock:
+		if !s.cfg.MockBackend {
+			return resolvedShell{}, nil, "", newSidecarError(errorCodeInvalidRequest, "mock backend is not enabled (start with --mock-backend)")
+		}
+		return resolvedShell{Name: backendMock}, newMockTerminalSession, backendMock, nil
+	case backendSerial:
+		if req.Serial == nil || req.Serial.Pipe == "" {
+			return resolvedShell{}, nil, "", newSidecarError(errorCodeInvalidRequest, "serial backend requires serial.pipe")
+		}
+		return resolvedShell{Name: backendSerial, Path: serialPipeName(req.Serial.Pipe)}, newSerialTerminalSession, backendSerial, nil
+	case backendTelnet:
+		address, err := telnetAddress(req.Telnet)
+		if err != nil {
+			return resolvedShell{}, nil, "", err
+		}
+		return resolvedShell{Name: backendTelnet, Path: address}, newTelnetTerminalSession, backendTelnet, nil
+	case backendTmux:
+		shell, err := tmuxShell(req.Tmux, s.cfg.LookPath)
+		if err != nil {
+			return resolvedShell{}, nil, "", err
+		}
+		if err := s.cfg.ExecPolicy.Check(shell.Path); err != nil {
+			return resolvedShell{}, nil, "", err
+		}
+		return shell, newTmuxTerminalSession, backendTmux, nil
+	default:
+		return resolvedShell{}, nil, "", newSidecarError(errorCodeInvalidRequest, "unknown backend %q", req.Backend)
+	}
+
+	s.probeMu.Lock()
+	reprobe := s.reprobe && req.Backend != backendPipes
+	if reprobe {
+		s.reprobe = false
+	}
+	s.probeMu.Unlock()
+	if reprobe {
+		s.probeBackends(false)
+	}
+
+	s.probeMu.Lock()
+	conPTYAvailable, conPTYErrorMessage, winptyAvailable := s.conPTYAvailable, s.conPTYErrorMessage, s.winptyAvailable
+	s.probeMu.Unlock()
+
+	opener, backend := s.cfg.TerminalOpener, backendConPTY
+	switch {
+	case req.Backend == backendPipes:
+		// Pipes need no console, so they work where ConPTY does not.
+		opener, backend = newPipesTerminalSession, backendPipes
+	case winptyAvailable && req.Backend != backendConPTY:
+		opener, backend = s.cfg.WinptyOpener, backendWinpty
+	case req.Backend == backendWinpty:
+		return resolvedShell{}, nil, "", newSidecarError(errorCodeInvalidRequest, "winpty backend is only used where ConPTY is missing")
+	case !conPTYAvailable:
+		return resolvedShell{}, nil, "", newSidecarError(errorCodeConPTYUnavailable, "%s", conPTYErrorMessage)
+	}
+
+	var shell resolvedShell
+	var err error
+	if req.Backend == backendContainerd {
+		// ctr wants a console for --tty and forwards its resizes.
+		shell, err = containerdShell(req, s.cfg.LookPath)
+		backend = backendContainerd
+	} else {
+		options := s.shellOptions()
+		options.VisualStudio = req.VisualStudio
+		options.Launch = shellLaunch{
+			ExtraArgs:       req.ExtraArgs,
+			NoProfile:       req.NoProfile,
+			ExecutionPolicy: req.ExecutionPolicy,
+			UTF8:            req.ForceUTF8,
+		}
+		if shell, err = resolveShellWithOptions(req.Shell, options); err != nil {
+			err = sidecarErrorFrom(err, errorCodeShellNotFound)
+		}
+	}
+	if err != nil {
+		return resolvedShell{}, nil, "", err
+	}
+	if err := s.cfg.ExecPolicy.Check(shell.Path); err != nil {
+		return resolvedShell{}, nil, "", err
+	}
+	return shell, opener, backend, nil
+}
+
+// newTerminalID returns a ULID no open terminal uses.
+func (s *sidecar) newTerminalID() (string, error) {
+	for {
+		id, err := newULID(time.Now(), rand.Reader)
+		if err != nil {
+			return "", newSidecarError(errorCodeStartupFailed, "failed to generate terminal id: %v", err)
+		}
+		s.mu.Lock()
+		_, taken := s.terminals[id]
+		taken = taken || s.reserved[id]
+		s.mu.Unlock()
+		if !taken {
+			return id, nil
+		}
+	}
+}
+
+// openTerminal starts a terminal and emits ready, or returns why it failed.
+func (s *sidecar) openTerminal(req openRequest) error {
+	existing, err := s.reserveTerminal(req.TerminalID, req.adopted == nil)
+	if err != nil {
+		return err
+	}
+	if existing != nil && req.AttachIfExists {
+		s.attachTerminal(existing, req)
+		return nil
+	}
+	if existing != nil {
+		return newSidecarError(errorCodeStartupFailed, "terminal already exists")
+	}
+	defer s.unreserveTerminal(req.TerminalID)
+	if adopted := req.adopted; adopted != nil {
+		return s.startTerminal(req, resolvedShell{Name: adopted.Display}, adopted.opener, adopted.Backend, adopted.Cwd)
+	}
+
+	shell, opener, backend, err := s.resolveBackend(req)
+	if err != nil {
+		return err
+	}
+
+	if len(req.EnvProfiles) > 0 {
+		// References expand against what the terminal may inherit, so a
+		// profile cannot read variables the env policy withholds.
+		base := childEnvironment(openRequest{InheritEnv: req.InheritEnv}, s.cfg.EnvPolicy, os.Environ())
+		env, err := s.cfg.EnvProfiles.Resolve(req.EnvProfiles, req.Env, base)
+		if err != nil {
+			return err
+		}
+		req.Env = env
+	}
+	req.environ = withShellDefaults(childEnvironment(req, s.cfg.EnvPolicy, os.Environ()), shell.Env, req.Env)
+	if req.LoginEnv {
+		if err := s.mergeLoginEnv(&req, shell); err != nil {
+			return err
+		}
+	}
+	home, _ := os.UserHomeDir()
+	cwd, err := resolveCwd(req, home)
+	if err != nil {
+		return sidecarErrorFrom(err, errorCodeCwdNotFound)
+	}
+	req.Cwd = cwd
+	if err := activatePython(&req, shell.Name, home); err != nil {
+		return err
+	}
+	// Report the cwd the shell ends up in even when pushd gets it there.
+	displayCwd := plainPath(req.Cwd)
+	if req.Cwd, shell, err = shellCwd(req.Cwd, shell, req.UNCCwd, home); err != nil {
+		return err
+	}
+	return s.startTerminal(req, shell, opener, backend, displayCwd)
+}
+
+// reserveTerminal claims id for a terminal being opened and, when limited,
+// a place under the terminal limit, so that opens racing for either fail
+// before a backend starts. It returns the terminal already using id
+// instead. The claim lasts until startTerminal registers the terminal or
+// unreserveTerminal gives it up.
+func (s *sidecar) reserveTerminal(id string, limited bool) (*terminalEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing := s.terminals[id]; existing != nil {
+		return existing, nil
+	}
+	if s.reserved[id] {
+		return nil, newSidecarError(errorCodeStartupFailed, "terminal already exists")
+	}
+	if limited && s.cfg.MaxTerminals > 0 && len(s.terminals)+len(s.reserved) >= s.cfg.MaxTerminals {
+		return nil, newSidecarError(errorCodeTerminalLimit, "terminal limit of %d reached", s.cfg.MaxTerminals)
+	}
+	if s.reserved == nil {
+		s.reserved = make(map[string]bool)
+	}
+	s.reserved[id] = true
+	return nil, nil
+}
+
+func (s *sidecar) unreserveTerminal(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reserved, id)
+}
+
+// startTerminal validates the options of a resolved open and starts its
+// terminal with opener, or adopts the session an upgrade handed over.
+func (s *sidecar) startTerminal(req openRequest, shell resolvedShell, opener terminalFactory, backend string, displayCwd string) error {
+	if err := validatePrivilege(req); err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+	if err := validateWindowOptions(req); err != nil {
+		return err
+	}
+	if err := validateResourceLimits(req); err != nil {
+		return err
+	}
+	if req.Checkpoint && !req.Emulate {
+		return newSidecarError(errorCodeInvalidRequest, "checkpoint requires emulate")
+	}
+	if req.Checkpoint && s.cfg.CheckpointDir == "" {
+		return newSidecarError(errorCodeInvalidRequest, "checkpoint requires the sidecar to run with --checkpoint-dir")
+	}
+
+	if req.ReadBufferBytes == 0 {
+		req.ReadBufferBytes = s.cfg.ReadBufferBytes
+	}
+	if err := validateReadBufferBytes(req.ReadBufferBytes); err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+
+	if _, err := newOutputTranscoder(req.OutputCodePage); err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+
+	encoder, err := newOutputEncoder(req)
+	if err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+	filter, err := newOutputFilter(req.OutputFilter)
+	if err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+
+	if req.Backpressure == "" {
+		req.Backpressure = s.cfg.Backpressure
+	}
+	events := newEventLog(s.cfg.ReplayEvents)
+	emitStream := func(payload any) {
+		if events == nil {
+			s.emit(payload)
+			return
+		}
+		events.Emit(payload, s.emit)
+	}
+	output, err := newOutputPump(req, emitStream)
+	if err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+
+	entry := &terminalEntry{
+		id:      req.TerminalID,
+		span:    req.span.StartChild("terminal.session"),
+		events:  events,
+		waiters: &outputWaiters{terminalID: req.TerminalID, emit: s.emit},
+		output:  output,
+		exited:  make(chan struct{}),
+		opened:  time.Now(),
+		display: shell.Name,
+		backend: backend,
+		cwd:     displayCwd,
+		tags:    req.Tags,
+		request: req,
+	}
+	if adopted := req.adopted; adopted != nil {
+		entry.opened = adopted.Opened
+		entry.shellVersion = adopted.ShellVersion
+		entry.codePage = adopted.CodePage
+	} else {
+		entry.shellVersion = s.versions.Version(shell, s.cfg.ProbeShellVersion)
+		entry.codePage = consoleCodePage(shell.Name, req.ForceUTF8)
+	}
+	if req.Emulate {
+		entry.screen = newScreenEmulator(req.Cols, req.Rows, req.ScrollbackLines)
+		if req.adopted != nil {
+			entry.screen.RestoreScrollback(req.adopted.Lines)
+		}
+	}
+	var restored *scrollbackCheckpoint
+	var restoreErr error
+	if req.Checkpoint {
+		if restored, restoreErr = loadScrollbackCheckpoint(s.cfg.CheckpointDir, entry.id); restored != nil {
+			entry.screen.RestoreScrollback(restored.Lines)
+		}
+		entry.checkpoint = func() {
+			if err := saveScrollbackCheckpoint(s.cfg.CheckpointDir, newScrollbackCheckpoint(entry)); err != nil {
+				s.emitError(entry.id, errorCodeCheckpointFailed, fmt.Sprintf("failed to save checkpoint: %v", err))
+			}
+		}
+	}
+	entry.modes = newModeTracker(func(alternate bool) {
+		output.Send(screenModeEvent{Type: eventTypeScreenMode, TerminalID: entry.id, Mode: screenModeName(alternate)})
+	})
+	cols, rows := clampTerminalSize(req.Cols, req.Rows)
+	entry.resizer = newResizeDebouncer(s.cfg.ResizeDebounce, cols, rows, func(cols int, rows int) {
+		s.applyResize(entry, cols, rows)
+	})
+	entry.idle, err = newIdleMonitor(req, func(closeIn time.Duration) {
+		emitStream(willCloseEvent{
+			Type:       eventTypeWillClose,
+			TerminalID: entry.id,
+			Reason:     closeReasonIdle,
+			CloseInMs:  closeIn.Milliseconds(),
+		})
+	}, func() {
+		if !s.removeTerminal(entry) {
+			return
+		}
+		entry.setReason(exitReasonIdleTimeout)
+		result := s.closeEntry(entry, idleCloseGrace)
+		result.Reason = closeReasonIdle
+		emitStream(closedEvent{Type: eventTypeClosed, terminalCloseResult: result})
+	})
+	if err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+	entry.startup, err = newStartupWatch(req, func() {
+		if !s.removeTerminal(entry) {
+			return
+		}
+		entry.release("terminal failed to start before pattern matched")
+		entry.setReason(exitReasonClosed)
+		_ = entry.session.Close()
+		s.emitError(entry.id, errorCodeStartupTimeout, fmt.Sprintf("shell produced no output within %dms", req.StartupTimeoutMs))
+	})
+	if err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+	entry.initial, err = newInitialCommand(req, func(command string) {
+		if err := entry.input.Enqueue(command); err != nil {
+			s.emitFailure(entry.id, err, errorCodeWriteBacklogged)
+		}
+	})
+	if err != nil {
+		return sidecarErrorFrom(err, errorCodeInvalidRequest)
+	}
+	s.runIsolated(entry.id, output.Run)
+
+	inspector := newVTInspector(req, output.Send)
+	callbacks := terminalCallbacks{
+		Output: func(chunk []byte) {
+			if entry.screen != nil {
+				entry.screen.Write(chunk)
+			}
+			entry.modes.Feed(chunk)
+			entry.idle.Touch()
+			entry.startup.Output()
+			entry.initial.Output()
+			entry.waiters.Feed(chunk)
+			if chunk = filter.Apply(inspector.Feed(chunk)); len(chunk) > 0 {
+				output.Push(encoder.Event(chunk))
+			}
+		},
+		ExitReason: entry.setReason,
+		Exit: func(code int) {
+			rest := append(filter.Apply(inspector.Flush()), filter.Flush()...)
+			if len(rest) > 0 {
+				output.Push(encoder.Event(rest))
+			}
+			if pending, ok := encoder.Flush(); ok {
+				output.Push(pending)
+			}
+			output.Close()
+			if entry.startup.Exited() {
+				s.emitError(entry.id, errorCodeStartupFailed, fmt.Sprintf("shell exited with code %d before producing output", code))
+			}
+			s.removeTerminal(entry)
+			entry.release("terminal exited before pattern matched")
+			emitStream(exitEvent{
+				Type:       eventTypeExit,
+				TerminalID: entry.id,
+				Code:       code,
+				Reason:     entry.reason(code),
+				Status:     ntStatus(code),
+			})
+			entry.exitCode = code
+			close(entry.exited)
+			entry.span.SetAttribute("exit.code", code)
+			entry.span.End(nil)
+		},
+	}
+
+	session, err := s.cfg.Chaos.Wrap(opener)(req, shell, callbacks, s.runIsolated)
+	if err != nil {
+		output.Close()
+		return sidecarErrorFrom(err, errorCodeStartupFailed)
+	}
+	entry.session = session
+	entry.input = newWriteQueue(session, func(err error) {
+		s.emitFailure(entry.id, err, errorCodeStartupFailed)
+	})
+	s.runIsolated(entry.id, entry.input.Run)
+
+	s.mu.Lock()
+	s.terminals[entry.id] = entry
+	delete(s.reserved, entry.id)
+	s.mu.Unlock()
+	if req.Checkpoint {
+		// The restored lines are in the next checkpoint; until the open
+		// succeeded the file was all there was.
+		_ = os.Remove(checkpointPath(s.cfg.CheckpointDir, entry.id))
+	}
+	entry.idle.Start()
+	entry.startup.Start()
+	entry.initial.Start()
+
+	ready := readyEvent{
+		Type:         eventTypeReady,
+		TerminalID:   entry.id,
+		RequestID:    req.RequestID,
+		Display:      shell.Name,
+		Cwd:          entry.cwd,
+		Backend:      entry.backend,
+		ShellVersion: entry.shellVersion,
+		CodePage:     entry.codePage,
+	}
+	if restored != nil {
+		ready.RestoredFrom = restored.SavedAt.Format(time.RFC3339Nano)
+	}
+	s.emit(ready)
+	if restoreErr != nil {
+		s.emitError(entry.id, errorCodeCheckpointFailed, fmt.Sprintf("failed to restore checkpoint: %v", restoreErr))
+	}
+	if restored != nil {
+		s.emit(entry.screen.Snapshot(entry.id, true))
+	}
+	return nil
+}
+
+// attachTerminal answers an open for a running terminal: it reports ready
+// again and replays the stream events from req.ResumeFromSeq on or, when
+// they are gone and the terminal is emulated, screen and scrollback.
+func (s *sidecar) attachTerminal(entry *terminalEntry, req openRequest) {
+	attached := func(last uint64, missed []any, resumed bool) {
+		s.emit(readyEvent{
+			Type:         eventTypeReady,
+			TerminalID:   entry.id,
+			RequestID:    req.RequestID,
+			Display:      entry.display,
+			Cwd:          entry.cwd,
+			Attached:     true,
+			Backend:      entry.backend,
+			ShellVersion: entry.shellVersion,
+			CodePage:     entry.codePage,
+			Seq:          last,
+			Resumed:      resumed,
+		})
+		for _, payload := range missed {
+			s.emitReplay(payload)
+		}
+		if !resumed && entry.screen != nil {
+			s.emit(entry.screen.Snapshot(entry.id, true))
+		}
+	}
+	if entry.events == nil {
+		attached(0, nil, false)
+		return
+	}
+	entry.events.Attach(req.ResumeFromSeq, attached)
+}
+
+// emitReplay sends a replayed stream event to the attaching client only.
+func (s *sidecar) emitReplay(payload any) {
+	if s.clients != nil {
+		s.clients.sendTo(s.clients.requester(), payload)
+		return
+	}
+	s.emit(payload)
+}
+
+// handleShutdown closes every terminal, waiting up to graceMs for shells to
+// exit on their own before terminating them.
+func (s *sidecar) handleShutdown(req shutdownRequest) {
+	grace, err := closeGracePeriod(req.GraceMs)
+	if err != nil {
+		s.emitFailure("", err, errorCodeInvalidRequest)
+		grace = 0
+	}
+	s.audit(auditRecord{Request: requestTypeShutdown}, nil)
+
+	if grace == 0 {
+		s.closeAllTerminals()
+		s.emit(shutdownAckEvent{Type: eventTypeShutdownAck})
+		return
+	}
+
+	s.emit(shutdownAckEvent{
+		Type:      eventTypeShutdownAck,
+		Terminals: s.shutdownGracefully(grace),
+	})
+}
+
+func (s *sidecar) handleWrite(req writeRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	entry.idle.Touch()
+	data, ready, err := entry.writes.Add(req)
+	if err != nil {
+		s.emitFailure(req.TerminalID, err, errorCodeInvalidRequest)
+		return
+	}
+	if !ready {
+		return
+	}
+
+	if err := entry.input.Enqueue(data); err != nil {
+		s.emitFailure(req.TerminalID, err, errorCodeWriteBacklogged)
+	}
+}
+
+// handleBroadcast resolves every target and checks every input queue before
+// queuing anything, so a missing, closing or backlogged terminal fails the
+// whole broadcast and no target receives a partial command.
+func (s *sidecar) handleBroadcast(req broadcastRequest) {
+	data, err := decodeWriteData(writeRequest{
+		Data:     req.Data,
+		Encoding: req.Encoding,
+		Keys:     req.Keys,
+		Sanitize: req.Sanitize,
+	})
+	if err != nil {
+		s.emitRequestFailure("", req.RequestID, err, errorCodeInvalidRequest)
+		return
+	}
+	if len(req.TerminalIDs) == 0 && len(req.Selector) == 0 {
+		s.emitRequestFailure("", req.RequestID, newSidecarError(errorCodeInvalidRequest, "broadcast needs terminalIds or a selector"), errorCodeInvalidRequest)
+		return
+	}
+
+	entries := make([]*terminalEntry, 0, len(req.TerminalIDs))
+	seen := make(map[string]bool, len(req.TerminalIDs))
+	var missing []string
+	s.mu.Lock()
+	for _, id := range req.TerminalIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if entry, ok := s.terminals[id]; ok {
+			entries = append(entries, entry)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(req.Selector) > 0 {
+		for id, entry := range s.terminals {
+			if !seen[id] && matchTags(entry.tags, req.Selector) {
+				seen[id] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+	s.mu.Unlock()
+	if len(missing) > 0 {
+		err := newSidecarError(errorCodeTerminalNotFound, "broadcast targets not found: %s", strings.Join(missing, ", "))
+		s.emitRequestFailure("", req.RequestID, err, errorCodeTerminalNotFound)
+		return
+	}
+
+	if len(entries) == 0 {
+		s.emitRequestFailure("", req.RequestID, newSidecarError(errorCodeTerminalNotFound, "no terminal matches the broadcast selector"), errorCodeTerminalNotFound)
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+	queues := make([]*writeQueue, len(entries))
+	for idx, entry := range entries {
+		queues[idx] = entry.input
+	}
+	if idx, err := enqueueAll(queues, data); err != nil {
+		s.emitRequestFailure(entries[idx].id, req.RequestID, err, errorCodeWriteBacklogged)
+		return
+	}
+	for _, entry := range entries {
+		entry.idle.Touch()
+	}
+}
+
+// handleList reports the terminals matching the selector, ordered by id.
+func (s *sidecar) handleList(req listRequest) {
+	terminals := make([]terminalInfo, 0)
+	s.mu.Lock()
+	for id, entry := range s.terminals {
+		if !matchTags(entry.tags, req.Selector) {
+			continue
+		}
+		terminals = append(terminals, terminalInfo{
+			TerminalID: id,
+			Display:    entry.display,
+			Cwd:        entry.cwd,
+			Tags:       entry.tags,
+			UptimeMs:   time.Since(entry.opened).Milliseconds(),
+			ScreenMode: screenModeName(entry.modes.AlternateScreen()),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(terminals, func(i, j int) bool { return terminals[i].TerminalID < terminals[j].TerminalID })
+	s.emit(listEvent{Type: eventTypeList, RequestID: req.RequestID, Terminals: terminals})
+}
+
+// mergeLoginEnv adds the login environment of shell, when it is a bash, or
+// else of Git Bash to req, keeping variables the request sets itself.
+func (s *sidecar) mergeLoginEnv(req *openRequest, shell resolvedShell) error {
+	bash := shell
+	switch shell.Name {
+	case "gitbash", shellCygwinBash, shellMSYS2Bash:
+	default:
+		var err error
+		if bash, err = resolveShellWithOptions("gitbash", s.shellOptions()); err != nil {
+			return sidecarErrorFrom(err, errorCodeShellNotFound)
+		}
+	}
+
+	captured, err := s.loginEnvs.Capture(bash.Path, req.environ, s.cfg.CaptureLoginEnv)
+	if err != nil {
+		return newSidecarError(errorCodeStartupFailed, "failed to capture the login environment of %s: %v", bash.Path, err)
+	}
+	req.environ = withShellDefaults(req.environ, captured, req.Env)
+	return nil
+}
+
+func (s *sidecar) shellOptions() shellResolveOptions {
+	return shellResolveOptions{
+		LookPath:     s.cfg.LookPath,
+		VSWhere:      s.cfg.VSWhere,
+		DefaultShell: s.cfg.DefaultShell,
+		Order:        s.cfg.ShellOrder,
+		TermProgram:  s.cfg.TermProgram,
+	}
+}
+
+// handleListShells resolves every supported shell and the default. That
+// searches the disk and may run vswhere, so it runs off the request loop.
+func (s *sidecar) handleListShells(req listShellsRequest) {
+	reply := s.replyTo()
+	s.runIsolated("", func() {
+		options := s.shellOptions()
+		order := options.Order
+		if len(order) == 0 {
+			order = shellOrder
+		}
+
+		shells := make([]shellInfo, 0, len(shellSpecs))
+		for _, name := range shellNames() {
+			info := shellInfo{Name: name}
+			if shell, err := resolveShellWithOptions(name, options); err == nil {
+				info.Path = shell.Path
+				info.Available = s.cfg.ExecPolicy.Check(shell.Path) == nil
+				if info.Available {
+					info.Version = s.versions.Version(shell, s.cfg.ProbeShellVersion)
+				}
+			}
+			shells = append(shells, info)
+		}
+
+		evt := shellsEvent{Type: eventTypeShells, RequestID: req.RequestID, Order: order, Shells: shells}
+		if shell, err := resolveShellWithOptions("", options); err == nil && s.cfg.ExecPolicy.Check(shell.Path) == nil {
+			evt.Default = shell.Name
+		}
+		reply(evt)
+	})
+}
+
+// probeBackends probes ConPTY, and winpty while ConPTY is missing, for the
+// opens that follow. A failure arms one re-probe on the next open when arm
+// is set.
+func (s *sidecar) probeBackends(arm bool) probeEvent {
+	probe, probeFallback := s.cfg.ProbeConPTY, s.cfg.ProbeWinpty
+	if probe == nil {
+		probe = probeConPTY
+	}
+	evt := probeEvent{Type: eventTypeProbe}
+	err := probe()
+	if err == nil {
+		evt.ConPTY, evt.Backend = true, backendConPTY
+	} else {
+		evt.Error = err.Error()
+		if probeFallback != nil && probeFallback() == nil {
+			evt.Winpty, evt.Backend = true, backendWinpty
+		}
+	}
+
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	s.conPTYAvailable = evt.ConPTY
+	s.conPTYErrorMessage = evt.Error
+	s.winptyAvailable = evt.Winpty
+	s.reprobe = arm && !evt.ConPTY
+	return evt
+}
+
+// handleProbe probes off the request loop, since creating a pseudo console
+// can stall while antivirus inspects it.
+func (s *sidecar) handleProbe(req probeRequest) {
+	reply := s.replyTo()
+	s.runIsolated("", func() {
+		evt := s.probeBackends(true)
+		evt.RequestID = req.RequestID
+		reply(evt)
+	})
+}
+
+// handleTmuxPanes lists a tmux session's panes off the request loop, as
+// tmux may be reached over ssh.
+func (s *sidecar) handleTmuxPanes(req tmuxPanesRequest) {
+	reply := s.replyTo()
+	s.runIsolated("", func() {
+		panes, err := listTmuxPanes(req.Tmux, s.cfg.LookPath, s.cfg.RunTmux)
+		if err != nil {
+			serr := sidecarErrorFrom(err, errorCodeStartupFailed)
+			reply(errorEvent{Type: eventTypeError, RequestID: req.RequestID, Code: serr.Code, Message: serr.Message})
+			return
+		}
+		reply(tmuxPanesEvent{Type: eventTypeTmuxPanes, RequestID: req.RequestID, Session: req.Tmux.Session, Panes: panes})
+	})
+}
+
+// handleEOF queues the EOF sequence behind earlier writes so a program
+// receives all of its input first.
+func (s *sidecar) handleEOF(req eofRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	data, err := eofSequence(req.Sequence)
+	if err != nil {
+		s.emitFailure(req.TerminalID, err, errorCodeInvalidRequest)
+		return
+	}
+	entry.idle.Touch()
+	if err := entry.input.Enqueue(data); err != nil {
+		s.emitFailure(req.TerminalID, err, errorCodeWriteBacklogged)
+	}
+}
+
+func (s *sidecar) handleResize(req resizeRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	cols, rows := clampTerminalSize(req.Cols, req.Rows)
+	entry.resizer.Resize(cols, rows)
+	if req.RequestID != "" {
+		s.emit(sizeEvent{Type: eventTypeSize, TerminalID: req.TerminalID, RequestID: req.RequestID, Cols: cols, Rows: rows})
+	}
+}
+
+func (s *sidecar) handleFocus(req focusRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok || !entry.modes.Enabled(focusReportingMode) {
+		return
+	}
+
+	sequence := "\x1b[O"
+	if req.Focused {
+		sequence = "\x1b[I"
+	}
+	if err := entry.input.Enqueue(sequence); err != nil {
+		s.emitFailure(req.TerminalID, err, errorCodeWriteBacklogged)
+	}
+}
+
+func (s *sidecar) handleGetSize(req getSizeRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	cols, rows := entry.resizer.Size()
+	s.emit(sizeEvent{Type: eventTypeSize, TerminalID: req.TerminalID, RequestID: req.RequestID, Cols: cols, Rows: rows})
+}
+
+func (s *sidecar) applyResize(entry *terminalEntry, cols int, rows int) {
+	if err := entry.session.Resize(cols, rows); err != nil {
+		s.emitFailure(entry.id, err, errorCodeStartupFailed)
+		return
+	}
+	if entry.screen != nil {
+		entry.screen.Resize(cols, rows)
+	}
+}
+
+func (s *sidecar) handleKillProcess(req killProcessRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	err := killTerminalProcess(entry.session, req.PID)
+	if err != nil {
+		s.emitRequestFailure(req.TerminalID, req.RequestID, err, errorCodeKillFailed)
+	}
+	s.audit(auditRecord{
+		Request:    requestTypeKillProcess,
+		TerminalID: req.TerminalID,
+		Message:    fmt.Sprintf("pid %d", req.PID),
+	}, err)
+}
+
+func (s *sidecar) handleClose(req closeRequest) {
+	rec := auditRecord{Request: requestTypeClose, TerminalID: req.TerminalID}
+	grace, err := closeGracePeriod(req.GraceMs)
+	if err != nil {
+		s.audit(rec, err)
+		s.emitFailure(req.TerminalID, err, errorCodeInvalidRequest)
+		return
+	}
+	if req.Force {
+		grace = 0
+	}
+
+	s.mu.Lock()
+	entry, exists := s.terminals[req.TerminalID]
+	if exists {
+		delete(s.terminals, req.TerminalID)
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		s.audit(rec, newSidecarError(errorCodeTerminalNotFound, "terminal not found"))
+		return
+	}
+	s.audit(rec, nil)
+
+	// The exit event drops the terminal's owner, so closed goes to the
+	// requester explicitly.
+	reply := s.replyTo()
+	if grace == 0 {
+		// Terminate before returning, so later requests no longer see the
+		// terminal; only the exit code is waited for in the background.
+		result := terminateEntry(entry)
+		s.runIsolated(entry.id, func() {
+			reply(closedEvent{Type: eventTypeClosed, terminalCloseResult: awaitTermination(entry, result)})
+		})
+		return
+	}
+	s.runIsolated(entry.id, func() {
+		reply(closedEvent{
+			Type:                eventTypeClosed,
+			terminalCloseResult: s.closeEntry(entry, grace),
+		})
+	})
+}
+
+// handleCloseAll closes the matching terminals in parallel and reports each
+// result in one closed_all event.
+func (s *sidecar) handleCloseAll(req closeAllRequest) {
+	if !req.All && len(req.Selector) == 0 && req.Shell == "" {
+		err := newSidecarError(errorCodeInvalidRequest, "close-all needs a selector, a shell or all")
+		s.emitRequestFailure("", req.RequestID, err, errorCodeInvalidRequest)
+		return
+	}
+	grace, err := closeGracePeriod(req.GraceMs)
+	if err != nil {
+		s.emitRequestFailure("", req.RequestID, err, errorCodeInvalidRequest)
+		return
+	}
+	if req.Force {
+		grace = 0
+	}
+
+	entries := s.takeTerminals(func(entry *terminalEntry) bool {
+		return (req.Shell == "" || entry.display == req.Shell) && matchTags(entry.tags, req.Selector)
+	})
+	for _, entry := range entries {
+		s.audit(auditRecord{Request: requestTypeCloseAll, TerminalID: entry.id}, nil)
+	}
+
+	reply := s.replyTo()
+	s.runIsolated("", func() {
+		reply(closedAllEvent{
+			Type:      eventTypeClosedAll,
+			RequestID: req.RequestID,
+			Terminals: s.closeEntries(entries, grace),
+		})
+	})
+}
+
+func (s *sidecar) handleSnapshot(req snapshotRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	if entry.screen == nil {
+		s.emitFailure(req.TerminalID, errEmulationDisabled, errorCodeInvalidRequest)
+		return
+	}
+
+	s.emit(entry.screen.Snapshot(req.TerminalID, req.IncludeScrollback))
+}
+
+func (s *sidecar) handleWait(req waitRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	if err := entry.waiters.Add(req); err != nil {
+		s.emitRequestFailure(req.TerminalID, req.RequestID, err, errorCodeInvalidRequest)
+	}
+}
+
+func (s *sidecar) handleSearch(req searchRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	if entry.screen == nil {
+		s.emitRequestFailure(req.TerminalID, req.RequestID, errEmulationDisabled, errorCodeInvalidRequest)
+		return
+	}
+
+	results, err := searchRetainedText(entry.screen.RetainedText(), req)
+	if err != nil {
+		s.emitRequestFailure(req.TerminalID, req.RequestID, err, errorCodeInvalidRequest)
+		return
+	}
+	s.emit(results)
+}
+
+func (s *sidecar) handleExport(req exportRequest) {
+	entry, ok := s.lookupTerminal(req.TerminalID)
+	if !ok {
+		return
+	}
+
+	if entry.screen == nil {
+		s.emitRequestFailure(req.TerminalID, req.RequestID, errEmulationDisabled, errorCodeInvalidRequest)
+		return
+	}
+
+	exported, err := exportRetainedOutput(entry.screen.RetainedLines(), req)
+	if err != nil {
+		s.emitRequestFailure(req.TerminalID, req.RequestID, err, errorCodeExportFailed)
+		return
+	}
+	s.emit(exported)
+}
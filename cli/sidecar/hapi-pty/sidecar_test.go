@@ -0,0 +1,599 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTerminal struct {
+	mu        sync.Mutex
+	req       openRequest
+	callbacks terminalCallbacks
+	writes    []string
+	resizes   [][2]int
+	killed    []int
+	closed    bool
+}
+
+func (f *fakeTerminal) Write(data string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.writes = append(f.writes, data)
+	return nil
+}
+
+func (f *fakeTerminal) Resize(cols int, rows int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resizes = append(f.resizes, [2]int{cols, rows})
+	return nil
+}
+
+func (f *fakeTerminal) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func (f *fakeTerminal) KillProcess(pid int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pid == 404 {
+		return newSidecarError(errorCodeProcessNotFound, "pid %d is not in the terminal's process tree", pid)
+	}
+	f.killed = append(f.killed, pid)
+	return nil
+}
+
+func (f *fakeTerminal) Writes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.writes...)
+}
+
+// waitForWrites polls until the terminal's write queue delivered n writes.
+func (f *fakeTerminal) waitForWrites(t *testing.T, n int) []string {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		writes := f.Writes()
+		if len(writes) >= n || time.Now().After(deadline) {
+			return writes
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (f *fakeTerminal) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Snapshot() *bytes.Buffer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.NewBuffer(append([]byte(nil), b.buf.Bytes()...))
+}
+
+type testSidecar struct {
+	*sidecar
+	stdout    *syncBuffer
+	terminals map[string]*fakeTerminal
+}
+
+func newTestSidecar(t *testing.T, cfg runConfig) *testSidecar {
+	t.Helper()
+
+	ts := &testSidecar{
+		stdout:    &syncBuffer{},
+		terminals: map[string]*fakeTerminal{},
+	}
+	if cfg.LookPath == nil {
+		cfg.LookPath = fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`})
+	}
+	if cfg.TerminalOpener == nil {
+		cfg.TerminalOpener = func(
+			req openRequest,
+			_ resolvedShell,
+			callbacks terminalCallbacks,
+			_ func(terminalID string, task func()),
+		) (terminalSession, error) {
+			terminal := &fakeTerminal{req: req, callbacks: callbacks}
+			ts.terminals[req.TerminalID] = terminal
+			return terminal, nil
+		}
+	}
+
+	ts.sidecar = newSidecar(cfg, ts.stdout)
+	t.Cleanup(ts.closeAllTerminals)
+	return ts
+}
+
+func (ts *testSidecar) events(t *testing.T) []map[string]any {
+	t.Helper()
+	return decodeRawEvents(t, ts.stdout.Snapshot())
+}
+
+func (ts *testSidecar) eventsOfType(t *testing.T, eventType string) []map[string]any {
+	t.Helper()
+
+	matched := make([]map[string]any, 0)
+	for _, evt := range ts.events(t) {
+		if evt["type"] == eventType {
+			matched = append(matched, evt)
+		}
+	}
+	return matched
+}
+
+func TestSidecarExitOfReplacedTerminalKeepsNewEntry(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	first := ts.terminals["t1"]
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+
+	first.callbacks.Exit(0)
+
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "ls"})
+	if writes := ts.terminals["t1"].waitForWrites(t, 1); len(writes) != 1 || writes[0] != "ls" {
+		t.Fatalf("expected write to reach reopened terminal, got %#v", writes)
+	}
+}
+
+func TestSidecarSnapshotRendersEmulatedScreen(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 20, Rows: 3, Emulate: true})
+	ts.terminals["t1"].callbacks.Output([]byte("PS> \x1b[32mok\x1b[0m\r\n"))
+	ts.handleRequest(snapshotRequest{Type: requestTypeSnapshot, TerminalID: "t1"})
+
+	snapshots := ts.eventsOfType(t, eventTypeSnapshot)
+	if len(snapshots) != 1 {
+		t.Fatalf("expected one snapshot event, got %d", len(snapshots))
+	}
+	lines := snapshots[0]["lines"].([]any)
+	first := lines[0].(map[string]any)
+	if first["text"] != "PS> ok" {
+		t.Fatalf("unexpected first line: %#v", first)
+	}
+	runs := first["runs"].([]any)
+	run := runs[0].(map[string]any)
+	if run["fg"] != "2" || run["start"].(float64) != 4 || run["length"].(float64) != 2 {
+		t.Fatalf("unexpected style run: %#v", run)
+	}
+}
+
+func TestSidecarOpenAttachesToExistingTerminal(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 20, Rows: 3, Emulate: true})
+	first := ts.terminals["t1"]
+	first.callbacks.Output([]byte("PS> dir\r\n"))
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", RequestID: "again", Cols: 20, Rows: 3, AttachIfExists: true})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 20, Rows: 3})
+
+	if ts.terminals["t1"] != first {
+		t.Fatal("attaching must not start a second shell")
+	}
+	ready := ts.eventsOfType(t, eventTypeReady)
+	if len(ready) != 2 || ready[1]["attached"] != true || ready[1]["requestId"] != "again" {
+		t.Fatalf("unexpected ready events: %+v", ready)
+	}
+	snapshots := ts.eventsOfType(t, eventTypeSnapshot)
+	if len(snapshots) != 1 || snapshots[0]["lines"].([]any)[0].(map[string]any)["text"] != "PS> dir" {
+		t.Fatalf("attach should replay the screen, got %+v", snapshots)
+	}
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeStartupFailed {
+		t.Fatalf("open without attachIfExists should still fail, got %+v", errors)
+	}
+}
+
+func TestSidecarAttachResumesFromSeq(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{ReplayEvents: 2})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 20, Rows: 3, Emulate: true})
+	for _, chunk := range []string{"one", "two", "three"} {
+		ts.terminals["t1"].callbacks.Output([]byte(chunk))
+	}
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", RequestID: "resume", Cols: 20, Rows: 3, AttachIfExists: true, ResumeFromSeq: 3})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", RequestID: "gone", Cols: 20, Rows: 3, AttachIfExists: true, ResumeFromSeq: 1})
+
+	ready := ts.eventsOfType(t, eventTypeReady)
+	if len(ready) != 3 || ready[1]["resumed"] != true || ready[1]["seq"] != float64(3) {
+		t.Fatalf("expected the first attach to resume at seq 3, got %+v", ready)
+	}
+	if _, resumed := ready[2]["resumed"]; resumed {
+		t.Fatalf("an attach from an evicted seq cannot resume, got %+v", ready[2])
+	}
+	outputs := ts.eventsOfType(t, eventTypeOutput)
+	if len(outputs) != 4 || outputs[3]["seq"] != float64(3) || outputs[3]["data"] != encodeBase64([]byte("three")) {
+		t.Fatalf("expected seq 3 to be replayed once, got %+v", outputs)
+	}
+	if snapshots := ts.eventsOfType(t, eventTypeSnapshot); len(snapshots) != 1 {
+		t.Fatalf("only the attach that could not resume should get a snapshot, got %+v", snapshots)
+	}
+}
+
+func TestSidecarSnapshotRequiresEmulation(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 20, Rows: 3})
+	ts.handleRequest(snapshotRequest{Type: requestTypeSnapshot, TerminalID: "t1"})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request error, got %#v", errors)
+	}
+}
+
+func TestSidecarKillProcess(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{MockBackend: true})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "m1", Cols: 80, Rows: 24, Backend: backendMock})
+	ts.handleRequest(killProcessRequest{Type: requestTypeKillProcess, TerminalID: "t1", PID: 1234})
+	ts.handleRequest(killProcessRequest{Type: requestTypeKillProcess, TerminalID: "t1", RequestID: "r1", PID: 404})
+	ts.handleRequest(killProcessRequest{Type: requestTypeKillProcess, TerminalID: "t1", RequestID: "r2"})
+	ts.handleRequest(killProcessRequest{Type: requestTypeKillProcess, TerminalID: "m1", RequestID: "r3", PID: 1})
+
+	if killed := ts.terminals["t1"].killed; len(killed) != 1 || killed[0] != 1234 {
+		t.Fatalf("unexpected killed pids: %v", killed)
+	}
+	var got []string
+	for _, evt := range ts.eventsOfType(t, eventTypeError) {
+		got = append(got, stringValue(evt["requestId"])+":"+stringValue(evt["code"]))
+	}
+	want := "r1:" + errorCodeProcessNotFound + ",r2:" + errorCodeInvalidRequest + ",r3:" + errorCodeInvalidRequest
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected kill-process errors %v, want %s", got, want)
+	}
+}
+
+func TestSidecarEOFFollowsQueuedWrites(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "b\ra\r"})
+	ts.handleRequest(eofRequest{Type: requestTypeEOF, TerminalID: "t1"})
+	ts.handleRequest(eofRequest{Type: requestTypeEOF, TerminalID: "t1", Sequence: eofSequenceCtrlD})
+	ts.handleRequest(eofRequest{Type: requestTypeEOF, TerminalID: "t1", Sequence: "ctrl-c"})
+
+	writes := ts.terminals["t1"].waitForWrites(t, 3)
+	if got := strings.Join(writes, ""); got != "b\ra\r\x1a\r\x04" {
+		t.Fatalf("unexpected writes %q", got)
+	}
+	if ts.terminals["t1"].Closed() {
+		t.Fatal("eof should leave the terminal open")
+	}
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request for unknown sequence, got %+v", errors)
+	}
+}
+
+func TestSidecarBroadcastWritesEveryTarget(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t3", Cols: 80, Rows: 24})
+	ts.handleRequest(broadcastRequest{
+		Type:        requestTypeBroadcast,
+		TerminalIDs: []string{"t1", "t2", "t1"},
+		Data:        "uptime",
+		Keys:        []string{"enter"},
+	})
+
+	for _, id := range []string{"t1", "t2"} {
+		if writes := ts.terminals[id].waitForWrites(t, 1); len(writes) != 1 || writes[0] != "uptime\r" {
+			t.Fatalf("%s: unexpected writes %#v", id, writes)
+		}
+	}
+	if writes := ts.terminals["t3"].Writes(); len(writes) != 0 {
+		t.Fatalf("t3 was not targeted, got %#v", writes)
+	}
+}
+
+func TestSidecarBroadcastWithUnknownTargetWritesNothing(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(broadcastRequest{Type: requestTypeBroadcast, RequestID: "b1", TerminalIDs: []string{"t1", "gone"}, Data: "rm -rf build"})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["requestId"] != "b1" || errors[0]["code"] != errorCodeTerminalNotFound {
+		t.Fatalf("expected terminal_not_found for the broadcast, got %+v", errors)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if writes := ts.terminals["t1"].Writes(); len(writes) != 0 {
+		t.Fatalf("broadcast with a missing target should write nothing, got %#v", writes)
+	}
+}
+
+func TestSidecarBroadcastWithBackloggedTargetWritesNothing(t *testing.T) {
+	stuck := &blockingTerminal{release: make(chan struct{})}
+	defer close(stuck.release)
+	healthy := &fakeTerminal{}
+	ts := newTestSidecar(t, runConfig{
+		TerminalOpener: func(req openRequest, _ resolvedShell, _ terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+			if req.TerminalID == "stuck" {
+				return stuck, nil
+			}
+			return healthy, nil
+		},
+	})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "ok", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "stuck", Cols: 80, Rows: 24})
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "stuck", Data: strings.Repeat("x", maxQueuedWriteBytes)})
+	ts.handleRequest(broadcastRequest{Type: requestTypeBroadcast, RequestID: "b1", TerminalIDs: []string{"ok", "stuck"}, Data: "make\r"})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["requestId"] != "b1" || errors[0]["terminalId"] != "stuck" || errors[0]["code"] != errorCodeWriteBacklogged {
+		t.Fatalf("expected write_backlogged for the stuck target, got %+v", errors)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if writes := healthy.Writes(); len(writes) != 0 {
+		t.Fatalf("broadcast with a backlogged target should write nothing, got %#v", writes)
+	}
+}
+
+func TestSidecarListFiltersByTags(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "b", Cols: 80, Rows: 24, Tags: map[string]string{"project": "api", "role": "server"}})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "a", Cols: 80, Rows: 24, Tags: map[string]string{"project": "api"}})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "c", Cols: 80, Rows: 24, Tags: map[string]string{"project": "web"}})
+	ts.handleRequest(listRequest{Type: requestTypeList, RequestID: "all"})
+	ts.handleRequest(listRequest{Type: requestTypeList, RequestID: "api", Selector: map[string]string{"project": "api"}})
+	ts.handleRequest(listRequest{Type: requestTypeList, RequestID: "roles", Selector: map[string]string{"role": ""}})
+
+	got := map[string]string{}
+	for _, evt := range ts.eventsOfType(t, eventTypeList) {
+		var ids []string
+		for _, terminal := range evt["terminals"].([]any) {
+			ids = append(ids, terminal.(map[string]any)["terminalId"].(string))
+		}
+		got[evt["requestId"].(string)] = strings.Join(ids, ",")
+	}
+	want := map[string]string{"all": "a,b,c", "api": "a,b", "roles": "b"}
+	for id, ids := range want {
+		if got[id] != ids {
+			t.Fatalf("list %s: got %q, want %q", id, got[id], ids)
+		}
+	}
+}
+
+func TestSidecarBroadcastBySelector(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, Tags: map[string]string{"cluster": "db"}})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24, Tags: map[string]string{"cluster": "db"}})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t3", Cols: 80, Rows: 24})
+	ts.handleRequest(broadcastRequest{Type: requestTypeBroadcast, Selector: map[string]string{"cluster": "db"}, Data: "df -h\r"})
+	ts.handleRequest(broadcastRequest{Type: requestTypeBroadcast, RequestID: "none", Selector: map[string]string{"cluster": "web"}, Data: "x"})
+
+	for _, id := range []string{"t1", "t2"} {
+		if writes := ts.terminals[id].waitForWrites(t, 1); len(writes) != 1 || writes[0] != "df -h\r" {
+			t.Fatalf("%s: unexpected writes %#v", id, writes)
+		}
+	}
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["requestId"] != "none" || errors[0]["code"] != errorCodeTerminalNotFound {
+		t.Fatalf("expected terminal_not_found for an empty selection, got %+v", errors)
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	id, err := newULID(time.UnixMilli(1469918176385), bytes.NewReader(make([]byte, 10)))
+	if err != nil {
+		t.Fatalf("newULID failed: %v", err)
+	}
+	if id != "01ARYZ6S410000000000000000" {
+		t.Fatalf("unexpected ULID %q", id)
+	}
+
+	id, _ = newULID(time.UnixMilli(1469918176385), bytes.NewReader(bytes.Repeat([]byte{0xff}, 10)))
+	if id != "01ARYZ6S41ZZZZZZZZZZZZZZZZ" {
+		t.Fatalf("unexpected ULID %q", id)
+	}
+}
+
+func TestSidecarAssignsTerminalIDs(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, RequestID: "o1", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, RequestID: "o2", Cols: 80, Rows: 24})
+
+	ready := ts.eventsOfType(t, eventTypeReady)
+	if len(ready) != 2 || ready[0]["requestId"] != "o1" || ready[1]["requestId"] != "o2" {
+		t.Fatalf("unexpected ready events: %+v", ready)
+	}
+	first, second := ready[0]["terminalId"].(string), ready[1]["terminalId"].(string)
+	if len(first) != 26 || first == second {
+		t.Fatalf("expected distinct ULIDs, got %q and %q", first, second)
+	}
+	if _, ok := ts.terminals[first]; !ok {
+		t.Fatalf("terminal %q was not opened under its assigned id", first)
+	}
+}
+
+func TestSidecarEnforcesMaxTerminals(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{MaxTerminals: 1})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["terminalId"] != "t2" || errors[0]["code"] != errorCodeTerminalLimit {
+		t.Fatalf("expected terminal_limit_reached for t2, got %+v", errors)
+	}
+
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24})
+	if _, opened := ts.terminals["t2"]; !opened {
+		t.Fatal("expected t2 to open after t1 closed")
+	}
+}
+
+func TestSidecarReservesTerminalsBeingOpened(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{MaxTerminals: 1})
+
+	// t1 is being opened elsewhere: its ID and the only place are taken.
+	if _, err := ts.reserveTerminal("t1", true); err != nil {
+		t.Fatalf("reserveTerminal failed: %v", err)
+	}
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24})
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 2 || errors[0]["code"] != errorCodeStartupFailed || errors[1]["code"] != errorCodeTerminalLimit {
+		t.Fatalf("expected the reserved ID and place to be refused, got %+v", errors)
+	}
+	ts.unreserveTerminal("t1")
+
+	// A failed open gives its reservation back.
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24, Checkpoint: true})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24})
+	if _, opened := ts.terminals["t2"]; !opened || len(ts.reserved) != 0 {
+		t.Fatalf("expected t2 to open once its failed open was released, reserved %v", ts.reserved)
+	}
+}
+
+func TestSidecarReprobesConPTYAfterFailure(t *testing.T) {
+	failures := 2
+	ts := newTestSidecar(t, runConfig{
+		ProbeConPTY: func() error {
+			if failures > 0 {
+				failures--
+				return newSidecarError(errorCodeConPTYUnavailable, "CreatePseudoConsole failed: HRESULT 0x80070005")
+			}
+			return nil
+		},
+		ProbeWinpty: func() error { return newSidecarError(errorCodeConPTYUnavailable, "no winpty") },
+	})
+	ts.probeBackends(true)
+
+	// The first open probes again, which still fails; the next does not.
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	if errs := ts.eventsOfType(t, eventTypeError); len(errs) != 1 || errs[0]["code"] != errorCodeConPTYUnavailable {
+		t.Fatalf("expected conpty_unavailable, got %+v", errs)
+	}
+	if failures != 0 {
+		t.Fatalf("open did not probe again, %d failures left", failures)
+	}
+
+	ts.handleRequest(probeRequest{Type: requestTypeProbe, RequestID: "p1"})
+	evt := waitForEventOfType(t, ts, eventTypeProbe)
+	if evt["requestId"] != "p1" || evt["conpty"] != true || evt["backend"] != backendConPTY {
+		t.Fatalf("unexpected probe result: %+v", evt)
+	}
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24})
+	if _, ok := ts.terminals["t2"]; !ok {
+		t.Fatal("open failed after a successful probe")
+	}
+}
+
+func TestSidecarListsTmuxPanes(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{
+		LookPath: fakeLookup(map[string]string{"tmux": "/usr/bin/tmux"}),
+		RunTmux: func(string, ...string) (string, error) {
+			return "%2\t@1\tmain\t0\t80\t24\t11\tbash\thost\n", nil
+		},
+	})
+
+	ts.handleRequest(tmuxPanesRequest{Type: requestTypeTmuxPanes, RequestID: "l1", Tmux: &tmuxOptions{Session: "work"}})
+	evt := waitForEventOfType(t, ts, eventTypeTmuxPanes)
+	panes, _ := evt["panes"].([]any)
+	if evt["requestId"] != "l1" || evt["session"] != "work" || len(panes) != 1 || panes[0].(map[string]any)["pane"] != "%2" {
+		t.Fatalf("unexpected tmux_panes event: %+v", evt)
+	}
+
+	ts.handleRequest(tmuxPanesRequest{Type: requestTypeTmuxPanes, RequestID: "l2"})
+	errEvt := waitForEventOfType(t, ts, eventTypeError)
+	if errEvt["requestId"] != "l2" || errEvt["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request for l2, got %+v", errEvt)
+	}
+}
+
+func TestSidecarReportsClampedSize(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(resizeRequest{Type: requestTypeResize, TerminalID: "t1", Cols: 100, Rows: 30})
+	if sizes := ts.eventsOfType(t, eventTypeSize); len(sizes) != 0 {
+		t.Fatalf("resize without a requestId should not be acknowledged, got %+v", sizes)
+	}
+
+	ts.handleRequest(resizeRequest{Type: requestTypeResize, TerminalID: "t1", RequestID: "r1", Cols: 40000, Rows: 0})
+	ts.handleRequest(getSizeRequest{Type: requestTypeGetSize, TerminalID: "t1", RequestID: "g1"})
+	sizes := ts.eventsOfType(t, eventTypeSize)
+	if len(sizes) != 2 {
+		t.Fatalf("expected two size events, got %+v", sizes)
+	}
+	for i, id := range []string{"r1", "g1"} {
+		if sizes[i]["requestId"] != id || sizes[i]["cols"] != float64(maxTerminalDimension) || sizes[i]["rows"] != float64(minTerminalDimension) {
+			t.Fatalf("unexpected size event: %+v", sizes[i])
+		}
+	}
+
+	terminal := ts.terminals["t1"]
+	terminal.mu.Lock()
+	defer terminal.mu.Unlock()
+	if want := [][2]int{{100, 30}, {maxTerminalDimension, minTerminalDimension}}; !reflect.DeepEqual(terminal.resizes, want) {
+		t.Fatalf("expected resizes %v, got %v", want, terminal.resizes)
+	}
+}
+
+func TestSidecarForwardsFocusOnlyWhenReportingEnabled(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	terminal := ts.terminals["t1"]
+
+	ts.handleRequest(focusRequest{Type: requestTypeFocus, TerminalID: "t1", Focused: true})
+	terminal.callbacks.Output([]byte("\x1b[?1004h"))
+	ts.handleRequest(focusRequest{Type: requestTypeFocus, TerminalID: "t1", Focused: false})
+	ts.handleRequest(focusRequest{Type: requestTypeFocus, TerminalID: "t1", Focused: true})
+
+	if got, want := terminal.waitForWrites(t, 2), []string{"\x1b[O", "\x1b[I"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected writes %q, got %q", want, got)
+	}
+}
+
+func TestSidecarReportsScreenMode(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.terminals["t1"].callbacks.Output([]byte("\x1b[?1049h\x1b[H"))
+
+	modes := ts.eventsOfType(t, eventTypeScreenMode)
+	if len(modes) != 1 || modes[0]["terminalId"] != "t1" || modes[0]["mode"] != screenModeAlternate {
+		t.Fatalf("unexpected screen_mode events: %+v", modes)
+	}
+
+	ts.handleRequest(listRequest{Type: requestTypeList})
+	list := waitForEventOfType(t, ts, eventTypeList)
+	terminals, _ := list["terminals"].([]any)
+	if len(terminals) != 1 || terminals[0].(map[string]any)["screenMode"] != screenModeAlternate {
+		t.Fatalf("expected the list to report the alternate screen, got %+v", list)
+	}
+}
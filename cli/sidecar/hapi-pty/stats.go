@@ -0,0 +1,99 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyWindow is how many recent round trips the latency stats cover.
+const latencyWindow = 32
+
+// latencyTracker keeps a rolling window of client-reported round trips.
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples [latencyWindow]float64
+	next    int
+	count   int
+}
+
+func (l *latencyTracker) Record(rttMs float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.samples[l.next] = rttMs
+	l.next = (l.next + 1) % latencyWindow
+	if l.count < latencyWindow {
+		l.count++
+	}
+}
+
+// Stats returns nil until a round trip has been reported.
+func (l *latencyTracker) Stats() *latencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.count == 0 {
+		return nil
+	}
+
+	last := l.samples[(l.next+latencyWindow-1)%latencyWindow]
+	stats := &latencyStats{Samples: l.count, LastMs: last, MinMs: last, MaxMs: last}
+	var total float64
+	for idx := 0; idx < l.count; idx++ {
+		sample := l.samples[idx]
+		total += sample
+		if sample < stats.MinMs {
+			stats.MinMs = sample
+		}
+		if sample > stats.MaxMs {
+			stats.MaxMs = sample
+		}
+	}
+	stats.AvgMs = total / float64(l.count)
+	return stats
+}
+
+func (s *sidecar) handlePing(req pingRequest) {
+	if req.RTTMs > 0 {
+		s.latency.Record(req.RTTMs)
+	}
+
+	pong := pongEvent{Type: eventTypePong, Nonce: req.Nonce, Timestamp: req.Timestamp}
+	if req.Nonce != "" || req.Timestamp != 0 {
+		pong.ReceivedAt = time.Now().UnixMilli()
+	}
+	s.emit(pong)
+}
+
+func (s *sidecar) terminalCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.terminals)
+}
+
+func (s *sidecar) emitHeartbeat() {
+	s.emit(heartbeatEvent{
+		Type:      eventTypeHeartbeat,
+		UptimeMs:  time.Since(s.startedAt).Milliseconds(),
+		Terminals: s.terminalCount(),
+	})
+}
+
+func (s *sidecar) handleStats() {
+	s.emit(statsEvent{
+		Type:         eventTypeStats,
+		Terminals:    s.terminalCount(),
+		MaxTerminals: s.cfg.MaxTerminals,
+		Latency:      s.latency.Stats(),
+		Clients:      s.connectedClients(),
+	})
+}
+
+func (s *sidecar) connectedClients() []clientInfo {
+	if s.clients != nil {
+		return s.clients.infos()
+	}
+	if client := s.client.Load(); client != nil {
+		return []clientInfo{*client}
+	}
+	return nil
+}
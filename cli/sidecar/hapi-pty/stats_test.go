@@ -0,0 +1,235 @@
+package main
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSidecarStatsReportsTerminalCount(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{MaxTerminals: 4})
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(statsRequest{Type: requestTypeStats})
+
+	stats := ts.eventsOfType(t, eventTypeStats)
+	if len(stats) != 1 || stats[0]["terminals"] != float64(1) || stats[0]["maxTerminals"] != float64(4) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSidecarPongEchoesNonceAndTimestamp(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(pingRequest{Type: requestTypePing, Nonce: "n1", Timestamp: 1700000000000})
+	ts.handleRequest(pingRequest{Type: requestTypePing})
+
+	pongs := ts.eventsOfType(t, eventTypePong)
+	if len(pongs) != 2 {
+		t.Fatalf("expected two pongs, got %+v", pongs)
+	}
+	if pongs[0]["nonce"] != "n1" || pongs[0]["timestamp"] != float64(1700000000000) {
+		t.Fatalf("pong should echo nonce and timestamp, got %+v", pongs[0])
+	}
+	if receivedAt, ok := pongs[0]["receivedAt"].(float64); !ok || receivedAt <= 0 {
+		t.Fatalf("pong should carry receivedAt, got %+v", pongs[0])
+	}
+	if len(pongs[1]) != 1 {
+		t.Fatalf("plain ping should get a plain pong, got %+v", pongs[1])
+	}
+}
+
+func TestSidecarStatsReportsRollingLatency(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(statsRequest{Type: requestTypeStats})
+	for _, rtt := range []float64{4, 8, 6} {
+		ts.handleRequest(pingRequest{Type: requestTypePing, RTTMs: rtt})
+	}
+	ts.handleRequest(statsRequest{Type: requestTypeStats})
+
+	stats := ts.eventsOfType(t, eventTypeStats)
+	if _, reported := stats[0]["latency"]; reported {
+		t.Fatalf("latency should be omitted before any sample, got %+v", stats[0])
+	}
+	latency, ok := stats[1]["latency"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected latency stats, got %+v", stats[1])
+	}
+	want := map[string]float64{"samples": 3, "lastMs": 6, "minMs": 4, "avgMs": 6, "maxMs": 8}
+	for key, value := range want {
+		if latency[key] != value {
+			t.Fatalf("latency %s = %v, want %v", key, latency[key], value)
+		}
+	}
+}
+
+func TestLatencyTrackerKeepsRecentWindow(t *testing.T) {
+	var tracker latencyTracker
+	for i := 0; i < latencyWindow; i++ {
+		tracker.Record(100)
+	}
+	for i := 0; i < latencyWindow; i++ {
+		tracker.Record(1)
+	}
+
+	stats := tracker.Stats()
+	if stats.Samples != latencyWindow || stats.MaxMs != 1 {
+		t.Fatalf("old samples should age out, got %+v", stats)
+	}
+}
+
+func TestRunSidecarEmitsHeartbeats(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	stdout := &syncBuffer{}
+	done := make(chan int, 1)
+	go func() {
+		done <- runSidecar(reader, stdout, runConfig{
+			HeartbeatInterval: 10 * time.Millisecond,
+			ProbeConPTY:       func() error { return nil },
+		})
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	var heartbeats []map[string]any
+	for len(heartbeats) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		heartbeats = heartbeats[:0]
+		for _, evt := range decodeRawEvents(t, stdout.Snapshot()) {
+			if evt["type"] == eventTypeHeartbeat {
+				heartbeats = append(heartbeats, evt)
+			}
+		}
+	}
+	if len(heartbeats) < 2 {
+		t.Fatalf("expected heartbeats, got %d", len(heartbeats))
+	}
+	if heartbeats[0]["terminals"] != float64(0) {
+		t.Fatalf("unexpected heartbeat: %+v", heartbeats[0])
+	}
+	if heartbeats[1]["uptimeMs"].(float64) < heartbeats[0]["uptimeMs"].(float64) {
+		t.Fatalf("uptime should not decrease: %+v", heartbeats)
+	}
+
+	hello := decodeRawEvents(t, stdout.Snapshot())[0]
+	if hello["heartbeatIntervalMs"] != float64(10) {
+		t.Fatalf("hello should advertise the heartbeat interval, got %+v", hello)
+	}
+
+	_ = writer.Close()
+	<-done
+}
+
+func TestClientHelloNegotiatesFeatures(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.emitHeartbeat()
+	ts.handleRequest(clientHelloRequest{
+		Type:      requestTypeHello,
+		RequestID: "h1",
+		Name:      "hub",
+		Version:   "2.1.0",
+		Features:  []string{clientFeatureTimestamps, "telepathy", clientFeatureTimestamps},
+	})
+	ts.emitHeartbeat()
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(statsRequest{Type: requestTypeStats})
+
+	acks := ts.eventsOfType(t, eventTypeHelloAck)
+	if len(acks) != 1 || acks[0]["requestId"] != "h1" || acks[0]["protocol"] != float64(protocolVersion) {
+		t.Fatalf("unexpected hello_ack: %+v", acks)
+	}
+	if features, _ := acks[0]["features"].([]any); len(features) != 1 || features[0] != clientFeatureTimestamps {
+		t.Fatalf("expected only the supported feature, once, got %+v", acks[0])
+	}
+	if heartbeats := ts.eventsOfType(t, eventTypeHeartbeat); len(heartbeats) != 1 {
+		t.Fatalf("heartbeats should stop once the client leaves them out, got %+v", heartbeats)
+	}
+	if !ts.terminals["t1"].req.Timestamps {
+		t.Fatal("timestamps feature should stamp the output of new terminals")
+	}
+
+	stats := ts.eventsOfType(t, eventTypeStats)
+	clients, _ := stats[0]["clients"].([]any)
+	if len(clients) != 1 || clients[0].(map[string]any)["name"] != "hub" {
+		t.Fatalf("stats should describe the client, got %+v", stats[0])
+	}
+}
+
+func TestClientHelloRejectsLongName(t *testing.T) {
+	line := `{"type":"hello","name":"` + string(make([]byte, maxClientNameBytes+1)) + `"}`
+	if _, err := decodeRequestLine([]byte(line)); err == nil {
+		t.Fatal("expected an oversized client name to be rejected")
+	}
+}
+
+func TestClientHelloRefusesUnsupportedProtocols(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+
+	ts.handleRequest(clientHelloRequest{Type: requestTypeHello, RequestID: "h1", Protocols: []int{99}})
+
+	errors := ts.eventsOfType(t, eventTypeError)
+	if len(errors) != 1 || errors[0]["code"] != errorCodeUnsupportedProtocol || errors[0]["requestId"] != "h1" {
+		t.Fatalf("expected unsupported_protocol, got %+v", errors)
+	}
+	if ts.client.Load() != nil {
+		t.Fatal("a refused hello should not be recorded")
+	}
+}
+
+func TestUnsubscribeSilencesCategoriesUntilResubscribed(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24})
+
+	ts.handleRequest(unsubscribeRequest{Type: requestTypeUnsubscribe, subscriptionChange: subscriptionChange{
+		RequestID:  "u1",
+		Categories: []string{eventCategoryOutput, eventCategoryHeartbeat},
+	}})
+	ts.handleRequest(subscribeRequest{Type: requestTypeSubscribe, subscriptionChange: subscriptionChange{
+		Categories:  []string{eventCategoryOutput},
+		TerminalIDs: []string{"t2"},
+	}})
+	ts.emitHeartbeat()
+	ts.terminals["t1"].callbacks.Output([]byte("muted"))
+	ts.terminals["t2"].callbacks.Output([]byte("watched"))
+	waitForEventOfType(t, ts, eventTypeOutput)
+
+	outputs := ts.eventsOfType(t, eventTypeOutput)
+	if len(outputs) != 1 || outputs[0]["terminalId"] != "t2" {
+		t.Fatalf("expected output only for the resubscribed terminal, got %+v", outputs)
+	}
+	if heartbeats := ts.eventsOfType(t, eventTypeHeartbeat); len(heartbeats) != 0 {
+		t.Fatalf("heartbeats should be silenced, got %+v", heartbeats)
+	}
+
+	subscriptions := ts.eventsOfType(t, eventTypeSubscriptions)
+	if len(subscriptions) != 2 || subscriptions[0]["requestId"] != "u1" {
+		t.Fatalf("expected each change to be acknowledged, got %+v", subscriptions)
+	}
+	output := subscriptions[1]["categories"].([]any)[0].(map[string]any)
+	if output["subscribed"] != false || output["except"].([]any)[0] != "t2" {
+		t.Fatalf("expected output to be off except for t2, got %+v", output)
+	}
+
+	ts.handleRequest(subscribeRequest{Type: requestTypeSubscribe, subscriptionChange: subscriptionChange{
+		Categories: []string{eventCategoryHeartbeat},
+	}})
+	ts.emitHeartbeat()
+	if heartbeats := ts.eventsOfType(t, eventTypeHeartbeat); len(heartbeats) != 1 {
+		t.Fatalf("heartbeats should resume after subscribing, got %+v", heartbeats)
+	}
+}
+
+func TestSubscribeRejectsUnknownCategories(t *testing.T) {
+	for _, line := range []string{
+		`{"type":"subscribe","categories":["gossip"]}`,
+		`{"type":"unsubscribe","categories":["output","ready"]}`,
+	} {
+		if _, err := decodeRequestLine([]byte(line)); err == nil {
+			t.Fatalf("expected %s to be rejected", line)
+		}
+	}
+}
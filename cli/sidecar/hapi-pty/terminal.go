@@ -1,15 +1,56 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"io"
+	"net"
+	"os"
 	"os/exec"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
+const (
+	backendConPTY = "conpty"
+	backendWinpty = "winpty"
+	backendPipes  = "pipes"
+	backendMock   = "mock"
+	backendTmux   = "tmux"
+
+	backendContainerd = "containerd"
+	backendSerial     = "serial"
+	backendTelnet     = "telnet"
+)
+
+// CreatePseudoConsole flags.
+const (
+	pseudoConsoleInheritCursor = 0x1
+)
+
+// conptyCreateFlags combines an open's named ConPTY flags with the ones it
+// passes through.
+func conptyCreateFlags(req openRequest) uint32 {
+	flags := req.ConPTYFlags
+	if req.InheritCursor {
+		flags |= pseudoConsoleInheritCursor
+	}
+	return flags
+}
+
 type terminalCallbacks struct {
+	// Output receives a chunk that is only valid for the duration of the call;
+	// the read buffer is reused for the next chunk.
 	Output func([]byte)
 	Exit   func(int)
+	// ExitReason, called before Exit, explains why the session ended when
+	// the exit code alone does not, e.g. exitReasonResourceLimit.
+	ExitReason func(reason string)
 }
 
 type terminalSession interface {
@@ -18,6 +59,31 @@ type terminalSession interface {
 	Close() error
 }
 
+// hangupSession is implemented by sessions that can ask the shell to exit on
+// its own, before Close terminates it.
+type hangupSession interface {
+	Hangup() error
+}
+
+// processKiller is implemented by sessions that can terminate a single
+// process in the shell's tree.
+type processKiller interface {
+	KillProcess(pid int) error
+}
+
+// killTerminalProcess terminates pid through session, failing for sessions
+// that cannot.
+func killTerminalProcess(session terminalSession, pid int) error {
+	if pid <= 0 {
+		return newSidecarError(errorCodeInvalidRequest, "pid must be positive")
+	}
+	killer, ok := session.(processKiller)
+	if !ok {
+		return newSidecarError(errorCodeInvalidRequest, "terminal does not support kill-process")
+	}
+	return killer.KillProcess(pid)
+}
+
 type terminalFactory func(
 	req openRequest,
 	shell resolvedShell,
@@ -25,21 +91,93 @@ type terminalFactory func(
 	runIsolated func(terminalID string, task func()),
 ) (terminalSession, error)
 
-func streamOutput(reader io.Reader, emit func([]byte)) {
+const (
+	minReadBufferBytes = 4096
+	maxReadBufferBytes = 64 * 1024
+	// readBufferShrinkAfter is how many consecutive short reads halve the buffer.
+	readBufferShrinkAfter = 8
+	// readBufferLimitBytes bounds an explicitly configured read size.
+	readBufferLimitBytes = 1024 * 1024
+)
+
+func validateReadBufferBytes(size int) error {
+	if size != 0 && (size < 512 || size > readBufferLimitBytes) {
+		return newSidecarError(errorCodeInvalidRequest, "readBufferBytes must be between 512 and %d", readBufferLimitBytes)
+	}
+	return nil
+}
+
+// readBufferSizer picks the next read size. A fixed size never changes;
+// otherwise the buffer doubles while reads fill it and halves after a run of
+// short reads, staying within minReadBufferBytes and maxReadBufferBytes.
+type readBufferSizer struct {
+	size       int
+	fixed      bool
+	shortReads int
+}
+
+func newReadBufferSizer(fixedSize int) *readBufferSizer {
+	if fixedSize > 0 {
+		return &readBufferSizer{size: fixedSize, fixed: true}
+	}
+	return &readBufferSizer{size: minReadBufferBytes}
+}
+
+func (r *readBufferSizer) Observe(n int) int {
+	if r.fixed {
+		return r.size
+	}
+
+	switch {
+	case n >= r.size:
+		r.shortReads = 0
+		if r.size < maxReadBufferBytes {
+			r.size *= 2
+		}
+	case n < r.size/4:
+		r.shortReads++
+		if r.shortReads >= readBufferShrinkAfter && r.size > minReadBufferBytes {
+			r.size /= 2
+			r.shortReads = 0
+		}
+	default:
+		r.shortReads = 0
+	}
+	return r.size
+}
+
+// streamOutput reads reader until it ends, passing each chunk to emit after
+// transcoding it to UTF-8 when transcoder is set.
+func streamOutput(reader io.Reader, fixedSize int, transcoder outputTranscoder, emit func([]byte)) {
 	if emit == nil {
 		return
 	}
+	if transcoder != nil {
+		defer func() {
+			if rest := transcoder.Flush(); len(rest) > 0 {
+				emit(rest)
+			}
+		}()
+	}
 
-	buffer := make([]byte, 4096)
+	sizer := newReadBufferSizer(fixedSize)
+	buffer := make([]byte, sizer.size)
 	for {
 		n, err := reader.Read(buffer)
 		if n > 0 {
-			chunk := make([]byte, n)
-			copy(chunk, buffer[:n])
-			emit(chunk)
+			chunk := buffer[:n]
+			if transcoder != nil {
+				chunk = transcoder.Transcode(chunk)
+			}
+			if len(chunk) > 0 {
+				emit(chunk)
+			}
 		}
 
 		if err == nil {
+			if size := sizer.Observe(n); size != len(buffer) {
+				buffer = make([]byte, size)
+			}
 			continue
 		}
 
@@ -51,6 +189,223 @@ func streamOutput(reader io.Reader, emit func([]byte)) {
 	}
 }
 
+// outputTranscoder converts output written in a legacy console code page to
+// UTF-8, for shells that ignore forceUtf8.
+type outputTranscoder interface {
+	Transcode(chunk []byte) []byte
+	// Flush returns a sequence cut off by the end of output.
+	Flush() []byte
+}
+
+// newOutputTranscoder returns nil when codePage needs no transcoding. Single
+// byte code pages are built in; others are left to the platform.
+func newOutputTranscoder(codePage int) (outputTranscoder, error) {
+	switch codePage {
+	case 0, utf8CodePage:
+		return nil, nil
+	}
+	if table, ok := singleByteCodePages[codePage]; ok {
+		return singleByteTranscoder{table: table}, nil
+	}
+	if codePage < 0 || codePage > 0xffff {
+		return nil, newSidecarError(errorCodeInvalidRequest, "invalid output code page %d", codePage)
+	}
+	return newSystemTranscoder(codePage)
+}
+
+type singleByteTranscoder struct {
+	table *[128]rune
+}
+
+func (t singleByteTranscoder) Transcode(chunk []byte) []byte {
+	ascii := true
+	for _, b := range chunk {
+		if b >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return chunk
+	}
+
+	out := make([]byte, 0, len(chunk)+len(chunk)/2)
+	for _, b := range chunk {
+		if b < 0x80 {
+			out = append(out, b)
+			continue
+		}
+		out = utf8.AppendRune(out, t.table[b-0x80])
+	}
+	return out
+}
+
+func (singleByteTranscoder) Flush() []byte {
+	return nil
+}
+
+// singleByteCodePages holds the upper halves of the common OEM and ANSI code
+// pages. Bytes 1252 leaves undefined map to the C1 controls, as on Windows.
+var singleByteCodePages = map[int]*[128]rune{
+	437: {
+		0x00C7, 0x00FC, 0x00E9, 0x00E2, 0x00E4, 0x00E0, 0x00E5, 0x00E7,
+		0x00EA, 0x00EB, 0x00E8, 0x00EF, 0x00EE, 0x00EC, 0x00C4, 0x00C5,
+		0x00C9, 0x00E6, 0x00C6, 0x00F4, 0x00F6, 0x00F2, 0x00FB, 0x00F9,
+		0x00FF, 0x00D6, 0x00DC, 0x00A2, 0x00A3, 0x00A5, 0x20A7, 0x0192,
+		0x00E1, 0x00ED, 0x00F3, 0x00FA, 0x00F1, 0x00D1, 0x00AA, 0x00BA,
+		0x00BF, 0x2310, 0x00AC, 0x00BD, 0x00BC, 0x00A1, 0x00AB, 0x00BB,
+		0x2591, 0x2592, 0x2593, 0x2502, 0x2524, 0x2561, 0x2562, 0x2556,
+		0x2555, 0x2563, 0x2551, 0x2557, 0x255D, 0x255C, 0x255B, 0x2510,
+		0x2514, 0x2534, 0x252C, 0x251C, 0x2500, 0x253C, 0x255E, 0x255F,
+		0x255A, 0x2554, 0x2569, 0x2566, 0x2560, 0x2550, 0x256C, 0x2567,
+		0x2568, 0x2564, 0x2565, 0x2559, 0x2558, 0x2552, 0x2553, 0x256B,
+		0x256A, 0x2518, 0x250C, 0x2588, 0x2584, 0x258C, 0x2590, 0x2580,
+		0x03B1, 0x00DF, 0x0393, 0x03C0, 0x03A3, 0x03C3, 0x00B5, 0x03C4,
+		0x03A6, 0x0398, 0x03A9, 0x03B4, 0x221E, 0x03C6, 0x03B5, 0x2229,
+		0x2261, 0x00B1, 0x2265, 0x2264, 0x2320, 0x2321, 0x00F7, 0x2248,
+		0x00B0, 0x2219, 0x00B7, 0x221A, 0x207F, 0x00B2, 0x25A0, 0x00A0,
+	},
+	850: {
+		0x00C7, 0x00FC, 0x00E9, 0x00E2, 0x00E4, 0x00E0, 0x00E5, 0x00E7,
+		0x00EA, 0x00EB, 0x00E8, 0x00EF, 0x00EE, 0x00EC, 0x00C4, 0x00C5,
+		0x00C9, 0x00E6, 0x00C6, 0x00F4, 0x00F6, 0x00F2, 0x00FB, 0x00F9,
+		0x00FF, 0x00D6, 0x00DC, 0x00F8, 0x00A3, 0x00D8, 0x00D7, 0x0192,
+		0x00E1, 0x00ED, 0x00F3, 0x00FA, 0x00F1, 0x00D1, 0x00AA, 0x00BA,
+		0x00BF, 0x00AE, 0x00AC, 0x00BD, 0x00BC, 0x00A1, 0x00AB, 0x00BB,
+		0x2591, 0x2592, 0x2593, 0x2502, 0x2524, 0x00C1, 0x00C2, 0x00C0,
+		0x00A9, 0x2563, 0x2551, 0x2557, 0x255D, 0x00A2, 0x00A5, 0x2510,
+		0x2514, 0x2534, 0x252C, 0x251C, 0x2500, 0x253C, 0x00E3, 0x00C3,
+		0x255A, 0x2554, 0x2569, 0x2566, 0x2560, 0x2550, 0x256C, 0x00A4,
+		0x00F0, 0x00D0, 0x00CA, 0x00CB, 0x00C8, 0x0131, 0x00CD, 0x00CE,
+		0x00CF, 0x2518, 0x250C, 0x2588, 0x2584, 0x00A6, 0x00CC, 0x2580,
+		0x00D3, 0x00DF, 0x00D4, 0x00D2, 0x00F5, 0x00D5, 0x00B5, 0x00FE,
+		0x00DE, 0x00DA, 0x00DB, 0x00D9, 0x00FD, 0x00DD, 0x00AF, 0x00B4,
+		0x00AD, 0x00B1, 0x2017, 0x00BE, 0x00B6, 0x00A7, 0x00F7, 0x00B8,
+		0x00B0, 0x00A8, 0x00B7, 0x00B9, 0x00B3, 0x00B2, 0x25A0, 0x00A0,
+	},
+	866: {
+		0x0410, 0x0411, 0x0412, 0x0413, 0x0414, 0x0415, 0x0416, 0x0417,
+		0x0418, 0x0419, 0x041A, 0x041B, 0x041C, 0x041D, 0x041E, 0x041F,
+		0x0420, 0x0421, 0x0422, 0x0423, 0x0424, 0x0425, 0x0426, 0x0427,
+		0x0428, 0x0429, 0x042A, 0x042B, 0x042C, 0x042D, 0x042E, 0x042F,
+		0x0430, 0x0431, 0x0432, 0x0433, 0x0434, 0x0435, 0x0436, 0x0437,
+		0x0438, 0x0439, 0x043A, 0x043B, 0x043C, 0x043D, 0x043E, 0x043F,
+		0x2591, 0x2592, 0x2593, 0x2502, 0x2524, 0x2561, 0x2562, 0x2556,
+		0x2555, 0x2563, 0x2551, 0x2557, 0x255D, 0x255C, 0x255B, 0x2510,
+		0x2514, 0x2534, 0x252C, 0x251C, 0x2500, 0x253C, 0x255E, 0x255F,
+		0x255A, 0x2554, 0x2569, 0x2566, 0x2560, 0x2550, 0x256C, 0x2567,
+		0x2568, 0x2564, 0x2565, 0x2559, 0x2558, 0x2552, 0x2553, 0x256B,
+		0x256A, 0x2518, 0x250C, 0x2588, 0x2584, 0x258C, 0x2590, 0x2580,
+		0x0440, 0x0441, 0x0442, 0x0443, 0x0444, 0x0445, 0x0446, 0x0447,
+		0x0448, 0x0449, 0x044A, 0x044B, 0x044C, 0x044D, 0x044E, 0x044F,
+		0x0401, 0x0451, 0x0404, 0x0454, 0x0407, 0x0457, 0x040E, 0x045E,
+		0x00B0, 0x2219, 0x00B7, 0x221A, 0x2116, 0x00A4, 0x25A0, 0x00A0,
+	},
+	1252: {
+		0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+		0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+		0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+		0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+		0x00A0, 0x00A1, 0x00A2, 0x00A3, 0x00A4, 0x00A5, 0x00A6, 0x00A7,
+		0x00A8, 0x00A9, 0x00AA, 0x00AB, 0x00AC, 0x00AD, 0x00AE, 0x00AF,
+		0x00B0, 0x00B1, 0x00B2, 0x00B3, 0x00B4, 0x00B5, 0x00B6, 0x00B7,
+		0x00B8, 0x00B9, 0x00BA, 0x00BB, 0x00BC, 0x00BD, 0x00BE, 0x00BF,
+		0x00C0, 0x00C1, 0x00C2, 0x00C3, 0x00C4, 0x00C5, 0x00C6, 0x00C7,
+		0x00C8, 0x00C9, 0x00CA, 0x00CB, 0x00CC, 0x00CD, 0x00CE, 0x00CF,
+		0x00D0, 0x00D1, 0x00D2, 0x00D3, 0x00D4, 0x00D5, 0x00D6, 0x00D7,
+		0x00D8, 0x00D9, 0x00DA, 0x00DB, 0x00DC, 0x00DD, 0x00DE, 0x00DF,
+		0x00E0, 0x00E1, 0x00E2, 0x00E3, 0x00E4, 0x00E5, 0x00E6, 0x00E7,
+		0x00E8, 0x00E9, 0x00EA, 0x00EB, 0x00EC, 0x00ED, 0x00EE, 0x00EF,
+		0x00F0, 0x00F1, 0x00F2, 0x00F3, 0x00F4, 0x00F5, 0x00F6, 0x00F7,
+		0x00F8, 0x00F9, 0x00FA, 0x00FB, 0x00FC, 0x00FD, 0x00FE, 0x00FF,
+	},
+}
+
+// crockfordBase32 is the ULID alphabet, which omits I, L, O and U.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a ULID: 48 bits of milliseconds since the epoch followed
+// by 80 random bits, as 26 Crockford base32 characters that sort by time.
+func newULID(now time.Time, entropy io.Reader) (string, error) {
+	var raw [16]byte
+	ms := uint64(now.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		raw[i] = byte(ms)
+		ms >>= 8
+	}
+	if _, err := io.ReadFull(entropy, raw[6:]); err != nil {
+		return "", err
+	}
+
+	// 128 bits fill 26 five-bit characters with two leading zero bits.
+	var out [26]byte
+	hi := uint64(raw[0])<<56 | uint64(raw[1])<<48 | uint64(raw[2])<<40 | uint64(raw[3])<<32 |
+		uint64(raw[4])<<24 | uint64(raw[5])<<16 | uint64(raw[6])<<8 | uint64(raw[7])
+	lo := uint64(raw[8])<<56 | uint64(raw[9])<<48 | uint64(raw[10])<<40 | uint64(raw[11])<<32 |
+		uint64(raw[12])<<24 | uint64(raw[13])<<16 | uint64(raw[14])<<8 | uint64(raw[15])
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:]), nil
+}
+
+// outputDrainTimeout bounds how long an exited shell's remaining output may
+// take to arrive before its exit is reported.
+const outputDrainTimeout = 2 * time.Second
+
+// outputDrain keeps a backend's output ordered before its exit. The backend
+// streams through Stream and calls Wait before reporting the exit; output
+// that arrives after Wait returned is dropped instead of following the exit.
+type outputDrain struct {
+	output func([]byte)
+	done   chan struct{}
+
+	mu     sync.Mutex
+	exited bool
+}
+
+func newOutputDrain(output func([]byte)) *outputDrain {
+	return &outputDrain{output: output, done: make(chan struct{})}
+}
+
+// Stream forwards reader's output until it ends.
+func (d *outputDrain) Stream(reader io.Reader, fixedSize int, transcoder outputTranscoder) {
+	defer close(d.done)
+	if d.output == nil {
+		return
+	}
+	streamOutput(reader, fixedSize, transcoder, d.forward)
+}
+
+func (d *outputDrain) forward(chunk []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.exited {
+		d.output(chunk)
+	}
+}
+
+// Wait blocks until the stream ended or timeout elapsed, reporting which,
+// and stops forwarding output.
+func (d *outputDrain) Wait(timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	drained := true
+	select {
+	case <-d.done:
+	case <-timer.C:
+		drained = false
+	}
+
+	d.mu.Lock()
+	d.exited = true
+	d.mu.Unlock()
+	return drained
+}
+
 func exitCodeFrom(err error) int {
 	if err == nil {
 		return 0
@@ -64,28 +419,1299 @@ func exitCodeFrom(err error) int {
 	return -1
 }
 
+// mergeEnvironment sets overrides in base. Names match the way the platform
+// compares them, ignoring case on Windows, where a replaced variable keeps
+// the spelling base gave it so "Path" is never joined by a second "PATH".
 func mergeEnvironment(base []string, overrides map[string]string) []string {
+	equal := func(a, b string) bool { return a == b }
+	if runtime.GOOS == "windows" {
+		equal = strings.EqualFold
+	}
+	return mergeEnvironmentNames(base, overrides, equal)
+}
+
+func mergeEnvironmentNames(base []string, overrides map[string]string, equal func(a, b string) bool) []string {
 	if len(overrides) == 0 {
 		return append([]string(nil), base...)
 	}
 
+	// Overrides that differ only in case apply in a fixed order.
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
 	merged := append([]string(nil), base...)
-	for key, value := range overrides {
-		prefix := key + "="
+	for _, key := range keys {
+		value := overrides[key]
 		replaced := false
 
 		for idx, item := range merged {
-			if strings.HasPrefix(item, prefix) {
-				merged[idx] = prefix + value
+			name, _, ok := strings.Cut(item, "=")
+			if ok && name != "" && equal(name, key) {
+				merged[idx] = name + "=" + value
 				replaced = true
 				break
 			}
 		}
 
 		if !replaced {
-			merged = append(merged, prefix+value)
+			merged = append(merged, key+"="+value)
 		}
 	}
 
 	return merged
 }
+
+// sortEnvironment orders env by name, ignoring case, as CreateProcess
+// requires of an environment block. Drive cwd entries such as "=C:=C:\"
+// have an empty name and sort first.
+func sortEnvironment(env []string) []string {
+	sorted := append([]string(nil), env...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToUpper(envEntryName(sorted[i])) < strings.ToUpper(envEntryName(sorted[j]))
+	})
+	return sorted
+}
+
+func envEntryName(entry string) string {
+	name, _, _ := strings.Cut(entry, "=")
+	return name
+}
+
+// pipesSession is backend "pipes": the shell with plain pipes for stdin and
+// for stdout and stderr combined, and no console. It is a degraded mode for
+// hosts that want dumb I/O or have no ConPTY; resizes are ignored.
+type pipesSession struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+func newPipesTerminalSession(
+	req openRequest,
+	shell resolvedShell,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	if req.Elevated || req.Privilege != privilegeInherit || req.MaxMemoryMb != 0 || req.CPURatePercent != 0 ||
+		req.Priority != priorityInherit || req.Affinity != 0 {
+		return nil, newSidecarError(errorCodeInvalidRequest, "the pipes backend cannot change a shell's privilege or limits")
+	}
+
+	cmd := pipesCommand(shell, req.NoWindow)
+	cmd.Dir = req.Cwd
+	cmd.Env = req.environ
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to create stdin pipe: %v", err)
+	}
+	output, outputWrite, err := os.Pipe()
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to create output pipe: %v", err)
+	}
+	cmd.Stdout = outputWrite
+	cmd.Stderr = outputWrite
+	err = cmd.Start()
+	_ = outputWrite.Close()
+	if err != nil {
+		_ = output.Close()
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to start shell process: %v", err)
+	}
+
+	s := &pipesSession{cmd: cmd, stdin: stdin}
+	transcoder, _ := newOutputTranscoder(req.OutputCodePage)
+	drain := newOutputDrain(callbacks.Output)
+	runIsolated(req.TerminalID, func() {
+		drain.Stream(output, req.ReadBufferBytes, transcoder)
+	})
+	runIsolated(req.TerminalID, func() {
+		code := exitCodeFrom(cmd.Wait())
+		// A background process may keep the pipe open past the shell.
+		drain.Wait(outputDrainTimeout)
+		_ = output.Close()
+		callbacks.Exit(code)
+	})
+	return s, nil
+}
+
+func (s *pipesSession) Write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stdin == nil {
+		return newSidecarError(errorCodeStartupFailed, "stdin pipe is closed")
+	}
+	if _, err := io.WriteString(s.stdin, data); err != nil {
+		return newSidecarError(errorCodeStartupFailed, "stdin write failed: %v", err)
+	}
+	return nil
+}
+
+func (s *pipesSession) Resize(int, int) error { return nil }
+
+// Hangup closes stdin, which shells reading it take as exit.
+func (s *pipesSession) Hangup() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stdin != nil {
+		_ = s.stdin.Close()
+		s.stdin = nil
+	}
+	return nil
+}
+
+func (s *pipesSession) Close() error {
+	_ = s.Hangup()
+
+	var closeErr error
+	s.closeOnce.Do(func() {
+		if err := s.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			closeErr = err
+		}
+	})
+	return closeErr
+}
+
+// mockClosedExitCode is reported when a mock terminal is closed, matching a
+// shell stopped by TerminateProcess.
+const mockClosedExitCode = 1
+
+// mockSession is the deterministic terminal behind backend "mock". It needs
+// no shell or ConPTY, so hosts and the sidecar's own tests can exercise the
+// full protocol anywhere; see mockOptions for its behaviour.
+type mockSession struct {
+	opts      mockOptions
+	callbacks terminalCallbacks
+	input     chan string
+	// done is closed once the mock has exited and stops reading input.
+	done chan struct{}
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newMockTerminalSession(
+	req openRequest,
+	_ resolvedShell,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	s := &mockSession{
+		callbacks: callbacks,
+		input:     make(chan string, 16),
+		done:      make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	if req.Mock != nil {
+		s.opts = *req.Mock
+	}
+
+	runIsolated(req.TerminalID, s.run)
+	return s, nil
+}
+
+func (s *mockSession) run() {
+	defer close(s.done)
+
+	if !s.play(s.opts.Output) {
+		s.callbacks.Exit(mockClosedExitCode)
+		return
+	}
+	s.output([]byte(s.opts.Prompt))
+
+	var line, echo []byte
+	afterCR := false
+	for {
+		var data string
+		select {
+		case <-s.closed:
+			s.callbacks.Exit(mockClosedExitCode)
+			return
+		case data = <-s.input:
+		}
+
+		for i := 0; i < len(data); i++ {
+			c := data[i]
+			if c == '\n' && afterCR {
+				afterCR = false
+				continue
+			}
+			afterCR = c == '\r'
+
+			switch c {
+			case '\r', '\n':
+				echo = append(echo, "\r\n"...)
+				s.echo(echo)
+				echo = echo[:0]
+
+				entered := string(line)
+				line = line[:0]
+				if code, ok := parseMockExit(entered); ok {
+					s.callbacks.Exit(code)
+					return
+				}
+				if !s.play(s.opts.Replies[entered]) {
+					s.callbacks.Exit(mockClosedExitCode)
+					return
+				}
+				s.output([]byte(s.opts.Prompt))
+			case '\b', 0x7f:
+				if len(line) > 0 {
+					line = line[:len(line)-1]
+					echo = append(echo, "\b \b"...)
+				}
+			default:
+				line = append(line, c)
+				echo = append(echo, c)
+			}
+		}
+		s.echo(echo)
+		echo = echo[:0]
+	}
+}
+
+func (s *mockSession) echo(data []byte) {
+	if !s.opts.NoEcho {
+		s.output(data)
+	}
+}
+
+func (s *mockSession) output(data []byte) {
+	if len(data) == 0 || s.callbacks.Output == nil {
+		return
+	}
+	s.callbacks.Output(data)
+}
+
+// play writes steps in order and reports false if closed during a delay.
+func (s *mockSession) play(steps []mockStep) bool {
+	for _, step := range steps {
+		if step.DelayMs > 0 {
+			timer := time.NewTimer(time.Duration(step.DelayMs) * time.Millisecond)
+			select {
+			case <-timer.C:
+			case <-s.closed:
+				timer.Stop()
+				return false
+			}
+		}
+		s.output([]byte(step.Data))
+	}
+	return true
+}
+
+// parseMockExit recognises "exit" and "exit CODE".
+func parseMockExit(line string) (int, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != "exit" || len(fields) > 2 {
+		return 0, false
+	}
+	if len(fields) == 1 {
+		return 0, true
+	}
+	code, err := strconv.Atoi(fields[1])
+	return code, err == nil
+}
+
+func (s *mockSession) Write(data string) error {
+	select {
+	case s.input <- data:
+		return nil
+	case <-s.done:
+		return io.ErrClosedPipe
+	}
+}
+
+func (s *mockSession) Resize(int, int) error { return nil }
+
+func (s *mockSession) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+const (
+	minTerminalDimension = 1
+	maxTerminalDimension = 32767
+)
+
+// defaultResizeDebounce is the --resize-debounce window.
+const defaultResizeDebounce = 30 * time.Millisecond
+
+// clampTerminalSize limits a size to what a console can hold, as makeCoord
+// does before every ConPTY call.
+func clampTerminalSize(cols int, rows int) (int, int) {
+	return clampTerminalDimension(cols), clampTerminalDimension(rows)
+}
+
+func clampTerminalDimension(n int) int {
+	if n < minTerminalDimension {
+		return minTerminalDimension
+	}
+	if n > maxTerminalDimension {
+		return maxTerminalDimension
+	}
+	return n
+}
+
+// resizeDebouncer coalesces a burst of resize requests, as sent while a
+// window is dragged, into at most one backend resize per window. The first
+// resize of a burst applies immediately and the last one when the window
+// ends, so the final size is never delayed by more than one window. Resizes
+// to the size already applied are dropped.
+type resizeDebouncer struct {
+	window time.Duration
+	apply  func(cols int, rows int)
+
+	mu          sync.Mutex
+	cols, rows  int
+	pendingCols int
+	pendingRows int
+	timer       *time.Timer
+	stopped     bool
+}
+
+func newResizeDebouncer(window time.Duration, cols int, rows int, apply func(cols int, rows int)) *resizeDebouncer {
+	return &resizeDebouncer{window: window, apply: apply, cols: cols, rows: rows}
+}
+
+func (d *resizeDebouncer) Resize(cols int, rows int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped {
+		return
+	}
+	if d.timer != nil {
+		d.pendingCols, d.pendingRows = cols, rows
+		return
+	}
+	d.applyLocked(cols, rows)
+}
+
+// Size returns the size last applied to the backend.
+func (d *resizeDebouncer) Size() (int, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cols, d.rows
+}
+
+func (d *resizeDebouncer) Stop() {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
+
+// applyLocked resizes the backend unless the size is unchanged, then opens
+// a window during which further resizes are only recorded.
+func (d *resizeDebouncer) applyLocked(cols int, rows int) {
+	if cols == d.cols && rows == d.rows {
+		return
+	}
+	d.cols, d.rows = cols, rows
+	d.apply(cols, rows)
+	if d.window <= 0 {
+		return
+	}
+	d.pendingCols, d.pendingRows = cols, rows
+	d.timer = time.AfterFunc(d.window, d.flush)
+}
+
+func (d *resizeDebouncer) flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.timer = nil
+	if !d.stopped {
+		d.applyLocked(d.pendingCols, d.pendingRows)
+	}
+}
+
+const (
+	telnetDefaultPort  = 23
+	telnetDialTimeout  = 10 * time.Second
+	telnetReadBytes    = 4096
+	telnetTerminalType = "xterm-256color"
+)
+
+// Telnet commands and the options a telnet terminal negotiates (RFC 854,
+// 857, 858, 1073 and 1091).
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWill = 251
+	telnetWont = 252
+	telnetDo   = 253
+	telnetDont = 254
+	telnetIAC  = 255
+
+	telnetOptionEcho         = 1
+	telnetOptionSGA          = 3
+	telnetOptionTerminalType = 24
+	telnetOptionNAWS         = 31
+
+	telnetSubnegotiationIs   = 0
+	telnetSubnegotiationSend = 1
+)
+
+// telnetAddress validates opts and returns the address it names.
+func telnetAddress(opts *telnetOptions) (string, error) {
+	if opts == nil || opts.Host == "" {
+		return "", newSidecarError(errorCodeInvalidRequest, "telnet backend requires telnet.host")
+	}
+	port := opts.Port
+	if port == 0 {
+		port = telnetDefaultPort
+	}
+	if port < 1 || port > 65535 {
+		return "", newSidecarError(errorCodeInvalidRequest, "telnet.port must be between 1 and 65535")
+	}
+	return net.JoinHostPort(opts.Host, strconv.Itoa(port)), nil
+}
+
+// telnetSession is a telnet client for consoles of network gear and lab
+// equipment. The server may echo and suppress go-ahead, and is told the
+// window size (NAWS) and terminal type when the request allows it; every
+// other option is refused. The terminal exits when the connection closes.
+type telnetSession struct {
+	conn      net.Conn
+	callbacks terminalCallbacks
+	naws      bool
+	termType  string
+
+	mu sync.Mutex
+	// local and remote hold the options enabled on each side, so the
+	// session answers only requests that change them and never loops.
+	local  map[byte]bool
+	remote map[byte]bool
+	// sizing is set once the server asked for NAWS, which the session
+	// offers unasked.
+	sizing     bool
+	cols, rows int
+}
+
+func newTelnetTerminalSession(
+	req openRequest,
+	shell resolvedShell,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	conn, err := net.DialTimeout("tcp", shell.Path, telnetDialTimeout)
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to connect to %s: %v", shell.Path, err)
+	}
+	s := &telnetSession{
+		conn:      conn,
+		callbacks: callbacks,
+		naws:      req.Telnet.NAWS == nil || *req.Telnet.NAWS,
+		termType:  req.Telnet.TerminalType,
+		local:     map[byte]bool{},
+		remote:    map[byte]bool{},
+		cols:      req.Cols,
+		rows:      req.Rows,
+	}
+	if s.naws {
+		s.mu.Lock()
+		s.local[telnetOptionNAWS] = true
+		s.send(telnetIAC, telnetWill, telnetOptionNAWS)
+		s.mu.Unlock()
+	}
+	runIsolated(req.TerminalID, func() {
+		s.run()
+		callbacks.Exit(0)
+	})
+	return s, nil
+}
+
+// run reads the connection until it closes, passing data on as output and
+// answering the server's commands.
+func (s *telnetSession) run() {
+	reader := bufio.NewReaderSize(s.conn, telnetReadBytes)
+	data := make([]byte, 0, telnetReadBytes)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			s.flush(data)
+			return
+		}
+		if b != telnetIAC {
+			data = append(data, b)
+			if reader.Buffered() == 0 {
+				data = s.flush(data)
+			}
+			continue
+		}
+
+		command, err := reader.ReadByte()
+		if err != nil {
+			s.flush(data)
+			return
+		}
+		switch command {
+		case telnetIAC:
+			data = append(data, telnetIAC)
+		case telnetWill, telnetWont, telnetDo, telnetDont:
+			option, err := reader.ReadByte()
+			if err != nil {
+				s.flush(data)
+				return
+			}
+			s.negotiate(command, option)
+		case telnetSB:
+			payload, err := readTelnetSubnegotiation(reader)
+			if err != nil {
+				s.flush(data)
+				return
+			}
+			s.subnegotiate(payload)
+		}
+		// Other commands (NOP, go-ahead, break and the like) carry nothing
+		// a terminal shows.
+		if reader.Buffered() == 0 {
+			data = s.flush(data)
+		}
+	}
+}
+
+func (s *telnetSession) flush(data []byte) []byte {
+	if len(data) > 0 {
+		s.callbacks.Output(append([]byte(nil), data...))
+	}
+	return data[:0]
+}
+
+// readTelnetSubnegotiation reads the payload of IAC SB up to IAC SE.
+func readTelnetSubnegotiation(reader *bufio.Reader) ([]byte, error) {
+	var payload []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != telnetIAC {
+			payload = append(payload, b)
+			continue
+		}
+		next, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if next == telnetSE {
+			return payload, nil
+		}
+		payload = append(payload, next)
+	}
+}
+
+// negotiate answers a WILL, WONT, DO or DONT for option.
+func (s *telnetSession) negotiate(command byte, option byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch command {
+	case telnetWill, telnetWont:
+		enable := command == telnetWill && (option == telnetOptionEcho || option == telnetOptionSGA)
+		if s.remote[option] == enable && (enable || command == telnetWont) {
+			return
+		}
+		s.remote[option] = enable
+		reply := byte(telnetDont)
+		if enable {
+			reply = telnetDo
+		}
+		s.send(telnetIAC, reply, option)
+	case telnetDo, telnetDont:
+		enable := command == telnetDo && s.supports(option)
+		if option == telnetOptionNAWS {
+			s.sizing = enable
+		}
+		if s.local[option] != enable || (!enable && command == telnetDo) {
+			s.local[option] = enable
+			reply := byte(telnetWont)
+			if enable {
+				reply = telnetWill
+			}
+			s.send(telnetIAC, reply, option)
+		}
+		if s.sizing && option == telnetOptionNAWS {
+			s.sendWindowSize()
+		}
+	}
+}
+
+// supports reports whether the session enables option on its side.
+func (s *telnetSession) supports(option byte) bool {
+	switch option {
+	case telnetOptionNAWS:
+		return s.naws
+	case telnetOptionTerminalType:
+		return s.termType != ""
+	case telnetOptionSGA:
+		return true
+	}
+	return false
+}
+
+func (s *telnetSession) subnegotiate(payload []byte) {
+	if len(payload) == 2 && payload[0] == telnetOptionTerminalType && payload[1] == telnetSubnegotiationSend {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.local[telnetOptionTerminalType] {
+			message := append([]byte{telnetIAC, telnetSB, telnetOptionTerminalType, telnetSubnegotiationIs}, s.termType...)
+			s.send(append(message, telnetIAC, telnetSE)...)
+		}
+	}
+}
+
+// sendWindowSize sends the NAWS subnegotiation; s.mu must be held.
+func (s *telnetSession) sendWindowSize() {
+	message := []byte{telnetIAC, telnetSB, telnetOptionNAWS}
+	for _, n := range []int{s.cols, s.rows} {
+		// A size byte equal to IAC is doubled like any other.
+		for _, b := range []byte{byte(n >> 8), byte(n)} {
+			message = append(message, b)
+			if b == telnetIAC {
+				message = append(message, b)
+			}
+		}
+	}
+	s.send(append(message, telnetIAC, telnetSE)...)
+}
+
+// send writes bytes already in telnet form; s.mu must be held.
+func (s *telnetSession) send(message ...byte) {
+	_, _ = s.conn.Write(message)
+}
+
+// Write sends input with IAC doubled and a lone CR, which Enter sends,
+// followed by NUL as the NVT requires.
+func (s *telnetSession) Write(data string) error {
+	message := make([]byte, 0, len(data)+8)
+	for i := 0; i < len(data); i++ {
+		message = append(message, data[i])
+		switch {
+		case data[i] == telnetIAC:
+			message = append(message, telnetIAC)
+		case data[i] == '\r' && (i+1 == len(data) || data[i+1] != '\n'):
+			message = append(message, 0)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.conn.Write(message); err != nil {
+		return newSidecarError(errorCodeStartupFailed, "telnet write failed: %v", err)
+	}
+	return nil
+}
+
+func (s *telnetSession) Resize(cols int, rows int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cols, s.rows = cols, rows
+	if s.sizing {
+		s.sendWindowSize()
+	}
+	return nil
+}
+
+func (s *telnetSession) Close() error {
+	return s.conn.Close()
+}
+
+const serialReadBytes = 4096
+
+// dialSerialPipe connects to a serial console's pipe, which a serial
+// terminal dials again every serialReconnectInterval while the console is
+// gone; tests replace both.
+var (
+	dialSerialPipe          = dialBrokerPipe
+	serialReconnectInterval = time.Second
+)
+
+// serialPipeName expands a bare pipe name to its local path.
+func serialPipeName(name string) string {
+	if strings.HasPrefix(name, `\\`) || strings.HasPrefix(name, "//") {
+		return name
+	}
+	return `\\.\pipe\` + name
+}
+
+// serialSession is a VM's serial console reached through a named pipe. The
+// console has no size and no process: resizes are ignored, and closing the
+// terminal only disconnects. Where the pipe closes, as a rebooting Hyper-V
+// VM's does, the session dials it again until it is back.
+type serialSession struct {
+	pipe      string
+	reconnect bool
+	callbacks terminalCallbacks
+
+	mu   sync.Mutex
+	conn io.ReadWriteCloser
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newSerialTerminalSession(
+	req openRequest,
+	shell resolvedShell,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	conn, err := dialSerialPipe(shell.Path)
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to connect to serial console %s: %v", shell.Path, err)
+	}
+	s := &serialSession{
+		pipe:      shell.Path,
+		reconnect: req.Serial.Reconnect == nil || *req.Serial.Reconnect,
+		callbacks: callbacks,
+		conn:      conn,
+		closed:    make(chan struct{}),
+	}
+	runIsolated(req.TerminalID, func() {
+		s.run(conn)
+		callbacks.Exit(0)
+	})
+	return s, nil
+}
+
+// run copies the console's output until the terminal closes, or until the
+// pipe does and the session does not reconnect.
+func (s *serialSession) run(conn io.ReadWriteCloser) {
+	buf := make([]byte, serialReadBytes)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			s.callbacks.Output(append([]byte(nil), buf[:n]...))
+		}
+		if err == nil {
+			continue
+		}
+		s.mu.Lock()
+		if s.conn == conn {
+			s.conn = nil
+		}
+		s.mu.Unlock()
+		_ = conn.Close()
+		select {
+		case <-s.closed:
+			return
+		default:
+		}
+		if !s.reconnect {
+			return
+		}
+		if conn = s.redial(); conn == nil {
+			return
+		}
+	}
+}
+
+// redial waits for the pipe to come back, returning nil once the terminal
+// is closed.
+func (s *serialSession) redial() io.ReadWriteCloser {
+	s.callbacks.Output([]byte("\r\n[serial console disconnected, reconnecting]\r\n"))
+	ticker := time.NewTicker(serialReconnectInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closed:
+			return nil
+		case <-ticker.C:
+		}
+		conn, err := dialSerialPipe(s.pipe)
+		if err != nil {
+			continue
+		}
+		s.mu.Lock()
+		select {
+		case <-s.closed:
+			s.mu.Unlock()
+			_ = conn.Close()
+			return nil
+		default:
+		}
+		s.conn = conn
+		s.mu.Unlock()
+		s.callbacks.Output([]byte("[serial console reconnected]\r\n"))
+		return conn
+	}
+}
+
+func (s *serialSession) Write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return newSidecarError(errorCodeStartupFailed, "serial console %s is disconnected", s.pipe)
+	}
+	if _, err := io.WriteString(s.conn, data); err != nil {
+		return newSidecarError(errorCodeStartupFailed, "serial console write failed: %v", err)
+	}
+	return nil
+}
+
+func (s *serialSession) Resize(cols int, rows int) error {
+	_, _ = cols, rows
+	return nil
+}
+
+func (s *serialSession) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		close(s.closed)
+		conn := s.conn
+		s.conn = nil
+		s.mu.Unlock()
+		if conn != nil {
+			// A synchronous pipe read does not end when its handle closes.
+			cancelPendingReads(conn)
+			_ = conn.Close()
+		}
+	})
+	return nil
+}
+
+// containerdShell resolves the ctr command that execs req.Containerd's
+// command into its container's running task, with a TTY. The backend relies
+// on the ctr CLI rather than the containerd client API.
+func containerdShell(req openRequest, lookPath shellLookupFunc) (resolvedShell, error) {
+	opts := req.Containerd
+	if opts == nil || opts.Container == "" {
+		return resolvedShell{}, newSidecarError(errorCodeInvalidRequest, "containerd backend requires containerd.container")
+	}
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	path, err := lookPath("ctr")
+	if err != nil {
+		return resolvedShell{}, newSidecarError(errorCodeShellNotFound, "ctr not found: %v", err)
+	}
+
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	execID := opts.ExecID
+	if execID == "" {
+		execID = "hapi-" + req.TerminalID
+	}
+	args := []string{"--namespace", namespace, "tasks", "exec", "--tty", "--exec-id", execID}
+	if opts.Cwd != "" {
+		args = append(args, "--cwd", opts.Cwd)
+	}
+	if opts.User != "" {
+		args = append(args, "--user", opts.User)
+	}
+	command := opts.Command
+	if len(command) == 0 {
+		command = []string{"sh"}
+	}
+	// "--" keeps a container or command starting with "-" from being read
+	// as a flag.
+	args = append(append(args, "--", opts.Container), command...)
+	return resolvedShell{Name: backendContainerd, Path: path, Args: args}, nil
+}
+
+// tmuxCommandBytes bounds the input carried by one send-keys command.
+const tmuxCommandBytes = 512
+
+// tmuxPaneFormat is the list-panes format listTmuxPanes parses. The title
+// comes last, as it may itself hold tabs.
+const tmuxPaneFormat = "#{pane_id}\t#{window_id}\t#{window_name}\t#{pane_index}\t#{pane_width}\t#{pane_height}\t#{pane_active}#{window_active}\t#{pane_current_command}\t#{pane_title}"
+
+// tmuxCommandFunc runs tmux, or ssh running it, and returns its output.
+type tmuxCommandFunc func(path string, args ...string) (string, error)
+
+// tmuxShell resolves the tmux control client for opts, or the ssh client
+// that runs it on opts.SSH.
+func tmuxShell(opts *tmuxOptions, lookPath shellLookupFunc) (resolvedShell, error) {
+	if opts == nil || opts.Session == "" {
+		return resolvedShell{}, newSidecarError(errorCodeInvalidRequest, "tmux backend requires tmux.session")
+	}
+	if opts.Pane != "" && !isTmuxPaneID(opts.Pane) {
+		return resolvedShell{}, newSidecarError(errorCodeInvalidRequest, "tmux.pane must be a pane ID such as %%3")
+	}
+	return tmuxCommand(opts, lookPath, "-C", "attach-session", "-t", opts.Session)
+}
+
+// listTmuxPanes lists the panes of every window of opts.Session, so a host
+// can pick the one an open adopts.
+func listTmuxPanes(opts *tmuxOptions, lookPath shellLookupFunc, run tmuxCommandFunc) ([]tmuxPaneInfo, error) {
+	if opts == nil || opts.Session == "" {
+		return nil, newSidecarError(errorCodeInvalidRequest, "tmux-panes requires tmux.session")
+	}
+	shell, err := tmuxCommand(opts, lookPath, "list-panes", "-s", "-t", opts.Session, "-F", tmuxPaneFormat)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		run = runTmux
+	}
+	out, err := run(shell.Path, shell.Args...)
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "tmux list-panes failed: %s", strings.TrimSpace(out+" "+err.Error()))
+	}
+
+	panes := []tmuxPaneInfo{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.SplitN(strings.TrimSuffix(line, "\r"), "\t", 9)
+		if len(fields) != 9 || !isTmuxPaneID(fields[0]) {
+			continue
+		}
+		index, _ := strconv.Atoi(fields[3])
+		cols, _ := strconv.Atoi(fields[4])
+		rows, _ := strconv.Atoi(fields[5])
+		panes = append(panes, tmuxPaneInfo{
+			Pane:       fields[0],
+			Window:     fields[1],
+			WindowName: fields[2],
+			Index:      index,
+			Cols:       cols,
+			Rows:       rows,
+			Active:     fields[6] == "11",
+			Command:    fields[7],
+			Title:      fields[8],
+		})
+	}
+	return panes, nil
+}
+
+func runTmux(path string, args ...string) (string, error) {
+	cmd := exec.Command(path, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return stderr.String(), err
+	}
+	return string(out), nil
+}
+
+// tmuxCommand resolves tmux with args on the server opts names, or the ssh
+// client that runs it on opts.SSH.
+func tmuxCommand(opts *tmuxOptions, lookPath shellLookupFunc, args ...string) (resolvedShell, error) {
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+	if opts.Socket != "" {
+		args = append([]string{"-L", opts.Socket}, args...)
+	}
+	program := "tmux"
+	if opts.SSH != "" {
+		if strings.HasPrefix(opts.SSH, "-") {
+			return resolvedShell{}, newSidecarError(errorCodeInvalidRequest, "tmux.ssh must be a destination, not an option")
+		}
+		quoted := make([]string, 0, len(args)+1)
+		for _, arg := range append([]string{"tmux"}, args...) {
+			quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+		}
+		program, args = "ssh", []string{"-T", opts.SSH, strings.Join(quoted, " ")}
+	}
+
+	path, err := lookPath(program)
+	if err != nil {
+		return resolvedShell{}, newSidecarError(errorCodeShellNotFound, "%s not found: %v", program, err)
+	}
+	return resolvedShell{Name: backendTmux, Path: path, Args: args}, nil
+}
+
+func isTmuxPaneID(id string) bool {
+	_, err := strconv.ParseUint(strings.TrimPrefix(id, "%"), 10, 32)
+	return strings.HasPrefix(id, "%") && err == nil
+}
+
+// tmuxSession adopts a tmux pane through a control mode client (tmux -C,
+// the protocol -CC wraps for iTerm2). The pane's %output notifications are
+// its output, input is sent with send-keys and the terminal size is the
+// client's. Closing the terminal detaches; the pane keeps running. The
+// terminal exits when the pane, its window or the tmux client goes away.
+type tmuxSession struct {
+	cmd       *exec.Cmd
+	callbacks terminalCallbacks
+
+	mu    sync.Mutex
+	stdin io.WriteCloser
+	// pane and window are learned from the first command's reply; input
+	// and resizes wait for them.
+	pane      string
+	window    string
+	ready     chan struct{}
+	readyOnce sync.Once
+	pending   []func(reply []string, failed bool)
+	// size is the latest resize, applied once the pane is known.
+	cols, rows int
+
+	closeOnce sync.Once
+}
+
+func newTmuxTerminalSession(
+	req openRequest,
+	shell resolvedShell,
+	callbacks terminalCallbacks,
+	runIsolated func(terminalID string, task func()),
+) (terminalSession, error) {
+	cmd := pipesCommand(shell, req.NoWindow)
+	cmd.Env = req.environ
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to create stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to create stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, newSidecarError(errorCodeStartupFailed, "failed to start tmux: %v", err)
+	}
+
+	s := &tmuxSession{
+		cmd:       cmd,
+		callbacks: callbacks,
+		stdin:     stdin,
+		ready:     make(chan struct{}),
+		cols:      req.Cols,
+		rows:      req.Rows,
+	}
+	target := req.Tmux.Pane
+	if target == "" {
+		target = req.Tmux.Session
+	}
+	s.command(func(reply []string, failed bool) {
+		fields := []string{}
+		if len(reply) > 0 {
+			fields = strings.Fields(reply[0])
+		}
+		if failed || len(fields) != 2 {
+			s.output("tmux: " + strings.Join(reply, "\r\n") + "\r\n")
+			_ = s.Close()
+			return
+		}
+		s.mu.Lock()
+		s.pane, s.window = fields[0], fields[1]
+		cols, rows := s.cols, s.rows
+		s.mu.Unlock()
+		if cols > 0 && rows > 0 {
+			_ = s.Resize(cols, rows)
+		}
+		// Start with what the pane shows, as an attach would.
+		s.command(func(reply []string, failed bool) {
+			if !failed {
+				s.output(strings.Join(reply, "\r\n"))
+			}
+			s.markReady()
+		}, "capture-pane", "-p", "-e", "-t", fields[0])
+	}, "display-message", "-p", "-t", target, "#{pane_id} #{window_id}")
+
+	runIsolated(req.TerminalID, func() {
+		code := s.read(stdout)
+		if err := cmd.Wait(); err != nil && code == 0 {
+			code = exitCodeFrom(err)
+		}
+		callbacks.Exit(code)
+	})
+	return s, nil
+}
+
+// command sends a tmux command, calling onReply with the lines of its
+// %begin/%end block, or of its %error block with failed set.
+func (s *tmuxSession) command(onReply func(reply []string, failed bool), args ...string) {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, tmuxQuote(arg))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stdin == nil {
+		return
+	}
+	if onReply == nil {
+		onReply = func([]string, bool) {}
+	}
+	s.pending = append(s.pending, onReply)
+	_, _ = io.WriteString(s.stdin, strings.Join(quoted, " ")+"\n")
+}
+
+// tmuxQuote quotes arg for tmux's command parser.
+func tmuxQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(arg) + `"`
+}
+
+// read handles the control client's output until it ends, returning the
+// exit code the terminal reports. It marks the session ready on return so
+// writes queued before the pane was found fail instead of blocking.
+func (s *tmuxSession) read(stdout io.Reader) int {
+	defer s.markReady()
+	reader := bufio.NewReader(stdout)
+	var reply []string
+	inReply, ours := false, false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return 0
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if inReply {
+			end, failed := strings.HasPrefix(line, "%end "), strings.HasPrefix(line, "%error ")
+			if !end && !failed {
+				reply = append(reply, line)
+				continue
+			}
+			inReply = false
+			if ours {
+				s.mu.Lock()
+				var onReply func([]string, bool)
+				if len(s.pending) > 0 {
+					onReply, s.pending = s.pending[0], s.pending[1:]
+				}
+				s.mu.Unlock()
+				if onReply != nil {
+					onReply(reply, failed)
+				}
+			}
+			continue
+		}
+
+		name, rest, _ := strings.Cut(line, " ")
+		switch name {
+		case "%begin":
+			// Blocks flagged 1 answer this client's commands, in order.
+			fields := strings.Fields(rest)
+			inReply, ours, reply = true, len(fields) == 3 && fields[2] == "1", nil
+		case "%output":
+			pane, data, _ := strings.Cut(rest, " ")
+			if pane == s.currentPane() {
+				s.callbacks.Output(unescapeTmuxOutput(data))
+			}
+		case "%window-close", "%unlinked-window-close":
+			if window, _, _ := strings.Cut(rest, " "); window == s.currentWindow() {
+				return 0
+			}
+		case "%layout-change":
+			window, layout, _ := strings.Cut(rest, " ")
+			if window == s.currentWindow() && !tmuxLayoutHasPane(layout, s.currentPane()) {
+				return 0
+			}
+		case "%exit":
+			return 0
+		}
+	}
+}
+
+func (s *tmuxSession) markReady() {
+	s.readyOnce.Do(func() { close(s.ready) })
+}
+
+func (s *tmuxSession) currentPane() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pane
+}
+
+func (s *tmuxSession) currentWindow() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.window
+}
+
+func (s *tmuxSession) output(text string) {
+	if text != "" {
+		s.callbacks.Output([]byte(text))
+	}
+}
+
+// unescapeTmuxOutput reverses the octal escapes %output uses for control
+// characters and backslashes.
+func unescapeTmuxOutput(data string) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\\' && i+3 < len(data) {
+			if n, err := strconv.ParseUint(data[i+1:i+4], 8, 8); err == nil {
+				out = append(out, byte(n))
+				i += 3
+				continue
+			}
+		}
+		out = append(out, data[i])
+	}
+	return out
+}
+
+// tmuxLayoutHasPane reports whether a window layout, e.g.
+// "b25f,80x24,0,0{40x24,0,0,1,39x24,41,0,2}", still holds pane "%2".
+// Each cell ends with its pane number after its size and offsets.
+func tmuxLayoutHasPane(layout string, pane string) bool {
+	number := strings.TrimPrefix(pane, "%")
+	layout, _, _ = strings.Cut(layout, " ")
+	for _, cell := range strings.FieldsFunc(layout, func(r rune) bool { return r == '{' || r == '}' || r == '[' || r == ']' }) {
+		parts := strings.Split(strings.Trim(cell, ","), ",")
+		for i := 0; i+3 < len(parts); i++ {
+			if strings.Contains(parts[i], "x") && parts[i+3] == number {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *tmuxSession) Write(data string) error {
+	<-s.ready
+	pane := s.currentPane()
+	if pane == "" {
+		return newSidecarError(errorCodeStartupFailed, "tmux pane is gone")
+	}
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > tmuxCommandBytes {
+			chunk = chunk[:tmuxCommandBytes]
+		}
+		data = data[len(chunk):]
+
+		args := make([]string, 0, len(chunk)+4)
+		args = append(args, "send-keys", "-t", pane, "-H")
+		for i := 0; i < len(chunk); i++ {
+			args = append(args, strconv.FormatUint(uint64(chunk[i]), 16))
+		}
+		s.command(nil, args...)
+	}
+	return nil
+}
+
+// Resize sets the control client's size, which tmux gives the pane's
+// window as it would any client's.
+func (s *tmuxSession) Resize(cols int, rows int) error {
+	s.mu.Lock()
+	s.cols, s.rows = cols, rows
+	known := s.pane != ""
+	s.mu.Unlock()
+	if known {
+		s.command(nil, "refresh-client", "-C", strconv.Itoa(cols)+"x"+strconv.Itoa(rows))
+	}
+	return nil
+}
+
+// Close detaches the control client, leaving the pane to tmux.
+func (s *tmuxSession) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		if s.stdin != nil {
+			_, _ = io.WriteString(s.stdin, "detach-client\n")
+			_ = s.stdin.Close()
+			s.stdin = nil
+		}
+		s.mu.Unlock()
+		s.markReady()
+		time.AfterFunc(outputDrainTimeout, func() { _ = s.cmd.Process.Kill() })
+	})
+	return nil
+}
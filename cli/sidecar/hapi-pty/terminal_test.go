@@ -0,0 +1,608 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadBufferSizerGrowsWhileReadsFillBuffer(t *testing.T) {
+	sizer := newReadBufferSizer(0)
+	for i := 0; i < 10; i++ {
+		sizer.Observe(sizer.size)
+	}
+	if sizer.size != maxReadBufferBytes {
+		t.Fatalf("expected buffer to grow to %d, got %d", maxReadBufferBytes, sizer.size)
+	}
+}
+
+func TestReadBufferSizerShrinksAfterShortReads(t *testing.T) {
+	sizer := newReadBufferSizer(0)
+	sizer.Observe(sizer.size)
+	sizer.Observe(sizer.size)
+	if sizer.size != 4*minReadBufferBytes {
+		t.Fatalf("unexpected grown size: %d", sizer.size)
+	}
+
+	for i := 0; i < readBufferShrinkAfter-1; i++ {
+		sizer.Observe(10)
+	}
+	if sizer.size != 4*minReadBufferBytes {
+		t.Fatalf("buffer shrank too early: %d", sizer.size)
+	}
+	sizer.Observe(10)
+	if sizer.size != 2*minReadBufferBytes {
+		t.Fatalf("expected buffer to halve, got %d", sizer.size)
+	}
+
+	for i := 0; i < 10*readBufferShrinkAfter; i++ {
+		sizer.Observe(10)
+	}
+	if sizer.size != minReadBufferBytes {
+		t.Fatalf("buffer shrank below minimum: %d", sizer.size)
+	}
+}
+
+func TestReadBufferSizerFixedSizeNeverChanges(t *testing.T) {
+	sizer := newReadBufferSizer(1024)
+	sizer.Observe(1024)
+	sizer.Observe(1)
+	if sizer.size != 1024 {
+		t.Fatalf("fixed size changed to %d", sizer.size)
+	}
+}
+
+func TestStreamOutputTranscodesOEMCodePage(t *testing.T) {
+	transcoder, err := newOutputTranscoder(437)
+	if err != nil {
+		t.Fatalf("newOutputTranscoder failed: %v", err)
+	}
+
+	var received bytes.Buffer
+	streamOutput(bytes.NewReader([]byte("C:\\>dir \x81ber\r\n\xc9\xcd\xbb")), 0, transcoder, func(chunk []byte) {
+		received.Write(chunk)
+	})
+	if got := received.String(); got != "C:\\>dir über\r\n╔═╗" {
+		t.Fatalf("unexpected transcoded output: %q", got)
+	}
+
+	for _, codePage := range []int{0, utf8CodePage} {
+		if transcoder, err := newOutputTranscoder(codePage); transcoder != nil || err != nil {
+			t.Fatalf("code page %d should not transcode: %v %v", codePage, transcoder, err)
+		}
+	}
+	if _, err := newOutputTranscoder(-1); err == nil {
+		t.Fatal("expected a negative code page to be rejected")
+	}
+}
+
+func TestStreamOutputUsesLargerReadsForFastStreams(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 256*1024)
+
+	var chunks int
+	var received bytes.Buffer
+	streamOutput(bytes.NewReader(payload), 0, nil, func(chunk []byte) {
+		chunks++
+		received.Write(chunk)
+	})
+
+	if !bytes.Equal(received.Bytes(), payload) {
+		t.Fatalf("received %d bytes, want %d", received.Len(), len(payload))
+	}
+	if fixedChunks := len(payload) / minReadBufferBytes; chunks >= fixedChunks {
+		t.Fatalf("expected fewer than %d reads, got %d", fixedChunks, chunks)
+	}
+}
+
+func TestOutputDrainDeliversOutputBeforeWaitReturns(t *testing.T) {
+	reader, writer := io.Pipe()
+	var mu sync.Mutex
+	var got []string
+	drain := newOutputDrain(func(chunk []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, string(chunk))
+	})
+	go drain.Stream(reader, 64, nil)
+
+	go func() {
+		_, _ = writer.Write([]byte("last line\r\n"))
+		_ = writer.Close()
+	}()
+	if !drain.Wait(time.Second) {
+		t.Fatal("stream should have drained")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 1 || got[0] != "last line\r\n" {
+		t.Fatalf("unexpected drained output: %q", got)
+	}
+}
+
+func TestOutputDrainDropsOutputAfterTimeout(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	outputs := 0
+	drain := newOutputDrain(func([]byte) { outputs++ })
+	go drain.Stream(reader, 64, nil)
+
+	if drain.Wait(10 * time.Millisecond) {
+		t.Fatal("open stream should not count as drained")
+	}
+	_, _ = writer.Write([]byte("late"))
+	if outputs != 0 {
+		t.Fatalf("output after Wait should be dropped, got %d chunks", outputs)
+	}
+}
+
+func TestValidateReadBufferBytes(t *testing.T) {
+	for _, size := range []int{0, 512, readBufferLimitBytes} {
+		if err := validateReadBufferBytes(size); err != nil {
+			t.Fatalf("size %d rejected: %v", size, err)
+		}
+	}
+	for _, size := range []int{-1, 100, readBufferLimitBytes + 1} {
+		if err := validateReadBufferBytes(size); err == nil {
+			t.Fatalf("size %d accepted", size)
+		}
+	}
+
+	if _, err := parseRunFlags([]string{"--read-buffer-bytes", "10"}, io.Discard); err == nil {
+		t.Fatal("expected invalid --read-buffer-bytes to fail")
+	}
+}
+
+func mockOutput(t *testing.T, ts *testSidecar) string {
+	t.Helper()
+
+	var output []byte
+	for _, evt := range ts.eventsOfType(t, eventTypeOutput) {
+		chunk, err := base64.StdEncoding.DecodeString(stringValue(evt["data"]))
+		if err != nil {
+			t.Fatalf("invalid output payload: %v", err)
+		}
+		output = append(output, chunk...)
+	}
+	return string(output)
+}
+
+func TestMergeEnvironmentIgnoresCaseLikeWindows(t *testing.T) {
+	base := []string{"=C:=C:\\", "Path=C:\\Windows", "TEMP=C:\\Temp"}
+	overrides := map[string]string{"PATH": "C:\\bin", "path": "C:\\last", "Foo": "1"}
+
+	merged := mergeEnvironmentNames(base, overrides, strings.EqualFold)
+	want := []string{"=C:=C:\\", "Path=C:\\last", "TEMP=C:\\Temp", "Foo=1"}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("got %q, want %q", merged, want)
+	}
+
+	sorted := sortEnvironment([]string{"windir=C:\\Windows", "Path=x", "=C:=C:\\", "APPDATA=y", "_=z"})
+	want = []string{"=C:=C:\\", "APPDATA=y", "Path=x", "windir=C:\\Windows", "_=z"}
+	if !reflect.DeepEqual(sorted, want) {
+		t.Fatalf("got %q, want %q", sorted, want)
+	}
+}
+
+func TestMockBackendEchoesRepliesAndExits(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{MockBackend: true})
+	ts.handleRequest(openRequest{
+		Type:       requestTypeOpen,
+		TerminalID: "t1",
+		Cols:       80,
+		Rows:       24,
+		Backend:    backendMock,
+		Mock: &mockOptions{
+			Output:  []mockStep{{Data: "welcome\r\n", DelayMs: 5}},
+			Prompt:  "> ",
+			Replies: map[string][]mockStep{"dir": {{Data: "a.txt\r\n"}}},
+		},
+	})
+	if ready := waitForEventOfType(t, ts, eventTypeReady); ready["displayName"] != backendMock {
+		t.Fatalf("unexpected ready event: %+v", ready)
+	}
+
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "dim\x7fr\r\n"})
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "exit 7\r"})
+
+	if exit := waitForEventOfType(t, ts, eventTypeExit); exit["code"] != float64(7) {
+		t.Fatalf("unexpected exit event: %+v", exit)
+	}
+	want := "welcome\r\n> dim\b \br\r\na.txt\r\n> exit 7\r\n"
+	if got := mockOutput(t, ts); got != want {
+		t.Fatalf("unexpected mock output %q, want %q", got, want)
+	}
+}
+
+func TestMockBackendCloseReportsTermination(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{MockBackend: true})
+	ts.handleRequest(openRequest{
+		Type:       requestTypeOpen,
+		TerminalID: "t1",
+		Cols:       80,
+		Rows:       24,
+		Backend:    backendMock,
+		Mock:       &mockOptions{Output: []mockStep{{Data: "never", DelayMs: 60000}}, NoEcho: true},
+	})
+	waitForEventOfType(t, ts, eventTypeReady)
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+
+	if exit := waitForEventOfType(t, ts, eventTypeExit); exit["code"] != float64(mockClosedExitCode) {
+		t.Fatalf("unexpected exit event: %+v", exit)
+	}
+	if got := mockOutput(t, ts); got != "" {
+		t.Fatalf("closed mock still wrote %q", got)
+	}
+}
+
+func TestMockBackendRequiresFlag(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, Backend: backendMock})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t2", Cols: 80, Rows: 24, Backend: "winpty"})
+
+	failures := ts.eventsOfType(t, eventTypeError)
+	if len(failures) != 2 || failures[0]["code"] != errorCodeInvalidRequest || failures[1]["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request errors, got %+v", failures)
+	}
+	if len(ts.terminals) != 0 {
+		t.Fatalf("no terminal should have been opened: %v", ts.terminals)
+	}
+}
+
+func TestTelnetBackendNegotiatesWindowSize(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+
+	ts := newTestSidecar(t, runConfig{})
+	ts.handleRequest(openRequest{
+		Type:       requestTypeOpen,
+		TerminalID: "t1",
+		Cols:       80,
+		Rows:       24,
+		Backend:    backendTelnet,
+		Telnet:     &telnetOptions{Host: "127.0.0.1", Port: addr.Port, TerminalType: "vt100"},
+	})
+	server := <-accepted
+	defer server.Close()
+	_ = server.SetDeadline(time.Now().Add(5 * time.Second))
+	expect := func(want []byte) {
+		t.Helper()
+		got := make([]byte, len(want))
+		if _, err := io.ReadFull(server, got); err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("server read %v, want %v: %v", got, want, err)
+		}
+	}
+
+	expect([]byte{telnetIAC, telnetWill, telnetOptionNAWS})
+	_, _ = server.Write([]byte{
+		telnetIAC, telnetDo, telnetOptionNAWS,
+		telnetIAC, telnetWill, telnetOptionEcho,
+		telnetIAC, telnetDo, telnetOptionTerminalType,
+		telnetIAC, telnetSB, telnetOptionTerminalType, telnetSubnegotiationSend, telnetIAC, telnetSE,
+		telnetIAC, telnetDo, 99,
+		'o', 'k', telnetIAC, telnetIAC,
+	})
+	expect([]byte{telnetIAC, telnetSB, telnetOptionNAWS, 0, 80, 0, 24, telnetIAC, telnetSE})
+	expect([]byte{telnetIAC, telnetDo, telnetOptionEcho})
+	expect([]byte{telnetIAC, telnetWill, telnetOptionTerminalType})
+	expect(append(append([]byte{telnetIAC, telnetSB, telnetOptionTerminalType, telnetSubnegotiationIs}, "vt100"...), telnetIAC, telnetSE))
+	expect([]byte{telnetIAC, telnetWont, 99})
+
+	ts.handleRequest(resizeRequest{Type: requestTypeResize, TerminalID: "t1", Cols: 255, Rows: 30})
+	expect([]byte{telnetIAC, telnetSB, telnetOptionNAWS, 0, 255, 255, 0, 30, telnetIAC, telnetSE})
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "ls\r\xff"})
+	expect([]byte{'l', 's', '\r', 0, telnetIAC, telnetIAC})
+
+	_ = server.Close()
+	waitForEventOfType(t, ts, eventTypeExit)
+	if got := mockOutput(t, ts); got != "ok\xff" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
+
+func TestSerialBackendReconnectsWhenPipeCloses(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	consoles := make(chan net.Conn, 2)
+	dialSerialPipe = func(name string) (io.ReadWriteCloser, error) {
+		if name != `\\.\pipe\vm-com1` {
+			t.Errorf("unexpected pipe %q", name)
+		}
+		select {
+		case <-consoles:
+			return nil, io.EOF
+		default:
+		}
+		client, console := net.Pipe()
+		consoles <- console
+		return client, nil
+	}
+	serialReconnectInterval = time.Millisecond
+	t.Cleanup(func() { dialSerialPipe, serialReconnectInterval = dialBrokerPipe, time.Second })
+	waitForOutput := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for !strings.Contains(mockOutput(t, ts), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q in %q", want, mockOutput(t, ts))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, Backend: backendSerial, Serial: &serialOptions{Pipe: "vm-com1"}})
+	console := <-consoles
+	if _, err := console.Write([]byte("login: ")); err != nil {
+		t.Fatalf("console write failed: %v", err)
+	}
+	waitForOutput("login: ")
+
+	// The VM reboots: its pipe closes, is missing for one dial and returns.
+	consoles <- nil
+	_ = console.Close()
+	waitForOutput("reconnected")
+	console = <-consoles
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "root\r"})
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(console, buf); err != nil || string(buf) != "root\r" {
+		t.Fatalf("unexpected console input %q: %v", buf, err)
+	}
+
+	ts.handleRequest(closeRequest{Type: requestTypeClose, TerminalID: "t1"})
+	if exit := waitForEventOfType(t, ts, eventTypeExit); exit["code"] != float64(0) {
+		t.Fatalf("unexpected exit event: %+v", exit)
+	}
+}
+
+func TestContainerdShellExecsIntoContainerTask(t *testing.T) {
+	lookup := fakeLookup(map[string]string{"ctr": "/usr/bin/ctr"})
+
+	shell, err := containerdShell(openRequest{TerminalID: "t1", Containerd: &containerdOptions{Container: "web"}}, lookup)
+	if err != nil {
+		t.Fatalf("containerdShell failed: %v", err)
+	}
+	want := []string{"--namespace", "default", "tasks", "exec", "--tty", "--exec-id", "hapi-t1", "--", "web", "sh"}
+	if shell.Path != "/usr/bin/ctr" || !reflect.DeepEqual(shell.Args, want) {
+		t.Fatalf("unexpected shell: %+v", shell)
+	}
+
+	shell, err = containerdShell(openRequest{TerminalID: "t1", Containerd: &containerdOptions{
+		Namespace: "k8s.io",
+		Container: "web",
+		ExecID:    "debug",
+		Command:   []string{"bash", "-l"},
+		Cwd:       "/srv",
+		User:      "1000",
+	}}, lookup)
+	if err != nil {
+		t.Fatalf("containerdShell failed: %v", err)
+	}
+	want = []string{"--namespace", "k8s.io", "tasks", "exec", "--tty", "--exec-id", "debug", "--cwd", "/srv", "--user", "1000", "--", "web", "bash", "-l"}
+	if !reflect.DeepEqual(shell.Args, want) {
+		t.Fatalf("unexpected args: %v", shell.Args)
+	}
+
+	if _, err := containerdShell(openRequest{TerminalID: "t1"}, lookup); err == nil {
+		t.Fatal("expected a request without a container to be refused")
+	}
+	_, err = containerdShell(openRequest{TerminalID: "t1", Containerd: &containerdOptions{Container: "web"}}, fakeLookup(nil))
+	var serr *sidecarError
+	if !errors.As(err, &serr) || serr.Code != errorCodeShellNotFound {
+		t.Fatalf("expected shell_not_found without ctr, got %v", err)
+	}
+}
+
+func TestTmuxShellRunsControlClientLocallyOrOverSSH(t *testing.T) {
+	lookup := fakeLookup(map[string]string{"tmux": "/usr/bin/tmux", "ssh": "/usr/bin/ssh"})
+
+	local, err := tmuxShell(&tmuxOptions{Session: "work", Socket: "hapi"}, lookup)
+	if err != nil {
+		t.Fatalf("tmuxShell failed: %v", err)
+	}
+	if local.Path != "/usr/bin/tmux" || !reflect.DeepEqual(local.Args, []string{"-L", "hapi", "-C", "attach-session", "-t", "work"}) {
+		t.Fatalf("unexpected local client: %+v", local)
+	}
+
+	remote, err := tmuxShell(&tmuxOptions{Session: "it's", SSH: "me@host"}, lookup)
+	if err != nil {
+		t.Fatalf("tmuxShell failed: %v", err)
+	}
+	want := []string{"-T", "me@host", `'tmux' '-C' 'attach-session' '-t' 'it'\''s'`}
+	if remote.Path != "/usr/bin/ssh" || !reflect.DeepEqual(remote.Args, want) {
+		t.Fatalf("unexpected remote client: %+v", remote)
+	}
+
+	for _, opts := range []*tmuxOptions{nil, {Session: "work", Pane: "3"}, {Session: "work", SSH: "-oProxyCommand=x"}} {
+		if _, err := tmuxShell(opts, lookup); err == nil {
+			t.Fatalf("expected %+v to be refused", opts)
+		}
+	}
+}
+
+func TestListTmuxPanesParsesListPanes(t *testing.T) {
+	lookup := fakeLookup(map[string]string{"tmux": "/usr/bin/tmux"})
+	var gotArgs []string
+	run := func(path string, args ...string) (string, error) {
+		gotArgs = args
+		return "%0\t@0\tedit\t0\t80\t24\t11\tvim\tmain.go\n" +
+			"%3\t@1\tlogs\t1\t40\t24\t01\ttail\ta\tb\n" +
+			"garbage\n", nil
+	}
+
+	panes, err := listTmuxPanes(&tmuxOptions{Session: "work", Socket: "hapi", Pane: "%9"}, lookup, run)
+	if err != nil {
+		t.Fatalf("listTmuxPanes failed: %v", err)
+	}
+	if want := []string{"-L", "hapi", "list-panes", "-s", "-t", "work", "-F", tmuxPaneFormat}; !reflect.DeepEqual(gotArgs, want) {
+		t.Fatalf("unexpected args %q", gotArgs)
+	}
+	want := []tmuxPaneInfo{
+		{Pane: "%0", Window: "@0", WindowName: "edit", Cols: 80, Rows: 24, Active: true, Command: "vim", Title: "main.go"},
+		{Pane: "%3", Window: "@1", WindowName: "logs", Index: 1, Cols: 40, Rows: 24, Command: "tail", Title: "a\tb"},
+	}
+	if !reflect.DeepEqual(panes, want) {
+		t.Fatalf("unexpected panes %+v", panes)
+	}
+
+	failing := func(string, ...string) (string, error) {
+		return "can't find session: work\n", errors.New("exit status 1")
+	}
+	_, err = listTmuxPanes(&tmuxOptions{Session: "work"}, lookup, failing)
+	var serr *sidecarError
+	if !errors.As(err, &serr) || serr.Code != errorCodeStartupFailed || !strings.Contains(serr.Message, "can't find session") {
+		t.Fatalf("expected startup_failed with tmux's message, got %v", err)
+	}
+	if _, err := listTmuxPanes(&tmuxOptions{}, lookup, run); !errors.As(err, &serr) || serr.Code != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request without a session, got %v", err)
+	}
+}
+
+func TestTmuxWriteFailsOnceControlClientEnds(t *testing.T) {
+	session := &tmuxSession{ready: make(chan struct{})}
+	session.read(strings.NewReader(""))
+
+	done := make(chan error, 1)
+	go func() { done <- session.Write("ls\r") }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected the write to fail without a pane")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("write blocked after the control client ended")
+	}
+}
+
+func TestTmuxControlModeParsing(t *testing.T) {
+	if got := string(unescapeTmuxOutput(`a\033[0m\134b\015\012`)); got != "a\x1b[0m\\b\r\n" {
+		t.Fatalf("unexpected unescaped output: %q", got)
+	}
+	layout := "b25f,80x24,0,0{40x24,0,0,1,39x24,41,0,12}"
+	if !tmuxLayoutHasPane(layout, "%12") || !tmuxLayoutHasPane(layout, "%1") || tmuxLayoutHasPane(layout, "%2") {
+		t.Fatalf("unexpected panes in layout %q", layout)
+	}
+	if got := tmuxQuote(`say "$HOME\"`); got != `"say \"\$HOME\\\""` {
+		t.Fatalf("unexpected quoting: %s", got)
+	}
+}
+
+func TestConPTYCreateFlagsPassUnknownFlagsThrough(t *testing.T) {
+	if flags := conptyCreateFlags(openRequest{}); flags != 0 {
+		t.Fatalf("expected no flags by default, got %#x", flags)
+	}
+	flags := conptyCreateFlags(openRequest{InheritCursor: true, ConPTYFlags: 0x40})
+	if flags != pseudoConsoleInheritCursor|0x40 {
+		t.Fatalf("unexpected flags: %#x", flags)
+	}
+}
+
+func TestPipesSessionRunsShellWithoutConsole(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run through pipes")
+	}
+
+	var mu sync.Mutex
+	var output bytes.Buffer
+	exited := make(chan int, 1)
+	session, err := newPipesTerminalSession(
+		openRequest{TerminalID: "t1", environ: []string{"GREETING=hi"}},
+		resolvedShell{Name: "sh", Path: sh},
+		terminalCallbacks{
+			Output: func(chunk []byte) {
+				mu.Lock()
+				output.Write(chunk)
+				mu.Unlock()
+			},
+			Exit: func(code int) { exited <- code },
+		},
+		func(_ string, task func()) { go task() },
+	)
+	if err != nil {
+		t.Fatalf("newPipesTerminalSession failed: %v", err)
+	}
+	if err := session.Resize(100, 30); err != nil {
+		t.Fatalf("resize should be ignored, got %v", err)
+	}
+	if err := session.Write("echo $GREETING; echo oops >&2; exit 3\n"); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	select {
+	case code := <-exited:
+		if code != 3 {
+			t.Fatalf("unexpected exit code %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		_ = session.Close()
+		t.Fatal("shell did not exit")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := output.String(); !strings.Contains(got, "hi\n") || !strings.Contains(got, "oops\n") {
+		t.Fatalf("unexpected output: %q", got)
+	}
+
+	if _, err := newPipesTerminalSession(openRequest{Privilege: privilegeLow}, resolvedShell{Path: sh}, terminalCallbacks{}, nil); err == nil {
+		t.Fatal("expected the pipes backend to refuse a privilege change")
+	}
+}
+
+func TestResizeDebouncerCoalescesBurstAndSkipsNoOps(t *testing.T) {
+	var mu sync.Mutex
+	var applied [][2]int
+	debouncer := newResizeDebouncer(20*time.Millisecond, 80, 24, func(cols int, rows int) {
+		mu.Lock()
+		defer mu.Unlock()
+		applied = append(applied, [2]int{cols, rows})
+	})
+	defer debouncer.Stop()
+
+	debouncer.Resize(80, 24)
+	debouncer.Resize(90, 30)
+	for cols := 91; cols <= 120; cols++ {
+		debouncer.Resize(cols, 30)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := append([][2]int(nil), applied...)
+		mu.Unlock()
+		if len(got) == 2 {
+			if want := [][2]int{{90, 30}, {120, 30}}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("expected resizes %v, got %v", want, got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("final size was not applied, got %v", got)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	debouncer.Resize(120, 30)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(applied) != 2 {
+		t.Fatalf("expected the unchanged size to be skipped, got %v", applied)
+	}
+}
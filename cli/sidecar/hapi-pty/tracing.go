@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	otelEndpointEnv       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otelTracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	otelHeadersEnv        = "OTEL_EXPORTER_OTLP_HEADERS"
+	otelProtocolEnv       = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	otelServiceNameEnv    = "OTEL_SERVICE_NAME"
+	otelSDKDisabledEnv    = "OTEL_SDK_DISABLED"
+
+	// otelBatchSize spans, or otelFlushInterval, trigger an export.
+	otelBatchSize     = 256
+	otelFlushInterval = 5 * time.Second
+	otelExportTimeout = 10 * time.Second
+
+	otelSpanKindServer   = 2
+	otelStatusCodeError  = 2
+	otelDefaultService   = "hapi-pty"
+	otelProtocolHTTPJSON = "http/json"
+)
+
+// spanContext identifies a span in W3C trace context terms.
+type spanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	// Sampled mirrors the traceparent flag; unsampled requests get no spans.
+	Sampled bool
+}
+
+func (c spanContext) IsValid() bool {
+	return c.TraceID != [16]byte{} && c.SpanID != [8]byte{}
+}
+
+// parseTraceparent reads a W3C traceparent header value.
+func parseTraceparent(value string) (spanContext, bool) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return spanContext{}, false
+	}
+
+	var ctx spanContext
+	if _, err := hex.Decode(ctx.TraceID[:], []byte(parts[1])); err != nil {
+		return spanContext{}, false
+	}
+	if _, err := hex.Decode(ctx.SpanID[:], []byte(parts[2])); err != nil {
+		return spanContext{}, false
+	}
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil || !ctx.IsValid() {
+		return spanContext{}, false
+	}
+	ctx.Sampled = flags&1 == 1
+	return ctx, true
+}
+
+// otelTracer records terminal lifecycle spans and exports them in batches
+// over OTLP/HTTP with JSON encoding. A nil tracer records nothing.
+type otelTracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+
+	mu      sync.Mutex
+	pending []map[string]any
+	flush   chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// newOTelTracerFromEnv configures a tracer from the standard OTEL_* variables;
+// it returns nil when no endpoint is set or the SDK is disabled.
+func newOTelTracerFromEnv(getenv func(string) string) (*otelTracer, error) {
+	if strings.EqualFold(getenv(otelSDKDisabledEnv), "true") {
+		return nil, nil
+	}
+
+	endpoint := getenv(otelTracesEndpointEnv)
+	if endpoint == "" {
+		base := getenv(otelEndpointEnv)
+		if base == "" {
+			return nil, nil
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	if protocol := getenv(otelProtocolEnv); protocol != "" && protocol != otelProtocolHTTPJSON {
+		return nil, fmt.Errorf("%s=%s is not supported, tracing needs %s", otelProtocolEnv, protocol, otelProtocolHTTPJSON)
+	}
+
+	headers := map[string]string{}
+	for _, item := range strings.Split(getenv(otelHeadersEnv), ",") {
+		if name, value, ok := strings.Cut(item, "="); ok && strings.TrimSpace(name) != "" {
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	service := getenv(otelServiceNameEnv)
+	if service == "" {
+		service = otelDefaultService
+	}
+
+	t := &otelTracer{
+		endpoint: endpoint,
+		headers:  headers,
+		service:  service,
+		client:   &http.Client{Timeout: otelExportTimeout},
+		flush:    make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.run()
+	return t, nil
+}
+
+// Start begins a span under parent, or a new trace when parent is invalid.
+func (t *otelTracer) Start(name string, parent spanContext) *otelSpan {
+	if t == nil {
+		return nil
+	}
+
+	span := &otelSpan{tracer: t, name: name, parent: parent, start: time.Now(), attributes: map[string]any{}}
+	span.context.TraceID = parent.TraceID
+	if !parent.IsValid() {
+		_, _ = rand.Read(span.context.TraceID[:])
+	}
+	_, _ = rand.Read(span.context.SpanID[:])
+	return span
+}
+
+// StartRequest begins a span for a request carrying an optional traceparent,
+// or returns nil when the host did not sample the request.
+func (t *otelTracer) StartRequest(name string, traceparent string, terminalID string) *otelSpan {
+	parent, ok := parseTraceparent(traceparent)
+	if ok && !parent.Sampled {
+		return nil
+	}
+	span := t.Start(name, parent)
+	span.SetAttribute("terminal.id", terminalID)
+	return span
+}
+
+func (t *otelTracer) enqueue(span map[string]any) {
+	t.mu.Lock()
+	t.pending = append(t.pending, span)
+	full := len(t.pending) >= otelBatchSize
+	t.mu.Unlock()
+
+	if full {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *otelTracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(otelFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.flush:
+		case <-t.stop:
+			t.export()
+			return
+		}
+		t.export()
+	}
+}
+
+// Shutdown exports the spans still pending.
+func (t *otelTracer) Shutdown() {
+	if t == nil {
+		return
+	}
+	close(t.stop)
+	<-t.done
+}
+
+func (t *otelTracer) export() {
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": t.service}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": otelDefaultService, "version": sidecarVersion},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	// Telemetry is best effort; a failed export drops the batch.
+	if resp, err := t.client.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// otelSpan is one in-flight span. Its methods ignore a nil receiver, so
+// call sites need no checks when tracing is off.
+type otelSpan struct {
+	tracer     *otelTracer
+	name       string
+	context    spanContext
+	parent     spanContext
+	start      time.Time
+	attributes map[string]any
+	endOnce    sync.Once
+}
+
+// StartChild begins a span under s; it is nil when s is.
+func (s *otelSpan) StartChild(name string) *otelSpan {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.Start(name, s.context)
+}
+
+func (s *otelSpan) Context() spanContext {
+	if s == nil {
+		return spanContext{}
+	}
+	return s.context
+}
+
+// SetAttribute records a string, bool or integer attribute.
+func (s *otelSpan) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.attributes[key] = value
+}
+
+// End finishes the span, marking it failed when err is set.
+func (s *otelSpan) End(err error) {
+	if s == nil {
+		return
+	}
+
+	s.endOnce.Do(func() {
+		s.tracer.mu.Lock()
+		span := map[string]any{
+			"traceId":           hex.EncodeToString(s.context.TraceID[:]),
+			"spanId":            hex.EncodeToString(s.context.SpanID[:]),
+			"name":              s.name,
+			"kind":              otelSpanKindServer,
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(time.Now().UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attributes),
+		}
+		s.tracer.mu.Unlock()
+		if s.parent.IsValid() {
+			span["parentSpanId"] = hex.EncodeToString(s.parent.SpanID[:])
+		}
+		if err != nil {
+			serr := sidecarErrorFrom(err, errorCodeUnknown)
+			span["status"] = map[string]any{"code": otelStatusCodeError, "message": serr.Code + ": " + serr.Message}
+		}
+		s.tracer.enqueue(span)
+	})
+}
+
+// otlpAttributes encodes attributes as OTLP JSON key/value pairs.
+func otlpAttributes(attributes map[string]any) []any {
+	encoded := make([]any, 0, len(attributes))
+	for key, value := range attributes {
+		var typed map[string]any
+		switch v := value.(type) {
+		case string:
+			typed = map[string]any{"stringValue": v}
+		case bool:
+			typed = map[string]any{"boolValue": v}
+		case int:
+			typed = map[string]any{"intValue": strconv.Itoa(v)}
+		default:
+			typed = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		encoded = append(encoded, map[string]any{"key": key, "value": typed})
+	}
+	return encoded
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	ctx, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || !ctx.Sampled || hex.EncodeToString(ctx.SpanID[:]) != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected context: %+v %v", ctx, ok)
+	}
+	if ctx, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"); !ok || ctx.Sampled {
+		t.Fatalf("unsampled parent misread: %+v %v", ctx, ok)
+	}
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736aa-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+	} {
+		if _, ok := parseTraceparent(value); ok {
+			t.Fatalf("%q: expected rejection", value)
+		}
+	}
+}
+
+func TestNewOTelTracerFromEnv(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	if tracer, err := newOTelTracerFromEnv(env(nil)); tracer != nil || err != nil {
+		t.Fatalf("tracing enabled without an endpoint: %v %v", tracer, err)
+	}
+	if tracer, _ := newOTelTracerFromEnv(env(map[string]string{otelEndpointEnv: "http://x", otelSDKDisabledEnv: "true"})); tracer != nil {
+		t.Fatal("tracing enabled although disabled")
+	}
+	if _, err := newOTelTracerFromEnv(env(map[string]string{otelEndpointEnv: "http://x", otelProtocolEnv: "grpc"})); err == nil {
+		t.Fatal("expected unsupported protocol error")
+	}
+
+	tracer, err := newOTelTracerFromEnv(env(map[string]string{otelEndpointEnv: "http://collector:4318/", otelHeadersEnv: "api-key=abc, x=y"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracer.Shutdown()
+	if tracer.endpoint != "http://collector:4318/v1/traces" || tracer.headers["api-key"] != "abc" || tracer.headers["x"] != "y" {
+		t.Fatalf("unexpected tracer config: %s %v", tracer.endpoint, tracer.headers)
+	}
+}
+
+func TestTracerExportsTerminalLifecycleSpans(t *testing.T) {
+	bodies := make(chan []byte, 4)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "token" {
+			t.Errorf("unexpected export %s %v", r.URL.Path, r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer collector.Close()
+
+	tracer, err := newOTelTracerFromEnv(func(name string) string {
+		return map[string]string{otelEndpointEnv: collector.URL, otelHeadersEnv: "Authorization=token"}[name]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ts := newTestSidecar(t, runConfig{Tracer: tracer})
+	ts.handleRequest(openRequest{Type: requestTypeOpen, TerminalID: "t1", Cols: 80, Rows: 24, Traceparent: traceparent})
+	ts.handleRequest(writeRequest{Type: requestTypeWrite, TerminalID: "t1", Data: "dir\r", Traceparent: traceparent})
+	ts.handleRequest(resizeRequest{Type: requestTypeResize, TerminalID: "t1", Cols: 100, Rows: 30, Traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"})
+	ts.terminals["t1"].callbacks.Exit(3)
+	tracer.Shutdown()
+
+	var export struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID      string `json:"traceId"`
+					SpanID       string `json:"spanId"`
+					ParentSpanID string `json:"parentSpanId"`
+					Name         string `json:"name"`
+					Attributes   []struct {
+						Key   string
+						Value map[string]any
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(<-bodies, &export); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := export.ResourceSpans[0].ScopeSpans[0].Spans
+	byName := map[string]int{}
+	for i, span := range spans {
+		byName[span.Name] = i
+		if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Fatalf("span %s is not in the host's trace: %s", span.Name, span.TraceID)
+		}
+	}
+	if len(spans) != 3 {
+		t.Fatalf("expected open, write and session spans, got %+v", spans)
+	}
+	open, session := spans[byName["terminal.open"]], spans[byName["terminal.session"]]
+	if open.ParentSpanID != "00f067aa0ba902b7" || spans[byName["terminal.write"]].ParentSpanID != "00f067aa0ba902b7" {
+		t.Fatalf("request spans are not parented by the traceparent: %+v", spans)
+	}
+	if session.ParentSpanID != open.SpanID {
+		t.Fatalf("session span is not a child of the open span: %+v", session)
+	}
+	exitCode := ""
+	for _, attr := range session.Attributes {
+		if attr.Key == "exit.code" {
+			exitCode, _ = attr.Value["intValue"].(string)
+		}
+	}
+	if exitCode != "3" {
+		t.Fatalf("session span lacks the exit code: %+v", session.Attributes)
+	}
+}
@@ -0,0 +1,1324 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	authTokenEnv = "HAPI_PTY_AUTH_TOKEN"
+	// authHandshakeTimeout bounds how long a client may hold the listener
+	// before presenting its token.
+	authHandshakeTimeout = 10 * time.Second
+	maxAuthRequestBytes  = 4096
+)
+
+// loadAuthToken reads the shared secret from path, or from the environment
+// when path is empty. Tokens are never accepted on the command line, where
+// other users could read them from the process list.
+func loadAuthToken(path string) (string, error) {
+	token := os.Getenv(authTokenEnv)
+	if path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read auth token: %w", err)
+		}
+		token = string(content)
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", fmt.Errorf("--listen requires an auth token in --auth-token-file or $%s", authTokenEnv)
+	}
+	return token, nil
+}
+
+// listenTransport opens the --listen socket, wrapped in TLS when configured.
+// A grpc:// address is left to serveGRPC, whose gRPC server does its own
+// TLS; a ws:// address with TLS is served as wss.
+func listenTransport(cfg runConfig) (net.Listener, error) {
+	address, isGRPC := strings.CutPrefix(cfg.Listen, grpcListenScheme)
+	address = strings.TrimPrefix(address, wsListenScheme)
+	var tlsConfig *tls.Config
+	if cfg.TLSCert != "" && !isGRPC {
+		var err error
+		if tlsConfig, err = loadTLSConfig(cfg.TLSCert, cfg.TLSKey, cfg.TLSClientCA); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	return listener, nil
+}
+
+func loadTLSConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// serveListener serves one client at a time. A client that disconnects or
+// idles out frees the listener for the next one; a shutdown request stops
+// it. So does closing stop, which disconnects the current client, closing
+// its terminals as any disconnect does.
+func serveListener(listener net.Listener, token string, cfg runConfig, stderr io.Writer, stop <-chan struct{}) int {
+	var mu sync.Mutex
+	var current net.Conn
+	stopped := false
+	if stop != nil {
+		go func() {
+			<-stop
+			mu.Lock()
+			stopped = true
+			if current != nil {
+				_ = current.Close()
+			}
+			mu.Unlock()
+			_ = listener.Close()
+		}()
+	}
+	isStopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stopped
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if isStopped() {
+				return 0
+			}
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		mu.Lock()
+		if stopped {
+			mu.Unlock()
+			_ = conn.Close()
+			return 0
+		}
+		current = conn
+		mu.Unlock()
+
+		exitCode, shutdown := serveConn(conn, token, cfg)
+		mu.Lock()
+		current = nil
+		mu.Unlock()
+		if shutdown {
+			return exitCode
+		}
+		if isStopped() {
+			return 0
+		}
+	}
+}
+
+func serveConn(conn net.Conn, token string, cfg runConfig) (int, bool) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	_, err := authenticate(conn, reader, token)
+	cfg.Peer = peerIdentity(conn)
+	if err != nil {
+		cfg.Audit.Record(auditRecord{Client: cfg.Peer, Request: requestTypeAuth}, newSidecarError(errorCodeUnauthorized, "%s", err))
+		_ = writeNDJSONLine(conn, errorEvent{
+			Type:    eventTypeError,
+			Code:    errorCodeUnauthorized,
+			Message: err.Error(),
+		})
+		return 0, false
+	}
+	cfg.Audit.Record(auditRecord{Client: cfg.Peer, Request: requestTypeAuth}, nil)
+
+	exitCode := runSidecar(reader, conn, cfg)
+	return exitCode, exitCode == 0
+}
+
+// peerIdentity names a client by its address, prefixed with the subject of
+// its verified TLS client certificate when it presented one.
+func peerIdentity(conn net.Conn) string {
+	identity := conn.RemoteAddr().String()
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if certs := tlsConn.ConnectionState().PeerCertificates; len(certs) > 0 {
+			identity = certs[0].Subject.CommonName + "@" + identity
+		}
+	}
+	return identity
+}
+
+// authenticate requires the first line to be an auth request carrying token,
+// and returns the client ID it names. The handshake is always JSON, whatever
+// encoding the session uses after it.
+func authenticate(conn net.Conn, reader *bufio.Reader, token string) (string, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(authHandshakeTimeout)); err != nil {
+		return "", err
+	}
+	line, tooLarge, err := readRequestLine(reader, maxAuthRequestBytes)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("auth handshake failed: %w", err)
+	}
+	if tooLarge {
+		return "", errors.New("auth request too large")
+	}
+	if err := conn.SetReadDeadline(time.Time{}); err != nil {
+		return "", err
+	}
+
+	var req authRequest
+	if err := json.Unmarshal(line, &req); err != nil || req.Type != requestTypeAuth {
+		return "", errors.New("first request must be an auth request")
+	}
+	if subtle.ConstantTimeCompare([]byte(req.Token), []byte(token)) != 1 {
+		return "", errors.New("invalid auth token")
+	}
+	return req.ClientID, nil
+}
+
+const (
+	clientDisconnectClose    = "close"
+	clientDisconnectPreserve = "preserve"
+	// clientNamespaceTag carries a namespaced terminal's client ID, so the
+	// selectors of list, close-all and broadcast can be confined to it.
+	clientNamespaceTag = "hapi.client"
+	maxClientIDBytes   = 64
+	// clientWriteTimeout disconnects a client that stops reading rather
+	// than let it stall every other client's events.
+	clientWriteTimeout = 10 * time.Second
+)
+
+// clientManager lets one sidecar serve concurrent --listen clients. Their
+// requests run on one main loop. An event goes to the client it is
+// addressed to or whose request it answers, or else to the clients owning
+// or attached to its terminal. An event naming neither goes to the client
+// being handled, or to every client between requests; tasks answering off
+// the main loop address their events instead, see sidecar.replyTo.
+// Request IDs are prefixed with the client's ID on the way in and stripped
+// on the way out, so an answer finds its client even when emitted after the
+// request returned. With namespaces, terminal IDs are prefixed too and each
+// client sees only its own terminals.
+type clientManager struct {
+	s          *sidecar
+	namespaces bool
+	policy     string
+	encode     func(io.Writer, any) error
+
+	mu       sync.Mutex
+	clients  map[string]*managedClient
+	owners   map[string]string
+	attached map[string]map[string]bool
+	// current is the client whose request the main loop is handling, and
+	// currentTerminal the terminal that request names.
+	current         *managedClient
+	currentTerminal string
+	generated       int
+}
+
+type managedClient struct {
+	id   string
+	conn net.Conn
+	// info is set by the client's hello request.
+	info          atomic.Pointer[clientInfo]
+	subscriptions *eventSubscriptions
+}
+
+// clientMessage is a request line from a client, or its arrival or
+// departure.
+type clientMessage struct {
+	client   *managedClient
+	line     []byte
+	tooLarge bool
+	invalid  error
+	joined   bool
+	left     bool
+}
+
+func newClientManager(s *sidecar) *clientManager {
+	m := &clientManager{
+		s:          s,
+		namespaces: s.cfg.ClientNamespaces,
+		policy:     s.cfg.ClientDisconnect,
+		encode:     s.writer.encode,
+		clients:    make(map[string]*managedClient),
+		owners:     make(map[string]string),
+		attached:   make(map[string]map[string]bool),
+	}
+	if m.encode == nil {
+		m.encode = writeNDJSONLine
+	}
+	s.writer.encode = m.route
+	s.clients = m
+	return m
+}
+
+// serveClientsConcurrently is serveListener for --multi-client.
+func serveClientsConcurrently(listener net.Listener, token string, cfg runConfig, stderr io.Writer, stop <-chan struct{}) (exitCode int) {
+	cfg = withRuntimeDefaults(cfg)
+	s := newSidecar(cfg, io.Discard)
+	defer s.recoverMainLoop(&exitCode)
+	defer cfg.Debug.Track(s)()
+	m := newClientManager(s)
+
+	messages := make(chan clientMessage, 64)
+	acceptErr := make(chan error, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				acceptErr <- err
+				return
+			}
+			go m.serveClient(conn, token, messages)
+		}
+	}()
+	return m.run(messages, acceptErr, func() { _ = listener.Close() }, stderr, stop)
+}
+
+// run is the main loop of concurrent clients. It handles their messages
+// until a request ends the sidecar, stop is closed or the clients can no
+// longer be accepted, as acceptErr reports.
+func (m *clientManager) run(messages <-chan clientMessage, acceptErr <-chan error, closeListener func(), stderr io.Writer, stop <-chan struct{}) int {
+	s := m.s
+	var heartbeats <-chan time.Time
+	if s.cfg.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(s.cfg.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeats = ticker.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			closeListener()
+			m.disconnectAll()
+			s.closeAllTerminals()
+			return 0
+		case err := <-acceptErr:
+			fmt.Fprintln(stderr, err)
+			m.disconnectAll()
+			s.closeAllTerminals()
+			return 1
+		case <-heartbeats:
+			s.emitHeartbeat()
+		case msg := <-messages:
+			if exitCode, done := m.handle(msg); done {
+				closeListener()
+				m.disconnectAll()
+				return exitCode
+			}
+		}
+	}
+}
+
+// serveClient authenticates conn and feeds its requests to the main loop.
+func (m *clientManager) serveClient(conn net.Conn, token string, messages chan<- clientMessage) {
+	cfg := m.s.cfg
+	reader := bufio.NewReader(conn)
+	clientID, err := authenticate(conn, reader, token)
+	peer := peerIdentity(conn)
+	var client *managedClient
+	if err == nil {
+		client, err = m.join(clientID, conn)
+	}
+	if err != nil {
+		cfg.Audit.Record(auditRecord{Client: peer, Request: requestTypeAuth}, newSidecarError(errorCodeUnauthorized, "%s", err))
+		_ = writeNDJSONLine(conn, errorEvent{
+			Type:    eventTypeError,
+			Code:    errorCodeUnauthorized,
+			Message: err.Error(),
+		})
+		_ = conn.Close()
+		return
+	}
+	cfg.Audit.Record(auditRecord{Client: peer, Request: requestTypeAuth}, nil)
+	m.feed(client, startRequestReader(reader, cfg.Encoding, cfg.MaxRequestBytes), messages)
+}
+
+// feed passes the requests client sends, as read from requests, to the main
+// loop until it disconnects or stays silent for the idle timeout.
+func (m *clientManager) feed(client *managedClient, requests <-chan scannerMessage, messages chan<- clientMessage) {
+	cfg := m.s.cfg
+	messages <- clientMessage{client: client, joined: true}
+	liveness := cfg.IdleTimeout
+	if cfg.PingInterval > 0 {
+		liveness = cfg.PingInterval * missedPingLimit
+	}
+	idle := time.AfterFunc(liveness, func() { _ = client.conn.Close() })
+	defer idle.Stop()
+	for msg := range requests {
+		if msg.Done {
+			break
+		}
+		if cfg.PingInterval == 0 || isPingLine(msg.Line) {
+			idle.Reset(liveness)
+		}
+		messages <- clientMessage{client: client, line: msg.Line, tooLarge: msg.TooLarge, invalid: msg.Invalid}
+	}
+	messages <- clientMessage{client: client, left: true}
+}
+
+// join registers a client under id, or under a generated ID when it named
+// none.
+func (m *clientManager) join(id string, conn net.Conn) (*managedClient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id == "" {
+		for id == "" || m.clients[id] != nil {
+			m.generated++
+			id = fmt.Sprintf("client-%d", m.generated)
+		}
+	} else if err := validateClientID(id); err != nil {
+		return nil, err
+	} else if m.clients[id] != nil {
+		return nil, fmt.Errorf("client %q is already connected", id)
+	}
+
+	client := &managedClient{id: id, conn: conn, subscriptions: newEventSubscriptions()}
+	m.clients[id] = client
+	return client, nil
+}
+
+func isPingLine(line []byte) bool {
+	req, err := decodeRequestLine(line)
+	_, isPing := req.(pingRequest)
+	return err == nil && isPing
+}
+
+func validateClientID(id string) error {
+	if len(id) > maxClientIDBytes {
+		return fmt.Errorf("clientId exceeds %d bytes", maxClientIDBytes)
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("clientId %q may only contain letters, digits, '-', '_' and '.'", id)
+		}
+	}
+	return nil
+}
+
+// handle runs one message on the main loop.
+func (m *clientManager) handle(msg clientMessage) (int, bool) {
+	switch {
+	case msg.joined:
+		m.setCurrent(msg.client, "")
+		m.s.emitHello()
+		m.setCurrent(nil, "")
+	case msg.left:
+		m.leave(msg.client)
+	case msg.tooLarge:
+		m.setCurrent(msg.client, "")
+		m.s.emitError("", errorCodeRequestTooLarge, fmt.Sprintf("request exceeds %d bytes", m.s.cfg.MaxRequestBytes))
+		m.setCurrent(nil, "")
+	case msg.invalid != nil:
+		m.setCurrent(msg.client, "")
+		m.s.emitRequestFailure("", "", msg.invalid, errorCodeUnknown)
+		m.setCurrent(nil, "")
+	default:
+		return m.dispatch(msg.client, msg.line)
+	}
+	return 0, false
+}
+
+func (m *clientManager) dispatch(client *managedClient, line []byte) (int, bool) {
+	s := m.s
+	s.cfg.Trace.Inbound(line)
+	s.history.Inbound(line)
+	line, requestID, err := s.wireRequestLine(line)
+	if requestID != "" {
+		requestID = client.id + ":" + requestID
+	}
+	var req request
+	if err == nil {
+		req, err = decodeRequestLine(m.scopeRequest(client, line))
+	}
+	terminalID := ""
+	if err == nil {
+		terminalID = stringField(req, "TerminalID")
+	}
+	m.setCurrent(client, terminalID)
+	defer m.setCurrent(nil, "")
+	if err != nil {
+		s.emitRequestFailure("", requestID, err, errorCodeUnknown)
+		return 0, false
+	}
+	if _, ok := req.(shutdownRequest); ok && m.namespaces {
+		s.emitFailure("", newSidecarError(errorCodeInvalidRequest, "shutdown would close other clients' terminals"), errorCodeInvalidRequest)
+		return 0, false
+	}
+
+	open, isOpen := req.(openRequest)
+	if isOpen {
+		m.claim(client, open)
+	}
+	exitCode, done := s.handleRequest(req)
+	if isOpen {
+		m.settle(client, open.TerminalID)
+	}
+	s.acknowledgeCall(req, requestID)
+	return exitCode, done
+}
+
+// claim makes client the owner of the terminal open creates, or attaches
+// it to the one open finds.
+func (m *clientManager) claim(client *managedClient, open openRequest) {
+	s := m.s
+	s.mu.Lock()
+	_, exists := s.terminals[open.TerminalID]
+	s.mu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case !exists:
+		m.owners[open.TerminalID] = client.id
+	case open.AttachIfExists:
+		if m.owners[open.TerminalID] == "" {
+			m.owners[open.TerminalID] = client.id
+		} else if m.owners[open.TerminalID] != client.id {
+			if m.attached[open.TerminalID] == nil {
+				m.attached[open.TerminalID] = make(map[string]bool)
+			}
+			m.attached[open.TerminalID][client.id] = true
+		}
+	}
+}
+
+// settle drops the claim of an open that failed.
+func (m *clientManager) settle(client *managedClient, terminalID string) {
+	s := m.s
+	s.mu.Lock()
+	_, exists := s.terminals[terminalID]
+	s.mu.Unlock()
+	if exists {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owners[terminalID] == client.id {
+		delete(m.owners, terminalID)
+	}
+}
+
+// leave unregisters client, closing the terminals it owns or, under the
+// preserve policy, leaving them to whoever attaches next.
+func (m *clientManager) leave(client *managedClient) {
+	_ = client.conn.Close()
+
+	m.mu.Lock()
+	delete(m.clients, client.id)
+	owned := make(map[string]bool)
+	for terminalID, owner := range m.owners {
+		if owner == client.id {
+			owned[terminalID] = true
+			delete(m.owners, terminalID)
+		}
+	}
+	for _, clients := range m.attached {
+		delete(clients, client.id)
+	}
+	m.mu.Unlock()
+
+	if m.policy == clientDisconnectPreserve || len(owned) == 0 {
+		return
+	}
+	for _, entry := range m.s.takeTerminals(func(entry *terminalEntry) bool { return owned[entry.id] }) {
+		entry.release("terminal closed before pattern matched")
+		_ = entry.session.Close()
+	}
+}
+
+func (m *clientManager) disconnectAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, client := range m.clients {
+		_ = client.conn.Close()
+	}
+}
+
+// identify records info for the client being handled.
+func (m *clientManager) identify(info *clientInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.current != nil {
+		info.ID = m.current.id
+		m.current.info.Store(info)
+	}
+}
+
+func (m *clientManager) currentSubscriptions() *eventSubscriptions {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.current == nil {
+		return nil
+	}
+	return m.current.subscriptions
+}
+
+func (m *clientManager) currentInfo() *clientInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.current == nil {
+		return nil
+	}
+	return m.current.info.Load()
+}
+
+// infos describes the connected clients that sent a hello request.
+func (m *clientManager) infos() []clientInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var infos []clientInfo
+	for _, client := range m.clients {
+		if info := client.info.Load(); info != nil {
+			infos = append(infos, *info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+func (m *clientManager) setCurrent(client *managedClient, terminalID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = client
+	m.currentTerminal = terminalID
+}
+
+// route is the sidecar writer's encoder: it delivers payload to its
+// recipients, each in its own scope.
+func (m *clientManager) route(_ io.Writer, payload any) error {
+	m.mu.Lock()
+	var recipients []*managedClient
+	if addressed, ok := payload.(addressedEvent); ok {
+		payload = addressed.payload
+		if client := addressed.client; client != nil && m.clients[client.id] == client {
+			recipients = []*managedClient{client}
+		}
+	} else {
+		recipients = m.recipients(payload)
+	}
+	m.mu.Unlock()
+
+	for _, client := range recipients {
+		info := client.info.Load()
+		if !info.wants(payload) || !client.subscriptions.Admits(payload) {
+			continue
+		}
+		scoped, ok := m.scopeEvent(client, info.protocolShim(payload))
+		if !ok {
+			continue
+		}
+		_ = client.conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		if err := m.encode(client.conn, scoped); err != nil {
+			// The client's reader sees the closed connection and leaves.
+			_ = client.conn.Close()
+		}
+	}
+
+	if exit, ok := payload.(exitEvent); ok {
+		m.mu.Lock()
+		delete(m.owners, exit.TerminalID)
+		delete(m.attached, exit.TerminalID)
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// addressedEvent is an event for one client alone: a stream event replayed
+// for the client attaching, or the answer of a request handled off the main
+// loop, which would otherwise go to whichever client is current by then.
+type addressedEvent struct {
+	client  *managedClient
+	payload any
+}
+
+func (e addressedEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.payload)
+}
+
+// requester returns the client whose request the main loop is handling.
+func (m *clientManager) requester() *managedClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.current
+}
+
+// sendTo sends payload to client alone, bypassing the routing that would
+// also send it to the terminal's other clients. It is dropped once client
+// has left.
+func (m *clientManager) sendTo(client *managedClient, payload any) {
+	_ = m.s.writer.Emit(addressedEvent{client: client, payload: payload})
+}
+
+func (m *clientManager) recipients(payload any) []*managedClient {
+	if requestID := stringField(payload, "RequestID"); requestID != "" {
+		clientID, _, _ := strings.Cut(requestID, ":")
+		if client := m.clients[clientID]; client != nil {
+			return []*managedClient{client}
+		}
+		return nil
+	}
+
+	if terminalID := stringField(payload, "TerminalID"); terminalID != "" {
+		var recipients []*managedClient
+		add := func(client *managedClient) {
+			for _, existing := range recipients {
+				if existing == client {
+					return
+				}
+			}
+			recipients = append(recipients, client)
+		}
+		if client := m.clients[m.owners[terminalID]]; client != nil {
+			add(client)
+		}
+		for clientID := range m.attached[terminalID] {
+			if client := m.clients[clientID]; client != nil {
+				add(client)
+			}
+		}
+		if m.current != nil && m.currentTerminal == terminalID {
+			add(m.current)
+		}
+		return recipients
+	}
+
+	// Only the main loop emits unaddressed events while a client is current.
+	if m.current != nil {
+		return []*managedClient{m.current}
+	}
+	recipients := make([]*managedClient, 0, len(m.clients))
+	for _, client := range m.clients {
+		recipients = append(recipients, client)
+	}
+	return recipients
+}
+
+// scopeRequest prefixes the request ID of line and, with namespaces, its
+// terminal IDs, and confines its selectors to the client's terminals.
+func (m *clientManager) scopeRequest(client *managedClient, line []byte) []byte {
+	msg, ok := decodeGeneric(line)
+	if !ok {
+		return line
+	}
+	if requestID, ok := msg["requestId"].(string); ok {
+		msg["requestId"] = client.id + ":" + requestID
+	}
+
+	if m.namespaces {
+		prefix := client.id + "/"
+		terminalID, _ := msg["terminalId"].(string)
+		if terminalID == "" && msg["type"] == requestTypeOpen {
+			if generated, err := m.s.newTerminalID(); err == nil {
+				terminalID = generated
+			}
+		}
+		if terminalID != "" {
+			msg["terminalId"] = prefix + terminalID
+		}
+		if ids, ok := msg["terminalIds"].([]any); ok {
+			for i, id := range ids {
+				if id, ok := id.(string); ok {
+					ids[i] = prefix + id
+				}
+			}
+		}
+
+		switch msg["type"] {
+		case requestTypeOpen:
+			msg["tags"] = withTag(msg["tags"], client.id)
+		case requestTypeList, requestTypeCloseAll:
+			delete(msg, "all")
+			msg["selector"] = withTag(msg["selector"], client.id)
+		case requestTypeBroadcast:
+			if msg["selector"] != nil {
+				msg["selector"] = withTag(msg["selector"], client.id)
+			}
+		}
+	}
+
+	scoped, err := json.Marshal(msg)
+	if err != nil {
+		return line
+	}
+	return scoped
+}
+
+func withTag(tags any, clientID string) map[string]any {
+	scoped, _ := tags.(map[string]any)
+	if scoped == nil {
+		scoped = make(map[string]any)
+	}
+	scoped[clientNamespaceTag] = clientID
+	return scoped
+}
+
+// scopeEvent undoes scopeRequest for client, reporting false when payload
+// concerns a terminal outside the client's namespace.
+func (m *clientManager) scopeEvent(client *managedClient, payload any) (any, bool) {
+	if !m.namespaces && stringField(payload, "RequestID") == "" {
+		return payload, true
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return payload, true
+	}
+	msg, ok := decodeGeneric(encoded)
+	if !ok {
+		return payload, true
+	}
+	if requestID, ok := msg["requestId"].(string); ok {
+		_, msg["requestId"], _ = strings.Cut(requestID, ":")
+	}
+	if m.namespaces && !unscopeTerminals(msg, client.id+"/") {
+		return nil, false
+	}
+	return msg, true
+}
+
+// unscopeTerminals strips prefix from the terminal IDs in value, drops the
+// namespace tag and removes the array elements describing other
+// namespaces' terminals. It reports false when value itself describes one.
+func unscopeTerminals(value any, prefix string) bool {
+	switch typed := value.(type) {
+	case map[string]any:
+		if terminalID, ok := typed["terminalId"].(string); ok && terminalID != "" {
+			if !strings.HasPrefix(terminalID, prefix) {
+				return false
+			}
+			typed["terminalId"] = strings.TrimPrefix(terminalID, prefix)
+		}
+		if tags, ok := typed["tags"].(map[string]any); ok {
+			delete(tags, clientNamespaceTag)
+			if len(tags) == 0 {
+				delete(typed, "tags")
+			}
+		}
+		for key, child := range typed {
+			if key == "tags" {
+				continue
+			}
+			if items, ok := child.([]any); ok {
+				typed[key] = unscopeItems(items, prefix)
+			} else if _, ok := child.(map[string]any); ok {
+				unscopeTerminals(child, prefix)
+			}
+		}
+	}
+	return true
+}
+
+func unscopeItems(items []any, prefix string) []any {
+	kept := items[:0]
+	for _, item := range items {
+		if id, ok := item.(string); ok && strings.HasPrefix(id, prefix) {
+			kept = append(kept, strings.TrimPrefix(id, prefix))
+			continue
+		}
+		if _, ok := item.(map[string]any); ok && !unscopeTerminals(item, prefix) {
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+func decodeGeneric(line []byte) (map[string]any, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var msg map[string]any
+	if err := decoder.Decode(&msg); err != nil || msg == nil {
+		return nil, false
+	}
+	return msg, true
+}
+
+// stringField returns the string field name of the struct in value, or ""
+// when it has none.
+func stringField(value any, name string) string {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
+
+const (
+	wsListenScheme = "ws://"
+	wsAcceptGUID   = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+
+	wsCloseNormal     = 1000
+	wsCloseProtocol   = 1002
+	wsCloseTooLarge   = 1009
+	maxWSResizeBytes  = 4096
+	wsMaxControlBytes = 125
+)
+
+// serveWebSocket is serveClientsConcurrently for --listen ws://. A
+// WebSocket on / is a control channel carrying one request or event per
+// text message. /terminals/{id}/attach carries a running terminal's raw
+// bytes both ways, as xterm.js's attach addon expects, and
+// /terminals/{id}/resize takes {"cols","rows"} posted beside it. Clients
+// authenticate with a bearer token or, as browsers cannot set headers on
+// a WebSocket, a token query parameter.
+func serveWebSocket(listener net.Listener, token string, cfg runConfig, stderr io.Writer, stop <-chan struct{}) (exitCode int) {
+	cfg = withRuntimeDefaults(cfg)
+	s := newSidecar(cfg, io.Discard)
+	defer s.recoverMainLoop(&exitCode)
+	defer cfg.Debug.Track(s)()
+	m := newClientManager(s)
+
+	messages := make(chan clientMessage, 64)
+	server := &http.Server{
+		Handler:  m.webSocketHandler(token, messages),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	acceptErr := make(chan error, 1)
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			acceptErr <- err
+		}
+	}()
+	exitCode = m.run(messages, acceptErr, func() { _ = listener.Close() }, stderr, stop)
+	_ = server.Close()
+	return exitCode
+}
+
+func (m *clientManager) webSocketHandler(token string, messages chan<- clientMessage) http.Handler {
+	cfg := m.s.cfg
+	authorized := func(w http.ResponseWriter, r *http.Request) bool {
+		presented := r.URL.Query().Get("token")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			presented = bearer
+		}
+		peer := r.RemoteAddr
+		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+			peer = r.TLS.PeerCertificates[0].Subject.CommonName + "@" + peer
+		}
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			cfg.Audit.Record(auditRecord{Client: peer, Request: requestTypeAuth}, newSidecarError(errorCodeUnauthorized, "invalid auth token"))
+			http.Error(w, "invalid auth token", http.StatusUnauthorized)
+			return false
+		}
+		cfg.Audit.Record(auditRecord{Client: peer, Request: requestTypeAuth}, nil)
+		return true
+	}
+	// Attach and resize name terminals as the sidecar knows them, which
+	// namespaced clients do not.
+	running := func(w http.ResponseWriter, terminalID string) bool {
+		if m.namespaces {
+			http.Error(w, "terminal endpoints are not available with --client-namespaces", http.StatusNotImplemented)
+			return false
+		}
+		m.s.mu.Lock()
+		_, exists := m.s.terminals[terminalID]
+		m.s.mu.Unlock()
+		if !exists {
+			http.Error(w, "terminal not found", http.StatusNotFound)
+		}
+		return exists
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		conn, err := upgradeWebSocket(w, r, cfg.MaxRequestBytes)
+		if err != nil {
+			return
+		}
+		client, err := m.join(r.URL.Query().Get("clientId"), conn)
+		if err != nil {
+			_ = writeNDJSONLine(conn, errorEvent{
+				Type:    eventTypeError,
+				Code:    errorCodeUnauthorized,
+				Message: err.Error(),
+			})
+			_ = conn.Close()
+			return
+		}
+		m.feed(client, startRequestReader(conn, cfg.Encoding, cfg.MaxRequestBytes), messages)
+	})
+	mux.HandleFunc("GET /terminals/{id}/attach", func(w http.ResponseWriter, r *http.Request) {
+		terminalID := r.PathValue("id")
+		if !authorized(w, r) || !running(w, terminalID) {
+			return
+		}
+		// Input is base64 in the write request, a third larger.
+		conn, err := upgradeWebSocket(w, r, cfg.MaxRequestBytes/4*3)
+		if err != nil {
+			return
+		}
+		attach := &wsAttachConn{wsConn: conn, terminalID: terminalID}
+		client, err := m.join("", attach)
+		if err == nil && !m.attach(client, terminalID) {
+			err = errors.New("terminal not found")
+		}
+		if err != nil {
+			_ = attach.Close()
+			return
+		}
+		defer func() { messages <- clientMessage{client: client, left: true} }()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			line, _ := json.Marshal(writeRequest{
+				Type:       requestTypeWrite,
+				TerminalID: terminalID,
+				Data:       base64.StdEncoding.EncodeToString(data),
+				Encoding:   writeEncodingBase64,
+			})
+			messages <- clientMessage{client: client, line: line}
+		}
+	})
+	mux.HandleFunc("POST /terminals/{id}/resize", func(w http.ResponseWriter, r *http.Request) {
+		terminalID := r.PathValue("id")
+		if !authorized(w, r) || !running(w, terminalID) {
+			return
+		}
+		req := resizeRequest{Type: requestTypeResize, TerminalID: terminalID}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWSResizeBytes)).Decode(&req); err != nil {
+			http.Error(w, "invalid resize: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Type, req.TerminalID = requestTypeResize, terminalID
+		line, _ := json.Marshal(req)
+
+		client, err := m.join("", discardConn{})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		messages <- clientMessage{client: client, line: line}
+		messages <- clientMessage{client: client, left: true}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// attach makes client an attached client of a running terminal, as an open
+// with attachIfExists does, but without starting one when it is gone.
+func (m *clientManager) attach(client *managedClient, terminalID string) bool {
+	m.s.mu.Lock()
+	_, exists := m.s.terminals[terminalID]
+	m.s.mu.Unlock()
+	if !exists {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.attached[terminalID] == nil {
+		m.attached[terminalID] = make(map[string]bool)
+	}
+	m.attached[terminalID][client.id] = true
+	return true
+}
+
+// upgradeWebSocket completes the opening handshake of RFC 6455, answering
+// a request that is not one with an error.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, maxBytes int) (*wsConn, error) {
+	upgrade := false
+	for _, value := range r.Header.Values("Connection") {
+		for _, option := range strings.Split(value, ",") {
+			upgrade = upgrade || strings.EqualFold(strings.TrimSpace(option), "upgrade")
+		}
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !upgrade || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a WebSocket upgrade", http.StatusUpgradeRequired)
+		return nil, errors.New("not a WebSocket upgrade")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported WebSocket version")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	accept := sha1.Sum([]byte(key + wsAcceptGUID))
+	if _, err := io.WriteString(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: "+
+		base64.StdEncoding.EncodeToString(accept[:])+"\r\n\r\n"); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &wsConn{Conn: conn, reader: rw.Reader, maxBytes: maxBytes}, nil
+}
+
+// wsConn is the server side of a WebSocket. As a client's net.Conn it reads
+// each message as a request line and writes each event line as a text
+// message.
+type wsConn struct {
+	net.Conn
+	reader   *bufio.Reader
+	maxBytes int
+	pending  []byte
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		_, message, err := c.ReadMessage()
+		if err != nil {
+			return 0, err
+		}
+		c.pending = append(message, '\n')
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		if err := c.writeFrame(wsOpText, line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close sends a close frame before closing the connection.
+func (c *wsConn) Close() error {
+	return c.closeWith(wsCloseNormal)
+}
+
+func (c *wsConn) closeWith(code int) error {
+	_ = c.writeFrame(wsOpClose, binary.BigEndian.AppendUint16(nil, uint16(code)))
+	c.writeMu.Lock()
+	c.closed = true
+	c.writeMu.Unlock()
+	return c.Conn.Close()
+}
+
+// ReadMessage returns the next text or binary message, answering pings
+// and reassembling fragments on the way. A close frame ends the stream.
+func (c *wsConn) ReadMessage() (byte, []byte, error) {
+	var opcode byte
+	var message []byte
+	for {
+		fin, frameOpcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch frameOpcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			_ = c.Close()
+			return 0, nil, io.EOF
+		case wsOpContinuation:
+			if opcode == 0 {
+				_ = c.closeWith(wsCloseProtocol)
+				return 0, nil, errors.New("WebSocket continuation without a message")
+			}
+		case wsOpText, wsOpBinary:
+			if opcode != 0 {
+				_ = c.closeWith(wsCloseProtocol)
+				return 0, nil, errors.New("WebSocket message interrupted by another")
+			}
+			opcode = frameOpcode
+		default:
+			_ = c.closeWith(wsCloseProtocol)
+			return 0, nil, fmt.Errorf("unsupported WebSocket opcode %d", frameOpcode)
+		}
+
+		if len(message)+len(payload) > c.maxBytes {
+			_ = c.closeWith(wsCloseTooLarge)
+			return 0, nil, fmt.Errorf("WebSocket message exceeds %d bytes", c.maxBytes)
+		}
+		message = append(message, payload...)
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode := header[0]&0x80 != 0, header[0]&0x0f
+	if header[1]&0x80 == 0 {
+		_ = c.closeWith(wsCloseProtocol)
+		return false, 0, nil, errors.New("WebSocket client frames must be masked")
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if opcode >= wsOpClose && (length > wsMaxControlBytes || !fin) {
+		_ = c.closeWith(wsCloseProtocol)
+		return false, 0, nil, errors.New("invalid WebSocket control frame")
+	}
+	if length > uint64(c.maxBytes) {
+		_ = c.closeWith(wsCloseTooLarge)
+		return false, 0, nil, fmt.Errorf("WebSocket frame exceeds %d bytes", c.maxBytes)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= math.MaxUint16:
+		frame = binary.BigEndian.AppendUint16(append(frame, 126), uint16(len(payload)))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 127), uint64(len(payload)))
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	_, err := c.Conn.Write(append(frame, payload...))
+	return err
+}
+
+// wsAttachConn is the client of an attach socket. Of the events routed to
+// it, it writes its terminal's output as binary messages and ends the
+// socket when the terminal goes away.
+type wsAttachConn struct {
+	*wsConn
+	terminalID string
+}
+
+func (c *wsAttachConn) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		var evt outputEvent
+		if len(line) == 0 || json.Unmarshal(line, &evt) != nil || evt.TerminalID != c.terminalID {
+			continue
+		}
+		switch evt.Type {
+		case eventTypeOutput:
+			data, err := decodeOutputEvent(evt)
+			if err != nil {
+				continue
+			}
+			if err := c.writeFrame(wsOpBinary, data); err != nil {
+				return 0, err
+			}
+		case eventTypeExit, eventTypeClosed:
+			_ = c.Close()
+		}
+	}
+	return len(p), nil
+}
+
+// discardConn is the connection of a client whose events nobody reads.
+type discardConn struct {
+	net.Conn
+}
+
+func (discardConn) Write(p []byte) (int, error)      { return len(p), nil }
+func (discardConn) Close() error                     { return nil }
+func (discardConn) SetWriteDeadline(time.Time) error { return nil }
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func startTestListener(t *testing.T, token string) (string, chan int) {
+	t.Helper()
+	return startTestListenerWithConfig(t, token, runConfig{})
+}
+
+func startTestListenerWithConfig(t *testing.T, token string, cfg runConfig) (string, chan int) {
+	t.Helper()
+
+	cfg.IdleTimeout = time.Minute
+	cfg.ProbeConPTY = func() error { return nil }
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	done := make(chan int, 1)
+	go func() {
+		done <- serveListener(listener, token, cfg, io.Discard, nil)
+	}()
+	return listener.Addr().String(), done
+}
+
+func dialTestListener(t *testing.T, addr string, firstLine string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	if _, err := io.WriteString(conn, firstLine+"\n"); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return conn, bufio.NewReader(conn)
+}
+
+func readTestEvent(t *testing.T, reader *bufio.Reader) map[string]any {
+	t.Helper()
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("read event failed: %v", err)
+	}
+	evt := map[string]any{}
+	if err := json.Unmarshal(line, &evt); err != nil {
+		t.Fatalf("decode event %q: %v", line, err)
+	}
+	return evt
+}
+
+func TestServeListenerRejectsUnauthenticatedClients(t *testing.T) {
+	addr, _ := startTestListener(t, "secret")
+
+	for _, firstLine := range []string{
+		`{"type":"ping"}`,
+		`{"type":"auth","token":"wrong"}`,
+	} {
+		_, reader := dialTestListener(t, addr, firstLine)
+		evt := readTestEvent(t, reader)
+		if evt["type"] != eventTypeError || evt["code"] != errorCodeUnauthorized {
+			t.Fatalf("expected unauthorized error for %s, got %#v", firstLine, evt)
+		}
+		if _, err := reader.ReadByte(); err != io.EOF {
+			t.Fatalf("connection should be closed after %s, got %v", firstLine, err)
+		}
+	}
+}
+
+func TestServeListenerServesAuthenticatedClient(t *testing.T) {
+	addr, done := startTestListener(t, "secret")
+
+	conn, reader := dialTestListener(t, addr, `{"type":"auth","token":"secret"}`)
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeHello {
+		t.Fatalf("expected hello after auth, got %#v", evt)
+	}
+
+	if _, err := io.WriteString(conn, `{"type":"shutdown"}`+"\n"); err != nil {
+		t.Fatalf("write shutdown failed: %v", err)
+	}
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeShutdownAck {
+		t.Fatalf("expected shutdown_ack, got %#v", evt)
+	}
+
+	select {
+	case exitCode := <-done:
+		if exitCode != 0 {
+			t.Fatalf("expected shutdown exit code 0, got %d", exitCode)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener kept serving after shutdown")
+	}
+}
+
+func TestLoadAuthToken(t *testing.T) {
+	t.Setenv(authTokenEnv, "")
+	if _, err := loadAuthToken(""); err == nil {
+		t.Fatal("expected missing token error")
+	}
+
+	t.Setenv(authTokenEnv, " from-env ")
+	if token, err := loadAuthToken(""); err != nil || token != "from-env" {
+		t.Fatalf("unexpected env token %q: %v", token, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	if token, err := loadAuthToken(path); err != nil || token != "from-file" {
+		t.Fatalf("unexpected file token %q: %v", token, err)
+	}
+}
+
+// testCertificate is a self-signed certificate that also acts as its own CA.
+type testCertificate struct {
+	certFile string
+	keyFile  string
+	pair     tls.Certificate
+	pool     *x509.CertPool
+}
+
+func newTestCertificate(t *testing.T, name string) testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	dir := t.TempDir()
+	cert := testCertificate{
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(cert.certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	if err := os.WriteFile(cert.keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	if cert.pair, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatalf("load key pair: %v", err)
+	}
+	cert.pool = x509.NewCertPool()
+	cert.pool.AppendCertsFromPEM(certPEM)
+	return cert
+}
+
+func TestListenTransportRequiresClientCertificate(t *testing.T) {
+	server := newTestCertificate(t, "server")
+	client := newTestCertificate(t, "client")
+
+	listener, err := listenTransport(runConfig{
+		Listen:      "127.0.0.1:0",
+		TLSCert:     server.certFile,
+		TLSKey:      server.keyFile,
+		TLSClientCA: client.certFile,
+	})
+	if err != nil {
+		t.Fatalf("listenTransport failed: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go serveListener(listener, "secret", runConfig{
+		IdleTimeout: time.Minute,
+		ProbeConPTY: func() error { return nil },
+	}, io.Discard, nil)
+
+	dial := func(certificates []tls.Certificate) (*bufio.Reader, error) {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{
+			RootCAs:      server.pool,
+			Certificates: certificates,
+		})
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+		if _, err := io.WriteString(conn, `{"type":"auth","token":"secret"}`+"\n"); err != nil {
+			return nil, err
+		}
+		reader := bufio.NewReader(conn)
+		_, err = reader.Peek(1)
+		return reader, err
+	}
+
+	if _, err := dial(nil); err == nil {
+		t.Fatal("client without certificate should be rejected")
+	}
+
+	reader, err := dial([]tls.Certificate{client.pair})
+	if err != nil {
+		t.Fatalf("client with certificate rejected: %v", err)
+	}
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeHello {
+		t.Fatalf("expected hello over TLS, got %#v", evt)
+	}
+}
+
+func TestParseRunFlagsTLSRequiresKeyPair(t *testing.T) {
+	if _, err := parseRunFlags([]string{"--tls-cert", "server.crt"}, io.Discard); err == nil {
+		t.Fatal("expected --tls-key to be required")
+	}
+	if _, err := parseRunFlags([]string{"--tls-client-ca", "ca.crt"}, io.Discard); err == nil {
+		t.Fatal("expected --tls-client-ca to require --tls-cert")
+	}
+}
+
+func TestServeListenerStopClosesClientAndTerminals(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	opened := make(chan *fakeTerminal, 1)
+	cfg := runConfig{
+		IdleTimeout: time.Minute,
+		ProbeConPTY: func() error { return nil },
+		LookPath:    fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`}),
+		TerminalOpener: func(req openRequest, _ resolvedShell, callbacks terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+			terminal := &fakeTerminal{req: req, callbacks: callbacks}
+			opened <- terminal
+			return terminal, nil
+		},
+	}
+	stop := make(chan struct{})
+	done := make(chan int, 1)
+	go func() {
+		done <- serveListener(listener, "secret", cfg, io.Discard, stop)
+	}()
+
+	conn, reader := dialTestListener(t, listener.Addr().String(), `{"type":"auth","token":"secret"}`)
+	if _, err := io.WriteString(conn, `{"type":"open","terminalId":"t1","cols":80,"rows":24}`+"\n"); err != nil {
+		t.Fatalf("write open failed: %v", err)
+	}
+	for evt := readTestEvent(t, reader); evt["type"] != eventTypeReady; evt = readTestEvent(t, reader) {
+	}
+	terminal := <-opened
+
+	close(stop)
+	select {
+	case code := <-done:
+		if code != 0 {
+			t.Fatalf("expected a stopped listener to exit 0, got %d", code)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener did not stop")
+	}
+	if !terminal.Closed() {
+		t.Fatal("stopping the listener left the client's terminal open")
+	}
+}
+
+func startMultiClientListener(t *testing.T, cfg runConfig) (string, chan int, chan *fakeTerminal) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	opened := make(chan *fakeTerminal, 4)
+	cfg.IdleTimeout = time.Minute
+	if cfg.ProbeConPTY == nil {
+		cfg.ProbeConPTY = func() error { return nil }
+	}
+	if cfg.LookPath == nil {
+		cfg.LookPath = fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`})
+	}
+	if cfg.TerminalOpener == nil {
+		cfg.TerminalOpener = func(req openRequest, _ resolvedShell, callbacks terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+			terminal := &fakeTerminal{req: req, callbacks: callbacks}
+			opened <- terminal
+			return terminal, nil
+		}
+	}
+	done := make(chan int, 1)
+	go func() {
+		done <- serveClientsConcurrently(listener, "secret", cfg, io.Discard, nil)
+	}()
+	return listener.Addr().String(), done, opened
+}
+
+func joinTestClient(t *testing.T, addr string, clientID string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, reader := dialTestListener(t, addr, `{"type":"auth","token":"secret","clientId":"`+clientID+`"}`)
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeHello {
+		t.Fatalf("expected hello for %s, got %#v", clientID, evt)
+	}
+	return conn, reader
+}
+
+func sendTestRequest(t *testing.T, conn net.Conn, line string) {
+	t.Helper()
+
+	if _, err := io.WriteString(conn, line+"\n"); err != nil {
+		t.Fatalf("write %s failed: %v", line, err)
+	}
+}
+
+func TestMultiClientNamespacesIsolateTerminals(t *testing.T) {
+	addr, _, opened := startMultiClientListener(t, runConfig{MultiClient: true, ClientNamespaces: true})
+
+	first, firstReader := joinTestClient(t, addr, "a")
+	second, secondReader := joinTestClient(t, addr, "b")
+
+	sendTestRequest(t, first, `{"type":"open","terminalId":"t1","cols":80,"rows":24,"requestId":"r1"}`)
+	evt := readTestEvent(t, firstReader)
+	if evt["type"] != eventTypeReady || evt["terminalId"] != "t1" || evt["requestId"] != "r1" {
+		t.Fatalf("expected a's terminal to be ready as t1, got %#v", evt)
+	}
+	firstTerminal := <-opened
+	if firstTerminal.req.TerminalID != "a/t1" {
+		t.Fatalf("expected a's terminal to be namespaced, got %q", firstTerminal.req.TerminalID)
+	}
+
+	sendTestRequest(t, second, `{"type":"open","terminalId":"t1","cols":80,"rows":24}`)
+	if evt := readTestEvent(t, secondReader); evt["type"] != eventTypeReady || evt["terminalId"] != "t1" {
+		t.Fatalf("expected b to open its own t1, got %#v", evt)
+	}
+	<-opened
+
+	firstTerminal.callbacks.Output([]byte("only for a"))
+	if evt := readTestEvent(t, firstReader); evt["type"] != eventTypeOutput || evt["terminalId"] != "t1" {
+		t.Fatalf("expected a to see its output, got %#v", evt)
+	}
+
+	sendTestRequest(t, second, `{"type":"list"}`)
+	evt = readTestEvent(t, secondReader)
+	terminals, _ := evt["terminals"].([]any)
+	if evt["type"] != eventTypeList || len(terminals) != 1 {
+		t.Fatalf("expected b to list only its own terminal, got %#v", evt)
+	}
+	if terminal, _ := terminals[0].(map[string]any); terminal["terminalId"] != "t1" || terminal["tags"] != nil {
+		t.Fatalf("expected b's terminal without its namespace, got %#v", terminal)
+	}
+
+	sendTestRequest(t, second, `{"type":"shutdown"}`)
+	if evt := readTestEvent(t, secondReader); evt["type"] != eventTypeError || evt["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected a namespaced client's shutdown to be refused, got %#v", evt)
+	}
+}
+
+func TestMultiClientDisconnectPolicy(t *testing.T) {
+	for _, policy := range []string{clientDisconnectClose, clientDisconnectPreserve} {
+		t.Run(policy, func(t *testing.T) {
+			addr, _, opened := startMultiClientListener(t, runConfig{MultiClient: true, ClientDisconnect: policy})
+
+			owner, ownerReader := joinTestClient(t, addr, "owner")
+			watcher, watcherReader := joinTestClient(t, addr, "watcher")
+			sendTestRequest(t, owner, `{"type":"open","terminalId":"t1","cols":80,"rows":24}`)
+			if evt := readTestEvent(t, ownerReader); evt["type"] != eventTypeReady {
+				t.Fatalf("expected ready, got %#v", evt)
+			}
+			terminal := <-opened
+
+			_ = owner.Close()
+			sendTestRequest(t, watcher, `{"type":"list"}`)
+			deadline := time.Now().Add(2 * time.Second)
+			for {
+				evt := readTestEvent(t, watcherReader)
+				terminals, _ := evt["terminals"].([]any)
+				if policy == clientDisconnectPreserve && len(terminals) == 1 {
+					break
+				}
+				if policy == clientDisconnectClose && terminal.Closed() && len(terminals) == 0 && evt["type"] == eventTypeList {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("policy %s: unexpected terminals after the owner left: %#v", policy, evt)
+				}
+				sendTestRequest(t, watcher, `{"type":"list"}`)
+			}
+			if policy == clientDisconnectPreserve && terminal.Closed() {
+				t.Fatal("preserve policy closed the owner's terminal")
+			}
+		})
+	}
+}
+
+func TestMultiClientRejectsDuplicateClientID(t *testing.T) {
+	addr, _, _ := startMultiClientListener(t, runConfig{MultiClient: true})
+
+	joinTestClient(t, addr, "a")
+	_, reader := dialTestListener(t, addr, `{"type":"auth","token":"secret","clientId":"a"}`)
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeError || evt["code"] != errorCodeUnauthorized {
+		t.Fatalf("expected a second client named a to be refused, got %#v", evt)
+	}
+	_, reader = dialTestListener(t, addr, `{"type":"auth","token":"secret","clientId":"a/b"}`)
+	if evt := readTestEvent(t, reader); evt["type"] != eventTypeError {
+		t.Fatalf("expected a client ID with a slash to be refused, got %#v", evt)
+	}
+}
+
+func TestParseRunFlagsMultiClientRequiresListen(t *testing.T) {
+	for _, args := range [][]string{
+		{"--multi-client"},
+		{"--client-namespaces"},
+		{"--client-disconnect", "preserve"},
+		{"--listen", "127.0.0.1:0", "--multi-client", "--client-disconnect", "detach"},
+	} {
+		if _, err := parseRunFlags(args, io.Discard); err == nil {
+			t.Fatalf("expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestMultiClientRecordsHelloPerClient(t *testing.T) {
+	addr, _, _ := startMultiClientListener(t, runConfig{MultiClient: true})
+
+	first, firstReader := joinTestClient(t, addr, "a")
+	second, secondReader := joinTestClient(t, addr, "b")
+	sendTestRequest(t, first, `{"type":"hello","name":"hub","features":[]}`)
+	if evt := readTestEvent(t, firstReader); evt["type"] != eventTypeHelloAck {
+		t.Fatalf("expected hello_ack, got %#v", evt)
+	}
+
+	sendTestRequest(t, second, `{"type":"stats"}`)
+	evt := readTestEvent(t, secondReader)
+	clients, _ := evt["clients"].([]any)
+	if evt["type"] != eventTypeStats || len(clients) != 1 || clients[0].(map[string]any)["id"] != "a" {
+		t.Fatalf("expected stats to list only the client that said hello, got %#v", evt)
+	}
+}
+
+func TestMultiClientAnswersIsolatedRequestsToTheirClient(t *testing.T) {
+	var gated atomic.Bool
+	probing, release := make(chan struct{}), make(chan struct{})
+	var handOver sync.Once
+	lookup := fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`})
+	addr, _, _ := startMultiClientListener(t, runConfig{
+		MultiClient: true,
+		ProbeConPTY: func() error {
+			if gated.Load() {
+				close(probing)
+				<-release
+			}
+			return nil
+		},
+		LookPath: func(file string) (string, error) {
+			if gated.Load() {
+				// Let a's probe answer while b's open holds the main loop.
+				handOver.Do(func() {
+					close(release)
+					time.Sleep(100 * time.Millisecond)
+				})
+			}
+			return lookup(file)
+		},
+	})
+
+	first, firstReader := joinTestClient(t, addr, "a")
+	second, secondReader := joinTestClient(t, addr, "b")
+	gated.Store(true)
+	sendTestRequest(t, first, `{"type":"probe"}`)
+	<-probing
+	sendTestRequest(t, second, `{"type":"open","terminalId":"t1","cols":80,"rows":24}`)
+
+	if evt := readTestEvent(t, firstReader); evt["type"] != eventTypeProbe {
+		t.Fatalf("expected a to get its probe answer, got %#v", evt)
+	}
+	if evt := readTestEvent(t, secondReader); evt["type"] != eventTypeReady {
+		t.Fatalf("expected b to get only its ready event, got %#v", evt)
+	}
+}
+
+func TestMultiClientCloseReportsClosedToTheOwner(t *testing.T) {
+	for name, request := range map[string]string{
+		"forced": `{"type":"close","terminalId":"t1","force":true}`,
+		"grace":  `{"type":"close","terminalId":"t1","graceMs":1000}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			addr, _, _ := startMultiClientListener(t, runConfig{
+				MultiClient: true,
+				TerminalOpener: func(req openRequest, _ resolvedShell, callbacks terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+					return &hangupTerminal{fakeTerminal: &fakeTerminal{req: req, callbacks: callbacks}}, nil
+				},
+			})
+
+			owner, ownerReader := joinTestClient(t, addr, "owner")
+			sendTestRequest(t, owner, `{"type":"open","terminalId":"t1","cols":80,"rows":24}`)
+			if evt := readTestEvent(t, ownerReader); evt["type"] != eventTypeReady {
+				t.Fatalf("expected ready, got %#v", evt)
+			}
+			sendTestRequest(t, owner, request)
+			if evt := readTestEvent(t, ownerReader); evt["type"] != eventTypeExit {
+				t.Fatalf("expected exit, got %#v", evt)
+			}
+			if evt := readTestEvent(t, ownerReader); evt["type"] != eventTypeClosed || evt["terminalId"] != "t1" {
+				t.Fatalf("expected the owner to get closed after exit, got %#v", evt)
+			}
+		})
+	}
+}
+
+func TestParseRunFlagsReplayEventsRequiresLongLivedTerminals(t *testing.T) {
+	if _, err := parseRunFlags([]string{"--replay-events", "100"}, io.Discard); err == nil {
+		t.Fatal("expected --replay-events to require --daemon or --multi-client")
+	}
+	if _, err := parseRunFlags([]string{"--listen", "127.0.0.1:0", "--multi-client", "--replay-events", "-1"}, io.Discard); err == nil {
+		t.Fatal("expected a negative --replay-events to be rejected")
+	}
+	if _, err := parseRunFlags([]string{"--listen", "127.0.0.1:0", "--multi-client", "--replay-events", "100"}, io.Discard); err != nil {
+		t.Fatalf("expected --replay-events with --multi-client to be accepted: %v", err)
+	}
+}
+
+func TestParseRunFlagsWebSocketCarriesJSON(t *testing.T) {
+	if _, err := parseRunFlags([]string{"--listen", "ws://127.0.0.1:0", "--encoding", "msgpack"}, io.Discard); err == nil {
+		t.Fatal("expected ws:// to refuse msgpack")
+	}
+	cfg, err := parseRunFlags([]string{"--listen", "ws://127.0.0.1:0"}, io.Discard)
+	if err != nil || !cfg.MultiClient {
+		t.Fatalf("expected ws:// to serve concurrent clients, got %+v (%v)", cfg, err)
+	}
+}
+
+// dialTestWebSocket opens a WebSocket to path, returning the connection and
+// functions to send and receive messages.
+func dialTestWebSocket(t *testing.T, addr string, path string) (net.Conn, func(byte, string), func() (byte, string)) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	request := "GET " + path + " HTTP/1.1\r\nHost: " + addr + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := io.WriteString(conn, request); err != nil {
+		t.Fatalf("handshake failed: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected the upgrade to be accepted, got %v (%v)", resp, err)
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", accept)
+	}
+
+	send := func(opcode byte, payload string) {
+		t.Helper()
+		mask := []byte{1, 2, 3, 4}
+		frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+		frame = append(frame, mask...)
+		for i := 0; i < len(payload); i++ {
+			frame = append(frame, payload[i]^mask[i%4])
+		}
+		if _, err := conn.Write(frame); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+	receive := func() (byte, string) {
+		t.Helper()
+		var header [2]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			t.Fatalf("receive failed: %v", err)
+		}
+		length := int(header[1] & 0x7f)
+		if length == 126 {
+			var extended [2]byte
+			_, _ = io.ReadFull(reader, extended[:])
+			length = int(binary.BigEndian.Uint16(extended[:]))
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			t.Fatalf("receive failed: %v", err)
+		}
+		return header[0] & 0x0f, string(payload)
+	}
+	return conn, send, receive
+}
+
+func TestServeWebSocketAttachSpeaksRawBytes(t *testing.T) {
+	listener, err := listenTransport(runConfig{Listen: "ws://127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("listenTransport failed: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	opened := make(chan *fakeTerminal, 1)
+	go serveWebSocket(listener, "secret", runConfig{
+		MultiClient: true,
+		IdleTimeout: time.Minute,
+		ProbeConPTY: func() error { return nil },
+		LookPath:    fakeLookup(map[string]string{"pwsh.exe": `C:\pwsh.exe`}),
+		TerminalOpener: func(req openRequest, _ resolvedShell, callbacks terminalCallbacks, _ func(string, func())) (terminalSession, error) {
+			terminal := &fakeTerminal{req: req, callbacks: callbacks}
+			opened <- terminal
+			return terminal, nil
+		},
+	}, io.Discard, nil)
+	addr := listener.Addr().String()
+
+	resp, err := http.Get("http://" + addr + "/terminals/t1/attach?token=wrong")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an invalid token to be refused, got %v (%v)", resp, err)
+	}
+	resp, err = http.Get("http://" + addr + "/terminals/t1/attach?token=secret")
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected attaching to a missing terminal to fail, got %v (%v)", resp, err)
+	}
+
+	_, sendControl, receiveControl := dialTestWebSocket(t, addr, "/?token=secret")
+	if _, message := receiveControl(); !strings.Contains(message, `"type":"hello"`) {
+		t.Fatalf("expected hello on the control channel, got %s", message)
+	}
+	sendControl(wsOpText, `{"type":"open","terminalId":"t1","cols":80,"rows":24}`)
+	if _, message := receiveControl(); !strings.Contains(message, `"type":"ready"`) {
+		t.Fatalf("expected ready, got %s", message)
+	}
+	terminal := <-opened
+
+	_, sendAttach, receiveAttach := dialTestWebSocket(t, addr, "/terminals/t1/attach?token=secret")
+	terminal.callbacks.Output([]byte("hi\x1b[0m"))
+	if opcode, data := receiveAttach(); opcode != wsOpBinary || data != "hi\x1b[0m" {
+		t.Fatalf("expected raw output in a binary message, got %d %q", opcode, data)
+	}
+	sendAttach(wsOpText, "ls\r")
+	resize, err := http.Post("http://"+addr+"/terminals/t1/resize?token=secret", "application/json", strings.NewReader(`{"cols":100,"rows":30}`))
+	if err != nil || resize.StatusCode != http.StatusNoContent {
+		t.Fatalf("expected the resize to be accepted, got %v (%v)", resize, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		terminal.mu.Lock()
+		wrote, resized := strings.Join(terminal.writes, ""), len(terminal.resizes) > 0
+		terminal.mu.Unlock()
+		if wrote == "ls\r" && resized {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the attach input and the resize to reach the terminal, got %q %v", wrote, resized)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	terminal.callbacks.Exit(0)
+	if opcode, _ := receiveAttach(); opcode != wsOpClose {
+		t.Fatalf("expected the attach socket to close when the terminal exits, got opcode %d", opcode)
+	}
+}
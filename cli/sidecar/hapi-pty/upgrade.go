@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// upgradeReplyTimeout bounds each step of an upgrade handoff.
+	upgradeReplyTimeout = 30 * time.Second
+	// upgradeFlushTimeout bounds how long queued output may take to reach
+	// the client before the new binary starts writing to it.
+	upgradeFlushTimeout = 2 * time.Second
+)
+
+// handoffState is what an upgrading daemon sends the new binary: handles
+// already duplicated into it, and every terminal it takes over.
+type handoffState struct {
+	RequestID string            `json:"requestId,omitempty"`
+	Listener  uintptr           `json:"listener"`
+	Client    uintptr           `json:"client"`
+	Terminals []handoffTerminal `json:"terminals"`
+}
+
+// handoffTerminal carries a terminal's resolved open, with its current size
+// and without the startup options that already ran, and what its ready and
+// list events report.
+type handoffTerminal struct {
+	Request      openRequest      `json:"request"`
+	Display      string           `json:"display"`
+	Cwd          string           `json:"cwd"`
+	Backend      string           `json:"backend"`
+	ShellVersion string           `json:"shellVersion,omitempty"`
+	CodePage     int              `json:"codePage,omitempty"`
+	Opened       time.Time        `json:"opened"`
+	Lines        []checkpointLine `json:"lines,omitempty"`
+	Session      sessionHandles   `json:"session"`
+}
+
+// sessionHandles are a ConPTY session's handles, valid in the process they
+// were duplicated into. Signal, Reference and Conhost make up the pseudo
+// console.
+type sessionHandles struct {
+	Signal    uintptr `json:"signal"`
+	Reference uintptr `json:"reference"`
+	Conhost   uintptr `json:"conhost"`
+	Stdin     uintptr `json:"stdin"`
+	Output    uintptr `json:"output"`
+	Process   uintptr `json:"process"`
+	Job       uintptr `json:"job,omitempty"`
+	PID       uint32  `json:"pid"`
+}
+
+// adoptedTerminal opens a handed over terminal through opener, which wraps
+// its session instead of starting a shell.
+type adoptedTerminal struct {
+	handoffTerminal
+	opener terminalFactory
+}
+
+// handoffReply answers a handoffState; handoffCommit then tells the new
+// binary to take over, or, when it never arrives, to exit.
+type handoffReply struct {
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+type handoffCommit struct {
+	Commit bool `json:"commit"`
+}
+
+// handoffConn exchanges JSON lines over the private handoff pipe.
+type handoffConn struct {
+	conn   io.ReadWriteCloser
+	reader *bufio.Reader
+}
+
+func newHandoffConn(conn io.ReadWriteCloser) *handoffConn {
+	return &handoffConn{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *handoffConn) send(payload any) error {
+	line, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Write(append(line, '\n'))
+	return err
+}
+
+// receive decodes the next line into v, giving up after timeout by closing
+// the pipe.
+func (c *handoffConn) receive(v any, timeout time.Duration) error {
+	type result struct {
+		line []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := c.reader.ReadBytes('\n')
+		done <- result{line, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return r.err
+		}
+		return json.Unmarshal(r.line, v)
+	case <-time.After(timeout):
+		_ = c.conn.Close()
+		return fmt.Errorf("no reply within %s", timeout)
+	}
+}
+
+// withHandoffArgs returns the daemon's args for the binary taking it over
+// on pipe, replacing the --handoff of an earlier upgrade.
+func withHandoffArgs(args []string, pipe string) []string {
+	out := make([]string, 0, len(args)+2)
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if name == "handoff" {
+			i++
+			continue
+		}
+		if strings.HasPrefix(name, "handoff=") {
+			continue
+		}
+		out = append(out, args[i])
+	}
+	return append(out, "--handoff", pipe)
+}
+
+// handleUpgrade hands the daemon to a new binary. Once the new process
+// committed, it reports done without closing the terminals, which this
+// process no longer owns.
+func (s *sidecar) handleUpgrade(req upgradeRequest) (int, bool) {
+	err := s.upgrade(req)
+	s.audit(auditRecord{Request: requestTypeUpgrade}, err)
+	if err != nil {
+		s.emitRequestFailure("", req.RequestID, err, errorCodeUpgradeFailed)
+		return 0, false
+	}
+	return 0, true
+}
+
+func (s *sidecar) upgrade(req upgradeRequest) error {
+	if s.daemon == nil {
+		return newSidecarError(errorCodeInvalidRequest, "upgrade requires the sidecar to run as a daemon, see --daemon")
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate sidecar executable: %w", err)
+	}
+
+	s.mu.Lock()
+	entries := make([]*terminalEntry, 0, len(s.terminals))
+	for _, entry := range s.terminals {
+		entries = append(entries, entry)
+	}
+	s.mu.Unlock()
+
+	child, err := startUpgradeProcess(path, s.cfg.DaemonArgs)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			child.abort()
+		}
+	}()
+
+	state := handoffState{RequestID: req.RequestID}
+	if state.Listener, err = child.exportListener(s.daemon.listener); err != nil {
+		return err
+	}
+	if state.Client, err = child.exportFile(s.daemon.client); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		handles, err := child.exportSession(entry.session)
+		if err != nil {
+			return fmt.Errorf("cannot hand over terminal %s: %w", entry.id, err)
+		}
+		state.Terminals = append(state.Terminals, newHandoffTerminal(entry, handles))
+	}
+
+	if err := child.send(state); err != nil {
+		return fmt.Errorf("failed to send handoff: %w", err)
+	}
+	var reply handoffReply
+	if err := child.receive(&reply, upgradeReplyTimeout); err != nil {
+		return fmt.Errorf("new binary did not take over: %w", err)
+	}
+	if !reply.Ready {
+		return fmt.Errorf("new binary refused the handoff: %s", reply.Error)
+	}
+
+	// Stop reading before the new process starts to, so each chunk of
+	// output and each request is read exactly once.
+	for i, entry := range entries {
+		if err := detachSession(entry.session); err != nil {
+			for _, detached := range entries[:i] {
+				resumeSession(detached.session)
+			}
+			return fmt.Errorf("failed to detach terminal %s: %w", entry.id, err)
+		}
+	}
+	cancelPendingReads(s.daemon.client)
+	flushOutputPumps(entries, upgradeFlushTimeout)
+	s.writer.Retarget(io.Discard)
+	if err := child.send(handoffCommit{Commit: true}); err != nil {
+		return fmt.Errorf("failed to commit handoff: %w", err)
+	}
+	committed = true
+	child.close()
+	return nil
+}
+
+func newHandoffTerminal(entry *terminalEntry, handles sessionHandles) handoffTerminal {
+	req := entry.request
+	req.Cols, req.Rows = entry.resizer.Size()
+	req.StartupTimeoutMs = 0
+	req.InitialCommand = ""
+	req.InitialCommandDelayMs = 0
+
+	terminal := handoffTerminal{
+		Request:      req,
+		Display:      entry.display,
+		Cwd:          entry.cwd,
+		Backend:      entry.backend,
+		ShellVersion: entry.shellVersion,
+		CodePage:     entry.codePage,
+		Opened:       entry.opened,
+		Session:      handles,
+	}
+	if entry.screen != nil {
+		terminal.Lines = newScrollbackCheckpoint(entry).Lines
+	}
+	return terminal
+}
+
+// flushOutputPumps waits until output queued for the client was written or
+// timeout elapsed.
+func flushOutputPumps(entries []*terminalEntry, timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for _, entry := range entries {
+		for entry.output.QueuedBytes() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// resumeDaemon takes a daemon over for the binary that started this one to
+// upgrade: it adopts the listener, terminals and client handed over on the
+// handoff pipe, and serves on once the old process committed.
+func resumeDaemon(cfg runConfig, stderr io.Writer) (exitCode int) {
+	conn, err := dialBrokerPipe(cfg.Handoff)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to connect to handoff pipe: %v\n", err)
+		return 1
+	}
+	handoff := newHandoffConn(conn)
+	defer conn.Close()
+
+	var state handoffState
+	if err := handoff.receive(&state, upgradeReplyTimeout); err != nil {
+		fmt.Fprintf(stderr, "failed to read handoff: %v\n", err)
+		return 1
+	}
+
+	cfg = withRuntimeDefaults(cfg)
+	s := newSidecar(cfg, io.Discard)
+	defer s.recoverMainLoop(&exitCode)
+	defer cfg.Debug.Track(s)()
+
+	starts, err := s.adoptTerminals(state.Terminals)
+	var listener daemonListener
+	if err == nil {
+		listener, err = adoptDaemonPipe(daemonPipePath(cfg.DaemonServe), state.Listener)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		_ = handoff.send(handoffReply{Error: err.Error()})
+		return 1
+	}
+	if err := handoff.send(handoffReply{Ready: true}); err != nil {
+		return 1
+	}
+	// Without a commit the old process kept the terminals; exiting only
+	// closes this process's duplicates of their handles.
+	var commit handoffCommit
+	if err := handoff.receive(&commit, upgradeReplyTimeout); err != nil || !commit.Commit {
+		fmt.Fprintln(stderr, "upgrade was not committed")
+		return 1
+	}
+
+	ids := make([]string, 0, len(state.Terminals))
+	for i, start := range starts {
+		start()
+		ids = append(ids, state.Terminals[i].Request.TerminalID)
+	}
+	client := os.NewFile(state.Client, "daemon-client")
+	s.daemon = &daemonState{listener: listener, client: client}
+	s.writer.Retarget(client)
+	s.emit(upgradeEvent{
+		Type:      eventTypeUpgrade,
+		RequestID: state.RequestID,
+		Version:   sidecarVersion,
+		PID:       os.Getpid(),
+		Terminals: ids,
+	})
+	return s.serveClients(client, stderr)
+}
+
+// adoptTerminals registers each handed over terminal and returns what
+// starts reading its output, deferred until the old process stopped.
+func (s *sidecar) adoptTerminals(terminals []handoffTerminal) ([]func(), error) {
+	starts := make([]func(), 0, len(terminals))
+	for _, terminal := range terminals {
+		var start func()
+		req := terminal.Request
+		req.adopted = &adoptedTerminal{
+			handoffTerminal: terminal,
+			opener: func(req openRequest, _ resolvedShell, callbacks terminalCallbacks, runIsolated func(string, func())) (terminalSession, error) {
+				session, run, err := adoptSession(terminal.Session, req, callbacks, runIsolated)
+				start = run
+				return session, err
+			},
+		}
+		if err := s.openTerminal(req); err != nil {
+			return nil, fmt.Errorf("failed to adopt terminal %s: %w", req.TerminalID, err)
+		}
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// runUpgrade implements "hapi-pty upgrade": it asks the daemon on a pipe to
+// hand over to the binary installed in place of its own. The daemon serves
+// one client at a time, so it waits while a host is connected.
+func runUpgrade(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("hapi-pty upgrade", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return exitCodeUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: hapi-pty upgrade PIPE")
+		return exitCodeUsage
+	}
+
+	conn, err := connectDaemonPipe(daemonPipePath(flags.Arg(0)), nil)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer conn.Close()
+	return requestUpgrade(conn, stdout, stderr)
+}
+
+// requestUpgrade sends an upgrade over conn and waits for its outcome,
+// printing the upgrade event.
+func requestUpgrade(conn io.ReadWriter, stdout io.Writer, stderr io.Writer) int {
+	const requestID = "upgrade"
+	line, err := json.Marshal(upgradeRequest{Type: requestTypeUpgrade, RequestID: requestID})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if _, err := conn.Write(append(line, '\n')); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			fmt.Fprintf(stderr, "daemon closed the connection: %v\n", err)
+			return 1
+		}
+		var evt struct {
+			Type      string `json:"type"`
+			RequestID string `json:"requestId"`
+			Message   string `json:"message"`
+		}
+		if json.Unmarshal(line, &evt) != nil || evt.RequestID != requestID {
+			continue
+		}
+		switch evt.Type {
+		case eventTypeUpgrade:
+			_, _ = stdout.Write(line)
+			return 0
+		case eventTypeError:
+			fmt.Fprintln(stderr, evt.Message)
+			return 1
+		}
+	}
+}
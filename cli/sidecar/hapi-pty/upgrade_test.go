@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithHandoffArgsReplacesEarlierHandoff(t *testing.T) {
+	args := []string{"--daemon-serve", "hapi", "--handoff", `\\.\pipe\old`, "-handoff=x", "--idle-timeout", "1m"}
+	got := withHandoffArgs(args, `\\.\pipe\new`)
+	want := []string{"--daemon-serve", "hapi", "--idle-timeout", "1m", "--handoff", `\\.\pipe\new`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestUpgradeRequiresDaemon(t *testing.T) {
+	ts := newTestSidecar(t, runConfig{})
+	if _, done := ts.handleRequest(upgradeRequest{Type: requestTypeUpgrade, RequestID: "u1"}); done {
+		t.Fatal("upgrade outside a daemon stopped the sidecar")
+	}
+	evt := waitForEventOfType(t, ts, eventTypeError)
+	if evt["requestId"] != "u1" || evt["code"] != errorCodeInvalidRequest {
+		t.Fatalf("expected invalid_request for u1, got %#v", evt)
+	}
+}
+
+func TestRequestUpgradeWaitsForItsOutcome(t *testing.T) {
+	client, daemon := net.Pipe()
+	t.Cleanup(func() { _ = client.Close() })
+	go func() {
+		reader := bufio.NewReader(daemon)
+		line, _ := reader.ReadBytes('\n')
+		var req upgradeRequest
+		_ = json.Unmarshal(line, &req)
+		_, _ = io.WriteString(daemon, `{"type":"output","terminalId":"t1","data":"x"}`+"\n")
+		_, _ = io.WriteString(daemon, `{"type":"upgrade","requestId":"`+req.RequestID+`","version":"2.0.0","pid":7,"terminals":["t1"]}`+"\n")
+	}()
+
+	var stdout, stderr strings.Builder
+	if code := requestUpgrade(client, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected success, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"pid":7`) {
+		t.Fatalf("expected the upgrade event on stdout, got %q", stdout.String())
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxPendingSequenceBytes bounds how much of an unterminated escape sequence is
+// held back waiting for its terminator before it is passed through verbatim.
+const maxPendingSequenceBytes = 1024 * 1024
+
+const (
+	oscConEmu    = "9"
+	oscClipboard = "52"
+)
+
+// progressStates maps the ConEmu/Windows Terminal OSC 9;4 state parameter.
+var progressStates = map[string]string{
+	"0": "none",
+	"1": "normal",
+	"2": "error",
+	"3": "indeterminate",
+	"4": "paused",
+}
+
+// vtInspector watches a terminal's output stream for escape sequences the
+// sidecar surfaces as structured events. Sequences split across reads are
+// held back until complete so they can be inspected (and suppressed) whole.
+type vtInspector struct {
+	terminalID        string
+	emit              func(payload any)
+	suppressClipboard bool
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func newVTInspector(req openRequest, emit func(payload any)) *vtInspector {
+	return &vtInspector{
+		terminalID:        req.TerminalID,
+		emit:              emit,
+		suppressClipboard: req.SuppressClipboard,
+	}
+}
+
+// Feed inspects a chunk and returns the bytes that should still be forwarded.
+// Chunks without escape sequences are returned as-is without copying.
+func (v *vtInspector) Feed(chunk []byte) []byte {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.pending) == 0 && bytes.IndexByte(chunk, 0x1b) < 0 {
+		return chunk
+	}
+
+	data := chunk
+	if len(v.pending) > 0 {
+		data = append(v.pending, chunk...)
+		v.pending = nil
+	}
+
+	out := make([]byte, 0, len(data))
+	for idx := 0; idx < len(data); {
+		start := bytes.IndexByte(data[idx:], 0x1b)
+		if start < 0 {
+			out = append(out, data[idx:]...)
+			break
+		}
+		start += idx
+		out = append(out, data[idx:start]...)
+
+		if start+1 >= len(data) {
+			v.pending = append([]byte(nil), data[start:]...)
+			break
+		}
+		if data[start+1] != ']' {
+			out = append(out, data[start])
+			idx = start + 1
+			continue
+		}
+
+		payloadEnd, end := findStringTerminator(data, start+2)
+		if end < 0 {
+			if len(data)-start > maxPendingSequenceBytes {
+				out = append(out, data[start:]...)
+			} else {
+				v.pending = append([]byte(nil), data[start:]...)
+			}
+			break
+		}
+
+		if !v.handleOSC(string(data[start+2 : payloadEnd])) {
+			out = append(out, data[start:end]...)
+		}
+		idx = end
+	}
+
+	return out
+}
+
+// Flush returns bytes still held back when the stream ends.
+func (v *vtInspector) Flush() []byte {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	pending := v.pending
+	v.pending = nil
+	return pending
+}
+
+// findStringTerminator locates BEL or ST after an OSC introducer, returning
+// the payload end and the index just past the terminator, or -1 if absent.
+func findStringTerminator(data []byte, from int) (int, int) {
+	for idx := from; idx < len(data); idx++ {
+		switch data[idx] {
+		case 0x07:
+			return idx, idx + 1
+		case 0x1b:
+			if idx+1 >= len(data) {
+				return -1, -1
+			}
+			if data[idx+1] == '\\' {
+				return idx, idx + 2
+			}
+		}
+	}
+	return -1, -1
+}
+
+// handleOSC dispatches a complete OSC payload and reports whether the raw
+// sequence should be removed from the forwarded output.
+func (v *vtInspector) handleOSC(payload string) bool {
+	command, rest, _ := strings.Cut(payload, ";")
+	switch command {
+	case oscClipboard:
+		return v.handleClipboard(rest)
+	case oscConEmu:
+		v.handleProgress(rest)
+		return false
+	default:
+		return false
+	}
+}
+
+func (v *vtInspector) handleClipboard(rest string) bool {
+	selection, encoded, ok := strings.Cut(rest, ";")
+	if !ok {
+		return false
+	}
+
+	// "?" asks the terminal to report the clipboard back; never surface or honour it.
+	if encoded == "?" {
+		return v.suppressClipboard
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+
+	if selection == "" {
+		selection = "c"
+	}
+	v.emit(clipboardEvent{
+		Type:       eventTypeClipboard,
+		TerminalID: v.terminalID,
+		Selection:  selection,
+		Text:       toValidUTF8(decoded),
+	})
+	return v.suppressClipboard
+}
+
+func (v *vtInspector) handleProgress(rest string) {
+	params := strings.Split(rest, ";")
+	if len(params) == 0 || params[0] != "4" {
+		return
+	}
+
+	stateParam := "0"
+	if len(params) > 1 && params[1] != "" {
+		stateParam = params[1]
+	}
+	state, ok := progressStates[stateParam]
+	if !ok {
+		return
+	}
+
+	percent := 0
+	if len(params) > 2 && params[2] != "" {
+		parsed, err := strconv.Atoi(params[2])
+		if err != nil {
+			return
+		}
+		percent = min(max(parsed, 0), 100)
+	}
+
+	v.emit(progressEvent{
+		Type:       eventTypeProgress,
+		TerminalID: v.terminalID,
+		State:      state,
+		Percent:    percent,
+	})
+}
+
+// stripANSI removes escape sequences and control characters other than tab,
+// newline and carriage return from a complete buffer of terminal output.
+func stripANSI(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for idx := 0; idx < len(data); idx++ {
+		b := data[idx]
+		switch {
+		case b == 0x1b:
+			idx = skipOutputEscape(data, idx)
+		case b == '\t' || b == '\n' || b == '\r':
+			out = append(out, b)
+		case b < 0x20 || b == 0x7f:
+		default:
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// skipOutputEscape returns the index of the last byte of the escape sequence
+// starting at data[start].
+func skipOutputEscape(data []byte, start int) int {
+	next := start + 1
+	if next >= len(data) {
+		return start
+	}
+
+	switch data[next] {
+	case '[':
+		for idx := next + 1; idx < len(data); idx++ {
+			if data[idx] >= 0x40 && data[idx] <= 0x7e {
+				return idx
+			}
+		}
+		return len(data) - 1
+	case ']', 'P', 'X', '^', '_':
+		if _, end := findStringTerminator(data, next+1); end > 0 {
+			return end - 1
+		}
+		return len(data) - 1
+	case '(', ')', '*', '+':
+		return min(next+1, len(data)-1)
+	default:
+		return next
+	}
+}
+
+// focusReportingMode is DECSET 1004: the program wants CSI I and CSI O
+// when the terminal gains and loses focus.
+const focusReportingMode = 1004
+
+// maxModeParamBytes bounds the parameters modeTracker collects for one CSI
+// sequence; longer sequences cannot be mode changes it tracks.
+const maxModeParamBytes = 64
+
+const (
+	modeStateGround = iota
+	modeStateEscape
+	modeStateCSI
+	modeStateIgnore
+)
+
+// alternateScreenModes switch to the alternate screen buffer; 1049 also
+// saves the cursor, which only matters to screenEmulator.
+var alternateScreenModes = []int{47, 1047, 1049}
+
+// modeTracker follows DECSET/DECRST (CSI ? Pm h / CSI ? Pm l) in a
+// terminal's output so input the sidecar generates on the host's behalf
+// matches what the program asked for. It keeps its parser state between
+// chunks rather than holding bytes back, so sequences may be split anywhere.
+// onAlternateScreen, when set, is called after a chunk that entered or left
+// the alternate screen.
+type modeTracker struct {
+	onAlternateScreen func(alternate bool)
+
+	mu        sync.Mutex
+	state     int
+	private   bool
+	params    []byte
+	modes     map[int]bool
+	alternate bool
+}
+
+func newModeTracker(onAlternateScreen func(alternate bool)) *modeTracker {
+	return &modeTracker{onAlternateScreen: onAlternateScreen, modes: map[int]bool{}}
+}
+
+func (m *modeTracker) Feed(chunk []byte) {
+	m.mu.Lock()
+	alternate := m.alternate
+	m.feedLocked(chunk)
+	changed := m.alternate != alternate
+	alternate = m.alternate
+	m.mu.Unlock()
+
+	if changed && m.onAlternateScreen != nil {
+		m.onAlternateScreen(alternate)
+	}
+}
+
+func (m *modeTracker) feedLocked(chunk []byte) {
+	for _, b := range chunk {
+		if b == 0x1b {
+			m.state = modeStateEscape
+			continue
+		}
+
+		switch m.state {
+		case modeStateEscape:
+			m.state = modeStateGround
+			if b == '[' {
+				m.state = modeStateCSI
+				m.private = false
+				m.params = m.params[:0]
+			}
+		case modeStateCSI, modeStateIgnore:
+			switch {
+			case b >= 0x40 && b <= 0x7e:
+				if m.state == modeStateCSI && m.private && (b == 'h' || b == 'l') {
+					for _, mode := range parseCSIParams(string(m.params)) {
+						m.modes[mode] = b == 'h'
+					}
+					m.alternate = m.alternateLocked()
+				}
+				m.state = modeStateGround
+			case m.state == modeStateIgnore:
+			case b == '?' && len(m.params) == 0 && !m.private:
+				m.private = true
+			case (b >= '0' && b <= '9' || b == ';') && len(m.params) < maxModeParamBytes:
+				m.params = append(m.params, b)
+			case b < 0x20:
+				// C0 controls execute inside a sequence without ending it.
+			default:
+				m.state = modeStateIgnore
+			}
+		}
+	}
+}
+
+func (m *modeTracker) alternateLocked() bool {
+	for _, mode := range alternateScreenModes {
+		if m.modes[mode] {
+			return true
+		}
+	}
+	return false
+}
+
+// AlternateScreen reports whether a full-screen program switched to the
+// alternate screen buffer and has not switched back.
+func (m *modeTracker) AlternateScreen() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.alternate
+}
+
+// Enabled reports whether the program last set mode rather than reset it.
+func (m *modeTracker) Enabled(mode int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.modes[mode]
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestVTInspectorEmitsClipboardEvent(t *testing.T) {
+	events := make([]any, 0)
+	inspector := newVTInspector(openRequest{TerminalID: "t1"}, func(payload any) {
+		events = append(events, payload)
+	})
+
+	input := "before\x1b]52;c;aGVsbG8=\x07after"
+	out := inspector.Feed([]byte(input))
+
+	if string(out) != input {
+		t.Fatalf("expected raw sequence to pass through, got %q", out)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected one clipboard event, got %d", len(events))
+	}
+	evt, ok := events[0].(clipboardEvent)
+	if !ok {
+		t.Fatalf("unexpected event type %T", events[0])
+	}
+	if evt.TerminalID != "t1" || evt.Selection != "c" || evt.Text != "hello" {
+		t.Fatalf("unexpected clipboard event: %+v", evt)
+	}
+}
+
+func TestVTInspectorSuppressesSplitClipboardSequence(t *testing.T) {
+	events := make([]any, 0)
+	inspector := newVTInspector(openRequest{TerminalID: "t1", SuppressClipboard: true}, func(payload any) {
+		events = append(events, payload)
+	})
+
+	first := inspector.Feed([]byte("vim\x1b"))
+	second := inspector.Feed([]byte("]52;;d29y"))
+	third := inspector.Feed([]byte("bGQ=\x1b\\done"))
+
+	if string(first) != "vim" || len(second) != 0 || string(third) != "done" {
+		t.Fatalf("unexpected forwarded output: %q %q %q", first, second, third)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected one clipboard event, got %d", len(events))
+	}
+	if evt := events[0].(clipboardEvent); evt.Text != "world" || evt.Selection != "c" {
+		t.Fatalf("unexpected clipboard event: %+v", evt)
+	}
+}
+
+func TestVTInspectorIgnoresClipboardQueries(t *testing.T) {
+	events := 0
+	inspector := newVTInspector(openRequest{TerminalID: "t1", SuppressClipboard: true}, func(any) {
+		events++
+	})
+
+	out := inspector.Feed([]byte("\x1b]52;c;?\x07"))
+	if len(out) != 0 || events != 0 {
+		t.Fatalf("expected query to be dropped silently, got %q and %d events", out, events)
+	}
+}
+
+func TestVTInspectorPassesThroughOtherSequences(t *testing.T) {
+	inspector := newVTInspector(openRequest{TerminalID: "t1"}, func(any) {
+		t.Fatal("unexpected event")
+	})
+
+	input := "\x1b[31mred\x1b[0m \x1b]0;title\x07"
+	if out := inspector.Feed([]byte(input)); string(out) != input {
+		t.Fatalf("unexpected forwarded output: %q", out)
+	}
+
+	if out := inspector.Feed([]byte("\x1b]0;unterminated")); len(out) != 0 {
+		t.Fatalf("expected unterminated OSC to be held back, got %q", out)
+	}
+	if rest := inspector.Flush(); string(rest) != "\x1b]0;unterminated" {
+		t.Fatalf("unexpected flushed bytes: %q", rest)
+	}
+}
+
+func TestVTInspectorEmitsProgressEvents(t *testing.T) {
+	events := make([]progressEvent, 0)
+	inspector := newVTInspector(openRequest{TerminalID: "t1"}, func(payload any) {
+		events = append(events, payload.(progressEvent))
+	})
+
+	input := "\x1b]9;4;1;42\x1b\\\x1b]9;4;3\x07\x1b]9;4;2;250\x07\x1b]9;4;0;0\x07\x1b]9;notify\x07"
+	if out := inspector.Feed([]byte(input)); string(out) != input {
+		t.Fatalf("expected progress sequences to pass through, got %q", out)
+	}
+
+	expected := []progressEvent{
+		{Type: eventTypeProgress, TerminalID: "t1", State: "normal", Percent: 42},
+		{Type: eventTypeProgress, TerminalID: "t1", State: "indeterminate", Percent: 0},
+		{Type: eventTypeProgress, TerminalID: "t1", State: "error", Percent: 100},
+		{Type: eventTypeProgress, TerminalID: "t1", State: "none", Percent: 0},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("unexpected progress events: %+v", events)
+	}
+	for idx := range expected {
+		if events[idx] != expected[idx] {
+			t.Fatalf("progress event %d mismatch: %+v", idx, events[idx])
+		}
+	}
+}
+
+func TestStripANSI(t *testing.T) {
+	input := "\x1b[1;32mgreen\x1b[0m\x1b]0;title\x07 text\x1b(B\r\n\x07bell"
+	if got := string(stripANSI([]byte(input))); got != "green text\r\nbell" {
+		t.Fatalf("unexpected stripped output: %q", got)
+	}
+}
+
+func TestVTInspectorPassesPlainChunksThrough(t *testing.T) {
+	inspector := newVTInspector(openRequest{TerminalID: "t1"}, func(any) {
+		t.Fatal("unexpected event for plain output")
+	})
+
+	chunk := []byte("plain output\r\n")
+	forwarded := inspector.Feed(chunk)
+	if &forwarded[0] != &chunk[0] {
+		t.Fatal("expected plain chunk to be forwarded without copying")
+	}
+}
+
+func TestModeTrackerFollowsSplitPrivateModes(t *testing.T) {
+	tracker := newModeTracker(nil)
+	tracker.Feed([]byte("vim\x1b[?10"))
+	if tracker.Enabled(focusReportingMode) {
+		t.Fatal("mode enabled before the sequence completed")
+	}
+	tracker.Feed([]byte("04;2004h"))
+	if !tracker.Enabled(focusReportingMode) || !tracker.Enabled(2004) {
+		t.Fatal("expected modes 1004 and 2004 to be enabled")
+	}
+
+	tracker.Feed([]byte("\x1b[1004l\x1b[>1004l"))
+	if !tracker.Enabled(focusReportingMode) {
+		t.Fatal("non-private sequences must not change private modes")
+	}
+	tracker.Feed([]byte("\x1b[?1004l"))
+	if tracker.Enabled(focusReportingMode) {
+		t.Fatal("expected mode 1004 to be reset")
+	}
+}
+
+func TestModeTrackerReportsAlternateScreenChanges(t *testing.T) {
+	var changes []bool
+	tracker := newModeTracker(func(alternate bool) { changes = append(changes, alternate) })
+
+	tracker.Feed([]byte("\x1b[?1049h\x1b[?1047h"))
+	tracker.Feed([]byte("\x1b[?1047l"))
+	if !tracker.AlternateScreen() {
+		t.Fatal("expected the alternate screen while 1049 is still set")
+	}
+	tracker.Feed([]byte("\x1b[?1049l"))
+	if tracker.AlternateScreen() {
+		t.Fatal("expected the main screen")
+	}
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Fatalf("expected one switch each way, got %v", changes)
+	}
+}
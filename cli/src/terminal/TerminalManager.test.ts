@@ -1,5 +1,5 @@
 import { describe, expect, it, vi } from 'vitest'
-import type { TerminalErrorPayload } from '@hapi/protocol'
+import type { TerminalErrorPayload, TerminalExitPayload } from '@hapi/protocol'
 import type { TerminalBackend, TerminalBackendCreateOptions, TerminalBackendError } from './backend'
 import { TerminalManager } from './TerminalManager'
 
@@ -12,7 +12,7 @@ class FakeBackend implements TerminalBackend {
 
     private readyHandler: (terminalId: string) => void = () => { }
     private outputHandler: (terminalId: string, data: string) => void = () => { }
-    private exitHandler: (terminalId: string, code: number | null, signal: string | null) => void = () => { }
+    private exitHandler: (terminalId: string, code: number | null, signal: string | null, status: string | null) => void = () => { }
     private errorHandler: (terminalId: string, error: TerminalBackendError) => void = () => { }
 
     create(options: TerminalBackendCreateOptions): void {
@@ -43,7 +43,7 @@ class FakeBackend implements TerminalBackend {
         this.outputHandler = callback
     }
 
-    onExit(callback: (terminalId: string, code: number | null, signal: string | null) => void): void {
+    onExit(callback: (terminalId: string, code: number | null, signal: string | null, status: string | null) => void): void {
         this.exitHandler = callback
     }
 
@@ -59,8 +59,8 @@ class FakeBackend implements TerminalBackend {
         this.outputHandler(terminalId, data)
     }
 
-    emitExit(terminalId: string, code: number | null, signal: string | null): void {
-        this.exitHandler(terminalId, code, signal)
+    emitExit(terminalId: string, code: number | null, signal: string | null, status: string | null = null): void {
+        this.exitHandler(terminalId, code, signal, status)
     }
 
     emitError(terminalId: string, error: TerminalBackendError): void {
@@ -74,6 +74,7 @@ function createManager(options: {
     platform?: NodeJS.Platform
     maxTerminals?: number
     idleTimeoutMs?: number
+    onExit?: (payload: TerminalExitPayload) => void
     onError?: (payload: TerminalErrorPayload) => void
 } = {}): TerminalManager {
     return new TerminalManager({
@@ -81,7 +82,7 @@ function createManager(options: {
         getSessionPath: () => process.cwd(),
         onReady: () => { },
         onOutput: () => { },
-        onExit: () => { },
+        onExit: options.onExit ?? (() => { }),
         onError: options.onError ?? (() => { }),
         backend: options.backend,
         backendFactory: options.backendFactory,
@@ -129,6 +130,28 @@ describe('TerminalManager', () => {
         ])
     })
 
+    it('forwards the Windows exit status separately from the signal', () => {
+        const backend = new FakeBackend()
+        const exits: TerminalExitPayload[] = []
+        const manager = createManager({
+            backend,
+            onExit: (payload) => exits.push(payload)
+        })
+
+        manager.create('terminal-1', 80, 24)
+        backend.emitExit('terminal-1', -1073741819, null, 'STATUS_ACCESS_VIOLATION')
+
+        expect(exits).toEqual([
+            {
+                sessionId: 'session-1',
+                terminalId: 'terminal-1',
+                code: -1073741819,
+                signal: null,
+                status: 'STATUS_ACCESS_VIOLATION'
+            }
+        ])
+    })
+
     it('emits structured manager-side errors', () => {
         const backend = new FakeBackend()
         const errors: TerminalErrorPayload[] = []
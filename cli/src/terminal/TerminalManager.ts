@@ -252,8 +252,8 @@ export class TerminalManager {
             this.onOutput({ sessionId: this.sessionId, terminalId, data })
         })
 
-        this.backend.onExit((terminalId, code, signal) => {
-            this.onExit({ sessionId: this.sessionId, terminalId, code, signal })
+        this.backend.onExit((terminalId, code, signal, status) => {
+            this.onExit({ sessionId: this.sessionId, terminalId, code, signal, status })
             this.cleanup(terminalId, false)
         })
 
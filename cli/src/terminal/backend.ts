@@ -24,6 +24,6 @@ export interface TerminalBackend {
 
     onReady(callback: (terminalId: string) => void): void
     onOutput(callback: (terminalId: string, data: string) => void): void
-    onExit(callback: (terminalId: string, code: number | null, signal: string | null) => void): void
+    onExit(callback: (terminalId: string, code: number | null, signal: string | null, status: string | null) => void): void
     onError(callback: (terminalId: string, error: TerminalBackendError) => void): void
 }
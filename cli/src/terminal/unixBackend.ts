@@ -9,7 +9,7 @@ type UnixRuntime = {
 type UnixBackendEvents = {
     onReady: (terminalId: string) => void
     onOutput: (terminalId: string, data: string) => void
-    onExit: (terminalId: string, code: number | null, signal: string | null) => void
+    onExit: (terminalId: string, code: number | null, signal: string | null, status: string | null) => void
     onError: (terminalId: string, error: TerminalBackendError) => void
 }
 
@@ -69,7 +69,7 @@ export class UnixBackend implements TerminalBackend {
                 onExit: (subprocess, exitCode) => {
                     this.terminals.delete(options.terminalId)
                     const signal = subprocess.signalCode ?? null
-                    this.events.onExit(options.terminalId, exitCode ?? null, signal)
+                    this.events.onExit(options.terminalId, exitCode ?? null, signal, null)
                 }
             })
 
@@ -147,7 +147,7 @@ export class UnixBackend implements TerminalBackend {
         this.events.onOutput = callback
     }
 
-    onExit(callback: (terminalId: string, code: number | null, signal: string | null) => void): void {
+    onExit(callback: (terminalId: string, code: number | null, signal: string | null, status: string | null) => void): void {
         this.events.onExit = callback
     }
 
@@ -123,7 +123,7 @@ describe('WindowsBackend', () => {
             rows: 24
         })
 
-        emitSidecarEvent(process, { type: 'hello', version: '1.0.0', protocol: 1 })
+        emitSidecarEvent(process, { type: 'hello', version: '1.0.0', protocol: 2 })
         await flushMicrotasks()
 
         const openMessage = stdinLines()
@@ -138,6 +138,32 @@ describe('WindowsBackend', () => {
         expect(errors).toHaveLength(0)
     })
 
+    it('reports the NTSTATUS name as the exit status rather than a signal', async () => {
+        const process = new FakeSidecarProcess()
+        const { backend } = createBackendWithFakeProcess({ process })
+        const exits: Array<{ terminalId: string; code: number | null; signal: string | null; status: string | null }> = []
+        backend.onExit((terminalId, code, signal, status) => exits.push({ terminalId, code, signal, status }))
+
+        backend.create({
+            terminalId: 'term-1',
+            cwd: 'C:/repo',
+            env: {},
+            cols: 80,
+            rows: 24
+        })
+
+        emitSidecarEvent(process, { type: 'hello', version: '1.0.0', protocol: 2 })
+        emitSidecarEvent(process, { type: 'ready', terminalId: 'term-1', displayName: 'pwsh' })
+        emitSidecarEvent(process, { type: 'exit', terminalId: 'term-1', code: -1073741819, status: 'STATUS_ACCESS_VIOLATION' })
+        emitSidecarEvent(process, { type: 'exit', terminalId: 'term-2', code: 0 })
+        await flushMicrotasks()
+
+        expect(exits).toEqual([
+            { terminalId: 'term-1', code: -1073741819, signal: null, status: 'STATUS_ACCESS_VIOLATION' },
+            { terminalId: 'term-2', code: 0, signal: null, status: null }
+        ])
+    })
+
     it('propagates sidecar_protocol_mismatch when hello protocol differs', async () => {
         const process = new FakeSidecarProcess()
         const { backend } = createBackendWithFakeProcess({ process })
@@ -217,7 +243,7 @@ describe('WindowsBackend', () => {
             rows: 24
         })
 
-        emitSidecarEvent(process, { type: 'hello', version: '1.0.0', protocol: 1 })
+        emitSidecarEvent(process, { type: 'hello', version: '1.0.0', protocol: 2 })
         await flushMicrotasks()
 
         await vi.advanceTimersByTimeAsync(35)
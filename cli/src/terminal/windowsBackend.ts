@@ -11,7 +11,7 @@ const MODULE_DIR = dirname(fileURLToPath(import.meta.url))
 
 const HAPI_PTY_PATH_ENV = 'HAPI_PTY_PATH'
 const SIDECAR_BINARY_NAME = 'hapi-pty.exe'
-const SIDECAR_PROTOCOL_VERSION = 1
+const SIDECAR_PROTOCOL_VERSION = 2
 
 const DEFAULT_HELLO_TIMEOUT_MS = 5_000
 const DEFAULT_HEARTBEAT_INTERVAL_MS = 30_000
@@ -70,6 +70,8 @@ type SidecarEvent = {
     type: 'exit'
     terminalId: string
     code: number
+    reason?: string
+    status?: string
 } | {
     type: 'error'
     terminalId?: string
@@ -84,7 +86,7 @@ type SidecarEvent = {
 type WindowsBackendEvents = {
     onReady: (terminalId: string) => void
     onOutput: (terminalId: string, data: string) => void
-    onExit: (terminalId: string, code: number | null, signal: string | null) => void
+    onExit: (terminalId: string, code: number | null, signal: string | null, status: string | null) => void
     onError: (terminalId: string, error: TerminalBackendError) => void
 }
 
@@ -266,7 +268,7 @@ export class WindowsBackend implements TerminalBackend {
         this.events.onOutput = callback
     }
 
-    onExit(callback: (terminalId: string, code: number | null, signal: string | null) => void): void {
+    onExit(callback: (terminalId: string, code: number | null, signal: string | null, status: string | null) => void): void {
         this.events.onExit = callback
     }
 
@@ -384,7 +386,7 @@ export class WindowsBackend implements TerminalBackend {
             case 'exit':
                 this.pendingReadyTerminalIds.delete(event.terminalId)
                 this.terminalIds.delete(event.terminalId)
-                this.events.onExit(event.terminalId, event.code, null)
+                this.events.onExit(event.terminalId, event.code, null, event.status ?? null)
                 return
             case 'error': {
                 const code = normalizeTerminalErrorCode(event.code)
@@ -99,7 +99,8 @@ export const TerminalExitPayloadSchema = z.object({
     sessionId: z.string().min(1),
     terminalId: z.string().min(1),
     code: z.number().int().nullable(),
-    signal: z.string().nullable()
+    signal: z.string().nullable(),
+    status: z.string().nullable().optional()
 })
 
 export type TerminalExitPayload = z.infer<typeof TerminalExitPayloadSchema>